@@ -0,0 +1,300 @@
+// Package admin provides the Telegram bot commands to manage the app at runtime from the admin chat.
+package admin
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/jobs"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/scheduler"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// controller pauses the jobs and keeps their last runs (see jobs.Control).
+type controller interface {
+	Pause()
+	Resume()
+	Paused() bool
+	LastRun(job string) (jobs.RunInfo, bool)
+	LastRuns() map[string]jobs.RunInfo
+}
+
+// reposter publishes the saved news again, corrects or retracts the published news by its hash
+// and re-drives the failed publications (see jobs.Job.Repost, jobs.Job.Correct, jobs.Job.Retract and jobs.Job.Redrive).
+type reposter interface {
+	Repost(ctx context.Context, hash string) (string, error)
+	Correct(ctx context.Context, hash, text string) error
+	Retract(ctx context.Context, hash string) error
+	DeadLetters(ctx context.Context, limit int) ([]*archivist.DeadLetter, error)
+	Redrive(ctx context.Context, hash string) (string, error)
+}
+
+// schedule lists the scheduled jobs with the metadata of their runs (see scheduler.Scheduler).
+type schedule interface {
+	Jobs() []scheduler.JobInfo
+}
+
+// deadLettersLimit is the number of the latest dead letters listed by /deadletters.
+const deadLettersLimit = 20
+
+// Bot handles the admin commands sent to the bot in the admin chat. It uses its own connection to the Bot API,
+// separate from the publisher. Commands from other chats are ignored.
+//
+// Commands:
+//   - /pause - pause the news jobs
+//   - /resume - resume the news jobs
+//   - /status - show if the jobs are paused, their last runs and the next runs of the scheduled jobs
+//   - /lastrun <job> - show the last run of the job (e.g. /lastrun MarketNews)
+//   - /repost <hash> - publish the saved news again
+//   - /correct <hash> <text> - replace the text of the published news
+//   - /retract <hash> - delete the published news from the channel
+//   - /deadletters - list the latest news failed to be published
+//   - /redrive <hash> - publish the failed news again
+type Bot struct {
+	api      *tgbotapi.BotAPI
+	chatID   int64 // admin chat ID
+	control  controller
+	reposter reposter
+	schedule schedule // optional
+	logger   *slog.Logger
+}
+
+// NewBot creates a new Bot. chatID must be the numeric ID of the admin chat.
+func NewBot(token, chatID string, control controller, reposter reposter) (*Bot, error) {
+	id, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("admin chat ID must be numeric: %w", err), errlvl.ERROR)
+	}
+
+	api, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to create Telegram bot: %w", err), errlvl.ERROR)
+	}
+
+	return &Bot{
+		api:      api,
+		chatID:   id,
+		control:  control,
+		reposter: reposter,
+		logger:   logging.For(logging.ModuleAdmin),
+	}, nil
+}
+
+// WithScheduler sets the scheduler of the jobs, /status lists their schedules, next runs and last durations.
+func (b *Bot) WithScheduler(s schedule) *Bot {
+	b.schedule = s
+	return b
+}
+
+// Start starts receiving the commands in the background until the context is done.
+func (b *Bot) Start(ctx context.Context) error {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	updates, err := b.api.GetUpdatesChan(u)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to get updates: %w", err), errlvl.ERROR)
+	}
+
+	go func() {
+		defer b.api.StopReceivingUpdates()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update := <-updates:
+				b.handleUpdate(ctx, update)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// handleUpdate executes the command from the admin chat and replies with its result.
+func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	msg := update.Message
+	if msg == nil || msg.Chat == nil || msg.Chat.ID != b.chatID || !msg.IsCommand() {
+		return
+	}
+
+	reply := b.handle(ctx, msg.Command(), strings.TrimSpace(msg.CommandArguments()))
+
+	m := tgbotapi.NewMessage(b.chatID, reply)
+	m.ReplyToMessageID = msg.MessageID
+	if _, err := b.api.Send(m); err != nil {
+		b.logger.Warn("[admin] Error sending reply", "command", msg.Command(), "error", err)
+	}
+}
+
+// handle executes the command and returns the reply text.
+func (b *Bot) handle(ctx context.Context, command, args string) string {
+	switch command {
+	case "pause":
+		b.control.Pause()
+		return "⏸ News jobs are paused"
+	case "resume":
+		b.control.Resume()
+		return "▶️ News jobs are resumed"
+	case "status":
+		return b.status()
+	case "lastrun":
+		if args == "" {
+			return "Usage: /lastrun <job>"
+		}
+		run, ok := b.control.LastRun(args)
+		if !ok {
+			return fmt.Sprintf("No runs of %s yet", args)
+		}
+		return formatRun(args, run)
+	case "repost":
+		if args == "" {
+			return "Usage: /repost <hash>"
+		}
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		id, err := b.reposter.Repost(ctx, args)
+		if err != nil {
+			return fmt.Sprintf("Error reposting %s: %s", args, err)
+		}
+		return fmt.Sprintf("Reposted %s, publication ID: %s", args, id)
+	case "correct":
+		hash, text, _ := strings.Cut(args, " ")
+		if text = strings.TrimSpace(text); hash == "" || text == "" {
+			return "Usage: /correct <hash> <text>"
+		}
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if err := b.reposter.Correct(ctx, hash, text); err != nil {
+			return fmt.Sprintf("Error correcting %s: %s", hash, err)
+		}
+		return fmt.Sprintf("Corrected %s", hash)
+	case "retract":
+		if args == "" {
+			return "Usage: /retract <hash>"
+		}
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if err := b.reposter.Retract(ctx, args); err != nil {
+			return fmt.Sprintf("Error retracting %s: %s", args, err)
+		}
+		return fmt.Sprintf("Retracted %s", args)
+	case "deadletters":
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		letters, err := b.reposter.DeadLetters(ctx, deadLettersLimit)
+		if err != nil {
+			return fmt.Sprintf("Error listing dead letters: %s", err)
+		}
+		return formatDeadLetters(letters)
+	case "redrive":
+		if args == "" {
+			return "Usage: /redrive <hash>"
+		}
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		id, err := b.reposter.Redrive(ctx, args)
+		if err != nil {
+			return fmt.Sprintf("Error re-driving %s: %s", args, err)
+		}
+		return fmt.Sprintf("Re-driven %s, publication ID: %s", args, id)
+	default:
+		return "Unknown command. Available: /pause, /resume, /status, /lastrun <job>, /repost <hash>, " +
+			"/correct <hash> <text>, /retract <hash>, /deadletters, /redrive <hash>"
+	}
+}
+
+// status returns the paused state, the last runs of all jobs and the next runs of the scheduled jobs.
+func (b *Bot) status() string {
+	var sb strings.Builder
+	if b.control.Paused() {
+		sb.WriteString("Status: paused ⏸")
+	} else {
+		sb.WriteString("Status: running ▶️")
+	}
+
+	runs := b.control.LastRuns()
+	names := make([]string, 0, len(runs))
+	for name := range runs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		sb.WriteString("\n")
+		sb.WriteString(formatRun(name, runs[name]))
+	}
+
+	if b.schedule != nil {
+		sb.WriteString("\n\nScheduled jobs:")
+		for _, job := range b.schedule.Jobs() {
+			sb.WriteString("\n")
+			sb.WriteString(formatScheduled(job))
+		}
+	}
+
+	return sb.String()
+}
+
+// formatScheduled formats the schedule, next run and last duration of the scheduled job in one line.
+func formatScheduled(job scheduler.JobInfo) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s (%s): ", job.Name, job.Schedule))
+	if job.NextRun.IsZero() {
+		sb.WriteString("no next run")
+	} else {
+		sb.WriteString(fmt.Sprintf("next in %s", time.Until(job.NextRun).Truncate(time.Second)))
+	}
+	if job.Runs > 0 {
+		sb.WriteString(fmt.Sprintf(", last took %s", job.LastDuration.Truncate(time.Millisecond)))
+	}
+	if job.Running {
+		sb.WriteString(", running")
+	}
+	if job.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf(", skipped %d overlapping runs", job.Skipped))
+	}
+	return sb.String()
+}
+
+// formatRun formats the job run in one line.
+func formatRun(job string, run jobs.RunInfo) string {
+	ago := time.Since(run.StartedAt).Truncate(time.Second)
+	if run.Paused {
+		return fmt.Sprintf("%s: skipped (paused) %s ago", job, ago)
+	}
+	return fmt.Sprintf("%s: %s ago, took %s, fetched %d, published %d",
+		job, ago, run.Duration.Truncate(time.Millisecond), run.Fetched, run.Published)
+}
+
+// formatDeadLetters formats the dead letters one per line with the number of attempts and the last error.
+func formatDeadLetters(letters []*archivist.DeadLetter) string {
+	if len(letters) == 0 {
+		return "No dead letters"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Dead letters (%d):", len(letters)))
+	for _, l := range letters {
+		ago := time.Since(l.LastFailedAt).Truncate(time.Second)
+		sb.WriteString(fmt.Sprintf("\n%s (%s) to %s: %d attempts, last %s ago: %s",
+			l.Hash, l.JobName, l.ChatID, l.Attempts, ago, truncate(l.Error, 200)))
+	}
+	return sb.String()
+}
+
+// truncate shortens the text to the max number of runes with the ellipsis.
+func truncate(s string, maxRunes int) string {
+	r := []rune(s)
+	if len(r) <= maxRunes {
+		return s
+	}
+	return string(r[:maxRunes-1]) + "…"
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/jobs"
+	"github.com/samgozman/fin-thread/pkg/scheduler"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeReposter struct {
+	err error
+}
+
+func (f *fakeReposter) Repost(context.Context, string) (string, error) {
+	return "42", f.err
+}
+
+func (f *fakeReposter) Correct(context.Context, string, string) error {
+	return f.err
+}
+
+func (f *fakeReposter) Retract(context.Context, string) error {
+	return f.err
+}
+
+func (f *fakeReposter) DeadLetters(context.Context, int) ([]*archivist.DeadLetter, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []*archivist.DeadLetter{{Hash: "abc", JobName: "MarketNews", ChatID: "@channel", Error: "Bad Request", Attempts: 3, LastFailedAt: time.Now()}}, nil
+}
+
+func (f *fakeReposter) Redrive(context.Context, string) (string, error) {
+	return "43", f.err
+}
+
+func TestBot_handle(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		args       string
+		repostErr  error
+		wantReply  string
+		wantPaused bool
+	}{
+		{
+			name:       "pause",
+			command:    "pause",
+			wantReply:  "paused",
+			wantPaused: true,
+		},
+		{
+			name:      "resume",
+			command:   "resume",
+			wantReply: "resumed",
+		},
+		{
+			name:      "status",
+			command:   "status",
+			wantReply: "MarketNews: ",
+		},
+		{
+			name:      "status of scheduled jobs",
+			command:   "status",
+			wantReply: "MarketNews (every 1m0s): next in 4", // 45s minus the test time
+		},
+		{
+			name:      "status of overlapping runs",
+			command:   "status",
+			wantReply: "Calendar (0 4 * * 1-5): no next run, last took 2.5s, running, skipped 3 overlapping runs",
+		},
+		{
+			name:      "last run",
+			command:   "lastrun",
+			args:      "MarketNews",
+			wantReply: "fetched 5, published 2",
+		},
+		{
+			name:      "last run of unknown job",
+			command:   "lastrun",
+			args:      "Unknown",
+			wantReply: "No runs of Unknown yet",
+		},
+		{
+			name:      "last run without job",
+			command:   "lastrun",
+			wantReply: "Usage: /lastrun",
+		},
+		{
+			name:      "repost",
+			command:   "repost",
+			args:      "abc",
+			wantReply: "Reposted abc, publication ID: 42",
+		},
+		{
+			name:      "repost error",
+			command:   "repost",
+			args:      "abc",
+			repostErr: errors.New("news abc not found"),
+			wantReply: "Error reposting abc: news abc not found",
+		},
+		{
+			name:      "correct",
+			command:   "correct",
+			args:      "abc Fed cuts rates by 25bp",
+			wantReply: "Corrected abc",
+		},
+		{
+			name:      "correct without text",
+			command:   "correct",
+			args:      "abc",
+			wantReply: "Usage: /correct",
+		},
+		{
+			name:      "retract",
+			command:   "retract",
+			args:      "abc",
+			wantReply: "Retracted abc",
+		},
+		{
+			name:      "retract error",
+			command:   "retract",
+			args:      "abc",
+			repostErr: errors.New("news abc is not published"),
+			wantReply: "Error retracting abc: news abc is not published",
+		},
+		{
+			name:      "dead letters",
+			command:   "deadletters",
+			wantReply: "abc (MarketNews) to @channel: 3 attempts, last 0s ago: Bad Request",
+		},
+		{
+			name:      "redrive",
+			command:   "redrive",
+			args:      "abc",
+			wantReply: "Re-driven abc, publication ID: 43",
+		},
+		{
+			name:      "redrive error",
+			command:   "redrive",
+			args:      "abc",
+			repostErr: errors.New("Forbidden: bot is not a member of the channel chat"),
+			wantReply: "Error re-driving abc: Forbidden",
+		},
+		{
+			name:      "redrive without hash",
+			command:   "redrive",
+			wantReply: "Usage: /redrive",
+		},
+		{
+			name:      "unknown command",
+			command:   "start",
+			wantReply: "Unknown command",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			control := jobs.NewControl()
+			b := &Bot{
+				control:  &fakeController{Control: control, runs: map[string]jobs.RunInfo{"MarketNews": {StartedAt: time.Now(), Fetched: 5, Published: 2}}},
+				reposter: &fakeReposter{err: tt.repostErr},
+				schedule: fakeSchedule{
+					{Name: "Calendar", Schedule: "0 4 * * 1-5", Runs: 1, LastDuration: 2500 * time.Millisecond, Running: true, Skipped: 3},
+					{Name: "MarketNews", Schedule: "every 1m0s", NextRun: time.Now().Add(45 * time.Second)},
+				},
+			}
+
+			reply := b.handle(context.Background(), tt.command, tt.args)
+			if !strings.Contains(reply, tt.wantReply) {
+				t.Errorf("handle() = %q, want to contain %q", reply, tt.wantReply)
+			}
+			if control.Paused() != tt.wantPaused {
+				t.Errorf("Paused() = %v, want %v", control.Paused(), tt.wantPaused)
+			}
+		})
+	}
+}
+
+// fakeController overrides the last runs of the jobs.Control.
+type fakeController struct {
+	*jobs.Control
+	runs map[string]jobs.RunInfo
+}
+
+func (f *fakeController) LastRun(job string) (jobs.RunInfo, bool) {
+	run, ok := f.runs[job]
+	return run, ok
+}
+
+func (f *fakeController) LastRuns() map[string]jobs.RunInfo {
+	return f.runs
+}
+
+// fakeSchedule returns the scheduled jobs as is.
+type fakeSchedule []scheduler.JobInfo
+
+func (f fakeSchedule) Jobs() []scheduler.JobInfo {
+	return f
+}
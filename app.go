@@ -6,15 +6,28 @@ import (
 	"github.com/avast/retry-go"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-co-op/gocron/v2"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/admin"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/composer"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/jobs"
 	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/heartbeat"
+	"github.com/samgozman/fin-thread/pkg/leader"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/pkg/scheduler"
+	"github.com/samgozman/fin-thread/pkg/storage"
 	"github.com/samgozman/fin-thread/publisher"
 	"github.com/samgozman/fin-thread/scavenger"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
-	"log/slog"
+	"github.com/samgozman/fin-thread/server"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -23,31 +36,148 @@ type App struct {
 }
 
 func (a *App) start() {
-	telegramPublisher, err := publisher.NewTelegramPublisher(
-		a.cnf.env.TelegramChannelID,
-		a.cnf.env.TelegramBotToken,
-		a.cnf.env.ShouldPublish,
-	)
+	var emitters metrics.Multi
+	if a.cnf.env.StatsdAddr != "" {
+		statsd, err := metrics.NewStatsD(a.cnf.env.StatsdAddr, "finthread", metrics.T("server", a.cnf.env.ServerName))
+		if err != nil {
+			logger.Error("[main] Error creating StatsD emitter", "error", err)
+			panic(err)
+		}
+		defer statsd.Close()
+		emitters = append(emitters, statsd)
+	}
+	// Prometheus metrics are exposed by the HTTP server on /metrics
+	var prometheus *metrics.Prometheus
+	if a.cnf.env.PrometheusEnabled {
+		prometheus = metrics.NewPrometheus("finthread", metrics.T("server", a.cnf.env.ServerName))
+		emitters = append(emitters, prometheus)
+	}
+	var metricsEmitter metrics.Emitter = metrics.Noop{}
+	if len(emitters) > 0 {
+		metricsEmitter = emitters
+	}
+
+	telegramPublisher, err := a.newTelegramPublisher(metricsEmitter)
 	if err != nil {
-		slog.Default().Error("[main] Error creating Telegram telegramPublisher:", err)
+		logger.Error("[main] Error creating Telegram telegramPublisher", "error", err)
 		panic(err)
 	}
 
 	archivistEntity, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
 	if err != nil {
-		slog.Default().Error("[main] Error creating Archivist:", err)
+		logger.Error("[main] Error creating Archivist", "error", err)
 		panic(err)
 	}
+	archivistEntity.WithMetrics(metricsEmitter)
+
+	// Migrate the schema automatically on every start
+	err = archivistEntity.Migrate()
+	if err != nil {
+		logger.Error("[main] Error migrating database", "error", err)
+		panic(err)
+	}
+	if a.cnf.env.SimilarityDedupEnabled {
+		err = archivistEntity.MigrateEmbeddings()
+		if err != nil {
+			logger.Error("[main] Error migrating news embeddings", "error", err)
+			panic(err)
+		}
+	}
+
+	// Use suspicious keywords from the database if they were seeded or edited by the operator
+	err = a.loadSuspiciousKeywords(archivistEntity)
+	if err != nil {
+		logger.Warn("[main] Error loading suspicious keywords, using defaults", "error", err)
+	}
 
-	composerEntity := composer.NewComposer(a.cnf.env.OpenAiToken, a.cnf.env.TogetherAIToken, a.cnf.env.GoogleGeminiToken)
+	// News and dead letters of the threads are skipped by the default pipeline, its reports, digests and feeds
+	defaultArchivist := archivistEntity.Scoped(archivist.Scope{Exclude: a.cnf.threadChatIDs()})
 
-	marketJournalist := journalist.NewJournalist("MarketNews", a.cnf.rssProviders.marketJournalists).
-		FlagByKeys(a.cnf.suspiciousKeywords).
-		Limit(2)
+	// Pauses the news jobs and records their runs for the admin commands and the readiness probe
+	control := jobs.NewControl()
+
+	// Only the leader replica runs the jobs and accepts the pushed news if leader election is enabled
+	var elector *leader.LeaseElector
+	if a.cnf.env.LeaderElection != "" {
+		elector, err = a.newLeaseElector()
+		if err != nil {
+			logger.Error("[main] Error creating leader elector", "error", err)
+			panic(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		released := make(chan struct{})
+		go func() {
+			elector.Run(ctx)
+			close(released)
+		}()
+		// The lease is released after the scheduler is stopped, so the next leader doesn't run the jobs twice
+		defer func() {
+			cancel()
+			<-released
+		}()
+	}
 
-	broadNews := journalist.NewJournalist("BroadNews", a.cnf.rssProviders.broadJournalists).
-		FlagByKeys(a.cnf.suspiciousKeywords).
-		Limit(1)
+	// HTTP API with stats for the operators and health probes
+	if a.cnf.env.HTTPAddr != "" {
+		srv := server.NewServer(a.cnf.env.HTTPAddr, defaultArchivist)
+		if a.cnf.env.PodcastBaseURL != "" {
+			srv.WithPodcast(a.cnf.env.PodcastBaseURL)
+		}
+		if a.cnf.env.FeedBaseURL != "" {
+			srv.WithFeed(publisher.NewFeed(a.cnf.env.FeedBaseURL), a.cnf.env.FeedItems)
+		}
+		srv.WithHealth([]server.HealthCheck{
+			{Name: "database", Check: archivistEntity.Ping},
+			{Name: "telegram", Check: func(context.Context) error { return telegramPublisher.Ping() }},
+		}, control, time.Duration(a.cnf.env.ReadyMaxJobAge)*time.Second)
+		if prometheus != nil {
+			srv.WithMetrics(prometheus.Handler())
+		}
+		if elector != nil {
+			srv.WithLeader(elector)
+		}
+		srv.Start()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(ctx)
+		}()
+	}
+
+	// Collects token usage of the LLM requests and guards the monthly budget
+	usageJob := jobs.NewLLMUsageJob(archivistEntity).WithMonthlyBudget(a.cnf.env.LLMMonthlyBudget)
+	// The saved spend is loaded before the news jobs start, so the exceeded budget is applied to their first runs
+	if a.cnf.env.LLMMonthlyBudget > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := usageJob.LoadSpend(ctx)
+		cancel()
+		if err != nil {
+			logger.Warn("[main] Error loading LLM spend, the budget is checked after the first usage job run", "error", err)
+			sentry.CaptureException(err)
+		}
+	}
+
+	composerEntity := a.newComposer(metricsEmitter, usageJob)
+	var templates *composer.PromptTemplates
+	if a.cnf.env.PromptsDir != "" || len(a.cnf.promptFiles) > 0 {
+		templates = composer.NewPromptTemplates(a.cnf.env.PromptsDir, a.cnf.promptFiles)
+		if err := templates.Load(); err != nil {
+			logger.Error("[main] Error loading prompt templates", "error", err)
+			panic(err)
+		}
+		logger.Info("[main] Loaded prompt templates", "stages", templates.Stages())
+		composerEntity.WithPromptTemplates(templates)
+		go reloadPromptsOnSIGHUP(templates)
+	}
+
+	var securities *composer.Securities
+	if a.cnf.env.TickerValidation {
+		securities = a.newSecurities()
+		composerEntity.WithSecurities(securities)
+	}
+
+	// Collects fetch latency and status of the providers
+	healthJob := jobs.NewProviderHealthJob(archivistEntity)
 
 	// get all stockMap and pass as a parameter to jobs
 	scv := scavenger.Scavenger{}
@@ -59,33 +189,98 @@ func (a *App) start() {
 		return fmt.Errorf("error fetching stockMap: %w", err)
 	}, retry.Attempts(2), retry.Delay(5*time.Second))
 	if err != nil {
-		slog.Default().Error("[main] Error fetching stockMap:", err)
+		logger.Error("[main] Error fetching stockMap", "error", err)
 
 		// TODO: Find a reliable API source for this sorts of data
 		// try to fill the gaps with static data
 		stockMap = scv.Screener.FetchFromString(a.cnf.env.StockSymbols)
 		if stockMap == nil {
-			slog.Default().Error("[main] Error fetching stockMap from env")
+			logger.Error("[main] Error fetching stockMap from env")
 		}
 	}
 
-	marketJob := jobs.NewJob(composerEntity, telegramPublisher, archivistEntity, marketJournalist, stockMap).
-		FetchUntil(time.Now().Add(-60 * time.Second)).
-		OmitSuspicious().
-		OmitIfAllKeysEmpty().
-		OmitUnlistedStocks().
-		RemoveClones().
-		ComposeText().
-		SaveToDB()
+	// Admin alerts are sent by the same bot to the separate chat
+	var alerter *jobs.Alerter
+	if a.cnf.env.AdminChatID != "" {
+		alerter = jobs.NewAlerter(telegramPublisher, a.cnf.env.AdminChatID, a.cnf.env.AdminAlertThreshold)
+	}
+
+	// Published news are mirrored to the additional targets
+	mirrors := publisher.NewMultiPublisher()
+	if a.cnf.env.DiscordWebhookURL != "" {
+		mirrors.Add("discord", publisher.NewDiscordPublisher(a.cnf.env.DiscordWebhookURL, a.cnf.env.ShouldPublish))
+	}
+	if a.cnf.env.MastodonURL != "" {
+		mastodon := publisher.NewMastodonPublisher(a.cnf.env.MastodonURL, a.cnf.env.MastodonToken, a.cnf.env.ShouldPublish)
+		mirrors.Add("mastodon", mastodon.WithMaxLength(a.cnf.env.MastodonMaxLength))
+	}
+	if a.cnf.env.BlueskyHandle != "" {
+		mirrors.Add("bluesky", publisher.NewBlueskyPublisher(
+			a.cnf.env.BlueskyURL,
+			a.cnf.env.BlueskyHandle,
+			a.cnf.env.BlueskyAppPassword,
+			a.cnf.env.ShouldPublish,
+		))
+	}
 
-	broadJob := jobs.NewJob(composerEntity, telegramPublisher, archivistEntity, broadNews, stockMap).
-		FetchUntil(time.Now().Add(-4 * time.Minute)).
-		OmitSuspicious().
-		OmitEmptyMeta(jobs.MetaTickers).
-		OmitUnlistedStocks().
-		RemoveClones().
-		ComposeText().
-		SaveToDB()
+	// Published news are collected into the email digest sent to the mailing list
+	email, err := a.newEmailPublisher()
+	if err != nil {
+		logger.Error("[main] Error creating email publisher", "error", err)
+		panic(err)
+	}
+
+	// Day price changes of the tickers mentioned in the composed news
+	var quotes marketdata.QuoteProvider
+	switch a.cnf.env.QuotesProvider {
+	case "yahoo":
+		quotes = marketdata.NewQuoteCache(&marketdata.MarketData{}, time.Duration(a.cnf.env.QuotesCacheTTL)*time.Second)
+	case "finnhub":
+		quotes = marketdata.NewQuoteCache(marketdata.NewFinnhub(a.cnf.env.FinnhubToken), time.Duration(a.cnf.env.QuotesCacheTTL)*time.Second)
+	}
+
+	services := &pipelineServices{
+		metrics:    metricsEmitter,
+		usage:      usageJob,
+		health:     healthJob,
+		control:    control,
+		alerter:    alerter,
+		stockMap:   stockMap,
+		quotes:     quotes,
+		templates:  templates,
+		securities: securities,
+	}
+
+	// The default pipeline publishes to the channels from the env, its news and dead letters exclude the threads
+	defaultPipeline := &pipeline{
+		cnf:       a.cnf,
+		publisher: telegramPublisher,
+		composer:  composerEntity,
+		archivist: defaultArchivist,
+		router:    jobs.NewRouter(a.cnf.channelRoutes()),
+		mirrors:   mirrors,
+		email:     email,
+	}
+	if err := defaultPipeline.buildJobs(services); err != nil {
+		logger.Error("[main] Error creating news jobs", "error", err)
+		panic(err)
+	}
+	newsJobs, publicationsJob := defaultPipeline.newsJobs, defaultPipeline.publicationsJob
+
+	// Threads run the news pipelines of the other channels, the broken thread is skipped without stopping the others
+	var threads []*pipeline
+	for i := range a.cnf.threads {
+		t := &a.cnf.threads[i]
+		thread, err := a.newThread(t, archivistEntity, services)
+		if err != nil {
+			err = fmt.Errorf("thread %s: %w", t.Name, err)
+			logger.Error("[main] Error creating thread, skipping it", "error", err)
+			sentry.CaptureException(err)
+			alerter.Alert("Thread", t.Name, err)
+			continue
+		}
+		threads = append(threads, thread)
+	}
 
 	// Sentry hub for fatal errors
 	hub := sentry.CurrentHub().Clone()
@@ -95,7 +290,29 @@ func (a *App) start() {
 	defer hub.Flush(2 * time.Second)
 	defer hub.Recover(nil)
 
-	s, err := gocron.NewScheduler()
+	// Ping external uptime monitor after each successful news job run, so silent failures are detected.
+	// Failed and paused runs and the runs skipped because the previous one is still running don't ping.
+	hb := heartbeat.New(a.cnf.env.HeartbeatURL)
+	if hb.Enabled() {
+		control.AfterSuccessfulRuns(func(jobName string, _ jobs.RunInfo) {
+			// The slow monitor doesn't hold up the job run
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := hb.Ping(ctx); err != nil {
+					logger.Warn("[heartbeat] Error sending heartbeat", "job", jobName, "error", err)
+				}
+			}()
+		})
+	}
+
+	// Only the leader replica executes scheduled jobs if leader election is enabled
+	var schedulerOptions []gocron.SchedulerOption
+	if elector != nil {
+		schedulerOptions = append(schedulerOptions, gocron.WithDistributedElector(elector))
+	}
+
+	s, err := scheduler.New(schedulerOptions...)
 	if err != nil {
 		hub.AddBreadcrumb(&sentry.Breadcrumb{
 			Category: "scheduler",
@@ -105,100 +322,232 @@ func (a *App) start() {
 		utils.CaptureSentryException("createSchedulerError", hub, err)
 		panic(err)
 	}
+	schedule := func(def scheduler.Definition, task func()) {
+		if err := s.Add(def, task); err != nil {
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "scheduler",
+				Message:  fmt.Sprintf("Error scheduling job for %s", def.Name),
+				Level:    sentry.LevelFatal,
+			}, nil)
+			utils.CaptureSentryException("createScheduleJobError", hub, err)
+			panic(err)
+		}
+	}
 
-	_, err = s.NewJob(
-		gocron.DurationJob(60*time.Second),
-		gocron.NewTask(marketJob.Run()),
-		gocron.WithSingletonMode(gocron.LimitModeReschedule), // for often jobs
-		gocron.WithName("scheduler for Market news"),
-	)
-
-	if err != nil {
-		hub.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Market news",
-			Level:    sentry.LevelFatal,
-		}, nil)
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+	// News jobs with the recovery and retries of their publications
+	defaultPipeline.schedule(schedule)
+	for _, thread := range threads {
+		thread.schedule(schedule)
 	}
 
-	_, err = s.NewJob(
-		gocron.DurationJob(4*time.Minute),
-		gocron.NewTask(broadJob.Run()),
-		gocron.WithName("scheduler for Broad market news"),
-	)
-	if err != nil {
-		hub.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Broad news",
-			Level:    sentry.LevelFatal,
-		}, nil)
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+	// Refresh the reference list of the tickers, the cache file is kept if the API is down
+	if securities != nil {
+		schedule(scheduler.Definition{
+			Name:  "Securities refresh",
+			Every: 24 * time.Hour,
+		}, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := securities.Refresh(ctx); err != nil {
+				logger.Warn("[securities] Error refreshing the list", "error", err)
+			}
+		})
 	}
 
+	schedule(scheduler.Definition{
+		Name:  "Provider health",
+		Every: 60 * time.Second,
+	}, healthJob.Run())
+
+	schedule(scheduler.Definition{
+		Name:  "LLM usage",
+		Every: 60 * time.Second,
+	}, usageJob.Run())
+
 	// Calendar job
 	calJob := jobs.NewCalendarJob(
 		scv.EconomicCalendar,
 		telegramPublisher,
 		archivistEntity,
 		"mql5-calendar",
-	)
+	).WithAlerter(alerter)
 
-	_, err = s.NewJob(
-		gocron.CronJob("0 4 * * 1-5", false), // every weekday at 4:00 UTC
-		gocron.NewTask(calJob.RunDailyCalendarJob()),
-		gocron.WithName("scheduler for Calendar"),
-	)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Calendar",
-			Level:    sentry.LevelFatal,
-		})
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
-	}
+	schedule(scheduler.Definition{
+		Name: "Calendar",
+		Cron: "0 4 * * 1-5", // every weekday at 4:00 UTC
+	}, calJob.RunDailyCalendarJob())
 
-	_, err = s.NewJob(
-		gocron.DurationJob(90*time.Second),
-		gocron.NewTask(calJob.RunCalendarUpdatesJob()),
-		gocron.WithName("scheduler for Calendar updates"),
-	)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Calendar updates",
-			Level:    sentry.LevelFatal,
-		})
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
-	}
+	schedule(scheduler.Definition{
+		Name:  "Calendar updates",
+		Every: 90 * time.Second,
+	}, calJob.RunCalendarUpdatesJob())
 
 	// Before market open job
 	bmoJob := jobs.NewSummaryJob(
 		composerEntity,
 		telegramPublisher,
-		archivistEntity,
-	)
-	_, err = s.NewJob(
-		// TODO: Use holidays calendar to avoid unnecessary runs
-		gocron.CronJob("0 14 * * 1-5", false), // every weekday at 14:00 UTC (market opens at 14:30 UTC)
-		gocron.NewTask(bmoJob.Run(time.Now().Truncate(24*time.Hour))),
-		gocron.WithName("scheduler for Before Market Open summary job"),
-	)
-	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Before Market Open",
-			Level:    sentry.LevelFatal,
-		})
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+		defaultArchivist,
+	).WithMetrics(metricsEmitter).WithAlerter(alerter)
+	// TODO: Use holidays calendar to avoid unnecessary runs
+	schedule(scheduler.Definition{
+		Name: "Before Market Open summary job",
+		Cron: "0 14 * * 1-5", // every weekday at 14:00 UTC (market opens at 14:30 UTC)
+	}, bmoJob.Run(time.Now().Truncate(24*time.Hour)))
+
+	// Weekly "news vs price" report job
+	weeklyJob := jobs.NewWeeklyReportJob(
+		scv.MarketData,
+		telegramPublisher,
+		defaultArchivist,
+	).WithAlerter(alerter)
+	schedule(scheduler.Definition{
+		Name: "Weekly report",
+		Cron: "0 12 * * 6", // every Saturday at 12:00 UTC
+	}, weeklyJob.Run())
+
+	// Daily audio digest job
+	if a.cnf.env.PodcastEnabled {
+		podcastJob := jobs.NewPodcastJob(
+			composerEntity,
+			telegramPublisher,
+			defaultArchivist,
+		).WithMetrics(metricsEmitter).WithAlerter(alerter)
+		if a.cnf.env.PodcastBaseURL != "" {
+			podcastJob.SaveEpisodes()
+		}
+		schedule(scheduler.Definition{
+			Name: "Podcast",
+			Cron: "30 21 * * 1-5", // every weekday at 21:30 UTC (after the market close)
+		}, podcastJob.Run())
 	}
 
-	defer func(s gocron.Scheduler) {
+	// Themed digest job
+	if a.cnf.env.DigestCron != "" {
+		digestJob := jobs.NewDigestJob(
+			composerEntity,
+			telegramPublisher,
+			defaultArchivist,
+			time.Duration(a.cnf.env.DigestHours)*time.Hour,
+		).WithMetrics(metricsEmitter).WithAlerter(alerter)
+		schedule(scheduler.Definition{
+			Name: "Digest",
+			Cron: a.cnf.env.DigestCron,
+		}, digestJob.Run())
+	}
+
+	// Market mood poll job
+	if a.cnf.env.MarketMoodCron != "" {
+		moodJob := jobs.NewMarketMoodJob(
+			telegramPublisher,
+			archivistEntity,
+			a.cnf.env.MarketMoodChannel,
+		).WithAlerter(alerter)
+		schedule(scheduler.Definition{
+			Name: "Market mood poll",
+			Cron: a.cnf.env.MarketMoodCron,
+		}, moodJob.Run())
+		schedule(scheduler.Definition{
+			Name: "Market mood results",
+			Cron: a.cnf.env.MarketMoodResultsCron,
+		}, moodJob.RunResults())
+	}
+
+	// Email digest job
+	if email != nil {
+		emailJob := jobs.NewEmailDigestJob(email).WithAlerter(alerter)
+		schedule(scheduler.Definition{
+			Name: "Email digest",
+			Cron: a.cnf.env.EmailDigestCron,
+		}, emailJob.Run())
+	}
+
+	// Database export job
+	if a.cnf.env.ExportS3Bucket != "" {
+		s3 := storage.NewS3(
+			a.cnf.env.ExportS3Endpoint,
+			a.cnf.env.ExportS3Region,
+			a.cnf.env.ExportS3Bucket,
+			a.cnf.env.ExportS3AccessKey,
+			a.cnf.env.ExportS3SecretKey,
+		)
+		exportJob := jobs.NewExportJob(archivistEntity, s3, "exports")
+		schedule(scheduler.Definition{
+			Name: "Database export",
+			Cron: "0 3 * * *", // every day at 3:00 UTC
+		}, exportJob.Run())
+	}
+
+	// Output feed job writes the RSS and Atom feeds of the published news for the static hosting
+	if a.cnf.env.FeedDir != "" || a.cnf.env.FeedS3Enabled {
+		feedJob := jobs.NewFeedJob(
+			defaultArchivist.Entities.News,
+			publisher.NewFeed(a.cnf.env.FeedBaseURL),
+			a.cnf.env.FeedItems,
+		).WriteTo(a.cnf.env.FeedDir)
+		if a.cnf.env.FeedS3Enabled {
+			feedJob.UploadTo(storage.NewS3(
+				a.cnf.env.ExportS3Endpoint,
+				a.cnf.env.ExportS3Region,
+				a.cnf.env.ExportS3Bucket,
+				a.cnf.env.ExportS3AccessKey,
+				a.cnf.env.ExportS3SecretKey,
+			), "feed")
+		}
+		schedule(scheduler.Definition{
+			Name:  "Output feed",
+			Every: 5 * time.Minute,
+		}, feedJob.Run())
+	}
+
+	// News retention job
+	if a.cnf.env.NewsRetentionDays > 0 {
+		day := 24 * time.Hour
+		retentionJob := jobs.NewRetentionJob(archivistEntity, time.Duration(a.cnf.env.NewsRetentionDays)*day).
+			WithPublishedRetention(time.Duration(a.cnf.env.PublishedRetentionDays) * day)
+		if a.cnf.env.SimilarityDedupEnabled {
+			retentionJob.WithEmbeddings()
+		}
+		schedule(scheduler.Definition{
+			Name: "News retention",
+			Cron: "0 4 * * *", // every day at 4:00 UTC, after the database export
+		}, retentionJob.Run())
+	}
+
+	// Engagement stats of the posts in the public channels for the top posts of the weekly report
+	if a.cnf.env.EngagementStatsDays > 0 {
+		engagementJob := jobs.NewEngagementJob(
+			archivistEntity,
+			publisher.NewTelegramStats(),
+			a.cnf.publicChannelIDs(),
+			time.Duration(a.cnf.env.EngagementStatsDays)*24*time.Hour,
+		)
+		schedule(scheduler.Definition{
+			Name:  "Engagement stats",
+			Every: time.Hour,
+		}, engagementJob.Run())
+	}
+
+	// Admin commands (/pause, /resume, /status, /lastrun, /repost, /correct, /retract, /deadletters, /redrive) are received by the bot in the admin chat
+	if a.cnf.env.AdminCommandsEnabled {
+		reposter := publicationsJob
+		if reposter == nil {
+			reposter = newsJobs[0] // news are not saved by any job, so /repost will find nothing
+		}
+		bot, err := admin.NewBot(a.cnf.env.TelegramBotToken, a.cnf.env.AdminChatID, control, reposter)
+		if err != nil {
+			logger.Error("[main] Error creating admin bot", "error", err)
+			panic(err)
+		}
+		bot.WithScheduler(s) // /status shows the next runs of the scheduled jobs
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := bot.Start(ctx); err != nil {
+			logger.Error("[main] Error starting admin bot", "error", err)
+			panic(err)
+		}
+	}
+
+	defer func(s *scheduler.Scheduler) {
 		err := s.Shutdown()
 		if err != nil {
 			panic(err)
@@ -206,6 +555,402 @@ func (a *App) start() {
 	}(s)
 	s.Start()
 
-	slog.Default().Info("Started fin-thread successfully")
-	select {}
+	logger.Info("Started fin-thread successfully")
+
+	// Runs until the process is stopped, then the deferred functions stop the scheduler, the admin bot,
+	// the leader election and the HTTP server
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	<-ctx.Done()
+	logger.Info("Stopping fin-thread")
+}
+
+// reloadPromptsOnSIGHUP reloads the prompt templates on every SIGHUP, so the prompts can be tuned without restart.
+// Broken templates are reported and the previous ones are kept.
+func reloadPromptsOnSIGHUP(templates *composer.PromptTemplates) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := templates.Load(); err != nil {
+			logger.Error("[main] Error reloading prompt templates, keeping the previous ones", "error", err)
+			sentry.CaptureException(err)
+			continue
+		}
+		logger.Info("[main] Reloaded prompt templates", "stages", templates.Stages())
+	}
+}
+
+// newLeaseElector creates Kubernetes Lease based leader elector. Pod name is used as the replica identity.
+func (a *App) newLeaseElector() (*leader.LeaseElector, error) {
+	identity := a.cnf.env.PodName
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("error getting hostname: %w", err)
+		}
+		identity = hostname
+	}
+
+	return leader.NewLeaseElector(a.cnf.env.LeaderElection, identity, 15*time.Second) //nolint:wrapcheck
+}
+
+// newTelegramPublisher creates the publisher of the default and named channels with the configured
+// retries, rate limits and message format.
+func (a *App) newTelegramPublisher(m metrics.Emitter) (*publisher.TelegramPublisher, error) {
+	telegramPublisher, err := publisher.NewTelegramPublisher(
+		a.cnf.env.TelegramChannelID,
+		a.cnf.env.TelegramBotToken,
+		a.cnf.env.ShouldPublish,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	telegramPublisher.
+		WithChannels(a.cnf.channelChatIDs()).
+		WithTopics(a.cnf.channelTopics()).
+		WithTemplates(a.cnf.channelTemplates()).
+		WithRetry(a.cnf.env.PublishRetryAttempts, time.Second, time.Duration(a.cnf.env.PublishRetryMaxDelay)*time.Second).
+		WithRateLimit(a.cnf.env.PublishRatePerChat, a.cnf.env.PublishRateGlobal).
+		WithMetrics(m)
+	switch a.cnf.env.MessageFormat {
+	case "markdownv2":
+		telegramPublisher.WithFormatter(publisher.NewMessageFormatter(publisher.ModeMarkdownV2).WithLinkPreview(a.cnf.env.LinkPreview))
+	case "html":
+		telegramPublisher.WithFormatter(publisher.NewMessageFormatter(tgbotapi.ModeHTML).WithLinkPreview(a.cnf.env.LinkPreview))
+	}
+
+	return telegramPublisher, nil
+}
+
+// newEmailPublisher creates the publisher of the email digest with the configured provider and template.
+// Returns nil if the email digest is disabled.
+func (a *App) newEmailPublisher() (*publisher.EmailPublisher, error) {
+	if a.cnf.env.EmailDigestCron == "" {
+		return nil, nil
+	}
+
+	var sender publisher.EmailSender
+	switch a.cnf.env.EmailProvider {
+	case "smtp":
+		sender = publisher.NewSMTPSender(a.cnf.env.SMTPAddr, a.cnf.env.SMTPUsername, a.cnf.env.SMTPPassword)
+	case "sendgrid":
+		sender = publisher.NewSendGridSender(a.cnf.env.SendGridAPIKey)
+	}
+
+	var to []string
+	for _, addr := range strings.Split(a.cnf.env.EmailTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+
+	email := publisher.NewEmailPublisher(sender, a.cnf.env.EmailFrom, to, a.cnf.env.ShouldPublish).
+		WithUnsubscribeURL(a.cnf.env.EmailUnsubscribeURL)
+	if a.cnf.env.EmailSubject != "" {
+		email.WithSubject(a.cnf.env.EmailSubject)
+	}
+	if a.cnf.env.EmailTemplate != "" {
+		tmpl, err := os.ReadFile(a.cnf.env.EmailTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("error reading email template: %w", err)
+		}
+		return email.WithTemplate(string(tmpl)) //nolint:wrapcheck
+	}
+
+	return email, nil
+}
+
+// newComposer creates the composer with the configured LLM provider, style and cache.
+// Token usage of the requests is collected by the usage job.
+func (a *App) newComposer(m metrics.Emitter, usageJob *jobs.LLMUsageJob) *composer.Composer {
+	composerEntity := composer.NewComposer(a.cnf.env.OpenAiToken, a.cnf.env.TogetherAIToken, a.cnf.env.GoogleGeminiToken).
+		WithMetrics(m).
+		ObserveUsage(usageJob.Observe).
+		WithStyle(composer.Style(a.cnf.env.ComposeStyle))
+	composerEntity.Config.FreshnessWindow = time.Duration(a.cnf.env.ComposeFreshnessWindow) * time.Minute
+	composerEntity.Config.MaxSentences = a.cnf.env.ComposeMaxSentences
+	composerEntity.Config.MaxChars = a.cnf.env.ComposeMaxChars
+	newProvider := func(f fallbackModel) composer.LLMProvider {
+		if f.Provider == composer.ProviderAnthropic {
+			return composer.NewAnthropic(a.cnf.env.AnthropicToken, f.Model).WithMetrics(m).ObserveUsage(usageJob.Observe)
+		}
+		return composer.NewOpenAICompatibleProvider(a.cnf.env.OpenAiToken, a.cnf.env.OpenAiBaseURL, f.Model).
+			WithMetrics(m).
+			ObserveUsage(usageJob.Observe)
+	}
+	fallback := a.cnf.fallbackModels()
+	var primary composer.FallbackModel
+	switch {
+	case a.cnf.env.ComposerProvider == composer.ProviderAnthropic:
+		p := composer.NewAnthropic(a.cnf.env.AnthropicToken, a.cnf.env.AnthropicModel).WithMetrics(m).ObserveUsage(usageJob.Observe)
+		primary = composer.FallbackModel{Model: p.Model, Provider: p}
+	case a.cnf.env.OpenAiBaseURL != "" || a.cnf.env.OpenAiModel != "":
+		p := composer.NewOpenAICompatibleProvider(a.cnf.env.OpenAiToken, a.cnf.env.OpenAiBaseURL, a.cnf.env.OpenAiModel).
+			WithMetrics(m).
+			ObserveUsage(usageJob.Observe)
+		primary = composer.FallbackModel{Model: p.Model, Provider: p}
+	case len(fallback) > 0:
+		// The default OpenAI backend is the first model of the chain
+		p := composer.NewOpenAIProvider(composerEntity.OpenAiClient).WithMetrics(m).ObserveUsage(usageJob.Observe)
+		primary = composer.FallbackModel{Model: p.Model, Provider: p}
+	}
+	if len(fallback) > 0 {
+		chain := []composer.FallbackModel{primary}
+		for _, f := range fallback {
+			chain = append(chain, composer.FallbackModel{Model: f.Model, Provider: newProvider(f)})
+		}
+		composerEntity.WithLLMProvider(composer.NewFallbackProvider(chain...).WithMetrics(m))
+	} else if primary.Provider != nil {
+		composerEntity.WithLLMProvider(primary.Provider)
+	}
+	// Cheap model for the tickers, markets and hashtags, so the better one writes the text only
+	if meta, ok := a.cnf.metaModel(); ok {
+		composerEntity.WithMetaExtraction(newProvider(meta), a.cnf.metaMarkets())
+	}
+	// Texts introducing the numbers or names absent from the original news are flagged or reverted
+	if a.cnf.env.ComposeVerify != "" {
+		var judge composer.LLMProvider
+		if m, ok := a.cnf.verifyModel(); ok {
+			judge = newProvider(m)
+		}
+		composerEntity.WithVerification(judge, composer.VerifyMode(a.cnf.env.ComposeVerify))
+	}
+	if a.cnf.env.ComposeCacheTTL > 0 {
+		composerEntity.WithCache(composer.NewComposeCache(a.cnf.env.ComposeCacheSize, time.Duration(a.cnf.env.ComposeCacheTTL)*time.Minute))
+	}
+
+	return composerEntity
+}
+
+// newSecurities creates the reference list of the tickers and loads it from the cache file or the API.
+// If the list can't be loaded, the tickers are not validated until the next refresh.
+func (a *App) newSecurities() *composer.Securities {
+	path := a.cnf.env.SecuritiesFile
+	if path == "" {
+		path = defaultSecuritiesFile
+	}
+	url := a.cnf.env.SecuritiesURL
+	if url == "" {
+		url = composer.DefaultSecuritiesURL
+	}
+	securities := composer.NewSecurities(path, url).WithAliases(a.cnf.tickerAliases)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := securities.Load(ctx); err != nil {
+		logger.Warn("[main] Error loading securities, tickers are not validated", "error", err)
+	} else {
+		logger.Info("[main] Loaded securities", "tickers", securities.Len())
+	}
+
+	return securities
+}
+
+// migrate creates or updates the database schema.
+func (a *App) migrate() error {
+	arch, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	if err != nil {
+		return fmt.Errorf("error creating Archivist: %w", err)
+	}
+
+	if err := arch.Migrate(); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if a.cnf.env.SimilarityDedupEnabled {
+		return arch.MigrateEmbeddings() //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+// rollback reverts the given number of the last applied schema migrations.
+func (a *App) rollback(steps int) error {
+	arch, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	if err != nil {
+		return fmt.Errorf("error creating Archivist: %w", err)
+	}
+
+	return arch.Rollback(steps) //nolint:wrapcheck
+}
+
+// bootstrap creates the database schema and seeds the configuration rows (channels, keyword sets)
+// from the current configuration, so fresh deployments don't need any manual SQL.
+func (a *App) bootstrap() error {
+	arch, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	if err != nil {
+		return fmt.Errorf("error creating Archivist: %w", err)
+	}
+
+	suspicious, err := archivist.NewKeywordSet(suspiciousKeywordSet, a.cnf.suspiciousKeywords)
+	if err != nil {
+		return fmt.Errorf("error creating keyword set: %w", err)
+	}
+
+	channels := []*archivist.Channel{
+		{Name: defaultChannelName, ChatID: a.cnf.env.TelegramChannelID},
+	}
+	for _, ch := range a.cnf.channels {
+		channels = append(channels, &archivist.Channel{Name: ch.Name, ChatID: ch.ChatID})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return arch.Bootstrap(ctx, &archivist.Seed{ //nolint:wrapcheck
+		Channels:    channels,
+		KeywordSets: []*archivist.KeywordSet{suspicious},
+	})
+}
+
+// backfill fetches the news of the job provider published since from (and before to, if set) and saves them
+// without composing and publishing, e.g. to fill the archive for the search and the statistics.
+// Already saved news are skipped. Returns the number of the saved news.
+func (a *App) backfill(ctx context.Context, jobName, providerName string, from, to time.Time) (int, error) {
+	cnf, def, provider, err := a.cnf.findProvider(jobName, providerName)
+	if err != nil {
+		return 0, err
+	}
+	providers, err := newsProviders([]rssProvider{provider})
+	if err != nil {
+		return 0, err
+	}
+
+	arch, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	if err != nil {
+		return 0, fmt.Errorf("error creating Archivist: %w", err)
+	}
+
+	// Historical feeds and APIs are slower than the latest news
+	news, err := journalist.NewJournalist(def.Name, providers).
+		WithProviderTimeout(time.Minute).
+		FilterDomains(def.AllowDomains, def.BlockDomains).
+		GetLatestNews(ctx, from)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching news: %w", err)
+	}
+
+	hashes := make([]string, len(news))
+	urls := make([]string, len(news))
+	for i, n := range news {
+		hashes[i] = n.ID
+		urls[i] = n.Link
+	}
+	exists, err := arch.Entities.News.ExistsByHashes(ctx, hashes)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	found, err := arch.Entities.News.FindAllByUrls(ctx, urls)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	savedURLs := make(map[string]bool, len(found))
+	for _, n := range found {
+		savedURLs[n.URL] = true
+	}
+
+	chatID := cnf.env.TelegramChannelID
+	if def.Channel != "" {
+		chatID = def.Channel
+		if id, ok := cnf.channelChatIDs()[def.Channel]; ok {
+			chatID = id
+		}
+	}
+
+	var dbNews []*archivist.News
+	for _, n := range news {
+		if (!to.IsZero() && !n.Date.Before(to)) || exists[n.ID] || savedURLs[n.Link] {
+			continue
+		}
+		savedURLs[n.Link] = true
+
+		item := &archivist.News{
+			Hash:          n.ID,
+			ChannelID:     chatID,
+			Channel:       def.Channel,
+			JobName:       def.Name,
+			ProviderName:  n.ProviderName,
+			OriginalTitle: n.Title,
+			OriginalDesc:  n.Description,
+			OriginalDate:  n.Date,
+			URL:           n.Link,
+			ImageURL:      n.ImageURL,
+			Content:       n.Content,
+			IsSuspicious:  n.IsSuspicious,
+			State:         archivist.NewsStateSaved,
+		}
+		if err := item.Validate(); err != nil {
+			logger.Warn("[backfill] Skipping invalid news", "url", n.Link, "error", err)
+			continue
+		}
+		dbNews = append(dbNews, item)
+	}
+
+	if err := arch.Entities.News.CreateMany(ctx, dbNews); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return len(dbNews), nil
+}
+
+// replay publishes the saved news again by its hash with the options of the job (the first job that saves news
+// if the name is empty), e.g. if the message was deleted from the channel by mistake. The news is not mirrored.
+// Returns the new publication ID.
+func (a *App) replay(ctx context.Context, jobName, hash string) (string, error) {
+	cnf, def, err := a.cnf.findJob(jobName, func(d *jobDefinition) bool { return d.SaveToDB })
+	if err != nil {
+		return "", err
+	}
+	// News are published by the bot of the job pipeline (the thread or the default one)
+	pipeline := &App{cnf: cnf}
+
+	arch, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	if err != nil {
+		return "", fmt.Errorf("error creating Archivist: %w", err)
+	}
+	telegramPublisher, err := pipeline.newTelegramPublisher(metrics.Noop{})
+	if err != nil {
+		return "", fmt.Errorf("error creating Telegram publisher: %w", err)
+	}
+	// Composer is used only for the translations of the job
+	composerEntity := pipeline.newComposer(metrics.Noop{}, jobs.NewLLMUsageJob(arch))
+
+	job := def.apply(jobs.NewJob(composerEntity, telegramPublisher, arch, journalist.NewJournalist(def.Name, nil), nil)).
+		ThreadLongText(a.cnf.env.ThreadMaxLength)
+
+	return job.Repost(ctx, hash) //nolint:wrapcheck
+}
+
+// export writes the archived news matching the filter to the writer in the format, e.g. for the analysis
+// of the coverage and engagement in pandas. Returns the number of the exported news.
+func (a *App) export(ctx context.Context, filter archivist.ExportFilter, w io.Writer, format archivist.ExportFormat) (int, error) {
+	arch, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	if err != nil {
+		return 0, fmt.Errorf("error creating Archivist: %w", err)
+	}
+
+	return arch.Entities.News.Export(ctx, filter, w, format) //nolint:wrapcheck
+}
+
+// loadSuspiciousKeywords replaces default suspicious keywords with the ones stored in the database (if any).
+func (a *App) loadSuspiciousKeywords(arch *archivist.Archivist) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set, err := arch.Entities.KeywordSets.FindByName(ctx, suspiciousKeywordSet)
+	if err != nil {
+		return fmt.Errorf("error finding keyword set: %w", err)
+	}
+	if set == nil {
+		return nil
+	}
+
+	keywords, err := set.List()
+	if err != nil {
+		return fmt.Errorf("error reading keyword set: %w", err)
+	}
+	if len(keywords) > 0 {
+		a.cnf.suspiciousKeywords = keywords
+	}
+
+	return nil
 }
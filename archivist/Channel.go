@@ -0,0 +1,80 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ChannelsDB struct {
+	Conn *gorm.DB
+}
+
+func NewChannelsDB(db *gorm.DB) *ChannelsDB {
+	return &ChannelsDB{Conn: db}
+}
+
+// Channel is the configuration row of the channel where the news are published.
+type Channel struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"`  // ID of the channel (UUID)
+	Name      string    `gorm:"size:64;uniqueIndex;not null;" json:"name"` // Name of the channel (e.g. "macro")
+	ChatID    string    `gorm:"size:64;not null;" json:"chat_id"`          // ID of the chat (chat ID in Telegram)
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+func (c *Channel) Validate() error {
+	if c.Name == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(c.Name) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	if len(c.ChatID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	return nil
+}
+
+func (c *Channel) BeforeCreate(*gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	if err := c.Validate(); err != nil {
+		return newError(errlvl.INFO, errChannelValidation, err)
+	}
+
+	return nil
+}
+
+// CreateIfNotExists creates the channels, skipping the ones that already exist by name.
+func (db *ChannelsDB) CreateIfNotExists(ctx context.Context, c []*Channel) error {
+	if len(c) == 0 {
+		return nil
+	}
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&c)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errChannelCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindAll returns all configured channels.
+func (db *ChannelsDB) FindAll(ctx context.Context) ([]*Channel, error) {
+	var c []*Channel
+	res := db.Conn.WithContext(ctx).Find(&c)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errChannelFind, res.Error)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,45 @@
+package archivist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannel_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  Channel
+		wantErr bool
+	}{
+		{
+			name: "valid channel",
+			fields: Channel{
+				Name:   "macro",
+				ChatID: "@macro_channel",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty name",
+			fields: Channel{
+				ChatID: "@macro_channel",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid channel with long ChatID",
+			fields: Channel{
+				Name:   "macro",
+				ChatID: strings.Repeat("a", 65), // ChatID length > 64
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fields.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package archivist
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"unicode/utf8"
+)
+
+// deadLetterErrorLength is the max length of the saved error in runes, longer errors are truncated.
+const deadLetterErrorLength = 2000
+
+type DeadLettersDB struct {
+	Conn *gorm.DB
+}
+
+func NewDeadLettersDB(db *gorm.DB) *DeadLettersDB {
+	return &DeadLettersDB{Conn: db}
+}
+
+// DeadLetter is the news which publication failed permanently (after the retries of the publisher).
+// Dead letters are retried by the scheduled job until the max attempts and can be re-driven manually,
+// the dead letter is deleted when the news is published.
+type DeadLetter struct {
+	Hash         string    `gorm:"primaryKey;size:32" json:"hash"`              // Hash of the news
+	JobName      string    `gorm:"size:64" json:"job_name"`                     // Name of the job that failed to publish the news
+	ChatID       string    `gorm:"size:64" json:"chat_id"`                      // Chat ID the news failed to be published to
+	Error        string    `gorm:"type:text" json:"error"`                      // Error of the last failed attempt
+	Attempts     int       `gorm:"not null;default:1" json:"attempts"`          // Number of the failed attempts
+	LastFailedAt time.Time `gorm:"not null;index" json:"last_failed_at"`        // Time of the last failed attempt
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"` // Time of the first failed attempt
+}
+
+func (d *DeadLetter) Validate() error {
+	if d.Hash == "" {
+		return newError(errlvl.INFO, errHashEmpty, nil)
+	}
+
+	if len(d.Hash) > 32 {
+		return newError(errlvl.INFO, errHashTooLong, nil)
+	}
+
+	if len(d.JobName) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	if len(d.ChatID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	return nil
+}
+
+func (d *DeadLetter) BeforeCreate(*gorm.DB) error {
+	if utf8.RuneCountInString(d.Error) > deadLetterErrorLength {
+		d.Error = string([]rune(d.Error)[:deadLetterErrorLength])
+	}
+	if d.LastFailedAt.IsZero() {
+		d.LastFailedAt = time.Now()
+	}
+
+	if err := d.Validate(); err != nil {
+		return newError(errlvl.INFO, errDeadLetterValidation, err)
+	}
+
+	return nil
+}
+
+// Record saves the failed publication of the news. The dead letter of the news failed again
+// gets the new error and the incremented attempts counter.
+func (db *DeadLettersDB) Record(ctx context.Context, d *DeadLetter) error {
+	d.Attempts = 1
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "hash"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"attempts":       gorm.Expr("dead_letters.attempts + 1"),
+			"job_name":       gorm.Expr("excluded.job_name"),
+			"chat_id":        gorm.Expr("excluded.chat_id"),
+			"error":          gorm.Expr("excluded.error"),
+			"last_failed_at": gorm.Expr("excluded.last_failed_at"),
+		}),
+	}).Create(d)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errDeadLetterRecord, res.Error)
+	}
+
+	return nil
+}
+
+// FindRetryable returns the dead letters with less than maxAttempts failed attempts, the oldest failures first.
+func (db *DeadLettersDB) FindRetryable(ctx context.Context, maxAttempts, limit int) ([]*DeadLetter, error) {
+	var letters []*DeadLetter
+	res := db.Conn.
+		WithContext(ctx).
+		Where("attempts < ?", maxAttempts).
+		Order("last_failed_at").
+		Limit(limit).
+		Find(&letters)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errDeadLetterFind, res.Error)
+	}
+
+	return letters, nil
+}
+
+// FindLatest returns the latest dead letters, the last failures first.
+func (db *DeadLettersDB) FindLatest(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	var letters []*DeadLetter
+	res := db.Conn.
+		WithContext(ctx).
+		Order("last_failed_at DESC").
+		Limit(limit).
+		Find(&letters)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errDeadLetterFind, res.Error)
+	}
+
+	return letters, nil
+}
+
+// Delete deletes the dead letter of the published news.
+func (db *DeadLettersDB) Delete(ctx context.Context, hash string) error {
+	res := db.Conn.WithContext(ctx).Where("hash = ?", hash).Delete(&DeadLetter{})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errDeadLetterDelete, res.Error)
+	}
+
+	return nil
+}
@@ -25,7 +25,7 @@ type Event struct {
 	ChannelID    string                        `gorm:"size:64" json:"channel_id"`                // ID of the channel (chat ID in Telegram)
 	ProviderName string                        `gorm:"size:64" json:"provider_name"`             // Name of the provider (e.g. "mql5")
 	Title        string                        `gorm:"size:256" json:"title"`                    // Event title
-	DateTime     time.Time                     `gorm:"not null" json:"date_time"`                // Event date and time
+	DateTime     time.Time                     `gorm:"not null;index" json:"date_time"`          // Event date and time
 	Country      ecal.EconomicCalendarCountry  `gorm:"size:32" json:"country"`                   // Country of the event
 	Currency     ecal.EconomicCalendarCurrency `gorm:"size:10" json:"currency"`                  // Currency impacted by the event
 	Impact       ecal.EconomicCalendarImpact   `gorm:"size:10" json:"impact"`                    // Impact of the event on the market
@@ -131,3 +131,15 @@ func (edb *EventsDB) FindAllUntilDate(ctx context.Context, until time.Time) ([]*
 
 	return events, nil
 }
+
+// FindInBatches iterates over all events in batches of the given size and calls fn for each batch.
+func (edb *EventsDB) FindInBatches(ctx context.Context, batchSize int, fn func(e []*Event) error) error {
+	var events []*Event
+	res := edb.Conn.WithContext(ctx).FindInBatches(&events, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(events)
+	})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errFindEventsInBatches, res.Error)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"time"
+)
+
+type JobRunsDB struct {
+	Conn *gorm.DB
+}
+
+func NewJobRunsDB(db *gorm.DB) *JobRunsDB {
+	return &JobRunsDB{Conn: db}
+}
+
+// JobRun is the audit record of one news job execution with the number of news left after each stage.
+type JobRun struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"`                       // ID of the run (UUID)
+	JobName    string    `gorm:"size:64;not null;index:idx_job_run_started" json:"job_name"`     // Name of the job (e.g. "MarketNews")
+	StartedAt  time.Time `gorm:"not null;index:idx_job_run_started,sort:desc" json:"started_at"` // Start of the run
+	FinishedAt time.Time `gorm:"not null" json:"finished_at"`                                    // End of the run
+	Fetched    int       `gorm:"not null;default:0" json:"fetched"`                              // Number of fetched news
+	Deduped    int       `gorm:"not null;default:0" json:"deduped"`                              // Number of news left after removing duplicates
+	Composed   int       `gorm:"not null;default:0" json:"composed"`                             // Number of news composed by the LLM
+	Published  int       `gorm:"not null;default:0" json:"published"`                            // Number of published news
+	Error      string    `gorm:"type:text" json:"error,omitempty"`                               // Error of the stage the run stopped at
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (r *JobRun) Validate() error {
+	if r.JobName == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(r.JobName) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	if r.StartedAt.IsZero() {
+		return newError(errlvl.INFO, errJobRunStartEmpty, nil)
+	}
+
+	if r.FinishedAt.Before(r.StartedAt) {
+		return newError(errlvl.INFO, errJobRunFinishedEarly, nil)
+	}
+
+	return nil
+}
+
+func (r *JobRun) BeforeCreate(*gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	if err := r.Validate(); err != nil {
+		return newError(errlvl.INFO, errJobRunValidation, err)
+	}
+
+	return nil
+}
+
+func (db *JobRunsDB) Create(ctx context.Context, r *JobRun) error {
+	res := db.Conn.WithContext(ctx).Create(r)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errJobRunCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindLatest returns the latest runs of the job, newest first.
+func (db *JobRunsDB) FindLatest(ctx context.Context, jobName string, limit int) ([]*JobRun, error) {
+	var runs []*JobRun
+	res := db.Conn.
+		WithContext(ctx).
+		Where("job_name = ?", jobName).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&runs)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errJobRunFind, res.Error)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,49 @@
+package archivist
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobRun_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		fields  JobRun
+		wantErr bool
+	}{
+		{
+			name:    "valid run",
+			fields:  JobRun{JobName: "MarketNews", StartedAt: now, FinishedAt: now.Add(time.Second), Fetched: 10},
+			wantErr: false,
+		},
+		{
+			name:    "empty job name",
+			fields:  JobRun{StartedAt: now, FinishedAt: now},
+			wantErr: true,
+		},
+		{
+			name:    "long job name",
+			fields:  JobRun{JobName: strings.Repeat("a", 65), StartedAt: now, FinishedAt: now},
+			wantErr: true,
+		},
+		{
+			name:    "empty start",
+			fields:  JobRun{JobName: "MarketNews", FinishedAt: now},
+			wantErr: true,
+		},
+		{
+			name:    "finished before start",
+			fields:  JobRun{JobName: "MarketNews", StartedAt: now, FinishedAt: now.Add(-time.Second)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fields.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
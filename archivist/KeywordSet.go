@@ -0,0 +1,113 @@
+package archivist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type KeywordSetsDB struct {
+	Conn *gorm.DB
+}
+
+func NewKeywordSetsDB(db *gorm.DB) *KeywordSetsDB {
+	return &KeywordSetsDB{Conn: db}
+}
+
+// KeywordSet is the named list of keywords used by the app (e.g. keywords to flag suspicious news).
+type KeywordSet struct {
+	ID        uuid.UUID      `gorm:"primaryKey;type:uuid;not null;" json:"id"`  // ID of the set (UUID)
+	Name      string         `gorm:"size:64;uniqueIndex;not null;" json:"name"` // Name of the set (e.g. "suspicious")
+	Keywords  datatypes.JSON `gorm:"" json:"keywords"`                          // JSON array of keywords
+	CreatedAt time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+// NewKeywordSet creates a new KeywordSet with the given name and keywords.
+func NewKeywordSet(name string, keywords []string) (*KeywordSet, error) {
+	k, err := json.Marshal(keywords)
+	if err != nil {
+		return nil, newError(errlvl.ERROR, errKeywordSetValidation, err)
+	}
+
+	return &KeywordSet{
+		Name:     name,
+		Keywords: k,
+	}, nil
+}
+
+// List returns the keywords of the set.
+func (k *KeywordSet) List() ([]string, error) {
+	var keywords []string
+	if len(k.Keywords) == 0 {
+		return keywords, nil
+	}
+
+	if err := json.Unmarshal(k.Keywords, &keywords); err != nil {
+		return nil, newError(errlvl.ERROR, errKeywordSetValidation, err)
+	}
+
+	return keywords, nil
+}
+
+func (k *KeywordSet) Validate() error {
+	if k.Name == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(k.Name) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	if _, err := k.List(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (k *KeywordSet) BeforeCreate(*gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+
+	if err := k.Validate(); err != nil {
+		return newError(errlvl.INFO, errKeywordSetValidation, err)
+	}
+
+	return nil
+}
+
+// CreateIfNotExists creates the keyword sets, skipping the ones that already exist by name.
+func (db *KeywordSetsDB) CreateIfNotExists(ctx context.Context, k []*KeywordSet) error {
+	if len(k) == 0 {
+		return nil
+	}
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&k)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errKeywordSetCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindByName finds the keyword set by its name. Returns nil if the set is not found.
+func (db *KeywordSetsDB) FindByName(ctx context.Context, name string) (*KeywordSet, error) {
+	var k KeywordSet
+	res := db.Conn.WithContext(ctx).Where("name = ?", name).First(&k)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil
+	}
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errKeywordSetFind, res.Error)
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,71 @@
+package archivist
+
+import (
+	"gorm.io/datatypes"
+	"reflect"
+	"testing"
+)
+
+func TestKeywordSet_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  KeywordSet
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "valid keywords",
+			fields: KeywordSet{
+				Name:     "suspicious",
+				Keywords: datatypes.JSON(`["study","research"]`),
+			},
+			want:    []string{"study", "research"},
+			wantErr: false,
+		},
+		{
+			name: "empty keywords",
+			fields: KeywordSet{
+				Name: "suspicious",
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "invalid keywords",
+			fields: KeywordSet{
+				Name:     "suspicious",
+				Keywords: datatypes.JSON(`{"study":1}`),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.List()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKeywordSet(t *testing.T) {
+	k, err := NewKeywordSet("suspicious", []string{"sign up", "buy now"})
+	if err != nil {
+		t.Fatalf("NewKeywordSet() error = %v", err)
+	}
+
+	if err := k.BeforeCreate(nil); err != nil {
+		t.Errorf("BeforeCreate() error = %v", err)
+	}
+
+	got, _ := k.List()
+	if want := []string{"sign up", "buy now"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NewKeywordSet() keywords = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type LLMUsageDB struct {
+	Conn *gorm.DB
+}
+
+func NewLLMUsageDB(db *gorm.DB) *LLMUsageDB {
+	return &LLMUsageDB{Conn: db.Table("llm_usage")}
+}
+
+// LLMUsage holds daily token usage and the estimated spend of the LLM model.
+type LLMUsage struct {
+	ID               uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"`                       // ID of the row (UUID)
+	Day              time.Time `gorm:"type:date;not null;uniqueIndex:idx_llm_usage_day" json:"day"`    // Day of the usage (UTC)
+	Provider         string    `gorm:"size:32;not null;uniqueIndex:idx_llm_usage_day" json:"provider"` // LLM provider (e.g. "openai")
+	Model            string    `gorm:"size:64;not null;uniqueIndex:idx_llm_usage_day" json:"model"`    // Model name (e.g. "gpt-4o")
+	Requests         int64     `gorm:"not null;default:0" json:"requests"`                             // Number of requests
+	PromptTokens     int64     `gorm:"not null;default:0" json:"prompt_tokens"`                        // Number of prompt tokens
+	CompletionTokens int64     `gorm:"not null;default:0" json:"completion_tokens"`                    // Number of completion tokens
+	Cost             float64   `gorm:"not null;default:0" json:"cost"`                                 // Estimated spend in USD by the list price
+	CreatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+// TableName overrides the default plural table name.
+func (LLMUsage) TableName() string {
+	return "llm_usage"
+}
+
+func (u *LLMUsage) Validate() error {
+	if u.Day.IsZero() {
+		return newError(errlvl.INFO, errLLMUsageDayEmpty, nil)
+	}
+
+	if u.Provider == "" || u.Model == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(u.Provider) > 32 || len(u.Model) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	return nil
+}
+
+func (u *LLMUsage) BeforeCreate(*gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+
+	if err := u.Validate(); err != nil {
+		return newError(errlvl.INFO, errLLMUsageValidation, err)
+	}
+
+	return nil
+}
+
+// Increment adds the counters to the daily usage of the models, creating the rows if needed.
+func (db *LLMUsageDB) Increment(ctx context.Context, usage []*LLMUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	counters := []string{"requests", "prompt_tokens", "completion_tokens", "cost"}
+	updates := make(map[string]interface{}, len(counters)+1)
+	for _, c := range counters {
+		updates[c] = gorm.Expr("llm_usage." + c + " + excluded." + c)
+	}
+	updates["updated_at"] = gorm.Expr("CURRENT_TIMESTAMP")
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}, {Name: "provider"}, {Name: "model"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&usage)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errLLMUsageIncrement, res.Error)
+	}
+
+	return nil
+}
+
+// SumCost returns the estimated spend of all models since the given day (inclusive).
+func (db *LLMUsageDB) SumCost(ctx context.Context, since time.Time) (float64, error) {
+	var cost float64
+	res := db.Conn.WithContext(ctx).
+		Select("COALESCE(SUM(cost), 0)").
+		Where("day >= ?", since.UTC().Truncate(24*time.Hour)).
+		Scan(&cost)
+	if res.Error != nil {
+		return 0, newError(errlvl.ERROR, errLLMUsageFind, res.Error)
+	}
+
+	return cost, nil
+}
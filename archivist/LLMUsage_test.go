@@ -0,0 +1,44 @@
+package archivist
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLLMUsage_Validate(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		fields  LLMUsage
+		wantErr bool
+	}{
+		{
+			name:    "valid usage",
+			fields:  LLMUsage{Day: day, Provider: "openai", Model: "gpt-4o", PromptTokens: 100},
+			wantErr: false,
+		},
+		{
+			name:    "empty day",
+			fields:  LLMUsage{Provider: "openai", Model: "gpt-4o"},
+			wantErr: true,
+		},
+		{
+			name:    "empty model",
+			fields:  LLMUsage{Day: day, Provider: "openai"},
+			wantErr: true,
+		},
+		{
+			name:    "long model",
+			fields:  LLMUsage{Day: day, Provider: "openai", Model: strings.Repeat("a", 65)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fields.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
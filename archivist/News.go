@@ -4,12 +4,17 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/composer"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/publisher"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 	"time"
 )
 
@@ -21,20 +26,51 @@ func NewNewsDB(db *gorm.DB) *NewsDB {
 	return &NewsDB{Conn: db.Table("news")}
 }
 
+// NewsState is the publication state of the news. News are fetched and composed in memory,
+// so the state machine starts when they are saved: saved → queued → publishing → published → retracted (optional).
+// News which publication failed permanently are failed until they are published from the dead letters.
+// News over the per-run publication limit of the job wait in the pending state: saved → pending → queued → ...
+// News to publish outside the publishing window of their channel wait in the held state: saved → held → queued → ...
+type NewsState = string
+
+const (
+	NewsStateSaved       NewsState = "saved"       // Composed and saved, not selected for publication (yet)
+	NewsStatePending     NewsState = "pending"     // Passed the pre-publish filters, over the per-run limit, waiting for the next runs
+	NewsStateHeld        NewsState = "held"        // Passed the pre-publish filters outside the publishing window of the channel, waiting for it to open
+	NewsStateQueued      NewsState = "queued"      // Passed the pre-publish filters, waiting for publication
+	NewsStatePublishing  NewsState = "publishing"  // Publication is in progress
+	NewsStatePublished   NewsState = "published"   // Published, publication IDs are saved
+	NewsStateInterrupted NewsState = "interrupted" // Publication was interrupted, the news may or may not be published
+	NewsStateRetracted   NewsState = "retracted"   // Published and deleted from the channel, e.g. the source retracted the news
+	NewsStateFailed      NewsState = "failed"      // Publication failed permanently, the news is in the dead letters
+)
+
 type News struct {
 	ID            uuid.UUID      `gorm:"primaryKey;type:uuid;not null;" json:"id"`  // ID of the news (UUID)
 	Hash          string         `gorm:"size:32;uniqueIndex;not null;" json:"hash"` // MD5 Hash of the news (URL + title + description + date)
 	ChannelID     string         `gorm:"size:64" json:"channel_id"`                 // ID of the channel (chat ID in Telegram)
+	Channel       string         `gorm:"size:64" json:"channel"`                    // Name of the channel the news is routed to (empty for the default channel)
+	JobName       string         `gorm:"size:64;index" json:"job_name"`             // Name of the job that fetched the news (empty for the news saved before)
 	PublicationID string         `gorm:"size:64" json:"publication_id"`             // ID of the publication (message ID in Telegram)
+	Publications  datatypes.JSON `gorm:"" json:"publications"`                      // IDs of the publication in all targets by the target name (e.g. {"discord": "123"})
 	ProviderName  string         `gorm:"size:64" json:"provider_name"`              // Name of the provider (e.g. "Reuters")
 	URL           string         `gorm:"size:512;uniqueIndex;not null;" json:"url"` // URL of the original news
+	ImageURL      string         `gorm:"size:1024" json:"image_url"`                // URL of the main image of the original news (optional)
 	OriginalTitle string         `gorm:"size:512" json:"original_title"`            // Original News title
 	OriginalDesc  string         `gorm:"size:1024" json:"original_desc"`            // Original News description
 	ComposedText  string         `gorm:"size:512" json:"composed_text"`             // Composed text
+	Content       string         `gorm:"type:text" json:"content,omitempty"`        // Main text of the article (optional, see journalist.ContentFetcher)
 	MetaData      datatypes.JSON `gorm:"" json:"meta_data"`                         // Meta data (tickers, markets, hashtags, etc.)
+	State         NewsState      `gorm:"size:16;index" json:"state"`                // Publication state (empty for the news saved before the states were added)
 	IsSuspicious  bool           `gorm:"default:false" json:"is_suspicious"`        // Is the news suspicious (contains keywords that should be checked by human before publishing)
 	IsFiltered    bool           `gorm:"default:false" json:"is_filtered"`          // Is the news filtered out by others service (e.g. Composer.Filter)
-	PublishedAt   time.Time      `gorm:"default:null" json:"published_at"`          // Composed News publication date
+	Priority      int            `gorm:"not null;default:0" json:"priority"`        // Publication priority set by the rules, higher is published first
+	Version       int            `gorm:"not null;default:1" json:"version"`         // Row version, incremented by every update (see NewsDB.Update)
+	Views         int            `gorm:"not null;default:0" json:"views"`           // Views of the publication in the channel, see NewsDB.UpdateStats
+	Forwards      int            `gorm:"not null;default:0" json:"forwards"`        // Forwards of the publication in the channel
+	Reactions     int            `gorm:"not null;default:0" json:"reactions"`       // Reactions of the publication in the channel (all emojis)
+	StatsAt       time.Time      `gorm:"default:null" json:"stats_at"`              // Date the engagement stats were collected (null if never)
+	PublishedAt   time.Time      `gorm:"default:null;index" json:"published_at"`    // Composed News publication date
 	OriginalDate  time.Time      `gorm:"not null" json:"original_date"`             // Original News date
 	CreatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
 	UpdatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
@@ -45,6 +81,14 @@ func (n *News) Validate() error {
 		return newError(errlvl.INFO, errChannelIDTooLong, nil)
 	}
 
+	if len(n.Channel) > 64 {
+		return newError(errlvl.INFO, errChannelTooLong, nil)
+	}
+
+	if len(n.JobName) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
 	if len(n.Hash) > 32 {
 		return newError(errlvl.INFO, errHashTooLong, nil)
 	}
@@ -65,6 +109,10 @@ func (n *News) Validate() error {
 		return newError(errlvl.INFO, errURLTooLong, nil)
 	}
 
+	if len(n.ImageURL) > 1024 {
+		return newError(errlvl.INFO, errImageURLTooLong, nil)
+	}
+
 	if len(n.OriginalTitle) > 512 {
 		return newError(errlvl.INFO, errOriginalTitleTooLong, nil)
 	}
@@ -93,6 +141,7 @@ func (n *News) GenerateHash() {
 func (n *News) BeforeCreate(*gorm.DB) error {
 	// Create UUID ID.
 	n.ID = uuid.New()
+	n.Version = 1
 
 	if len(n.Hash) == 0 {
 		n.GenerateHash()
@@ -110,6 +159,33 @@ func (n *News) BeforeCreate(*gorm.DB) error {
 	return nil
 }
 
+// ToFeedItem converts the published news to the item of the output feed. The composed text is used
+// as the item text, the tickers and hashtags of the meta data are its categories.
+func (n *News) ToFeedItem() publisher.FeedItem {
+	item := publisher.FeedItem{
+		ID:        n.Hash,
+		Title:     n.OriginalTitle,
+		Text:      n.ComposedText,
+		Link:      n.URL,
+		Published: n.PublishedAt,
+	}
+	if item.Text == "" {
+		item.Text = n.OriginalDesc
+	}
+
+	var meta composer.ComposedMeta
+	if n.MetaData != nil && json.Unmarshal(n.MetaData, &meta) == nil {
+		for _, t := range meta.Tickers {
+			item.Categories = append(item.Categories, strings.TrimPrefix(t, "$"))
+		}
+		for _, h := range meta.Hashtags {
+			item.Categories = append(item.Categories, strings.TrimPrefix(h, "#"))
+		}
+	}
+
+	return item
+}
+
 func (n *News) ToHeadline() *composer.Headline {
 	return &composer.Headline{
 		ID:   n.ID.String(),
@@ -127,10 +203,144 @@ func (db *NewsDB) Create(ctx context.Context, n []*News) error {
 	return nil
 }
 
+// Update updates the news by its hash if it wasn't changed since it was read (the Version matches)
+// and increments its Version. The conflict error is returned if the news was updated by another
+// job or instance in between (see IsNewsConflict), so the stale copy never overwrites the row.
+// News without the Version (e.g. not read from the database) are updated unconditionally.
 func (db *NewsDB) Update(ctx context.Context, n *News) error {
-	res := db.Conn.WithContext(ctx).Where("hash = ?", n.Hash).Updates(n)
+	if err := updateVersioned(db.Conn.WithContext(ctx), n); err != nil {
+		if errors.Is(err, errNewsVersionConflict) {
+			return newError(errlvl.WARN, errNewsVersionConflict, nil)
+		}
+		return newError(errlvl.ERROR, errNewsUpdate, err)
+	}
+
+	return nil
+}
+
+// Upsert inserts the news and returns the inserted ones. News with the hash or the URL that already exists
+// in the DB are skipped (INSERT ... ON CONFLICT DO NOTHING RETURNING hash), so when
+// the overlapping jobs or instances save the same news, only one of them gets it for publication.
+func (db *NewsDB) Upsert(ctx context.Context, n []*News) ([]*News, error) {
+	if len(n) == 0 {
+		return nil, nil
+	}
+
+	var inserted []*News
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range n {
+			// Hooks are called explicitly, so the tickers and entities of the skipped news are not synced
+			if err := item.BeforeCreate(tx); err != nil {
+				return err
+			}
+			res := upsertQuery(tx.Session(&gorm.Session{SkipHooks: true}), item)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				// Saved by another job or instance
+				continue
+			}
+			if err := item.AfterSave(tx.Session(&gorm.Session{NewDB: true})); err != nil {
+				return err
+			}
+			inserted = append(inserted, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, newError(errlvl.ERROR, errNewsUpsert, err)
+	}
+
+	return inserted, nil
+}
+
+// upsertQuery inserts the news if its hash doesn't exist yet, RowsAffected is 0 for the existing one.
+func upsertQuery(tx *gorm.DB, n *News) *gorm.DB {
+	return tx.Clauses(
+		// Without the conflict target, so the news with the same URL but another hash are skipped too
+		clause.OnConflict{DoNothing: true},
+		clause.Returning{Columns: []clause.Column{{Name: "hash"}}},
+	).Create(n)
+}
+
+// IsNewsConflict returns true if the news wasn't updated because it was changed concurrently (see NewsDB.Update).
+func IsNewsConflict(err error) bool {
+	return errors.Is(err, errNewsVersionConflict)
+}
+
+// CreateMany creates the news in a single transaction.
+// News with the hash or the URL that already exists in the DB are skipped (ON CONFLICT DO NOTHING).
+func (db *NewsDB) CreateMany(ctx context.Context, n []*News) error {
+	if len(n) == 0 {
+		return nil
+	}
+
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&n).Error
+	})
+	if err != nil {
+		return newError(errlvl.ERROR, errNewsCreation, err)
+	}
+
+	return nil
+}
+
+// UpdateMany updates the news by their hashes with the version check (see Update) in a single transaction.
+// If any update fails or conflicts, none of the news are updated.
+func (db *NewsDB) UpdateMany(ctx context.Context, n []*News) error {
+	if len(n) == 0 {
+		return nil
+	}
+
+	versions := make([]int, len(n))
+	for i, item := range n {
+		versions[i] = item.Version
+	}
+
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range n {
+			if err := updateVersioned(tx, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// None of the news are updated, so their versions are the same as in the database
+		for i, item := range n {
+			item.Version = versions[i]
+		}
+		if errors.Is(err, errNewsVersionConflict) {
+			return newError(errlvl.WARN, errNewsVersionConflict, nil)
+		}
+		return newError(errlvl.ERROR, errNewsUpdate, err)
+	}
+
+	return nil
+}
+
+// engagementColumns are updated by NewsDB.UpdateStats only, so the stale copy of the news doesn't overwrite the stats.
+var engagementColumns = []string{"Views", "Forwards", "Reactions", "StatsAt"}
+
+// updateVersioned updates the news with the version check and increments its Version.
+// Returns errNewsVersionConflict if the row was changed since the news was read.
+func updateVersioned(tx *gorm.DB, n *News) error {
+	tx = tx.Omit(engagementColumns...)
+	if n.Version == 0 {
+		return tx.Where("hash = ?", n.Hash).Updates(n).Error
+	}
+
+	version := n.Version
+	n.Version++
+	res := tx.Where("hash = ? AND version = ?", n.Hash, version).Updates(n)
 	if res.Error != nil {
-		return newError(errlvl.ERROR, errNewsUpdate, res.Error)
+		n.Version = version
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		n.Version = version
+		return errNewsVersionConflict
 	}
 
 	return nil
@@ -147,6 +357,32 @@ func (db *NewsDB) FindAllByHashes(ctx context.Context, hashes []string) ([]*News
 	return n, nil
 }
 
+// existsBatchSize is the max number of the hashes checked by one query of ExistsByHashes.
+const existsBatchSize = 1000
+
+// ExistsByHashes returns the set of the given hashes that are already saved. Only the hash column is read
+// (covered by its unique index), so it's much cheaper than FindAllByHashes for the large feeds.
+func (db *NewsDB) ExistsByHashes(ctx context.Context, hashes []string) (map[string]bool, error) {
+	exists := make(map[string]bool)
+	for start := 0; start < len(hashes); start += existsBatchSize {
+		var found []string
+		res := existingHashesQuery(db.Conn.WithContext(ctx), hashes[start:min(start+existsBatchSize, len(hashes))]).Pluck("hash", &found)
+		if res.Error != nil {
+			return nil, newError(errlvl.ERROR, errNewsExistsByHash, res.Error)
+		}
+		for _, h := range found {
+			exists[h] = true
+		}
+	}
+
+	return exists, nil
+}
+
+// existingHashesQuery finds the saved news among the given hashes.
+func existingHashesQuery(tx *gorm.DB, hashes []string) *gorm.DB {
+	return tx.Model(&News{}).Where("hash IN ?", hashes)
+}
+
 // FindAllByUrls finds news by its URL.
 func (db *NewsDB) FindAllByUrls(ctx context.Context, urls []string) ([]*News, error) {
 	var n []*News
@@ -158,6 +394,28 @@ func (db *NewsDB) FindAllByUrls(ctx context.Context, urls []string) ([]*News, er
 	return n, nil
 }
 
+// FindLatestPublished finds the latest published news, the newest first.
+// Retracted news are skipped.
+func (db *NewsDB) FindLatestPublished(ctx context.Context, limit int) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).Scopes(latestPublishedScope(limit)).Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindPublished, res.Error)
+	}
+
+	return n, nil
+}
+
+// latestPublishedScope selects the latest published news. News saved before the states were added have no state.
+func latestPublishedScope(limit int) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("published_at IS NOT NULL").
+			Where("state IN ?", []NewsState{NewsStatePublished, ""}).
+			Order("published_at DESC").
+			Limit(limit)
+	}
+}
+
 // FindAllUntilDate finds all news until the provided published date.
 func (db *NewsDB) FindAllUntilDate(ctx context.Context, until time.Time) ([]*News, error) {
 	var n []*News
@@ -168,3 +426,258 @@ func (db *NewsDB) FindAllUntilDate(ctx context.Context, until time.Time) ([]*New
 
 	return n, nil
 }
+
+// FindAllByStates finds news in the given states created since the given date (oldest first).
+func (db *NewsDB) FindAllByStates(ctx context.Context, states []NewsState, since time.Time) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("state IN ?", states).
+		Where("created_at >= ?", since).
+		Order("created_at").
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindByStates, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindPending finds the pending news of the job created since the given date (oldest first).
+func (db *NewsDB) FindPending(ctx context.Context, jobName string, since time.Time) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("state = ?", NewsStatePending).
+		Where("job_name = ?", jobName).
+		Where("created_at >= ?", since).
+		Order("created_at").
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindPending, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindHeld finds the held news of the job to publish to the channel (name, empty for the default one) created since
+// the given date (oldest first). All of them are returned if the limit is not positive.
+func (db *NewsDB) FindHeld(ctx context.Context, jobName, channel string, since time.Time, limit int) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).Scopes(heldScope(jobName, channel, since, limit)).Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindHeld, res.Error)
+	}
+
+	return n, nil
+}
+
+// heldScope selects the held news of the job and the channel, see NewsDB.FindHeld.
+func heldScope(jobName, channel string, since time.Time, limit int) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("state = ?", NewsStateHeld).
+			Where("job_name = ?", jobName).
+			Where("channel = ?", channel).
+			Where("created_at >= ?", since).
+			Order("created_at")
+		if limit > 0 {
+			tx = tx.Limit(limit)
+		}
+		return tx
+	}
+}
+
+// FindQueued finds up to the limit of the queued news of the job created since the given date,
+// ordered by the priority (higher first) and then by the creation date (oldest first).
+func (db *NewsDB) FindQueued(ctx context.Context, jobName string, since time.Time, limit int) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("state = ?", NewsStateQueued).
+		Where("job_name = ?", jobName).
+		Where("created_at >= ?", since).
+		Order("priority DESC").
+		Order("created_at").
+		Limit(limit).
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindQueued, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindInBatches iterates over all news in batches of the given size and calls fn for each batch.
+func (db *NewsDB) FindInBatches(ctx context.Context, batchSize int, fn func(n []*News) error) error {
+	var n []*News
+	res := db.Conn.WithContext(ctx).FindInBatches(&n, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(n)
+	})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errNewsFindInBatches, res.Error)
+	}
+	return nil
+}
+
+// newsSearchDocument is the full-text search document of the news: original title, description and composed text.
+const newsSearchDocument = `to_tsvector('english', coalesce(original_title, '') || ' ' || coalesce(original_desc, '') || ' ' || coalesce(composed_text, ''))`
+
+// NewsSearchFilter narrows down the news search. Zero fields are not applied.
+type NewsSearchFilter struct {
+	Providers  []string  // Provider names
+	From       time.Time // Original date from (inclusive)
+	To         time.Time // Original date to (exclusive)
+	Tickers    []string  // News with any of the tickers in the meta data
+	Entities   []string  // News mentioning any of the entities, e.g. "Fed" or "Powell" (see NewsEntity)
+	Suspicious *bool     // Suspicion flag
+	Limit      int       // Max number of news, 20 if not set
+}
+
+// defaultSearchLimit is the number of the found news if the filter has no limit.
+const defaultSearchLimit = 20
+
+// Search finds the news matching the full-text query (web search syntax, e.g. `inflation -europe "rate cut"`)
+// and the filter. News are ordered by the relevance, the newest first if the query is empty.
+// Requires the news_search migration (search_vector column with the GIN index).
+// Note: SQLite finds the news containing the query as is (case-insensitive), the newest first.
+func (db *NewsDB) Search(ctx context.Context, query string, f NewsSearchFilter) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).Scopes(searchScope(query, f)).Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsSearch, res.Error)
+	}
+
+	return n, nil
+}
+
+// searchScope applies the full-text query and the filter to the news query.
+func searchScope(query string, f NewsSearchFilter) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		order := clause.Expr{SQL: "original_date DESC"}
+		if query != "" && isSQLite(tx) {
+			like := "%" + query + "%"
+			tx = tx.Where("original_title LIKE ? OR original_desc LIKE ? OR composed_text LIKE ?", like, like, like)
+		} else if query != "" {
+			tsQuery := clause.Expr{SQL: "websearch_to_tsquery('english', ?)", Vars: []interface{}{query}}
+			tx = tx.Where("search_vector @@ ?", tsQuery)
+			order = clause.Expr{SQL: "ts_rank(search_vector, ?) DESC, original_date DESC", Vars: []interface{}{tsQuery}}
+		}
+		if len(f.Providers) > 0 {
+			tx = tx.Where("provider_name IN ?", f.Providers)
+		}
+		if !f.From.IsZero() {
+			tx = tx.Where("original_date >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			tx = tx.Where("original_date < ?", f.To)
+		}
+		if len(f.Tickers) > 0 && isSQLite(tx) {
+			tx = tx.Where("EXISTS (SELECT 1 FROM json_each(meta_data, '$.tickers') WHERE value IN ?)", f.Tickers)
+		} else if len(f.Tickers) > 0 {
+			tx = tx.Where(clause.Expr{
+				SQL:                "jsonb_exists_any(meta_data->'tickers', ARRAY[?])",
+				Vars:               []interface{}{f.Tickers},
+				WithoutParentheses: true,
+			})
+		}
+		if len(f.Entities) > 0 {
+			tx = tx.Scopes(entitiesScope(f.Entities))
+		}
+		if f.Suspicious != nil {
+			tx = tx.Where("is_suspicious = ?", *f.Suspicious)
+		}
+
+		limit := f.Limit
+		if limit <= 0 {
+			limit = defaultSearchLimit
+		}
+
+		return tx.Clauses(clause.OrderBy{Expression: order}).Limit(limit)
+	}
+}
+
+// FindForStats finds up to the limit of the news published in the channels since the given date
+// to collect their engagement stats, the ones without stats first and then the least recently collected.
+func (db *NewsDB) FindForStats(ctx context.Context, channelIDs []string, since time.Time, limit int) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).Scopes(forStatsScope(channelIDs, since, limit)).Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindForStats, res.Error)
+	}
+
+	return n, nil
+}
+
+// forStatsScope selects the published news of the channels to collect the engagement stats of, see NewsDB.FindForStats.
+func forStatsScope(channelIDs []string, since time.Time, limit int) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("state IN ?", []NewsState{NewsStatePublished, ""}).
+			Where("channel_id IN ?", channelIDs).
+			Where("publication_id <> ''").
+			Where("published_at >= ?", since).
+			Order("stats_at ASC NULLS FIRST").
+			Limit(limit)
+	}
+}
+
+// UpdateStats saves the engagement stats of the published news by its hash. The Version and UpdatedAt
+// of the news are kept, so the stats collection doesn't conflict with the updates of the jobs.
+func (db *NewsDB) UpdateStats(ctx context.Context, hash string, views, forwards, reactions int) error {
+	res := db.Conn.WithContext(ctx).Where("hash = ?", hash).UpdateColumns(map[string]any{
+		"views":     views,
+		"forwards":  forwards,
+		"reactions": reactions,
+		"stats_at":  time.Now(),
+	})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errNewsUpdateStats, res.Error)
+	}
+
+	return nil
+}
+
+// FindTopEngaged finds up to the limit of the news published since the given date with the most views,
+// e.g. the top posts of the week. News without the collected stats are skipped.
+func (db *NewsDB) FindTopEngaged(ctx context.Context, since time.Time, limit int) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).Scopes(topEngagedScope(since, limit)).Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindTopEngaged, res.Error)
+	}
+
+	return n, nil
+}
+
+// topEngagedScope selects the most viewed news published since the date, see NewsDB.FindTopEngaged.
+func topEngagedScope(since time.Time, limit int) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("state IN ?", []NewsState{NewsStatePublished, ""}).
+			Where("published_at >= ?", since).
+			Where("views > 0").
+			Order("views DESC").
+			Order("reactions DESC").
+			Limit(limit)
+	}
+}
+
+// Prune deletes the not published news created before olderThan and the published ones created before
+// publishedOlderThan, published news are kept forever if publishedOlderThan is zero.
+// Returns the number of deleted news.
+func (db *NewsDB) Prune(ctx context.Context, olderThan, publishedOlderThan time.Time) (int64, error) {
+	res := db.Conn.WithContext(ctx).Scopes(pruneScope(olderThan, publishedOlderThan)).Delete(&News{})
+	if res.Error != nil {
+		return 0, newError(errlvl.ERROR, errNewsPrune, res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
+
+// pruneScope selects the news to delete by the retention cutoffs, see NewsDB.Prune.
+func pruneScope(olderThan, publishedOlderThan time.Time) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if publishedOlderThan.IsZero() {
+			return tx.Where("published_at IS NULL AND created_at < ?", olderThan)
+		}
+		return tx.Where(
+			"(published_at IS NULL AND created_at < ?) OR (published_at IS NOT NULL AND created_at < ?)",
+			olderThan, publishedOlderThan,
+		)
+	}
+}
@@ -0,0 +1,162 @@
+package archivist
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vector is the pgvector column value. It is stored in the pgvector text format: "[1,2,3]".
+type Vector []float32
+
+// Value implements driver.Valuer interface.
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+
+	return sb.String(), nil
+}
+
+// Scan implements sql.Scanner interface.
+func (v *Vector) Scan(src any) error {
+	var s string
+	switch val := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		s = val
+	case []byte:
+		s = string(val)
+	default:
+		return fmt.Errorf("unsupported vector type %T", src)
+	}
+
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	vec := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return fmt.Errorf("failed to parse vector value: %w", err)
+		}
+		vec[i] = float32(f)
+	}
+	*v = vec
+
+	return nil
+}
+
+type NewsEmbeddingsDB struct {
+	Conn *gorm.DB
+}
+
+func NewNewsEmbeddingsDB(db *gorm.DB) *NewsEmbeddingsDB {
+	return &NewsEmbeddingsDB{Conn: db.Table("news_embeddings")}
+}
+
+// NewsEmbedding is the embedding vector of the news title and description, used to find
+// the same story from the different providers. Requires the pgvector extension.
+type NewsEmbedding struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"`  // ID of the row (UUID)
+	Hash      string    `gorm:"size:32;uniqueIndex;not null;" json:"hash"` // Hash of the news (see News.Hash)
+	Embedding Vector    `gorm:"type:vector(1536);not null" json:"-"`       // Embedding of the news title and description
+	Distance  float64   `gorm:"->;-:migration" json:"distance,omitempty"`  // Cosine distance to the searched vector (only in FindSimilar)
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at,omitempty"`
+}
+
+func (e *NewsEmbedding) Validate() error {
+	if len(e.Hash) > 32 {
+		return newError(errlvl.INFO, errHashTooLong, nil)
+	}
+
+	if len(e.Embedding) == 0 {
+		return newError(errlvl.INFO, errEmbeddingEmpty, nil)
+	}
+
+	return nil
+}
+
+func (e *NewsEmbedding) BeforeCreate(*gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	if err := e.Validate(); err != nil {
+		return newError(errlvl.INFO, errNewsEmbeddingValidation, err)
+	}
+
+	return nil
+}
+
+// Create creates the embeddings, embeddings of the already stored news (by hash) are skipped.
+func (db *NewsEmbeddingsDB) Create(ctx context.Context, e []*NewsEmbedding) error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoNothing: true,
+	}).Create(&e)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errNewsEmbeddingCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindSimilar finds the closest embedding created since the given date with the cosine distance
+// not greater than maxDistance. Returns nil if there is no such embedding.
+func (db *NewsEmbeddingsDB) FindSimilar(ctx context.Context, v Vector, since time.Time, maxDistance float64) (*NewsEmbedding, error) {
+	var e NewsEmbedding
+	res := db.Conn.WithContext(ctx).
+		Select("id, hash, created_at, embedding <=> ? AS distance", v).
+		Where("created_at >= ?", since).
+		Where("embedding <=> ? <= ?", v, maxDistance).
+		Order(clause.Expr{SQL: "embedding <=> ?", Vars: []any{v}}).
+		Take(&e)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, newError(errlvl.ERROR, errNewsEmbeddingFind, res.Error)
+	}
+
+	return &e, nil
+}
+
+// Prune deletes the embeddings created before olderThan. Old embeddings are not needed for the deduplication
+// of the fresh news. Returns the number of deleted embeddings.
+func (db *NewsEmbeddingsDB) Prune(ctx context.Context, olderThan time.Time) (int64, error) {
+	res := db.Conn.WithContext(ctx).Where("created_at < ?", olderThan).Delete(&NewsEmbedding{})
+	if res.Error != nil {
+		return 0, newError(errlvl.ERROR, errNewsEmbeddingPrune, res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,92 @@
+package archivist
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewsEmbedding_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		embedding NewsEmbedding
+		wantErr   bool
+	}{
+		{
+			name:      "valid embedding",
+			embedding: NewsEmbedding{Hash: strings.Repeat("a", 32), Embedding: Vector{0.1, 0.2}},
+			wantErr:   false,
+		},
+		{
+			name:      "hash is too long",
+			embedding: NewsEmbedding{Hash: strings.Repeat("a", 33), Embedding: Vector{0.1, 0.2}},
+			wantErr:   true,
+		},
+		{
+			name:      "empty embedding",
+			embedding: NewsEmbedding{Hash: strings.Repeat("a", 32)},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.embedding.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVector_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Vector
+		wantErr bool
+	}{
+		{
+			name: "string",
+			src:  "[0.5,-1,0.25]",
+			want: Vector{0.5, -1, 0.25},
+		},
+		{
+			name: "bytes",
+			src:  []byte("[1, 2]"),
+			want: Vector{1, 2},
+		},
+		{
+			name: "empty",
+			src:  "[]",
+			want: Vector{},
+		},
+		{
+			name:    "invalid value",
+			src:     "[1,a]",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			src:     42,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Vector
+			err := got.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVector_Value(t *testing.T) {
+	got, err := Vector{0.5, -1, 0.25}.Value()
+	if err != nil || got != "[0.5,-1,0.25]" {
+		t.Errorf("Value() = %v, %v", got, err)
+	}
+}
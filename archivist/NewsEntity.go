@@ -0,0 +1,101 @@
+package archivist
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// Kinds of the news entities.
+const (
+	EntityCompany     = "company"
+	EntityPerson      = "person"
+	EntityCentralBank = "central_bank"
+	EntityCountry     = "country"
+)
+
+// maxEntityName is the max length of the saved entity name, longer names are skipped.
+const maxEntityName = 128
+
+// NewsEntity is the named entity (company, person, central bank or country) mentioned in the news.
+// Entities are copied from the news meta data on save, so the news mentioning the entity are found
+// by the index (see NewsSearchFilter.Entities).
+type NewsEntity struct {
+	NewsHash  string `gorm:"primaryKey;size:32;not null" json:"news_hash"` // Hash of the news
+	Kind      string `gorm:"primaryKey;size:16;not null" json:"kind"`      // EntityCompany, EntityPerson, etc.
+	LowerName string `gorm:"primaryKey;size:128;not null;index" json:"-"`  // Lower case name for the search
+	Name      string `gorm:"size:128;not null" json:"name"`                // Name as written, e.g. "Jerome Powell"
+}
+
+// newsEntities returns the unique entities of the news by their kinds.
+func newsEntities(hash string, e *composer.Entities) []*NewsEntity {
+	result := []*NewsEntity{}
+	if e == nil {
+		return result
+	}
+
+	seen := make(map[string]bool)
+	add := func(kind string, names []string) {
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			lower := entityKey(name)
+			if lower == "" || len(name) > maxEntityName || seen[kind+lower] {
+				continue
+			}
+			seen[kind+lower] = true
+			result = append(result, &NewsEntity{NewsHash: hash, Kind: kind, LowerName: lower, Name: name})
+		}
+	}
+	add(EntityCompany, e.Companies)
+	add(EntityPerson, e.People)
+	add(EntityCentralBank, e.CentralBanks)
+	add(EntityCountry, e.Countries)
+
+	return result
+}
+
+// entityKey returns the lower case name of the entity with the single spaces.
+func entityKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// syncNewsEntities replaces the entities of the news with the given ones.
+func syncNewsEntities(tx *gorm.DB, hash string, entities []*NewsEntity) error {
+	if err := tx.Where("news_hash = ?", hash).Delete(&NewsEntity{}).Error; err != nil {
+		return err
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entities).Error
+}
+
+// entitiesScope finds the news mentioning any of the entities: the whole name (case-insensitive)
+// or its last word, so "Powell" finds the news mentioning "Jerome Powell".
+func entitiesScope(entities []string) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		conds := make([]string, 0, len(entities))
+		vars := make([]interface{}, 0, 2*len(entities))
+		for _, e := range entities {
+			key := entityKey(e)
+			if key == "" {
+				continue
+			}
+			conds = append(conds, "news_entities.lower_name = ? OR news_entities.lower_name LIKE ? ESCAPE '\\'")
+			vars = append(vars, key, "% "+escapeLike(key))
+		}
+		if len(conds) == 0 {
+			return tx
+		}
+
+		return tx.Where("EXISTS (SELECT 1 FROM news_entities WHERE news_entities.news_hash = news.hash AND ("+
+			strings.Join(conds, " OR ")+"))", vars...)
+	}
+}
+
+// escapeLike escapes the wildcards of the LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
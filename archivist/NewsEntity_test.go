@@ -0,0 +1,63 @@
+package archivist
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_newsEntities(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities *composer.Entities
+		want     []*NewsEntity
+	}{
+		{
+			name: "entities by kind",
+			entities: &composer.Entities{
+				Companies:    []string{"Apple", " apple ", ""},
+				People:       []string{"Jerome  Powell"},
+				CentralBanks: []string{"Fed"},
+				Countries:    []string{"China", strings.Repeat("x", 129)},
+			},
+			want: []*NewsEntity{
+				{NewsHash: "h1", Kind: EntityCompany, LowerName: "apple", Name: "Apple"},
+				{NewsHash: "h1", Kind: EntityPerson, LowerName: "jerome powell", Name: "Jerome  Powell"},
+				{NewsHash: "h1", Kind: EntityCentralBank, LowerName: "fed", Name: "Fed"},
+				{NewsHash: "h1", Kind: EntityCountry, LowerName: "china", Name: "China"},
+			},
+		},
+		{
+			name: "no entities",
+			want: []*NewsEntity{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newsEntities("h1", tt.entities); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newsEntities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_entitiesScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var n []*News
+		return tx.Table("news").Scopes(entitiesScope([]string{" Powell", "", "100%"})).Find(&n)
+	})
+	want := `SELECT * FROM "news" WHERE EXISTS (SELECT 1 FROM news_entities WHERE news_entities.news_hash = news.hash AND ` +
+		`(news_entities.lower_name = 'powell' OR news_entities.lower_name LIKE '% powell' ESCAPE '\' OR ` +
+		`news_entities.lower_name = '100%' OR news_entities.lower_name LIKE '% 100\%' ESCAPE '\'))`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("entitiesScope() SQL =\n%s\nwant\n%s", got, want)
+	}
+}
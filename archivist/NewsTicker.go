@@ -0,0 +1,124 @@
+package archivist
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// NewsTicker is the ticker mentioned in the news. Tickers are copied from the news meta data on save,
+// so the ticker statistics are computed by the index instead of parsing the JSON of every news.
+type NewsTicker struct {
+	NewsHash string `gorm:"primaryKey;size:32;not null" json:"news_hash"`    // Hash of the news
+	Ticker   string `gorm:"primaryKey;size:16;not null;index" json:"ticker"` // Ticker without the "$", e.g. "AAPL"
+}
+
+// TickerCount is the number of the published news mentioning the ticker.
+type TickerCount struct {
+	Ticker   string `json:"ticker"`
+	Mentions int    `json:"mentions"`
+}
+
+// AfterSave keeps the tickers and entities of the news in sync with its meta data.
+// News updated without the meta data keep their tickers and entities.
+func (n *News) AfterSave(tx *gorm.DB) error {
+	if n.MetaData == nil || n.Hash == "" {
+		return nil
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil {
+		// Meta data of the news is optional, broken one is not the reason to fail the save
+		return nil //nolint:nilerr
+	}
+
+	if err := syncNewsTickers(tx, n.Hash, newsTickers(n.Hash, meta.Tickers)); err != nil {
+		return newError(errlvl.ERROR, errNewsTickersSync, err)
+	}
+	if err := syncNewsEntities(tx, n.Hash, newsEntities(n.Hash, meta.Entities)); err != nil {
+		return newError(errlvl.ERROR, errNewsEntitiesSync, err)
+	}
+
+	return nil
+}
+
+// newsTickers returns the unique normalized tickers of the news.
+func newsTickers(hash string, tickers []string) []*NewsTicker {
+	seen := make(map[string]bool, len(tickers))
+	result := make([]*NewsTicker, 0, len(tickers))
+	for _, t := range tickers {
+		t = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(t, "$")))
+		if t == "" || len(t) > 16 || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, &NewsTicker{NewsHash: hash, Ticker: t})
+	}
+	return result
+}
+
+// syncNewsTickers replaces the tickers of the news with the given ones.
+func syncNewsTickers(tx *gorm.DB, hash string, tickers []*NewsTicker) error {
+	del := tx.Where("news_hash = ?", hash)
+	if len(tickers) > 0 {
+		keep := make([]string, len(tickers))
+		for i, t := range tickers {
+			keep[i] = t.Ticker
+		}
+		del = del.Where("ticker NOT IN ?", keep)
+	}
+	if err := del.Delete(&NewsTicker{}).Error; err != nil {
+		return err
+	}
+	if len(tickers) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tickers).Error
+}
+
+// CountByTickerSince returns the number of the news published since the given time by the mentioned ticker.
+func (db *NewsDB) CountByTickerSince(ctx context.Context, since time.Time) (map[string]int, error) {
+	var counts []*TickerCount
+	res := tickerCountsQuery(db.Conn.WithContext(ctx), since).Find(&counts)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsTickersCount, res.Error)
+	}
+
+	result := make(map[string]int, len(counts))
+	for _, c := range counts {
+		result[c.Ticker] = c.Mentions
+	}
+
+	return result, nil
+}
+
+// TopTickers returns the tickers mentioned in the most news published since the given time,
+// the most mentioned first (alphabetically on ties).
+func (db *NewsDB) TopTickers(ctx context.Context, since time.Time, limit int) ([]*TickerCount, error) {
+	var counts []*TickerCount
+	res := tickerCountsQuery(db.Conn.WithContext(ctx), since).
+		Order("mentions DESC, news_tickers.ticker").
+		Limit(limit).
+		Find(&counts)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsTickersCount, res.Error)
+	}
+
+	return counts, nil
+}
+
+// tickerCountsQuery counts the news published since the given time by the ticker.
+func tickerCountsQuery(tx *gorm.DB, since time.Time) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).
+		Table("news_tickers").
+		Select("news_tickers.ticker, COUNT(*) AS mentions").
+		Joins("JOIN news ON news.hash = news_tickers.news_hash").
+		Where("news.published_at >= ?", since).
+		Group("news_tickers.ticker")
+}
@@ -0,0 +1,57 @@
+package archivist
+
+import (
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_newsTickers(t *testing.T) {
+	tests := []struct {
+		name    string
+		tickers []string
+		want    []*NewsTicker
+	}{
+		{
+			name:    "normalized and deduplicated",
+			tickers: []string{"$AAPL", "aapl", " msft ", "", "$", "TOOLONGTICKERNAME"},
+			want: []*NewsTicker{
+				{NewsHash: "h1", Ticker: "AAPL"},
+				{NewsHash: "h1", Ticker: "MSFT"},
+			},
+		},
+		{
+			name:    "no tickers",
+			tickers: nil,
+			want:    []*NewsTicker{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newsTickers("h1", tt.tickers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newsTickers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tickerCountsQuery(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var counts []*TickerCount
+		return tickerCountsQuery(tx.Table("news"), since).Order("mentions DESC, news_tickers.ticker").Limit(5).Find(&counts)
+	})
+	want := `SELECT news_tickers.ticker, COUNT(*) AS mentions FROM "news_tickers" JOIN news ON news.hash = news_tickers.news_hash ` +
+		`WHERE news.published_at >= '2024-01-01 00:00:00' GROUP BY "news_tickers"."ticker" ORDER BY mentions DESC, news_tickers.ticker LIMIT 5`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("tickerCountsQuery() SQL =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,12 +1,17 @@
 package archivist
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -142,6 +147,32 @@ func TestNews_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Test News Validate - Invalid News (Channel too long)",
+			fields: News{
+				ChannelID:     "testChannel",
+				Channel:       strings.Repeat("a", 65),
+				ProviderName:  "testProvider",
+				URL:           "https://test.com",
+				OriginalTitle: "Test Title",
+				OriginalDesc:  "Test Description",
+				OriginalDate:  time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test News Validate - Invalid News (ImageURL too long)",
+			fields: News{
+				ChannelID:     "testChannel",
+				ProviderName:  "testProvider",
+				URL:           "https://test.com",
+				ImageURL:      "https://test.com/" + strings.Repeat("a", 1024),
+				OriginalTitle: "Test Title",
+				OriginalDesc:  "Test Description",
+				OriginalDate:  time.Now(),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -151,3 +182,414 @@ func TestNews_Validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_searchScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	suspicious := false
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  string
+		filter NewsSearchFilter
+		want   string
+	}{
+		{
+			name: "no query and filters",
+			want: `SELECT * FROM "news" ORDER BY original_date DESC LIMIT 20`,
+		},
+		{
+			name:   "query with filters",
+			query:  "inflation",
+			filter: NewsSearchFilter{Providers: []string{"cnbc"}, From: date, Tickers: []string{"AAPL", "MSFT"}, Suspicious: &suspicious, Limit: 5},
+			want: `SELECT * FROM "news" WHERE search_vector @@ websearch_to_tsquery('english', 'inflation') AND provider_name IN ('cnbc') ` +
+				`AND original_date >= '2024-01-01 00:00:00' AND jsonb_exists_any(meta_data->'tickers', ARRAY['AAPL','MSFT']) ` +
+				`AND is_suspicious = false ORDER BY ts_rank(search_vector, websearch_to_tsquery('english', 'inflation')) DESC, original_date DESC LIMIT 5`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				var n []*News
+				return tx.Table("news").Scopes(searchScope(tt.query, tt.filter)).Find(&n)
+			})
+			if strings.TrimSpace(got) != tt.want {
+				t.Errorf("searchScope() SQL =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pruneScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	olderThan := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	publishedOlderThan := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		publishedOlderThan time.Time
+		want               string
+	}{
+		{
+			name: "keep published news",
+			want: `DELETE FROM "news" WHERE published_at IS NULL AND created_at < '2024-03-01 00:00:00'`,
+		},
+		{
+			name:               "prune published news",
+			publishedOlderThan: publishedOlderThan,
+			want: `DELETE FROM "news" WHERE (published_at IS NULL AND created_at < '2024-03-01 00:00:00') ` +
+				`OR (published_at IS NOT NULL AND created_at < '2023-03-01 00:00:00')`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Table("news").Scopes(pruneScope(olderThan, tt.publishedOlderThan)).Delete(&News{})
+			})
+			if strings.TrimSpace(got) != tt.want {
+				t.Errorf("pruneScope() SQL =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_heldScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{
+			name: "all held news",
+			want: `SELECT * FROM "news" WHERE state = 'held' AND job_name = 'market' AND channel = 'crypto' ` +
+				`AND created_at >= '2024-03-01 00:00:00' ORDER BY created_at`,
+		},
+		{
+			name:  "limited",
+			limit: 5,
+			want: `SELECT * FROM "news" WHERE state = 'held' AND job_name = 'market' AND channel = 'crypto' ` +
+				`AND created_at >= '2024-03-01 00:00:00' ORDER BY created_at LIMIT 5`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				var n []*News
+				return tx.Table("news").Scopes(heldScope("market", "crypto", since, tt.limit)).Find(&n)
+			})
+			if strings.TrimSpace(got) != tt.want {
+				t.Errorf("heldScope() SQL =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_forStatsScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var n []*News
+		return tx.Table("news").Scopes(forStatsScope([]string{"@finthread", "@crypto"}, since, 50)).Find(&n)
+	})
+	want := `SELECT * FROM "news" WHERE state IN ('published','') AND channel_id IN ('@finthread','@crypto') ` +
+		`AND publication_id <> '' AND published_at >= '2024-03-01 00:00:00' ORDER BY stats_at ASC NULLS FIRST LIMIT 50`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("forStatsScope() SQL =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_topEngagedScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var n []*News
+		return tx.Table("news").Scopes(topEngagedScope(since, 5)).Find(&n)
+	})
+	want := `SELECT * FROM "news" WHERE state IN ('published','') AND published_at >= '2024-03-01 00:00:00' ` +
+		`AND views > 0 ORDER BY views DESC,reactions DESC LIMIT 5`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("topEngagedScope() SQL =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_existingHashesQuery(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var found []string
+		return existingHashesQuery(tx, []string{"a1", "b2"}).Pluck("hash", &found)
+	})
+	want := `SELECT "hash" FROM "news" WHERE hash IN ('a1','b2')`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("existingHashesQuery() SQL =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNews_ToFeedItem(t *testing.T) {
+	published := time.Date(2024, 3, 8, 21, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		news *News
+		want publisher.FeedItem
+	}{
+		{
+			name: "composed news",
+			news: &News{
+				Hash:          "abc",
+				URL:           "https://example.com/apple",
+				OriginalTitle: "Apple beats estimates",
+				OriginalDesc:  "Apple reported Q1 results.",
+				ComposedText:  "Apple's Q1 revenue rose 5%.",
+				MetaData:      datatypes.JSON(`{"tickers": ["$AAPL"], "hashtags": ["#earnings"]}`),
+				PublishedAt:   published,
+			},
+			want: publisher.FeedItem{
+				ID:         "abc",
+				Title:      "Apple beats estimates",
+				Text:       "Apple's Q1 revenue rose 5%.",
+				Link:       "https://example.com/apple",
+				Published:  published,
+				Categories: []string{"AAPL", "earnings"},
+			},
+		},
+		{
+			name: "not composed news",
+			news: &News{
+				Hash:          "def",
+				OriginalTitle: "Fed holds rates",
+				OriginalDesc:  "The Fed kept rates unchanged.",
+				PublishedAt:   published,
+			},
+			want: publisher.FeedItem{
+				ID:        "def",
+				Title:     "Fed holds rates",
+				Text:      "The Fed kept rates unchanged.",
+				Published: published,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.news.ToFeedItem(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToFeedItem() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_latestPublishedScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var n []*News
+		return tx.Table("news").Scopes(latestPublishedScope(50)).Find(&n)
+	})
+	want := `SELECT * FROM "news" WHERE published_at IS NOT NULL AND state IN ('published','') ORDER BY published_at DESC LIMIT 50`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("latestPublishedScope() SQL =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNewsDB_UpsertAndUpdate(t *testing.T) {
+	a, err := NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	first := []*News{
+		{URL: "https://example.com/apple", OriginalTitle: "Apple beats estimates", OriginalDate: now, PublishedAt: now, MetaData: datatypes.JSON(`{"tickers": ["AAPL"]}`)},
+	}
+	inserted, err := a.Entities.News.Upsert(ctx, first)
+	if err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if len(inserted) != 1 || inserted[0].Version != 1 {
+		t.Fatalf("Upsert() = %v, want the news inserted with version 1", inserted)
+	}
+
+	// The second instance saves the same news and a new one
+	second := []*News{
+		{URL: "https://example.com/apple", OriginalTitle: "Apple beats estimates", OriginalDate: now, MetaData: datatypes.JSON(`{"tickers": ["MSFT"]}`)},
+		{URL: "https://example.com/fed", OriginalTitle: "Fed holds rates", OriginalDate: now},
+	}
+	inserted, err = a.Entities.News.Upsert(ctx, second)
+	if err != nil {
+		t.Fatalf("Upsert() second error = %v", err)
+	}
+	if len(inserted) != 1 || inserted[0].URL != "https://example.com/fed" {
+		t.Fatalf("Upsert() second = %v, want only the new news", inserted)
+	}
+	if counts, _ := a.Entities.News.CountByTickerSince(ctx, time.Time{}); counts["AAPL"] != 1 || counts["MSFT"] != 0 {
+		t.Errorf("CountByTickerSince() = %v, want only the tickers of the inserted news", counts)
+	}
+
+	found, err := a.Entities.News.FindAllByHashes(ctx, []string{first[0].Hash})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("FindAllByHashes() = %v, %v", found, err)
+	}
+	stale := *found[0]
+
+	first[0].State = NewsStatePublished
+	if err := a.Entities.News.Update(ctx, first[0]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if first[0].Version != 2 {
+		t.Errorf("Update() version = %d, want 2", first[0].Version)
+	}
+
+	stale.State = NewsStateQueued
+	if err := a.Entities.News.Update(ctx, &stale); !IsNewsConflict(err) {
+		t.Fatalf("Update() of the stale news error = %v, want conflict", err)
+	}
+	if stale.Version != 1 {
+		t.Errorf("Update() of the stale news version = %d, want unchanged 1", stale.Version)
+	}
+
+	inserted[0].State = NewsStateQueued
+	if err := a.Entities.News.UpdateMany(ctx, []*News{inserted[0], &stale}); !IsNewsConflict(err) {
+		t.Fatalf("UpdateMany() with the stale news error = %v, want conflict", err)
+	}
+	if inserted[0].Version != 1 {
+		t.Errorf("UpdateMany() version = %d, want unchanged 1 after rollback", inserted[0].Version)
+	}
+
+	found, _ = a.Entities.News.FindAllByHashes(ctx, []string{first[0].Hash, inserted[0].Hash})
+	for _, n := range found {
+		if n.Hash == first[0].Hash && (n.State != NewsStatePublished || n.Version != 2) {
+			t.Errorf("news %s = %s v%d, want published v2", n.URL, n.State, n.Version)
+		}
+		if n.Hash == inserted[0].Hash && (n.State != NewsStateSaved && n.State != "" || n.Version != 1) {
+			t.Errorf("news %s = %s v%d, want unchanged", n.URL, n.State, n.Version)
+		}
+	}
+}
+
+func TestNewsDB_Upsert_sameURL(t *testing.T) {
+	a, err := NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	// Two providers link the same article with different titles, so the hashes differ
+	news := []*News{
+		{URL: "https://example.com/apple", OriginalTitle: "Apple beats estimates", OriginalDate: now},
+		{URL: "https://example.com/apple", OriginalTitle: "Apple Q3 earnings beat", OriginalDate: now},
+		{URL: "https://example.com/fed", OriginalTitle: "Fed holds rates", OriginalDate: now},
+	}
+	inserted, err := a.Entities.News.Upsert(ctx, news)
+	if err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	var urls []string
+	for _, n := range inserted {
+		urls = append(urls, n.URL)
+	}
+	if want := []string{"https://example.com/apple", "https://example.com/fed"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Upsert() = %v, want %v", urls, want)
+	}
+
+	more := []*News{
+		{URL: "https://example.com/apple", OriginalTitle: "Apple shares jump", OriginalDate: now},
+		{URL: "https://example.com/oil", OriginalTitle: "Oil rises", OriginalDate: now},
+	}
+	if err := a.Entities.News.CreateMany(ctx, more); err != nil {
+		t.Fatalf("CreateMany() error = %v", err)
+	}
+	found, _ := a.Entities.News.FindAllByHashes(ctx, []string{more[0].Hash, more[1].Hash})
+	if len(found) != 1 || found[0].URL != "https://example.com/oil" {
+		t.Errorf("CreateMany() saved %v, want only the news with the new URL", found)
+	}
+}
+
+func TestNewsDB_FindQueued(t *testing.T) {
+	a, err := NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	news := []*News{
+		{URL: "https://example.com/old", OriginalTitle: "Old", OriginalDate: now, JobName: "news", CreatedAt: now.Add(-2 * time.Hour)},
+		{URL: "https://example.com/low", OriginalTitle: "Low", OriginalDate: now, JobName: "news", CreatedAt: now.Add(-time.Hour)},
+		{URL: "https://example.com/high", OriginalTitle: "High", OriginalDate: now, JobName: "news", CreatedAt: now, Priority: 1},
+		{URL: "https://example.com/other", OriginalTitle: "Other", OriginalDate: now, JobName: "other", CreatedAt: now},
+		{URL: "https://example.com/saved", OriginalTitle: "Saved", OriginalDate: now, JobName: "news", CreatedAt: now},
+	}
+	for _, n := range news[:4] {
+		n.State = NewsStateQueued
+	}
+	if _, err := a.Entities.News.Upsert(ctx, news); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	found, err := a.Entities.News.FindQueued(ctx, "news", now.Add(-90*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("FindQueued() error = %v", err)
+	}
+	var urls []string
+	for _, n := range found {
+		urls = append(urls, n.URL)
+	}
+	if want := []string{"https://example.com/high", "https://example.com/low"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("FindQueued() = %v, want %v", urls, want)
+	}
+
+	found, _ = a.Entities.News.FindQueued(ctx, "news", time.Time{}, 1)
+	if len(found) != 1 || found[0].URL != "https://example.com/high" {
+		t.Errorf("FindQueued() with the limit = %v, want only the high priority news", found)
+	}
+}
+
+func Test_upsertQuery(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return upsertQuery(tx.Table("news"), &News{Hash: "abc", URL: "https://example.com", OriginalDate: time.Now()})
+	})
+	if want := `ON CONFLICT DO NOTHING RETURNING "hash"`; !strings.HasSuffix(strings.TrimSpace(got), want) {
+		t.Errorf("upsertQuery() SQL =\n%s\nwant suffix\n%s", got, want)
+	}
+}
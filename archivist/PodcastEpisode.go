@@ -0,0 +1,106 @@
+package archivist
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PodcastEpisodesDB struct {
+	Conn *gorm.DB
+}
+
+func NewPodcastEpisodesDB(db *gorm.DB) *PodcastEpisodesDB {
+	return &PodcastEpisodesDB{Conn: db}
+}
+
+// PodcastEpisode is the daily audio digest with its script and MP3 audio.
+type PodcastEpisode struct {
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"` // ID of the episode (UUID)
+	Title         string    `gorm:"size:256;not null" json:"title"`           // Episode title
+	Script        string    `gorm:"type:text" json:"script"`                  // Text of the episode used for the speech
+	Audio         []byte    `gorm:"type:bytea" json:"-"`                      // MP3 audio of the episode
+	AudioSize     int64     `gorm:"not null;default:0" json:"audio_size"`     // Size of the audio in bytes
+	PublicationID string    `gorm:"size:64" json:"publication_id"`            // ID of the publication in the channel
+	PublishedAt   time.Time `gorm:"not null;index" json:"published_at"`       // Time when the episode was published
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (p *PodcastEpisode) Validate() error {
+	if p.Title == "" {
+		return newError(errlvl.INFO, errTitleEmpty, nil)
+	}
+
+	if len(p.Title) > 256 {
+		return newError(errlvl.INFO, errTitleTooLong, nil)
+	}
+
+	if len(p.PublicationID) > 64 {
+		return newError(errlvl.INFO, errPubIDTooLong, nil)
+	}
+
+	if len(p.Audio) == 0 {
+		return newError(errlvl.INFO, errAudioEmpty, nil)
+	}
+
+	return nil
+}
+
+func (p *PodcastEpisode) BeforeCreate(*gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	if p.PublishedAt.IsZero() {
+		p.PublishedAt = time.Now().UTC()
+	}
+	p.AudioSize = int64(len(p.Audio))
+
+	if err := p.Validate(); err != nil {
+		return newError(errlvl.INFO, errPodcastEpisodeValidation, err)
+	}
+
+	return nil
+}
+
+func (db *PodcastEpisodesDB) Create(ctx context.Context, p *PodcastEpisode) error {
+	res := db.Conn.WithContext(ctx).Create(p)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errPodcastEpisodeCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindLatest returns the latest episodes without the audio (for the feed), newest first.
+func (db *PodcastEpisodesDB) FindLatest(ctx context.Context, limit int) ([]*PodcastEpisode, error) {
+	var episodes []*PodcastEpisode
+	res := db.Conn.
+		WithContext(ctx).
+		Omit("audio").
+		Order("published_at DESC").
+		Limit(limit).
+		Find(&episodes)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errPodcastEpisodeFind, res.Error)
+	}
+
+	return episodes, nil
+}
+
+// FindByID returns the episode with the audio. Returns nil if the episode doesn't exist.
+func (db *PodcastEpisodesDB) FindByID(ctx context.Context, id uuid.UUID) (*PodcastEpisode, error) {
+	var episode PodcastEpisode
+	res := db.Conn.WithContext(ctx).Where("id = ?", id).First(&episode)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil
+	}
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errPodcastEpisodeFind, res.Error)
+	}
+
+	return &episode, nil
+}
@@ -0,0 +1,47 @@
+package archivist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPodcastEpisode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		episode PodcastEpisode
+		wantErr bool
+	}{
+		{
+			name:    "valid episode",
+			episode: PodcastEpisode{Title: "Daily digest", Audio: []byte("mp3")},
+			wantErr: false,
+		},
+		{
+			name:    "empty title",
+			episode: PodcastEpisode{Audio: []byte("mp3")},
+			wantErr: true,
+		},
+		{
+			name:    "title is too long",
+			episode: PodcastEpisode{Title: strings.Repeat("a", 257), Audio: []byte("mp3")},
+			wantErr: true,
+		},
+		{
+			name:    "publication id is too long",
+			episode: PodcastEpisode{Title: "Daily digest", PublicationID: strings.Repeat("1", 65), Audio: []byte("mp3")},
+			wantErr: true,
+		},
+		{
+			name:    "empty audio",
+			episode: PodcastEpisode{Title: "Daily digest"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.episode.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
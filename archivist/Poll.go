@@ -0,0 +1,143 @@
+package archivist
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PollsDB struct {
+	Conn *gorm.DB
+}
+
+func NewPollsDB(db *gorm.DB) *PollsDB {
+	return &PollsDB{Conn: db}
+}
+
+// Poll is the engagement poll published to the channel (e.g. the daily market mood).
+// The poll is open until it is closed with its final votes, so the results can be published as the follow-up post.
+type Poll struct {
+	ID            uuid.UUID      `gorm:"primaryKey;type:uuid;not null;" json:"id"`      // ID of the poll (UUID)
+	Name          string         `gorm:"size:64;not null;index" json:"name"`            // Kind of the poll, e.g. "market_mood"
+	ChatID        string         `gorm:"size:64" json:"chat_id"`                        // Chat ID the poll is published to
+	PublicationID string         `gorm:"size:64" json:"publication_id"`                 // ID of the poll message in the channel
+	PollID        string         `gorm:"size:64" json:"poll_id"`                        // Telegram poll ID
+	Question      string         `gorm:"size:300;not null" json:"question"`             // Question of the poll
+	Options       datatypes.JSON `gorm:"" json:"options"`                               // JSON array of the answer options
+	Votes         datatypes.JSON `gorm:"" json:"votes"`                                 // JSON array of the votes by option, null until closed
+	TotalVoters   int            `gorm:"not null;default:0" json:"total_voters"`        // Number of the users voted in the poll
+	IsClosed      bool           `gorm:"not null;default:false;index" json:"is_closed"` // True if the poll is stopped
+	PublishedAt   time.Time      `gorm:"not null;index" json:"published_at"`            // Time when the poll was published
+	ClosedAt      time.Time      `json:"closed_at"`                                     // Time when the poll was stopped
+	CreatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (p *Poll) Validate() error {
+	if p.Name == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(p.Name) > 64 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	if p.Question == "" {
+		return newError(errlvl.INFO, errQuestionEmpty, nil)
+	}
+
+	if len(p.Question) > 300 {
+		return newError(errlvl.INFO, errQuestionTooLong, nil)
+	}
+
+	if len(p.ChatID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	if len(p.PublicationID) > 64 {
+		return newError(errlvl.INFO, errPubIDTooLong, nil)
+	}
+
+	return nil
+}
+
+func (p *Poll) BeforeCreate(*gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	if p.PublishedAt.IsZero() {
+		p.PublishedAt = time.Now().UTC()
+	}
+
+	if err := p.Validate(); err != nil {
+		return newError(errlvl.INFO, errPollValidation, err)
+	}
+
+	return nil
+}
+
+// OptionList returns the answer options of the poll.
+func (p *Poll) OptionList() []string {
+	var options []string
+	if p.Options != nil {
+		_ = json.Unmarshal(p.Options, &options)
+	}
+	return options
+}
+
+// VoteList returns the votes of the closed poll in the order of the options, nil if the poll is open.
+func (p *Poll) VoteList() []int {
+	var votes []int
+	if p.Votes != nil {
+		_ = json.Unmarshal(p.Votes, &votes)
+	}
+	return votes
+}
+
+func (db *PollsDB) Create(ctx context.Context, p *Poll) error {
+	res := db.Conn.WithContext(ctx).Create(p)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errPollCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindOpen returns the open polls of the kind published before the given time, the oldest first.
+func (db *PollsDB) FindOpen(ctx context.Context, name string, before time.Time) ([]*Poll, error) {
+	var polls []*Poll
+	res := db.Conn.
+		WithContext(ctx).
+		Where("name = ? AND is_closed = ? AND published_at < ?", name, false, before).
+		Order("published_at").
+		Find(&polls)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errPollFind, res.Error)
+	}
+
+	return polls, nil
+}
+
+// Close saves the final votes of the poll (in the order of its options) and marks it as closed.
+func (db *PollsDB) Close(ctx context.Context, id uuid.UUID, votes []int, totalVoters int) error {
+	jsonVotes, err := json.Marshal(votes)
+	if err != nil {
+		return newError(errlvl.ERROR, errPollClose, err)
+	}
+
+	res := db.Conn.WithContext(ctx).Model(&Poll{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"votes":        datatypes.JSON(jsonVotes),
+		"total_voters": totalVoters,
+		"is_closed":    true,
+		"closed_at":    time.Now().UTC(),
+	})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errPollClose, res.Error)
+	}
+
+	return nil
+}
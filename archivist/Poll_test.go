@@ -0,0 +1,98 @@
+package archivist
+
+import (
+	"context"
+	"gorm.io/datatypes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoll_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		poll    Poll
+		wantErr bool
+	}{
+		{
+			name:    "valid poll",
+			poll:    Poll{Name: "market_mood", Question: "Mood?", ChatID: "@macro", PublicationID: "42"},
+			wantErr: false,
+		},
+		{
+			name:    "empty name",
+			poll:    Poll{Question: "Mood?"},
+			wantErr: true,
+		},
+		{
+			name:    "empty question",
+			poll:    Poll{Name: "market_mood"},
+			wantErr: true,
+		},
+		{
+			name:    "question is too long",
+			poll:    Poll{Name: "market_mood", Question: strings.Repeat("a", 301)},
+			wantErr: true,
+		},
+		{
+			name:    "publication id is too long",
+			poll:    Poll{Name: "market_mood", Question: "Mood?", PublicationID: strings.Repeat("1", 65)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.poll.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPollsDB_Close(t *testing.T) {
+	a, err := NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	polls := []*Poll{
+		{Name: "market_mood", Question: "Today?", PublishedAt: now.Add(-time.Hour)},
+		{Name: "market_mood", Question: "Yesterday?", PublishedAt: now.Add(-25 * time.Hour), Options: datatypes.JSON(`["Bullish","Bearish"]`)},
+		{Name: "other", Question: "Other?", PublishedAt: now.Add(-25 * time.Hour)},
+	}
+	for _, p := range polls {
+		if err := a.Entities.Polls.Create(ctx, p); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	open, err := a.Entities.Polls.FindOpen(ctx, "market_mood", now)
+	if err != nil || len(open) != 2 || open[0].Question != "Yesterday?" {
+		t.Fatalf("FindOpen() = %v, %v, want 2 polls the oldest first", open, err)
+	}
+	if got := open[0].OptionList(); !reflect.DeepEqual(got, []string{"Bullish", "Bearish"}) {
+		t.Errorf("OptionList() = %v, want the options", got)
+	}
+
+	if err := a.Entities.Polls.Close(ctx, open[0].ID, []int{7, 3}, 10); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	open, err = a.Entities.Polls.FindOpen(ctx, "market_mood", now)
+	if err != nil || len(open) != 1 || open[0].Question != "Today?" {
+		t.Fatalf("FindOpen() = %v, %v, want the open poll only", open, err)
+	}
+
+	var closed Poll
+	if err := a.db.Where("question = ?", "Yesterday?").First(&closed).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if !closed.IsClosed || closed.TotalVoters != 10 || !reflect.DeepEqual(closed.VoteList(), []int{7, 3}) || closed.ClosedAt.IsZero() {
+		t.Errorf("Close() = %+v, want the closed poll with the votes", closed)
+	}
+}
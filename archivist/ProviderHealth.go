@@ -0,0 +1,138 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ProviderHealthDB struct {
+	Conn *gorm.DB
+}
+
+func NewProviderHealthDB(db *gorm.DB) *ProviderHealthDB {
+	return &ProviderHealthDB{Conn: db.Table("provider_health")}
+}
+
+// ProviderHealth holds hourly fetch latency and reliability counters of the news provider.
+type ProviderHealth struct {
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"`                                   // ID of the row (UUID)
+	ProviderName  string    `gorm:"size:64;not null;uniqueIndex:idx_provider_health_hour" json:"provider_name"` // Name of the provider (e.g. "Reuters")
+	Hour          time.Time `gorm:"not null;uniqueIndex:idx_provider_health_hour" json:"hour"`                  // Start of the hour (UTC)
+	Fetches       int64     `gorm:"not null;default:0" json:"fetches"`                                          // Number of fetches
+	Failures      int64     `gorm:"not null;default:0" json:"failures"`                                         // Number of failed fetches
+	LatencySumMs  int64     `gorm:"not null;default:0" json:"latency_sum_ms"`                                   // Sum of the fetch latencies
+	LatencyMaxMs  int64     `gorm:"not null;default:0" json:"latency_max_ms"`                                   // Max fetch latency
+	Status2xx     int64     `gorm:"column:status_2xx;not null;default:0" json:"status_2xx"`                     // Number of 2xx responses
+	Status3xx     int64     `gorm:"column:status_3xx;not null;default:0" json:"status_3xx"`                     // Number of 3xx responses
+	Status4xx     int64     `gorm:"column:status_4xx;not null;default:0" json:"status_4xx"`                     // Number of 4xx responses
+	Status5xx     int64     `gorm:"column:status_5xx;not null;default:0" json:"status_5xx"`                     // Number of 5xx responses
+	StatusNetwork int64     `gorm:"not null;default:0" json:"status_network"`                                   // Number of fetches without response (timeouts, DNS)
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+// TableName overrides the default plural table name.
+func (ProviderHealth) TableName() string {
+	return "provider_health"
+}
+
+func (h *ProviderHealth) Validate() error {
+	if h.ProviderName == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(h.ProviderName) > 64 {
+		return newError(errlvl.INFO, errProviderNameTooLong, nil)
+	}
+
+	return nil
+}
+
+func (h *ProviderHealth) BeforeCreate(*gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+
+	if err := h.Validate(); err != nil {
+		return newError(errlvl.INFO, errProviderHealthValidation, err)
+	}
+
+	return nil
+}
+
+// AddFetch adds the single fetch result to the counters.
+func (h *ProviderHealth) AddFetch(latency time.Duration, statusCode int, failed bool) {
+	ms := latency.Milliseconds()
+	h.Fetches++
+	h.LatencySumMs += ms
+	if ms > h.LatencyMaxMs {
+		h.LatencyMaxMs = ms
+	}
+	if failed {
+		h.Failures++
+	}
+
+	switch {
+	case statusCode >= 500:
+		h.Status5xx++
+	case statusCode >= 400:
+		h.Status4xx++
+	case statusCode >= 300:
+		h.Status3xx++
+	case statusCode >= 200:
+		h.Status2xx++
+	default:
+		h.StatusNetwork++
+	}
+}
+
+// Increment adds the counters to the hourly health of the providers, creating the rows if needed.
+func (db *ProviderHealthDB) Increment(ctx context.Context, health []*ProviderHealth) error {
+	if len(health) == 0 {
+		return nil
+	}
+
+	counters := []string{
+		"fetches", "failures", "latency_sum_ms",
+		"status_2xx", "status_3xx", "status_4xx", "status_5xx", "status_network",
+	}
+	updates := make(map[string]interface{}, len(counters)+2)
+	for _, c := range counters {
+		updates[c] = gorm.Expr("provider_health." + c + " + excluded." + c)
+	}
+	greatest := "GREATEST"
+	if isSQLite(db.Conn) {
+		// Multi-argument MAX of SQLite is the scalar function
+		greatest = "MAX"
+	}
+	updates["latency_max_ms"] = gorm.Expr(greatest + "(provider_health.latency_max_ms, excluded.latency_max_ms)")
+	updates["updated_at"] = gorm.Expr("CURRENT_TIMESTAMP")
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider_name"}, {Name: "hour"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&health)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errProviderHealthIncrement, res.Error)
+	}
+
+	return nil
+}
+
+// FindSince returns the hourly health of all providers since the given time, sorted by provider and hour.
+func (db *ProviderHealthDB) FindSince(ctx context.Context, since time.Time) ([]*ProviderHealth, error) {
+	var h []*ProviderHealth
+	res := db.Conn.WithContext(ctx).
+		Where("hour >= ?", since.UTC().Truncate(time.Hour)).
+		Order("provider_name, hour").
+		Find(&h)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errProviderHealthFind, res.Error)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,57 @@
+package archivist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProviderHealth_AddFetch(t *testing.T) {
+	type fetch struct {
+		latency    time.Duration
+		statusCode int
+		failed     bool
+	}
+	tests := []struct {
+		name    string
+		fetches []fetch
+		want    ProviderHealth
+	}{
+		{
+			name: "mixed results",
+			fetches: []fetch{
+				{latency: 100 * time.Millisecond, statusCode: 200},
+				{latency: 300 * time.Millisecond, statusCode: 503, failed: true},
+				{latency: 5 * time.Second, statusCode: 0, failed: true},
+				{latency: 50 * time.Millisecond, statusCode: 404, failed: true},
+				{latency: 10 * time.Millisecond, statusCode: 304},
+			},
+			want: ProviderHealth{
+				Fetches:       5,
+				Failures:      3,
+				LatencySumMs:  5460,
+				LatencyMaxMs:  5000,
+				Status2xx:     1,
+				Status3xx:     1,
+				Status4xx:     1,
+				Status5xx:     1,
+				StatusNetwork: 1,
+			},
+		},
+		{
+			name:    "no fetches",
+			fetches: nil,
+			want:    ProviderHealth{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ProviderHealth
+			for _, f := range tt.fetches {
+				got.AddFetch(f.latency, f.statusCode, f.failed)
+			}
+			if got != tt.want {
+				t.Errorf("AddFetch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ProviderStatsDB struct {
+	Conn *gorm.DB
+}
+
+func NewProviderStatsDB(db *gorm.DB) *ProviderStatsDB {
+	return &ProviderStatsDB{Conn: db}
+}
+
+// ProviderStat holds daily quality counters of the news provider.
+type ProviderStat struct {
+	ID             uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"`                                // ID of the row (UUID)
+	ProviderName   string    `gorm:"size:64;not null;uniqueIndex:idx_provider_stat_day" json:"provider_name"` // Name of the provider (e.g. "Reuters")
+	Day            time.Time `gorm:"type:date;not null;uniqueIndex:idx_provider_stat_day" json:"day"`         // Day of the counters (UTC)
+	Fetched        int64     `gorm:"not null;default:0" json:"fetched"`                                       // Number of fetched news
+	Duplicates     int64     `gorm:"not null;default:0" json:"duplicates"`                                    // Number of news filtered out as duplicates
+	Suspicious     int64     `gorm:"not null;default:0" json:"suspicious"`                                    // Number of news flagged as suspicious
+	ComposeDropped int64     `gorm:"not null;default:0" json:"compose_dropped"`                               // Number of news dropped by the composer or pre-publish filters
+	Published      int64     `gorm:"not null;default:0" json:"published"`                                     // Number of published news
+	Engagement     int64     `gorm:"not null;default:0" json:"engagement"`                                    // Engagement (reactions, clicks) of the published news
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+func (s *ProviderStat) Validate() error {
+	if s.ProviderName == "" {
+		return newError(errlvl.INFO, errNameEmpty, nil)
+	}
+
+	if len(s.ProviderName) > 64 {
+		return newError(errlvl.INFO, errProviderNameTooLong, nil)
+	}
+
+	return nil
+}
+
+func (s *ProviderStat) BeforeCreate(*gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	if err := s.Validate(); err != nil {
+		return newError(errlvl.INFO, errProviderStatValidation, err)
+	}
+
+	return nil
+}
+
+// Increment adds the counters to the daily stats of the providers, creating the rows if needed.
+func (db *ProviderStatsDB) Increment(ctx context.Context, stats []*ProviderStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	for _, s := range stats {
+		if s.Day.IsZero() {
+			s.Day = time.Now().UTC().Truncate(24 * time.Hour)
+		}
+	}
+
+	counters := []string{"fetched", "duplicates", "suspicious", "compose_dropped", "published", "engagement"}
+	updates := make(map[string]interface{}, len(counters)+1)
+	for _, c := range counters {
+		updates[c] = gorm.Expr("provider_stats." + c + " + excluded." + c)
+	}
+	updates["updated_at"] = gorm.Expr("CURRENT_TIMESTAMP")
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider_name"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&stats)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errProviderStatIncrement, res.Error)
+	}
+
+	return nil
+}
+
+// ProviderQuality is the aggregated quality of the provider for the period.
+type ProviderQuality struct {
+	ProviderName      string  `json:"provider_name"`
+	Fetched           int64   `json:"fetched"`
+	Duplicates        int64   `json:"duplicates"`
+	Suspicious        int64   `json:"suspicious"`
+	ComposeDropped    int64   `json:"compose_dropped"`
+	Published         int64   `json:"published"`
+	Engagement        int64   `json:"engagement"`
+	DuplicateRate     float64 `json:"duplicate_rate" gorm:"-"`      // Duplicates / Fetched
+	SuspiciousRate    float64 `json:"suspicious_rate" gorm:"-"`     // Suspicious / Fetched
+	ComposeDropRate   float64 `json:"compose_drop_rate" gorm:"-"`   // ComposeDropped / (Fetched - Duplicates)
+	EngagementPerPost float64 `json:"engagement_per_post" gorm:"-"` // Engagement / Published
+}
+
+// FindQualitySince returns aggregated quality of all providers since the given day, sorted by name.
+func (db *ProviderStatsDB) FindQualitySince(ctx context.Context, since time.Time) ([]*ProviderQuality, error) {
+	var q []*ProviderQuality
+	res := db.Conn.WithContext(ctx).
+		Model(&ProviderStat{}).
+		Select(`provider_name,
+			SUM(fetched) AS fetched,
+			SUM(duplicates) AS duplicates,
+			SUM(suspicious) AS suspicious,
+			SUM(compose_dropped) AS compose_dropped,
+			SUM(published) AS published,
+			SUM(engagement) AS engagement`).
+		Where("day >= ?", since.UTC().Truncate(24*time.Hour)).
+		Group("provider_name").
+		Order("provider_name").
+		Scan(&q)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errProviderStatFind, res.Error)
+	}
+
+	for _, p := range q {
+		p.calculateRates()
+	}
+
+	return q, nil
+}
+
+// calculateRates fills the relative metrics from the counters.
+func (q *ProviderQuality) calculateRates() {
+	q.DuplicateRate = rate(q.Duplicates, q.Fetched)
+	q.SuspiciousRate = rate(q.Suspicious, q.Fetched)
+	q.ComposeDropRate = rate(q.ComposeDropped, q.Fetched-q.Duplicates)
+	q.EngagementPerPost = rate(q.Engagement, q.Published)
+}
+
+func rate(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,67 @@
+package archivist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProviderStat_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  ProviderStat
+		wantErr bool
+	}{
+		{
+			name:    "valid stat",
+			fields:  ProviderStat{ProviderName: "Reuters"},
+			wantErr: false,
+		},
+		{
+			name:    "empty provider name",
+			fields:  ProviderStat{},
+			wantErr: true,
+		},
+		{
+			name:    "long provider name",
+			fields:  ProviderStat{ProviderName: strings.Repeat("a", 65)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fields.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderQuality_calculateRates(t *testing.T) {
+	tests := []struct {
+		name string
+		q    ProviderQuality
+		want ProviderQuality
+	}{
+		{
+			name: "all counters",
+			q:    ProviderQuality{Fetched: 10, Duplicates: 2, Suspicious: 1, ComposeDropped: 4, Published: 4, Engagement: 20},
+			want: ProviderQuality{
+				Fetched: 10, Duplicates: 2, Suspicious: 1, ComposeDropped: 4, Published: 4, Engagement: 20,
+				DuplicateRate: 0.2, SuspiciousRate: 0.1, ComposeDropRate: 0.5, EngagementPerPost: 5,
+			},
+		},
+		{
+			name: "no news fetched",
+			q:    ProviderQuality{},
+			want: ProviderQuality{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.calculateRates()
+			if tt.q != tt.want {
+				t.Errorf("calculateRates() = %+v, want %+v", tt.q, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package archivist
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PublicationKeysDB struct {
+	Conn *gorm.DB
+}
+
+func NewPublicationKeysDB(db *gorm.DB) *PublicationKeysDB {
+	return &PublicationKeysDB{Conn: db}
+}
+
+// PublicationKey is the idempotency key of the news publication to the chat. The key is claimed right before
+// sending the news and completed with the publication ID right after, so the same news is not published twice
+// to the chat by the retried job or by another instance running by accident.
+type PublicationKey struct {
+	Hash          string    `gorm:"primaryKey;size:32" json:"hash"`              // Hash of the news
+	ChatID        string    `gorm:"primaryKey;size:64" json:"chat_id"`           // Chat ID the news is published to
+	PublicationID string    `gorm:"size:64" json:"publication_id"`               // Empty while the publication is in progress or if it was interrupted
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"` // Time the key was claimed
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (k *PublicationKey) Validate() error {
+	if len(k.Hash) > 32 {
+		return newError(errlvl.INFO, errHashTooLong, nil)
+	}
+
+	if len(k.ChatID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	if len(k.PublicationID) > 64 {
+		return newError(errlvl.INFO, errPubIDTooLong, nil)
+	}
+
+	return nil
+}
+
+func (k *PublicationKey) BeforeCreate(*gorm.DB) error {
+	if err := k.Validate(); err != nil {
+		return newError(errlvl.INFO, errPublicationKeyValidation, err)
+	}
+
+	return nil
+}
+
+// Claim takes the key of the news publication to the chat. Returns true if the key is taken by this call.
+// Otherwise, returns the existing key: the completed one has the publication ID, the empty publication ID means
+// the news is being published by another run or its publication was interrupted.
+func (db *PublicationKeysDB) Claim(ctx context.Context, hash, chatID string) (*PublicationKey, bool, error) {
+	key := &PublicationKey{Hash: hash, ChatID: chatID}
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if res.Error != nil {
+		return nil, false, newError(errlvl.ERROR, errPublicationKeyClaim, res.Error)
+	}
+	if res.RowsAffected == 1 {
+		return key, true, nil
+	}
+
+	existing := &PublicationKey{}
+	res = db.Conn.WithContext(ctx).Where("hash = ? AND chat_id = ?", hash, chatID).Take(existing)
+	if res.Error != nil {
+		return nil, false, newError(errlvl.ERROR, errPublicationKeyClaim, res.Error)
+	}
+
+	return existing, false, nil
+}
+
+// Complete saves the publication ID of the claimed key. The published news (optional) is updated
+// in the same transaction, so the publication is persisted right after sending.
+func (db *PublicationKeysDB) Complete(ctx context.Context, hash, chatID, pubID string, n *News) error {
+	if len(pubID) > 64 {
+		return newError(errlvl.INFO, errPublicationKeyComplete, errPubIDTooLong)
+	}
+
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&PublicationKey{}).
+			Where("hash = ? AND chat_id = ?", hash, chatID).
+			Update("publication_id", pubID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errPublicationKeyNotClaimed
+		}
+
+		if n == nil {
+			return nil
+		}
+		return tx.Where("hash = ?", n.Hash).Updates(n).Error
+	})
+	if err != nil {
+		return newError(errlvl.ERROR, errPublicationKeyComplete, err)
+	}
+
+	return nil
+}
+
+// Release deletes the claimed key of the failed publication, so the news can be published again (e.g. by the recovery).
+// Completed keys are not deleted.
+func (db *PublicationKeysDB) Release(ctx context.Context, hash, chatID string) error {
+	res := db.Conn.WithContext(ctx).
+		Where("hash = ? AND chat_id = ? AND publication_id = ''", hash, chatID).
+		Delete(&PublicationKey{})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errPublicationKeyRelease, res.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package archivist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPublicationKey_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  PublicationKey
+		wantErr bool
+	}{
+		{
+			name:    "valid key",
+			fields:  PublicationKey{Hash: "d41d8cd98f00b204e9800998ecf8427e", ChatID: "@my_channel", PublicationID: "42"},
+			wantErr: false,
+		},
+		{
+			name:    "claimed key without publication",
+			fields:  PublicationKey{Hash: "d41d8cd98f00b204e9800998ecf8427e", ChatID: "-1001234567890"},
+			wantErr: false,
+		},
+		{
+			name:    "long hash",
+			fields:  PublicationKey{Hash: strings.Repeat("a", 33), ChatID: "@my_channel"},
+			wantErr: true,
+		},
+		{
+			name:    "long chat id",
+			fields:  PublicationKey{Hash: "d41d8cd98f00b204e9800998ecf8427e", ChatID: strings.Repeat("a", 65)},
+			wantErr: true,
+		},
+		{
+			name:    "long publication id",
+			fields:  PublicationKey{Hash: "d41d8cd98f00b204e9800998ecf8427e", ChatID: "@my_channel", PublicationID: strings.Repeat("1", 65)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fields.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("PublicationKey.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,14 +1,26 @@
 package archivist
 
 import (
+	"context"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"gorm.io/gorm"
 )
 
 // entities is a struct that contains all the entities that Archivist is responsible for.
 type entities struct {
-	News   *NewsDB
-	Events *EventsDB
+	News            *NewsDB
+	Events          *EventsDB
+	Channels        *ChannelsDB
+	KeywordSets     *KeywordSetsDB
+	ProviderStats   *ProviderStatsDB
+	ProviderHealth  *ProviderHealthDB
+	Podcasts        *PodcastEpisodesDB
+	Embeddings      *NewsEmbeddingsDB
+	JobRuns         *JobRunsDB
+	LLMUsage        *LLMUsageDB
+	PublicationKeys *PublicationKeysDB
+	DeadLetters     *DeadLettersDB
+	Polls           *PollsDB
 }
 
 // Archivist is responsible for storing and retrieving data from the database.
@@ -18,26 +30,93 @@ type Archivist struct {
 }
 
 // NewArchivist creates a new Archivist with provided DSN to connect to database.
+// Note: it does not migrate the schema, use Archivist.Migrate or Archivist.Bootstrap for that.
 //
-// DSN is a string in the format of: "user=gorm password=gorm dbname=gorm port=9920 sslmode=disable".
+// DSN is a string in the format of: "user=gorm password=gorm dbname=gorm port=9920 sslmode=disable"
+// or the SQLite database file for the local usage: "sqlite://fin-thread.db" (see IsSQLiteDSN).
 func NewArchivist(dsn string) (*Archivist, error) {
-	conn, err := connectToPG(dsn)
+	conn, err := connect(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	// Migrate the schema automatically for now.
-	// TODO: Add migration tool later.
-	err = conn.AutoMigrate(&News{}, &Event{})
-	if err != nil {
-		return nil, newError(errlvl.FATAL, errFailedMigration, err)
-	}
-
 	return &Archivist{
 		db: conn,
 		Entities: &entities{
-			News:   NewNewsDB(conn),
-			Events: NewEventsDB(conn),
+			News:            NewNewsDB(conn),
+			Events:          NewEventsDB(conn),
+			Channels:        NewChannelsDB(conn),
+			KeywordSets:     NewKeywordSetsDB(conn),
+			ProviderStats:   NewProviderStatsDB(conn),
+			ProviderHealth:  NewProviderHealthDB(conn),
+			Podcasts:        NewPodcastEpisodesDB(conn),
+			Embeddings:      NewNewsEmbeddingsDB(conn),
+			JobRuns:         NewJobRunsDB(conn),
+			LLMUsage:        NewLLMUsageDB(conn),
+			PublicationKeys: NewPublicationKeysDB(conn),
+			DeadLetters:     NewDeadLettersDB(conn),
+			Polls:           NewPollsDB(conn),
 		},
 	}, nil
 }
+
+// Ping checks the database connection.
+func (a *Archivist) Ping(ctx context.Context) error {
+	db, err := a.db.DB()
+	if err != nil {
+		return newError(errlvl.ERROR, errFailedConnection, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return newError(errlvl.ERROR, errFailedConnection, err)
+	}
+
+	return nil
+}
+
+// MigrateEmbeddings enables the pgvector extension and creates the news embeddings table
+// with the HNSW index for the cosine distance. It is separate from Migrate, because the extension
+// has to be available in the database (pgvector >= 0.5.0).
+func (a *Archivist) MigrateEmbeddings() error {
+	if isSQLite(a.db) {
+		return newError(errlvl.FATAL, errFailedMigration, errEmbeddingsUnsupported)
+	}
+
+	if err := a.db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+		return newError(errlvl.FATAL, errFailedMigration, err)
+	}
+
+	if err := a.db.AutoMigrate(&NewsEmbedding{}); err != nil {
+		return newError(errlvl.FATAL, errFailedMigration, err)
+	}
+
+	err := a.db.Exec("CREATE INDEX IF NOT EXISTS idx_news_embeddings_embedding ON news_embeddings USING hnsw (embedding vector_cosine_ops)").Error
+	if err != nil {
+		return newError(errlvl.FATAL, errFailedMigration, err)
+	}
+
+	return nil
+}
+
+// Seed holds the configuration rows that should exist in the fresh database.
+type Seed struct {
+	Channels    []*Channel
+	KeywordSets []*KeywordSet
+}
+
+// Bootstrap migrates the schema and creates the seed configuration rows.
+// Rows that already exist (by name) are left untouched, so it is safe to run it multiple times.
+func (a *Archivist) Bootstrap(ctx context.Context, seed *Seed) error {
+	if err := a.Migrate(); err != nil {
+		return err
+	}
+
+	if seed == nil {
+		return nil
+	}
+
+	if err := a.Entities.Channels.CreateIfNotExists(ctx, seed.Channels); err != nil {
+		return err
+	}
+
+	return a.Entities.KeywordSets.CreateIfNotExists(ctx, seed.KeywordSets)
+}
@@ -3,13 +3,70 @@ package archivist
 import (
 	"fmt"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/glebarez/sqlite"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/pkg/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log/slog"
+	"strings"
 	"time"
 )
 
+// logger is the logger of the database connections.
+var logger = logging.For(logging.ModuleArchivist)
+
+// dialectSQLite is the name of the SQLite dialector, see isSQLite.
+const dialectSQLite = "sqlite"
+
+// IsSQLiteDSN returns true if the DSN is the SQLite database file: "sqlite://<path>" or the SQLite URI "file:<path>".
+func IsSQLiteDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "sqlite:") || strings.HasPrefix(dsn, "file:")
+}
+
+// isSQLite returns true if the connection is the SQLite database. Postgres-only features (full-text search,
+// advisory locks, pgvector) are replaced or skipped for SQLite.
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == dialectSQLite
+}
+
+// connect opens the SQLite database for the SQLite DSN (see IsSQLiteDSN) and the Postgres one otherwise.
+func connect(dsn string) (*gorm.DB, error) {
+	if IsSQLiteDSN(dsn) {
+		return connectToSQLite(dsn)
+	}
+	return connectToPG(dsn)
+}
+
+// connectToSQLite opens the SQLite database file, it is created if not exists.
+func connectToSQLite(dsn string) (*gorm.DB, error) {
+	// SQLite doesn't support DEFAULT in the multi-row inserts (e.g. news without the publication date),
+	// so the rows are inserted one by one
+	conn, err := gorm.Open(sqlite.Open(sqlitePath(dsn)), &gorm.Config{CreateBatchSize: 1})
+	if err != nil {
+		return nil, newError(errlvl.FATAL, errFailedConnection, fmt.Errorf("failed to open SQLite: %w", err))
+	}
+
+	db, err := conn.DB()
+	if err != nil {
+		return nil, newError(errlvl.FATAL, errFailedConnection, err)
+	}
+	// SQLite has the single writer, one connection serializes the writes instead of failing with "database is locked"
+	db.SetMaxOpenConns(1)
+
+	logger.Info("[connectToSQLite] Opened SQLite database")
+	return conn, nil
+}
+
+// sqlitePath returns the path of the database file (or the SQLite URI) from the SQLite DSN,
+// e.g. "sqlite://data/fin.db" -> "data/fin.db", "sqlite:///var/fin.db" -> "/var/fin.db".
+func sqlitePath(dsn string) string {
+	if strings.HasPrefix(dsn, "file:") {
+		return dsn
+	}
+	path := strings.TrimPrefix(dsn, "sqlite:")
+	return strings.TrimPrefix(path, "//")
+}
+
 func connectToPG(dsn string) (*gorm.DB, error) {
 	bf := backoff.NewExponentialBackOff()
 	bf.InitialInterval = 10 * time.Second
@@ -21,10 +78,10 @@ func connectToPG(dsn string) (*gorm.DB, error) {
 			DSN: dsn,
 		}))
 		if err != nil {
-			slog.Info("[connectToPG] Postgres not yet ready...")
+			logger.Info("[connectToPG] Postgres not yet ready...")
 			return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
 		}
-		slog.Info("[connectToPG] Connected to Postgres!")
+		logger.Info("[connectToPG] Connected to Postgres!")
 		return conn, nil
 	}, bf)
 	if err != nil {
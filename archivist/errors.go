@@ -9,30 +9,103 @@ import (
 type archivistError error
 
 var (
-	errChannelIDTooLong     archivistError = errors.New("channel_id is too long")
-	errHashTooLong          archivistError = errors.New("hash is too long")
-	errPubIDTooLong         archivistError = errors.New("publication_id is too long")
-	errProviderNameTooLong  archivistError = errors.New("provider_name is too long")
-	errURLTooLong           archivistError = errors.New("url is too long")
-	errOriginalTitleTooLong archivistError = errors.New("original_title is too long")
-	errOriginalDescTooLong  archivistError = errors.New("original_desc is too long")
-	errComposedTextTooLong  archivistError = errors.New("composed_text is too long")
-	errOriginalDateEmpty    archivistError = errors.New("original_date is empty")
-	errTitleTooLong         archivistError = errors.New("title is too long")
-	errURLEmpty             archivistError = errors.New("url is empty")
-	errEventValidation      archivistError = errors.New("event validation failed")
-	errEventCreation        archivistError = errors.New("event creation failed")
-	errEventUpdate          archivistError = errors.New("event update failed")
-	errFindRecentEvents     archivistError = errors.New("failed to find recent events")
-	errFindUntilEvents      archivistError = errors.New("failed to find events until the given date")
-	errNewsValidation       archivistError = errors.New("news validation failed")
-	errNewsCreation         archivistError = errors.New("news creation failed")
-	errNewsUpdate           archivistError = errors.New("news update failed")
-	errNewsFindAllByHash    archivistError = errors.New("failed to find news by hash")
-	errNewsFindAllByUrls    archivistError = errors.New("failed to find news by urls")
-	errNewsFindUntil        archivistError = errors.New("failed to find news until the given date")
-	errFailedMigration      archivistError = errors.New("failed to migrate schema")
-	errFailedConnection     archivistError = errors.New("failed to connect to database")
+	errChannelIDTooLong         archivistError = errors.New("channel_id is too long")
+	errChannelTooLong           archivistError = errors.New("channel is too long")
+	errHashTooLong              archivistError = errors.New("hash is too long")
+	errHashEmpty                archivistError = errors.New("hash is empty")
+	errPubIDTooLong             archivistError = errors.New("publication_id is too long")
+	errProviderNameTooLong      archivistError = errors.New("provider_name is too long")
+	errURLTooLong               archivistError = errors.New("url is too long")
+	errImageURLTooLong          archivistError = errors.New("image_url is too long")
+	errOriginalTitleTooLong     archivistError = errors.New("original_title is too long")
+	errOriginalDescTooLong      archivistError = errors.New("original_desc is too long")
+	errComposedTextTooLong      archivistError = errors.New("composed_text is too long")
+	errOriginalDateEmpty        archivistError = errors.New("original_date is empty")
+	errTitleTooLong             archivistError = errors.New("title is too long")
+	errURLEmpty                 archivistError = errors.New("url is empty")
+	errEventValidation          archivistError = errors.New("event validation failed")
+	errEventCreation            archivistError = errors.New("event creation failed")
+	errEventUpdate              archivistError = errors.New("event update failed")
+	errFindRecentEvents         archivistError = errors.New("failed to find recent events")
+	errFindUntilEvents          archivistError = errors.New("failed to find events until the given date")
+	errFindEventsInBatches      archivistError = errors.New("failed to find events in batches")
+	errNewsValidation           archivistError = errors.New("news validation failed")
+	errNewsCreation             archivistError = errors.New("news creation failed")
+	errNewsUpdate               archivistError = errors.New("news update failed")
+	errNewsUpsert               archivistError = errors.New("news upsert failed")
+	errNewsVersionConflict      archivistError = errors.New("news was changed concurrently")
+	errNewsFindAllByHash        archivistError = errors.New("failed to find news by hash")
+	errNewsExistsByHash         archivistError = errors.New("failed to check existing news by hash")
+	errNewsFindAllByUrls        archivistError = errors.New("failed to find news by urls")
+	errNewsFindUntil            archivistError = errors.New("failed to find news until the given date")
+	errNewsFindByStates         archivistError = errors.New("failed to find news by states")
+	errNewsFindInBatches        archivistError = errors.New("failed to find news in batches")
+	errNewsSearch               archivistError = errors.New("failed to search news")
+	errNewsPrune                archivistError = errors.New("failed to prune news")
+	errNameEmpty                archivistError = errors.New("name is empty")
+	errNameTooLong              archivistError = errors.New("name is too long")
+	errChannelValidation        archivistError = errors.New("channel validation failed")
+	errChannelCreation          archivistError = errors.New("channel creation failed")
+	errChannelFind              archivistError = errors.New("failed to find channels")
+	errKeywordSetValidation     archivistError = errors.New("keyword set validation failed")
+	errKeywordSetCreation       archivistError = errors.New("keyword set creation failed")
+	errKeywordSetFind           archivistError = errors.New("failed to find keyword set")
+	errProviderStatValidation   archivistError = errors.New("provider stat validation failed")
+	errProviderStatIncrement    archivistError = errors.New("failed to increment provider stats")
+	errProviderStatFind         archivistError = errors.New("failed to find provider stats")
+	errProviderHealthValidation archivistError = errors.New("provider health validation failed")
+	errProviderHealthIncrement  archivistError = errors.New("failed to increment provider health")
+	errProviderHealthFind       archivistError = errors.New("failed to find provider health")
+	errTitleEmpty               archivistError = errors.New("title is empty")
+	errAudioEmpty               archivistError = errors.New("audio is empty")
+	errPodcastEpisodeValidation archivistError = errors.New("podcast episode validation failed")
+	errPodcastEpisodeCreation   archivistError = errors.New("podcast episode creation failed")
+	errPodcastEpisodeFind       archivistError = errors.New("failed to find podcast episodes")
+	errEmbeddingEmpty           archivistError = errors.New("embedding is empty")
+	errNewsEmbeddingValidation  archivistError = errors.New("news embedding validation failed")
+	errNewsEmbeddingCreation    archivistError = errors.New("news embedding creation failed")
+	errNewsEmbeddingFind        archivistError = errors.New("failed to find similar news embeddings")
+	errNewsEmbeddingPrune       archivistError = errors.New("failed to prune news embeddings")
+	errJobRunStartEmpty         archivistError = errors.New("started_at is empty")
+	errJobRunFinishedEarly      archivistError = errors.New("finished_at is before started_at")
+	errJobRunValidation         archivistError = errors.New("job run validation failed")
+	errJobRunCreation           archivistError = errors.New("job run creation failed")
+	errJobRunFind               archivistError = errors.New("failed to find job runs")
+	errLLMUsageDayEmpty         archivistError = errors.New("day is empty")
+	errLLMUsageValidation       archivistError = errors.New("llm usage validation failed")
+	errLLMUsageIncrement        archivistError = errors.New("failed to increment llm usage")
+	errLLMUsageFind             archivistError = errors.New("failed to sum llm usage cost")
+	errPublicationKeyValidation archivistError = errors.New("publication key validation failed")
+	errPublicationKeyClaim      archivistError = errors.New("failed to claim publication key")
+	errPublicationKeyComplete   archivistError = errors.New("failed to complete publication key")
+	errPublicationKeyRelease    archivistError = errors.New("failed to release publication key")
+	errPublicationKeyNotClaimed archivistError = errors.New("publication key is not claimed")
+	errNewsFindPending          archivistError = errors.New("failed to find pending news")
+	errNewsFindQueued           archivistError = errors.New("failed to find queued news")
+	errNewsFindHeld             archivistError = errors.New("failed to find held news")
+	errNewsExport               archivistError = errors.New("failed to export news")
+	errExportFormat             archivistError = errors.New("unknown export format")
+	errNewsFindPublished        archivistError = errors.New("failed to find published news")
+	errNewsFindForStats         archivistError = errors.New("failed to find news for engagement stats")
+	errNewsUpdateStats          archivistError = errors.New("failed to update news engagement stats")
+	errNewsFindTopEngaged       archivistError = errors.New("failed to find top engaged news")
+	errNewsTickersSync          archivistError = errors.New("failed to sync news tickers")
+	errNewsTickersCount         archivistError = errors.New("failed to count news tickers")
+	errNewsEntitiesSync         archivistError = errors.New("failed to sync news entities")
+	errDeadLetterValidation     archivistError = errors.New("dead letter validation failed")
+	errDeadLetterRecord         archivistError = errors.New("failed to record dead letter")
+	errDeadLetterFind           archivistError = errors.New("failed to find dead letters")
+	errDeadLetterDelete         archivistError = errors.New("failed to delete dead letter")
+	errQuestionEmpty            archivistError = errors.New("question is empty")
+	errQuestionTooLong          archivistError = errors.New("question is too long")
+	errPollValidation           archivistError = errors.New("poll validation failed")
+	errPollCreation             archivistError = errors.New("poll creation failed")
+	errPollFind                 archivistError = errors.New("failed to find polls")
+	errPollClose                archivistError = errors.New("failed to close poll")
+	errFailedMigration          archivistError = errors.New("failed to migrate schema")
+	errFailedRollback           archivistError = errors.New("failed to rollback schema migrations")
+	errFailedConnection         archivistError = errors.New("failed to connect to database")
+	errEmbeddingsUnsupported    archivistError = errors.New("news embeddings require Postgres with pgvector")
 )
 
 // newError creates a wrapped error instance with the given errors.
@@ -0,0 +1,280 @@
+package archivist
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/parquet-go/parquet-go"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is the file format of the exported news.
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"     // CSV with the header row, lists are comma-separated in one column
+	ExportParquet ExportFormat = "parquet" // Parquet file with one row group per batch of the news
+)
+
+// exportBatchSize is the number of the news written to the Parquet row group at once.
+const exportBatchSize = 1000
+
+// ExportFilter narrows down the exported news. Zero fields are not applied.
+type ExportFilter struct {
+	From      time.Time   // Original date from (inclusive)
+	To        time.Time   // Original date to (exclusive)
+	Jobs      []string    // Names of the jobs that fetched the news
+	Providers []string    // Provider names
+	States    []NewsState // Publication states, e.g. only the published news
+}
+
+// NewsExportRow is the flat row of the exported news with the composed meta data and the publication stats,
+// so it can be loaded as is into the data frame.
+type NewsExportRow struct {
+	Hash                string     `parquet:"hash"`
+	JobName             string     `parquet:"job_name"`
+	Provider            string     `parquet:"provider"`
+	Channel             string     `parquet:"channel"`
+	URL                 string     `parquet:"url"`
+	Title               string     `parquet:"title"`
+	Description         string     `parquet:"description"`
+	ComposedText        string     `parquet:"composed_text"`
+	State               string     `parquet:"state"`
+	IsSuspicious        bool       `parquet:"is_suspicious"`
+	IsFiltered          bool       `parquet:"is_filtered"`
+	Priority            int64      `parquet:"priority"`
+	Tickers             string     `parquet:"tickers"`  // comma-separated
+	Markets             string     `parquet:"markets"`  // comma-separated
+	Hashtags            string     `parquet:"hashtags"` // comma-separated
+	Entities            string     `parquet:"entities"` // comma-separated names of the companies, people, central banks and countries
+	Sentiment           string     `parquet:"sentiment"`
+	SentimentConfidence float64    `parquet:"sentiment_confidence"`
+	Importance          *int64     `parquet:"importance,optional"` // nil if not rated
+	Sources             int64      `parquet:"sources"`             // number of the sources of the merged story, 0 for the single news
+	Publications        int64      `parquet:"publications"`        // number of the targets the news is published to
+	OriginalDate        time.Time  `parquet:"original_date,timestamp(millisecond)"`
+	CreatedAt           time.Time  `parquet:"created_at,timestamp(millisecond)"`
+	PublishedAt         *time.Time `parquet:"published_at,optional"`          // nil if not published
+	PublishDelaySeconds *float64   `parquet:"publish_delay_seconds,optional"` // from the original date to the publication
+}
+
+// exportColumns are the CSV columns in the order of the NewsExportRow fields, named as the Parquet columns.
+var exportColumns = []string{
+	"hash", "job_name", "provider", "channel", "url", "title", "description", "composed_text", "state",
+	"is_suspicious", "is_filtered", "priority", "tickers", "markets", "hashtags", "entities", "sentiment",
+	"sentiment_confidence", "importance", "sources", "publications", "original_date", "created_at", "published_at",
+	"publish_delay_seconds",
+}
+
+// ToExportRow converts the news to the flat export row.
+func (n *News) ToExportRow() NewsExportRow {
+	row := NewsExportRow{
+		Hash:         n.Hash,
+		JobName:      n.JobName,
+		Provider:     n.ProviderName,
+		Channel:      n.Channel,
+		URL:          n.URL,
+		Title:        n.OriginalTitle,
+		Description:  n.OriginalDesc,
+		ComposedText: n.ComposedText,
+		State:        n.State,
+		IsSuspicious: n.IsSuspicious,
+		IsFiltered:   n.IsFiltered,
+		Priority:     int64(n.Priority),
+		OriginalDate: n.OriginalDate.UTC(),
+		CreatedAt:    n.CreatedAt.UTC(),
+	}
+
+	var meta composer.ComposedMeta
+	if n.MetaData != nil && json.Unmarshal(n.MetaData, &meta) == nil {
+		row.Tickers = strings.Join(meta.Tickers, ",")
+		row.Markets = strings.Join(meta.Markets, ",")
+		row.Hashtags = strings.Join(meta.Hashtags, ",")
+		if meta.Entities != nil {
+			row.Entities = strings.Join(meta.Entities.Names(), ",")
+		}
+		if meta.Sentiment != nil {
+			row.Sentiment = string(meta.Sentiment.Label)
+			row.SentimentConfidence = meta.Sentiment.Confidence
+		}
+		if meta.Importance != nil {
+			importance := int64(*meta.Importance)
+			row.Importance = &importance
+		}
+		row.Sources = int64(len(meta.Sources))
+	}
+
+	var publications map[string]string
+	if n.Publications != nil && json.Unmarshal(n.Publications, &publications) == nil {
+		row.Publications = int64(len(publications))
+	}
+	if row.Publications == 0 && n.PublicationID != "" {
+		row.Publications = 1
+	}
+
+	if !n.PublishedAt.IsZero() {
+		published := n.PublishedAt.UTC()
+		delay := published.Sub(n.OriginalDate).Seconds()
+		row.PublishedAt = &published
+		row.PublishDelaySeconds = &delay
+	}
+
+	return row
+}
+
+// csvRecord returns the CSV record of the row in the order of the exportColumns.
+func (r *NewsExportRow) csvRecord() []string {
+	record := []string{
+		r.Hash, r.JobName, r.Provider, r.Channel, r.URL, r.Title, r.Description, r.ComposedText, r.State,
+		strconv.FormatBool(r.IsSuspicious), strconv.FormatBool(r.IsFiltered), strconv.FormatInt(r.Priority, 10),
+		r.Tickers, r.Markets, r.Hashtags, r.Entities, r.Sentiment,
+		strconv.FormatFloat(r.SentimentConfidence, 'f', -1, 64), "", strconv.FormatInt(r.Sources, 10),
+		strconv.FormatInt(r.Publications, 10), r.OriginalDate.Format(time.RFC3339), r.CreatedAt.Format(time.RFC3339), "", "",
+	}
+	if r.Importance != nil {
+		record[18] = strconv.FormatInt(*r.Importance, 10)
+	}
+	if r.PublishedAt != nil {
+		record[23] = r.PublishedAt.Format(time.RFC3339)
+		record[24] = strconv.FormatFloat(*r.PublishDelaySeconds, 'f', 0, 64)
+	}
+	return record
+}
+
+// exportWriter writes the export rows in the file format.
+type exportWriter interface {
+	Write(rows []NewsExportRow) error
+	Close() error
+}
+
+// csvExportWriter writes the rows as CSV with the header row.
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) (*csvExportWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &csvExportWriter{w: cw}, nil
+}
+
+func (w *csvExportWriter) Write(rows []NewsExportRow) error {
+	for i := range rows {
+		if err := w.w.Write(rows[i].csvRecord()); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+func (w *csvExportWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error() //nolint:wrapcheck
+}
+
+// parquetExportWriter writes the rows as the Parquet file, each batch is flushed as the row group.
+type parquetExportWriter struct {
+	w *parquet.GenericWriter[NewsExportRow]
+}
+
+func (w *parquetExportWriter) Write(rows []NewsExportRow) error {
+	if _, err := w.w.Write(rows); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return w.w.Flush() //nolint:wrapcheck
+}
+
+func (w *parquetExportWriter) Close() error {
+	return w.w.Close() //nolint:wrapcheck
+}
+
+// newExportWriter returns the writer of the rows in the format.
+func newExportWriter(w io.Writer, format ExportFormat) (exportWriter, error) {
+	switch format {
+	case ExportCSV:
+		return newCSVExportWriter(w)
+	case ExportParquet:
+		return &parquetExportWriter{w: parquet.NewGenericWriter[NewsExportRow](w)}, nil
+	default:
+		return nil, errExportFormat
+	}
+}
+
+// Export writes the news matching the filter with their meta data and publication stats to the writer
+// in the format (see NewsExportRow), the oldest first. The news are read in batches, so the whole archive
+// can be exported without loading it into memory. Returns the number of the exported news.
+func (db *NewsDB) Export(ctx context.Context, f ExportFilter, w io.Writer, format ExportFormat) (int, error) {
+	ew, err := newExportWriter(w, format)
+	if err != nil {
+		return 0, newError(errlvl.ERROR, errNewsExport, err)
+	}
+
+	tx := db.Conn.WithContext(ctx).Scopes(exportScope(f))
+	rows, err := tx.Rows()
+	if err != nil {
+		return 0, newError(errlvl.ERROR, errNewsExport, err)
+	}
+	defer rows.Close()
+
+	count := 0
+	batch := make([]NewsExportRow, 0, exportBatchSize)
+	for rows.Next() {
+		var n News
+		if err := tx.ScanRows(rows, &n); err != nil {
+			return count, newError(errlvl.ERROR, errNewsExport, err)
+		}
+		batch = append(batch, n.ToExportRow())
+		if len(batch) == exportBatchSize {
+			if err := ew.Write(batch); err != nil {
+				return count, newError(errlvl.ERROR, errNewsExport, err)
+			}
+			count += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, newError(errlvl.ERROR, errNewsExport, err)
+	}
+
+	if len(batch) > 0 {
+		if err := ew.Write(batch); err != nil {
+			return count, newError(errlvl.ERROR, errNewsExport, err)
+		}
+		count += len(batch)
+	}
+	if err := ew.Close(); err != nil {
+		return count, newError(errlvl.ERROR, errNewsExport, err)
+	}
+
+	return count, nil
+}
+
+// exportScope applies the export filter to the news query, the oldest news first.
+func exportScope(f ExportFilter) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if !f.From.IsZero() {
+			tx = tx.Where("original_date >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			tx = tx.Where("original_date < ?", f.To)
+		}
+		if len(f.Jobs) > 0 {
+			tx = tx.Where("job_name IN ?", f.Jobs)
+		}
+		if len(f.Providers) > 0 {
+			tx = tx.Where("provider_name IN ?", f.Providers)
+		}
+		if len(f.States) > 0 {
+			tx = tx.Where("state IN ?", f.States)
+		}
+		return tx.Order("original_date").Order("id")
+	}
+}
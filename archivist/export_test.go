@@ -0,0 +1,119 @@
+package archivist
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/parquet-go/parquet-go"
+	"gorm.io/datatypes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNews_ToExportRow(t *testing.T) {
+	original := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	published := original.Add(90 * time.Second)
+	importance := int64(80)
+	delay := 90.0
+
+	tests := []struct {
+		name string
+		news *News
+		want NewsExportRow
+	}{
+		{
+			name: "published with meta",
+			news: &News{
+				Hash: "1", JobName: "market", ProviderName: "reuters", URL: "https://example.com/1",
+				OriginalTitle: "Fed cuts rates", ComposedText: "The Fed cut rates.", State: NewsStatePublished,
+				MetaData: datatypes.JSON(`{"tickers":["SPY","QQQ"],"markets":["stocks"],"hashtags":["fed"],` +
+					`"sentiment":{"label":"bullish","confidence":0.8},"importance":80,"entities":{"central_banks":["Fed"]},` +
+					`"sources":[{"name":"reuters"},{"name":"cnbc"}]}`),
+				Publications:  datatypes.JSON(`{"telegram":"10","discord":"20"}`),
+				PublicationID: "10", OriginalDate: original, PublishedAt: published,
+			},
+			want: NewsExportRow{
+				Hash: "1", JobName: "market", Provider: "reuters", URL: "https://example.com/1",
+				Title: "Fed cuts rates", ComposedText: "The Fed cut rates.", State: NewsStatePublished,
+				Tickers: "SPY,QQQ", Markets: "stocks", Hashtags: "fed", Entities: "Fed",
+				Sentiment: "bullish", SentimentConfidence: 0.8, Importance: &importance, Sources: 2,
+				Publications: 2, OriginalDate: original, PublishedAt: &published, PublishDelaySeconds: &delay,
+			},
+		},
+		{
+			name: "saved without meta",
+			news: &News{Hash: "2", URL: "https://example.com/2", State: NewsStateSaved, IsFiltered: true, OriginalDate: original},
+			want: NewsExportRow{Hash: "2", URL: "https://example.com/2", State: NewsStateSaved, IsFiltered: true, OriginalDate: original},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.news.ToExportRow(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToExportRow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewsDB_Export(t *testing.T) {
+	a, err := NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	news := []*News{
+		{URL: "https://example.com/new", OriginalTitle: "New", OriginalDate: now, JobName: "market", State: NewsStatePublished, PublishedAt: now},
+		{URL: "https://example.com/old", OriginalTitle: "Old", OriginalDate: now.Add(-time.Hour), JobName: "market", State: NewsStatePublished, PublishedAt: now},
+		{URL: "https://example.com/saved", OriginalTitle: "Saved", OriginalDate: now, JobName: "market", State: NewsStateSaved},
+		{URL: "https://example.com/other", OriginalTitle: "Other", OriginalDate: now, JobName: "broad", State: NewsStatePublished, PublishedAt: now},
+	}
+	if _, err := a.Entities.News.Upsert(ctx, news); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	filter := ExportFilter{Jobs: []string{"market"}, States: []NewsState{NewsStatePublished}}
+
+	var buf bytes.Buffer
+	count, err := a.Entities.News.Export(ctx, filter, &buf, ExportCSV)
+	if err != nil || count != 2 {
+		t.Fatalf("Export() csv = %d, %v, want 2 news", count, err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv.ReadAll() error = %v", err)
+	}
+	if len(records) != 3 || !reflect.DeepEqual(records[0], exportColumns) {
+		t.Fatalf("Export() csv = %v, want the header and 2 rows", records)
+	}
+	if records[1][5] != "Old" || records[2][5] != "New" || records[1][24] != "3600" {
+		t.Errorf("Export() csv rows = %v, want the oldest first with the publish delay", records[1:])
+	}
+	for _, r := range records {
+		if len(r) != len(exportColumns) {
+			t.Errorf("Export() csv row = %v, want %d columns", r, len(exportColumns))
+		}
+	}
+
+	buf.Reset()
+	count, err = a.Entities.News.Export(ctx, filter, &buf, ExportParquet)
+	if err != nil || count != 2 {
+		t.Fatalf("Export() parquet = %d, %v, want 2 news", count, err)
+	}
+	rows, err := parquet.Read[NewsExportRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("parquet.Read() error = %v", err)
+	}
+	if len(rows) != 2 || rows[0].Title != "Old" || rows[1].Title != "New" || !rows[0].OriginalDate.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Export() parquet = %+v, want the oldest first", rows)
+	}
+
+	if _, err := a.Entities.News.Export(ctx, filter, &buf, "xlsx"); !errors.Is(err, errExportFormat) {
+		t.Errorf("Export() unknown format error = %v, want %v", err, errExportFormat)
+	}
+}
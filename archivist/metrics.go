@@ -0,0 +1,42 @@
+package archivist
+
+import (
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"gorm.io/gorm"
+	"time"
+)
+
+const metricsStartKey = "metrics:start"
+
+// WithMetrics registers the gorm callbacks that emit the latency of the database writes (create, update, delete)
+// tagged with the table and the operation.
+func (a *Archivist) WithMetrics(m metrics.Emitter) *Archivist {
+	before := func(db *gorm.DB) {
+		db.InstanceSet(metricsStartKey, time.Now())
+	}
+	after := func(op string) func(db *gorm.DB) {
+		return func(db *gorm.DB) {
+			v, _ := db.InstanceGet(metricsStartKey)
+			start, ok := v.(time.Time)
+			if !ok {
+				return
+			}
+			status := "ok"
+			if db.Error != nil {
+				status = "error"
+			}
+			m.Timing(metrics.DBLatency, time.Since(start),
+				metrics.T("table", db.Statement.Table), metrics.T("op", op), metrics.T("status", status))
+		}
+	}
+
+	cb := a.db.Callback()
+	_ = cb.Create().Before("gorm:create").Register("metrics:before_create", before)
+	_ = cb.Create().After("gorm:create").Register("metrics:after_create", after("create"))
+	_ = cb.Update().Before("gorm:update").Register("metrics:before_update", before)
+	_ = cb.Update().After("gorm:update").Register("metrics:after_update", after("update"))
+	_ = cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before)
+	_ = cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete"))
+
+	return a
+}
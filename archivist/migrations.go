@@ -0,0 +1,413 @@
+package archivist
+
+import (
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+// migrationLockID is the key of the Postgres advisory lock, so only one instance migrates the schema at a time.
+const migrationLockID = 727_001
+
+// Migration is a versioned schema change. Pending migrations are applied in the version order on startup,
+// Down reverts the migration for the manual rollback.
+//
+// Note: the baseline migration creates the tables from the current models, so the next migrations
+// must be idempotent (e.g. check Migrator().HasColumn before adding a column).
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// schemaMigration is the record of the applied migration.
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:128;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrations is the list of all schema migrations in the version order. Append new ones to the end.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			// Tables of the deployments created before the migrations are updated in place
+			return tx.AutoMigrate(&News{}, &Event{}, &Channel{}, &KeywordSet{}, &ProviderStat{}, &ProviderHealth{}, &PodcastEpisode{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&News{}, &Event{}, &Channel{}, &KeywordSet{}, &ProviderStat{}, &ProviderHealth{}, &PodcastEpisode{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "news_search",
+		Up: func(tx *gorm.DB) error {
+			// SQLite has no full-text search column, the news are searched with LIKE (see searchScope)
+			if isSQLite(tx) {
+				return nil
+			}
+			// Generated column is kept up to date by Postgres, so the news writes don't change
+			if err := tx.Exec(`ALTER TABLE news ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (` +
+				newsSearchDocument + `) STORED`).Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE INDEX IF NOT EXISTS idx_news_search_vector ON news USING GIN (search_vector)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if isSQLite(tx) {
+				return nil
+			}
+			if err := tx.Exec("DROP INDEX IF EXISTS idx_news_search_vector").Error; err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE news DROP COLUMN IF EXISTS search_vector").Error
+		},
+	},
+	{
+		Version: 3,
+		Name:    "job_runs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&JobRun{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&JobRun{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "news_image_url",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&News{}, "ImageURL") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&News{}, "ImageURL")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&News{}, "ImageURL") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&News{}, "ImageURL")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "llm_usage",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&LLMUsage{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&LLMUsage{})
+		},
+	},
+	{
+		Version: 6,
+		Name:    "news_channel",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&News{}, "Channel") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&News{}, "Channel")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&News{}, "Channel") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&News{}, "Channel")
+		},
+	},
+	{
+		Version: 7,
+		Name:    "publication_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&PublicationKey{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&PublicationKey{})
+		},
+	},
+	{
+		Version: 8,
+		Name:    "news_tickers",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&NewsTicker{}); err != nil {
+				return err
+			}
+			if isSQLite(tx) {
+				// SQLite can't add the foreign key to the existing table. Backfill is not needed:
+				// the SQLite databases are created with all migrations applied to the empty tables
+				return tx.Exec(`CREATE TRIGGER IF NOT EXISTS news_tickers_cascade AFTER DELETE ON news ` +
+					`BEGIN DELETE FROM news_tickers WHERE news_hash = OLD.hash; END`).Error
+			}
+			// Tickers of the pruned news are deleted with them
+			if err := tx.Exec(`ALTER TABLE news_tickers DROP CONSTRAINT IF EXISTS fk_news_tickers_news, ` +
+				`ADD CONSTRAINT fk_news_tickers_news FOREIGN KEY (news_hash) REFERENCES news (hash) ON DELETE CASCADE`).Error; err != nil {
+				return err
+			}
+			// Backfill from the meta data of the saved news, the new ones are synced by News.AfterSave
+			return tx.Exec(`INSERT INTO news_tickers (news_hash, ticker) ` +
+				`SELECT DISTINCT hash, upper(ltrim(t, '$')) FROM news, jsonb_array_elements_text(meta_data->'tickers') AS t ` +
+				`WHERE jsonb_typeof(meta_data->'tickers') = 'array' AND length(ltrim(t, '$')) BETWEEN 1 AND 16 ` +
+				`ON CONFLICT DO NOTHING`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if isSQLite(tx) {
+				if err := tx.Exec("DROP TRIGGER IF EXISTS news_tickers_cascade").Error; err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&NewsTicker{})
+		},
+	},
+	{
+		Version: 9,
+		Name:    "news_pending",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"JobName", "Priority"} {
+				if tx.Migrator().HasColumn(&News{}, field) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&News{}, field); err != nil {
+					return err
+				}
+			}
+			if tx.Migrator().HasIndex(&News{}, "JobName") {
+				return nil
+			}
+			return tx.Migrator().CreateIndex(&News{}, "JobName")
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range []string{"JobName", "Priority"} {
+				if !tx.Migrator().HasColumn(&News{}, field) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&News{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 10,
+		Name:    "news_content",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&News{}, "Content") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&News{}, "Content")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&News{}, "Content") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&News{}, "Content")
+		},
+	},
+	{
+		Version: 11,
+		Name:    "dead_letters",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&DeadLetter{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&DeadLetter{})
+		},
+	},
+	{
+		Version: 12,
+		Name:    "news_entities",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&NewsEntity{}); err != nil {
+				return err
+			}
+			// Backfill is not needed: the entities are extracted only by the new versions of the composer
+			if isSQLite(tx) {
+				return tx.Exec(`CREATE TRIGGER IF NOT EXISTS news_entities_cascade AFTER DELETE ON news ` +
+					`BEGIN DELETE FROM news_entities WHERE news_hash = OLD.hash; END`).Error
+			}
+			// Entities of the pruned news are deleted with them
+			return tx.Exec(`ALTER TABLE news_entities DROP CONSTRAINT IF EXISTS fk_news_entities_news, ` +
+				`ADD CONSTRAINT fk_news_entities_news FOREIGN KEY (news_hash) REFERENCES news (hash) ON DELETE CASCADE`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if isSQLite(tx) {
+				if err := tx.Exec("DROP TRIGGER IF EXISTS news_entities_cascade").Error; err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&NewsEntity{})
+		},
+	},
+	{
+		Version: 13,
+		Name:    "news_version",
+		Up: func(tx *gorm.DB) error {
+			// Existing news get the version 1 by the column default
+			if tx.Migrator().HasColumn(&News{}, "Version") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&News{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&News{}, "Version") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&News{}, "Version")
+		},
+	},
+	{
+		Version: 14,
+		Name:    "polls",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&Poll{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&Poll{})
+		},
+	},
+	{
+		Version: 15,
+		Name:    "news_engagement",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range engagementColumns {
+				if tx.Migrator().HasColumn(&News{}, field) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&News{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range engagementColumns {
+				if !tx.Migrator().HasColumn(&News{}, field) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&News{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// Migrate applies the pending schema migrations. All of them are applied in one transaction
+// under the advisory lock, so a failed migration leaves the schema untouched and concurrent
+// instances don't migrate the schema twice.
+func (a *Archivist) Migrate() error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		applied, err := lockMigrations(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range pendingMigrations(migrations, applied) {
+			if err := m.Up(tx); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			if err := tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error; err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return newError(errlvl.FATAL, errFailedMigration, err)
+	}
+
+	return nil
+}
+
+// Rollback reverts the given number of the last applied migrations in one transaction.
+func (a *Archivist) Rollback(steps int) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		applied, err := lockMigrations(tx)
+		if err != nil {
+			return err
+		}
+
+		rollback, err := rollbackMigrations(migrations, applied, steps)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range rollback {
+			if err := m.Down(tx); err != nil {
+				return fmt.Errorf("rollback of migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			if err := tx.Delete(&schemaMigration{Version: m.Version}).Error; err != nil {
+				return fmt.Errorf("failed to delete migration record %d: %w", m.Version, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return newError(errlvl.FATAL, errFailedRollback, err)
+	}
+
+	return nil
+}
+
+// lockMigrations takes the advisory lock until the end of the transaction, creates the migrations table
+// if needed and returns the applied versions. SQLite is locked by the transaction itself.
+func lockMigrations(tx *gorm.DB) ([]int64, error) {
+	if !isSQLite(tx) {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return nil, fmt.Errorf("failed to take migration lock: %w", err)
+		}
+	}
+	if err := tx.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var applied []int64
+	if err := tx.Model(&schemaMigration{}).Order("version").Pluck("version", &applied).Error; err != nil {
+		return nil, fmt.Errorf("failed to find applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// pendingMigrations returns the migrations that are not applied yet in the version order.
+func pendingMigrations(all []Migration, applied []int64) []Migration {
+	var pending []Migration
+	for _, m := range all {
+		if !slices.Contains(applied, m.Version) {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// rollbackMigrations returns the last applied migrations to revert in the reverse version order.
+// Returns an error if the applied migration is unknown (e.g. the database was migrated by the newer version).
+func rollbackMigrations(all []Migration, applied []int64, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	steps = min(steps, len(applied))
+
+	rollback := make([]Migration, 0, steps)
+	for i := len(applied) - 1; i >= len(applied)-steps; i-- {
+		idx := slices.IndexFunc(all, func(m Migration) bool { return m.Version == applied[i] })
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown migration %d", applied[i])
+		}
+		rollback = append(rollback, all[idx])
+	}
+
+	return rollback, nil
+}
@@ -0,0 +1,109 @@
+package archivist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrations_Order(t *testing.T) {
+	for i, m := range migrations {
+		if m.Name == "" || m.Up == nil || m.Down == nil {
+			t.Errorf("migration %d is incomplete", m.Version)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d is out of order after %d", m.Version, migrations[i-1].Version)
+		}
+	}
+}
+
+func Test_pendingMigrations(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	tests := []struct {
+		name    string
+		applied []int64
+		want    []int64
+	}{
+		{
+			name: "fresh database",
+			want: []int64{1, 2, 3},
+		},
+		{
+			name:    "partially migrated",
+			applied: []int64{1},
+			want:    []int64{2, 3},
+		},
+		{
+			name:    "up to date",
+			applied: []int64{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versions(pendingMigrations(all, tt.applied)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pendingMigrations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rollbackMigrations(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	tests := []struct {
+		name    string
+		applied []int64
+		steps   int
+		want    []int64
+		wantErr bool
+	}{
+		{
+			name:    "last migration",
+			applied: []int64{1, 2, 3},
+			steps:   1,
+			want:    []int64{3},
+		},
+		{
+			name:    "in reverse order",
+			applied: []int64{1, 2},
+			steps:   2,
+			want:    []int64{2, 1},
+		},
+		{
+			name:    "more steps than applied",
+			applied: []int64{1},
+			steps:   5,
+			want:    []int64{1},
+		},
+		{
+			name:    "unknown migration",
+			applied: []int64{1, 4},
+			steps:   1,
+			wantErr: true,
+		},
+		{
+			name:    "zero steps",
+			applied: []int64{1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rollbackMigrations(all, tt.applied, tt.steps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rollbackMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(versions(got), tt.want) {
+				t.Errorf("rollbackMigrations() = %v, want %v", versions(got), tt.want)
+			}
+		})
+	}
+}
+
+func versions(ms []Migration) []int64 {
+	var v []int64
+	for _, m := range ms {
+		v = append(v, m.Version)
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+package archivist
+
+import (
+	"gorm.io/gorm"
+)
+
+// Scope limits the news and dead letters of the archivist to the channels of one tenant, so the pipelines
+// of several channels can share one database without recovering or retrying the publications of each other.
+type Scope struct {
+	ChatIDs []string // Chat IDs of the tenant channels, all channels if empty
+	Exclude []string // Chat IDs of the other tenants, skipped by the tenant without its own ChatIDs
+}
+
+// Scoped returns the archivist which news and dead letters are limited to the scope (by news.channel_id and
+// dead_letters.chat_id). Other entities are shared by all tenants. The news hashes and URLs stay unique
+// across the tenants, so the news saved by one tenant is not published by the other.
+func (a *Archivist) Scoped(s Scope) *Archivist {
+	if len(s.ChatIDs) == 0 && len(s.Exclude) == 0 {
+		return a
+	}
+
+	entities := *a.Entities
+	entities.News = NewNewsDB(s.apply(a.db, "channel_id"))
+	entities.DeadLetters = NewDeadLettersDB(s.apply(a.db, "chat_id"))
+
+	return &Archivist{
+		db:       a.db,
+		Entities: &entities,
+	}
+}
+
+// apply returns the connection with the scope conditions on the chat ID column.
+// The session keeps the conditions for every query made from the returned connection.
+func (s Scope) apply(db *gorm.DB, column string) *gorm.DB {
+	tx := db
+	if len(s.ChatIDs) > 0 {
+		tx = tx.Where(column+" IN ?", s.ChatIDs)
+	}
+	if len(s.Exclude) > 0 {
+		tx = tx.Where("("+column+" IS NULL OR "+column+" NOT IN ?)", s.Exclude)
+	}
+	return tx.Session(&gorm.Session{})
+}
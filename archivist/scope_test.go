@@ -0,0 +1,95 @@
+package archivist
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestArchivist_Scoped(t *testing.T) {
+	a, err := NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+	news := []*News{
+		{Hash: "default", ChannelID: "@macro", URL: "https://example.com/1", OriginalTitle: "Default", OriginalDate: time.Now(), State: NewsStatePending},
+		{Hash: "crypto", ChannelID: "-1001", URL: "https://example.com/2", OriginalTitle: "Crypto", OriginalDate: time.Now(), State: NewsStatePending},
+		{Hash: "energy", ChannelID: "-1002", URL: "https://example.com/3", OriginalTitle: "Energy", OriginalDate: time.Now(), State: NewsStatePending},
+	}
+	if err := a.Entities.News.Create(ctx, news); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, n := range news {
+		if err := a.Entities.DeadLetters.Record(ctx, &DeadLetter{Hash: n.Hash, ChatID: n.ChannelID, Error: "failed"}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		scope Scope
+		want  []string
+	}{
+		{
+			name:  "empty scope",
+			scope: Scope{},
+			want:  []string{"crypto", "default", "energy"},
+		},
+		{
+			name:  "tenant channels",
+			scope: Scope{ChatIDs: []string{"-1001"}},
+			want:  []string{"crypto"},
+		},
+		{
+			name:  "excluded tenants",
+			scope: Scope{Exclude: []string{"-1001", "-1002"}},
+			want:  []string{"default"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoped := a.Scoped(tt.scope)
+
+			// Queries are made twice to check that the conditions are not accumulated by the connection
+			for i := 0; i < 2; i++ {
+				found, err := scoped.Entities.News.FindAllByStates(ctx, []NewsState{NewsStatePending}, since)
+				if err != nil {
+					t.Fatalf("FindAllByStates() error = %v", err)
+				}
+				if got := newsHashes(found); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("FindAllByStates() = %v, want %v", got, tt.want)
+				}
+
+				letters, err := scoped.Entities.DeadLetters.FindRetryable(ctx, 5, 10)
+				if err != nil {
+					t.Fatalf("FindRetryable() error = %v", err)
+				}
+				hashes := make([]string, len(letters))
+				for i, l := range letters {
+					hashes[i] = l.Hash
+				}
+				sort.Strings(hashes)
+				if !reflect.DeepEqual(hashes, tt.want) {
+					t.Errorf("FindRetryable() = %v, want %v", hashes, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func newsHashes(news []*News) []string {
+	hashes := make([]string, len(news))
+	for i, n := range news {
+		hashes[i] = n.Hash
+	}
+	sort.Strings(hashes)
+	return hashes
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/journalist"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"time"
+)
+
+// usage is the help of the CLI, the flags of the command are printed by `finfeed <command> -h`.
+const usage = `Usage: finfeed [command] [flags]
+
+Commands:
+  serve        run the scheduler with the news jobs (default)
+  migrate      apply the database schema migrations and exit
+  backfill     fetch the historical news of the provider and save them without publishing
+  replay       publish the saved news again by its hash
+  export       write the archived news to the CSV or Parquet file for the analysis
+  discover     find the feed of the site and print the provider for the jobs config
+  healthcheck  check the /healthz endpoint of the running app (HTTP_ADDR)
+
+Flags of the previous versions (-migrate, -rollback N, -bootstrap, -healthcheck) are still supported.
+`
+
+// command parses the flags of the CLI command and returns its action, so the wrong flags are reported
+// before the configuration is loaded.
+type command func(args []string) (action func(a *App) error, err error)
+
+// commands are the CLI commands by their names, see usage.
+var commands = map[string]command{
+	"serve":    serveCommand,
+	"migrate":  migrateCommand,
+	"backfill": backfillCommand,
+	"replay":   replayCommand,
+	"export":   exportCommand,
+}
+
+// standaloneCommands are the CLI commands that run without the configuration, see commands.
+var standaloneCommands = map[string]func(args []string) error{
+	"discover":    discoverCommand,
+	"healthcheck": healthcheckCommand,
+}
+
+// healthcheckCommand checks the /healthz endpoint of the running app. Used by the container healthcheck,
+// the image has no shell or curl.
+func healthcheckCommand(args []string) error {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return checkHealth(os.Getenv("HTTP_ADDR"))
+}
+
+// discoverCommand finds the feed of the site by its page URL and prints the provider for the jobs config.
+func discoverCommand(args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	pageURL := fs.String("url", "", "URL of the site page, e.g. the homepage (required)")
+	if err := fs.Parse(args); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if *pageURL == "" {
+		return errors.New("-url is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cfg, err := journalist.NewJournalist("discover", nil).Discover(ctx, *pageURL)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	out, err := yaml.Marshal([]map[string]string{{"name": cfg.Name, "url": cfg.URL}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal provider: %w", err)
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+// serveCommand runs the scheduler until the app is stopped.
+func serveCommand(args []string) (func(a *App) error, error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return func(a *App) error {
+		a.start()
+		return nil
+	}, nil
+}
+
+// migrateCommand applies the migrations, reverts the last ones (-rollback N) or bootstraps the database (-bootstrap).
+func migrateCommand(args []string) (func(a *App) error, error) {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	rollback := fs.Int("rollback", 0, "Revert the given number of the last applied schema migrations")
+	bootstrap := fs.Bool("bootstrap", false, "Migrate the database schema and create the seed configuration rows")
+	if err := fs.Parse(args); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if *rollback < 0 {
+		return nil, errors.New("-rollback must be positive")
+	}
+	if *rollback > 0 && *bootstrap {
+		return nil, errors.New("-rollback and -bootstrap can't be used together")
+	}
+
+	return func(a *App) error {
+		switch {
+		case *bootstrap:
+			if err := a.bootstrap(); err != nil {
+				return fmt.Errorf("error bootstrapping database: %w", err)
+			}
+			logger.Info("[main] Database bootstrapped successfully")
+		case *rollback > 0:
+			if err := a.rollback(*rollback); err != nil {
+				return fmt.Errorf("error rolling back database migrations: %w", err)
+			}
+			logger.Info("[main] Database migrations rolled back successfully", "steps", *rollback)
+		default:
+			if err := a.migrate(); err != nil {
+				return fmt.Errorf("error migrating database: %w", err)
+			}
+			logger.Info("[main] Database migrated successfully")
+		}
+		return nil
+	}, nil
+}
+
+// backfillCommand fetches the historical news of the provider and saves them without publishing.
+func backfillCommand(args []string) (func(a *App) error, error) {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	provider := fs.String("provider", "", "Name of the provider from the jobs config (required)")
+	job := fs.String("job", "", "Name of the job with the provider (the first job with the provider by default)")
+	from := fs.String("from", "", "Fetch the news published since the date, e.g. 2024-01-31 or 2024-01-31T15:04:05Z (required)")
+	to := fs.String("to", "", "Skip the news published since the date (optional)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Deadline of the backfill")
+	if err := fs.Parse(args); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if *provider == "" {
+		return nil, errors.New("-provider is required")
+	}
+	fromDate, err := parseDateFlag("from", *from)
+	if err != nil {
+		return nil, err
+	}
+	var toDate time.Time
+	if *to != "" {
+		if toDate, err = parseDateFlag("to", *to); err != nil {
+			return nil, err
+		}
+		if !toDate.After(fromDate) {
+			return nil, errors.New("-to must be after -from")
+		}
+	}
+
+	return func(a *App) error {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		saved, err := a.backfill(ctx, *job, *provider, fromDate, toDate)
+		if err != nil {
+			return fmt.Errorf("error backfilling news: %w", err)
+		}
+		logger.Info("[main] News backfilled successfully", "provider", *provider, "saved", saved)
+		return nil
+	}, nil
+}
+
+// replayCommand publishes the saved news again by its hash.
+func replayCommand(args []string) (func(a *App) error, error) {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	hash := fs.String("hash", "", "Hash of the saved news (required)")
+	job := fs.String("job", "", "Name of the job which options are used (the first job that saves news by default)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if *hash == "" {
+		return nil, errors.New("-hash is required")
+	}
+
+	return func(a *App) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		pubID, err := a.replay(ctx, *job, strings.TrimSpace(*hash))
+		if err != nil {
+			return fmt.Errorf("error replaying news: %w", err)
+		}
+		logger.Info("[main] News replayed successfully", "hash", *hash, "publication_id", pubID)
+		return nil
+	}, nil
+}
+
+// exportCommand writes the archived news to the CSV or Parquet file.
+func exportCommand(args []string) (func(a *App) error, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", string(archivist.ExportCSV), "File format: csv or parquet")
+	out := fs.String("out", "", "Path of the exported file (stdout by default)")
+	from := fs.String("from", "", "Export the news published since the date, e.g. 2024-01-31 or 2024-01-31T15:04:05Z (optional)")
+	to := fs.String("to", "", "Skip the news published since the date (optional)")
+	jobNames := fs.String("job", "", "Comma-separated names of the jobs that fetched the news (all by default)")
+	providers := fs.String("provider", "", "Comma-separated names of the providers (all by default)")
+	states := fs.String("state", "", "Comma-separated publication states, e.g. published (all by default)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Deadline of the export")
+	if err := fs.Parse(args); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if f := archivist.ExportFormat(*format); f != archivist.ExportCSV && f != archivist.ExportParquet {
+		return nil, errors.New("-format must be csv or parquet")
+	}
+	if *format == string(archivist.ExportParquet) && *out == "" {
+		return nil, errors.New("-out is required for parquet")
+	}
+
+	filter := archivist.ExportFilter{
+		Jobs:      splitFlagList(*jobNames),
+		Providers: splitFlagList(*providers),
+		States:    splitFlagList(*states),
+	}
+	var err error
+	if *from != "" {
+		if filter.From, err = parseDateFlag("from", *from); err != nil {
+			return nil, err
+		}
+	}
+	if *to != "" {
+		if filter.To, err = parseDateFlag("to", *to); err != nil {
+			return nil, err
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return nil, errors.New("-to must be after -from")
+	}
+
+	return func(a *App) error {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		w := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return fmt.Errorf("error creating export file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		count, err := a.export(ctx, filter, w, archivist.ExportFormat(*format))
+		if err != nil {
+			return fmt.Errorf("error exporting news: %w", err)
+		}
+		logger.Info("[main] News exported successfully", "format", *format, "news", count)
+		return nil
+	}, nil
+}
+
+// splitFlagList returns the trimmed non-empty items of the comma-separated flag value.
+func splitFlagList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// commandArgs returns the command name and its arguments. The app runs the scheduler if the command is omitted,
+// the flags of the previous versions are mapped to the commands: -migrate, -rollback N, -bootstrap, -healthcheck.
+func commandArgs(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "serve", nil
+	}
+	switch arg := strings.TrimLeft(args[0], "-"); {
+	case arg == "h", arg == "help", arg == "healthcheck", arg == "discover":
+		return arg, args[1:]
+	case arg == "migrate":
+		return "migrate", args[1:]
+	case arg == "bootstrap", strings.HasPrefix(arg, "rollback"):
+		return "migrate", args
+	case strings.HasPrefix(args[0], "-"):
+		return "serve", args
+	default:
+		return args[0], args[1:]
+	}
+}
+
+// parseDateFlag parses the date (2006-01-02, UTC) or the time in RFC 3339 of the required flag.
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("-%s is required", name)
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s must be the date (2006-01-02) or the time in RFC 3339", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,176 @@
+package composer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicURL          = "https://api.anthropic.com/v1/messages"
+	anthropicVersion      = "2023-06-01"
+	anthropicDefaultModel = "claude-3-5-haiku-latest"
+)
+
+// Anthropic is a LLMProvider that creates completions with the Anthropic Claude messages API.
+type Anthropic struct {
+	APIKey  string
+	Model   string
+	URL     string
+	client  *http.Client
+	metrics metrics.Emitter
+	usage   UsageObserver
+}
+
+// NewAnthropic creates new Anthropic client. If model is empty, the default model is used.
+func NewAnthropic(apiKey, model string) *Anthropic {
+	if model == "" {
+		model = anthropicDefaultModel
+	}
+
+	return &Anthropic{
+		APIKey:  apiKey,
+		Model:   model,
+		URL:     anthropicURL,
+		client:  &http.Client{Timeout: 2 * time.Minute},
+		metrics: metrics.Noop{},
+	}
+}
+
+// WithMetrics sets the metrics emitter for the token usage.
+func (a *Anthropic) WithMetrics(m metrics.Emitter) *Anthropic {
+	a.metrics = m
+	return a
+}
+
+// ObserveUsage sets the observer that will receive the token usage of each request.
+func (a *Anthropic) ObserveUsage(u UsageObserver) *Anthropic {
+	a.usage = u
+	return a
+}
+
+// Complete creates a new message with Claude. The system prompt is sent separately from the messages,
+// and the Prefill (if set) is sent as the beginning of the assistant answer and prepended to the result,
+// which keeps Claude from adding any explanations around the JSON.
+//
+// Claude doesn't recommend to alter both temperature and top_p, so only temperature (capped to 1) is used.
+func (a *Anthropic) Complete(ctx context.Context, req LLMRequest) (string, error) {
+	messages := []anthropicMessage{{Role: "user", Content: req.User}}
+	if req.Prefill != "" {
+		messages = append(messages, anthropicMessage{Role: "assistant", Content: req.Prefill})
+	}
+
+	bodyJSON, err := json.Marshal(anthropicRequest{
+		Model:         a.Model,
+		System:        req.System,
+		Messages:      messages,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   min(req.Temperature, 1),
+		StopSequences: req.Stop,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(bodyJSON))
+	if err != nil {
+		return "", newError(
+			fmt.Errorf("error creating request: %w", err),
+			errlvl.ERROR,
+			"Anthropic.Complete",
+			"NewRequestWithContext",
+		)
+	}
+
+	httpReq.Header.Set("x-api-key", a.APIKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return "", newError(
+			fmt.Errorf("error sending request: %w", err),
+			errlvl.ERROR,
+			"Anthropic.Complete",
+			"client.Do",
+		)
+	}
+	defer resp.Body.Close()
+
+	var response anthropicResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return "", newError(
+			fmt.Errorf("error decoding response: %w", err),
+			errlvl.ERROR,
+			"Anthropic.Complete",
+			"json.NewDecoder",
+		)
+	}
+
+	if response.Error != nil {
+		return "", newError(
+			fmt.Errorf("%s: %s", response.Error.Type, response.Error.Message),
+			errlvl.ERROR,
+			"Anthropic.Complete",
+			"response.Error",
+		)
+	}
+
+	recordUsage(a.metrics, a.usage, Usage{
+		Provider:         ProviderAnthropic,
+		Model:            a.Model,
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+	})
+
+	var text strings.Builder
+	for _, c := range response.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("empty response")
+	}
+
+	return req.Prefill + text.String(), nil
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   float32            `json:"temperature"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+}
+
+type anthropicResponse struct {
+	ID      string `json:"id"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
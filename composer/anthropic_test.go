@@ -0,0 +1,93 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAnthropic_Complete(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          LLMRequest
+		response     string
+		wantMessages []anthropicMessage
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "with prefill",
+			req: LLMRequest{
+				System:      "Answer in JSON",
+				User:        `[{"id":"1"}]`,
+				MaxTokens:   2048,
+				Temperature: 1.5,
+				Stop:        []string{"#"},
+				Prefill:     "[",
+			},
+			response: `{"content":[{"type":"text","text":"{\"id\":\"1\"}]"}],"stop_reason":"end_turn"}`,
+			wantMessages: []anthropicMessage{
+				{Role: "user", Content: `[{"id":"1"}]`},
+				{Role: "assistant", Content: "["},
+			},
+			want: `[{"id":"1"}]`,
+		},
+		{
+			name:         "without prefill",
+			req:          LLMRequest{System: "Write a script", User: "[]", MaxTokens: 1200},
+			response:     `{"content":[{"type":"text","text":"Good evening. "},{"type":"text","text":"That's all."}]}`,
+			wantMessages: []anthropicMessage{{Role: "user", Content: "[]"}},
+			want:         "Good evening. That's all.",
+		},
+		{
+			name:         "api error",
+			req:          LLMRequest{System: "Write a script", User: "[]", MaxTokens: 1200},
+			response:     `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			wantMessages: []anthropicMessage{{Role: "user", Content: "[]"}},
+			wantErr:      true,
+		},
+		{
+			name:         "empty content",
+			req:          LLMRequest{System: "Write a script", User: "[]", MaxTokens: 1200},
+			response:     `{"content":[]}`,
+			wantMessages: []anthropicMessage{{Role: "user", Content: "[]"}},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("x-api-key") != "token" || r.Header.Get("anthropic-version") != anthropicVersion {
+					t.Errorf("unexpected headers: %v", r.Header)
+				}
+
+				var body anthropicRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				if body.Model != anthropicDefaultModel || body.System != tt.req.System || body.Temperature > 1 {
+					t.Errorf("unexpected request: %+v", body)
+				}
+				if !reflect.DeepEqual(body.Messages, tt.wantMessages) {
+					t.Errorf("messages = %+v, want %+v", body.Messages, tt.wantMessages)
+				}
+
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			a := NewAnthropic("token", "")
+			a.URL = srv.URL
+			got, err := a.Complete(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Complete() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package composer
+
+import (
+	"github.com/samgozman/fin-thread/journalist"
+	"unicode/utf8"
+)
+
+// composeMetaTokens is the estimated number of the answer tokens per composed news besides its text:
+// ID, tickers, markets, hashtags, sentiment and the JSON syntax.
+const composeMetaTokens = 64
+
+// estimateTokens estimates the number of tokens in the text, ~4 characters per token for English.
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// estimatePromptTokens estimates the number of the prompt tokens of the news in the JSON of the compose request
+// (see journalist.NewsList.ToContentJSON), the article text is counted up to its limit in the prompt.
+func estimatePromptTokens(n *journalist.News) int {
+	jsonNews, err := journalist.NewsList{n}.ToContentJSON()
+	if err != nil {
+		return 0
+	}
+	return estimateTokens(jsonNews)
+}
+
+// estimateComposedTokens estimates the number of the answer tokens of the composed news.
+// The composed text is the rephrased title and description, so it is not longer than the original.
+func estimateComposedTokens(n *journalist.News) int {
+	return estimateTokens(n.Title) + estimateTokens(n.Description) + composeMetaTokens
+}
+
+// batchNews splits the news list into batches, so the estimated prompt news and the answer of each batch fit
+// into maxTokens and the JSON is not truncated. Each batch has at least one news, so the news larger than
+// the budget is sent alone. Returns the whole list as one batch if maxTokens is not positive.
+func batchNews(news journalist.NewsList, maxTokens int) []journalist.NewsList {
+	if len(news) == 0 {
+		return nil
+	}
+	if maxTokens <= 0 {
+		return []journalist.NewsList{news}
+	}
+
+	var batches []journalist.NewsList
+	var batch journalist.NewsList
+	tokens := 0
+	for _, n := range news {
+		t := estimatePromptTokens(n) + estimateComposedTokens(n)
+		if len(batch) > 0 && tokens+t > maxTokens {
+			batches = append(batches, batch)
+			batch, tokens = nil, 0
+		}
+		batch = append(batch, n)
+		tokens += t
+	}
+
+	return append(batches, batch)
+}
@@ -0,0 +1,124 @@
+package composer
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_batchNews(t *testing.T) {
+	newsOfLen := func(id string, chars int) *journalist.News {
+		return &journalist.News{ID: id, Title: strings.Repeat("a", chars)}
+	}
+	// Each news is estimated as 36 tokens of the text + composeMetaTokens = 100 answer tokens
+	// and 47 prompt tokens of its JSON
+	news := journalist.NewsList{newsOfLen("1", 144), newsOfLen("2", 144), newsOfLen("3", 144)}
+	// Article texts are sent up to 3000 runes, so each news is estimated as ~830 tokens
+	articles := journalist.NewsList{
+		{ID: "4", Content: strings.Repeat("b", 10000)},
+		{ID: "5", Content: strings.Repeat("b", 10000)},
+	}
+
+	tests := []struct {
+		name      string
+		news      journalist.NewsList
+		maxTokens int
+		want      [][]string
+	}{
+		{
+			name:      "empty list",
+			news:      nil,
+			maxTokens: 1000,
+			want:      nil,
+		},
+		{
+			name:      "fits into one batch",
+			news:      news,
+			maxTokens: 450,
+			want:      [][]string{{"1", "2", "3"}},
+		},
+		{
+			name:      "split into batches",
+			news:      news,
+			maxTokens: 300,
+			want:      [][]string{{"1", "2"}, {"3"}},
+		},
+		{
+			name:      "prompt tokens counted",
+			news:      news,
+			maxTokens: 250,
+			want:      [][]string{{"1"}, {"2"}, {"3"}},
+		},
+		{
+			name:      "article text counted up to the prompt limit",
+			news:      articles,
+			maxTokens: 2000,
+			want:      [][]string{{"4", "5"}},
+		},
+		{
+			name:      "article texts split into batches",
+			news:      articles,
+			maxTokens: 1000,
+			want:      [][]string{{"4"}, {"5"}},
+		},
+		{
+			name:      "news larger than the budget is sent alone",
+			news:      news,
+			maxTokens: 50,
+			want:      [][]string{{"1"}, {"2"}, {"3"}},
+		},
+		{
+			name:      "no limit",
+			news:      news,
+			maxTokens: 0,
+			want:      [][]string{{"1", "2", "3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchNews(tt.news, tt.maxTokens)
+			if len(got) != len(tt.want) {
+				t.Fatalf("batchNews() returned %d batches, want %d", len(got), len(tt.want))
+			}
+			for i, batch := range got {
+				ids := make([]string, len(batch))
+				for j, n := range batch {
+					ids[j] = n.ID
+				}
+				if strings.Join(ids, ",") != strings.Join(tt.want[i], ",") {
+					t.Errorf("batchNews() batch %d = %v, want %v", i, ids, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComposer_Compose_batches(t *testing.T) {
+	var news journalist.NewsList
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		news = append(news, &journalist.News{ID: id, Title: strings.Repeat("a", 144), Date: time.Now()})
+	}
+
+	mockClient := new(MockOpenAiClient)
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.Anything).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{Message: openai.ChatCompletionMessage{Content: `[{"id":"1","text":"Text"}]`}},
+		},
+	}, nil)
+
+	c := &Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}
+	c.Config.ComposeParams.MaxTokens = 300
+
+	got, err := c.Compose(context.Background(), news)
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+	mockClient.AssertNumberOfCalls(t, "CreateChatCompletion", 3)
+	if len(got) != 3 {
+		t.Errorf("Compose() merged %d news, want 3", len(got))
+	}
+}
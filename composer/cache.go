@@ -0,0 +1,100 @@
+package composer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ComposeCache is the in-memory LRU cache of the composed news keyed by the news hash (journalist.News.ID),
+// so the news re-composed after the failed job run (e.g. publish error) don't cost the LLM tokens again.
+// Entries expire after the TTL, the least recently used ones are evicted when the cache is full.
+type ComposeCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	order *list.List               // entries from the most to the least recently used
+	items map[string]*list.Element // elements of the order list by the news hash
+}
+
+type cachedComposed struct {
+	hash       string
+	news       *ComposedNews
+	composedAt time.Time
+}
+
+// NewComposeCache creates a new ComposeCache for at most size news kept for the TTL.
+func NewComposeCache(size int, ttl time.Duration) *ComposeCache {
+	return &ComposeCache{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the copy of the cached composed news by the news hash (or the key of SetKey).
+func (c *ComposeCache) Get(hash string) (*ComposedNews, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[hash]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cachedComposed)
+	if c.now().Sub(entry.composedAt) >= c.ttl {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+
+	n := *entry.news
+	return &n, true
+}
+
+// Set caches the composed news by its ID (the news hash).
+func (c *ComposeCache) Set(n *ComposedNews) {
+	if n == nil {
+		return
+	}
+	c.SetKey(n.ID, n)
+}
+
+// SetKey caches the composed news by the key, e.g. the news hash with the compose style.
+func (c *ComposeCache) SetKey(key string, n *ComposedNews) {
+	if n == nil || key == "" || c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cp := *n
+	if el, ok := c.items[key]; ok {
+		el.Value = &cachedComposed{hash: key, news: &cp, composedAt: c.now()}
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cachedComposed{hash: key, news: &cp, composedAt: c.now()})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Len returns the number of the cached news, including the expired ones that were not evicted yet.
+func (c *ComposeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *ComposeCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*cachedComposed).hash)
+}
@@ -0,0 +1,121 @@
+package composer
+
+import (
+	"github.com/samgozman/fin-thread/journalist"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestComposeCache(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		size    int
+		set     []string      // IDs of the news to cache in order
+		get     []string      // IDs of the news to get before the elapsed time (refreshes the LRU order)
+		elapsed time.Duration // time passed after the news were cached
+		want    []string      // IDs of the news that are found
+	}{
+		{
+			name: "cached news",
+			size: 10,
+			set:  []string{"a", "b"},
+			want: []string{"a", "b"},
+		},
+		{
+			name:    "expired news",
+			size:    10,
+			set:     []string{"a", "b"},
+			elapsed: time.Hour,
+			want:    nil,
+		},
+		{
+			name: "least recently used news are evicted",
+			size: 2,
+			set:  []string{"a", "b", "c"},
+			want: []string{"b", "c"},
+		},
+		{
+			name: "recently read news are kept",
+			size: 2,
+			set:  []string{"a", "b"},
+			get:  []string{"a"},
+			want: []string{"a"},
+		},
+		{
+			name: "disabled cache",
+			size: 0,
+			set:  []string{"a"},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			c := NewComposeCache(tt.size, time.Hour)
+			c.now = func() time.Time { return now }
+
+			for _, id := range tt.set {
+				c.Set(&ComposedNews{ID: id, Text: "text " + id})
+			}
+			for _, id := range tt.get {
+				c.Get(id)
+			}
+			if len(tt.get) > 0 {
+				// The news read last must survive the next eviction
+				c.Set(&ComposedNews{ID: "new"})
+			}
+			now = now.Add(tt.elapsed)
+
+			var got []string
+			for _, id := range append(tt.set, tt.get...) {
+				if n, ok := c.Get(id); ok && !slices.Contains(got, id) {
+					if n.Text != "text "+id {
+						t.Errorf("Get(%s) text = %s", id, n.Text)
+					}
+					got = append(got, id)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cached news = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposer_cachedComposed(t *testing.T) {
+	cache := NewComposeCache(10, time.Hour)
+	cache.Set(&ComposedNews{ID: "1", Text: "composed"})
+	news := journalist.NewsList{{ID: "1"}, {ID: "2"}}
+
+	c := (&Composer{}).WithCache(cache)
+	composed, missing := c.cachedComposed(news, StyleDefault, lengthLimit{})
+	if len(composed) != 1 || composed[0].ID != "1" || composed[0].Text != "composed" {
+		t.Errorf("cachedComposed() composed = %v, want news 1", composed)
+	}
+	if len(missing) != 1 || missing[0].ID != "2" {
+		t.Errorf("cachedComposed() missing = %v, want news 2", missing)
+	}
+
+	// Cached news are copied, so the caller can't change the cache
+	composed[0].Text = "changed"
+	if n, _ := cache.Get("1"); n.Text != "composed" {
+		t.Errorf("cached news text = %s, want composed", n.Text)
+	}
+
+	// Same news composed in the other style are cached separately
+	if composed, _ := c.cachedComposed(news, StyleConcise, lengthLimit{}); composed != nil {
+		t.Errorf("cachedComposed() concise = %v, want none", composed)
+	}
+	if composed, _ := c.cachedComposed(news, StyleDefault, lengthLimit{chars: 300}); composed != nil {
+		t.Errorf("cachedComposed() limited = %v, want none", composed)
+	}
+
+	composed, missing = (&Composer{}).cachedComposed(news, StyleDefault, lengthLimit{})
+	if composed != nil || len(missing) != 2 {
+		t.Errorf("cachedComposed() without cache = %v, %v, want all news missing", composed, missing)
+	}
+}
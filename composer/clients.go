@@ -18,6 +18,16 @@ type openAiClientInterface interface {
 	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (response openai.ChatCompletionResponse, err error)
 }
 
+// speechClientInterface is an interface for OpenAI text-to-speech API client.
+type speechClientInterface interface {
+	CreateSpeech(ctx context.Context, req openai.CreateSpeechRequest) (response io.ReadCloser, err error)
+}
+
+// embeddingsClientInterface is an interface for OpenAI embeddings API client.
+type embeddingsClientInterface interface {
+	CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (res openai.EmbeddingResponse, err error)
+}
+
 // togetherAIClientInterface is an interface for TogetherAI API client.
 type togetherAIClientInterface interface {
 	CreateChatCompletion(ctx context.Context, options togetherAIRequest) (*TogetherAIResponse, error)
@@ -7,89 +7,328 @@ import (
 	"fmt"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/pkg/metrics"
 	"time"
 
 	"github.com/samber/lo"
 	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/logging"
 	"github.com/sashabaranov/go-openai"
 )
 
 // TODO: refactor Composer to be able to choose provider for each method
 
+// logger is the logger of the composer stages.
+var logger = logging.For(logging.ModuleComposer)
+
 // Composer is used to compose (rephrase) news and events, find some meta information about them,
 // filter out some unnecessary stuff, summarise them and so on.
 type Composer struct {
 	OpenAiClient       openAiClientInterface
+	SpeechClient       speechClientInterface
+	EmbeddingsClient   embeddingsClientInterface
 	TogetherAIClient   togetherAIClientInterface
 	GoogleGeminiClient GoogleGeminiClientInterface
+	LLM                LLMProvider // text completions backend, OpenAiClient is used if nil
 	Config             *promptConfig
+	metrics            metrics.Emitter  // token usage of the default OpenAI backend
+	cache              *ComposeCache    // composed news by the news hash, nil to compose every time
+	usage              UsageObserver    // token usage of the default OpenAI backend and the vision requests
+	templates          *PromptTemplates // prompts loaded from the files, nil to use the Config prompts only
+	style              Style            // default style of the composed text, see ComposeStyled
+	securities         *Securities      // reference list to validate the tickers of the composed news, nil to keep them
+	meta               LLMProvider      // backend of the separate meta extraction, nil to compose the meta with the text
+	metaMarkets        []string         // markets the meta extraction chooses from
+	verifyJudge        LLMProvider      // judge of the composed texts passing the verification heuristics, nil to skip
+	verifyMode         VerifyMode       // action on the unverified texts, empty to skip the verification
 }
 
 // NewComposer creates a new Composer instance with OpenAI and TogetherAI clients and default config.
 func NewComposer(oaiToken, tgrAiToken, geminiToken string) *Composer {
+	oaiClient := openai.NewClient(oaiToken)
 	return &Composer{
-		OpenAiClient:       openai.NewClient(oaiToken),
+		OpenAiClient:       oaiClient,
+		SpeechClient:       oaiClient,
+		EmbeddingsClient:   oaiClient,
 		TogetherAIClient:   NewTogetherAI(tgrAiToken),
 		GoogleGeminiClient: NewGoogleGemini(geminiToken),
 		Config:             defaultPromptConfig(),
 	}
 }
 
-// Compose creates a new AI-composed news from the given news list.
+// WithLLMProvider sets the backend for the text completion methods (Compose, Summarise, ComposeDigestScript).
+func (c *Composer) WithLLMProvider(p LLMProvider) *Composer {
+	c.LLM = p
+	return c
+}
+
+// WithMetrics sets the metrics emitter for the token usage of the default OpenAI backend.
+// Providers set with WithLLMProvider have their own metrics emitters.
+func (c *Composer) WithMetrics(m metrics.Emitter) *Composer {
+	c.metrics = m
+	return c
+}
+
+// ObserveUsage sets the observer for the token usage of the default OpenAI backend and the image figures requests.
+// Providers set with WithLLMProvider have their own usage observers.
+func (c *Composer) ObserveUsage(u UsageObserver) *Composer {
+	c.usage = u
+	return c
+}
+
+// WithCache sets the cache of the composed news, so the same news are not composed twice (e.g. on job retries).
+func (c *Composer) WithCache(cache *ComposeCache) *Composer {
+	c.cache = cache
+	return c
+}
+
+// WithPromptTemplates sets the prompt templates, they replace the Config prompts of the stages with the template.
+func (c *Composer) WithPromptTemplates(t *PromptTemplates) *Composer {
+	c.templates = t
+	return c
+}
+
+// WithStyle sets the default style of the composed text (tone and length), used by Compose.
+func (c *Composer) WithStyle(s Style) *Composer {
+	c.style = s
+	return c
+}
+
+// WithSecurities sets the reference list of the tickers: composed news get the valid tickers only,
+// aliases and company names are replaced with the tickers.
+func (c *Composer) WithSecurities(s *Securities) *Composer {
+	c.securities = s
+	return c
+}
+
+// prompt returns the prompt of the stage rendered from its template or the default one if there is no template.
+// Templates are checked on load, so the default prompt is also used if the template fails to execute.
+func (c *Composer) prompt(stage string, data PromptData, defaultPrompt func() string) string {
+	if c.templates != nil {
+		if p, err := c.templates.Render(stage, data); err == nil {
+			return p
+		}
+	}
+	return defaultPrompt()
+}
+
+// llm returns the configured LLMProvider or OpenAI provider by default.
+func (c *Composer) llm() LLMProvider {
+	if c.LLM != nil {
+		return c.LLM
+	}
+	p := NewOpenAIProvider(c.OpenAiClient)
+	if c.metrics != nil {
+		p.WithMetrics(c.metrics)
+	}
+	return p.ObserveUsage(c.usage)
+}
+
+// Compose creates a new AI-composed news from the given news list in the default style (see WithStyle).
 // It will also find some meta information about the news and events (markets, tickers, hashtags).
 func (c *Composer) Compose(ctx context.Context, news journalist.NewsList) ([]*ComposedNews, error) {
-	// RemoveDuplicates out news that are not from today
-	var todayNews journalist.NewsList = lo.Filter(news, func(n *journalist.News, _ int) bool {
-		return n.Date.Day() == time.Now().Day()
-	})
+	return c.ComposeStyled(ctx, news, c.style)
+}
 
-	if len(todayNews) == 0 {
+// ComposeStyled is Compose with the given style of the text, e.g. for the job publishing to the channel
+// with its own audience. The default style of the Composer is used if the style is empty.
+func (c *Composer) ComposeStyled(ctx context.Context, news journalist.NewsList, style Style) ([]*ComposedNews, error) {
+	return c.ComposeWith(ctx, news, ComposeOptions{Style: style})
+}
+
+// ComposeOptions are the options of the job composing the news, zero values use the defaults of the Composer.
+type ComposeOptions struct {
+	Style        Style         // style of the composed text, the default style of the Composer if empty
+	Window       time.Duration // only the news published within the window are composed, Config.FreshnessWindow if 0
+	Lite         bool          // compose with the fast path of ComposeLite, the style and length are not applied
+	MaxSentences int           // max sentences of the composed text, Config.MaxSentences if 0
+	MaxChars     int           // max characters of the composed text, Config.MaxChars if 0
+}
+
+// ComposeWith is Compose with the options of the job.
+func (c *Composer) ComposeWith(ctx context.Context, news journalist.NewsList, opts ComposeOptions) ([]*ComposedNews, error) {
+	window := opts.Window
+	if window <= 0 {
+		window = c.Config.FreshnessWindow
+	}
+	fresh := filterFresh(news, window, time.Now())
+	if len(fresh) == 0 {
 		return nil, nil
 	}
 
+	if opts.Lite {
+		return c.composeLite(ctx, fresh)
+	}
+	return c.composeStyled(ctx, fresh, opts.Style, c.lengthLimit(opts))
+}
+
+// composeStyled composes the fresh news in the style within the length limit.
+func (c *Composer) composeStyled(ctx context.Context, news journalist.NewsList, style Style, length lengthLimit) ([]*ComposedNews, error) {
+	if style == StyleDefault {
+		style = c.style
+	}
+
+	composed, missing := c.cachedComposed(news.RemoveFlagged(), style, length)
+
+	// Template gets the style and length to switch the presets itself, the default prompt gets the instructions
+	data := PromptData{
+		MaxLen:       style.MaxWords(),
+		Style:        string(style),
+		MaxSentences: length.sentences,
+		MaxChars:     length.chars,
+		SeparateMeta: c.meta != nil,
+	}
+	prompt := c.prompt(PromptCompose, data, func() string {
+		p := length.apply(style.apply(c.Config.ComposePrompt))
+		if c.meta != nil {
+			p += skipMetaInstruction
+		}
+		return p
+	})
+
+	// Large lists are composed in batches, so the answer is not truncated by MaxTokens
+	for _, batch := range batchNews(missing, c.Config.ComposeParams.MaxTokens) {
+		batchComposed, err := c.composeBatch(ctx, batch, prompt, c.Config.ComposeParams)
+		if err != nil {
+			return nil, err
+		}
+		if c.meta != nil {
+			metas, err := c.ExtractMeta(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
+			fillMeta(batchComposed, metas)
+		}
+		// Models often ignore the length instruction, the texts over the limit are rewritten or trimmed
+		c.enforceLength(ctx, batchComposed, length)
+		c.verify(ctx, batchComposed, newsSources(batch), length)
+		c.cacheComposed(batchComposed, style, length)
+		composed = append(composed, batchComposed...)
+	}
+
+	return composed, nil
+}
+
+// ComposeLite is the fast path of Compose for the breaking news: all news are composed in one short request
+// without batching, the text is a single sentence and the sentiment is not rated. Styles are not applied.
+func (c *Composer) ComposeLite(ctx context.Context, news journalist.NewsList) ([]*ComposedNews, error) {
+	return c.ComposeWith(ctx, news, ComposeOptions{Lite: true})
+}
+
+// composeLite composes the fresh news with the lite prompt.
+func (c *Composer) composeLite(ctx context.Context, news journalist.NewsList) ([]*ComposedNews, error) {
+	composed, missing := c.cachedComposed(news.RemoveFlagged(), StyleDefault, lengthLimit{})
+	if len(missing) == 0 {
+		return composed, nil
+	}
+
+	liteComposed, err := c.composeBatch(ctx, missing, c.prompt(PromptComposeLite, PromptData{MaxLen: maxComposedWords}, func() string {
+		return c.Config.ComposeLitePrompt
+	}), c.Config.ComposeLiteParams)
+	if err != nil {
+		return nil, err
+	}
+	c.verify(ctx, liteComposed, newsSources(missing), lengthLimit{})
+	c.cacheComposed(liteComposed, StyleDefault, lengthLimit{})
+
+	return append(composed, liteComposed...), nil
+}
+
+// filterFresh removes the news published earlier than the window before now. Dates of the news are absolute
+// instants, so the news around midnight and from the providers in the other timezones are compared correctly.
+// News dated in the future (e.g. the clock skew of the provider) are kept.
+func filterFresh(news journalist.NewsList, window time.Duration, now time.Time) journalist.NewsList {
+	if window <= 0 {
+		return news
+	}
+	since := now.Add(-window)
+	return lo.Filter(news, func(n *journalist.News, _ int) bool {
+		return !n.Date.Before(since)
+	})
+}
+
+// cacheComposed saves the composed news of the style and length to the cache if it is enabled.
+func (c *Composer) cacheComposed(composed []*ComposedNews, style Style, length lengthLimit) {
+	if c.cache == nil {
+		return
+	}
+	for _, n := range composed {
+		c.cache.SetKey(composeCacheKey(n.ID, style, length), n)
+	}
+}
+
+// composeCacheKey returns the cache key of the news composed in the style and length, the same news in the other
+// styles and lengths are cached separately.
+func composeCacheKey(hash string, style Style, length lengthLimit) string {
+	if lk := length.cacheKey(); lk != "" {
+		return string(style) + ":" + lk + ":" + hash
+	}
+	if style == StyleDefault {
+		return hash
+	}
+	return string(style) + ":" + hash
+}
+
+// cachedComposed returns the cached composed news of the style and length and the news that are missing in the cache.
+func (c *Composer) cachedComposed(news journalist.NewsList, style Style, length lengthLimit) ([]*ComposedNews, journalist.NewsList) {
+	if c.cache == nil {
+		return nil, news
+	}
+
+	var composed []*ComposedNews
+	var missing journalist.NewsList
+	for _, n := range news {
+		if cn, ok := c.cache.Get(composeCacheKey(n.ID, style, length)); ok {
+			composed = append(composed, cn)
+		} else {
+			missing = append(missing, n)
+		}
+	}
+	return composed, missing
+}
+
+// composeBatch composes the news in one LLM request with the given prompt and completion parameters.
+func (c *Composer) composeBatch(ctx context.Context, news journalist.NewsList, prompt string, params StageParams) ([]*ComposedNews, error) {
 	// Convert news to JSON
-	preFilteredNews := todayNews.RemoveFlagged()
-	jsonNews, err := preFilteredNews.ToContentJSON()
+	jsonNews, err := news.ToContentJSON()
 	if err != nil {
 		return nil, newError(err, errlvl.ERROR, "Compose", "NewsList.ToContentJSON")
 	}
 
+	return c.composeJSON(ctx, jsonNews, prompt, params)
+}
+
+// composeJSON composes the news of the JSON array in one LLM request with the given prompt and completion parameters.
+func (c *Composer) composeJSON(ctx context.Context, jsonNews, prompt string, params StageParams) ([]*ComposedNews, error) {
 	// Compose news
-	resp, err := c.OpenAiClient.CreateChatCompletion(
+	resp, err := c.llm().Complete(
 		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT3Dot5Turbo0125,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: c.Config.ComposePrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: jsonNews,
-				},
-			},
-			Temperature:      1,
-			MaxTokens:        2048,
-			TopP:             1,
-			FrequencyPenalty: 0,
-			PresencePenalty:  0,
-			Stop:             []string{"#"}, // Stop on hashtags in text
+		LLMRequest{
+			System:      prompt,
+			User:        jsonNews,
+			Temperature: params.Temperature,
+			MaxTokens:   params.MaxTokens,
+			TopP:        params.TopP,
+			Stop:        []string{"#"}, // Stop on hashtags in text
+			Prefill:     "[",
+			JSON:        true,
+			Schema:      composedNewsSchema,
 		},
 	)
 	if err != nil {
-		return nil, newError(err, errlvl.WARN, "Compose", "OpenAiClient.CreateChatCompletion")
+		return nil, newError(err, errlvl.WARN, "Compose", "LLM.Complete")
 	}
 
-	matches, err := aiJSONStringFixer(resp.Choices[0].Message.Content)
-	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Compose", "aiJSONStringFixer")
+	fullComposedNews, err := parseComposedNews(resp)
+	if err == nil {
+		err = validateComposedNews(fullComposedNews)
 	}
-
-	var fullComposedNews []*ComposedNews
-	err = json.Unmarshal([]byte(matches), &fullComposedNews)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Compose", "json.Unmarshal").WithValue(matches)
+		// One repair attempt: the model fixes its own answer according to the error
+		fullComposedNews, err = c.repairComposed(ctx, resp, err)
+		if err != nil {
+			return nil, newError(err, errlvl.ERROR, "Compose", "repairComposed").WithValue(resp)
+		}
 	}
 
 	for _, n := range fullComposedNews {
@@ -97,11 +336,120 @@ func (c *Composer) Compose(ctx context.Context, news journalist.NewsList) ([]*Co
 		for i, t := range n.Tickers {
 			n.Tickers[i] = utils.ReplaceUnicodeSymbols(t)
 		}
+		if c.securities != nil {
+			n.Tickers = c.securities.Normalize(n.Tickers)
+		}
+		n.Sentiment = normalizeSentiment(n.Sentiment)
+		n.Importance = normalizeImportance(n.Importance)
+		n.Entities = normalizeEntities(n.Entities)
 	}
 
 	return fullComposedNews, nil
 }
 
+// repairComposed asks the model to fix the malformed Compose answer and parses the fixed one.
+func (c *Composer) repairComposed(ctx context.Context, answer string, parseErr error) ([]*ComposedNews, error) {
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System:      composeRepairPrompt,
+			User:        fmt.Sprintf("Error: %s\nAnswer:\n%s", parseErr, answer),
+			Temperature: 0,
+			MaxTokens:   c.Config.ComposeParams.MaxTokens,
+			TopP:        1,
+			Prefill:     "[",
+			JSON:        true,
+			Schema:      composedNewsSchema,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	composed, err := parseComposedNews(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repaired answer: %w", err)
+	}
+	if err := validateComposedNews(composed); err != nil {
+		return nil, fmt.Errorf("invalid repaired answer: %w", err)
+	}
+
+	return composed, nil
+}
+
+// Classify is the first LLM stage before Compose: it drops unimportant news (PR fluff, ads, clickbait)
+// with the ClassifyPrompt, so only the remaining news are composed. Returns the same news list
+// with IsFiltered flag set to true for the dropped news.
+func (c *Composer) Classify(ctx context.Context, news journalist.NewsList) (journalist.NewsList, error) {
+	if len(news) == 0 {
+		return nil, nil
+	}
+
+	preFilteredNews := news.RemoveFlagged()
+	if len(preFilteredNews) == 0 {
+		return news, nil
+	}
+
+	jsonNews, err := preFilteredNews.ToContentJSON()
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Classify", "NewsList.ToContentJSON")
+	}
+
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System:      c.prompt(PromptClassify, PromptData{}, func() string { return c.Config.ClassifyPrompt }),
+			User:        jsonNews,
+			Temperature: c.Config.ClassifyParams.Temperature,
+			MaxTokens:   c.Config.ClassifyParams.MaxTokens,
+			TopP:        c.Config.ClassifyParams.TopP,
+			Prefill:     "[",
+			JSON:        true,
+		},
+	)
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "Classify", "LLM.Complete")
+	}
+
+	matches, err := aiJSONStringFixer(resp)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Classify", "aiJSONStringFixer")
+	}
+
+	var verdicts []*classifiedNews
+	err = json.Unmarshal([]byte(matches), &verdicts)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Classify", "json.Unmarshal").WithValue(matches)
+	}
+
+	markDropped(preFilteredNews, verdicts)
+
+	return news, nil
+}
+
+// classifiedNews is the verdict of the classify stage for the news.
+type classifiedNews struct {
+	ID   string `json:"id"`
+	Keep bool   `json:"keep"`
+}
+
+// markDropped sets IsFiltered flag for the news that are explicitly dropped by the classify stage.
+// News missing in the answer are kept, so the truncated answer doesn't drop the important news.
+func markDropped(news journalist.NewsList, verdicts []*classifiedNews) {
+	dropped := make(map[string]bool, len(verdicts))
+	for _, v := range verdicts {
+		if !v.Keep {
+			dropped[v.ID] = true
+		}
+	}
+
+	for _, n := range news {
+		if dropped[n.ID] {
+			n.IsFiltered = true
+		}
+	}
+}
+
 // Summarise create a short AI summary for the Headline array of any kind.
 // It will also add Markdown links in summary.
 //
@@ -127,32 +475,25 @@ func (c *Composer) Summarise(ctx context.Context, headlines []*Headline, headlin
 		return nil, newError(err, errlvl.ERROR, "Summarise", "json.Marshal headlines").WithValue(fmt.Sprintf("%+v", headlines))
 	}
 
-	resp, err := c.OpenAiClient.CreateChatCompletion(
+	resp, err := c.llm().Complete(
 		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT3Dot5Turbo0125,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: c.Config.SummarisePrompt(headlinesLimit),
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: string(jsonHeadlines),
-				},
-			},
-			Temperature:      1,
-			MaxTokens:        maxTokens,
-			TopP:             0.7,
-			FrequencyPenalty: 0,
-			PresencePenalty:  0,
+		LLMRequest{
+			System: c.prompt(PromptSummarise, PromptData{MaxLen: maxWordsPerSentence, Headlines: headlinesLimit}, func() string {
+				return c.Config.SummarisePrompt(headlinesLimit)
+			}),
+			User:        string(jsonHeadlines),
+			Temperature: 1,
+			MaxTokens:   maxTokens,
+			TopP:        0.7,
+			Prefill:     "[",
+			JSON:        true,
 		},
 	)
 	if err != nil {
-		return nil, newError(err, errlvl.WARN, "Summarise", "OpenAiClient.CreateChatCompletion")
+		return nil, newError(err, errlvl.WARN, "Summarise", "LLM.Complete")
 	}
 
-	matches, err := aiJSONStringFixer(resp.Choices[0].Message.Content)
+	matches, err := aiJSONStringFixer(resp)
 	if err != nil {
 		return nil, newError(err, errlvl.ERROR, "Summarise", "aiJSONStringFixer")
 	}
@@ -160,7 +501,7 @@ func (c *Composer) Summarise(ctx context.Context, headlines []*Headline, headlin
 	var h []*SummarisedHeadline
 	err = json.Unmarshal([]byte(matches), &h)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Summarise", "json.Unmarshal").WithValue(resp.Choices[0].Message.Content)
+		return nil, newError(err, errlvl.ERROR, "Summarise", "json.Unmarshal").WithValue(resp)
 	}
 
 	return h, nil
@@ -182,8 +523,10 @@ func (c *Composer) Filter(ctx context.Context, news journalist.NewsList) (journa
 	resp, err := c.TogetherAIClient.CreateChatCompletion(
 		ctx,
 		togetherAIRequest{
-			Model:             "mistralai/Mixtral-8x7B-Instruct-v0.1",
-			Prompt:            c.Config.FilterPromptInstruct(jsonNews),
+			Model: "mistralai/Mixtral-8x7B-Instruct-v0.1",
+			Prompt: c.prompt(PromptFilter, PromptData{News: jsonNews}, func() string {
+				return c.Config.FilterPromptInstruct(jsonNews)
+			}),
 			MaxTokens:         2048,
 			Temperature:       0.7,
 			TopP:              0.7,
@@ -250,15 +593,29 @@ type SummarisedHeadline struct {
 }
 
 type ComposedNews struct {
-	ID       string   `json:"id"`
-	Text     string   `json:"text"`
-	Tickers  []string `json:"tickers"`  // tickers mentioned or/and related to the news
-	Markets  []string `json:"markets"`  // US/EU/Asia stocks, bonds, commodities, housing, etc.
-	Hashtags []string `json:"hashtags"` // hashtags related to the news (#inflation, #fed, #buybacks, etc.)
+	ID        string     `json:"id"`
+	Text      string     `json:"text"`
+	Tickers   []string   `json:"tickers"`             // tickers mentioned or/and related to the news
+	Markets   []string   `json:"markets"`             // US/EU/Asia stocks, bonds, commodities, housing, etc.
+	Hashtags  []string   `json:"hashtags"`            // hashtags related to the news (#inflation, #fed, #buybacks, etc.)
+	Sentiment *Sentiment `json:"sentiment,omitempty"` // market sentiment of the news (nil if not recognized)
+	// Importance of the news for the investors from 0 (minor) to MaxImportance (the story of the day), nil if not rated
+	Importance *int `json:"importance,omitempty"`
+	// Companies, people, central banks and countries mentioned in the news, nil if none are found
+	Entities *Entities `json:"entities,omitempty"`
+	// Other sources of the story merged from several news (see ComposeMerged), nil for the single news
+	Sources []Source `json:"sources,omitempty"`
+	// True if the text introduces the numbers or claims absent from the original news, see WithVerification
+	Unverified bool `json:"unverified,omitempty"`
 }
 
 type ComposedMeta struct {
-	Tickers  []string `json:"tickers"`
-	Markets  []string `json:"markets"`
-	Hashtags []string `json:"hashtags"`
+	Tickers    []string   `json:"tickers"`
+	Markets    []string   `json:"markets"`
+	Hashtags   []string   `json:"hashtags"`
+	Sentiment  *Sentiment `json:"sentiment,omitempty"`
+	Importance *int       `json:"importance,omitempty"`
+	Entities   *Entities  `json:"entities,omitempty"`
+	Sources    []Source   `json:"sources,omitempty"`
+	Unverified bool       `json:"unverified,omitempty"`
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/sashabaranov/go-openai"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -84,11 +85,12 @@ func TestComposer_Compose(t *testing.T) {
 			expectedFilteredNews: journalist.NewsList{news[0], news[1], news[2]},
 			want: []*ComposedNews{
 				{
-					ID:       "1",
-					Text:     "Ray Dalio warns about the soaring U.S. government debt reaching a critical inflection point, potentially leading to larger problems.",
-					Tickers:  []string{"AAPL"},
-					Markets:  []string{},
-					Hashtags: []string{"debt"},
+					ID:        "1",
+					Text:      "Ray Dalio warns about the soaring U.S. government debt reaching a critical inflection point, potentially leading to larger problems.",
+					Tickers:   []string{"AAPL"},
+					Markets:   []string{},
+					Hashtags:  []string{"debt"},
+					Sentiment: &Sentiment{Label: SentimentBearish, Confidence: 0.7},
 				},
 				{
 					ID:       "2",
@@ -158,6 +160,15 @@ func TestComposer_Compose(t *testing.T) {
 				FrequencyPenalty: 0,
 				PresencePenalty:  0,
 				Stop:             []string{"#"},
+				Tools: []openai.Tool{{
+					Type: openai.ToolTypeFunction,
+					Function: openai.FunctionDefinition{
+						Name:        composedNewsSchema.Name,
+						Description: composedNewsSchema.Description,
+						Parameters:  composedNewsSchema.Definition,
+					},
+				}},
+				ToolChoice: openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: composedNewsSchema.Name}},
 			}).Return(openai.ChatCompletionResponse{
 				Choices: []openai.ChatCompletionChoice{
 					{
@@ -193,6 +204,72 @@ func TestComposer_Compose(t *testing.T) {
 	}
 }
 
+func TestComposer_Classify(t *testing.T) {
+	tests := []struct {
+		name         string
+		answer       string
+		err          error
+		wantFiltered []bool
+		wantErr      bool
+	}{
+		{
+			name:         "Should mark dropped news as filtered",
+			answer:       `[{"id":"2","keep":false},{"id":"3","keep":true}]`,
+			wantFiltered: []bool{false, true, false},
+		},
+		{
+			name:         "Should keep news missing in the answer",
+			answer:       `[{"id":"2","keep":true}]`,
+			wantFiltered: []bool{false, false, false},
+		},
+		{
+			name:    "Should return error if LLM returns error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := journalist.NewsList{
+				{ID: "1", Title: "Suspicious news", IsSuspicious: true},
+				{ID: "2", Title: "Buy our premium newsletter today"},
+				{ID: "3", Title: "Fed holds rates steady"},
+			}
+			jsonNews, _ := news.RemoveFlagged().ToContentJSON()
+			defConf := defaultPromptConfig()
+
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, openai.ChatCompletionRequest{
+				Model: openai.GPT3Dot5Turbo0125,
+				Messages: []openai.ChatCompletionMessage{
+					{Role: openai.ChatMessageRoleSystem, Content: defConf.ClassifyPrompt},
+					{Role: openai.ChatMessageRoleUser, Content: jsonNews},
+				},
+				Temperature: defConf.ClassifyParams.Temperature,
+				MaxTokens:   defConf.ClassifyParams.MaxTokens,
+				TopP:        defConf.ClassifyParams.TopP,
+			}).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: tt.answer}}},
+			}, tt.err)
+
+			c := &Composer{OpenAiClient: mockClient, Config: defConf}
+			got, err := c.Classify(context.Background(), news)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Classify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, n := range got {
+				if n.IsFiltered != tt.wantFiltered[i] {
+					t.Errorf("Classify() news %s IsFiltered = %v, want %v", n.ID, n.IsFiltered, tt.wantFiltered[i])
+				}
+			}
+		})
+	}
+}
+
 func TestComposer_Summarise(t *testing.T) {
 	type fields struct {
 		OpenAiClient openAiClientInterface
@@ -442,3 +519,148 @@ func TestComposer_Filter(t *testing.T) {
 		})
 	}
 }
+
+func TestComposer_ComposeLite(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Fed cuts rates by 50 bps", Date: time.Now().UTC()},
+		{ID: "2", Title: "Old news", Date: time.Now().UTC().AddDate(0, 0, -2)},
+		{ID: "3", Title: "Cached news", Date: time.Now().UTC()},
+	}
+	answer := `[{"id":"1","text":"The Fed cut rates by 50 bps.","tickers":[],"markets":["SPY"],"hashtags":["fed"]}]`
+
+	mockClient := new(MockOpenAiClient)
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+		// Only not cached fresh news are composed with the lite prompt
+		return req.Messages[0].Content == defaultPromptConfig().ComposeLitePrompt &&
+			strings.Contains(req.Messages[1].Content, `"id":"1"`) &&
+			!strings.Contains(req.Messages[1].Content, `"id":"3"`)
+	})).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: answer}}},
+	}, nil).Once()
+
+	cache := NewComposeCache(10, time.Hour)
+	cache.Set(&ComposedNews{ID: "3", Text: "Cached text"})
+	c := (&Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}).WithCache(cache)
+
+	got, err := c.ComposeLite(context.Background(), news)
+	if err != nil {
+		t.Fatalf("ComposeLite() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "3" || got[1].ID != "1" || got[1].Text != "The Fed cut rates by 50 bps." {
+		t.Errorf("ComposeLite() = %v, want cached news 3 and composed news 1", got)
+	}
+	if _, ok := cache.Get("1"); !ok {
+		t.Error("ComposeLite() didn't cache the composed news")
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestComposer_ComposeStyled(t *testing.T) {
+	news := journalist.NewsList{{ID: "1", Title: "Fed cuts rates by 50 bps", Date: time.Now().UTC()}}
+	answer := `[{"id":"1","text":"Fed cuts 50 bps.","tickers":[],"markets":["SPY"],"hashtags":["fed"],"sentiment":{"label":"bullish","confidence":0.8}}]`
+
+	mockClient := new(MockOpenAiClient)
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+		return strings.HasPrefix(req.Messages[0].Content, defaultPromptConfig().ComposePrompt) &&
+			strings.Contains(req.Messages[0].Content, stylePresets[StyleConcise].instruction) &&
+			strings.Contains(req.Messages[0].Content, "20 words max")
+	})).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: answer}}},
+	}, nil).Once()
+
+	cache := NewComposeCache(10, time.Hour)
+	c := (&Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}).WithCache(cache).WithStyle(StyleAnalytical)
+
+	got, err := c.ComposeStyled(context.Background(), news, StyleConcise)
+	if err != nil {
+		t.Fatalf("ComposeStyled() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Text != "Fed cuts 50 bps." {
+		t.Errorf("ComposeStyled() = %v, want concise news 1", got)
+	}
+	if _, ok := cache.Get(composeCacheKey("1", StyleConcise, lengthLimit{})); !ok {
+		t.Error("ComposeStyled() didn't cache the composed news with the style")
+	}
+	if _, ok := cache.Get("1"); ok {
+		t.Error("ComposeStyled() cached the concise news as the default style")
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestStyle_apply(t *testing.T) {
+	prompt := defaultPromptConfig().ComposePrompt
+	if got := StyleDefault.apply(prompt); got != prompt {
+		t.Errorf("StyleDefault.apply() changed the prompt")
+	}
+	for s, p := range stylePresets {
+		if got := s.apply(prompt); !strings.Contains(got, p.instruction) || s.MaxWords() != p.maxWords {
+			t.Errorf("%s.apply() = %q, max words %d", s, got, s.MaxWords())
+		}
+	}
+	if StyleDefault.MaxWords() != maxComposedWords {
+		t.Errorf("StyleDefault.MaxWords() = %d, want %d", StyleDefault.MaxWords(), maxComposedWords)
+	}
+}
+
+func Test_filterFresh(t *testing.T) {
+	now := time.Date(2024, 3, 2, 0, 30, 0, 0, time.UTC)
+	ny := time.FixedZone("EST", -5*60*60)
+
+	tests := []struct {
+		name   string
+		date   time.Time
+		window time.Duration
+		want   bool
+	}{
+		{
+			name:   "news within the window",
+			date:   now.Add(-time.Hour),
+			window: 6 * time.Hour,
+			want:   true,
+		},
+		{
+			name:   "news of the previous day before midnight",
+			date:   time.Date(2024, 3, 1, 23, 50, 0, 0, time.UTC),
+			window: 6 * time.Hour,
+			want:   true,
+		},
+		{
+			name:   "news in the other timezone",
+			date:   time.Date(2024, 3, 1, 18, 0, 0, 0, ny), // 23:00 UTC
+			window: 6 * time.Hour,
+			want:   true,
+		},
+		{
+			name:   "stale news of the same day of month",
+			date:   now.AddDate(0, -1, 0),
+			window: 6 * time.Hour,
+			want:   false,
+		},
+		{
+			name:   "news older than the window",
+			date:   now.Add(-7 * time.Hour),
+			window: 6 * time.Hour,
+			want:   false,
+		},
+		{
+			name:   "news in the future",
+			date:   now.Add(time.Minute),
+			window: 6 * time.Hour,
+			want:   true,
+		},
+		{
+			name:   "disabled window",
+			date:   now.AddDate(-1, 0, 0),
+			window: 0,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterFresh(journalist.NewsList{{ID: "1", Date: tt.date}}, tt.window, now)
+			if (len(got) == 1) != tt.want {
+				t.Errorf("filterFresh() = %v, want fresh %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package composer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+	"io"
+	"strings"
+)
+
+const (
+	speechModel      = openai.TTSModel1
+	speechVoice      = openai.VoiceOnyx
+	maxSpeechInput   = 4096 // OpenAI limit of characters for the single speech request
+	maxDigestTokens  = 1200
+	digestHeadlines  = 40
+	digestScriptTemp = 0.7
+	maxThemedTokens  = 1500 // themed digest of the top stories per market
+	digestNewsLimit  = 80   // max number of the news in the themed digest request
+)
+
+// DigestNews is the published news for the themed digest.
+type DigestNews struct {
+	ID      string   `json:"id"`
+	Text    string   `json:"text"`
+	Markets []string `json:"markets,omitempty"`
+	Tickers []string `json:"tickers,omitempty"`
+}
+
+// DigestSection is the market section of the themed digest with its top stories.
+type DigestSection struct {
+	Market  string         `json:"market"`
+	Stories []*DigestStory `json:"stories"`
+}
+
+// DigestStory is the summary of the top story, ID is the ID of the news it is based on.
+type DigestStory struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+}
+
+// ComposeDigest groups the published news into the top stories per market with the DigestPrompt.
+// Only the first digestNewsLimit news are used, so pass the most recent ones first.
+func (c *Composer) ComposeDigest(ctx context.Context, news []*DigestNews) ([]*DigestSection, error) {
+	if len(news) == 0 {
+		return nil, nil
+	}
+
+	if len(news) > digestNewsLimit {
+		news = news[:digestNewsLimit]
+	}
+
+	jsonNews, err := json.Marshal(news)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ComposeDigest", "json.Marshal news")
+	}
+
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System:      c.prompt(PromptDigest, PromptData{MaxLen: maxDigestSummaryWords}, func() string { return c.Config.DigestPrompt }),
+			User:        string(jsonNews),
+			Temperature: 0.5,
+			MaxTokens:   maxThemedTokens,
+			TopP:        1,
+			Prefill:     "[",
+			JSON:        true,
+		},
+	)
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "ComposeDigest", "LLM.Complete")
+	}
+
+	matches, err := aiJSONStringFixer(resp)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ComposeDigest", "aiJSONStringFixer")
+	}
+
+	var sections []*DigestSection
+	if err := json.Unmarshal([]byte(matches), &sections); err != nil {
+		return nil, newError(err, errlvl.ERROR, "ComposeDigest", "json.Unmarshal").WithValue(resp)
+	}
+
+	// Sections without stories are useless for the digest
+	result := make([]*DigestSection, 0, len(sections))
+	for _, s := range sections {
+		if s != nil && s.Market != "" && len(s.Stories) > 0 {
+			result = append(result, s)
+		}
+	}
+
+	return result, nil
+}
+
+// ComposeDigestScript creates a plain text script of the daily audio digest from the given headlines.
+// The script is meant to be read by TextToSpeech, so it doesn't contain any Markdown.
+func (c *Composer) ComposeDigestScript(ctx context.Context, headlines []*Headline) (string, error) {
+	if len(headlines) == 0 {
+		return "", nil
+	}
+
+	if len(headlines) > digestHeadlines {
+		headlines = headlines[:digestHeadlines]
+	}
+
+	jsonHeadlines, err := json.Marshal(headlines)
+	if err != nil {
+		return "", newError(err, errlvl.ERROR, "ComposeDigestScript", "json.Marshal headlines").WithValue(fmt.Sprintf("%+v", headlines))
+	}
+
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System:      c.prompt(PromptDigestScript, PromptData{MaxLen: digestScriptWords}, func() string { return c.Config.DigestScriptPrompt }),
+			User:        string(jsonHeadlines),
+			Temperature: digestScriptTemp,
+			MaxTokens:   maxDigestTokens,
+		},
+	)
+	if err != nil {
+		return "", newError(err, errlvl.WARN, "ComposeDigestScript", "LLM.Complete")
+	}
+
+	return strings.TrimSpace(resp), nil
+}
+
+// TextToSpeech converts the text to the MP3 audio. Long texts are split by sentences into chunks
+// that fit into the single speech request, the resulting MP3 streams are concatenated in order.
+func (c *Composer) TextToSpeech(ctx context.Context, text string) ([]byte, error) {
+	var audio bytes.Buffer
+	for _, chunk := range utils.SplitBySentences(text, maxSpeechInput) {
+		resp, err := c.SpeechClient.CreateSpeech(ctx, openai.CreateSpeechRequest{
+			Model:          speechModel,
+			Input:          chunk,
+			Voice:          speechVoice,
+			ResponseFormat: openai.SpeechResponseFormatMp3,
+		})
+		if err != nil {
+			return nil, newError(err, errlvl.WARN, "TextToSpeech", "SpeechClient.CreateSpeech")
+		}
+
+		_, err = io.Copy(&audio, resp)
+		resp.Close()
+		if err != nil {
+			return nil, newError(err, errlvl.ERROR, "TextToSpeech", "io.Copy")
+		}
+	}
+
+	return audio.Bytes(), nil
+}
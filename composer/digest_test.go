@@ -0,0 +1,111 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"strings"
+	"testing"
+)
+
+type MockSpeechClient struct {
+	mock.Mock
+}
+
+func (m *MockSpeechClient) CreateSpeech(ctx context.Context, req openai.CreateSpeechRequest) (io.ReadCloser, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func TestComposer_TextToSpeech(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		setup   func(m *MockSpeechClient)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "chunks are concatenated",
+			text: strings.Repeat("a", maxSpeechInput) + " " + "Second chunk.",
+			setup: func(m *MockSpeechClient) {
+				m.On("CreateSpeech", mock.Anything, mock.MatchedBy(func(req openai.CreateSpeechRequest) bool {
+					return len(req.Input) == maxSpeechInput
+				})).Return(io.NopCloser(strings.NewReader("first")), nil)
+				m.On("CreateSpeech", mock.Anything, mock.MatchedBy(func(req openai.CreateSpeechRequest) bool {
+					return req.Input == "Second chunk."
+				})).Return(io.NopCloser(strings.NewReader("second")), nil)
+			},
+			want: "firstsecond",
+		},
+		{
+			name: "speech error",
+			text: "Hello.",
+			setup: func(m *MockSpeechClient) {
+				m.On("CreateSpeech", mock.Anything, mock.Anything).
+					Return(io.NopCloser(strings.NewReader("")), errors.New("rate limit"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockSpeechClient)
+			tt.setup(mockClient)
+			c := &Composer{SpeechClient: mockClient, Config: defaultPromptConfig()}
+
+			got, err := c.TextToSpeech(context.Background(), tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TextToSpeech() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("TextToSpeech() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposer_ComposeDigest(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    []string // markets
+		wantErr bool
+	}{
+		{
+			name:   "sections without stories are skipped",
+			answer: `[{"market":"US stocks","stories":[{"id":"1","summary":"Stocks rally"}]},{"market":"Bonds","stories":[]}]`,
+			want:   []string{"US stocks"},
+		},
+		{
+			name:    "invalid answer",
+			answer:  "no digest",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, mock.Anything).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: tt.answer}}},
+			}, nil)
+			c := &Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}
+
+			got, err := c.ComposeDigest(context.Background(), []*DigestNews{{ID: "1", Text: "Stocks rally"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ComposeDigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ComposeDigest() returned %d sections, want %d", len(got), len(tt.want))
+			}
+			for i, s := range got {
+				if s.Market != tt.want[i] {
+					t.Errorf("ComposeDigest() section %d = %v, want %v", i, s.Market, tt.want[i])
+				}
+			}
+		})
+	}
+}
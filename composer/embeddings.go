@@ -0,0 +1,48 @@
+package composer
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	embeddingModel = openai.SmallEmbedding3
+	// EmbeddingDimensions is the size of the embedding vectors returned by Composer.Embed.
+	EmbeddingDimensions = 1536
+)
+
+// Embed creates the embedding vectors for the texts (in the same order) to compare them by semantic similarity.
+func (c *Composer) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.EmbeddingsClient.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input:      texts,
+		Model:      embeddingModel,
+		Dimensions: EmbeddingDimensions,
+	})
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "Embed", "EmbeddingsClient.CreateEmbeddings")
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, newError(
+			fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data)),
+			errlvl.ERROR,
+			"Embed",
+			"EmbeddingsClient.CreateEmbeddings",
+		)
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, e := range resp.Data {
+		if e.Index < 0 || e.Index >= len(texts) {
+			return nil, newError(fmt.Errorf("unexpected embedding index %d", e.Index), errlvl.ERROR, "Embed", "EmbeddingsClient.CreateEmbeddings")
+		}
+		embeddings[e.Index] = e.Embedding
+	}
+
+	return embeddings, nil
+}
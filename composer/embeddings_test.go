@@ -0,0 +1,72 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/sashabaranov/go-openai"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmbeddingsClient struct {
+	mock.Mock
+}
+
+func (m *MockEmbeddingsClient) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	args := m.Called(ctx, conv)
+	return args.Get(0).(openai.EmbeddingResponse), args.Error(1) //nolint:wrapcheck
+}
+
+func TestComposer_Embed(t *testing.T) {
+	tests := []struct {
+		name    string
+		texts   []string
+		resp    openai.EmbeddingResponse
+		err     error
+		want    [][]float32
+		wantErr bool
+	}{
+		{
+			name:  "ordered by index",
+			texts: []string{"Fed holds rates", "Apple beats estimates"},
+			resp: openai.EmbeddingResponse{Data: []openai.Embedding{
+				{Index: 1, Embedding: []float32{0, 1}},
+				{Index: 0, Embedding: []float32{1, 0}},
+			}},
+			want: [][]float32{{1, 0}, {0, 1}},
+		},
+		{
+			name:    "missing embeddings",
+			texts:   []string{"Fed holds rates", "Apple beats estimates"},
+			resp:    openai.EmbeddingResponse{Data: []openai.Embedding{{Index: 0, Embedding: []float32{1, 0}}}},
+			wantErr: true,
+		},
+		{
+			name:    "client error",
+			texts:   []string{"Fed holds rates"},
+			err:     errors.New("rate limited"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockEmbeddingsClient)
+			mockClient.On("CreateEmbeddings", mock.Anything, openai.EmbeddingRequest{
+				Input:      tt.texts,
+				Model:      embeddingModel,
+				Dimensions: EmbeddingDimensions,
+			}).Return(tt.resp, tt.err)
+
+			c := &Composer{EmbeddingsClient: mockClient}
+			got, err := c.Embed(context.Background(), tt.texts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Embed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Embed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package composer
+
+import (
+	"slices"
+	"strings"
+)
+
+// Entities are the named entities mentioned in the news.
+type Entities struct {
+	Companies    []string `json:"companies,omitempty"`     // e.g. "Apple", "Nvidia"
+	People       []string `json:"people,omitempty"`        // executives, officials and politicians, e.g. "Jerome Powell"
+	CentralBanks []string `json:"central_banks,omitempty"` // e.g. "Fed", "ECB"
+	Countries    []string `json:"countries,omitempty"`     // e.g. "United States", "China"
+}
+
+// Names returns all names of the entities.
+func (e *Entities) Names() []string {
+	if e == nil {
+		return nil
+	}
+	return slices.Concat(e.Companies, e.People, e.CentralBanks, e.Countries)
+}
+
+// centralBankAliases are the names of the central banks mapped to their short names by the lower case name,
+// so the news about the same bank are found by one name.
+var centralBankAliases = map[string]string{
+	"fed":                           "Fed",
+	"the fed":                       "Fed",
+	"federal reserve":               "Fed",
+	"the federal reserve":           "Fed",
+	"us federal reserve":            "Fed",
+	"fomc":                          "Fed",
+	"federal open market committee": "Fed",
+	"ecb":                           "ECB",
+	"european central bank":         "ECB",
+	"boe":                           "BoE",
+	"bank of england":               "BoE",
+	"boj":                           "BoJ",
+	"bank of japan":                 "BoJ",
+	"pboc":                          "PBoC",
+	"people's bank of china":        "PBoC",
+	"snb":                           "SNB",
+	"swiss national bank":           "SNB",
+	"boc":                           "BoC",
+	"bank of canada":                "BoC",
+	"rba":                           "RBA",
+	"reserve bank of australia":     "RBA",
+	"rbi":                           "RBI",
+	"reserve bank of india":         "RBI",
+}
+
+// normalizeEntities fixes the entities returned by the model: the names are trimmed, deduplicated
+// case-insensitively and the central banks are mapped to their short names. Returns nil if there are no entities.
+func normalizeEntities(e *Entities) *Entities {
+	if e == nil {
+		return nil
+	}
+
+	normalized := &Entities{
+		Companies:    uniqueNames(e.Companies, nil),
+		People:       uniqueNames(e.People, nil),
+		CentralBanks: uniqueNames(e.CentralBanks, centralBankAliases),
+		Countries:    uniqueNames(e.Countries, nil),
+	}
+	if len(normalized.Names()) == 0 {
+		return nil
+	}
+
+	return normalized
+}
+
+// uniqueNames returns the trimmed non-empty names without the case-insensitive duplicates, the names
+// found in aliases are replaced. Returns nil if there are no names.
+func uniqueNames(names []string, aliases map[string]string) []string {
+	var unique []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		if alias, ok := aliases[strings.ToLower(name)]; ok {
+			name = alias
+		}
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
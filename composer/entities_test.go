@@ -0,0 +1,57 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_normalizeEntities(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *Entities
+		want *Entities
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "empty",
+			e:    &Entities{Companies: []string{" "}, People: []string{}},
+		},
+		{
+			name: "names are trimmed and deduplicated",
+			e: &Entities{
+				Companies: []string{" Apple", "apple", "Nvidia  Corp"},
+				People:    []string{"Jerome Powell", "jerome powell"},
+				Countries: []string{"China", ""},
+			},
+			want: &Entities{
+				Companies: []string{"Apple", "Nvidia Corp"},
+				People:    []string{"Jerome Powell"},
+				Countries: []string{"China"},
+			},
+		},
+		{
+			name: "central banks aliases",
+			e:    &Entities{CentralBanks: []string{"Federal Reserve", "FOMC", "the Fed", "European Central Bank", "Norges Bank"}},
+			want: &Entities{CentralBanks: []string{"Fed", "ECB", "Norges Bank"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeEntities(tt.e); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeEntities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntities_Names(t *testing.T) {
+	e := &Entities{Companies: []string{"Apple"}, People: []string{"Tim Cook"}, CentralBanks: []string{"Fed"}, Countries: []string{"China"}}
+	if got, want := e.Names(), []string{"Apple", "Tim Cook", "Fed", "China"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if got := (*Entities)(nil).Names(); got != nil {
+		t.Errorf("Names() of nil = %v, want nil", got)
+	}
+}
@@ -0,0 +1,85 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"strconv"
+	"strings"
+)
+
+// FallbackModel is the LLMProvider of the fallback chain with the model name for the telemetry.
+type FallbackModel struct {
+	Model    string
+	Provider LLMProvider
+}
+
+// FallbackProvider is the LLMProvider that tries the models of the chain in order: each model is retried
+// on errors and malformed JSON answers, then the next model is requested, e.g. GPT-3.5 then GPT-4o-mini.
+type FallbackProvider struct {
+	Chain   []FallbackModel
+	Retries int // number of retries of each model before the fallback
+	metrics metrics.Emitter
+}
+
+// NewFallbackProvider creates a new FallbackProvider with one retry of each model.
+func NewFallbackProvider(chain ...FallbackModel) *FallbackProvider {
+	return &FallbackProvider{
+		Chain:   chain,
+		Retries: 1,
+		metrics: metrics.Noop{},
+	}
+}
+
+// WithMetrics sets the metrics emitter for the attempts and the models that served the requests.
+func (f *FallbackProvider) WithMetrics(m metrics.Emitter) *FallbackProvider {
+	f.metrics = m
+	return f
+}
+
+// Complete returns the first valid answer of the chain. Answers of the JSON requests must contain JSON,
+// otherwise the attempt is failed. Returns the errors of all attempts if no model has answered.
+func (f *FallbackProvider) Complete(ctx context.Context, req LLMRequest) (string, error) {
+	var errs []error
+	for i, m := range f.Chain {
+		for attempt := 0; attempt <= f.Retries; attempt++ {
+			if err := ctx.Err(); err != nil {
+				return "", errors.Join(append(errs, err)...)
+			}
+
+			answer, err := m.Provider.Complete(ctx, req)
+			result := "ok"
+			switch {
+			case err != nil:
+				result = "error"
+			case req.JSON && !containsJSON(answer):
+				result = "malformed"
+				err = errors.New("malformed JSON answer")
+			}
+			f.metrics.Count(metrics.LLMAttempts, 1, metrics.T("model", m.Model), metrics.T("result", result))
+
+			if err == nil {
+				f.metrics.Count(metrics.LLMServed, 1, metrics.T("model", m.Model), metrics.T("fallback", strconv.FormatBool(i > 0)))
+				return answer, nil
+			}
+			errs = append(errs, fmt.Errorf("%s (attempt %d): %w", m.Model, attempt+1, err))
+		}
+	}
+	if len(errs) == 0 {
+		return "", errors.New("empty fallback chain")
+	}
+
+	return "", errors.Join(errs...)
+}
+
+// containsJSON checks if the answer is the JSON or contains the JSON array or object that can be parsed
+// after aiJSONStringFixer, as the Composer does.
+func containsJSON(answer string) bool {
+	if json.Valid([]byte(strings.TrimSpace(answer))) {
+		return true
+	}
+	fixed, err := aiJSONStringFixer(answer)
+	return err == nil && json.Valid([]byte(fixed))
+}
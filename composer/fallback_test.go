@@ -0,0 +1,101 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// scriptedProvider answers with the next answer or error of the script on each request.
+type scriptedProvider struct {
+	answers []string
+	errs    []error
+	calls   int
+}
+
+func (p *scriptedProvider) Complete(context.Context, LLMRequest) (string, error) {
+	i := p.calls
+	p.calls++
+	if i < len(p.errs) && p.errs[i] != nil {
+		return "", p.errs[i]
+	}
+	if i < len(p.answers) {
+		return p.answers[i], nil
+	}
+	return "", errors.New("no answer")
+}
+
+func TestFallbackProvider_Complete(t *testing.T) {
+	errAPI := errors.New("api error")
+	tests := []struct {
+		name          string
+		req           LLMRequest
+		primary       *scriptedProvider
+		secondary     *scriptedProvider
+		want          string
+		wantErr       bool
+		wantPrimary   int
+		wantSecondary int
+	}{
+		{
+			name:        "primary answers",
+			req:         LLMRequest{JSON: true},
+			primary:     &scriptedProvider{answers: []string{`[{"id":"1"}]`}},
+			secondary:   &scriptedProvider{},
+			want:        `[{"id":"1"}]`,
+			wantPrimary: 1,
+		},
+		{
+			name:        "primary retried after error",
+			req:         LLMRequest{JSON: true},
+			primary:     &scriptedProvider{answers: []string{"", `{"news":[]}`}, errs: []error{errAPI}},
+			secondary:   &scriptedProvider{},
+			want:        `{"news":[]}`,
+			wantPrimary: 2,
+		},
+		{
+			name:          "fallback after malformed answers",
+			req:           LLMRequest{JSON: true},
+			primary:       &scriptedProvider{answers: []string{"sorry", "no json"}},
+			secondary:     &scriptedProvider{answers: []string{"[]"}},
+			want:          "[]",
+			wantPrimary:   2,
+			wantSecondary: 1,
+		},
+		{
+			name:        "text answer is not checked",
+			req:         LLMRequest{},
+			primary:     &scriptedProvider{answers: []string{"plain text"}},
+			secondary:   &scriptedProvider{},
+			want:        "plain text",
+			wantPrimary: 1,
+		},
+		{
+			name:          "all models failed",
+			req:           LLMRequest{JSON: true},
+			primary:       &scriptedProvider{errs: []error{errAPI, errAPI}},
+			secondary:     &scriptedProvider{errs: []error{errAPI, errAPI}},
+			wantErr:       true,
+			wantPrimary:   2,
+			wantSecondary: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallbackProvider(
+				FallbackModel{Model: "gpt-3.5-turbo", Provider: tt.primary},
+				FallbackModel{Model: "gpt-4o-mini", Provider: tt.secondary},
+			)
+			got, err := f.Complete(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Complete() = %q, want %q", got, tt.want)
+			}
+			if tt.primary.calls != tt.wantPrimary || tt.secondary.calls != tt.wantSecondary {
+				t.Errorf("calls = %d, %d, want %d, %d", tt.primary.calls, tt.secondary.calls, tt.wantPrimary, tt.wantSecondary)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/sync/errgroup"
+	"strings"
+	"sync"
+)
+
+const (
+	visionModel         = "gpt-4o"
+	noFiguresAnswer     = "NONE"
+	maxParallelVisions  = 3
+	maxImageFiguresSize = 512
+)
+
+// ExtractImageFigures uses the vision model to extract key figures from the main image of the news
+// (charts, tables) and saves them to News.ImageFigures, so they are used in the Compose prompt.
+// Some sources publish key numbers only as images, so without this step they will be lost.
+//
+// News without image or flagged news are skipped. Errors for the single news don't stop processing
+// of the others, they are returned joined at the end.
+func (c *Composer) ExtractImageFigures(ctx context.Context, news journalist.NewsList) error {
+	var eg errgroup.Group
+	eg.SetLimit(maxParallelVisions)
+
+	var mu sync.Mutex
+	var errs []error
+
+	for _, n := range news {
+		if n.ImageURL == "" || n.IsFiltered || n.IsSuspicious {
+			continue
+		}
+
+		eg.Go(func() error {
+			figures, err := c.extractFigures(ctx, n)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return nil
+			}
+
+			n.ImageFigures = figures
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	if len(errs) > 0 {
+		return newError(errors.Join(errs...), errlvl.WARN, "ExtractImageFigures", "OpenAiClient.CreateChatCompletion")
+	}
+
+	return nil
+}
+
+func (c *Composer) extractFigures(ctx context.Context, n *journalist.News) (string, error) {
+	resp, err := c.OpenAiClient.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: visionModel,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: c.prompt(PromptImageFigures, PromptData{}, func() string { return c.Config.ImageFiguresPrompt }),
+				},
+				{
+					Role: openai.ChatMessageRoleUser,
+					MultiContent: []openai.ChatMessagePart{
+						{
+							Type: openai.ChatMessagePartTypeText,
+							Text: n.Title,
+						},
+						{
+							Type: openai.ChatMessagePartTypeImageURL,
+							ImageURL: &openai.ChatMessageImageURL{
+								URL:    n.ImageURL,
+								Detail: openai.ImageURLDetailLow,
+							},
+						},
+					},
+				},
+			},
+			Temperature: 0,
+			MaxTokens:   256,
+		},
+	)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	recordUsage(c.metrics, c.usage, Usage{
+		Provider:         ProviderOpenAI,
+		Model:            visionModel,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	})
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response from the vision model")
+	}
+
+	figures := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if strings.EqualFold(figures, noFiguresAnswer) {
+		return "", nil
+	}
+	if len(figures) > maxImageFiguresSize {
+		figures = figures[:maxImageFiguresSize]
+	}
+
+	return figures, nil
+}
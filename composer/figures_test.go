@@ -0,0 +1,99 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestComposer_ExtractImageFigures(t *testing.T) {
+	chatResponse := func(content string) openai.ChatCompletionResponse {
+		return openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: content}}},
+		}
+	}
+	withImage := func(url string) interface{} {
+		return mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+			return req.Model == visionModel && req.Messages[1].MultiContent[1].ImageURL.URL == url
+		})
+	}
+
+	tests := []struct {
+		name        string
+		news        journalist.NewsList
+		setup       func(m *MockOpenAiClient)
+		wantFigures []string
+		wantErr     bool
+	}{
+		{
+			name: "figures extracted from chart",
+			news: journalist.NewsList{
+				{ID: "1", Title: "Inflation cools", ImageURL: "https://example.com/chart.png"},
+				{ID: "2", Title: "No image"},
+			},
+			setup: func(m *MockOpenAiClient) {
+				m.On("CreateChatCompletion", mock.Anything, withImage("https://example.com/chart.png")).
+					Return(chatResponse(" CPI YoY: 3.1% (Jan) "), nil)
+			},
+			wantFigures: []string{"CPI YoY: 3.1% (Jan)", ""},
+		},
+		{
+			name: "image without figures",
+			news: journalist.NewsList{
+				{ID: "1", Title: "CEO steps down", ImageURL: "https://example.com/photo.jpg"},
+			},
+			setup: func(m *MockOpenAiClient) {
+				m.On("CreateChatCompletion", mock.Anything, withImage("https://example.com/photo.jpg")).
+					Return(chatResponse("NONE"), nil)
+			},
+			wantFigures: []string{""},
+		},
+		{
+			name: "flagged news are skipped",
+			news: journalist.NewsList{
+				{ID: "1", Title: "Buy now", ImageURL: "https://example.com/ad.png", IsSuspicious: true},
+			},
+			setup:       func(m *MockOpenAiClient) {},
+			wantFigures: []string{""},
+		},
+		{
+			name: "error doesn't stop other news",
+			news: journalist.NewsList{
+				{ID: "1", Title: "GDP", ImageURL: "https://example.com/broken.png"},
+				{ID: "2", Title: "Jobs", ImageURL: "https://example.com/jobs.png"},
+			},
+			setup: func(m *MockOpenAiClient) {
+				m.On("CreateChatCompletion", mock.Anything, withImage("https://example.com/broken.png")).
+					Return(openai.ChatCompletionResponse{}, errors.New("invalid image"))
+				m.On("CreateChatCompletion", mock.Anything, withImage("https://example.com/jobs.png")).
+					Return(chatResponse("NFP: 353K (Jan)"), nil)
+			},
+			wantFigures: []string{"", "NFP: 353K (Jan)"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockOpenAiClient)
+			tt.setup(mockClient)
+			c := &Composer{
+				OpenAiClient: mockClient,
+				Config:       defaultPromptConfig(),
+			}
+
+			err := c.ExtractImageFigures(context.Background(), tt.news)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractImageFigures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, n := range tt.news {
+				if n.ImageFigures != tt.wantFigures[i] {
+					t.Errorf("ExtractImageFigures() news %s figures = %q, want %q", n.ID, n.ImageFigures, tt.wantFigures[i])
+				}
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package composer
+
+// MaxImportance is the importance of the most important story of the day, see ComposedNews.Importance.
+const MaxImportance = 100
+
+// normalizeImportance clamps the importance returned by the model to 0..MaxImportance.
+func normalizeImportance(i *int) *int {
+	if i == nil {
+		return nil
+	}
+
+	v := min(max(*i, 0), MaxImportance)
+	return &v
+}
@@ -0,0 +1,42 @@
+package composer
+
+import (
+	"testing"
+)
+
+func Test_normalizeImportance(t *testing.T) {
+	value := func(i int) *int { return &i }
+
+	tests := []struct {
+		name string
+		i    *int
+		want *int
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "valid",
+			i:    value(75),
+			want: value(75),
+		},
+		{
+			name: "negative",
+			i:    value(-5),
+			want: value(0),
+		},
+		{
+			name: "over the max",
+			i:    value(150),
+			want: value(MaxImportance),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeImportance(tt.i)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("normalizeImportance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
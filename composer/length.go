@@ -0,0 +1,153 @@
+package composer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lengthLimit is the max length of the composed text, zero fields are not limited.
+type lengthLimit struct {
+	sentences int // max number of sentences
+	chars     int // max number of characters (runes)
+}
+
+// lengthLimit returns the length limit of the options, the limits of the Config are used for the zero ones.
+func (c *Composer) lengthLimit(opts ComposeOptions) lengthLimit {
+	l := lengthLimit{sentences: opts.MaxSentences, chars: opts.MaxChars}
+	if l.sentences <= 0 {
+		l.sentences = c.Config.MaxSentences
+	}
+	if l.chars <= 0 {
+		l.chars = c.Config.MaxChars
+	}
+	return l
+}
+
+// empty reports whether the text length is not limited.
+func (l lengthLimit) empty() bool {
+	return l.sentences <= 0 && l.chars <= 0
+}
+
+// String returns the limit description for the prompts, e.g. "2 sentences and 300 characters".
+func (l lengthLimit) String() string {
+	var parts []string
+	if l.sentences > 0 {
+		parts = append(parts, fmt.Sprintf("%d sentences", l.sentences))
+	}
+	if l.chars > 0 {
+		parts = append(parts, fmt.Sprintf("%d characters", l.chars))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// cacheKey returns the part of the cache key of the news composed with the limit, empty if not limited.
+func (l lengthLimit) cacheKey() string {
+	if l.empty() {
+		return ""
+	}
+	return fmt.Sprintf("s%dc%d", l.sentences, l.chars)
+}
+
+// apply adds the length instruction to the compose prompt. The prompt is kept as is if the length is not limited.
+func (l lengthLimit) apply(prompt string) string {
+	if l.empty() {
+		return prompt
+	}
+	return fmt.Sprintf("%s\t\tLENGTH (overrides the length above): The 'text' is %s max.\n", prompt, l)
+}
+
+// fits reports whether the text is within the limit.
+func (l lengthLimit) fits(text string) bool {
+	if l.chars > 0 && utf8.RuneCountInString(text) > l.chars {
+		return false
+	}
+	return l.sentences <= 0 || len(splitSentences(text)) <= l.sentences
+}
+
+// trim cuts the text to the limit: the extra sentences are dropped, then the text over the max characters
+// is cut at the last word boundary with the ellipsis.
+func (l lengthLimit) trim(text string) string {
+	if l.sentences > 0 {
+		if sentences := splitSentences(text); len(sentences) > l.sentences {
+			text = strings.Join(sentences[:l.sentences], " ")
+		}
+	}
+	if l.chars <= 0 || utf8.RuneCountInString(text) <= l.chars {
+		return text
+	}
+
+	runes := []rune(text)[:l.chars-1]
+	if i := strings.LastIndexFunc(string(runes), unicode.IsSpace); i > 0 {
+		return strings.TrimRightFunc(string(runes)[:i], unicode.IsPunct) + "…"
+	}
+	return string(runes) + "…"
+}
+
+// splitSentences splits the text into sentences by the terminal punctuation followed by the space.
+// Dots of the abbreviations (e.g. "U.S.") and numbers (e.g. "1.5%") don't end the sentence.
+func splitSentences(text string) []string {
+	var sentences []string
+	words := strings.Fields(text)
+	start := 0
+	for i, w := range words {
+		last := w[len(w)-1]
+		if last != '.' && last != '!' && last != '?' {
+			continue
+		}
+		if last == '.' && strings.Contains(w[:len(w)-1], ".") {
+			continue
+		}
+		sentences = append(sentences, strings.Join(words[start:i+1], " "))
+		start = i + 1
+	}
+	if start < len(words) {
+		sentences = append(sentences, strings.Join(words[start:], " "))
+	}
+	return sentences
+}
+
+// shortenPrompt asks the model to rewrite the composed text that doesn't fit the length limit.
+const shortenPrompt = `You will receive the text of the financial news.
+		Rewrite it to be %s max. Keep the key facts, numbers and tickers, drop the details and context first.
+		Answer with the rewritten text only, no quotes, explanation or other text is allowed.
+`
+
+// enforceLength rewrites the texts of the composed news over the limit once and trims the ones still over the limit.
+// The texts are trimmed if the rewrite fails, so the length is always within the limit.
+func (c *Composer) enforceLength(ctx context.Context, composed []*ComposedNews, l lengthLimit) {
+	if l.empty() {
+		return
+	}
+	for _, n := range composed {
+		if l.fits(n.Text) {
+			continue
+		}
+		if text, err := c.shorten(ctx, n.Text, l); err == nil && text != "" {
+			n.Text = text
+		}
+		if !l.fits(n.Text) {
+			n.Text = l.trim(n.Text)
+		}
+	}
+}
+
+// shorten asks the model to rewrite the text within the length limit.
+func (c *Composer) shorten(ctx context.Context, text string, l lengthLimit) (string, error) {
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System:      fmt.Sprintf(shortenPrompt, l),
+			User:        text,
+			Temperature: 0,
+			MaxTokens:   c.Config.ComposeParams.MaxTokens,
+			TopP:        1,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(strings.TrimSpace(resp), `"`), nil
+}
@@ -0,0 +1,140 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/sashabaranov/go-openai"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_splitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "sentences",
+			text: "Fed cuts rates by 50 bps. Stocks rally! Is it over?",
+			want: []string{"Fed cuts rates by 50 bps.", "Stocks rally!", "Is it over?"},
+		},
+		{
+			name: "abbreviations and numbers",
+			text: "U.S. GDP grew 2.5% in Q3. Markets are up",
+			want: []string{"U.S. GDP grew 2.5% in Q3.", "Markets are up"},
+		},
+		{
+			name: "empty",
+			text: "  ",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_lengthLimit_trim(t *testing.T) {
+	text := "Fed cuts rates by 50 bps. Stocks rally on the news. Bonds fall."
+	tests := []struct {
+		name  string
+		limit lengthLimit
+		want  string
+	}{
+		{
+			name:  "not limited",
+			limit: lengthLimit{},
+			want:  text,
+		},
+		{
+			name:  "sentences",
+			limit: lengthLimit{sentences: 2},
+			want:  "Fed cuts rates by 50 bps. Stocks rally on the news.",
+		},
+		{
+			name:  "chars at the word boundary",
+			limit: lengthLimit{chars: 30},
+			want:  "Fed cuts rates by 50 bps…",
+		},
+		{
+			name:  "sentences and chars",
+			limit: lengthLimit{sentences: 1, chars: 100},
+			want:  "Fed cuts rates by 50 bps.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.limit.trim(text)
+			if got != tt.want {
+				t.Errorf("trim() = %q, want %q", got, tt.want)
+			}
+			if !tt.limit.fits(got) {
+				t.Errorf("trim() = %q doesn't fit the limit", got)
+			}
+		})
+	}
+}
+
+func Test_composeCacheKey(t *testing.T) {
+	if got := composeCacheKey("1", StyleDefault, lengthLimit{}); got != "1" {
+		t.Errorf("composeCacheKey() = %q, want the hash", got)
+	}
+	if got := composeCacheKey("1", StyleConcise, lengthLimit{}); got != "concise:1" {
+		t.Errorf("composeCacheKey() = %q, want concise:1", got)
+	}
+	if got := composeCacheKey("1", StyleDefault, lengthLimit{sentences: 2, chars: 500}); got != ":s2c500:1" {
+		t.Errorf("composeCacheKey() = %q, want :s2c500:1", got)
+	}
+}
+
+func TestComposer_ComposeWith_length(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Fed cuts rates by 50 bps", Date: time.Now().UTC()},
+		{ID: "2", Title: "Apple beats estimates", Date: time.Now().UTC()},
+	}
+	answer := `[{"id":"1","text":"The Fed cut rates by 50 bps. It is the first cut since 2020. Stocks rallied.","tickers":[],"markets":[],"hashtags":[]},` +
+		`{"id":"2","text":"Apple beat the estimates. Shares rose 3% after the report. Services revenue hit a record.","tickers":["AAPL"],"markets":[],"hashtags":[]}]`
+
+	mockClient := new(MockOpenAiClient)
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+		return strings.Contains(req.Messages[0].Content, "The 'text' is 2 sentences and 60 characters max.")
+	})).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: answer}}},
+	}, nil).Once()
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+		return strings.HasPrefix(req.Messages[1].Content, "The Fed")
+	})).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: `"The Fed cut rates by 50 bps, stocks rallied."`}}},
+	}, nil).Once()
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+		return strings.HasPrefix(req.Messages[1].Content, "Apple")
+	})).Return(openai.ChatCompletionResponse{}, errors.New("timeout")).Once()
+
+	c := &Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}
+	c.Config.MaxChars = 60
+
+	got, err := c.ComposeWith(context.Background(), news, ComposeOptions{MaxSentences: 2})
+	if err != nil {
+		t.Fatalf("ComposeWith() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ComposeWith() = %v, want 2 news", got)
+	}
+	if got[0].Text != "The Fed cut rates by 50 bps, stocks rallied." {
+		t.Errorf("ComposeWith() text = %q, want the rewritten text", got[0].Text)
+	}
+	if got[1].Text != "Apple beat the estimates. Shares rose 3% after the report." {
+		t.Errorf("ComposeWith() text = %q, want the trimmed text", got[1].Text)
+	}
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,178 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/sashabaranov/go-openai"
+	"strings"
+)
+
+// Supported LLM providers for the text completion methods of the Composer.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// LLMProvider is a backend that creates text completions for the Composer prompts.
+type LLMProvider interface {
+	Complete(ctx context.Context, req LLMRequest) (string, error)
+}
+
+// LLMRequest is a provider-agnostic completion request.
+type LLMRequest struct {
+	System      string   // system prompt with the instructions
+	User        string   // user message (usually JSON with news or headlines)
+	MaxTokens   int      // hard limit of the completion size in tokens
+	Temperature float32  // sampling temperature
+	TopP        float32  // nucleus sampling, ignored by providers that don't support it along with temperature
+	Stop        []string // stop sequences
+	Prefill     string   // beginning of the answer for providers that support prefilling (e.g. "[" for JSON arrays)
+	JSON        bool     // answer is expected to be JSON, providers can enable their JSON output mode
+	Schema      *Schema  // schema of the structured answer (optional), providers that support it answer with the JSON object
+}
+
+// OpenAIProvider creates completions with the OpenAI chat completions API
+// or any OpenAI-compatible server (Ollama, vLLM, etc.).
+type OpenAIProvider struct {
+	Client   openAiClientInterface
+	Model    string
+	JSONMode bool // if true, JSON requests are sent with the json_object response format
+	Tools    bool // if true, requests with the Schema are sent with the forced function call
+	metrics  metrics.Emitter
+	usage    UsageObserver
+}
+
+// NewOpenAIProvider creates a new OpenAIProvider with the default model.
+func NewOpenAIProvider(client openAiClientInterface) *OpenAIProvider {
+	return &OpenAIProvider{
+		Client:  client,
+		Model:   openai.GPT3Dot5Turbo0125,
+		Tools:   true,
+		metrics: metrics.Noop{},
+	}
+}
+
+// WithMetrics sets the metrics emitter for the token usage.
+func (o *OpenAIProvider) WithMetrics(m metrics.Emitter) *OpenAIProvider {
+	o.metrics = m
+	return o
+}
+
+// ObserveUsage sets the observer that will receive the token usage of each request.
+func (o *OpenAIProvider) ObserveUsage(u UsageObserver) *OpenAIProvider {
+	o.usage = u
+	return o
+}
+
+// NewOpenAICompatibleProvider creates a new OpenAIProvider for the OpenAI-compatible server
+// (e.g. "http://localhost:11434/v1" for Ollama) and the given model. JSON output mode is enabled
+// only for the models known to support it, other models rely on the lenient JSON parsing.
+// Function calling for the structured answers is used only with the OpenAI API.
+// Empty baseURL or model fall back to the OpenAI defaults.
+func NewOpenAICompatibleProvider(token, baseURL, model string) *OpenAIProvider {
+	config := openai.DefaultConfig(token)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+
+	p := NewOpenAIProvider(openai.NewClientWithConfig(config))
+	if model != "" {
+		p.Model = model
+	}
+	p.JSONMode = supportsJSONMode(p.Model)
+	p.Tools = baseURL == ""
+
+	return p
+}
+
+// jsonModeModels is a list of model name prefixes that support the json_object response format
+// and reliably produce valid JSON with it.
+var jsonModeModels = []string{
+	"gpt-3.5-turbo",
+	"gpt-4",
+	"llama3",
+	"qwen2",
+	"mistral",
+	"mixtral",
+	"gemma2",
+}
+
+// supportsJSONMode checks if the model supports JSON output mode. Model names may contain
+// the namespace and the tag (e.g. "meta-llama/llama3.1:8b"), so only the base name is compared.
+func supportsJSONMode(model string) bool {
+	name := strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	for _, prefix := range jsonModeModels {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete creates a new chat completion. OpenAI doesn't support prefilling, so Prefill is ignored.
+// Requests with the Schema are answered with the arguments of the forced function call.
+func (o *OpenAIProvider) Complete(ctx context.Context, req LLMRequest) (string, error) {
+	var format *openai.ChatCompletionResponseFormat
+	if o.JSONMode && req.JSON {
+		format = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+
+	var tools []openai.Tool
+	var toolChoice any
+	if o.Tools && req.Schema != nil {
+		tools = []openai.Tool{{
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionDefinition{
+				Name:        req.Schema.Name,
+				Description: req.Schema.Description,
+				Parameters:  req.Schema.Definition,
+			},
+		}}
+		toolChoice = openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: req.Schema.Name}}
+		format = nil
+	}
+
+	resp, err := o.Client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: o.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: req.System,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: req.User,
+				},
+			},
+			Temperature:    req.Temperature,
+			MaxTokens:      req.MaxTokens,
+			TopP:           req.TopP,
+			Stop:           req.Stop,
+			ResponseFormat: format,
+			Tools:          tools,
+			ToolChoice:     toolChoice,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	recordUsage(o.metrics, o.usage, Usage{
+		Provider:         ProviderOpenAI,
+		Model:            o.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	})
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response")
+	}
+	for _, call := range resp.Choices[0].Message.ToolCalls {
+		if req.Schema != nil && call.Function.Name == req.Schema.Name {
+			return call.Function.Arguments, nil
+		}
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
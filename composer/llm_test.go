@@ -0,0 +1,92 @@
+package composer
+
+import (
+	"context"
+	"github.com/sashabaranov/go-openai"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_supportsJSONMode(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{model: openai.GPT3Dot5Turbo0125, want: true},
+		{model: "llama3.1:8b", want: true},
+		{model: "meta-llama/Llama3-70B-Instruct", want: true},
+		{model: "deepseek-r1:14b", want: false},
+		{model: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := supportsJSONMode(tt.model); got != tt.want {
+				t.Errorf("supportsJSONMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_Complete(t *testing.T) {
+	tests := []struct {
+		name       string
+		jsonMode   bool
+		req        LLMRequest
+		wantFormat bool
+	}{
+		{
+			name:       "json mode for json request",
+			jsonMode:   true,
+			req:        LLMRequest{System: "system", User: "[]", JSON: true},
+			wantFormat: true,
+		},
+		{
+			name:     "json mode for text request",
+			jsonMode: true,
+			req:      LLMRequest{System: "system", User: "[]"},
+		},
+		{
+			name: "json request without json mode",
+			req:  LLMRequest{System: "system", User: "[]", JSON: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+				return req.Model == "llama3.1" && (req.ResponseFormat != nil) == tt.wantFormat
+			})).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "[]"}}},
+			}, nil)
+
+			p := &OpenAIProvider{Client: mockClient, Model: "llama3.1", JSONMode: tt.jsonMode}
+			got, err := p.Complete(context.Background(), tt.req)
+			if err != nil || got != "[]" {
+				t.Errorf("Complete() = %q, %v", got, err)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOpenAIProvider_Complete_schema(t *testing.T) {
+	mockClient := new(MockOpenAiClient)
+	mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+		return len(req.Tools) == 1 && req.Tools[0].Function.Name == composedNewsSchema.Name && req.ResponseFormat == nil
+	})).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{
+			ToolCalls: []openai.ToolCall{{
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: composedNewsSchema.Name, Arguments: `{"news":[]}`},
+			}},
+		}}},
+	}, nil)
+
+	p := &OpenAIProvider{Client: mockClient, Model: openai.GPT3Dot5Turbo0125, JSONMode: true, Tools: true}
+	got, err := p.Complete(context.Background(), LLMRequest{System: "system", User: "[]", JSON: true, Schema: composedNewsSchema})
+	if err != nil || got != `{"news":[]}` {
+		t.Errorf("Complete() = %q, %v", got, err)
+	}
+	mockClient.AssertExpectations(t)
+}
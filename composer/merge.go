@@ -0,0 +1,123 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"time"
+	"unicode/utf8"
+)
+
+// mergedContentLength is the max length of the article text of each merged news (runes), so the prompt
+// of the story reported by many sources stays small.
+const mergedContentLength = 1500
+
+var errNoMergedStory = errors.New("no story with the id of the first news in the answer")
+
+// Source is the source of the news merged into the composed story.
+type Source struct {
+	Name string `json:"name"` // name of the provider
+	URL  string `json:"url"`  // link to the news of the provider
+}
+
+// ComposeMerged composes one story of the related news reported by several sources (e.g. the same event
+// from five providers): the LLM combines the facts of all news into one text citing the sources.
+// The story gets the ID of the first fresh news and the others are its Sources. Flagged news are skipped.
+// Returns nil if there are no fresh news to compose.
+func (c *Composer) ComposeMerged(ctx context.Context, news journalist.NewsList, opts ComposeOptions) (*ComposedNews, error) {
+	window := opts.Window
+	if window <= 0 {
+		window = c.Config.FreshnessWindow
+	}
+	fresh := filterFresh(news.RemoveFlagged(), window, time.Now())
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+
+	style := opts.Style
+	if style == StyleDefault {
+		style = c.style
+	}
+
+	jsonNews, err := mergedNewsJSON(fresh)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ComposeMerged", "json.Marshal")
+	}
+
+	length := c.lengthLimit(opts)
+	data := PromptData{MaxLen: style.MaxWords(), Style: string(style), MaxSentences: length.sentences, MaxChars: length.chars}
+	prompt := c.prompt(PromptComposeMerged, data, func() string {
+		return length.apply(style.apply(c.Config.ComposeMergedPrompt))
+	})
+
+	composed, err := c.composeJSON(ctx, jsonNews, prompt, c.Config.ComposeParams)
+	if err != nil {
+		return nil, err
+	}
+
+	lead := fresh[0]
+	for _, n := range composed {
+		if n.ID == lead.ID {
+			c.enforceLength(ctx, []*ComposedNews{n}, length)
+			c.verify(ctx, []*ComposedNews{n}, mergedSource(lead.ID, fresh), length)
+			n.Sources = mergedSources(fresh)
+			return n, nil
+		}
+	}
+	return nil, newError(errNoMergedStory, errlvl.WARN, "ComposeMerged", "composeJSON")
+}
+
+// mergedNewsJSON returns the JSON of the merged news content with the names of their sources.
+func mergedNewsJSON(news journalist.NewsList) (string, error) {
+	type sourceNews struct {
+		ID          string `json:"id"`
+		Source      string `json:"source"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Figures     string `json:"figures,omitempty"`
+		Content     string `json:"content,omitempty"`
+	}
+
+	items := make([]*sourceNews, len(news))
+	for i, n := range news {
+		items[i] = &sourceNews{
+			ID:          n.ID,
+			Source:      n.ProviderName,
+			Title:       n.Title,
+			Description: n.Description,
+			Figures:     n.ImageFigures,
+			Content:     truncateRunes(n.Content, mergedContentLength),
+		}
+	}
+
+	b, err := json.Marshal(items)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return string(b), nil
+}
+
+// mergedSources returns the sources of the news after the first one, the first news is the source of the story itself.
+// News without the link and the links repeated are skipped.
+func mergedSources(news journalist.NewsList) []Source {
+	seen := map[string]bool{news[0].Link: true}
+	var sources []Source
+	for _, n := range news[1:] {
+		if n.Link == "" || seen[n.Link] {
+			continue
+		}
+		seen[n.Link] = true
+		sources = append(sources, Source{Name: n.ProviderName, URL: n.Link})
+	}
+	return sources
+}
+
+// truncateRunes cuts the string to the max number of runes.
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
@@ -0,0 +1,70 @@
+package composer
+
+import (
+	"context"
+	"github.com/sashabaranov/go-openai"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestComposer_ComposeMerged(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Fed holds rates", ProviderName: "reuters", Link: "https://reuters.com/fed", Date: time.Now().UTC()},
+		{ID: "2", Title: "Fed keeps rates steady", ProviderName: "cnbc", Link: "https://cnbc.com/fed", Date: time.Now().UTC()},
+		{ID: "3", Title: "Fed holds", ProviderName: "reuters", Link: "https://reuters.com/fed", Date: time.Now().UTC()},
+		{ID: "4", Title: "Fed is on hold", ProviderName: "spam", Link: "https://spam.com/fed", Date: time.Now().UTC(), IsSuspicious: true},
+	}
+
+	tests := []struct {
+		name    string
+		answer  string
+		want    *ComposedNews
+		wantErr bool
+	}{
+		{
+			name:   "merged story",
+			answer: `[{"id":"1","text":"The Fed held rates, according to Reuters and CNBC.","tickers":[],"markets":["SPY"],"hashtags":["fed"]}]`,
+			want: &ComposedNews{
+				ID:       "1",
+				Text:     "The Fed held rates, according to Reuters and CNBC.",
+				Tickers:  []string{},
+				Markets:  []string{"SPY"},
+				Hashtags: []string{"fed"},
+				Sources:  []Source{{Name: "cnbc", URL: "https://cnbc.com/fed"}},
+			},
+		},
+		{
+			name:    "story of the other news",
+			answer:  `[{"id":"2","text":"The Fed held rates.","tickers":[],"markets":[],"hashtags":[]}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+				// Sources are cited by their names, the flagged news are not merged
+				return strings.HasPrefix(req.Messages[0].Content, defaultPromptConfig().ComposeMergedPrompt) &&
+					strings.Contains(req.Messages[1].Content, `"source":"cnbc"`) &&
+					!strings.Contains(req.Messages[1].Content, `"id":"4"`)
+			})).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: tt.answer}}},
+			}, nil).Once()
+
+			c := &Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}
+			got, err := c.ComposeMerged(context.Background(), news, ComposeOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ComposeMerged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComposeMerged() = %+v, want %+v", got, tt.want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"strings"
+)
+
+// DefaultMetaMarkets are the markets (index and asset class ETF tickers) the meta extraction chooses from
+// if the markets are not set, see WithMetaExtraction.
+var DefaultMetaMarkets = []string{"SPY", "QQQ", "DIA", "IWM", "VIX", "TLT", "GLD", "SLV", "USO", "UNG", "UUP", "EEM", "FXI", "BTC"}
+
+// metaHashtags are the hashtags the news can be tagged with, the same as in the compose prompts.
+var metaHashtags = []string{
+	"inflation", "interestrates", "crisis", "unemployment", "bankruptcy", "dividends", "IPO",
+	"debt", "war", "buybacks", "fed", "AI", "crypto", "bitcoin",
+}
+
+// skipMetaInstruction is added to the compose prompt if the meta is extracted by the separate request.
+const skipMetaInstruction = "\t\tMETA (overrides the meta instructions above): leave 'tickers', 'markets' and 'hashtags' empty, " +
+	"they are filled by the separate request.\n"
+
+// NewsMeta is the meta of the news found by the ExtractMeta.
+type NewsMeta struct {
+	ID       string   `json:"id"`
+	Tickers  []string `json:"tickers"`
+	Markets  []string `json:"markets"`
+	Hashtags []string `json:"hashtags"`
+}
+
+// metaSchema returns the schema of the ExtractMeta answer with the markets and hashtags constrained to the lists.
+func metaSchema(markets []string) *Schema {
+	list := func(enum []string) jsonschema.Definition {
+		return jsonschema.Definition{Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String, Enum: enum}}
+	}
+	return &Schema{
+		Name:        "tag_news",
+		Description: "Tag the news with the mentioned stock tickers, the affected markets and the topic hashtags",
+		Definition: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"news": {
+					Type: jsonschema.Array,
+					Items: &jsonschema.Definition{
+						Type: jsonschema.Object,
+						Properties: map[string]jsonschema.Definition{
+							"id": {Type: jsonschema.String, Description: "ID of the original news"},
+							"tickers": {
+								Type:        jsonschema.Array,
+								Items:       &jsonschema.Definition{Type: jsonschema.String},
+								Description: "Tickers of the mentioned stocks, no ETFs or crypto",
+							},
+							"markets":  list(markets),
+							"hashtags": list(metaHashtags),
+						},
+						Required: []string{"id", "tickers", "markets", "hashtags"},
+					},
+				},
+			},
+			Required: []string{"news"},
+		},
+	}
+}
+
+// WithMetaExtraction sets the provider of the separate meta extraction request (see ExtractMeta), e.g. the cheaper
+// model than the one composing the text. The compose requests leave the tickers, markets and hashtags empty
+// and they are filled by the provider choosing the markets from the list (DefaultMetaMarkets if empty).
+// Note: the lite and merged compose requests find the meta themselves.
+func (c *Composer) WithMetaExtraction(p LLMProvider, markets []string) *Composer {
+	if len(markets) == 0 {
+		markets = DefaultMetaMarkets
+	}
+	c.meta = p
+	c.metaMarkets = markets
+	return c
+}
+
+// ExtractMeta finds the tickers, markets and hashtags of the news with the meta extraction provider
+// (see WithMetaExtraction). Providers that support function calling are constrained to the markets and hashtags
+// from the lists, the values out of the lists are dropped from the answers of the others.
+func (c *Composer) ExtractMeta(ctx context.Context, news journalist.NewsList) ([]*NewsMeta, error) {
+	if len(news) == 0 {
+		return nil, nil
+	}
+
+	jsonNews, err := news.ToContentJSON()
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ExtractMeta", "NewsList.ToContentJSON")
+	}
+
+	data := PromptData{Markets: strings.Join(c.metaMarkets, ", ")}
+	resp, err := c.meta.Complete(
+		ctx,
+		LLMRequest{
+			System:      c.prompt(PromptMeta, data, func() string { return c.Config.MetaPrompt(c.metaMarkets, metaHashtags) }),
+			User:        jsonNews,
+			Temperature: c.Config.MetaParams.Temperature,
+			MaxTokens:   c.Config.MetaParams.MaxTokens,
+			TopP:        c.Config.MetaParams.TopP,
+			Prefill:     "[",
+			JSON:        true,
+			Schema:      metaSchema(c.metaMarkets),
+		},
+	)
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "ExtractMeta", "LLM.Complete")
+	}
+
+	metas, err := parseNewsMeta(resp)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ExtractMeta", "parseNewsMeta").WithValue(resp)
+	}
+
+	for _, m := range metas {
+		for i, t := range m.Tickers {
+			m.Tickers[i] = utils.ReplaceUnicodeSymbols(t)
+		}
+		if c.securities != nil {
+			m.Tickers = c.securities.Normalize(m.Tickers)
+		}
+		m.Markets = filterAllowed(m.Markets, c.metaMarkets)
+		m.Hashtags = filterAllowed(m.Hashtags, metaHashtags)
+	}
+
+	return metas, nil
+}
+
+// fillMeta replaces the meta of the composed news with the extracted one. News missing in the answer
+// are left without the meta.
+func fillMeta(composed []*ComposedNews, metas []*NewsMeta) {
+	byID := make(map[string]*NewsMeta, len(metas))
+	for _, m := range metas {
+		byID[m.ID] = m
+	}
+	for _, n := range composed {
+		m, ok := byID[n.ID]
+		if !ok {
+			n.Tickers, n.Markets, n.Hashtags = []string{}, []string{}, []string{}
+			continue
+		}
+		n.Tickers, n.Markets, n.Hashtags = m.Tickers, m.Markets, m.Hashtags
+	}
+}
+
+// parseNewsMeta parses the ExtractMeta answer: the structured answer object (see metaSchema)
+// or the free-form JSON array.
+func parseNewsMeta(answer string) ([]*NewsMeta, error) {
+	var structured struct {
+		News []*NewsMeta `json:"news"`
+	}
+	trimmed := strings.TrimSpace(answer)
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &structured) == nil && structured.News != nil {
+		return structured.News, nil
+	}
+
+	matches, err := aiJSONStringFixer(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	var metas []*NewsMeta
+	if err := json.Unmarshal([]byte(matches), &metas); err != nil {
+		return nil, err
+	}
+	return metas, nil
+}
+
+// filterAllowed returns the values found in the allowed list (case-insensitive) spelled as in the list.
+// Duplicates are removed, the result is never nil.
+func filterAllowed(values, allowed []string) []string {
+	result := []string{}
+	for _, v := range values {
+		for _, a := range allowed {
+			if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(v), "#"), a) && !containsString(result, a) {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// containsString reports whether the list contains the value.
+func containsString(list []string, value string) bool {
+	for _, s := range list {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/samgozman/fin-thread/journalist"
+)
+
+func Test_parseNewsMeta(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    []*NewsMeta
+		wantErr bool
+	}{
+		{
+			name:   "function call arguments",
+			answer: `{"news":[{"id":"1","tickers":["AAPL"],"markets":["QQQ"],"hashtags":["AI"]}]}`,
+			want:   []*NewsMeta{{ID: "1", Tickers: []string{"AAPL"}, Markets: []string{"QQQ"}, Hashtags: []string{"AI"}}},
+		},
+		{
+			name:   "array with the text around",
+			answer: "Sure: [{\"id\":\"1\",\"tickers\":[],\"markets\":[\"SPY\"],\"hashtags\":[]}]",
+			want:   []*NewsMeta{{ID: "1", Tickers: []string{}, Markets: []string{"SPY"}, Hashtags: []string{}}},
+		},
+		{
+			name:    "not JSON",
+			answer:  "no meta found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNewsMeta(tt.answer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNewsMeta() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNewsMeta() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_filterAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		allowed []string
+		want    []string
+	}{
+		{
+			name:    "case and hash sign",
+			values:  []string{"#Fed", "ai", "stocks", "FED"},
+			allowed: metaHashtags,
+			want:    []string{"fed", "AI"},
+		},
+		{
+			name:    "nothing allowed",
+			values:  []string{"RUT"},
+			allowed: DefaultMetaMarkets,
+			want:    []string{},
+		},
+		{
+			name:    "empty",
+			values:  nil,
+			allowed: DefaultMetaMarkets,
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterAllowed(tt.values, tt.allowed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposer_ComposeWith_meta(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Fed cuts rates by 50 bps", Date: time.Now().UTC()},
+		{ID: "2", Title: "Apple beats estimates", Date: time.Now().UTC()},
+	}
+	composeAnswer := `[{"id":"1","text":"The Fed cut rates by 50 bps.","tickers":[],"markets":["SPY"],"hashtags":["fed"]},` +
+		`{"id":"2","text":"Apple beat the estimates.","tickers":[],"markets":[],"hashtags":[]}]`
+	metaAnswer := `{"news":[{"id":"1","tickers":[],"markets":["SPY","RUT"],"hashtags":["fed","interestrates"]},` +
+		`{"id":"2","tickers":["AAPL"],"markets":["QQQ"],"hashtags":[]}]}`
+
+	t.Run("meta is filled by the separate request", func(t *testing.T) {
+		meta := &scriptedProvider{answers: []string{metaAnswer}}
+		c := &Composer{LLM: &scriptedProvider{answers: []string{composeAnswer}}, Config: defaultPromptConfig()}
+		c.WithMetaExtraction(meta, []string{"SPY", "QQQ"})
+
+		got, err := c.ComposeWith(context.Background(), news, ComposeOptions{})
+		if err != nil {
+			t.Fatalf("ComposeWith() error = %v", err)
+		}
+		if meta.calls != 1 || len(got) != 2 {
+			t.Fatalf("ComposeWith() = %v with %d meta requests, want 2 news and 1 request", got, meta.calls)
+		}
+		if got[0].Text != "The Fed cut rates by 50 bps." {
+			t.Errorf("ComposeWith() text = %q, want the composed text", got[0].Text)
+		}
+		if !reflect.DeepEqual(got[0].Markets, []string{"SPY"}) || !reflect.DeepEqual(got[0].Hashtags, []string{"fed", "interestrates"}) {
+			t.Errorf("ComposeWith() meta = %v %v, want the extracted meta within the lists", got[0].Markets, got[0].Hashtags)
+		}
+		if !reflect.DeepEqual(got[1].Tickers, []string{"AAPL"}) || !reflect.DeepEqual(got[1].Markets, []string{"QQQ"}) {
+			t.Errorf("ComposeWith() meta = %v %v, want the extracted meta", got[1].Tickers, got[1].Markets)
+		}
+	})
+
+	t.Run("meta request fails", func(t *testing.T) {
+		c := &Composer{LLM: &scriptedProvider{answers: []string{composeAnswer}}, Config: defaultPromptConfig()}
+		c.WithMetaExtraction(&scriptedProvider{errs: []error{errors.New("timeout")}}, nil)
+
+		if _, err := c.ComposeWith(context.Background(), news, ComposeOptions{}); err == nil {
+			t.Error("ComposeWith() error = nil, want the meta extraction error")
+		}
+	})
+}
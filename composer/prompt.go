@@ -1,19 +1,67 @@
 package composer
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StageParams holds the completion parameters of the LLM stage, so each stage can be tuned independently.
+type StageParams struct {
+	MaxTokens   int
+	Temperature float32
+	TopP        float32
+}
 
 type promptConfig struct {
-	ComposePrompt        string
+	ClassifyPrompt       string      // first stage: drops unimportant news before composing
+	ClassifyParams       StageParams // completion parameters of the classify stage
+	ComposePrompt        string      // second stage: composes text and meta for the remaining news
+	ComposeParams        StageParams // completion parameters of the compose stage
+	ComposeLitePrompt    string      // fast path of the compose stage for the breaking news
+	ComposeLiteParams    StageParams // completion parameters of the lite compose stage
+	ComposeMergedPrompt  string      // composes one story of the related news reported by several sources
+	SuspiciousPrompt     string      // scores the spam likelihood of the news flagged by the suspicious keywords
+	SuspiciousParams     StageParams // completion parameters of the suspicious review
+	VerifyPrompt         string      // checks the composed texts against the original news, see WithVerification
+	VerifyParams         StageParams // completion parameters of the verification judge
+	MetaPrompt           metaPromptFunc
+	MetaParams           StageParams // completion parameters of the separate meta extraction, see WithMetaExtraction
+	TranslatePrompt      translatePromptFunc
+	TranslateParams      StageParams // completion parameters of the translation
+	ImageFiguresPrompt   string
+	DigestScriptPrompt   string
+	DigestPrompt         string // groups the published news into the top stories per market
 	SummarisePrompt      summarisePromptFunc
 	FilterPromptInstruct filterPromptFunc
+	FreshnessWindow      time.Duration // only the news published within the window are composed, 0 to compose all
+	MaxSentences         int           // max sentences of the composed text, 0 for the length of the style
+	MaxChars             int           // max characters of the composed text, 0 for the length of the style
 }
 
+// defaultFreshnessWindow is the max age of the composed news, the older ones are not worth publishing.
+const defaultFreshnessWindow = 6 * time.Hour
+
 const (
-	maxWordsPerSentence = 10
+	maxWordsPerSentence   = 10  // summary of the headline
+	maxComposedWords      = 40  // composed text of the news (1-2 sentences)
+	maxDigestSummaryWords = 15  // summary of the digest story
+	digestScriptWords     = 600 // script of the audio digest
 )
 
 func defaultPromptConfig() *promptConfig {
 	return &promptConfig{
+		ClassifyPrompt: `You will receive a JSON array of financial news with IDs.
+		You need to decide which news are important for the investors and should be published.
+		Keep the news about the markets, economy, companies, earnings, central banks, politics and other events that can move the prices.
+		Drop press releases fluff, advertising, clickbait, opinion pieces without new facts, personal finance tips and non-financial news.
+		Answer with every news from the input: 'keep' is true for the important news and false for the dropped ones.
+		Always answer in the following JSON format: [{id:"", keep:true}]
+		----------------------------------------
+		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+		ClassifyParams: StageParams{MaxTokens: 1024, Temperature: 0.2, TopP: 1},
+		ComposeParams:  StageParams{MaxTokens: 2048, Temperature: 1, TopP: 1},
 		ComposePrompt: `You need to fill some (or none) tickers, markets and hashtags arrays for each news.
 		If news are mentioning some companies and stocks you need to find appropriate stocks 'tickers' (ONLY STOCKS, ignore ETFs and crypto). 
 		If news are about some market events you need to fill 'markets' with some index tickers (like SPY, QQQ, or RUT etc.) based on the context.
@@ -21,10 +69,102 @@ func defaultPromptConfig() *promptConfig {
 		You only need to choose appropriate hashtag (0-3) only from this list: inflation, interestrates, crisis, unemployment, bankruptcy, dividends, IPO, debt, war, buybacks, fed, AI, crypto, bitcoin.
 		It is OK if you don't find some tickers, markets or hashtags. It's also possible that you will find none.
 		Next you need to create an informative, original 'text' based on the title and description.
+		Some news can have 'figures' with key numbers from the article's chart or table, use the most important of them in the 'text'.
+		Some news can have the article 'content', prefer its facts and numbers over the title and description.
 		You need to write a 'text' that would be easy to read and understand, 1-2 sentences long.
+		Also rate the market 'sentiment' of the news for the mentioned stocks or markets: 'label' is one of bullish, bearish or neutral,
+		'confidence' is your confidence in the label from 0 to 1.
+		Rate the 'importance' of the news for the investors as an integer from 0 (minor news) to 100 (the market-moving story of the day).
+		Fill 'entities' with the names mentioned in the news: 'companies' (short names like Apple), 'people' (full names of the executives,
+		officials and politicians like Jerome Powell), 'central_banks' (like Fed, ECB) and 'countries'. Leave the arrays empty if there are none.
+		Always answer in the following JSON format: [{id:"", text:"", tickers:[], markets:[], hashtags:[], sentiment:{label:"", confidence:0}, importance:0, entities:{companies:[], people:[], central_banks:[], countries:[]}}]
+		----------------------------------------
+		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+		ComposeLiteParams: StageParams{MaxTokens: 512, Temperature: 0.5, TopP: 1},
+		FreshnessWindow:   defaultFreshnessWindow,
+		ComposeLitePrompt: `You will receive a JSON array of breaking financial news with IDs.
+		For each news write a short, informative 'text' based on the title and description: ONE sentence, facts only.
+		Fill 'tickers' with the stocks mentioned in the news (ONLY STOCKS, ignore ETFs and crypto) and 'markets' with the affected index tickers (like SPY, QQQ).
+		Choose 0-2 'hashtags' only from this list: inflation, interestrates, crisis, unemployment, bankruptcy, dividends, IPO, debt, war, buybacks, fed, AI, crypto, bitcoin.
+		Leave the arrays empty if nothing fits.
 		Always answer in the following JSON format: [{id:"", text:"", tickers:[], markets:[], hashtags:[]}]
 		----------------------------------------
 		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+		ComposeMergedPrompt: `You will receive a JSON array of financial news about the same event reported by different sources.
+		You need to compose ONE story that combines the facts of all news, answer with a single item with the 'id' of the first news.
+		Create an informative, original 'text' 2-3 sentences long with the facts confirmed by the sources and the details only some of them report.
+		Cite the sources by their 'source' names, e.g. "according to Reuters and CNBC", especially where their facts or numbers differ.
+		Some news can have the article 'content', prefer its facts and numbers over the title and description.
+		Fill 'tickers' with the stocks mentioned in the news (ONLY STOCKS, ignore ETFs and crypto) and 'markets' with the affected index tickers (like SPY, QQQ).
+		Choose 0-3 'hashtags' only from this list: inflation, interestrates, crisis, unemployment, bankruptcy, dividends, IPO, debt, war, buybacks, fed, AI, crypto, bitcoin.
+		Rate the market 'sentiment' of the story ('label' is bullish, bearish or neutral, 'confidence' from 0 to 1) and its 'importance' from 0 to 100.
+		Fill 'entities' with the names mentioned in the news: 'companies', 'people', 'central_banks' and 'countries'.
+		Always answer in the following JSON format: [{id:"", text:"", tickers:[], markets:[], hashtags:[], sentiment:{label:"", confidence:0}, importance:0, entities:{companies:[], people:[], central_banks:[], countries:[]}}]
+		----------------------------------------
+		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+		SuspiciousPrompt: `You will receive a JSON array of financial news with IDs.
+		You need to rate how likely each news is spam, advertising, a sponsored article or a promotion of a stock, product or service.
+		Real news about the companies' research, studies, partnerships or earnings are NOT spam, even if they sound positive.
+		'score' is the likelihood from 0 (real news) to 1 (spam or advertorial).
+		Always answer in the following JSON format: [{id:"", score:0}]
+		----------------------------------------
+		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+		SuspiciousParams: StageParams{MaxTokens: 512, Temperature: 0.2, TopP: 1},
+		VerifyPrompt: `You will receive a JSON array of financial news with IDs: the original 'source' and the composed 'text'.
+		You need to check that each 'text' states only the facts of its 'source'.
+		'supported' is false if the text adds numbers, dates, names, causes or other claims that are not in the source
+		or contradicts it. Rephrasing, shortening and rounding of the source numbers are fine.
+		Always answer in the following JSON format: [{id:"", supported:true}]
+		----------------------------------------
+		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+		VerifyParams: StageParams{MaxTokens: 512, Temperature: 0, TopP: 1},
+		MetaPrompt: func(markets, hashtags []string) string {
+			return fmt.Sprintf(`You will receive a JSON array of financial news with IDs.
+				You need to tag each news with the meta for the financial news channel.
+				Fill 'tickers' with the stocks mentioned in the news (ONLY STOCKS, ignore ETFs and crypto).
+				Fill 'markets' with the markets affected by the news only from this list: %s.
+				Choose 0-3 'hashtags' only from this list: %s.
+				It is OK if you don't find some tickers, markets or hashtags. It's also possible that you will find none.
+				Always answer in the following JSON format: [{id:"", tickers:[], markets:[], hashtags:[]}]
+				----------------------------------------
+				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`, strings.Join(markets, ", "), strings.Join(hashtags, ", "))
+		},
+		MetaParams: StageParams{MaxTokens: 1024, Temperature: 0, TopP: 1},
+		TranslatePrompt: func(language string) string {
+			return fmt.Sprintf(`You will receive a JSON array of financial news with IDs.
+				You need to translate the title and the text of each news into %s for the financial news channel.
+				Keep the meaning, numbers, tickers ($AAPL), company and person names as is. Use the common financial terms of the language.
+				Always answer in the following JSON format: [{id:"", title:"", text:""}]
+				----------------------------------------
+				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`, language)
+		},
+		TranslateParams: StageParams{MaxTokens: 2048, Temperature: 0.3, TopP: 1},
+		ImageFiguresPrompt: `You will receive the main image of the financial news article and its title.
+		If the image is a chart or a table, extract up to 5 key figures (numbers with their meaning and period) related to the title.
+		Answer with a short plain text, one figure per line, e.g. "CPI YoY: 3.1% (Jan)".
+		If the image doesn't contain any figures (photo, logo, etc.), answer with NONE.
+`,
+		DigestScriptPrompt: `You will receive a JSON array of today's financial news and events headlines.
+		You need to write a script of the daily audio digest for the financial news channel, about 600 words long.
+		Start with a short greeting, then tell about the most important market, economical and company news
+		grouped by topic, with smooth transitions between them, and finish with a short wrap-up.
+		The script will be read by the text-to-speech engine, so use plain text only: no Markdown, links, emojis or lists.
+		Write numbers, tickers and abbreviations the way they should be pronounced.
+`,
+		DigestPrompt: `You will receive a JSON array of published financial news with IDs, markets and tickers.
+		You need to create a digest of the most important stories grouped by market (e.g. US stocks, bonds, commodities, crypto).
+		Pick at most 5 top stories per market and at most 5 markets, most important first. Skip minor and repeated stories.
+		Write a short (15 words max) summary for each story and keep the ID of the news it is based on.
+		Always answer in the following JSON format: [{market:"", stories:[{id:"", summary:""}]}]
+		----------------------------------------
+		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
 `,
 		SummarisePrompt: func(headlinesLimit int) string {
 			return fmt.Sprintf(`You will receive a JSON array of news with IDs.
@@ -53,4 +193,8 @@ func defaultPromptConfig() *promptConfig {
 
 type summarisePromptFunc = func(headlinesLimit int) string
 
+type translatePromptFunc = func(language string) string
+
+type metaPromptFunc = func(markets, hashtags []string) string
+
 type filterPromptFunc = func(newsJson string) string
@@ -0,0 +1,109 @@
+package composer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"strings"
+)
+
+// Schema is the JSON schema of the structured LLM answer. Providers that support structured output
+// (e.g. OpenAI function calling) enforce it, others rely on the prompt and the lenient JSON parsing.
+type Schema struct {
+	Name        string // name of the function for the function calling
+	Description string
+	Definition  jsonschema.Definition // answer is always a JSON object
+}
+
+// composedNewsSchema is the schema of the Compose answer: the object with the list of composed news.
+var composedNewsSchema = &Schema{
+	Name:        "compose_news",
+	Description: "Publish the composed news with the tickers, markets, hashtags, sentiment, importance and entities",
+	Definition: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"news": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"id":       {Type: jsonschema.String, Description: "ID of the original news"},
+						"text":     {Type: jsonschema.String, Description: "Composed text of the news"},
+						"tickers":  {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+						"markets":  {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+						"hashtags": {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+						"sentiment": {
+							Type: jsonschema.Object,
+							Properties: map[string]jsonschema.Definition{
+								"label": {
+									Type: jsonschema.String,
+									Enum: []string{string(SentimentBullish), string(SentimentBearish), string(SentimentNeutral)},
+								},
+								"confidence": {Type: jsonschema.Number, Description: "Confidence from 0 to 1"},
+							},
+							Required: []string{"label", "confidence"},
+						},
+						"importance": {Type: jsonschema.Integer, Description: "Importance for the investors from 0 to 100"},
+						"entities": {
+							Type: jsonschema.Object,
+							Properties: map[string]jsonschema.Definition{
+								"companies":     {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+								"people":        {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+								"central_banks": {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+								"countries":     {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+							},
+						},
+					},
+					Required: []string{"id", "text", "tickers", "markets", "hashtags"},
+				},
+			},
+		},
+		Required: []string{"news"},
+	},
+}
+
+// composeRepairPrompt asks the model to fix the malformed Compose answer.
+const composeRepairPrompt = `Your previous answer is not a valid JSON array of composed news.
+Fix it according to the error and answer with the corrected JSON only, keep the content unchanged.
+Format: [{id:"", text:"", tickers:[], markets:[], hashtags:[], sentiment:{label:"", confidence:0}, importance:0, entities:{companies:[], people:[], central_banks:[], countries:[]}}]
+ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.`
+
+// parseComposedNews parses the Compose answer: the structured answer object (see composedNewsSchema)
+// or the free-form JSON array. The free-form answers are fixed with aiJSONStringFixer.
+func parseComposedNews(answer string) ([]*ComposedNews, error) {
+	var structured struct {
+		News []*ComposedNews `json:"news"`
+	}
+	trimmed := strings.TrimSpace(answer)
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &structured) == nil && structured.News != nil {
+		return structured.News, nil
+	}
+
+	matches, err := aiJSONStringFixer(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	var composed []*ComposedNews
+	if err := json.Unmarshal([]byte(matches), &composed); err != nil {
+		return nil, err
+	}
+
+	return composed, nil
+}
+
+// validateComposedNews checks the required fields of the composed news: each news must have the ID and the text.
+func validateComposedNews(composed []*ComposedNews) error {
+	for i, n := range composed {
+		switch {
+		case n == nil:
+			return fmt.Errorf("news %d is null", i)
+		case n.ID == "":
+			return fmt.Errorf("news %d has no id", i)
+		case strings.TrimSpace(n.Text) == "":
+			return fmt.Errorf("news %q has no text", n.ID)
+		}
+	}
+
+	return nil
+}
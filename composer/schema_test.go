@@ -0,0 +1,116 @@
+package composer
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func Test_parseComposedNews(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		wantIDs []string
+		wantErr bool
+	}{
+		{
+			name:    "structured answer",
+			answer:  `{"news":[{"id":"1","text":"Text","tickers":[],"markets":[],"hashtags":[]}]}`,
+			wantIDs: []string{"1"},
+		},
+		{
+			name:    "free-form array",
+			answer:  "```json\n[{\"id\":\"1\",\"text\":\"Text\"},{\"id\":\"2\",\"text\":\"Text\"}]\n```",
+			wantIDs: []string{"1", "2"},
+		},
+		{
+			name:    "single object",
+			answer:  `{"id":"1","text":"Text"}`,
+			wantIDs: []string{"1"},
+		},
+		{
+			name:    "truncated answer",
+			answer:  `[{"id":"1","text":"Te`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseComposedNews(tt.answer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseComposedNews() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("parseComposedNews() returned %d news, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, n := range got {
+				if n.ID != tt.wantIDs[i] {
+					t.Errorf("parseComposedNews() news %d ID = %v, want %v", i, n.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_validateComposedNews(t *testing.T) {
+	tests := []struct {
+		name     string
+		composed []*ComposedNews
+		wantErr  bool
+	}{
+		{name: "valid", composed: []*ComposedNews{{ID: "1", Text: "Text"}}},
+		{name: "empty list", composed: nil},
+		{name: "null news", composed: []*ComposedNews{nil}, wantErr: true},
+		{name: "no id", composed: []*ComposedNews{{Text: "Text"}}, wantErr: true},
+		{name: "no text", composed: []*ComposedNews{{ID: "1", Text: " "}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateComposedNews(tt.composed); (err != nil) != tt.wantErr {
+				t.Errorf("validateComposedNews() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComposer_Compose_repair(t *testing.T) {
+	news := journalist.NewsList{{ID: "1", Title: "Title", Description: "Description", Date: time.Now()}}
+	answer := func(content string) openai.ChatCompletionResponse {
+		return openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: content}}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		repair  string
+		wantErr bool
+	}{
+		{name: "repaired answer", repair: `[{"id":"1","text":"Text"}]`},
+		{name: "broken repaired answer", repair: `[{"id":"1"}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+				return req.Messages[0].Content != composeRepairPrompt
+			})).Return(answer(`[{"id":"1","text":`), nil)
+			mockClient.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+				return req.Messages[0].Content == composeRepairPrompt
+			})).Return(answer(tt.repair), nil)
+
+			c := &Composer{OpenAiClient: mockClient, Config: defaultPromptConfig()}
+			got, err := c.Compose(context.Background(), news)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compose() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertNumberOfCalls(t, "CreateChatCompletion", 2)
+			if !tt.wantErr && (len(got) != 1 || got[0].Text != "Text") {
+				t.Errorf("Compose() = %v, want the repaired news", got)
+			}
+		})
+	}
+}
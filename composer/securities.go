@@ -0,0 +1,284 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSecuritiesURL is the SEC list of the US listed companies with their tickers.
+const DefaultSecuritiesURL = "https://www.sec.gov/files/company_tickers.json"
+
+// securitiesUserAgent is sent with the list requests, SEC rejects the requests without the contact.
+const securitiesUserAgent = "fin-thread (https://github.com/samgozman/fin-thread)"
+
+// defaultTickerAliases are the symbols the LLM uses for the same company, mapped to the one symbol,
+// so the news are not tagged twice.
+var defaultTickerAliases = map[string]string{
+	"GOOG":  "GOOGL",
+	"FB":    "META",
+	"BRK.A": "BRK-A",
+	"BRK.B": "BRK-B",
+	"BF.B":  "BF-B",
+}
+
+// companySuffixRe matches the legal form at the end of the company name, e.g. "Apple Inc." or "Tesla, Inc.".
+var companySuffixRe = regexp.MustCompile(`(?i)[,.]?\s+(inc|corp|corporation|co|company|ltd|plc|llc|n\.?v|s\.?a|ag|holdings?|group)\.?$`)
+
+// Security is the listed security of the reference list.
+type Security struct {
+	Ticker string `json:"ticker"`
+	Name   string `json:"name"`
+}
+
+// Securities is the reference list of the valid tickers used to clean the tickers of the composed news:
+// aliases are mapped to one symbol, company names are replaced with their tickers and unknown symbols
+// (hallucinated by the LLM) are dropped. The list is cached in the local file and refreshed from the API
+// in the SEC company_tickers.json format.
+type Securities struct {
+	path      string            // local cache of the list (optional)
+	url       string            // API with the list (optional)
+	aliases   map[string]string // aliases of the tickers by the alias
+	userAgent string
+	client    *http.Client
+
+	mu      sync.RWMutex
+	tickers map[string]bool   // known tickers
+	names   map[string]string // tickers by the normalized company name
+}
+
+// NewSecurities creates the empty Securities with the default aliases, the list is not read until Load is called.
+func NewSecurities(path, url string) *Securities {
+	aliases := make(map[string]string, len(defaultTickerAliases))
+	for alias, ticker := range defaultTickerAliases {
+		aliases[alias] = ticker
+	}
+
+	return &Securities{
+		path:      path,
+		url:       url,
+		aliases:   aliases,
+		userAgent: securitiesUserAgent,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithAliases adds the aliases of the tickers (alias to ticker, e.g. "GOOG": "GOOGL"), they override the defaults.
+func (s *Securities) WithAliases(aliases map[string]string) *Securities {
+	for alias, ticker := range aliases {
+		s.aliases[normalizeSymbol(alias)] = normalizeSymbol(ticker)
+	}
+	return s
+}
+
+// WithUserAgent sets the user agent of the list requests (SEC requires the contact email).
+func (s *Securities) WithUserAgent(userAgent string) *Securities {
+	s.userAgent = userAgent
+	return s
+}
+
+// Load reads the list from the local cache file. If there is no cache yet, the list is fetched from the API.
+func (s *Securities) Load(ctx context.Context) error {
+	if s.path != "" {
+		b, err := os.ReadFile(s.path)
+		if err == nil {
+			var list []Security
+			if err := json.Unmarshal(b, &list); err != nil {
+				return fmt.Errorf("securities cache %s: %w", s.path, err)
+			}
+			s.set(list)
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("securities cache: %w", err)
+		}
+	}
+	if s.url == "" {
+		return errors.New("securities: no cache file and API URL")
+	}
+
+	return s.Refresh(ctx)
+}
+
+// Refresh fetches the list from the API and saves it to the local cache file.
+// The current list is kept if the request fails.
+func (s *Securities) Refresh(ctx context.Context) error {
+	list, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return errors.New("securities: empty list")
+	}
+	s.set(list)
+
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("securities cache: %w", err)
+	}
+	// Written to the temp file first, so the crash doesn't leave the broken cache
+	tmp := filepath.Join(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("securities cache: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("securities cache: %w", err)
+	}
+
+	return nil
+}
+
+// fetch requests the list from the API.
+func (s *Securities) fetch(ctx context.Context) ([]Security, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch securities: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch securities: %s", resp.Status)
+	}
+
+	var companies map[string]struct {
+		Ticker string `json:"ticker"`
+		Title  string `json:"title"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&companies); err != nil {
+		return nil, fmt.Errorf("failed to decode securities: %w", err)
+	}
+
+	// Companies are keyed by the rank ("0", "1", ...), the order is kept for the main tickers (see set)
+	keys := make([]string, 0, len(companies))
+	for k := range companies {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+
+	list := make([]Security, 0, len(companies))
+	for _, k := range keys {
+		list = append(list, Security{Ticker: companies[k].Ticker, Name: companies[k].Title})
+	}
+
+	return list, nil
+}
+
+// set replaces the list.
+func (s *Securities) set(list []Security) {
+	tickers := make(map[string]bool, len(list))
+	names := make(map[string]string, len(list))
+	for _, sec := range list {
+		ticker := normalizeSymbol(sec.Ticker)
+		if ticker == "" {
+			continue
+		}
+		tickers[ticker] = true
+		// The first ticker of the company is its main one (e.g. GOOGL of the Alphabet Inc.)
+		if name := normalizeCompanyName(sec.Name); name != "" {
+			if _, ok := names[name]; !ok {
+				names[name] = ticker
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.tickers = tickers
+	s.names = names
+	s.mu.Unlock()
+}
+
+// Len returns the number of the known tickers.
+func (s *Securities) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tickers)
+}
+
+// Normalize returns the valid tickers of the list without duplicates: aliases are mapped to one symbol,
+// the share class is written with "-" (BRK.B -> BRK-B), company names are replaced with their tickers
+// and the unknown symbols are dropped. Tickers are returned as is if the list is not loaded.
+func (s *Securities) Normalize(tickers []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.tickers) == 0 {
+		return tickers
+	}
+
+	valid := make([]string, 0, len(tickers))
+	seen := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		ticker, ok := s.resolve(t)
+		if !ok || seen[ticker] {
+			continue
+		}
+		seen[ticker] = true
+		valid = append(valid, ticker)
+	}
+
+	return valid
+}
+
+// resolve returns the known ticker of the symbol or the company name.
+func (s *Securities) resolve(symbol string) (string, bool) {
+	ticker := normalizeSymbol(symbol)
+	if alias, ok := s.aliases[ticker]; ok {
+		ticker = alias
+	}
+	if s.tickers[ticker] {
+		return ticker, true
+	}
+
+	// Share class separator differs between the sources: BRK.B, BRK/B and BRK-B
+	if class := strings.NewReplacer(".", "-", "/", "-").Replace(ticker); s.tickers[class] {
+		return class, true
+	}
+
+	if t, ok := s.names[normalizeCompanyName(symbol)]; ok {
+		return t, true
+	}
+
+	return "", false
+}
+
+// normalizeSymbol returns the upper case symbol without "$" and spaces.
+func normalizeSymbol(s string) string {
+	return strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(s), "$"))
+}
+
+// normalizeCompanyName returns the lower case company name without the legal form, e.g. "apple" of "Apple Inc.".
+func normalizeCompanyName(name string) string {
+	name = strings.TrimSpace(name)
+	for {
+		trimmed := companySuffixRe.ReplaceAllString(name, "")
+		if trimmed == name {
+			break
+		}
+		name = trimmed
+	}
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,124 @@
+package composer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSecurities_Normalize(t *testing.T) {
+	s := NewSecurities("", "").WithAliases(map[string]string{"$twtr": "x"})
+	s.set([]Security{
+		{Ticker: "AAPL", Name: "Apple Inc."},
+		{Ticker: "GOOGL", Name: "Alphabet Inc."},
+		{Ticker: "GOOG", Name: "Alphabet Inc."},
+		{Ticker: "META", Name: "Meta Platforms, Inc."},
+		{Ticker: "BRK-B", Name: "Berkshire Hathaway Inc"},
+		{Ticker: "X", Name: "United States Steel Corp"},
+	})
+
+	tests := []struct {
+		name    string
+		tickers []string
+		want    []string
+	}{
+		{
+			name:    "valid tickers",
+			tickers: []string{"AAPL", "$META"},
+			want:    []string{"AAPL", "META"},
+		},
+		{
+			name:    "hallucinated tickers are dropped",
+			tickers: []string{"AAPL", "APPLE1", "FOO"},
+			want:    []string{"AAPL"},
+		},
+		{
+			name:    "aliases are mapped without duplicates",
+			tickers: []string{"GOOG", "GOOGL", "FB"},
+			want:    []string{"GOOGL", "META"},
+		},
+		{
+			name:    "share class separator",
+			tickers: []string{"BRK.B", "brk/b"},
+			want:    []string{"BRK-B"},
+		},
+		{
+			name:    "company names",
+			tickers: []string{"Apple", "Alphabet Inc.", "Meta Platforms"},
+			want:    []string{"AAPL", "GOOGL", "META"},
+		},
+		{
+			name:    "custom aliases",
+			tickers: []string{"TWTR"},
+			want:    []string{"X"},
+		},
+		{
+			name:    "no tickers",
+			tickers: nil,
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Normalize(tt.tickers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurities_NormalizeNotLoaded(t *testing.T) {
+	tickers := []string{"AAPL", "FOO"}
+	if got := NewSecurities("", "").Normalize(tickers); !reflect.DeepEqual(got, tickers) {
+		t.Errorf("Normalize() = %v, want %v", got, tickers)
+	}
+}
+
+func TestSecurities_Load(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("User-Agent") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"1":{"cik_str":1652044,"ticker":"GOOG","title":"Alphabet Inc."},` +
+			`"0":{"cik_str":1652044,"ticker":"GOOGL","title":"Alphabet Inc."}}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "securities.json")
+
+	// No cache yet, the list is fetched and saved
+	s := NewSecurities(path, srv.URL)
+	if err := s.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if s.Len() != 2 || requests != 1 {
+		t.Errorf("Load() = %d tickers with %d requests, want 2 tickers with 1 request", s.Len(), requests)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Load() cache file error = %v", err)
+	}
+
+	// Cache is used by the next start
+	cached := NewSecurities(path, srv.URL)
+	if err := cached.Load(context.Background()); err != nil {
+		t.Fatalf("Load() cached error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Load() cached requests = %d, want 1", requests)
+	}
+	// The first ticker of the company in the rank order is its main one
+	if got := cached.Normalize([]string{"Alphabet"}); !reflect.DeepEqual(got, []string{"GOOGL"}) {
+		t.Errorf("Normalize() = %v, want [GOOGL]", got)
+	}
+
+	if err := NewSecurities("", "").Load(context.Background()); err == nil {
+		t.Error("Load() without cache and API error = nil, want error")
+	}
+}
@@ -0,0 +1,38 @@
+package composer
+
+import "strings"
+
+// SentimentLabel is the market sentiment of the news.
+type SentimentLabel string
+
+const (
+	SentimentBullish SentimentLabel = "bullish"
+	SentimentBearish SentimentLabel = "bearish"
+	SentimentNeutral SentimentLabel = "neutral"
+)
+
+// Sentiment is the market sentiment of the news with the model's confidence.
+type Sentiment struct {
+	Label      SentimentLabel `json:"label"`
+	Confidence float64        `json:"confidence"` // 0..1
+}
+
+// normalizeSentiment fixes the sentiment returned by the model: the label is lowercased and the confidence
+// is clamped to 0..1. Returns nil if the label is unknown.
+func normalizeSentiment(s *Sentiment) *Sentiment {
+	if s == nil {
+		return nil
+	}
+
+	label := SentimentLabel(strings.ToLower(strings.TrimSpace(string(s.Label))))
+	switch label {
+	case SentimentBullish, SentimentBearish, SentimentNeutral:
+	default:
+		return nil
+	}
+
+	return &Sentiment{
+		Label:      label,
+		Confidence: min(max(s.Confidence, 0), 1),
+	}
+}
@@ -0,0 +1,44 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_normalizeSentiment(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *Sentiment
+		want *Sentiment
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "valid",
+			s:    &Sentiment{Label: SentimentBullish, Confidence: 0.8},
+			want: &Sentiment{Label: SentimentBullish, Confidence: 0.8},
+		},
+		{
+			name: "label case and spaces",
+			s:    &Sentiment{Label: " Bearish", Confidence: 0.6},
+			want: &Sentiment{Label: SentimentBearish, Confidence: 0.6},
+		},
+		{
+			name: "confidence out of range",
+			s:    &Sentiment{Label: SentimentNeutral, Confidence: 85},
+			want: &Sentiment{Label: SentimentNeutral, Confidence: 1},
+		},
+		{
+			name: "unknown label",
+			s:    &Sentiment{Label: "positive", Confidence: 0.9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSentiment(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeSentiment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
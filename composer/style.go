@@ -0,0 +1,55 @@
+package composer
+
+import "fmt"
+
+// Style is the preset of the tone and length of the composed text, e.g. terse headlines for the trading channel
+// and short summaries for the newsletter one.
+type Style string
+
+const (
+	StyleDefault    Style = ""           // 1-2 informative sentences (maxComposedWords)
+	StyleConcise    Style = "concise"    // one terse sentence with facts and numbers only
+	StyleAnalytical Style = "analytical" // 2-3 sentences with the context and the likely market impact
+	StyleCasual     Style = "casual"     // friendly conversational tone for the retail investors
+)
+
+// stylePreset is the instruction added to the compose prompt and the max length of the text in words.
+type stylePreset struct {
+	instruction string
+	maxWords    int
+}
+
+var stylePresets = map[Style]stylePreset{
+	StyleConcise: {
+		instruction: "Write the 'text' as a terse headline for traders: ONE sentence, facts and numbers only, no introductions or filler words.",
+		maxWords:    20,
+	},
+	StyleAnalytical: {
+		instruction: "Write the 'text' as a 2-3 sentences summary for the newsletter readers: the key facts first, " +
+			"then the context and the likely impact on the mentioned stocks or markets. Don't speculate beyond the news.",
+		maxWords: 70,
+	},
+	StyleCasual: {
+		instruction: "Write the 'text' in a friendly, conversational tone that is easy to follow for the retail investors, " +
+			"1-2 sentences. Explain the jargon in plain words, no slang or emojis.",
+		maxWords: maxComposedWords,
+	},
+}
+
+// MaxWords returns the max length of the composed text of the style in words.
+func (s Style) MaxWords() int {
+	if p, ok := stylePresets[s]; ok {
+		return p.maxWords
+	}
+	return maxComposedWords
+}
+
+// apply adds the style instruction to the compose prompt. The default style keeps the prompt as is.
+func (s Style) apply(prompt string) string {
+	p, ok := stylePresets[s]
+	if !ok {
+		return prompt
+	}
+	return fmt.Sprintf("%s\t\tSTYLE (overrides the length and tone above): %s The 'text' is %d words max.\n",
+		prompt, p.instruction, p.maxWords)
+}
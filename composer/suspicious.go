@@ -0,0 +1,82 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+)
+
+// ReviewSuspicious scores the spam or advertorial likelihood of the news flagged by the suspicious keywords,
+// so the keywords stay the cheap pre-filter and only the flagged news are sent to the LLM.
+// News scored lower than the threshold (0..1) are unflagged. Returns the same news list.
+func (c *Composer) ReviewSuspicious(ctx context.Context, news journalist.NewsList, threshold float64) (journalist.NewsList, error) {
+	var flagged journalist.NewsList
+	for _, n := range news {
+		if n.IsSuspicious && !n.IsFiltered {
+			flagged = append(flagged, n)
+		}
+	}
+	if len(flagged) == 0 {
+		return news, nil
+	}
+
+	jsonNews, err := flagged.ToContentJSON()
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ReviewSuspicious", "NewsList.ToContentJSON")
+	}
+
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System:      c.prompt(PromptSuspicious, PromptData{}, func() string { return c.Config.SuspiciousPrompt }),
+			User:        jsonNews,
+			Temperature: c.Config.SuspiciousParams.Temperature,
+			MaxTokens:   c.Config.SuspiciousParams.MaxTokens,
+			TopP:        c.Config.SuspiciousParams.TopP,
+			Prefill:     "[",
+			JSON:        true,
+		},
+	)
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "ReviewSuspicious", "LLM.Complete")
+	}
+
+	matches, err := aiJSONStringFixer(resp)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ReviewSuspicious", "aiJSONStringFixer")
+	}
+
+	var scores []*suspicionScore
+	err = json.Unmarshal([]byte(matches), &scores)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "ReviewSuspicious", "json.Unmarshal").WithValue(matches)
+	}
+
+	unflagSuspicious(flagged, scores, threshold)
+
+	return news, nil
+}
+
+// suspicionScore is the spam or advertorial likelihood of the news (0..1).
+type suspicionScore struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// unflagSuspicious clears IsSuspicious flag of the news scored lower than the threshold.
+// News missing in the answer stay flagged, so the truncated answer doesn't publish the spam.
+func unflagSuspicious(news journalist.NewsList, scores []*suspicionScore, threshold float64) {
+	clean := make(map[string]bool, len(scores))
+	for _, s := range scores {
+		if s.Score < threshold {
+			clean[s.ID] = true
+		}
+	}
+
+	for _, n := range news {
+		if clean[n.ID] {
+			n.IsSuspicious = false
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestComposer_ReviewSuspicious(t *testing.T) {
+	tests := []struct {
+		name           string
+		answer         string
+		err            error
+		wantSuspicious []bool
+		wantErr        bool
+	}{
+		{
+			name:           "Should unflag news scored lower than the threshold",
+			answer:         `[{"id":"1","score":0.1},{"id":"2","score":0.9}]`,
+			wantSuspicious: []bool{false, true, false, false},
+		},
+		{
+			name:           "Should keep news scored at the threshold flagged",
+			answer:         `[{"id":"1","score":0.7},{"id":"2","score":0.2}]`,
+			wantSuspicious: []bool{true, false, false, false},
+		},
+		{
+			name:           "Should keep news missing in the answer flagged",
+			answer:         `[{"id":"2","score":0.1}]`,
+			wantSuspicious: []bool{true, false, false, false},
+		},
+		{
+			name:    "Should return error if LLM returns error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := journalist.NewsList{
+				{ID: "1", Title: "New study shows Apple Watch detects sleep apnea", IsSuspicious: true},
+				{ID: "2", Title: "This penny stock could soar 1000%, research shows", IsSuspicious: true},
+				{ID: "3", Title: "Fed holds rates steady"},
+				{ID: "4", Title: "Filtered news", IsFiltered: true},
+			}
+			jsonNews, _ := news[:2].ToContentJSON()
+			defConf := defaultPromptConfig()
+
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, openai.ChatCompletionRequest{
+				Model: openai.GPT3Dot5Turbo0125,
+				Messages: []openai.ChatCompletionMessage{
+					{Role: openai.ChatMessageRoleSystem, Content: defConf.SuspiciousPrompt},
+					{Role: openai.ChatMessageRoleUser, Content: jsonNews},
+				},
+				Temperature: defConf.SuspiciousParams.Temperature,
+				MaxTokens:   defConf.SuspiciousParams.MaxTokens,
+				TopP:        defConf.SuspiciousParams.TopP,
+			}).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: tt.answer}}},
+			}, tt.err)
+
+			c := &Composer{OpenAiClient: mockClient, Config: defConf}
+			got, err := c.ReviewSuspicious(context.Background(), news, 0.7)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReviewSuspicious() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, n := range got {
+				if n.IsSuspicious != tt.wantSuspicious[i] {
+					t.Errorf("ReviewSuspicious() news %s IsSuspicious = %v, want %v", n.ID, n.IsSuspicious, tt.wantSuspicious[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComposer_ReviewSuspicious_noFlagged(t *testing.T) {
+	news := journalist.NewsList{{ID: "1", Title: "Fed holds rates steady"}}
+
+	// LLM must not be called
+	c := &Composer{OpenAiClient: new(MockOpenAiClient), Config: defaultPromptConfig()}
+	got, err := c.ReviewSuspicious(context.Background(), news, 0.7)
+	if err != nil || len(got) != 1 {
+		t.Errorf("ReviewSuspicious() = %v, %v, want the same news", got, err)
+	}
+}
@@ -0,0 +1,185 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Stages of the prompt templates. Template of the stage is the <stage>.tmpl file in the prompts directory.
+// Note: the sentiment of the news is rated by the compose stage.
+const (
+	PromptClassify      = "classify"
+	PromptCompose       = "compose"
+	PromptComposeLite   = "compose_lite"
+	PromptComposeMerged = "compose_merged"
+	PromptSuspicious    = "suspicious"
+	PromptVerify        = "verify"
+	PromptMeta          = "meta"
+	PromptTranslate     = "translate"
+	PromptImageFigures  = "image_figures"
+	PromptDigest        = "digest"
+	PromptDigestScript  = "digest_script"
+	PromptSummarise     = "summarise"
+	PromptFilter        = "filter"
+)
+
+// PromptStages are all the stages that can have the prompt template.
+var PromptStages = []string{
+	PromptClassify,
+	PromptCompose,
+	PromptComposeLite,
+	PromptComposeMerged,
+	PromptSuspicious,
+	PromptVerify,
+	PromptMeta,
+	PromptTranslate,
+	PromptImageFigures,
+	PromptDigest,
+	PromptDigestScript,
+	PromptSummarise,
+	PromptFilter,
+}
+
+// promptTemplateExt is the extension of the template files in the prompts directory.
+const promptTemplateExt = ".tmpl"
+
+// PromptData is the data the prompt templates are executed with, e.g. {{.MaxLen}}.
+type PromptData struct {
+	MaxLen    int    // max length of the generated text of each news in words
+	Headlines int    // number of the headlines to summarise (summarise)
+	Language  string // language to translate the news into (translate)
+	News      string // JSON array of the news (filter, the other stages receive the news in the user message)
+	Style     string // style of the composed text, e.g. "concise", empty for the default one (compose, compose_merged)
+
+	MaxSentences int // max sentences of the composed text, 0 if not limited (compose, compose_merged)
+	MaxChars     int // max characters of the composed text, 0 if not limited (compose, compose_merged)
+
+	Markets      string // comma-separated markets to choose from (meta)
+	SeparateMeta bool   // true if the meta is extracted by the separate request, so the text only is composed (compose)
+}
+
+// samplePromptData is used to check the templates on load, so the broken ones are rejected before use.
+var samplePromptData = PromptData{MaxLen: maxWordsPerSentence, Headlines: 5, Language: "English", News: "[]"}
+
+// PromptTemplates are the prompts of the LLM stages loaded from the text/template files, so they can be tuned
+// without rebuilding the app. Stages without the template use the default prompts of the Composer.
+// Load can be called again to reload the changed files (e.g. on SIGHUP).
+type PromptTemplates struct {
+	dir   string            // prompts directory with the <stage>.tmpl files (optional)
+	files map[string]string // template files by the stage, take precedence over the prompts directory (optional)
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// NewPromptTemplates creates the templates from the prompts directory and the files by the stage.
+// Templates are not read until Load is called.
+func NewPromptTemplates(dir string, files map[string]string) *PromptTemplates {
+	return &PromptTemplates{
+		dir:       dir,
+		files:     files,
+		templates: make(map[string]*template.Template),
+	}
+}
+
+// Load reads and parses all the template files. Templates are checked by executing them with the sample data
+// and replace the current ones only if all of them are valid, so the broken file keeps the previous prompts.
+func (p *PromptTemplates) Load() error {
+	paths, err := p.paths()
+	if err != nil {
+		return err
+	}
+
+	templates := make(map[string]*template.Template, len(paths))
+	for stage, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("prompt %s: %w", stage, err)
+		}
+		t, err := template.New(stage).Option("missingkey=error").Parse(string(b))
+		if err != nil {
+			return fmt.Errorf("prompt %s: %w", stage, err)
+		}
+		if err := t.Execute(io.Discard, samplePromptData); err != nil {
+			return fmt.Errorf("prompt %s: %w", stage, err)
+		}
+		templates[stage] = t
+	}
+
+	p.mu.Lock()
+	p.templates = templates
+	p.mu.Unlock()
+
+	return nil
+}
+
+// paths returns the template files by the stage: the files of the prompts directory overridden by the explicit ones.
+func (p *PromptTemplates) paths() (map[string]string, error) {
+	paths := make(map[string]string)
+
+	if p.dir != "" {
+		entries, err := os.ReadDir(p.dir)
+		if err != nil {
+			return nil, fmt.Errorf("prompts directory: %w", err)
+		}
+		for _, e := range entries {
+			stage, ok := strings.CutSuffix(e.Name(), promptTemplateExt)
+			if !ok || e.IsDir() {
+				continue
+			}
+			if !slices.Contains(PromptStages, stage) {
+				return nil, fmt.Errorf("prompts directory: unknown stage of %s", e.Name())
+			}
+			paths[stage] = filepath.Join(p.dir, e.Name())
+		}
+	}
+
+	for stage, path := range p.files {
+		if !slices.Contains(PromptStages, stage) {
+			return nil, fmt.Errorf("prompt %s: unknown stage, must be one of: %s", stage, strings.Join(PromptStages, ", "))
+		}
+		paths[stage] = path
+	}
+
+	return paths, nil
+}
+
+// Stages returns the sorted stages with the loaded templates.
+func (p *PromptTemplates) Stages() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stages := make([]string, 0, len(p.templates))
+	for stage := range p.templates {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	return stages
+}
+
+// errNoPromptTemplate is returned by Render if the stage has no template.
+var errNoPromptTemplate = errors.New("no prompt template")
+
+// Render executes the template of the stage with the data.
+func (p *PromptTemplates) Render(stage string, data PromptData) (string, error) {
+	p.mu.RLock()
+	t, ok := p.templates[stage]
+	p.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("prompt %s: %w", stage, errNoPromptTemplate)
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("prompt %s: %w", stage, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,136 @@
+package composer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writePrompt(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPromptTemplates_Load(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, filepath.Join(dir, "compose.tmpl"), "Write {{.MaxLen}} words at most.")
+	writePrompt(t, filepath.Join(dir, "digest.tmpl"), "Digest from the directory.")
+	writePrompt(t, filepath.Join(dir, "notes.txt"), "Not a template.")
+	override := filepath.Join(t.TempDir(), "digest.tmpl")
+	writePrompt(t, override, "Digest of {{.MaxLen}} words.")
+	broken := filepath.Join(t.TempDir(), "broken.tmpl")
+	writePrompt(t, broken, "Translate into {{.Lang}}.")
+
+	tests := []struct {
+		name       string
+		dir        string
+		files      map[string]string
+		wantStages []string
+		wantPrompt map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "directory",
+			dir:        dir,
+			wantStages: []string{PromptCompose, PromptDigest},
+			wantPrompt: map[string]string{
+				PromptCompose: "Write 40 words at most.",
+				PromptDigest:  "Digest from the directory.",
+			},
+		},
+		{
+			name:       "files override the directory",
+			dir:        dir,
+			files:      map[string]string{PromptDigest: override},
+			wantStages: []string{PromptCompose, PromptDigest},
+			wantPrompt: map[string]string{PromptDigest: "Digest of 40 words."},
+		},
+		{
+			name:    "unknown stage",
+			files:   map[string]string{"sentiment": override},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			files:   map[string]string{PromptTranslate: broken},
+			wantErr: true,
+		},
+		{
+			name:    "missing directory",
+			dir:     filepath.Join(dir, "missing"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPromptTemplates(tt.dir, tt.files)
+			err := p.Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := p.Stages(); !slices.Equal(got, tt.wantStages) {
+				t.Errorf("Stages() = %v, want %v", got, tt.wantStages)
+			}
+			for stage, want := range tt.wantPrompt {
+				got, err := p.Render(stage, PromptData{MaxLen: maxComposedWords})
+				if err != nil || got != want {
+					t.Errorf("Render(%s) = %q, %v, want %q", stage, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPromptTemplates_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.tmpl")
+	writePrompt(t, path, "First version.")
+
+	p := NewPromptTemplates("", map[string]string{PromptCompose: path})
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Broken template keeps the previous version
+	writePrompt(t, path, "Broken {{.MaxLen")
+	if err := p.Load(); err == nil {
+		t.Fatal("Load() of the broken template error = nil")
+	}
+	if got, _ := p.Render(PromptCompose, PromptData{}); got != "First version." {
+		t.Errorf("Render() after failed reload = %q, want the first version", got)
+	}
+
+	writePrompt(t, path, "Second version.")
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, _ := p.Render(PromptCompose, PromptData{}); got != "Second version." {
+		t.Errorf("Render() after reload = %q, want the second version", got)
+	}
+}
+
+func TestComposer_prompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "translate.tmpl")
+	writePrompt(t, path, "Translate into {{.Language}}.")
+	templates := NewPromptTemplates("", map[string]string{PromptTranslate: path})
+	if err := templates.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Composer{Config: defaultPromptConfig()}
+	if got, want := c.prompt(PromptTranslate, PromptData{Language: "German"}, func() string {
+		return c.Config.TranslatePrompt("German")
+	}), c.Config.TranslatePrompt("German"); got != want {
+		t.Errorf("prompt() without templates = %q, want the default one", got)
+	}
+
+	c.WithPromptTemplates(templates)
+	if got := c.prompt(PromptTranslate, PromptData{Language: "German"}, func() string { return "default" }); got != "Translate into German." {
+		t.Errorf("prompt() = %q, want %q", got, "Translate into German.")
+	}
+	if got := c.prompt(PromptCompose, PromptData{}, func() string { return "default" }); got != "default" {
+		t.Errorf("prompt() of the stage without template = %q, want %q", got, "default")
+	}
+}
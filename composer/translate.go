@@ -0,0 +1,66 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+)
+
+// TranslatedNews is the title and the composed text of the news to translate or their translation.
+type TranslatedNews struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Translate translates the titles and composed texts of the news into the language (e.g. "German")
+// with the TranslatePrompt. Tickers, numbers and names are kept as is. Returns the translations by the news ID,
+// news missing in the answer or translated with the empty text are skipped.
+func (c *Composer) Translate(ctx context.Context, news []*TranslatedNews, language string) (map[string]*TranslatedNews, error) {
+	if len(news) == 0 {
+		return map[string]*TranslatedNews{}, nil
+	}
+
+	jsonNews, err := json.Marshal(news)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Translate", "json.Marshal news")
+	}
+
+	resp, err := c.llm().Complete(
+		ctx,
+		LLMRequest{
+			System: c.prompt(PromptTranslate, PromptData{Language: language}, func() string {
+				return c.Config.TranslatePrompt(language)
+			}),
+			User:        string(jsonNews),
+			Temperature: c.Config.TranslateParams.Temperature,
+			MaxTokens:   c.Config.TranslateParams.MaxTokens,
+			TopP:        c.Config.TranslateParams.TopP,
+			Prefill:     "[",
+			JSON:        true,
+		},
+	)
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "Translate", "LLM.Complete").WithValue(language)
+	}
+
+	matches, err := aiJSONStringFixer(resp)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Translate", "aiJSONStringFixer")
+	}
+
+	var translated []*TranslatedNews
+	err = json.Unmarshal([]byte(matches), &translated)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Translate", "json.Unmarshal").WithValue(matches)
+	}
+
+	translations := make(map[string]*TranslatedNews, len(translated))
+	for _, t := range translated {
+		if t.Text != "" {
+			translations[t.ID] = t
+		}
+	}
+
+	return translations, nil
+}
@@ -0,0 +1,76 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/mock"
+	"reflect"
+	"testing"
+)
+
+func TestComposer_Translate(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		err     error
+		want    map[string]*TranslatedNews
+		wantErr bool
+	}{
+		{
+			name:   "Should return translations by the news ID",
+			answer: `[{"id":"1","title":"Fed","text":"Die Fed lässt die Zinsen unverändert"},{"id":"2","text":"$AAPL steigt um 3%"}]`,
+			want: map[string]*TranslatedNews{
+				"1": {ID: "1", Title: "Fed", Text: "Die Fed lässt die Zinsen unverändert"},
+				"2": {ID: "2", Text: "$AAPL steigt um 3%"},
+			},
+		},
+		{
+			name:   "Should skip empty translations",
+			answer: `[{"id":"1","text":"Die Fed lässt die Zinsen unverändert"},{"id":"2","text":""}]`,
+			want:   map[string]*TranslatedNews{"1": {ID: "1", Text: "Die Fed lässt die Zinsen unverändert"}},
+		},
+		{
+			name:    "Should return error if LLM returns error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if answer is not JSON",
+			answer:  "Sorry, I can't do that",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := []*TranslatedNews{
+				{ID: "1", Title: "Fed", Text: "Fed holds rates steady"},
+				{ID: "2", Text: "$AAPL rises 3%"},
+			}
+			defConf := defaultPromptConfig()
+
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, openai.ChatCompletionRequest{
+				Model: openai.GPT3Dot5Turbo0125,
+				Messages: []openai.ChatCompletionMessage{
+					{Role: openai.ChatMessageRoleSystem, Content: defConf.TranslatePrompt("German")},
+					{Role: openai.ChatMessageRoleUser, Content: `[{"id":"1","title":"Fed","text":"Fed holds rates steady"},{"id":"2","text":"$AAPL rises 3%"}]`},
+				},
+				Temperature: defConf.TranslateParams.Temperature,
+				MaxTokens:   defConf.TranslateParams.MaxTokens,
+				TopP:        defConf.TranslateParams.TopP,
+			}).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: tt.answer}}},
+			}, tt.err)
+
+			c := &Composer{OpenAiClient: mockClient, Config: defConf}
+			got, err := c.Translate(context.Background(), news, "German")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Translate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
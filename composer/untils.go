@@ -6,8 +6,15 @@ import (
 	"strings"
 )
 
+// thinkBlockRegex matches the reasoning blocks of the local reasoning models (e.g. DeepSeek R1, Qwen3).
+var thinkBlockRegex = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
 // aiJSONStringFixer will fix the most weird OpenAI & Mistral bugs with a broken JSON array.
+// It also handles the models that don't emit strict JSON: reasoning blocks are dropped
+// and a single JSON object (JSON output mode answer) is wrapped into the array.
 func aiJSONStringFixer(str string) (string, error) {
+	str = thinkBlockRegex.ReplaceAllString(str, "")
+
 	// Often Mistral bug for empty arrays
 	if str == "[[]]" || strings.Contains(str, "[\\]") {
 		return "[]", nil
@@ -23,9 +30,16 @@ func aiJSONStringFixer(str string) (string, error) {
 	// If not, try a first array []
 	re = regexp.MustCompile(`\[([\S\s]*)]`)
 	matches = re.FindString(str)
-	if matches == "" {
-		return "", newError(errEmptyRegexMatch, errlvl.ERROR, "aiJSONStringFixer", "regexp.FindString").WithValue(str)
+	if matches != "" {
+		return matches, nil
+	}
+
+	// Some models answer with a single object instead of the array
+	re = regexp.MustCompile(`{([\S\s]*)}`)
+	matches = re.FindString(str)
+	if matches != "" {
+		return "[" + matches + "]", nil
 	}
 
-	return matches, nil
+	return "", newError(errEmptyRegexMatch, errlvl.ERROR, "aiJSONStringFixer", "regexp.FindString").WithValue(str)
 }
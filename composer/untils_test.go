@@ -36,6 +36,22 @@ func Test_aiJSONStringFixer(t *testing.T) {
 			want:    "[{\"a\": 1}]",
 			wantErr: false,
 		},
+		{
+			name: "Test with single object",
+			args: args{
+				str: "```json\n{\"a\": 1}\n```",
+			},
+			want:    "[{\"a\": 1}]",
+			wantErr: false,
+		},
+		{
+			name: "Test with reasoning block",
+			args: args{
+				str: "<think>\nMaybe [1] or [2]?\n</think>\n[{\"a\": 1}]",
+			},
+			want:    "[{\"a\": 1}]",
+			wantErr: false,
+		},
 		{
 			name: "Test with no array",
 			args: args{
@@ -0,0 +1,62 @@
+package composer
+
+import (
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"strings"
+)
+
+// Usage is the number of tokens used by one LLM request.
+type Usage struct {
+	Provider         string // LLM provider, e.g. ProviderOpenAI
+	Model            string // model of the request, e.g. "gpt-4o"
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// UsageObserver receives the token usage of every LLM request. It must be safe for concurrent use.
+type UsageObserver func(u Usage)
+
+// modelPrice is the price of the model in USD per 1M tokens.
+type modelPrice struct {
+	prefix     string // model name prefix, e.g. "gpt-4o"
+	prompt     float64
+	completion float64
+}
+
+// modelPrices are the list prices of the known models. More specific prefixes go first.
+// Unknown models (e.g. the locally hosted ones) are free.
+var modelPrices = []modelPrice{
+	{prefix: "gpt-4o-mini", prompt: 0.15, completion: 0.6},
+	{prefix: "gpt-4o", prompt: 2.5, completion: 10},
+	{prefix: "gpt-4-turbo", prompt: 10, completion: 30},
+	{prefix: "gpt-4", prompt: 30, completion: 60},
+	{prefix: "gpt-3.5-turbo", prompt: 0.5, completion: 1.5},
+	{prefix: "claude-3-5-haiku", prompt: 0.8, completion: 4},
+	{prefix: "claude-3-5-sonnet", prompt: 3, completion: 15},
+	{prefix: "claude-3-haiku", prompt: 0.25, completion: 1.25},
+	{prefix: "claude-3-opus", prompt: 15, completion: 75},
+}
+
+// Cost returns the estimated cost of the request in USD by the list price of the model.
+func (u Usage) Cost() float64 {
+	model := strings.ToLower(u.Model)
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return (float64(u.PromptTokens)*p.prompt + float64(u.CompletionTokens)*p.completion) / 1_000_000
+		}
+	}
+	return 0
+}
+
+// recordUsage emits the prompt and completion tokens used by the LLM request and passes them to the observer.
+func recordUsage(m metrics.Emitter, o UsageObserver, u Usage) {
+	if o != nil {
+		o(u)
+	}
+	if m == nil {
+		return
+	}
+	tags := []metrics.Tag{metrics.T("provider", u.Provider), metrics.T("model", u.Model)}
+	m.Count(metrics.LLMTokens, int64(u.PromptTokens), append(tags, metrics.T("type", "prompt"))...)
+	m.Count(metrics.LLMTokens, int64(u.CompletionTokens), append(tags, metrics.T("type", "completion"))...)
+}
@@ -0,0 +1,54 @@
+package composer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUsage_Cost(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  float64
+	}{
+		{
+			name:  "known model",
+			usage: Usage{Model: "gpt-4o", PromptTokens: 1_000_000, CompletionTokens: 100_000},
+			want:  3.5,
+		},
+		{
+			name:  "more specific prefix",
+			usage: Usage{Model: "gpt-4o-mini-2024-07-18", PromptTokens: 1_000_000, CompletionTokens: 1_000_000},
+			want:  0.75,
+		},
+		{
+			name:  "model name case",
+			usage: Usage{Model: "GPT-3.5-turbo-0125", PromptTokens: 2_000_000},
+			want:  1,
+		},
+		{
+			name:  "unknown model",
+			usage: Usage{Model: "llama3.1:8b", PromptTokens: 1_000_000, CompletionTokens: 1_000_000},
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.usage.Cost(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordUsage(t *testing.T) {
+	var got []Usage
+	u := Usage{Provider: ProviderOpenAI, Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5}
+
+	recordUsage(nil, func(u Usage) { got = append(got, u) }, u)
+	recordUsage(nil, nil, u)
+
+	if len(got) != 1 || got[0] != u {
+		t.Errorf("recordUsage() observed %v, want %v", got, u)
+	}
+}
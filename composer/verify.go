@@ -0,0 +1,357 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// VerifyMode is the action on the composed text that fails the verification, see WithVerification.
+type VerifyMode string
+
+const (
+	VerifyFlag   VerifyMode = "flag"   // the text is kept and the news is marked as Unverified
+	VerifyRevert VerifyMode = "revert" // the text is replaced with the original title
+)
+
+// verifySourceLength is the max length of the source sent to the judge (runes), the title and description go first.
+const verifySourceLength = 4000
+
+// numberPattern matches the numbers with the thousands separators and decimals, e.g. "1,500", "2.5" or "3,25".
+var numberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+
+// numberWords are the spelled numbers of the original text, the models often write them as digits.
+var numberWords = map[string]float64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+// nameConnectors are the lowercase words within the names, the acronyms are made with and without them
+// (e.g. "Bank of Japan" is "BOJ" and "BJ").
+var nameConnectors = map[string]bool{"of": true, "the": true, "and": true, "for": true}
+
+// WithVerification enables the verification of the composed texts against the original news, so the text
+// doesn't introduce the numbers or names absent from the title, description, figures and content of the news.
+// The texts passing the overlap heuristics are checked by the optional judge (nil to use the heuristics only).
+// Texts failing the verification are flagged or reverted to the original title by the mode.
+func (c *Composer) WithVerification(judge LLMProvider, mode VerifyMode) *Composer {
+	c.verifyJudge = judge
+	c.verifyMode = mode
+	return c
+}
+
+// verifySource is the original content of the composed news.
+type verifySource struct {
+	text     string // title, description, figures and content the text is composed from
+	original string // title of the news the unverified text is reverted to
+}
+
+// newsSources returns the original content of the news by their IDs.
+func newsSources(news journalist.NewsList) map[string]verifySource {
+	sources := make(map[string]verifySource, len(news))
+	for _, n := range news {
+		sources[n.ID] = verifySource{text: sourceText(n), original: n.Title}
+	}
+	return sources
+}
+
+// mergedSource returns the original content of the story merged from the news: the content of all news
+// under the ID of the story, so the facts of any source are supported.
+func mergedSource(id string, news journalist.NewsList) map[string]verifySource {
+	texts := make([]string, len(news))
+	for i, n := range news {
+		texts[i] = sourceText(n)
+	}
+	return map[string]verifySource{id: {text: strings.Join(texts, "\n"), original: news[0].Title}}
+}
+
+// sourceText returns the title, description, figures and content of the news.
+func sourceText(n *journalist.News) string {
+	return strings.Join([]string{n.Title, n.Description, n.ImageFigures, n.Content}, "\n")
+}
+
+// verify checks the composed texts against their sources if the verification is enabled. Judge failures
+// are logged and the texts passing the heuristics are kept. Reverted texts are trimmed to the length limit.
+func (c *Composer) verify(ctx context.Context, composed []*ComposedNews, sources map[string]verifySource, l lengthLimit) {
+	if c.verifyMode == "" {
+		return
+	}
+
+	failed := make(map[string][]string) // unsupported claims by the news ID, empty for the ones failed by the judge
+	var judged []*ComposedNews
+	for _, n := range composed {
+		src, ok := sources[n.ID]
+		if !ok {
+			continue
+		}
+		if unsupported := unsupportedClaims(n.Text, src.text); len(unsupported) > 0 {
+			failed[n.ID] = unsupported
+			continue
+		}
+		judged = append(judged, n)
+	}
+	if c.verifyJudge != nil && len(judged) > 0 {
+		verdicts, err := c.judge(ctx, judged, sources)
+		if err != nil {
+			logger.WarnContext(ctx, "[composer] Error judging composed texts, heuristics are used", "error", err)
+		}
+		for _, v := range verdicts {
+			if !v.Supported {
+				failed[v.ID] = []string{}
+			}
+		}
+	}
+
+	for _, n := range composed {
+		unsupported, ok := failed[n.ID]
+		if !ok {
+			continue
+		}
+		logger.InfoContext(ctx, "[composer] Composed text failed the verification",
+			"id", n.ID, "mode", c.verifyMode, "unsupported", unsupported)
+		if c.metrics != nil {
+			c.metrics.Count(metrics.NewsUnverified, 1, metrics.T("mode", string(c.verifyMode)))
+		}
+		if c.verifyMode == VerifyRevert {
+			n.Text = l.trim(sources[n.ID].original)
+			continue
+		}
+		n.Unverified = true
+	}
+}
+
+// verdict is the answer of the judge on the composed text.
+type verdict struct {
+	ID        string `json:"id"`
+	Supported bool   `json:"supported"`
+}
+
+// judge asks the judge model whether the composed texts are supported by their sources.
+// News missing in the answer are considered supported.
+func (c *Composer) judge(ctx context.Context, composed []*ComposedNews, sources map[string]verifySource) ([]*verdict, error) {
+	type judgedNews struct {
+		ID     string `json:"id"`
+		Source string `json:"source"`
+		Text   string `json:"text"`
+	}
+	input := make([]judgedNews, len(composed))
+	for i, n := range composed {
+		input[i] = judgedNews{ID: n.ID, Source: truncateRunes(sources[n.ID].text, verifySourceLength), Text: n.Text}
+	}
+	jsonNews, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal news: %w", err)
+	}
+
+	resp, err := c.verifyJudge.Complete(
+		ctx,
+		LLMRequest{
+			System:      c.prompt(PromptVerify, PromptData{}, func() string { return c.Config.VerifyPrompt }),
+			User:        string(jsonNews),
+			Temperature: c.Config.VerifyParams.Temperature,
+			MaxTokens:   c.Config.VerifyParams.MaxTokens,
+			TopP:        c.Config.VerifyParams.TopP,
+			Prefill:     "[",
+			JSON:        true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := aiJSONStringFixer(resp)
+	if err != nil {
+		return nil, err
+	}
+	var verdicts []*verdict
+	if err := json.Unmarshal([]byte(matches), &verdicts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verdicts: %w", err)
+	}
+	return verdicts, nil
+}
+
+// unsupportedClaims returns the numbers and names of the text absent from the source. The numbers can be rounded
+// (e.g. "2.5%" of "2.47%") and spelled in the source, the names can be shortened (e.g. "Fed" of "Federal Reserve")
+// or be the acronyms of the source names (e.g. "ECB" of "European Central Bank").
+func unsupportedClaims(text, source string) []string {
+	var unsupported []string
+
+	sourceNumbers := extractNumbers(source)
+	for _, w := range strings.FieldsFunc(strings.ToLower(source), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if v, ok := numberWords[w]; ok {
+			sourceNumbers = append(sourceNumbers, parsedNumber{value: v})
+		}
+	}
+	for _, n := range extractNumbers(text) {
+		if !n.in(sourceNumbers) {
+			unsupported = append(unsupported, n.text)
+		}
+	}
+
+	words, acronyms := sourceWords(source)
+	for _, name := range textNames(text) {
+		if !nameSupported(name, words, acronyms) {
+			unsupported = append(unsupported, name)
+		}
+	}
+
+	return unsupported
+}
+
+// parsedNumber is the number of the text with its decimal places.
+type parsedNumber struct {
+	text     string
+	value    float64
+	decimals int
+}
+
+// in reports whether the number is one of the numbers, the ones with more decimals are rounded to the number.
+func (p parsedNumber) in(numbers []parsedNumber) bool {
+	scale := math.Pow(10, float64(p.decimals))
+	for _, n := range numbers {
+		if math.Round(n.value*scale) == math.Round(p.value*scale) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractNumbers returns the numbers of the text. The comma is the thousands separator if it is followed
+// by three digits (e.g. "1,500"), otherwise it is the decimal separator (e.g. "3,25").
+func extractNumbers(text string) []parsedNumber {
+	var numbers []parsedNumber
+	for _, m := range numberPattern.FindAllString(text, -1) {
+		s := m
+		if strings.Contains(s, ".") || thousandsGroups(s) {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(s, ",", ".")
+		}
+		// Dotted dates and versions (e.g. "02.01.2006") are compared by their parts
+		if strings.Count(s, ".") > 1 {
+			for _, part := range strings.Split(s, ".") {
+				v, _ := strconv.ParseFloat(part, 64)
+				numbers = append(numbers, parsedNumber{text: part, value: v})
+			}
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			continue
+		}
+		decimals := 0
+		if i := strings.Index(s, "."); i >= 0 {
+			decimals = len(s) - i - 1
+		}
+		numbers = append(numbers, parsedNumber{text: m, value: v, decimals: decimals})
+	}
+	return numbers
+}
+
+// thousandsGroups reports whether all the comma groups of the number have three digits.
+func thousandsGroups(s string) bool {
+	parts := strings.Split(s, ",")
+	for _, p := range parts[1:] {
+		if len(p) != 3 {
+			return false
+		}
+	}
+	return len(parts) > 1
+}
+
+// textNames returns the capitalized words of the text that don't start the sentence, tickers and hashtags are skipped.
+func textNames(text string) []string {
+	var names []string
+	fields := strings.Fields(text)
+	for i, f := range fields {
+		if i == 0 || strings.ContainsAny(fields[i-1][len(fields[i-1])-1:], ".!?:") {
+			continue
+		}
+		if strings.HasPrefix(f, "$") || strings.HasPrefix(f, "#") {
+			continue
+		}
+		w := normalizeWord(f)
+		if w == "" {
+			continue
+		}
+		if r := []rune(w)[0]; unicode.IsUpper(r) {
+			names = append(names, w)
+		}
+	}
+	return names
+}
+
+// sourceWords returns the lowercase words of the source and the acronyms of its capitalized names.
+func sourceWords(source string) (words, acronyms map[string]bool) {
+	words, acronyms = make(map[string]bool), make(map[string]bool)
+	var run, full []rune // initials of the current name without and with the connectors
+	flush := func() {
+		for _, initials := range [][]rune{run, full} {
+			for i := range initials {
+				for j := i + 2; j <= len(initials) && j-i <= 5; j++ {
+					acronyms[string(initials[i:j])] = true
+				}
+			}
+		}
+		run, full = nil, nil
+	}
+	for _, f := range strings.Fields(source) {
+		w := normalizeWord(f)
+		if w == "" {
+			flush()
+			continue
+		}
+		words[strings.ToLower(w)] = true
+		switch r := []rune(w)[0]; {
+		case unicode.IsUpper(r):
+			run = append(run, r)
+			full = append(full, r)
+		case nameConnectors[w] && len(run) > 0:
+			full = append(full, unicode.ToUpper(r))
+		default:
+			flush()
+		}
+		if strings.ContainsAny(f[len(f)-1:], ".,;:!?") {
+			flush()
+		}
+	}
+	flush()
+	return words, acronyms
+}
+
+// nameSupported reports whether the name is the word, the acronym or shares the prefix with the word of the source,
+// e.g. "Fed" of "Federal" or "Germany" of "German".
+func nameSupported(name string, words, acronyms map[string]bool) bool {
+	lower := strings.ToLower(name)
+	if words[lower] || acronyms[strings.ToUpper(name)] && strings.ToUpper(name) == name {
+		return true
+	}
+	if len([]rune(lower)) < 3 {
+		return false
+	}
+	for w := range words {
+		if strings.HasPrefix(w, lower) || len(w) >= 4 && strings.HasPrefix(lower, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWord trims the punctuation and possessive of the word and removes the dots of the abbreviations,
+// e.g. "U.S." is "US" and "Apple's" is "Apple".
+func normalizeWord(w string) string {
+	w = strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	w = strings.TrimSuffix(strings.TrimSuffix(w, "'s"), "’s")
+	w = strings.ReplaceAll(w, ".", "")
+	if !strings.ContainsFunc(w, unicode.IsLetter) {
+		return ""
+	}
+	return w
+}
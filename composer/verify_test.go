@@ -0,0 +1,149 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_unsupportedClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "supported numbers",
+			text:   "Apple revenue rose 2.5% to $1,200 million in three months.",
+			source: "Apple revenue up 2.47% to $1,200M\nQuarterly revenue grew in 3 months",
+			want:   nil,
+		},
+		{
+			name:   "spelled numbers",
+			text:   "Bank hires 5 new directors.",
+			source: "Bank hires five new directors",
+			want:   nil,
+		},
+		{
+			name:   "introduced number",
+			text:   "Fed cuts rates by 50 bps to 4.75%.",
+			source: "Federal Reserve cuts interest rates by 50 basis points",
+			want:   []string{"4.75"},
+		},
+		{
+			name:   "decimal comma",
+			text:   "Inflation in Germany is 2,4%.",
+			source: "German inflation at 2.4%",
+			want:   nil,
+		},
+		{
+			name:   "short names and acronyms",
+			text:   "The ECB and the Fed hold rates, U.S. stocks rise.",
+			source: "European Central Bank and Federal Reserve hold rates steady, United States stocks rise",
+			want:   nil,
+		},
+		{
+			name:   "acronym with connectors",
+			text:   "Rates are kept by the BOJ.",
+			source: "Bank of Japan keeps rates",
+			want:   nil,
+		},
+		{
+			name:   "introduced name",
+			text:   "Shares of Tesla and Nvidia fall. Investors sell the $TSLA stock.",
+			source: "Tesla shares fall on the weak deliveries",
+			want:   []string{"Nvidia"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unsupportedClaims(tt.text, tt.source)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unsupportedClaims() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposer_verify(t *testing.T) {
+	sources := map[string]verifySource{
+		"1": {text: "Apple revenue up 5% in Q3", original: "Apple revenue up 5% in Q3"},
+		"2": {text: "Tesla shares fall on weak deliveries", original: "Tesla shares fall on weak deliveries"},
+		"3": {text: "Microsoft announces the dividend", original: "Microsoft announces the dividend"},
+	}
+	compose := func() []*ComposedNews {
+		return []*ComposedNews{
+			{ID: "1", Text: "Apple revenue rose 7% in the third quarter."},
+			{ID: "2", Text: "Tesla shares fall after weak deliveries."},
+			{ID: "3", Text: "Microsoft announces the dividend."},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		mode      VerifyMode
+		judge     *scriptedProvider
+		wantText  map[string]string
+		wantFlags map[string]bool
+		wantCalls int
+	}{
+		{
+			name:     "disabled",
+			wantText: map[string]string{"1": "Apple revenue rose 7% in the third quarter."},
+		},
+		{
+			name:      "flag by heuristics",
+			mode:      VerifyFlag,
+			wantText:  map[string]string{"1": "Apple revenue rose 7% in the third quarter."},
+			wantFlags: map[string]bool{"1": true},
+		},
+		{
+			name:     "revert by heuristics",
+			mode:     VerifyRevert,
+			wantText: map[string]string{"1": "Apple revenue up 5% in Q3"},
+		},
+		{
+			name:      "flag by judge",
+			mode:      VerifyFlag,
+			judge:     &scriptedProvider{answers: []string{`[{"id":"2","supported":false},{"id":"3","supported":true}]`}},
+			wantFlags: map[string]bool{"1": true, "2": true},
+			wantCalls: 1,
+		},
+		{
+			name:      "judge failure keeps heuristics",
+			mode:      VerifyRevert,
+			judge:     &scriptedProvider{errs: []error{errors.New("timeout")}},
+			wantText:  map[string]string{"1": "Apple revenue up 5% in Q3", "2": "Tesla shares fall after weak deliveries."},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var judge LLMProvider
+			if tt.judge != nil {
+				judge = tt.judge
+			}
+			c := (&Composer{Config: defaultPromptConfig()}).WithVerification(judge, tt.mode)
+
+			composed := compose()
+			c.verify(context.Background(), composed, sources, lengthLimit{})
+
+			for _, n := range composed {
+				if want, ok := tt.wantText[n.ID]; ok && n.Text != want {
+					t.Errorf("news %s: Text = %q, want %q", n.ID, n.Text, want)
+				}
+				if n.Unverified != tt.wantFlags[n.ID] {
+					t.Errorf("news %s: Unverified = %v, want %v", n.ID, n.Unverified, tt.wantFlags[n.ID])
+				}
+			}
+			if tt.judge != nil && tt.judge.calls != tt.wantCalls {
+				t.Errorf("judge calls = %d, want %d", tt.judge.calls, tt.wantCalls)
+			}
+		})
+	}
+}
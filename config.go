@@ -2,34 +2,155 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/jobs"
 	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/rules"
+	"github.com/samgozman/fin-thread/publisher"
+	"slices"
+	"strings"
+	"time"
 )
 
 // Env is a structure that holds all the environment variables that are used in the app.
 type Env struct {
-	TelegramChannelID string `mapstructure:"TELEGRAM_CHANNEL_ID" validate:"required"`
-	TelegramBotToken  string `mapstructure:"TELEGRAM_BOT_TOKEN" validate:"required"`
-	OpenAiToken       string `mapstructure:"OPENAI_TOKEN" validate:"required"`
-	TogetherAIToken   string `mapstructure:"TOGETHER_AI_TOKEN" validate:"required"`
-	GoogleGeminiToken string `mapstructure:"GOOGLE_GEMINI_TOKEN"`
-	PostgresDSN       string `mapstructure:"POSTGRES_DSN" validate:"required"`
-	SentryDSN         string `mapstructure:"SENTRY_DSN" validate:"required"`
-	StockSymbols      string `mapstructure:"STOCK_SYMBOLS" validate:"required"`
-	MarketJournalists string `mapstructure:"MARKET_JOURNALISTS" validate:"required,json"`
-	BroadJournalists  string `mapstructure:"BROAD_JOURNALISTS" validate:"required,json"`
-	ServerName        string `mapstructure:"SERVER_NAME"`
-	ShouldPublish     bool   `mapstructure:"SHOULD_PUBLISH" validate:"boolean"`
+	TelegramChannelID        string  `mapstructure:"TELEGRAM_CHANNEL_ID" validate:"required"`
+	TelegramChannels         string  `mapstructure:"TELEGRAM_CHANNELS" validate:"omitempty,json"`
+	TickerChannels           string  `mapstructure:"TICKER_CHANNELS" validate:"omitempty,json"`
+	TelegramBotToken         string  `mapstructure:"TELEGRAM_BOT_TOKEN" validate:"required"`
+	DiscordWebhookURL        string  `mapstructure:"DISCORD_WEBHOOK_URL" validate:"omitempty,url"`
+	MastodonURL              string  `mapstructure:"MASTODON_URL" validate:"omitempty,url"`
+	MastodonToken            string  `mapstructure:"MASTODON_TOKEN" validate:"required_with=MastodonURL"`
+	MastodonMaxLength        int     `mapstructure:"MASTODON_MAX_LENGTH" validate:"gte=100"`
+	BlueskyURL               string  `mapstructure:"BLUESKY_URL" validate:"omitempty,url"`
+	BlueskyHandle            string  `mapstructure:"BLUESKY_HANDLE"`
+	BlueskyAppPassword       string  `mapstructure:"BLUESKY_APP_PASSWORD" validate:"required_with=BlueskyHandle"`
+	OpenAiToken              string  `mapstructure:"OPENAI_TOKEN" validate:"required"`
+	TogetherAIToken          string  `mapstructure:"TOGETHER_AI_TOKEN" validate:"required"`
+	GoogleGeminiToken        string  `mapstructure:"GOOGLE_GEMINI_TOKEN"`
+	OpenAiBaseURL            string  `mapstructure:"OPENAI_BASE_URL" validate:"omitempty,url"`
+	OpenAiModel              string  `mapstructure:"OPENAI_MODEL"`
+	ComposerProvider         string  `mapstructure:"COMPOSER_PROVIDER" validate:"omitempty,oneof=openai anthropic"`
+	AnthropicToken           string  `mapstructure:"ANTHROPIC_TOKEN" validate:"required_if=ComposerProvider anthropic"`
+	AnthropicModel           string  `mapstructure:"ANTHROPIC_MODEL"`
+	ComposerFallback         string  `mapstructure:"COMPOSER_FALLBACK"`
+	MetaModel                string  `mapstructure:"META_MODEL"`
+	MetaMarkets              string  `mapstructure:"META_MARKETS"`
+	ComposeVerify            string  `mapstructure:"COMPOSE_VERIFY" validate:"omitempty,oneof=flag revert"`
+	VerifyModel              string  `mapstructure:"VERIFY_MODEL" validate:"excluded_without=ComposeVerify"`
+	PromptsDir               string  `mapstructure:"PROMPTS_DIR" validate:"omitempty,dir"`
+	ComposeStyle             string  `mapstructure:"COMPOSE_STYLE" validate:"omitempty,oneof=concise analytical casual"`
+	PromptFiles              string  `mapstructure:"PROMPT_FILES" validate:"omitempty,json"`
+	TickerValidation         bool    `mapstructure:"TICKER_VALIDATION" validate:"boolean"`
+	SecuritiesFile           string  `mapstructure:"SECURITIES_FILE"`
+	SecuritiesURL            string  `mapstructure:"SECURITIES_URL" validate:"omitempty,url"`
+	TickerAliases            string  `mapstructure:"TICKER_ALIASES" validate:"omitempty,json"`
+	PostgresDSN              string  `mapstructure:"POSTGRES_DSN" validate:"required"`
+	SentryDSN                string  `mapstructure:"SENTRY_DSN" validate:"required"`
+	SentryTracesSampleRate   float64 `mapstructure:"SENTRY_TRACES_SAMPLE_RATE" validate:"gte=0,lte=1"`
+	SentryProfilesSampleRate float64 `mapstructure:"SENTRY_PROFILES_SAMPLE_RATE" validate:"gte=0,lte=1"`
+	SentryMaxValueLength     int     `mapstructure:"SENTRY_MAX_VALUE_LENGTH" validate:"gte=0"`
+	StockSymbols             string  `mapstructure:"STOCK_SYMBOLS" validate:"required"`
+	MarketJournalists        string  `mapstructure:"MARKET_JOURNALISTS" validate:"required_without=JobsConfig,omitempty,json"`
+	BroadJournalists         string  `mapstructure:"BROAD_JOURNALISTS" validate:"required_without=JobsConfig,omitempty,json"`
+	JobsConfig               string  `mapstructure:"JOBS_CONFIG" validate:"omitempty,file"`
+	ServerName               string  `mapstructure:"SERVER_NAME"`
+	ShouldPublish            bool    `mapstructure:"SHOULD_PUBLISH" validate:"boolean"`
+	HeartbeatURL             string  `mapstructure:"HEARTBEAT_URL" validate:"omitempty,url"`
+	StatsdAddr               string  `mapstructure:"STATSD_ADDR" validate:"omitempty,hostname_port"`
+	LeaderElection           string  `mapstructure:"LEADER_ELECTION_LEASE"`
+	PodName                  string  `mapstructure:"POD_NAME"`
+	ExportS3Endpoint         string  `mapstructure:"EXPORT_S3_ENDPOINT" validate:"required_with=ExportS3Bucket,omitempty,url"`
+	ExportS3Region           string  `mapstructure:"EXPORT_S3_REGION"`
+	ExportS3Bucket           string  `mapstructure:"EXPORT_S3_BUCKET" validate:"required_if=FeedS3Enabled true"`
+	ExportS3AccessKey        string  `mapstructure:"EXPORT_S3_ACCESS_KEY" validate:"required_with=ExportS3Bucket"`
+	ExportS3SecretKey        string  `mapstructure:"EXPORT_S3_SECRET_KEY" validate:"required_with=ExportS3Bucket"`
+	Rules                    string  `mapstructure:"RULES" validate:"omitempty,json"`
+	RulesFile                string  `mapstructure:"RULES_FILE" validate:"omitempty,file"`
+	AdminChatID              string  `mapstructure:"ADMIN_CHAT_ID" validate:"required_if=AdminCommandsEnabled true"`
+	AdminAlertThreshold      int     `mapstructure:"ADMIN_ALERT_THRESHOLD" validate:"gte=0"`
+	AdminCommandsEnabled     bool    `mapstructure:"ADMIN_COMMANDS_ENABLED" validate:"boolean"`
+	HTTPAddr                 string  `mapstructure:"HTTP_ADDR" validate:"required_if=PrometheusEnabled true,omitempty,hostname_port"`
+	PrometheusEnabled        bool    `mapstructure:"PROMETHEUS_ENABLED" validate:"boolean"`
+	ReadyMaxJobAge           int     `mapstructure:"READY_MAX_JOB_AGE" validate:"gte=0"`
+	ExtractImageFigures      bool    `mapstructure:"EXTRACT_IMAGE_FIGURES" validate:"boolean"`
+	AttachImages             bool    `mapstructure:"ATTACH_IMAGES" validate:"boolean"`
+	ClassifyNews             bool    `mapstructure:"CLASSIFY_NEWS" validate:"boolean"`
+	SuspiciousThreshold      float64 `mapstructure:"SUSPICIOUS_THRESHOLD" validate:"gte=0,lte=1"`
+	MessageFormat            string  `mapstructure:"MESSAGE_FORMAT" validate:"omitempty,oneof=markdownv2 html"`
+	LinkPreview              bool    `mapstructure:"LINK_PREVIEW" validate:"boolean"`
+	SentimentMinConfidence   float64 `mapstructure:"SENTIMENT_MIN_CONFIDENCE" validate:"gte=0,lte=1"`
+	PodcastEnabled           bool    `mapstructure:"PODCAST_ENABLED" validate:"boolean"`
+	SimilarityDedupEnabled   bool    `mapstructure:"SIMILARITY_DEDUP_ENABLED" validate:"boolean"`
+	SimilarityDedupMin       float64 `mapstructure:"SIMILARITY_DEDUP_MIN" validate:"gte=0.5,lte=1"`
+	SimilarityDedupWindow    int     `mapstructure:"SIMILARITY_DEDUP_WINDOW" validate:"gte=1,lte=168"`
+	CalendarNewsEnabled      bool    `mapstructure:"CALENDAR_NEWS_ENABLED" validate:"boolean"`
+	PodcastBaseURL           string  `mapstructure:"PODCAST_BASE_URL" validate:"omitempty,url"`
+	FeedBaseURL              string  `mapstructure:"FEED_BASE_URL" validate:"required_with=FeedDir,required_if=FeedS3Enabled true,omitempty,url"`
+	FeedItems                int     `mapstructure:"FEED_ITEMS" validate:"gte=1,lte=500"`
+	FeedDir                  string  `mapstructure:"FEED_DIR" validate:"omitempty,dir"`
+	FeedS3Enabled            bool    `mapstructure:"FEED_S3_ENABLED" validate:"boolean"`
+	DigestCron               string  `mapstructure:"DIGEST_CRON" validate:"omitempty,cron"`
+	DigestHours              int     `mapstructure:"DIGEST_HOURS" validate:"gte=1,lte=168"`
+	MarketMoodCron           string  `mapstructure:"MARKET_MOOD_CRON" validate:"omitempty,cron"`
+	MarketMoodResultsCron    string  `mapstructure:"MARKET_MOOD_RESULTS_CRON" validate:"required_with=MarketMoodCron,omitempty,cron"`
+	MarketMoodChannel        string  `mapstructure:"MARKET_MOOD_CHANNEL"`
+	EmailDigestCron          string  `mapstructure:"EMAIL_DIGEST_CRON" validate:"omitempty,cron"`
+	EmailProvider            string  `mapstructure:"EMAIL_PROVIDER" validate:"required_with=EmailDigestCron,omitempty,oneof=smtp sendgrid"`
+	EmailFrom                string  `mapstructure:"EMAIL_FROM" validate:"required_with=EmailDigestCron"`
+	EmailTo                  string  `mapstructure:"EMAIL_TO" validate:"required_with=EmailDigestCron"`
+	EmailSubject             string  `mapstructure:"EMAIL_SUBJECT"`
+	EmailUnsubscribeURL      string  `mapstructure:"EMAIL_UNSUBSCRIBE_URL" validate:"omitempty,url"`
+	EmailTemplate            string  `mapstructure:"EMAIL_TEMPLATE" validate:"omitempty,file"`
+	SMTPAddr                 string  `mapstructure:"SMTP_ADDR" validate:"required_if=EmailProvider smtp,omitempty,hostname_port"`
+	SMTPUsername             string  `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword             string  `mapstructure:"SMTP_PASSWORD" validate:"required_with=SMTPUsername"`
+	SendGridAPIKey           string  `mapstructure:"SENDGRID_API_KEY" validate:"required_if=EmailProvider sendgrid"`
+	QuotesProvider           string  `mapstructure:"QUOTES_PROVIDER" validate:"omitempty,oneof=yahoo finnhub"`
+	FinnhubToken             string  `mapstructure:"FINNHUB_TOKEN" validate:"required_if=QuotesProvider finnhub"`
+	QuotesCacheTTL           int     `mapstructure:"QUOTES_CACHE_TTL" validate:"gte=1"`
+	ThreadMaxLength          int     `mapstructure:"THREAD_MAX_LENGTH" validate:"gte=0,lte=4096"`
+	PublishRetryAttempts     int     `mapstructure:"PUBLISH_RETRY_ATTEMPTS" validate:"gte=1,lte=10"`
+	DeadLetterMaxAttempts    int     `mapstructure:"DEAD_LETTER_MAX_ATTEMPTS" validate:"gte=1"`
+	PublishRetryMaxDelay     int     `mapstructure:"PUBLISH_RETRY_MAX_DELAY" validate:"gte=1,lte=300"`
+	PublishRatePerChat       int     `mapstructure:"PUBLISH_RATE_PER_CHAT" validate:"gte=0"`
+	JobTimeout               int     `mapstructure:"JOB_TIMEOUT" validate:"gte=5,lte=600"`
+	PublishRateGlobal        int     `mapstructure:"PUBLISH_RATE_GLOBAL" validate:"gte=0"`
+	NewsRetentionDays        int     `mapstructure:"NEWS_RETENTION_DAYS" validate:"gte=0"`
+	PublishedRetentionDays   int     `mapstructure:"PUBLISHED_RETENTION_DAYS" validate:"gte=0"`
+	EngagementStatsDays      int     `mapstructure:"ENGAGEMENT_STATS_DAYS" validate:"gte=0,lte=90"`
+	PublishWindow            string  `mapstructure:"PUBLISH_WINDOW"`
+	QuietHours               string  `mapstructure:"QUIET_HOURS"`
+	PublishTimezone          string  `mapstructure:"PUBLISH_TIMEZONE" validate:"omitempty,timezone"`
+	RoundupHeader            string  `mapstructure:"ROUNDUP_HEADER"`
+	ComposeFreshnessWindow   int     `mapstructure:"COMPOSE_FRESHNESS_WINDOW" validate:"gte=0"`
+	ComposeMaxSentences      int     `mapstructure:"COMPOSE_MAX_SENTENCES" validate:"gte=0"`
+	ComposeMaxChars          int     `mapstructure:"COMPOSE_MAX_CHARS" validate:"gte=0,lte=4096"`
+	ComposeCacheTTL          int     `mapstructure:"COMPOSE_CACHE_TTL" validate:"gte=0"`
+	ComposeCacheSize         int     `mapstructure:"COMPOSE_CACHE_SIZE" validate:"gte=1"`
+	LLMMonthlyBudget         float64 `mapstructure:"LLM_MONTHLY_BUDGET" validate:"gte=0"`
 }
 
+const (
+	defaultChannelName    = "default"         // Name of the channel seeded from TELEGRAM_CHANNEL_ID
+	suspiciousKeywordSet  = "suspicious"      // Name of the keyword set with suspicious keywords
+	defaultSecuritiesFile = "securities.json" // Cache of the securities list if SECURITIES_FILE is not set
+)
+
 type Config struct {
-	env                *Env     // Holds all the environment variables that are used in the app
-	suspiciousKeywords []string // Used to "flag" suspicious news by the journalist.Journalist
-	rssProviders       struct {
-		marketJournalists []journalist.NewsProvider // Market news journalists
-		broadJournalists  []journalist.NewsProvider // Broad news journalists
-	}
+	env                *Env              // Holds all the environment variables that are used in the app
+	suspiciousKeywords []string          // Used to "flag" suspicious news by the journalist.Journalist
+	rules              []rules.Rule      // Operator-defined rules for filtering, flagging, priority and channel routing
+	channels           []channel         // Named channels with routing by news meta
+	tickerChannels     map[string]string // Channels subscribed to the news of the tickers by the ticker
+	tickerAliases      map[string]string // Tickers by their aliases from TICKER_ALIASES
+	jobs               []jobDefinition   // News jobs from JOBS_CONFIG file or the default ones
+	promptFiles        map[string]string // Prompt template files by the composer stage from PROMPT_FILES
+	// News pipelines of the other channels from JOBS_CONFIG file, each with its own bot, composer and jobs
+	threads []threadDefinition
 }
 
 // NewConfig creates a new Config object with the given Env and default values from DefaultConfig.
@@ -37,19 +158,65 @@ func NewConfig(env *Env) (*Config, error) {
 	c := DefaultConfig()
 	c.env = env
 
-	// unmarshal rss providers and validate them
-	marketJournalists, err := unmarshalRssProviders(env.MarketJournalists)
+	var err error
+	if env.JobsConfig != "" {
+		var f *jobsFile
+		if f, err = loadJobsFile(env.JobsConfig); err == nil {
+			c.jobs, c.threads = f.Jobs, f.Threads
+		}
+	} else {
+		c.jobs, err = defaultJobs(env)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("marketJournalists: %w", err)
+		return nil, fmt.Errorf("jobs: %w", err)
 	}
 
-	broadJournalists, err := unmarshalRssProviders(env.BroadJournalists)
-	if err != nil {
-		return nil, fmt.Errorf("broadJournalists: %w", err)
+	if env.TelegramChannels != "" {
+		c.channels, err = unmarshalChannels(env.TelegramChannels)
+		if err != nil {
+			return nil, fmt.Errorf("channels: %w", err)
+		}
+		c.applyChannelStyles()
 	}
 
-	c.rssProviders.marketJournalists = marketJournalists
-	c.rssProviders.broadJournalists = broadJournalists
+	if env.TickerChannels != "" {
+		if err := json.Unmarshal([]byte(env.TickerChannels), &c.tickerChannels); err != nil {
+			return nil, fmt.Errorf("ticker channels: %w", err)
+		}
+	}
+
+	if env.TickerAliases != "" {
+		if err := json.Unmarshal([]byte(env.TickerAliases), &c.tickerAliases); err != nil {
+			return nil, fmt.Errorf("ticker aliases: %w", err)
+		}
+	}
+
+	if env.PromptFiles != "" {
+		if err := json.Unmarshal([]byte(env.PromptFiles), &c.promptFiles); err != nil {
+			return nil, fmt.Errorf("prompt files: %w", err)
+		}
+	}
+
+	if env.Rules != "" {
+		if err := json.Unmarshal([]byte(env.Rules), &c.rules); err != nil {
+			return nil, fmt.Errorf("rules: %w", err)
+		}
+	}
+	if env.RulesFile != "" {
+		fileRules, err := rules.LoadFile(env.RulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("rules: %w", err)
+		}
+		c.rules = append(c.rules, fileRules...)
+	}
+	if err := errors.Join(c.validateChannels()...); err != nil {
+		return nil, err
+	}
+
+	// Rules are compiled again with the suspicious keywords, this only reports the broken ones at startup
+	if _, err := rules.Compile(c.rules); err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
 
 	return c, nil
 }
@@ -132,13 +299,74 @@ func DefaultConfig() *Config {
 	}
 }
 
+// rssProvider is the provider configuration. If Command is set, the provider is an external plugin
+// process (see journalist.PluginProvider), if Subreddits are set, the provider fetches the hot Reddit posts
+// (see journalist.RedditProvider), if Forms are set, the provider fetches the new SEC EDGAR filings
+// (see journalist.EdgarProvider), if Accounts are set, the provider fetches the X posts
+// (see journalist.XProvider), if Token is set, the news are pushed to the ingest endpoint
+// (see journalist.WebhookProvider), if Fields are set, the provider fetches the JSON API with the given URL
+// (see journalist.JSONProvider), if Selectors are set, the provider scrapes the HTML page with the given URL
+// (see journalist.HTMLProvider), otherwise it is RSS, Atom or JSON Feed with the given URL.
+// Type selects the provider registered with journalist.RegisterProvider explicitly, e.g. the third-party one
+// configured with Options.
 type rssProvider struct {
-	Name string `validate:"required"`
-	URL  string `validate:"required,url"`
+	Name       string   `json:"name" yaml:"name" validate:"required"`
+	URL        string   `json:"url" yaml:"url" validate:"required_without_all=Command Subreddits Forms Accounts Token Type,omitempty,url"`
+	Command    string   `json:"command" yaml:"command"`
+	Args       []string `json:"args" yaml:"args"`
+	Subreddits []string `json:"subreddits" yaml:"subreddits"`
+	MinScore   int      `json:"min_score" yaml:"min_score" validate:"gte=0"`                 // min score of the Reddit posts (default 100)
+	Forms      []string `json:"forms" yaml:"forms"`                                          // SEC form types, e.g. "8-K", "13F-HR", "S-1"
+	Watchlist  []string `json:"watchlist" yaml:"watchlist"`                                  // CIKs or tickers to fetch the filings of (optional)
+	UserAgent  string   `json:"user_agent" yaml:"user_agent" validate:"required_with=Forms"` // SEC requires the contact email in the user agent
+	// MinInterval is the minimum interval between the RSS feed requests in seconds, 0 - fetch on every job run
+	MinInterval int                    `json:"min_interval" yaml:"min_interval" validate:"gte=0"`
+	Accounts    []string               `json:"accounts" yaml:"accounts"`                      // X accounts, e.g. "DeItaone"
+	BearerToken string                 `json:"bearer_token" yaml:"bearer_token"`              // X API v2 bearer token, the Mirror is used if empty
+	Mirror      string                 `json:"mirror" yaml:"mirror" validate:"omitempty,url"` // nitter-style mirror with the RSS feeds of the accounts
+	Token       string                 `json:"token" yaml:"token"`                            // secret of the pushed news requests (webhook)
+	Type        string                 `json:"type" yaml:"type"`                              // registered provider type, inferred from the fields if empty
+	Options     map[string]interface{} `json:"options" yaml:"options"`                        // settings of the third-party provider
+	// Path of the news array in the JSON API response, e.g. "data.articles" (the response itself if empty)
+	Items      string            `json:"items" yaml:"items"`
+	Fields     jsonFields        `json:"fields" yaml:"fields"`         // paths of the news fields in the JSON API item
+	Headers    map[string]string `json:"headers" yaml:"headers"`       // headers of the JSON API requests, e.g. the API key
+	Pagination jsonPagination    `json:"pagination" yaml:"pagination"` // pagination of the JSON API (optional)
+	// CSS selectors of the news on the HTML page without RSS, e.g. {item: "ul.notices > li", title: "a"}
+	Selectors htmlSelectors `json:"selectors" yaml:"selectors"`
+}
+
+// jsonFields are the paths of the news fields in the JSON API item, see journalist.JSONFields.
+type jsonFields struct {
+	Title       string `json:"title" yaml:"title" validate:"required_with=Date"`
+	Description string `json:"description" yaml:"description"`
+	Link        string `json:"link" yaml:"link"`
+	Date        string `json:"date" yaml:"date" validate:"required_with=Title"`
+	Image       string `json:"image" yaml:"image"`
+}
+
+// htmlSelectors are the CSS selectors of the news on the HTML page, see journalist.HTMLSelectors.
+type htmlSelectors struct {
+	Item        string `json:"item" yaml:"item" validate:"required_with=Title"`
+	Title       string `json:"title" yaml:"title" validate:"required_with=Item"`
+	Link        string `json:"link" yaml:"link"`               // the title link if empty
+	Date        string `json:"date" yaml:"date"`               // the items without date are dated when they appear
+	Description string `json:"description" yaml:"description"` // optional
+	DateLayout  string `json:"date_layout" yaml:"date_layout"` // Go layout of the date text, e.g. "02 Jan 2006"
+}
+
+// jsonPagination is the pagination of the JSON API, see journalist.JSONPagination.
+type jsonPagination struct {
+	PageParam   string `json:"page_param" yaml:"page_param"`     // query parameter of the page number, e.g. "page"
+	StartPage   int    `json:"start_page" yaml:"start_page"`     // number of the first page, 1 by default
+	CursorPath  string `json:"cursor_path" yaml:"cursor_path"`   // path of the next page cursor in the response
+	CursorParam string `json:"cursor_param" yaml:"cursor_param"` // query parameter of the cursor, "cursor" by default
+	NextPath    string `json:"next_path" yaml:"next_path"`       // path of the next page URL in the response
+	MaxPages    int    `json:"max_pages" yaml:"max_pages" validate:"gte=0,lte=20"`
 }
 
 // unmarshalRssProviders unmarshal a JSON string into a slice of rssProvider objects.
-func unmarshalRssProviders(str string) ([]journalist.NewsProvider, error) {
+func unmarshalRssProviders(str string) ([]rssProvider, error) {
 	var rssProviderList []rssProvider
 	err := json.Unmarshal([]byte(str), &rssProviderList)
 	if err != nil {
@@ -149,12 +377,324 @@ func unmarshalRssProviders(str string) ([]journalist.NewsProvider, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error validating journalist: %w", err)
 		}
+		if err := item.validateType(); err != nil {
+			return nil, fmt.Errorf("error validating journalist: %w", err)
+		}
 	}
 
-	result := make([]journalist.NewsProvider, 0, len(rssProviderList))
-	for _, item := range rssProviderList {
-		result = append(result, journalist.NewRssProvider(item.Name, item.URL))
+	return rssProviderList, nil
+}
+
+// providerType returns the registered provider type: the explicit Type or the one inferred from the fields.
+func (p *rssProvider) providerType() string {
+	switch {
+	case p.Type != "":
+		return p.Type
+	case p.Command != "":
+		return journalist.ProviderPlugin
+	case len(p.Forms) > 0:
+		return journalist.ProviderEdgar
+	case len(p.Accounts) > 0:
+		return journalist.ProviderX
+	case len(p.Subreddits) > 0:
+		return journalist.ProviderReddit
+	case p.Token != "":
+		return journalist.ProviderWebhook
+	case p.Fields.Title != "":
+		return journalist.ProviderJSON
+	case p.Selectors.Item != "":
+		return journalist.ProviderHTML
+	default:
+		return journalist.ProviderRSS
+	}
+}
+
+// validateType checks that the provider type is registered.
+func (p *rssProvider) validateType() error {
+	if !journalist.IsProviderRegistered(p.providerType()) {
+		return fmt.Errorf("provider %s: unknown type %q, registered: %s",
+			p.Name, p.providerType(), strings.Join(journalist.RegisteredProviders(), ", "))
+	}
+	return nil
+}
+
+// newsProviders creates the news providers from their configuration with the registered factories.
+func newsProviders(list []rssProvider) ([]journalist.NewsProvider, error) {
+	result := make([]journalist.NewsProvider, 0, len(list))
+	for _, item := range list {
+		p, err := journalist.NewProvider(journalist.ProviderConfig{
+			Type:        item.providerType(),
+			Name:        item.Name,
+			URL:         item.URL,
+			UserAgent:   item.UserAgent,
+			MinInterval: time.Duration(item.MinInterval) * time.Second,
+			Command:     item.Command,
+			Args:        item.Args,
+			Forms:       item.Forms,
+			Watchlist:   item.Watchlist,
+			Accounts:    item.Accounts,
+			BearerToken: item.BearerToken,
+			Mirror:      item.Mirror,
+			Subreddits:  item.Subreddits,
+			MinScore:    item.MinScore,
+			Token:       item.Token,
+			Options:     item.Options,
+			Items:       item.Items,
+			Fields:      journalist.JSONFields(item.Fields),
+			Headers:     item.Headers,
+			Pagination:  journalist.JSONPagination(item.Pagination),
+			Selectors:   journalist.HTMLSelectors(item.Selectors),
+		})
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		result = append(result, p)
 	}
 
 	return result, nil
 }
+
+// channel is the named channel configuration. News with any of the tickers, markets, hashtags or entities
+// are routed to this channel instead of the default one. Channel with the topic is the topic
+// of the forum supergroup, several channels can share the same chat with different topics.
+type channel struct {
+	Name     string   `json:"name" validate:"required,max=64"`
+	ChatID   string   `json:"chat_id" validate:"required,max=64"`
+	TopicID  int      `json:"topic_id" validate:"gte=0"`                                  // message_thread_id of the forum topic (optional)
+	Style    string   `json:"style" validate:"omitempty,oneof=concise analytical casual"` // style of the jobs publishing to the channel
+	Tickers  []string `json:"tickers"`
+	Markets  []string `json:"markets"`
+	Hashtags []string `json:"hashtags"`
+	Entities []string `json:"entities"` // companies, people, central banks or countries, e.g. "Fed"
+	Template string   `json:"template"` // go text/template of the published messages (optional), see publisher.MessageTemplate
+
+	MaxSentences int `json:"max_sentences" validate:"gte=0"`      // max sentences of the composed text of the jobs publishing to the channel
+	MaxChars     int `json:"max_chars" validate:"gte=0,lte=4096"` // max characters of the composed text of the jobs publishing to the channel
+
+	// Publishing window (e.g. "07:00-23:00"), quiet hours (e.g. "12:00-13:00") in the timezone (e.g. "Europe/Berlin")
+	// and the header of the held news published when the window opens, see jobs.PublishWindow
+	Window        string `json:"window"`
+	QuietHours    string `json:"quiet_hours"`
+	Timezone      string `json:"timezone"`
+	RoundupHeader string `json:"roundup_header"`
+
+	messageTemplate *publisher.MessageTemplate // parsed Template
+}
+
+// unmarshalChannels unmarshal a JSON string into a slice of channel objects.
+func unmarshalChannels(str string) ([]channel, error) {
+	var channels []channel
+	err := json.Unmarshal([]byte(str), &channels)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling channels: %w", err)
+	}
+	for i, item := range channels {
+		err := validator.New().Struct(item)
+		if err != nil {
+			return nil, fmt.Errorf("error validating channel: %w", err)
+		}
+		if item.Template != "" {
+			channels[i].messageTemplate, err = publisher.ParseMessageTemplate(item.Name, item.Template)
+			if err != nil {
+				return nil, fmt.Errorf("error validating channel: %w", err)
+			}
+		}
+	}
+
+	return channels, nil
+}
+
+// ruleSet compiles the operator-defined rules with the fetch stage rule that flags the news
+// containing the suspicious keywords.
+func (c *Config) ruleSet() (*rules.Set, error) {
+	all := c.rules
+	if len(c.suspiciousKeywords) > 0 {
+		all = append([]rules.Rule{{
+			Name:     suspiciousKeywordSet,
+			Stage:    rules.StageFetch,
+			Keywords: c.suspiciousKeywords,
+			Flag:     true,
+		}}, all...)
+	}
+
+	return rules.Compile(all) //nolint:wrapcheck
+}
+
+// fallbackModel is the model of the composer fallback chain or the meta extraction, see COMPOSER_FALLBACK and META_MODEL.
+type fallbackModel struct {
+	Provider string // composer.ProviderOpenAI or composer.ProviderAnthropic
+	Model    string
+}
+
+// fallbackModels parses the comma-separated fallback chain of the composer: models with the optional provider
+// prefix, e.g. "gpt-4o-mini,anthropic:claude-3-5-haiku-latest". OpenAI is the default provider.
+func (c *Config) fallbackModels() []fallbackModel {
+	var models []fallbackModel
+	for _, item := range strings.Split(c.env.ComposerFallback, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			models = append(models, parseModel(item))
+		}
+	}
+	return models
+}
+
+// metaModel returns the model of the separate meta extraction in the same format as the fallback models,
+// see META_MODEL. Returns false if the meta is composed with the text.
+func (c *Config) metaModel() (fallbackModel, bool) {
+	item := strings.TrimSpace(c.env.MetaModel)
+	if item == "" {
+		return fallbackModel{}, false
+	}
+	return parseModel(item), true
+}
+
+// verifyModel returns the judge model of the composed texts verification in the same format as the fallback models,
+// see VERIFY_MODEL. Returns false if the texts are verified by the heuristics only.
+func (c *Config) verifyModel() (fallbackModel, bool) {
+	item := strings.TrimSpace(c.env.VerifyModel)
+	if item == "" {
+		return fallbackModel{}, false
+	}
+	return parseModel(item), true
+}
+
+// metaMarkets returns the markets of the meta extraction from the comma-separated META_MARKETS.
+func (c *Config) metaMarkets() []string {
+	var markets []string
+	for _, item := range strings.Split(c.env.MetaMarkets, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			markets = append(markets, item)
+		}
+	}
+	return markets
+}
+
+// parseModel parses the model with the optional provider prefix, e.g. "anthropic:claude-3-5-haiku-latest".
+// OpenAI is the default provider.
+func parseModel(item string) fallbackModel {
+	provider, model, found := strings.Cut(item, ":")
+	if !found {
+		provider, model = composer.ProviderOpenAI, item
+	}
+	return fallbackModel{Provider: strings.TrimSpace(provider), Model: strings.TrimSpace(model)}
+}
+
+// channelChatIDs returns the map of the channel names to their chat ids.
+func (c *Config) channelChatIDs() map[string]string {
+	chatIDs := make(map[string]string, len(c.channels))
+	for _, ch := range c.channels {
+		chatIDs[ch.Name] = ch.ChatID
+	}
+	return chatIDs
+}
+
+// publishWindows returns the publishing windows of the default (empty name) and named channels by their names,
+// only the channels with the window or the quiet hours. Named channels use PUBLISH_TIMEZONE and ROUNDUP_HEADER
+// without their own ones.
+func (c *Config) publishWindows() (map[string]*jobs.PublishWindow, error) {
+	windows := make(map[string]*jobs.PublishWindow)
+	w, err := jobs.NewPublishWindow(c.env.PublishWindow, c.env.QuietHours, c.env.PublishTimezone, c.env.RoundupHeader)
+	if err != nil {
+		return nil, fmt.Errorf("PUBLISH_WINDOW: %w", err)
+	}
+	if w != nil {
+		windows[""] = w
+	}
+
+	for _, ch := range c.channels {
+		timezone, header := ch.Timezone, ch.RoundupHeader
+		if timezone == "" {
+			timezone = c.env.PublishTimezone
+		}
+		if header == "" {
+			header = c.env.RoundupHeader
+		}
+		w, err := jobs.NewPublishWindow(ch.Window, ch.QuietHours, timezone, header)
+		if err != nil {
+			return nil, fmt.Errorf("TELEGRAM_CHANNELS window of the channel %s: %w", ch.Name, err)
+		}
+		if w != nil {
+			windows[ch.Name] = w
+		}
+	}
+
+	return windows, nil
+}
+
+// publicChannelIDs returns the usernames of the default, named and thread channels (e.g. @my_channel) without
+// duplicates, only the posts of the public channels have the engagement stats (see publisher.TelegramStats).
+func (c *Config) publicChannelIDs() []string {
+	chatIDs := []string{c.env.TelegramChannelID}
+	for _, ch := range c.channels {
+		chatIDs = append(chatIDs, ch.ChatID)
+	}
+	for _, t := range c.threads {
+		chatIDs = append(chatIDs, t.ChannelID)
+	}
+
+	var public []string
+	for _, id := range chatIDs {
+		if strings.HasPrefix(id, "@") && !slices.Contains(public, id) {
+			public = append(public, id)
+		}
+	}
+	return public
+}
+
+// channelTopics returns the map of the channel names to their forum topics (only channels with the topic).
+func (c *Config) channelTopics() map[string]int {
+	topics := make(map[string]int)
+	for _, ch := range c.channels {
+		if ch.TopicID > 0 {
+			topics[ch.Name] = ch.TopicID
+		}
+	}
+	return topics
+}
+
+// channelTemplates returns the map of the channel names to their message templates (only channels with the template).
+func (c *Config) channelTemplates() map[string]*publisher.MessageTemplate {
+	templates := make(map[string]*publisher.MessageTemplate)
+	for _, ch := range c.channels {
+		if ch.messageTemplate != nil {
+			templates[ch.Name] = ch.messageTemplate
+		}
+	}
+	return templates
+}
+
+// applyChannelStyles sets the style and length limits of the channel to the jobs publishing to it
+// without their own ones.
+func (c *Config) applyChannelStyles() {
+	channels := make(map[string]channel, len(c.channels))
+	for _, ch := range c.channels {
+		channels[ch.Name] = ch
+	}
+	for i := range c.jobs {
+		ch := channels[c.jobs[i].Channel]
+		if c.jobs[i].Style == "" {
+			c.jobs[i].Style = ch.Style
+		}
+		if c.jobs[i].MaxSentences == 0 {
+			c.jobs[i].MaxSentences = ch.MaxSentences
+		}
+		if c.jobs[i].MaxChars == 0 {
+			c.jobs[i].MaxChars = ch.MaxChars
+		}
+	}
+}
+
+// channelRoutes returns the routes to the channels in the configured order.
+func (c *Config) channelRoutes() []jobs.ChannelRoute {
+	routes := make([]jobs.ChannelRoute, 0, len(c.channels))
+	for _, ch := range c.channels {
+		routes = append(routes, jobs.ChannelRoute{
+			Channel:  ch.Name,
+			Tickers:  ch.Tickers,
+			Markets:  ch.Markets,
+			Hashtags: ch.Hashtags,
+			Entities: ch.Entities,
+		})
+	}
+	return routes
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"github.com/getsentry/sentry-go"
+	"regexp"
+	"strings"
+)
+
+const filteredValue = "[Filtered]"
+
+// credentialsInURL matches user info in URLs and DSNs, e.g. "postgres://user:password@" or "https://public@".
+var credentialsInURL = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s@]+@`)
+
+// credentialsInDSN matches password values in key=value DSNs, e.g. "password=secret".
+var credentialsInDSN = regexp.MustCompile(`(?i)(password=)\S+`)
+
+// SentryScrubber removes sensitive data from the Sentry events and breadcrumbs before they are sent:
+// known secrets (tokens, DSNs), credentials in URLs and overly long values (full news bodies, LLM responses).
+type SentryScrubber struct {
+	secrets        []string // exact secret values to replace, e.g. API tokens
+	maxValueLength int      // values longer than this are truncated, 0 disables truncation
+}
+
+// NewSentryScrubber creates a new SentryScrubber. Empty secrets are ignored.
+func NewSentryScrubber(secrets []string, maxValueLength int) *SentryScrubber {
+	s := &SentryScrubber{maxValueLength: maxValueLength}
+	for _, secret := range secrets {
+		if secret != "" {
+			s.secrets = append(s.secrets, secret)
+		}
+	}
+	return s
+}
+
+// BeforeSend is a sentry.ClientOptions.BeforeSend hook.
+func (s *SentryScrubber) BeforeSend(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	event.Message = s.Scrub(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = s.Scrub(event.Exception[i].Value)
+	}
+	for _, b := range event.Breadcrumbs {
+		s.scrubBreadcrumb(b)
+	}
+	for k, v := range event.Extra {
+		if str, ok := v.(string); ok {
+			event.Extra[k] = s.Scrub(str)
+		}
+	}
+	for k, v := range event.Tags {
+		event.Tags[k] = s.Scrub(v)
+	}
+	if event.Request != nil {
+		event.Request.URL = s.Scrub(event.Request.URL)
+		event.Request.Data = s.Scrub(event.Request.Data)
+		event.Request.Cookies = ""
+		for k := range event.Request.Headers {
+			if strings.EqualFold(k, "Authorization") || strings.EqualFold(k, "Cookie") {
+				event.Request.Headers[k] = filteredValue
+			}
+		}
+	}
+	return event
+}
+
+// BeforeBreadcrumb is a sentry.ClientOptions.BeforeBreadcrumb hook.
+func (s *SentryScrubber) BeforeBreadcrumb(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+	s.scrubBreadcrumb(breadcrumb)
+	return breadcrumb
+}
+
+func (s *SentryScrubber) scrubBreadcrumb(b *sentry.Breadcrumb) {
+	b.Message = s.Scrub(b.Message)
+	for k, v := range b.Data {
+		if str, ok := v.(string); ok {
+			b.Data[k] = s.Scrub(str)
+		}
+	}
+}
+
+// Scrub replaces secrets and credentials in the string and truncates it to the max length.
+func (s *SentryScrubber) Scrub(str string) string {
+	if str == "" {
+		return str
+	}
+
+	for _, secret := range s.secrets {
+		str = strings.ReplaceAll(str, secret, filteredValue)
+	}
+	str = credentialsInURL.ReplaceAllString(str, "${1}"+filteredValue+"@")
+	str = credentialsInDSN.ReplaceAllString(str, "${1}"+filteredValue)
+
+	if s.maxValueLength > 0 && len(str) > s.maxValueLength {
+		// cut on the rune boundary
+		cut := s.maxValueLength
+		for cut > 0 && !isRuneStart(str[cut]) {
+			cut--
+		}
+		str = str[:cut] + "...[truncated]"
+	}
+
+	return str
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -100,3 +100,67 @@ func ReplaceUnicodeSymbols(s string) string {
 
 	return decoded
 }
+
+// SplitBySentences splits the text into chunks of at most `limit` bytes on the sentence boundaries
+// (after ".", "!" or "?" followed by a space, or after a new line). Sentences longer than the limit
+// are split by the last space (or by the limit itself if there are no spaces).
+func SplitBySentences(text string, limit int) []string {
+	var chunks []string
+	var current strings.Builder
+
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, sentence := range splitSentences(text) {
+		for len(strings.TrimRight(sentence, " \n")) > limit {
+			cut := strings.LastIndex(sentence[:limit], " ")
+			if cut <= 0 {
+				cut = limit
+				for cut > 0 && !isRuneStart(sentence[cut]) {
+					cut--
+				}
+			}
+			flush()
+			current.WriteString(sentence[:cut])
+			flush()
+			sentence = sentence[cut:]
+		}
+
+		if current.Len()+len(strings.TrimRight(sentence, " \n")) > limit {
+			flush()
+		}
+		current.WriteString(sentence)
+	}
+	flush()
+
+	return chunks
+}
+
+// splitSentences splits the text into sentences, each sentence keeps its trailing whitespace.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		end := -1
+		switch {
+		case text[i] == '\n':
+			end = i + 1
+		case strings.IndexByte(".!?", text[i]) >= 0 && i+1 < len(text) && text[i+1] == ' ':
+			end = i + 2
+		}
+		if end > 0 {
+			sentences = append(sentences, text[start:end])
+			start = end
+			i = end - 1
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+
+	return sentences
+}
@@ -178,3 +178,68 @@ func Test_ReplaceUnicodeSymbols(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitBySentences(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "short text is a single chunk",
+			text:  "Stocks rallied. Bonds fell.",
+			limit: 100,
+			want:  []string{"Stocks rallied. Bonds fell."},
+		},
+		{
+			name:  "split by sentences",
+			text:  "Stocks rallied today. Bonds fell! Oil is flat? Gold rose.",
+			limit: 25,
+			want:  []string{"Stocks rallied today.", "Bonds fell! Oil is flat?", "Gold rose."},
+		},
+		{
+			name:  "numbers and links are not split",
+			text:  "CPI rose 3.1% in [AAPL](https://example.com/AAPL). Done.",
+			limit: 50,
+			want:  []string{"CPI rose 3.1% in [AAPL](https://example.com/AAPL).", "Done."},
+		},
+		{
+			name:  "split by new lines",
+			text:  "First line\nSecond line",
+			limit: 15,
+			want:  []string{"First line", "Second line"},
+		},
+		{
+			name:  "long sentence split by spaces",
+			text:  "Stocks rallied on strong earnings",
+			limit: 15,
+			want:  []string{"Stocks rallied", "on strong", "earnings"},
+		},
+		{
+			name:  "long word split by limit",
+			text:  "abcdefghij",
+			limit: 4,
+			want:  []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:  "empty text",
+			text:  " \n ",
+			limit: 10,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitBySentences(tt.text, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitBySentences() = %q, want %q", got, tt.want)
+			}
+			for _, chunk := range got {
+				if len(chunk) > tt.limit {
+					t.Errorf("SplitBySentences() chunk %q is longer than %d", chunk, tt.limit)
+				}
+			}
+		})
+	}
+}
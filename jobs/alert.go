@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// alertSender is the interface for the publisher that sends alerts to the admin chat.
+type alertSender interface {
+	PublishTo(channelID, msg string) (string, error)
+}
+
+// Alerter sends concise alerts to the admin chat when job stages fail.
+// Sentry emails are too slow during market hours, so this is the fast path for on-call.
+//
+// Alert for the job stage is sent when the number of its errors within the window reaches the threshold.
+// After the alert, the same stage is muted for the cooldown period to avoid flooding the chat.
+type Alerter struct {
+	sender    alertSender   // publisher that sends the alerts
+	chatID    string        // admin chat ID
+	threshold int           // number of errors within the window that triggers the alert
+	window    time.Duration // errors older than this are not counted
+	cooldown  time.Duration // minimal interval between alerts for the same job stage
+	logger    *slog.Logger
+
+	mu        sync.Mutex
+	errors    map[string][]time.Time // error times by job stage
+	alertedAt map[string]time.Time   // last alert time by job stage
+}
+
+// NewAlerter creates a new Alerter. Threshold less than 1 means alert on every error.
+func NewAlerter(sender alertSender, chatID string, threshold int) *Alerter {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Alerter{
+		sender:    sender,
+		chatID:    chatID,
+		threshold: threshold,
+		window:    15 * time.Minute,
+		cooldown:  15 * time.Minute,
+		logger:    logging.For(logging.ModuleJobs),
+		errors:    make(map[string][]time.Time),
+		alertedAt: make(map[string]time.Time),
+	}
+}
+
+// Alert registers the error of the job stage and sends the alert if the threshold is reached.
+// It is safe to call on nil Alerter.
+func (a *Alerter) Alert(job, stage string, err error) {
+	if a == nil || err == nil {
+		return
+	}
+
+	if !a.register(job+"."+stage, time.Now()) {
+		return
+	}
+
+	if _, e := a.sender.PublishTo(a.chatID, formatAlert(job, stage, err)); e != nil {
+		a.logger.Warn("[alert] Error sending admin alert", "job", job, "stage", stage, "error", e)
+	}
+}
+
+// register saves the error time and returns true if the alert should be sent.
+func (a *Alerter) register(key string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Keep only errors within the window
+	times := a.errors[key]
+	fresh := times[:0]
+	for _, t := range times {
+		if now.Sub(t) < a.window {
+			fresh = append(fresh, t)
+		}
+	}
+	fresh = append(fresh, now)
+	a.errors[key] = fresh
+
+	if len(fresh) < a.threshold {
+		return false
+	}
+	if last, ok := a.alertedAt[key]; ok && now.Sub(last) < a.cooldown {
+		return false
+	}
+
+	a.alertedAt[key] = now
+	a.errors[key] = nil
+	return true
+}
+
+// formatAlert formats the alert message in Telegram Markdown.
+func formatAlert(job, stage string, err error) string {
+	summary := err.Error()
+	if len(summary) > 500 {
+		summary = summary[:500] + "..."
+	}
+	// Backticks will break the code block
+	summary = strings.ReplaceAll(summary, "`", "'")
+
+	return fmt.Sprintf("🚨 *Job failed*\nJob: %s\nStage: %s\n```\n%s\n```", job, stage, summary)
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeAlertSender struct {
+	messages []string
+}
+
+func (f *fakeAlertSender) PublishTo(_, msg string) (string, error) {
+	f.messages = append(f.messages, msg)
+	return "", nil
+}
+
+func TestAlerter_register(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		threshold int
+		errors    []time.Duration // offsets of the errors from now
+		want      []bool
+	}{
+		{
+			name:      "alert on every error with cooldown",
+			threshold: 1,
+			errors:    []time.Duration{0, time.Minute, 20 * time.Minute},
+			want:      []bool{true, false, true},
+		},
+		{
+			name:      "alert when threshold is reached",
+			threshold: 3,
+			errors:    []time.Duration{0, time.Minute, 2 * time.Minute},
+			want:      []bool{false, false, true},
+		},
+		{
+			name:      "old errors are not counted",
+			threshold: 2,
+			errors:    []time.Duration{0, 20 * time.Minute, 21 * time.Minute},
+			want:      []bool{false, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAlerter(&fakeAlertSender{}, "admin", tt.threshold)
+			for i, offset := range tt.errors {
+				if got := a.register("job.stage", now.Add(offset)); got != tt.want[i] {
+					t.Errorf("register() error #%d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAlerter_Alert(t *testing.T) {
+	sender := &fakeAlertSender{}
+	a := NewAlerter(sender, "admin", 1)
+
+	a.Alert("Run.MarketNews", "compose", errors.New("openai: `timeout`"))
+	a.Alert("Run.MarketNews", "compose", nil)
+
+	var nilAlerter *Alerter
+	nilAlerter.Alert("Run.MarketNews", "compose", errors.New("ignored"))
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("Alert() sent %d messages, want 1", len(sender.messages))
+	}
+	msg := sender.messages[0]
+	if !strings.Contains(msg, "Run.MarketNews") || !strings.Contains(msg, "compose") || !strings.Contains(msg, "openai: 'timeout'") {
+		t.Errorf("Alert() message = %q", msg)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
 	"github.com/samgozman/fin-thread/publisher"
 	"github.com/samgozman/fin-thread/scavenger/ecal"
 	"log/slog"
@@ -22,6 +23,7 @@ type CalendarJob struct {
 	archivist         *archivist.Archivist         // archivist that will save news to the database
 	logger            *slog.Logger                 // special logger for the job
 	providerName      string                       // name of the job provider
+	alerter           *Alerter                     // sends alerts to the admin chat on failures (optional)
 }
 
 func NewCalendarJob(
@@ -34,19 +36,25 @@ func NewCalendarJob(
 		calendarScavenger: calendarScavenger,
 		publisher:         publisher,
 		archivist:         archivist,
-		logger:            slog.Default(),
+		logger:            logging.For(logging.ModuleJobs),
 		providerName:      providerName,
 	}
 }
 
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *CalendarJob) WithAlerter(a *Alerter) *CalendarJob {
+	j.alerter = a
+	return j
+}
+
 // RunDailyCalendarJob creates events plan for the upcoming day and publishes them to the channel.
 // It should be run every business day.
 func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
 	return func() {
-		_ = retry.Do(func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+		err := retry.Do(func() error {
+			ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 25*time.Second)
 			defer cancel()
-			j.logger.Info("[calendar] Running daily plan")
+			j.logger.InfoContext(ctx, "[calendar] Running daily plan")
 
 			tx := sentry.StartTransaction(ctx, "RunDailyCalendarJob")
 			tx.Op = "job-calendar"
@@ -70,7 +78,7 @@ func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("[job-calendar] Error fetching events: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				utils.CaptureSentryException("calendarJobFetchError", hub, e)
 				return e
 			}
@@ -92,7 +100,7 @@ func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("[job-calendar] Error publishing events: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				utils.CaptureSentryException("calendarJobPublishError", hub, e)
 				// Note: Unrecoverable error, because Telegram API often hangs up, but somehow publishes the message
 				return retry.Unrecoverable(e) //nolint:wrapcheck
@@ -114,7 +122,7 @@ func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("[job-calendar] Error saving events: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				utils.CaptureSentryException("calendarJobSaveError", hub, e)
 				return retry.Unrecoverable(e) //nolint:wrapcheck
 			}
@@ -130,13 +138,14 @@ func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
 			retry.Attempts(5),
 			retry.Delay(10*time.Minute),
 		)
+		j.alerter.Alert("DailyCalendar", "run", err)
 	}
 }
 
 // RunCalendarUpdatesJob fetches "Actual" values for today's events and publishes updates to the channel.
 func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 	return func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 25*time.Second)
 		defer cancel()
 
 		tx := sentry.StartTransaction(ctx, "RunCalendarUpdatesJob")
@@ -159,8 +168,9 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 		span.Finish()
 		if err != nil {
 			e := fmt.Errorf("[job-calendar-updates] Error fetching eventsDB: %w", err)
-			j.logger.Error(e.Error())
+			j.logger.ErrorContext(ctx, e.Error())
 			utils.CaptureSentryException("calendarUpdatesJobFindRecentError", hub, e)
+			j.alerter.Alert("CalendarUpdates", "findRecent", e)
 			return
 		}
 		hub.AddBreadcrumb(&sentry.Breadcrumb{
@@ -180,8 +190,9 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 		span.Finish()
 		if err != nil {
 			e := fmt.Errorf("[job-calendar-updates] Error fetching events from provider: %w", err)
-			j.logger.Error(e.Error())
+			j.logger.ErrorContext(ctx, e.Error())
 			utils.CaptureSentryException("calendarUpdatesJobFetchError", hub, e)
+			j.alerter.Alert("CalendarUpdates", "fetch", e)
 			return
 		}
 		hub.AddBreadcrumb(&sentry.Breadcrumb{
@@ -234,8 +245,9 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("[job-calendar-updates] Error updating event: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				utils.CaptureSentryException("calendarUpdatesJobUpdateEventError", hub, e)
+				j.alerter.Alert("CalendarUpdates", "updateEvent", e)
 				return
 			}
 		}
@@ -264,8 +276,9 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("[job-calendar-updates] Error publishing event: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				utils.CaptureSentryException("calendarUpdatesJobPublishError", hub, e)
+				j.alerter.Alert("CalendarUpdates", "publish", e)
 				return
 			}
 		}
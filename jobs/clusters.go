@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ClusterMethod is the way the related news of the run are grouped into one story (see Job.ClusterStories).
+type ClusterMethod string
+
+const (
+	ClusterByTitle      ClusterMethod = "title"      // share of the common words of the titles
+	ClusterByEmbeddings ClusterMethod = "embeddings" // cosine similarity of the news embeddings
+)
+
+// defaultClusterSimilarity is the min similarity of the news of one story by the cluster method.
+var defaultClusterSimilarity = map[ClusterMethod]float64{
+	ClusterByTitle:      0.5,
+	ClusterByEmbeddings: 0.85,
+}
+
+// titleStopWords are the words that don't tell the stories apart.
+var titleStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true, "in": true, "on": true,
+	"for": true, "with": true, "as": true, "at": true, "by": true, "from": true, "is": true, "are": true,
+	"was": true, "be": true, "its": true, "after": true, "over": true, "amid": true, "says": true, "said": true,
+}
+
+// ClusterStories sets the options that will group the related news of the run (e.g. the same event reported
+// by several providers) and publish each group as one story composed from all its news instead of the separate posts.
+// minSimilarity is the min similarity (0..1) of the news of one story, the default of the method if 0.
+// Note: requires shouldComposeText to be true, ClusterByEmbeddings also requires the embeddings client of the Composer.
+func (job *Job) ClusterStories(method ClusterMethod, minSimilarity float64) *Job {
+	if minSimilarity <= 0 {
+		minSimilarity = defaultClusterSimilarity[method]
+	}
+	job.options.clusterMethod = method
+	job.options.clusterSimilarity = minSimilarity
+	return job
+}
+
+// clusterStories groups the related news of the run. Returns the news not related to any other news and
+// the clusters of the rest, the earliest news of the cluster first. Flagged news are never clustered.
+// Errors of the embeddings are reported, but don't stop the job: the news are composed one by one then.
+func (job *Job) clusterStories(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	news journalist.NewsList,
+) (journalist.NewsList, []journalist.NewsList) {
+	if job.options.clusterMethod == "" || !job.options.shouldComposeText {
+		return news, nil
+	}
+
+	var singles, candidates journalist.NewsList
+	for _, n := range news {
+		if n.IsFiltered || n.IsSuspicious {
+			singles = append(singles, n)
+		} else {
+			candidates = append(candidates, n)
+		}
+	}
+	if len(candidates) < 2 {
+		return news, nil
+	}
+
+	similar, err := job.newsSimilarity(ctx, tx, candidates)
+	if err != nil {
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "cluster"))
+		e := fmt.Errorf("[%s][clusterStories.newsSimilarity]: %w", job.name, err)
+		job.logger.WarnContext(ctx, e.Error())
+		utils.CaptureSentryException("jobClusterStoriesError", hub, e)
+		return news, nil
+	}
+
+	var clusters []journalist.NewsList
+	clustered := 0
+	for _, group := range clusterNews(len(candidates), similar) {
+		if len(group) == 1 {
+			singles = append(singles, candidates[group[0]])
+			continue
+		}
+
+		cluster := make(journalist.NewsList, len(group))
+		for i, idx := range group {
+			cluster[i] = candidates[idx]
+		}
+		slices.SortStableFunc(cluster, func(a, b *journalist.News) int {
+			return a.Date.Compare(b.Date)
+		})
+		clusters = append(clusters, cluster)
+		clustered += len(cluster)
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("clusterStories returned %d stories of %d news", len(clusters), clustered),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return singles, clusters
+}
+
+// newsSimilarity returns the function checking if the news are the same story by the cluster method.
+func (job *Job) newsSimilarity(ctx context.Context, tx *sentry.Span, news journalist.NewsList) (func(i, j int) bool, error) {
+	minSimilarity := job.options.clusterSimilarity
+
+	if job.options.clusterMethod == ClusterByEmbeddings {
+		texts := make([]string, len(news))
+		for i, n := range news {
+			texts[i] = n.Title + "\n" + n.Description
+		}
+
+		span := tx.StartChild("clusterStories.Embed")
+		start := time.Now()
+		embeddings, err := job.composer.Embed(ctx, texts)
+		job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", "embed"))
+		span.Finish()
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		return func(i, j int) bool {
+			return cosineDistance(archivist.Vector(embeddings[i]), archivist.Vector(embeddings[j])) <= 1-minSimilarity
+		}, nil
+	}
+
+	words := make([]map[string]bool, len(news))
+	for i, n := range news {
+		words[i] = titleWords(n.Title)
+	}
+	return func(i, j int) bool {
+		return titleSimilarity(words[i], words[j]) >= minSimilarity
+	}, nil
+}
+
+// composeClusters composes one story of each cluster and marks the rest of its news as filtered, so only
+// the story is published. Returns the composed stories and the news of the clusters failed to be composed,
+// they are composed one by one. Errors are reported, but don't stop the job.
+func (job *Job) composeClusters(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	clusters []journalist.NewsList,
+) ([]*composer.ComposedNews, journalist.NewsList) {
+	var composed []*composer.ComposedNews
+	var unmerged journalist.NewsList
+
+	for _, cluster := range clusters {
+		// Composed by the original titles if the budget is exceeded
+		if job.budget.BudgetExceeded() {
+			unmerged = append(unmerged, cluster...)
+			continue
+		}
+
+		span := tx.StartChild("composeClusters.ComposeMerged")
+		start := time.Now()
+		story, err := job.composer.ComposeMerged(ctx, cluster, job.composeOptions())
+		job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", "compose_merged"))
+		span.Finish()
+		if err != nil {
+			job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "compose_merged"))
+			e := fmt.Errorf("[%s][composeClusters.ComposeMerged]: %w", job.name, err)
+			job.logger.WarnContext(ctx, e.Error())
+			utils.CaptureSentryException("jobComposeMergedError", hub, e)
+			unmerged = append(unmerged, cluster...)
+			continue
+		}
+		if story == nil {
+			unmerged = append(unmerged, cluster...)
+			continue
+		}
+
+		for _, n := range cluster {
+			if n.ID != story.ID {
+				n.IsFiltered = true
+			}
+		}
+		composed = append(composed, story)
+	}
+
+	job.metrics.Count(metrics.NewsComposed, int64(len(composed)), job.metricsTag())
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("composeClusters returned %d stories", len(composed)),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return composed, unmerged
+}
+
+// clusterNews groups the items by the similarity: the item joins the first group with a similar item
+// or starts the new one. Groups are returned in the order of their first items.
+func clusterNews(n int, similar func(i, j int) bool) [][]int {
+	var groups [][]int
+	for i := 0; i < n; i++ {
+		joined := false
+		for g, group := range groups {
+			if slices.ContainsFunc(group, func(j int) bool { return similar(i, j) }) {
+				groups[g] = append(group, i)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, []int{i})
+		}
+	}
+	return groups
+}
+
+// titleWords returns the lowercase words of the title without the stop words.
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !titleStopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// titleSimilarity returns the share of the common words of the titles (Jaccard index), 0 for the empty titles.
+func titleSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+	for w := range a {
+		if b[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
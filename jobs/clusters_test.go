@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_titleSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "same words", a: "Fed holds rates steady", b: "Fed Holds Rates Steady!", want: 1},
+		{name: "stop words are ignored", a: "The Fed holds rates", b: "Fed holds the rates", want: 1},
+		{name: "some common words", a: "Fed cuts rates", b: "Fed cuts rates, stocks rally", want: 0.6},
+		{name: "different stories", a: "Apple beats estimates", b: "Oil falls", want: 0},
+		{name: "empty title", a: "", b: "Oil falls", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := titleSimilarity(titleWords(tt.a), titleWords(tt.b)); got != tt.want {
+				t.Errorf("titleSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_clusterNews(t *testing.T) {
+	// 0, 2 and 3 are the same story, 2 is similar to 3 only
+	pairs := map[[2]int]bool{{0, 2}: true, {2, 3}: true}
+	similar := func(i, j int) bool {
+		return pairs[[2]int{i, j}] || pairs[[2]int{j, i}]
+	}
+
+	got := clusterNews(5, similar)
+	want := [][]int{{0, 2, 3}, {1}, {4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clusterNews() = %v, want %v", got, want)
+	}
+}
+
+func TestJob_clusterStories(t *testing.T) {
+	now := time.Now()
+	news := journalist.NewsList{
+		{ID: "reuters", Title: "Fed holds interest rates steady", Date: now},
+		{ID: "oil", Title: "Oil falls on demand worries", Date: now},
+		{ID: "cnbc", Title: "Fed holds rates steady, signals cuts", Date: now.Add(-time.Minute)},
+		{ID: "flagged", Title: "Fed holds interest rates steady", Date: now, IsSuspicious: true},
+	}
+
+	job := (&Job{
+		journalist: journalist.NewJournalist("test", nil),
+		logger:     slog.Default(),
+		metrics:    metrics.Noop{},
+		options:    &jobOptions{shouldComposeText: true},
+	}).ClusterStories(ClusterByTitle, 0)
+	tx := sentry.StartTransaction(context.Background(), "test")
+	hub := sentry.CurrentHub().Clone()
+
+	singles, clusters := job.clusterStories(context.Background(), tx, hub, news)
+
+	var singleIDs []string
+	for _, n := range singles {
+		singleIDs = append(singleIDs, n.ID)
+	}
+	if want := []string{"flagged", "oil"}; !reflect.DeepEqual(singleIDs, want) {
+		t.Errorf("clusterStories() singles = %v, want %v", singleIDs, want)
+	}
+	if len(clusters) != 1 || len(clusters[0]) != 2 || clusters[0][0].ID != "cnbc" || clusters[0][1].ID != "reuters" {
+		t.Errorf("clusterStories() clusters = %v, want the earliest cnbc news first and reuters", clusters)
+	}
+}
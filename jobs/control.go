@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RunInfo holds the result of the last news job run.
+type RunInfo struct {
+	StartedAt time.Time     // when the run started
+	Duration  time.Duration // how long the run took
+	Fetched   int           // number of fetched news
+	Deduped   int           // number of news left after removing duplicates
+	Composed  int           // number of news composed by the LLM
+	Published int           // number of published news
+	Paused    bool          // if true, the run was skipped because the jobs are paused
+	Failed    bool          // if true, the run stopped on the error at some stage
+}
+
+// Control is the runtime control of the news jobs: pause/resume and the last runs. It is shared by the jobs
+// and the admin commands. Nil Control is never paused and doesn't record the runs.
+type Control struct {
+	paused atomic.Bool
+
+	mu        sync.Mutex
+	runs      map[string]RunInfo   // last run by job name
+	succeeded map[string]time.Time // start of the last successful run by job name
+
+	afterSuccess func(job string, info RunInfo) // called after every successful run, see AfterSuccessfulRuns
+}
+
+// NewControl creates a new Control.
+func NewControl() *Control {
+	return &Control{runs: make(map[string]RunInfo), succeeded: make(map[string]time.Time)}
+}
+
+// Pause pauses all the jobs using the Control. The currently running jobs are not interrupted.
+func (c *Control) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume resumes the paused jobs.
+func (c *Control) Resume() {
+	c.paused.Store(false)
+}
+
+// Paused returns true if the jobs are paused.
+func (c *Control) Paused() bool {
+	if c == nil {
+		return false
+	}
+	return c.paused.Load()
+}
+
+// LastRun returns the last run of the job by its name (e.g. "MarketNews").
+func (c *Control) LastRun(job string) (RunInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, ok := c.runs[job]
+	return info, ok
+}
+
+// LastRuns returns the last runs of all the jobs by their names.
+func (c *Control) LastRuns() map[string]RunInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	runs := make(map[string]RunInfo, len(c.runs))
+	for name, info := range c.runs {
+		runs[name] = info
+	}
+	return runs
+}
+
+// LastSuccesses returns the start time of the last successful (not paused or failed) run of the jobs by their names.
+func (c *Control) LastSuccesses() map[string]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	succeeded := make(map[string]time.Time, len(c.succeeded))
+	for name, t := range c.succeeded {
+		succeeded[name] = t
+	}
+	return succeeded
+}
+
+// AfterSuccessfulRuns sets the function called after every successful (not paused or failed) run of the jobs,
+// e.g. to ping the uptime monitor. Runs skipped by the scheduler because the previous run is still running
+// are not reported. The function is called by the job goroutine, so the slow ones should not block it.
+// Must be set before the jobs are started.
+func (c *Control) AfterSuccessfulRuns(fn func(job string, info RunInfo)) {
+	c.afterSuccess = fn
+}
+
+// record saves the run of the job.
+func (c *Control) record(job string, info RunInfo) {
+	if c == nil {
+		return
+	}
+
+	succeeded := !info.Paused && !info.Failed
+	c.mu.Lock()
+	c.runs[job] = info
+	if succeeded {
+		c.succeeded[job] = info.StartedAt
+	}
+	c.mu.Unlock()
+
+	if succeeded && c.afterSuccess != nil {
+		c.afterSuccess(job, info)
+	}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestControl(t *testing.T) {
+	c := NewControl()
+	if c.Paused() {
+		t.Error("Paused() = true for the new Control")
+	}
+
+	c.Pause()
+	if !c.Paused() {
+		t.Error("Paused() = false after Pause()")
+	}
+	c.Resume()
+	if c.Paused() {
+		t.Error("Paused() = true after Resume()")
+	}
+
+	run := RunInfo{StartedAt: time.Now(), Fetched: 3, Published: 1}
+	c.record("MarketNews", run)
+	if got, ok := c.LastRun("MarketNews"); !ok || got != run {
+		t.Errorf("LastRun() = %v, %v, want %v", got, ok, run)
+	}
+	if _, ok := c.LastRun("BroadNews"); ok {
+		t.Error("LastRun() found the run of unknown job")
+	}
+	if runs := c.LastRuns(); len(runs) != 1 || runs["MarketNews"] != run {
+		t.Errorf("LastRuns() = %v", runs)
+	}
+
+	c.record("MarketNews", RunInfo{StartedAt: run.StartedAt.Add(time.Minute), Failed: true})
+	c.record("BroadNews", RunInfo{StartedAt: run.StartedAt, Paused: true})
+	if successes := c.LastSuccesses(); len(successes) != 1 || !successes["MarketNews"].Equal(run.StartedAt) {
+		t.Errorf("LastSuccesses() = %v, want only the first MarketNews run", successes)
+	}
+
+	var reported []RunInfo
+	c.AfterSuccessfulRuns(func(_ string, info RunInfo) {
+		reported = append(reported, info)
+	})
+	c.record("MarketNews", RunInfo{StartedAt: run.StartedAt, Paused: true})
+	c.record("MarketNews", RunInfo{StartedAt: run.StartedAt, Failed: true})
+	c.record("MarketNews", run)
+	if len(reported) != 1 || reported[0] != run {
+		t.Errorf("AfterSuccessfulRuns() reported %v, want only the successful run %v", reported, run)
+	}
+}
+
+func TestControl_nil(t *testing.T) {
+	var c *Control
+	if c.Paused() {
+		t.Error("Paused() = true for nil Control")
+	}
+	c.record("MarketNews", RunInfo{}) // should not panic
+}
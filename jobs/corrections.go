@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"time"
+)
+
+// Correct replaces the text of the published news and updates its messages in the channel, the mirrors
+// the translation (translated again) and ticker channels and the unsent email digest, e.g. if the source corrected the story.
+// The text replaces the composed text of the news (or the original description if the job doesn't compose the text).
+// Note: requires SaveToDB to be set.
+func (job *Job) Correct(ctx context.Context, hash, text string) error {
+	tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.Correct", job.name))
+	tx.Op = "job"
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+
+	n, err := job.findPublished(ctx, tx, hub, "Correct", hash)
+	if err != nil {
+		return err
+	}
+
+	if job.options.shouldComposeText {
+		n.ComposedText = text
+	} else {
+		n.OriginalDesc = text
+	}
+	formattedText, changes := job.formatNews(ctx, n)
+
+	span := tx.StartChild("Correct.UpdatePublication")
+	err = job.update(ctx, newsChannel(n), n.PublicationID, *n, formattedText, changes)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][Correct.UpdatePublication]: %w", job.name, err)
+		utils.CaptureSentryException("jobCorrectError", hub, e)
+		return e
+	}
+
+	if job.mirrors.Len() > 0 {
+		span = tx.StartChild("Correct.UpdateMirrors")
+		err = job.mirrors.UpdateAll(publicationIDs(n), formattedText)
+		span.Finish()
+		if err != nil {
+			job.reportMirrorError(hub, "Correct.UpdateAll", err)
+		}
+	}
+
+	job.updateTranslations(ctx, tx, hub, n)
+	job.updateSubscriptions(ctx, tx, hub, n)
+	if job.email != nil {
+		job.email.Update(n.Hash, newsText(*n))
+	}
+
+	return job.persistNews(ctx, hub, n)
+}
+
+// Retract deletes the messages of the published news from the channel, the mirrors, the translation and ticker channels
+// and the unsent email digest and marks the news as retracted, e.g. if the source retracted the story. Note: requires SaveToDB to be set.
+func (job *Job) Retract(ctx context.Context, hash string) error {
+	tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.Retract", job.name))
+	tx.Op = "job"
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+
+	n, err := job.findPublished(ctx, tx, hub, "Retract", hash)
+	if err != nil {
+		return err
+	}
+
+	span := tx.StartChild("Retract.DeletePublication")
+	err = job.publisher.DeletePublicationIn(n.ChannelID, n.PublicationID)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][Retract.DeletePublication]: %w", job.name, err)
+		utils.CaptureSentryException("jobRetractError", hub, e)
+		return e
+	}
+
+	if job.mirrors.Len() > 0 {
+		span = tx.StartChild("Retract.DeleteMirrors")
+		err = job.mirrors.DeleteAll(publicationIDs(n))
+		span.Finish()
+		if err != nil {
+			job.reportMirrorError(hub, "Retract.DeleteAll", err)
+		}
+	}
+
+	job.deleteTranslations(tx, hub, n)
+	job.deleteSubscriptions(tx, hub, n)
+	if job.email != nil {
+		job.email.Remove(n.Hash)
+	}
+
+	n.State = archivist.NewsStateRetracted
+	return job.persistNews(ctx, hub, n)
+}
+
+// update replaces the published news in the channel with the same kind of message it was published with.
+// Retries of the failed requests stop when the context is done.
+func (job *Job) update(ctx context.Context, channel, pubID string, n archivist.News, text string, changes map[string]float64) error {
+	p := job.publisher.WithContext(ctx)
+	if job.options.shouldComposeText && p.Formatter != nil {
+		return p.UpdateMessage(channel, pubID, job.message(n, changes))
+	}
+	if maxLen := job.options.threadMaxLength; maxLen > 0 && len(text) > maxLen {
+		// Only the first message of the thread can be updated
+		return p.UpdatePublicationIn(channel, pubID, formatThread(text, maxLen)[0])
+	}
+	return p.UpdatePublicationIn(channel, pubID, text)
+}
+
+// findPublished returns the published news by its hash.
+func (job *Job) findPublished(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, op, hash string) (*archivist.News, error) {
+	span := tx.StartChild(op + ".FindAllByHashes")
+	news, err := job.archivist.Entities.News.FindAllByHashes(ctx, []string{hash})
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][%s.FindAllByHashes]: %w", job.name, op, err)
+		utils.CaptureSentryException("job"+op+"Error", hub, e)
+		return nil, e
+	}
+	if len(news) == 0 {
+		return nil, fmt.Errorf("[%s][%s]: news %s not found", job.name, op, hash)
+	}
+	if !isPublished(news[0]) {
+		return nil, fmt.Errorf("[%s][%s]: news %s is not published", job.name, op, hash)
+	}
+
+	return news[0], nil
+}
+
+// reportMirrorError reports the failed publication or update of the mirrors. It doesn't stop the job,
+// because the news is already published or updated in the main channel.
+func (job *Job) reportMirrorError(hub *sentry.Hub, op string, err error) {
+	job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "mirror"))
+	e := fmt.Errorf("[%s][%s]: %w", job.name, op, err)
+	job.logger.Warn(e.Error())
+	utils.CaptureSentryException("jobMirrorError", hub, e)
+	job.alerter.Alert(job.name, "mirror", e)
+}
+
+// isPublished returns true if the news is published and not retracted.
+// News saved before the states were added have no state, but have the publication ID.
+func isPublished(n *archivist.News) bool {
+	return n.PublicationID != "" && (n.State == archivist.NewsStatePublished || n.State == "")
+}
+
+// publicationIDs returns the publication IDs of the news by the target name (see Job.mirror).
+func publicationIDs(n *archivist.News) map[string]string {
+	ids := map[string]string{}
+	if len(n.Publications) > 0 {
+		_ = json.Unmarshal(n.Publications, &ids)
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"gorm.io/datatypes"
+	"reflect"
+	"testing"
+)
+
+func Test_isPublished(t *testing.T) {
+	tests := []struct {
+		name string
+		news archivist.News
+		want bool
+	}{
+		{
+			name: "published",
+			news: archivist.News{PublicationID: "1", State: archivist.NewsStatePublished},
+			want: true,
+		},
+		{
+			name: "published before the states were added",
+			news: archivist.News{PublicationID: "1"},
+			want: true,
+		},
+		{
+			name: "retracted",
+			news: archivist.News{PublicationID: "1", State: archivist.NewsStateRetracted},
+			want: false,
+		},
+		{
+			name: "interrupted",
+			news: archivist.News{State: archivist.NewsStateInterrupted},
+			want: false,
+		},
+		{
+			name: "queued",
+			news: archivist.News{State: archivist.NewsStateQueued},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPublished(&tt.news); got != tt.want {
+				t.Errorf("isPublished() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_publicationIDs(t *testing.T) {
+	tests := []struct {
+		name         string
+		publications datatypes.JSON
+		want         map[string]string
+	}{
+		{
+			name:         "all targets",
+			publications: datatypes.JSON(`{"telegram":"1","discord":"d1"}`),
+			want:         map[string]string{"telegram": "1", "discord": "d1"},
+		},
+		{
+			name: "no publications",
+			want: map[string]string{},
+		},
+		{
+			name:         "invalid json",
+			publications: datatypes.JSON(`{`),
+			want:         map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publicationIDs(&archivist.News{Publications: tt.publications}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("publicationIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"time"
+)
+
+// deadLettersBatch is the max number of the dead letters retried by one run.
+const deadLettersBatch = 20
+
+// deadLetter saves the news failed to be published to the chat to the dead letters and marks it as failed,
+// so it is retried by Job.RetryDeadLetters instead of the recovery. Returns false if the dead letter is not saved:
+// the news are not saved to the DB or the publication is interrupted by the deadline, not failed.
+func (job *Job) deadLetter(ctx context.Context, hub *sentry.Hub, n *archivist.News, chatID string, err error) bool {
+	if !job.options.shouldSaveToDB || ctx.Err() != nil {
+		return false
+	}
+
+	letter := &archivist.DeadLetter{Hash: n.Hash, JobName: job.journalist.Name, ChatID: chatID, Error: err.Error()}
+	if err := job.archivist.Entities.DeadLetters.Record(ctx, letter); err != nil {
+		e := fmt.Errorf("[%s][deadLetter.DeadLetters.Record]: %w", job.name, err)
+		utils.CaptureSentryException("jobDeadLetterError", hub, e)
+		return false
+	}
+
+	n.State = archivist.NewsStateFailed
+	_ = job.persistNews(ctx, hub, n) // error is reported, the news is in the dead letters anyway
+	return true
+}
+
+// RetryDeadLetters returns the job function that publishes the news from the dead letters with less than
+// maxAttempts failed attempts. Published news are deleted from the dead letters, the failed ones get
+// the attempts counter incremented. Note: requires SaveToDB to be set.
+func (job *Job) RetryDeadLetters(maxAttempts int) JobFunc {
+	return func() {
+		if !job.options.shouldSaveToDB || job.control.Paused() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), job.options.timeout)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.RetryDeadLetters", job.name))
+		tx.Op = "job"
+
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		span := tx.StartChild("RetryDeadLetters.FindRetryable")
+		letters, err := job.archivist.Entities.DeadLetters.FindRetryable(ctx, maxAttempts, deadLettersBatch)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[%s][RetryDeadLetters.FindRetryable]: %w", job.name, err)
+			utils.CaptureSentryException("jobRetryDeadLettersError", hub, e)
+			job.alerter.Alert(job.name, "dead letters", e)
+			return
+		}
+
+		published := 0
+		for _, letter := range letters {
+			if _, err := job.redrive(ctx, tx, hub, letter.Hash); err == nil {
+				published++
+			}
+		}
+
+		if len(letters) > 0 {
+			job.logger.InfoContext(ctx, fmt.Sprintf("[%s][RetryDeadLetters]: published %d of %d dead letters", job.name, published, len(letters)))
+		}
+	}
+}
+
+// DeadLetters returns the latest dead letters, the last failures first.
+func (job *Job) DeadLetters(ctx context.Context, limit int) ([]*archivist.DeadLetter, error) {
+	letters, err := job.archivist.Entities.DeadLetters.FindLatest(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[%s][DeadLetters.FindLatest]: %w", job.name, err)
+	}
+	return letters, nil
+}
+
+// Redrive publishes the news from the dead letters by its hash regardless of its failed attempts,
+// e.g. after the channel permissions are fixed. Returns the publication ID. Note: requires SaveToDB to be set.
+func (job *Job) Redrive(ctx context.Context, hash string) (string, error) {
+	tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.Redrive", job.name))
+	tx.Op = "job"
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+
+	return job.redrive(ctx, tx, hub, hash)
+}
+
+// redrive publishes the failed news again and deletes its dead letter if it is published (or already was).
+func (job *Job) redrive(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, hash string) (string, error) {
+	span := tx.StartChild("redrive.FindAllByHashes")
+	news, err := job.archivist.Entities.News.FindAllByHashes(ctx, []string{hash})
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][redrive.FindAllByHashes]: %w", job.name, err)
+		utils.CaptureSentryException("jobRedriveError", hub, e)
+		return "", e
+	}
+
+	var pubID string
+	switch {
+	case len(news) == 0:
+		// The news is pruned by the retention, nothing to publish
+		err = fmt.Errorf("[%s][redrive]: news %s not found", job.name, hash)
+	case news[0].State == archivist.NewsStatePublished:
+		pubID = news[0].PublicationID
+	default:
+		_, err = job.publish(ctx, tx, hub, news[:1])
+		if err != nil {
+			return "", err
+		}
+		// The news published by another run has its publication ID as well, see Job.claimPublication
+		if pubID = news[0].PublicationID; pubID == "" {
+			return "", fmt.Errorf("[%s][redrive]: news %s is being published by another run", job.name, hash)
+		}
+	}
+
+	if delErr := job.archivist.Entities.DeadLetters.Delete(ctx, hash); delErr != nil {
+		e := fmt.Errorf("[%s][redrive.DeadLetters.Delete]: %w", job.name, delErr)
+		utils.CaptureSentryException("jobRedriveError", hub, e)
+		return pubID, errors.Join(err, e)
+	}
+
+	return pubID, err
+}
@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	minDigestNews = 5 // minimum number of the published news to make the digest
+	digestTickers = 5 // number of the most mentioned tickers in the digest
+)
+
+// DigestJob publishes the themed digest of the news published in the last period: the top stories
+// per market composed by the LLM and the most mentioned tickers. The digest message is pinned in the channel.
+type DigestJob struct {
+	composer  *composer.Composer           // composer that will group the news into the top stories
+	publisher *publisher.TelegramPublisher // publisher that will publish and pin the digest
+	archivist *archivist.Archivist         // archivist that will read the published news from the database
+	period    time.Duration                // digest covers the news published in the last period
+	logger    *slog.Logger                 // special logger for the job
+	metrics   metrics.Emitter              // metrics emitter for job counters and latencies
+	alerter   *Alerter                     // sends alerts to the admin chat on failures (optional)
+}
+
+func NewDigestJob(
+	composer *composer.Composer,
+	publisher *publisher.TelegramPublisher,
+	archivist *archivist.Archivist,
+	period time.Duration,
+) *DigestJob {
+	return &DigestJob{
+		composer:  composer,
+		publisher: publisher,
+		archivist: archivist,
+		period:    period,
+		logger:    logging.For(logging.ModuleJobs),
+		metrics:   metrics.Noop{},
+	}
+}
+
+// WithMetrics sets the metrics emitter for the job counters and latencies.
+func (j *DigestJob) WithMetrics(m metrics.Emitter) *DigestJob {
+	j.metrics = m
+	return j
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *DigestJob) WithAlerter(a *Alerter) *DigestJob {
+	j.alerter = a
+	return j
+}
+
+// Run creates the digest of the news published in the last period.
+func (j *DigestJob) Run() JobFunc {
+	return func() {
+		err := j.run()
+		j.alerter.Alert("DigestJob", "run", err)
+	}
+}
+
+func (j *DigestJob) run() error {
+	ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 2*time.Minute)
+	defer cancel()
+
+	tx := sentry.StartTransaction(ctx, "RunDigestJob")
+	tx.Op = "job-digest"
+
+	// Sentry performance monitoring
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+	defer hub.Recover(nil)
+
+	span := tx.StartChild("News.FindAllUntilDate")
+	news, err := j.archivist.Entities.News.FindAllUntilDate(ctx, time.Now().Add(-j.period))
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-digest] Error fetching news from the database: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobDigestNewsFindAllError", hub, e)
+		return e
+	}
+
+	news = publishedNews(news)
+	if len(news) < minDigestNews {
+		j.logger.InfoContext(ctx, "[job-digest] Not enough published news for the digest", "total", len(news))
+		return nil
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("Found %d published news for the digest", len(news)),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	span = tx.StartChild("ComposeDigest")
+	start := time.Now()
+	sections, err := j.composer.ComposeDigest(ctx, digestNews(news))
+	j.metrics.Timing(metrics.LLMLatency, time.Since(start), metrics.T("job", "Digest"), metrics.T("stage", "digest"))
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-digest] Error composing digest: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobDigestComposeError", hub, e)
+		return e
+	}
+	if len(sections) == 0 {
+		j.logger.InfoContext(ctx, "[job-digest] Empty digest")
+		return nil
+	}
+
+	links := make(map[string]string, len(news))
+	for _, n := range news {
+		links[n.ID.String()] = n.ToHeadline().Link
+	}
+	// The digest is published without the tickers if they can't be counted
+	span = tx.StartChild("News.TopTickers")
+	counts, err := j.archivist.Entities.News.TopTickers(ctx, time.Now().Add(-j.period), digestTickers)
+	span.Finish()
+	if err != nil {
+		j.logger.WarnContext(ctx, "[job-digest] Error counting tickers in the database", "error", err)
+	}
+	message := formatDigest(sections, tickerReports(counts), links, j.period)
+
+	span = tx.StartChild("TelegramPublisher.Publish")
+	pubID, err := j.publisher.Publish(message)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-digest] Error publishing digest: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobDigestPublishError", hub, e)
+		return e
+	}
+
+	// Digest is published anyway, so the failed pin is not the job failure
+	span = tx.StartChild("TelegramPublisher.Pin")
+	err = j.publisher.Pin("", pubID)
+	span.Finish()
+	if err != nil {
+		j.logger.WarnContext(ctx, "[job-digest] Error pinning digest", "error", err)
+		utils.CaptureSentryException("jobDigestPinError", hub, err)
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "Digest published successfully",
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return nil
+}
+
+// publishedNews returns the composed and published news, the most recent first.
+func publishedNews(news []*archivist.News) []*archivist.News {
+	var published []*archivist.News
+	for _, n := range news {
+		if n.ComposedText != "" && n.PublicationID != "" {
+			published = append(published, n)
+		}
+	}
+	sort.SliceStable(published, func(i, k int) bool {
+		return published[i].PublishedAt.After(published[k].PublishedAt)
+	})
+
+	return published
+}
+
+// digestNews converts the published news to the composer's digest news with markets and tickers from the meta data.
+func digestNews(news []*archivist.News) []*composer.DigestNews {
+	result := make([]*composer.DigestNews, 0, len(news))
+	for _, n := range news {
+		d := &composer.DigestNews{ID: n.ID.String(), Text: n.ComposedText}
+		var meta composer.ComposedMeta
+		if n.MetaData != nil && json.Unmarshal(n.MetaData, &meta) == nil {
+			d.Markets, d.Tickers = meta.Markets, meta.Tickers
+		}
+		result = append(result, d)
+	}
+
+	return result
+}
+
+// formatDigest formats the digest: top stories per market linked to the published news and the most mentioned tickers.
+func formatDigest(sections []*composer.DigestSection, tickers []*tickerReport, links map[string]string, period time.Duration) string {
+	var m strings.Builder
+	if period >= 7*24*time.Hour {
+		m.WriteString("🗞 #digest\nTop stories of the week\n")
+	} else {
+		m.WriteString(fmt.Sprintf("🗞 #digest\nTop stories of the last %d hours\n", int(period.Hours())))
+	}
+
+	for _, s := range sections {
+		m.WriteString(fmt.Sprintf("\n*%s*\n", s.Market))
+		for _, story := range s.Stories {
+			if link, ok := links[story.ID]; ok {
+				m.WriteString(fmt.Sprintf("- %s [→](%s)\n", story.Summary, link))
+				continue
+			}
+			m.WriteString(fmt.Sprintf("- %s\n", story.Summary))
+		}
+	}
+
+	if len(tickers) > 0 {
+		mentions := make([]string, len(tickers))
+		for i, t := range tickers {
+			mentions[i] = fmt.Sprintf("$%s (%d)", t.Ticker, t.Mentions)
+		}
+		m.WriteString("\nMost mentioned: " + strings.Join(mentions, ", ") + "\n")
+	}
+
+	return strings.TrimSuffix(m.String(), "\n")
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"testing"
+	"time"
+)
+
+func Test_publishedNews(t *testing.T) {
+	now := time.Now()
+	older := &archivist.News{ID: uuid.New(), ComposedText: "Older", PublicationID: "1", PublishedAt: now.Add(-time.Hour)}
+	newer := &archivist.News{ID: uuid.New(), ComposedText: "Newer", PublicationID: "2", PublishedAt: now}
+	news := []*archivist.News{
+		older,
+		{ID: uuid.New(), ComposedText: "Not published", PublishedAt: now},
+		{ID: uuid.New(), PublicationID: "3", PublishedAt: now},
+		newer,
+	}
+
+	got := publishedNews(news)
+	if len(got) != 2 || got[0] != newer || got[1] != older {
+		t.Errorf("publishedNews() = %v, want the published news with the newest first", got)
+	}
+}
+
+func Test_formatDigest(t *testing.T) {
+	sections := []*composer.DigestSection{
+		{Market: "US stocks", Stories: []*composer.DigestStory{
+			{ID: "1", Summary: "Stocks rally after CPI"},
+			{ID: "unknown", Summary: "Oil falls"},
+		}},
+	}
+	links := map[string]string{"1": "https://t.me/channel/10"}
+
+	tests := []struct {
+		name    string
+		tickers []*tickerReport
+		period  time.Duration
+		want    string
+	}{
+		{
+			name:    "daily digest",
+			tickers: []*tickerReport{{Ticker: "AAPL", Mentions: 3}, {Ticker: "MSFT", Mentions: 2}},
+			period:  24 * time.Hour,
+			want: "🗞 #digest\nTop stories of the last 24 hours\n\n*US stocks*\n" +
+				"- Stocks rally after CPI [→](https://t.me/channel/10)\n- Oil falls\n\nMost mentioned: $AAPL (3), $MSFT (2)",
+		},
+		{
+			name:   "weekly digest without tickers",
+			period: 7 * 24 * time.Hour,
+			want: "🗞 #digest\nTop stories of the week\n\n*US stocks*\n" +
+				"- Stocks rally after CPI [→](https://t.me/channel/10)\n- Oil falls",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDigest(sections, tt.tickers, links, tt.period); got != tt.want {
+				t.Errorf("formatDigest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"time"
+)
+
+// EmailTo sets the EmailPublisher that collects the published news into the email digest.
+// The digest is sent by the EmailDigestJob.
+func (job *Job) EmailTo(e *publisher.EmailPublisher) *Job {
+	job.email = e
+	return job
+}
+
+// collectEmail adds the published news to the next email digest.
+func (job *Job) collectEmail(n archivist.News) {
+	if job.email == nil {
+		return
+	}
+	job.email.Add(newsEmailItem(n))
+}
+
+// newsEmailItem converts the published news to the item of the email digest, grouped by its markets.
+func newsEmailItem(n archivist.News) publisher.EmailItem {
+	item := publisher.EmailItem{
+		ID:        n.Hash,
+		Title:     n.OriginalTitle,
+		Text:      newsText(n),
+		Link:      n.URL,
+		Published: n.PublishedAt,
+	}
+
+	var meta composer.ComposedMeta
+	if n.MetaData != nil && json.Unmarshal(n.MetaData, &meta) == nil {
+		item.Markets = meta.Markets
+	}
+	return item
+}
+
+// newsText returns the composed text of the news or its original description if the text isn't composed.
+func newsText(n archivist.News) string {
+	if n.ComposedText != "" {
+		return n.ComposedText
+	}
+	return n.OriginalDesc
+}
+
+// EmailDigestJob sends the digest of the news collected by the EmailPublisher since the previous run.
+type EmailDigestJob struct {
+	email   *publisher.EmailPublisher
+	logger  *slog.Logger
+	alerter *Alerter // sends alerts to the admin chat on failures (optional)
+}
+
+func NewEmailDigestJob(email *publisher.EmailPublisher) *EmailDigestJob {
+	return &EmailDigestJob{
+		email:  email,
+		logger: logging.For(logging.ModuleJobs),
+	}
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *EmailDigestJob) WithAlerter(a *Alerter) *EmailDigestJob {
+	j.alerter = a
+	return j
+}
+
+// Run sends the email digest. Nothing is sent if no news were published since the previous run.
+func (j *EmailDigestJob) Run() JobFunc {
+	return func() {
+		err := j.run()
+		j.alerter.Alert("EmailDigestJob", "run", err)
+	}
+}
+
+func (j *EmailDigestJob) run() error {
+	ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), time.Minute)
+	defer cancel()
+
+	tx := sentry.StartTransaction(ctx, "RunEmailDigestJob")
+	tx.Op = "job-email-digest"
+
+	// Sentry performance monitoring
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+	defer hub.Recover(nil)
+
+	span := tx.StartChild("EmailPublisher.Flush")
+	sent, err := j.email.Flush(ctx)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-email-digest] Error sending email digest: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("emailDigestJobSendError", hub, e)
+		return e
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("Sent email digest with %d news", sent),
+		Level:    sentry.LevelInfo,
+	}, nil)
+	return nil
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"gorm.io/datatypes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_newsEmailItem(t *testing.T) {
+	published := time.Date(2024, 3, 8, 21, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		news archivist.News
+		want publisher.EmailItem
+	}{
+		{
+			name: "composed news",
+			news: archivist.News{
+				Hash:          "abc",
+				URL:           "https://example.com/fed",
+				OriginalTitle: "Fed holds rates",
+				OriginalDesc:  "The Fed kept rates unchanged.",
+				ComposedText:  "Fed held rates at 5.5%.",
+				MetaData:      datatypes.JSON(`{"markets": ["bonds", "US stocks"]}`),
+				PublishedAt:   published,
+			},
+			want: publisher.EmailItem{
+				ID:        "abc",
+				Title:     "Fed holds rates",
+				Text:      "Fed held rates at 5.5%.",
+				Link:      "https://example.com/fed",
+				Markets:   []string{"bonds", "US stocks"},
+				Published: published,
+			},
+		},
+		{
+			name: "not composed news",
+			news: archivist.News{
+				Hash:          "def",
+				OriginalTitle: "Apple beats estimates",
+				OriginalDesc:  "Apple reported Q1 results.",
+				PublishedAt:   published,
+			},
+			want: publisher.EmailItem{
+				ID:        "def",
+				Title:     "Apple beats estimates",
+				Text:      "Apple reported Q1 results.",
+				Published: published,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newsEmailItem(tt.news); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newsEmailItem() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
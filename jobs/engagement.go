@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"time"
+)
+
+// engagementBatch is the max number of the posts which stats are collected by one run.
+const engagementBatch = 100
+
+// StatsSource returns the engagement stats of the published messages, see publisher.TelegramStats.
+type StatsSource interface {
+	MessageStats(ctx context.Context, chatID, pubID string) (*publisher.MessageStats, error)
+}
+
+// EngagementJob periodically collects the views, forwards and reactions of the posts published
+// in the public channels and saves them on the news, so the weekly report can show the top posts.
+// Each run updates the posts without stats first and then the least recently updated ones.
+type EngagementJob struct {
+	archivist  *archivist.Archivist // archivist that will read and update the news in the database
+	stats      StatsSource          // source of the engagement stats of the posts
+	channelIDs []string             // public channels (e.g. @my_channel) which posts are tracked
+	window     time.Duration        // posts published earlier are not tracked anymore
+	logger     *slog.Logger         // special logger for the job
+}
+
+func NewEngagementJob(archivist *archivist.Archivist, stats StatsSource, channelIDs []string, window time.Duration) *EngagementJob {
+	return &EngagementJob{
+		archivist:  archivist,
+		stats:      stats,
+		channelIDs: channelIDs,
+		window:     window,
+		logger:     logging.For(logging.ModuleJobs),
+	}
+}
+
+// Run collects the engagement stats of the posts published within the window.
+func (j *EngagementJob) Run() JobFunc {
+	return func() {
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 10*time.Minute)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, "RunEngagementJob")
+		tx.Op = "job-engagement"
+
+		// Sentry performance monitoring
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		span := tx.StartChild("News.FindForStats")
+		news, err := j.archivist.Entities.News.FindForStats(ctx, j.channelIDs, time.Now().Add(-j.window), engagementBatch)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-engagement] Error finding published news: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("engagementJobFindError", hub, e)
+			return
+		}
+
+		span = tx.StartChild("EngagementJob.collect")
+		updated, err := j.collect(ctx, news, j.archivist.Entities.News.UpdateStats)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-engagement] Error collecting engagement stats: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("engagementJobCollectError", hub, e)
+			return
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Updated engagement stats of %d of %d posts", updated, len(news)),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+}
+
+// collect fetches the stats of the news and saves them. Failures of the single posts are logged and skipped,
+// the error is returned if no stats were collected at all or saving fails. Returns the number of the updated news.
+func (j *EngagementJob) collect(
+	ctx context.Context,
+	news []*archivist.News,
+	save func(ctx context.Context, hash string, views, forwards, reactions int) error,
+) (int, error) {
+	var updated int
+	var lastErr error
+	for _, n := range news {
+		s, err := j.stats.MessageStats(ctx, n.ChannelID, n.PublicationID)
+		if errors.Is(err, publisher.ErrStatsUnavailable) {
+			continue
+		}
+		if err != nil {
+			j.logger.WarnContext(ctx, "[job-engagement] Error fetching post stats",
+				"channel", n.ChannelID, "publication", n.PublicationID, "error", err)
+			lastErr = err
+			continue
+		}
+		if err := save(ctx, n.Hash, s.Views, s.Forwards, s.Reactions); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	if updated == 0 && lastErr != nil {
+		return 0, lastErr
+	}
+	return updated, nil
+}
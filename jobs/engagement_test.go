@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/publisher"
+	"reflect"
+	"testing"
+)
+
+// fakeStats returns the stats or the errors of the posts by their publication IDs.
+type fakeStats map[string]any
+
+func (f fakeStats) MessageStats(_ context.Context, _, pubID string) (*publisher.MessageStats, error) {
+	switch v := f[pubID].(type) {
+	case *publisher.MessageStats:
+		return v, nil
+	case error:
+		return nil, v
+	}
+	return nil, errors.New("unknown post")
+}
+
+func TestEngagementJob_collect(t *testing.T) {
+	news := []*archivist.News{
+		{Hash: "a", ChannelID: "@finthread", PublicationID: "1"},
+		{Hash: "b", ChannelID: "-100123", PublicationID: "2"},
+		{Hash: "c", ChannelID: "@finthread", PublicationID: "3"},
+	}
+
+	tests := []struct {
+		name      string
+		stats     fakeStats
+		saveErr   error
+		want      int
+		wantSaved map[string][3]int
+		wantErr   bool
+	}{
+		{
+			name: "failed and unavailable posts skipped",
+			stats: fakeStats{
+				"1": &publisher.MessageStats{Views: 1200, Forwards: 3, Reactions: 15},
+				"2": publisher.ErrStatsUnavailable,
+				"3": errors.New("timeout"),
+			},
+			want:      1,
+			wantSaved: map[string][3]int{"a": {1200, 3, 15}},
+		},
+		{
+			name:      "no stats collected",
+			stats:     fakeStats{"2": publisher.ErrStatsUnavailable, "3": errors.New("timeout")},
+			wantSaved: map[string][3]int{},
+			wantErr:   true,
+		},
+		{
+			name:      "save failure",
+			stats:     fakeStats{"1": &publisher.MessageStats{Views: 10}},
+			saveErr:   errors.New("db is down"),
+			wantSaved: map[string][3]int{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &EngagementJob{stats: tt.stats, logger: logging.For(logging.ModuleJobs)}
+			saved := make(map[string][3]int)
+			save := func(_ context.Context, hash string, views, forwards, reactions int) error {
+				if tt.saveErr != nil {
+					return tt.saveErr
+				}
+				saved[hash] = [3]int{views, forwards, reactions}
+				return nil
+			}
+
+			got, err := j.collect(context.Background(), news, save)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("collect() = %d, want %d", got, tt.want)
+			}
+			if !reflect.DeepEqual(saved, tt.wantSaved) {
+				t.Errorf("saved stats = %v, want %v", saved, tt.wantSaved)
+			}
+		})
+	}
+}
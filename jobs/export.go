@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/storage"
+	"log/slog"
+	"time"
+)
+
+const exportBatchSize = 500
+
+// ExportJob periodically exports archived tables to the S3-compatible storage as gzipped JSONL files.
+// It is a lightweight backup that doesn't depend on the full pg_dump infrastructure.
+type ExportJob struct {
+	archivist *archivist.Archivist // archivist that will read the tables from the database
+	storage   *storage.S3          // storage where the exports will be uploaded
+	prefix    string               // prefix of the object keys, e.g. "exports"
+	logger    *slog.Logger         // special logger for the job
+}
+
+func NewExportJob(archivist *archivist.Archivist, storage *storage.S3, prefix string) *ExportJob {
+	return &ExportJob{
+		archivist: archivist,
+		storage:   storage,
+		prefix:    prefix,
+		logger:    logging.For(logging.ModuleJobs),
+	}
+}
+
+// Run exports news and events tables. Each run is stored in its own timestamped folder.
+func (j *ExportJob) Run() JobFunc {
+	return func() {
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 15*time.Minute)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, "RunExportJob")
+		tx.Op = "job-export"
+
+		// Sentry performance monitoring
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		folder := fmt.Sprintf("%s/%s", j.prefix, time.Now().UTC().Format("2006-01-02T15-04-05Z"))
+
+		span := tx.StartChild("News.FindInBatches")
+		news := newJSONLWriter()
+		err := j.archivist.Entities.News.FindInBatches(ctx, exportBatchSize, func(n []*archivist.News) error {
+			return writeJSONL(news, n)
+		})
+		span.Finish()
+		if err == nil {
+			span = tx.StartChild("Storage.PutObject.News")
+			err = j.upload(ctx, folder+"/news.jsonl.gz", news)
+			span.Finish()
+		}
+		if err != nil {
+			e := fmt.Errorf("[job-export] Error exporting news: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("exportJobNewsError", hub, e)
+			return
+		}
+
+		span = tx.StartChild("Events.FindInBatches")
+		events := newJSONLWriter()
+		err = j.archivist.Entities.Events.FindInBatches(ctx, exportBatchSize, func(e []*archivist.Event) error {
+			return writeJSONL(events, e)
+		})
+		span.Finish()
+		if err == nil {
+			span = tx.StartChild("Storage.PutObject.Events")
+			err = j.upload(ctx, folder+"/events.jsonl.gz", events)
+			span.Finish()
+		}
+		if err != nil {
+			e := fmt.Errorf("[job-export] Error exporting events: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("exportJobEventsError", hub, e)
+			return
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Exported %d news and %d events to %s", news.count, events.count, folder),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+}
+
+func (j *ExportJob) upload(ctx context.Context, key string, w *jsonlWriter) error {
+	body, err := w.close()
+	if err != nil {
+		return err
+	}
+
+	return j.storage.PutObject(ctx, key, body, "application/gzip") //nolint:wrapcheck
+}
+
+// jsonlWriter encodes rows as gzipped JSON lines.
+type jsonlWriter struct {
+	buf   *bytes.Buffer
+	gz    *gzip.Writer
+	enc   *json.Encoder
+	count int // number of written rows
+}
+
+func newJSONLWriter() *jsonlWriter {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	return &jsonlWriter{
+		buf: buf,
+		gz:  gz,
+		enc: json.NewEncoder(gz),
+	}
+}
+
+// writeJSONL encodes each element of the rows slice as a separate line.
+func writeJSONL[T any](w *jsonlWriter, rows []T) error {
+	for _, r := range rows {
+		if err := w.enc.Encode(r); err != nil {
+			return fmt.Errorf("error encoding row: %w", err)
+		}
+		w.count++
+	}
+	return nil
+}
+
+// close flushes the gzip stream and returns the compressed content.
+func (w *jsonlWriter) close() ([]byte, error) {
+	if err := w.gz.Close(); err != nil {
+		return nil, fmt.Errorf("error closing gzip writer: %w", err)
+	}
+	return w.buf.Bytes(), nil
+}
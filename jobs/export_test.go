@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteJSONL(t *testing.T) {
+	type row struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+
+	tests := []struct {
+		name    string
+		batches [][]row
+		want    []row
+	}{
+		{
+			name:    "empty export",
+			batches: nil,
+			want:    nil,
+		},
+		{
+			name: "multiple batches",
+			batches: [][]row{
+				{{ID: 1, Title: "first"}, {ID: 2, Title: "second"}},
+				{{ID: 3, Title: "third"}},
+			},
+			want: []row{{ID: 1, Title: "first"}, {ID: 2, Title: "second"}, {ID: 3, Title: "third"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newJSONLWriter()
+			for _, b := range tt.batches {
+				if err := writeJSONL(w, b); err != nil {
+					t.Fatalf("writeJSONL() error = %v", err)
+				}
+			}
+			body, err := w.close()
+			if err != nil {
+				t.Fatalf("close() error = %v", err)
+			}
+			if w.count != len(tt.want) {
+				t.Errorf("count = %d, want %d", w.count, len(tt.want))
+			}
+
+			gz, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("gzip.NewReader() error = %v", err)
+			}
+			var got []row
+			scanner := bufio.NewScanner(gz)
+			for scanner.Scan() {
+				var r row
+				if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				got = append(got, r)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
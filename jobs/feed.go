@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// publishedNewsStore finds the latest published news (see archivist.NewsDB.FindLatestPublished).
+type publishedNewsStore interface {
+	FindLatestPublished(ctx context.Context, limit int) ([]*archivist.News, error)
+}
+
+// objectStorage uploads the objects (see storage.S3).
+type objectStorage interface {
+	PutObject(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// FeedJob periodically writes the RSS and Atom feeds of the latest published news to the directory
+// and/or the S3-compatible storage, so the news can be followed without Telegram.
+type FeedJob struct {
+	news    publishedNewsStore
+	feed    *publisher.Feed // builder of the feeds
+	limit   int             // number of the latest news in the feeds
+	dir     string          // directory to write the feeds to (optional)
+	storage objectStorage   // storage to upload the feeds to (optional)
+	prefix  string          // prefix of the object keys, e.g. "feed"
+	logger  *slog.Logger
+}
+
+// NewFeedJob creates a new FeedJob with the latest limit news in the feeds.
+// Set the targets with WriteTo and UploadTo.
+func NewFeedJob(news publishedNewsStore, feed *publisher.Feed, limit int) *FeedJob {
+	return &FeedJob{
+		news:   news,
+		feed:   feed,
+		limit:  limit,
+		logger: logging.For(logging.ModuleJobs),
+	}
+}
+
+// WriteTo writes the feeds to the directory as feed.xml and feed.atom.
+func (j *FeedJob) WriteTo(dir string) *FeedJob {
+	j.dir = dir
+	return j
+}
+
+// UploadTo uploads the feeds to the storage with the key prefix, e.g. "feed/feed.xml".
+func (j *FeedJob) UploadTo(storage objectStorage, prefix string) *FeedJob {
+	j.storage = storage
+	j.prefix = prefix
+	return j
+}
+
+// Run renders the feeds of the latest published news and writes them to the targets.
+func (j *FeedJob) Run() JobFunc {
+	return func() {
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), time.Minute)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, "RunFeedJob")
+		tx.Op = "job-feed"
+
+		// Sentry performance monitoring
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		span := tx.StartChild("News.FindLatestPublished")
+		news, err := j.news.FindLatestPublished(ctx, j.limit)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-feed] Error finding published news: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("feedJobFindError", hub, e)
+			return
+		}
+
+		span = tx.StartChild("Feed.write")
+		err = j.write(ctx, news)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-feed] Error writing feeds: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("feedJobWriteError", hub, e)
+			return
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Wrote feeds with %d news", len(news)),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+}
+
+// write renders the RSS and Atom feeds of the news and writes them to all targets.
+// Errors of the targets are joined, so the failed storage doesn't stop writing the files.
+func (j *FeedJob) write(ctx context.Context, news []*archivist.News) error {
+	items := make([]publisher.FeedItem, len(news))
+	for i, n := range news {
+		items[i] = n.ToFeedItem()
+	}
+
+	rss, err := j.feed.RSS(items)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	atom, err := j.feed.Atom(items)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	files := []struct {
+		name        string
+		body        []byte
+		contentType string
+	}{
+		{name: publisher.RSSFeedFile, body: rss, contentType: "application/rss+xml; charset=utf-8"},
+		{name: publisher.AtomFeedFile, body: atom, contentType: "application/atom+xml; charset=utf-8"},
+	}
+
+	var errs []error
+	for _, f := range files {
+		if j.dir != "" {
+			if err := writeFileAtomic(filepath.Join(j.dir, f.name), f.body); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if j.storage != nil {
+			if err := j.storage.PutObject(ctx, path.Join(j.prefix, f.name), f.body, f.contentType); err != nil {
+				errs = append(errs, fmt.Errorf("upload %s: %w", f.name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeFileAtomic writes the file through the temp file, so the readers (e.g. the web server) never see it half-written.
+func writeFileAtomic(name string, body []byte) error {
+	tmp := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".tmp")
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeObjectStorage struct {
+	objects map[string]string
+	err     error
+}
+
+func (s *fakeObjectStorage) PutObject(_ context.Context, key string, body []byte, _ string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.objects[key] = string(body)
+	return nil
+}
+
+func TestFeedJob_write(t *testing.T) {
+	news := []*archivist.News{
+		{
+			Hash:          "abc",
+			URL:           "https://example.com/fed",
+			OriginalTitle: "Fed holds rates",
+			ComposedText:  "The Fed kept rates unchanged.",
+			PublishedAt:   time.Date(2024, 3, 8, 21, 30, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		dir      bool
+		storage  *fakeObjectStorage
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			name: "directory",
+			dir:  true,
+		},
+		{
+			name:     "storage",
+			storage:  &fakeObjectStorage{objects: map[string]string{}},
+			wantKeys: []string{"feed/feed.xml", "feed/feed.atom"},
+		},
+		{
+			name:    "failed storage doesn't stop the files",
+			dir:     true,
+			storage: &fakeObjectStorage{err: errors.New("access denied")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewFeedJob(nil, publisher.NewFeed("https://example.com"), 10)
+			dir := ""
+			if tt.dir {
+				dir = t.TempDir()
+				j.WriteTo(dir)
+			}
+			if tt.storage != nil {
+				j.UploadTo(tt.storage, "feed")
+			}
+
+			if err := j.write(context.Background(), news); (err != nil) != tt.wantErr {
+				t.Fatalf("write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if dir != "" {
+				for _, name := range []string{publisher.RSSFeedFile, publisher.AtomFeedFile} {
+					body, err := os.ReadFile(filepath.Join(dir, name))
+					if err != nil {
+						t.Fatalf("feed %s is not written: %v", name, err)
+					}
+					if !strings.Contains(string(body), "The Fed kept rates unchanged.") {
+						t.Errorf("feed %s = %s, want to contain the news", name, body)
+					}
+				}
+				if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+					t.Errorf("directory has %d files, want only the feeds", len(entries))
+				}
+			}
+			for _, key := range tt.wantKeys {
+				if !strings.Contains(tt.storage.objects[key], "Fed holds rates") {
+					t.Errorf("object %s = %q, want the feed", key, tt.storage.objects[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+)
+
+// claimPublication takes the idempotency key of the news publication to the chat right before sending it.
+// Returns false if the news is already published to the chat (with its publication ID) or is being published
+// by another run, so the retried job or the second instance doesn't post it twice.
+// Publications are not tracked if the news are not saved to the DB.
+func (job *Job) claimPublication(ctx context.Context, hub *sentry.Hub, hash, chatID string) (claimed bool, pubID string, err error) {
+	if !job.options.shouldSaveToDB {
+		return true, "", nil
+	}
+
+	key, claimed, err := job.archivist.Entities.PublicationKeys.Claim(ctx, hash, chatID)
+	if err != nil {
+		e := fmt.Errorf("[%s][claimPublication.PublicationKeys.Claim]: %w", job.name, err)
+		utils.CaptureSentryException("jobClaimPublicationError", hub, e)
+		job.alerter.Alert(job.name, "publish", e)
+		return false, "", e
+	}
+	if !claimed {
+		job.logger.WarnContext(ctx, fmt.Sprintf("[%s][claimPublication]: news %s is already published to %s", job.name, hash, chatID),
+			"publication_id", key.PublicationID)
+	}
+
+	return claimed, key.PublicationID, nil
+}
+
+// completePublication saves the publication ID of the claimed key along with the published news (optional)
+// in one transaction right after sending.
+func (job *Job) completePublication(ctx context.Context, hub *sentry.Hub, hash, chatID, pubID string, n *archivist.News) error {
+	if !job.options.shouldSaveToDB {
+		return nil
+	}
+
+	if err := job.archivist.Entities.PublicationKeys.Complete(ctx, hash, chatID, pubID, n); err != nil {
+		e := fmt.Errorf("[%s][completePublication.PublicationKeys.Complete]: %w", job.name, err)
+		utils.CaptureSentryException("jobCompletePublicationError", hub, e)
+		job.alerter.Alert(job.name, "update", e)
+		return e
+	}
+
+	return nil
+}
+
+// releasePublication deletes the claimed key of the failed publication, so the news can be published again.
+func (job *Job) releasePublication(ctx context.Context, hub *sentry.Hub, hash, chatID string) {
+	if !job.options.shouldSaveToDB {
+		return
+	}
+
+	if err := job.archivist.Entities.PublicationKeys.Release(ctx, hash, chatID); err != nil {
+		e := fmt.Errorf("[%s][releasePublication.PublicationKeys.Release]: %w", job.name, err)
+		utils.CaptureSentryException("jobReleasePublicationError", hub, e)
+	}
+}
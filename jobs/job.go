@@ -10,10 +10,17 @@ import (
 	"github.com/samgozman/fin-thread/composer"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/pkg/observability"
+	"github.com/samgozman/fin-thread/pkg/rules"
 	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"gorm.io/datatypes"
 	"log/slog"
 	"slices"
+	"sort"
 	"strings"
 	"time"
 )
@@ -27,19 +34,57 @@ type Job struct {
 	journalist *journalist.Journalist       // journalist that will fetch news
 	stocks     *stocks.StockMap             // stocks that will be used to filter news and compose meta (optional). TODO: use more fields from Stock struct
 	logger     *slog.Logger                 // special logger for the job
+	metrics    metrics.Emitter              // metrics emitter for job counters and latencies
+	rules      *rules.Set                   // operator-defined rules for filtering, priority and channel routing (optional)
+	fetchRules *rules.Set                   // operator-defined rules for dropping and flagging the fetched news (optional)
+	router     *Router                      // routes news to the named channels by their meta (optional)
+	mirrors    *publisher.MultiPublisher    // additional targets where the published news are mirrored (optional)
+	email      *publisher.EmailPublisher    // collects the published news into the email digest (optional)
+	alerter    *Alerter                     // sends alerts to the admin chat on failures (optional)
+	control    *Control                     // pauses the job and records its runs (optional)
+	quotes     marketdata.QuoteProvider     // fetches the day price changes of the mentioned tickers (optional)
+	content    *journalist.ContentFetcher   // downloads the article texts of the news for the compose prompt (optional)
+	links      *journalist.LinkResolver     // resolves the links of the news to the canonical or archive URLs (optional)
+	budget     *LLMUsageJob                 // pauses the compose stage when the monthly LLM budget is exceeded (optional)
+	summary    *runSummary                  // sends the summary of the runs to the admin chat (optional)
+	windows    map[string]*PublishWindow    // publishing windows of the channels by their names (optional)
 	options    *jobOptions                  // job options
 }
 
 // jobOptions holds job options needed for the job execution.
 type jobOptions struct {
-	until              time.Time       // fetch articles until this date
-	omitSuspicious     bool            // if true, will not publish suspicious articles
-	omitEmptyMetaKeys  *omitKeyOptions // holds keys that will omit news if empty. Note: requires shouldComposeText to be true
-	omitIfAllKeysEmpty bool            // if true, will omit articles with empty meta for all keys. Note: requires shouldComposeText to be set
-	omitUnlistedStocks bool            // if true, will omit articles with stocks unlisted in the Job.stocks
-	shouldComposeText  bool            // if true, will compose text for the article using OpenAI. If false, will use original title and description
-	shouldSaveToDB     bool            // if true, will save all news to the database
-	shouldRemoveClones bool            // if true, will remove duplicated news found in the DB. Note: requires shouldSaveToDB to be true
+	until              time.Time               // fetch articles until this date
+	omitSuspicious     bool                    // if true, will not publish suspicious articles
+	omitEmptyMetaKeys  *omitKeyOptions         // holds keys that will omit news if empty. Note: requires shouldComposeText to be true
+	omitIfAllKeysEmpty bool                    // if true, will omit articles with empty meta for all keys. Note: requires shouldComposeText to be set
+	omitUnlistedStocks bool                    // if true, will omit articles with stocks unlisted in the Job.stocks
+	shouldComposeText  bool                    // if true, will compose text for the article using OpenAI. If false, will use original title and description
+	breaking           bool                    // if true, news skip the LLM filter stages, are composed by the lite prompt and published with the priority
+	shouldClassify     bool                    // if true, unimportant news are dropped by the Composer.Classify stage instead of Composer.Filter
+	suspiciousMin      float64                 // if > 0, news flagged by the keywords are unflagged by the Composer.ReviewSuspicious if their spam score is lower
+	shouldReadImages   bool                    // if true, will extract figures from the news images for the compose prompt. Note: requires shouldComposeText to be true
+	shouldAttachImages bool                    // if true, will publish the news with their images as the photo captions if the text fits
+	shouldSaveToDB     bool                    // if true, will save all news to the database
+	shouldRemoveClones bool                    // if true, will remove duplicated news found in the DB. Note: requires shouldSaveToDB to be true
+	threadMaxLength    int                     // if > 0, texts longer than this will be published as a thread of messages
+	similarityWindow   time.Duration           // if > 0, will remove news similar to the news fetched within this window. Note: requires shouldRemoveClones to be true
+	minSimilarity      float64                 // min cosine similarity of the news embeddings to treat them as the same story
+	clusterMethod      ClusterMethod           // if set, related news of the run are published as one merged story. Note: requires shouldComposeText to be true
+	clusterSimilarity  float64                 // min similarity of the news of one story by the clusterMethod
+	channel            string                  // name of the channel (or chat ID) where the news are published instead of the default one
+	style              composer.Style          // style of the composed text, the default style of the Composer if empty
+	maxSentences       int                     // max sentences of the composed text, the Composer config if 0
+	maxChars           int                     // max characters of the composed text, the Composer config if 0
+	freshness          time.Duration           // only the news published within the window are composed, the Composer window if 0
+	sentimentMin       float64                 // if > 0, will prefix the text with the sentiment emoji if its confidence is not lower. Note: requires shouldComposeText to be true
+	minImportance      int                     // if > 0, will not publish the news rated by the composer as less important. Note: requires shouldComposeText to be true
+	translations       []Translation           // channels where the published news are also published translated. Note: requires shouldComposeText to be true
+	subscriptions      map[string]string       // channels subscribed to the news of the tickers by the ticker. Note: requires shouldComposeText to be true
+	maxPublish         int                     // if > 0, news over this number are left pending for the next runs. Note: requires shouldSaveToDB to be true
+	overflowOrder      OverflowOrder           // order of publishing the pending and new news if maxPublish is set
+	queuePublications  bool                    // if true, the run only queues the news, they are published by the PublishQueue worker. Note: requires shouldSaveToDB to be true
+	timeout            time.Duration           // deadline of the whole run
+	stageTimeouts      map[stage]time.Duration // deadlines of the run stages, limited by the timeout
 }
 
 // NewJob creates a new Job instance.
@@ -57,8 +102,16 @@ func NewJob(
 		archivist:  archivist,
 		journalist: journalist,
 		stocks:     stocks,
-		logger:     slog.Default(),
-		options:    &jobOptions{},
+		logger:     logging.For(logging.ModuleJobs),
+		metrics:    metrics.Noop{},
+		options: &jobOptions{
+			timeout: defaultTimeout,
+			stageTimeouts: map[stage]time.Duration{
+				StageFetch:   defaultFetchTimeout,
+				StageCompose: defaultComposeTimeout,
+				StagePublish: defaultPublishTimeout,
+			},
+		},
 	}
 }
 
@@ -68,6 +121,22 @@ func (job *Job) FetchUntil(until time.Time) *Job {
 	return job
 }
 
+// WithTimeout sets the deadline of the whole run (25 seconds by default).
+func (job *Job) WithTimeout(timeout time.Duration) *Job {
+	job.options.timeout = timeout
+	return job
+}
+
+// WithStageTimeout sets the deadline of the run stage. Stage deadlines are limited by the run timeout,
+// 0 removes the stage deadline.
+func (job *Job) WithStageTimeout(s stage, timeout time.Duration) *Job {
+	if job.options.stageTimeouts == nil {
+		job.options.stageTimeouts = make(map[stage]time.Duration)
+	}
+	job.options.stageTimeouts[s] = timeout
+	return job
+}
+
 // OmitSuspicious sets the flag that will omit suspicious articles.
 func (job *Job) OmitSuspicious() *Job {
 	job.options.omitSuspicious = true
@@ -109,6 +178,54 @@ func (job *Job) ComposeText() *Job {
 	return job
 }
 
+// Breaking marks the job news as breaking: they are published with the shortened pipeline (dedupe, lite compose,
+// publish) with the 🚨 prefix, and their messages skip the queue of the publisher rate limiter.
+func (job *Job) Breaking() *Job {
+	job.options.breaking = true
+	if job.publisher != nil {
+		job.publisher = job.publisher.Priority()
+	}
+	return job
+}
+
+// ClassifyNews sets the flag that will drop unimportant news (PR fluff, ads) with the separate LLM stage
+// (Composer.Classify) before composing, instead of the default Composer.Filter.
+func (job *Job) ClassifyNews() *Job {
+	job.options.shouldClassify = true
+	return job
+}
+
+// ReviewSuspicious sets the threshold (0..1) of the spam and advertorial score for the news flagged
+// by the suspicious keywords. Flagged news are scored by the separate LLM stage (Composer.ReviewSuspicious)
+// and unflagged if their score is lower, so the keywords only pre-filter the news for the LLM.
+func (job *Job) ReviewSuspicious(threshold float64) *Job {
+	job.options.suspiciousMin = threshold
+	return job
+}
+
+// ExtractImageFigures sets the flag that will extract key figures from the news images (charts, tables)
+// using the vision model and pass them to the compose prompt. Note: requires ComposeText to be set.
+func (job *Job) ExtractImageFigures() *Job {
+	job.options.shouldReadImages = true
+	return job
+}
+
+// AttachImages sets the flag that will publish the news with their images (e.g. the RSS enclosure)
+// as the photo with the caption. Texts longer than the caption limit are published without the image.
+// Note: requires SaveToDB to be set, because the image URL is saved with the news.
+func (job *Job) AttachImages() *Job {
+	job.options.shouldAttachImages = true
+	return job
+}
+
+// ThreadLongText sets the max length of the single message. Longer texts will be published as a thread:
+// a chain of numbered messages, each one replying to the previous.
+// Composed news formatted by the publisher.MessageFormatter are always published as a single message.
+func (job *Job) ThreadLongText(maxLength int) *Job {
+	job.options.threadMaxLength = maxLength
+	return job
+}
+
 // RemoveClones sets the flag that will remove duplicated news found in the DB.
 func (job *Job) RemoveClones() *Job {
 	job.options.shouldRemoveClones = true
@@ -121,16 +238,160 @@ func (job *Job) SaveToDB() *Job {
 	return job
 }
 
+// ShowSentiment sets the flag that will prefix the published text with the sentiment emoji
+// (🟢 bullish, 🔴 bearish) if the sentiment confidence is not lower than minConfidence.
+// Note: requires ComposeText to be set.
+func (job *Job) ShowSentiment(minConfidence float64) *Job {
+	job.options.sentimentMin = minConfidence
+	return job
+}
+
+// MinImportance sets the min importance (0..composer.MaxImportance) of the published news rated by the composer.
+// The news not rated (e.g. composed by the lite prompt of the breaking jobs) are published.
+// Note: requires ComposeText to be set.
+func (job *Job) MinImportance(minImportance int) *Job {
+	job.options.minImportance = minImportance
+	return job
+}
+
+// PublishToChannel sets the channel name (see publisher.TelegramPublisher.Channels) or chat ID where the news
+// are published instead of the default channel. Router and rules can still route the news to other channels.
+func (job *Job) PublishToChannel(channel string) *Job {
+	job.options.channel = channel
+	return job
+}
+
+// LimitPublications sets the max number of the news published per run, e.g. to not flood the channel with the backlog
+// of the feeds after a downtime. The rest are saved as pending and published by the next runs in the given order.
+// Note: requires SaveToDB to be set.
+func (job *Job) LimitPublications(maxPerRun int, order OverflowOrder) *Job {
+	job.options.maxPublish = maxPerRun
+	job.options.overflowOrder = order
+	return job
+}
+
+// QueuePublications sets the flag that makes the run stop after the news are queued for publication, they are
+// published by the separate PublishQueue worker at its own pace. The slow compose stage doesn't hold the publication
+// and the queued news survive restarts. Note: requires SaveToDB to be set.
+func (job *Job) QueuePublications() *Job {
+	job.options.queuePublications = true
+	return job
+}
+
+// WithStyle sets the style (tone and length) of the composed text of the job, e.g. terse headlines for the trading
+// channel. The default style of the Composer is used if not set. Note: the breaking news are always composed short.
+func (job *Job) WithStyle(style composer.Style) *Job {
+	job.options.style = style
+	return job
+}
+
+// LimitLength sets the max sentences and characters of the composed text of the job, e.g. short posts for the
+// Telegram channel and longer ones for the newsletter. The limits of the Composer config are used for the zero ones.
+// Note: requires ComposeText to be set, the breaking news are composed by the lite prompt without the limits.
+func (job *Job) LimitLength(maxSentences, maxChars int) *Job {
+	job.options.maxSentences = maxSentences
+	job.options.maxChars = maxChars
+	return job
+}
+
+// WithFreshnessWindow sets the max age of the news composed by the job, e.g. a longer one for the slow feeds.
+// The FreshnessWindow of the Composer config is used if not set. Note: requires ComposeText to be set.
+func (job *Job) WithFreshnessWindow(window time.Duration) *Job {
+	job.options.freshness = window
+	return job
+}
+
 // OmitUnlistedStocks sets the flag that will omit articles publishing with stocks unlisted in the Job.stocks.
 func (job *Job) OmitUnlistedStocks() *Job {
 	job.options.omitUnlistedStocks = true
 	return job
 }
 
+// WithMetrics sets the metrics emitter for the job counters and latencies.
+func (job *Job) WithMetrics(m metrics.Emitter) *Job {
+	job.metrics = m
+	return job
+}
+
+// WithRules sets the rules that will be evaluated to filter, flag, prioritise and route the news.
+// Rules of the fetch stage are evaluated before composing, the other ones before publishing.
+func (job *Job) WithRules(r *rules.Set) *Job {
+	job.fetchRules = r.Stage(rules.StageFetch)
+	job.rules = r.Stage(rules.StagePublish)
+	return job
+}
+
+// WithRouter sets the Router that will send the news to the named channels based on their tickers, markets and hashtags.
+func (job *Job) WithRouter(r *Router) *Job {
+	job.router = r
+	return job
+}
+
+// MirrorTo sets additional publishers (e.g. Discord) where all published news will be mirrored.
+// Publication IDs from all targets are saved to the News.Publications.
+func (job *Job) MirrorTo(m *publisher.MultiPublisher) *Job {
+	job.mirrors = m
+	return job
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed stages.
+func (job *Job) WithAlerter(a *Alerter) *Job {
+	job.alerter = a
+	return job
+}
+
+// WithControl sets the Control that can pause the job and records its last runs.
+func (job *Job) WithControl(c *Control) *Job {
+	job.control = c
+	return job
+}
+
+// FetchContent enables downloading the articles of the news: their main text is passed truncated
+// to the compose prompt instead of the short description and saved with the news.
+func (job *Job) FetchContent(f *journalist.ContentFetcher) *Job {
+	job.content = f
+	return job
+}
+
+// ResolveLinks enables resolving the links of the news before they are saved and published: tracking redirects
+// are followed to the canonical URL without the UTM parameters, paywalled articles are linked to the archive.
+// Note: the breaking news are published with their original links.
+func (job *Job) ResolveLinks(r *journalist.LinkResolver) *Job {
+	job.links = r
+	return job
+}
+
+// WithQuotes enables the day price changes of the mentioned tickers in the published news, e.g. "$AAPL +1.4%".
+// Note: requires shouldComposeText to be true.
+func (job *Job) WithQuotes(q marketdata.QuoteProvider) *Job {
+	job.quotes = q
+	return job
+}
+
+// WithBudget pauses the compose stage when the monthly LLM budget of the usage job is exceeded,
+// the news are published with their original titles instead.
+func (job *Job) WithBudget(b *LLMUsageJob) *Job {
+	job.budget = b
+	return job
+}
+
 // Run return job function that will be executed by the scheduler.
 func (job *Job) Run() JobFunc {
 	return func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+		run := RunInfo{StartedAt: time.Now()}
+		var err error // error of the stage the run stopped at
+		defer func() {
+			run.Duration = time.Since(run.StartedAt)
+			run.Failed = err != nil
+			job.control.record(job.journalist.Name, run)
+			job.sendRunSummary(run, err)
+		}()
+		if job.control.Paused() {
+			run.Paused = true
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), job.options.timeout)
 		defer cancel()
 
 		tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s", job.name))
@@ -147,46 +408,129 @@ func (job *Job) Run() JobFunc {
 		defer hub.Flush(2 * time.Second)
 		defer hub.Recover(nil)
 
-		news, err := job.getLatestNews(ctx, tx, hub)
-		if len(news) == 0 || err != nil {
-			return
-		}
+		// Errors and breadcrumbs of the run can be searched by the job configuration and the run ID of the logs
+		tags := job.sentryTags()
+		tags[observability.TagRunID] = logging.RunID(ctx)
+		observability.Tag(tx, hub, tags)
+		observability.SetContext(hub, "job", tags)
+		hub.AddBreadcrumb(observability.Breadcrumb("job", fmt.Sprintf("Job %s started", job.name), sentry.LevelInfo, tags), nil)
 
-		news, err = job.removeDuplicates(ctx, tx, hub, news)
-		if err != nil || len(news) == 0 {
-			return
-		}
+		job.metrics.Count(metrics.JobRuns, 1, job.metricsTag())
+		defer func(start time.Time) {
+			job.metrics.Timing(metrics.JobDuration, time.Since(start), job.metricsTag())
+		}(time.Now())
 
-		news, err = job.filterByComposer(ctx, tx, hub, news)
-		if err != nil || len(news) == 0 {
-			return
-		}
+		// Provider quality counters and the run audit record are saved even if the run stops at some stage
+		stats := providerStats{}
+		defer job.saveProviderStats(ctx, hub, stats)
+		defer func() { job.saveJobRun(ctx, hub, run, err) }()
 
-		composedNews, err := job.composeNews(ctx, tx, hub, news)
-		if err != nil || len(composedNews) == 0 {
+		filteredNews, err := job.prepareNews(ctx, tx, hub, &run, stats)
+		if err != nil {
 			return
 		}
 
-		dbNews, err := job.saveNews(ctx, tx, hub, news, composedNews)
-		if err != nil || len(dbNews) == 0 {
+		// News over the per-run limit wait for the next runs, so the pending ones are published even without new news
+		filteredNews, err = job.limitPublications(ctx, tx, hub, filteredNews)
+		if err != nil || len(filteredNews) == 0 {
 			return
 		}
 
-		filteredNews, err := job.prepublishFilter(tx, hub, dbNews)
+		// News of the channels outside their publishing windows wait for them to open
+		filteredNews, err = job.holdClosed(ctx, tx, hub, filteredNews)
 		if err != nil || len(filteredNews) == 0 {
 			return
 		}
 
-		publishedNews, err := job.publish(tx, hub, filteredNews)
-		if err != nil || len(publishedNews) == 0 {
+		err = job.queueNews(ctx, tx, hub, filteredNews)
+		if err != nil || job.options.queuePublications {
 			return
 		}
 
-		err = job.updateNews(ctx, tx, hub, publishedNews)
-		if err != nil {
-			return
+		publishCtx, cancelPublish := job.stageContext(ctx, StagePublish)
+		defer cancelPublish()
+		publishedNews, err := job.publish(publishCtx, tx, hub, filteredNews)
+		stats.countPublished(publishedNews)
+		run.Published = len(publishedNews)
+
+		job.publishTranslations(publishCtx, tx, hub, publishedNews)
+		job.publishSubscriptions(publishCtx, tx, hub, publishedNews)
+	}
+}
+
+// prepareNews fetches, deduplicates, composes and saves the latest news and returns the ones selected
+// for publication by the pre-publish filters. Returns no news without the error if there is nothing to publish.
+func (job *Job) prepareNews(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	run *RunInfo,
+	stats providerStats,
+) ([]*archivist.News, error) {
+	fetchCtx, cancelFetch := job.stageContext(ctx, StageFetch)
+	fetchedNews, err := job.getLatestNews(fetchCtx, tx, hub)
+	cancelFetch()
+	run.Fetched = len(fetchedNews)
+	if len(fetchedNews) == 0 || err != nil {
+		return nil, err
+	}
+	stats.countFetched(fetchedNews)
+
+	news, err := job.removeDuplicates(ctx, tx, hub, fetchedNews)
+	if err != nil {
+		return nil, err
+	}
+	news = job.removeSimilar(ctx, tx, hub, news)
+	news = job.applyFetchRules(tx, hub, news)
+	run.Deduped = len(news)
+	if job.options.shouldRemoveClones {
+		stats.countDuplicates(fetchedNews, news)
+	}
+	if len(news) == 0 {
+		return nil, nil
+	}
+
+	// Suspicious review, filter, image figures and compose LLM calls share the compose stage deadline
+	composeCtx, cancelCompose := job.stageContext(ctx, StageCompose)
+	defer cancelCompose()
+
+	// Breaking news skip the LLM review and filter stages to be published faster
+	if !job.options.breaking {
+		job.reviewSuspicious(composeCtx, tx, hub, news)
+
+		news, err = job.filterByComposer(composeCtx, tx, hub, news)
+		if err != nil || len(news) == 0 {
+			return nil, err
 		}
+
+		job.fetchContent(composeCtx, tx, hub, news)
+		job.resolveLinks(composeCtx, tx, hub, news)
+		job.extractImageFigures(composeCtx, tx, hub, news)
+	}
+
+	singles, clusters := job.clusterStories(composeCtx, tx, hub, news)
+	merged, unmerged := job.composeClusters(composeCtx, tx, hub, clusters)
+
+	composedNews, err := job.composeNews(composeCtx, tx, hub, append(singles, unmerged...))
+	composedNews = append(composedNews, merged...)
+	run.Composed = len(composedNews)
+	if err != nil || len(composedNews) == 0 {
+		return nil, err
+	}
+	cancelCompose()
+
+	dbNews, err := job.saveNews(ctx, tx, hub, news, composedNews)
+	if err != nil || len(dbNews) == 0 {
+		return nil, err
 	}
+
+	filteredNews, err := job.prepublishFilter(tx, hub, dbNews)
+	if err != nil {
+		return nil, err
+	}
+	stats.countDropped(dbNews, filteredNews)
+
+	return filteredNews, nil
 }
 
 func (job *Job) filterByComposer(
@@ -195,13 +539,22 @@ func (job *Job) filterByComposer(
 	hub *sentry.Hub,
 	news journalist.NewsList,
 ) (journalist.NewsList, error) {
-	span := tx.StartChild("filterByComposer.Filter")
-	news, err := job.composer.Filter(ctx, news)
+	stage, filter := "filter", job.composer.Filter
+	if job.options.shouldClassify {
+		stage, filter = "classify", job.composer.Classify
+	}
+
+	span := tx.StartChild("filterByComposer." + stage)
+	start := time.Now()
+	news, err := filter(ctx, news)
+	job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", stage))
 	span.Finish()
 	if err != nil {
-		e := fmt.Errorf("[%s][Filter]: %w", job.name, err)
-		job.logger.Info(e.Error())
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", stage))
+		e := fmt.Errorf("[%s][filterByComposer.%s]: %w", job.name, stage, err)
+		job.logger.InfoContext(ctx, e.Error())
 		utils.CaptureSentryException("jobComposerFilterError", hub, e)
+		job.alerter.Alert(job.name, stage, e)
 		return nil, e
 	}
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
@@ -217,10 +570,31 @@ func (job *Job) getLatestNews(ctx context.Context, tx *sentry.Span, hub *sentry.
 	span := tx.StartChild("getLatestNews.GetLatestNews")
 	news, err := job.journalist.GetLatestNews(ctx, job.options.until)
 	span.Finish()
+	job.metrics.Count(metrics.NewsFetched, int64(len(news)), job.metricsTag())
+
+	var fetchErr *journalist.FetchError
+	if errors.As(err, &fetchErr) && !fetchErr.AllFailed() {
+		// Some providers failed, the job continues with the news of the rest
+		e := fmt.Errorf("[%s][getLatestNews.GetLatestNews]: %w", job.name, err)
+		job.logger.WarnContext(ctx, e.Error(), "failed_providers", fetchErr.Providers())
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetTag(observability.TagFailedProviders, observability.List(fetchErr.Providers()))
+			utils.CaptureSentryException("jobGetLatestNewsPartialError", hub, e)
+		})
+		job.alerter.Alert(job.name, "fetch", e)
+		err = nil
+	}
 	if err != nil {
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "fetch"))
 		e := fmt.Errorf("[%s][getLatestNews.GetLatestNews]: %w", job.name, err)
-		job.logger.Info(e.Error())
-		utils.CaptureSentryException("jobGetLatestNewsError", hub, e)
+		job.logger.InfoContext(ctx, e.Error())
+		hub.WithScope(func(scope *sentry.Scope) {
+			if fetchErr != nil {
+				scope.SetTag(observability.TagFailedProviders, observability.List(fetchErr.Providers()))
+			}
+			utils.CaptureSentryException("jobGetLatestNewsError", hub, e)
+		})
+		job.alerter.Alert(job.name, "fetch", e)
 		return nil, e
 	}
 
@@ -244,13 +618,13 @@ func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sent
 		hashes[i] = n.ID
 	}
 
-	// TODO: Replace with ExistsByHashes
-	span := tx.StartChild("removeDuplicates.FindAllByHashes")
-	existsByHash, err := job.archivist.Entities.News.FindAllByHashes(ctx, hashes)
+	span := tx.StartChild("removeDuplicates.ExistsByHashes")
+	existedHashes, err := job.archivist.Entities.News.ExistsByHashes(ctx, hashes)
 	span.Finish()
 	if err != nil {
-		e := fmt.Errorf("[%s][removeDuplicates.FindAllByHashes]: %w", job.name, err)
+		e := fmt.Errorf("[%s][removeDuplicates.ExistsByHashes]: %w", job.name, err)
 		utils.CaptureSentryException("jobRemoveDuplicatesError", hub, e)
+		job.alerter.Alert(job.name, "removeDuplicates", e)
 		return nil, e
 	}
 
@@ -264,16 +638,13 @@ func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sent
 	if err != nil {
 		e := fmt.Errorf("[%s][removeDuplicates.FindAllByUrls]: %w", job.name, err)
 		utils.CaptureSentryException("jobRemoveDuplicatesError", hub, e)
+		job.alerter.Alert(job.name, "removeDuplicates", e)
 		return nil, e
 	}
 
 	span.Finish()
 
-	// Create array of hashes and urls of existed news for convenience
-	existedHashes := make([]string, len(existsByHash))
-	for i, n := range existsByHash {
-		existedHashes[i] = n.Hash
-	}
+	// Create array of urls of existed news for convenience
 	existedUrls := make([]string, len(existsByURL))
 	for i, n := range existsByURL {
 		existedUrls[i] = n.URL
@@ -283,7 +654,7 @@ func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sent
 
 	// create array without duplicates
 	for _, n := range news {
-		if slices.Contains(existedHashes, n.ID) {
+		if existedHashes[n.ID] {
 			continue
 		}
 
@@ -294,6 +665,8 @@ func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sent
 		result = append(result, n)
 	}
 
+	job.metrics.Count(metrics.NewsDuplicates, int64(len(news)-len(result)), job.metricsTag())
+
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
 		Category: "successful",
 		Message:  fmt.Sprintf("removeDuplicates returned %d news", len(news)),
@@ -303,22 +676,192 @@ func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sent
 	return result, nil
 }
 
+// applyFetchRules drops and flags the news by the fetch stage rules, so the dropped news don't reach the LLM.
+// Broken rules are reported, but don't stop the job.
+func (job *Job) applyFetchRules(tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) journalist.NewsList {
+	if job.fetchRules.Len() == 0 {
+		return news
+	}
+
+	span := tx.StartChild("applyFetchRules")
+	defer span.Finish()
+
+	result := make(journalist.NewsList, 0, len(news))
+	for _, n := range news {
+		decision, err := job.fetchRules.Evaluate(rules.News{
+			Job:         job.journalist.Name,
+			Provider:    n.ProviderName,
+			Title:       n.Title,
+			Description: n.Description,
+			URL:         n.Link,
+			Date:        n.Date,
+			Suspicious:  n.IsSuspicious,
+		})
+		if err != nil {
+			e := fmt.Errorf("[%s][applyFetchRules.Evaluate]: %w", job.name, err)
+			job.logger.Warn(e.Error())
+			utils.CaptureSentryException("jobRulesEvaluateError", hub, e)
+		}
+		if decision.Drop {
+			continue
+		}
+		if decision.Flag {
+			n.IsSuspicious = true
+		}
+		result = append(result, n)
+	}
+
+	return result
+}
+
+// reviewSuspicious unflags the news flagged by the suspicious keywords in place if the LLM doesn't score them
+// as spam or advertorial. Errors are reported, but don't stop the job, because the keyword flags are kept then.
+func (job *Job) reviewSuspicious(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) {
+	if job.options.suspiciousMin <= 0 {
+		return
+	}
+
+	span := tx.StartChild("reviewSuspicious.ReviewSuspicious")
+	start := time.Now()
+	_, err := job.composer.ReviewSuspicious(ctx, news, job.options.suspiciousMin)
+	job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", "suspicious"))
+	span.Finish()
+	if err != nil {
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "suspicious"))
+		e := fmt.Errorf("[%s][reviewSuspicious.ReviewSuspicious]: %w", job.name, err)
+		job.logger.InfoContext(ctx, e.Error())
+		utils.CaptureSentryException("jobReviewSuspiciousError", hub, e)
+		return
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "reviewSuspicious finished",
+		Level:    sentry.LevelInfo,
+	}, nil)
+}
+
+// fetchContent downloads the article texts of the news in place.
+// Errors are reported, but don't stop the job, because news can be composed by the description.
+func (job *Job) fetchContent(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) {
+	if job.content == nil {
+		return
+	}
+
+	span := tx.StartChild("fetchContent.FetchContent")
+	err := job.content.FetchContent(ctx, news)
+	span.Finish()
+	if err != nil {
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "content"))
+		e := fmt.Errorf("[%s][fetchContent.FetchContent]: %w", job.name, err)
+		job.logger.InfoContext(ctx, e.Error())
+		utils.CaptureSentryException("jobFetchContentError", hub, e)
+		return
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "fetchContent finished",
+		Level:    sentry.LevelInfo,
+	}, nil)
+}
+
+// resolveLinks replaces the links of the news with the canonical or archive URLs in place.
+// Errors are reported, but don't stop the job, because the news are published with their original links.
+func (job *Job) resolveLinks(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) {
+	if job.links == nil {
+		return
+	}
+
+	span := tx.StartChild("resolveLinks.ResolveLinks")
+	err := job.links.ResolveLinks(ctx, news)
+	span.Finish()
+	if err != nil {
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "links"))
+		e := fmt.Errorf("[%s][resolveLinks.ResolveLinks]: %w", job.name, err)
+		job.logger.InfoContext(ctx, e.Error())
+		utils.CaptureSentryException("jobResolveLinksError", hub, e)
+		return
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "resolveLinks finished",
+		Level:    sentry.LevelInfo,
+	}, nil)
+}
+
+// extractImageFigures extracts figures from the news images in place.
+// Errors are reported, but don't stop the job, because news can be composed without figures.
+func (job *Job) extractImageFigures(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) {
+	if !job.options.shouldComposeText || !job.options.shouldReadImages {
+		return
+	}
+
+	span := tx.StartChild("extractImageFigures.ExtractImageFigures")
+	start := time.Now()
+	err := job.composer.ExtractImageFigures(ctx, news)
+	job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", "vision"))
+	span.Finish()
+	if err != nil {
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "vision"))
+		e := fmt.Errorf("[%s][extractImageFigures.ExtractImageFigures]: %w", job.name, err)
+		job.logger.InfoContext(ctx, e.Error())
+		utils.CaptureSentryException("jobExtractImageFiguresError", hub, e)
+		return
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "extractImageFigures finished",
+		Level:    sentry.LevelInfo,
+	}, nil)
+}
+
+// composeOptions returns the compose options of the job.
+func (job *Job) composeOptions() composer.ComposeOptions {
+	return composer.ComposeOptions{
+		Style:        job.options.style,
+		Window:       job.options.freshness,
+		Lite:         job.options.breaking,
+		MaxSentences: job.options.maxSentences,
+		MaxChars:     job.options.maxChars,
+	}
+}
+
 // composeNews composes text for the article using OpenAI and finds meta.
 func (job *Job) composeNews(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) ([]*composer.ComposedNews, error) {
 	if !job.options.shouldComposeText {
 		return nil, nil
 	}
 
-	// TODO: Split openai jobs - 1: remove unnecessary news, 2: compose text
-	span := tx.StartChild("composeNews.Compose")
-	composedNews, err := job.composer.Compose(ctx, news)
+	if job.budget.BudgetExceeded() {
+		e := fmt.Errorf("[%s][composeNews.Compose]: %w", job.name, errBudgetExceeded)
+		job.logger.WarnContext(ctx, e.Error())
+		job.alerter.Alert(job.name, "budget", e)
+		return originalComposed(news), nil
+	}
+
+	stage := "compose"
+	if job.options.breaking {
+		stage = "compose_lite"
+	}
+
+	span := tx.StartChild("composeNews." + stage)
+	start := time.Now()
+	composedNews, err := job.composer.ComposeWith(ctx, news, job.composeOptions())
+	job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", stage))
 	span.Finish()
 	if err != nil {
-		e := fmt.Errorf("[%s][composeNews.Compose]: %w", job.name, err)
+		job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", stage))
+		e := fmt.Errorf("[%s][composeNews.%s]: %w", job.name, stage, err)
 		utils.CaptureSentryException("jobComposeNewsError", hub, e)
+		job.alerter.Alert(job.name, "compose", e)
 		return nil, e
 	}
 
+	job.metrics.Count(metrics.NewsComposed, int64(len(composedNews)), job.metricsTag())
+
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
 		Category: "successful",
 		Message:  fmt.Sprintf("composeNews returned %d news", len(composedNews)),
@@ -353,22 +896,32 @@ func (job *Job) saveNews(
 	for i, n := range news {
 		dbNews[i] = &archivist.News{
 			Hash:          n.ID,
-			ChannelID:     job.publisher.ChannelID,
+			ChannelID:     job.publisher.ChatID(job.options.channel),
+			Channel:       job.options.channel,
+			JobName:       job.journalist.Name,
 			ProviderName:  n.ProviderName,
 			OriginalTitle: n.Title,
 			OriginalDesc:  n.Description,
 			OriginalDate:  n.Date,
 			URL:           n.Link,
+			ImageURL:      imageURL(n),
+			Content:       n.Content,
 			IsSuspicious:  n.IsSuspicious,
 			IsFiltered:    n.IsFiltered,
+			State:         archivist.NewsStateSaved,
 		}
 
 		// Save composed text and meta if found in the map
 		if val, ok := composedNewsMap[n.ID]; ok {
 			meta, err := json.Marshal(composer.ComposedMeta{
-				Tickers:  val.Tickers,
-				Markets:  val.Markets,
-				Hashtags: val.Hashtags,
+				Tickers:    val.Tickers,
+				Markets:    val.Markets,
+				Hashtags:   val.Hashtags,
+				Sentiment:  val.Sentiment,
+				Importance: val.Importance,
+				Entities:   val.Entities,
+				Sources:    val.Sources,
+				Unverified: val.Unverified,
 			})
 			if err != nil {
 				return nil, fmt.Errorf("[Job.saveNews][json.Marshal] meta: %w", err)
@@ -379,22 +932,28 @@ func (job *Job) saveNews(
 		}
 	}
 
-	span := tx.StartChild("saveNews.News.Create")
-	err := job.archivist.Entities.News.Create(ctx, dbNews)
+	// Only the news inserted by this run are published, the ones saved in between by the overlapping
+	// run or another instance are theirs to publish
+	span := tx.StartChild("saveNews.News.Upsert")
+	inserted, err := job.archivist.Entities.News.Upsert(ctx, dbNews)
 	span.Finish()
 	if err != nil {
-		e := fmt.Errorf("[%s][saveNews.News.Create]: %w", job.name, err)
+		e := fmt.Errorf("[%s][saveNews.News.Upsert]: %w", job.name, err)
 		utils.CaptureSentryException("jobSaveNewsError", hub, e)
+		job.alerter.Alert(job.name, "save", e)
 		return nil, e
 	}
+	if skipped := len(dbNews) - len(inserted); skipped > 0 {
+		job.logger.InfoContext(ctx, fmt.Sprintf("[%s][saveNews] Skipped %d news saved concurrently", job.name, skipped))
+	}
 
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
 		Category: "successful",
-		Message:  fmt.Sprintf("saveNews returned %d news", len(dbNews)),
+		Message:  fmt.Sprintf("saveNews returned %d news", len(inserted)),
 		Level:    sentry.LevelInfo,
 	}, nil)
 
-	return dbNews, nil
+	return inserted, nil
 }
 
 // prepublishFilter final filter before publishing which will use all options and gathered info from previous steps.
@@ -404,6 +963,7 @@ func (job *Job) prepublishFilter(
 	news []*archivist.News,
 ) ([]*archivist.News, error) {
 	filteredNews := make([]*archivist.News, 0, len(news))
+	importances := make(map[*archivist.News]int, len(news))
 	span := tx.StartChild("prepublishFilter")
 
 NewsRange:
@@ -424,6 +984,7 @@ NewsRange:
 		if err != nil {
 			e := fmt.Errorf("[Job.publish][json.Unmarshal] meta: %w. Value: %v", err, n.MetaData)
 			utils.CaptureSentryException("jobPrepublishFilterError", hub, e)
+			job.alerter.Alert(job.name, "prepublishFilter", e)
 			return nil, e
 		}
 
@@ -449,6 +1010,11 @@ NewsRange:
 			}
 		}
 
+		// Skip news rated as less important if needed
+		if job.options.minImportance > 0 && meta.Importance != nil && *meta.Importance < job.options.minImportance {
+			continue
+		}
+
 		// Omit if all keys are empty and omitIfAllKeysEmpty is set
 		if job.options.omitIfAllKeysEmpty &&
 			len(meta.Tickers) == 0 &&
@@ -457,9 +1023,43 @@ NewsRange:
 			continue
 		}
 
+		// Route news to the channel by its meta
+		if channel := job.router.Route(meta); channel != "" {
+			n.ChannelID = job.publisher.ChatID(channel)
+			n.Channel = channel
+		}
+
+		// Apply operator-defined rules (can override the route)
+		if job.rules.Len() > 0 {
+			decision, err := job.rules.Evaluate(job.rulesNews(n, meta))
+			if err != nil {
+				// Broken rule should not stop the publication, so just report it
+				e := fmt.Errorf("[%s][prepublishFilter.Evaluate]: %w", job.name, err)
+				job.logger.Warn(e.Error())
+				utils.CaptureSentryException("jobRulesEvaluateError", hub, e)
+			}
+			if decision.Drop {
+				continue
+			}
+			if decision.Channel != "" {
+				n.ChannelID = job.publisher.ChatID(decision.Channel)
+				n.Channel = decision.Channel
+			}
+			n.Priority = decision.Priority
+		}
+
+		importances[n] = importance(meta)
 		filteredNews = append(filteredNews, n)
 	}
 
+	// News with higher priority are published first, the biggest stories first within the same priority
+	slices.SortStableFunc(filteredNews, func(a, b *archivist.News) int {
+		if a.Priority != b.Priority {
+			return b.Priority - a.Priority
+		}
+		return importances[b] - importances[a]
+	})
+
 	span.Finish()
 
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
@@ -472,47 +1072,208 @@ NewsRange:
 }
 
 // publish publishes the news to the channel and updates dbNews with PublicationID and PublishedAt fields.
+// The publication state of each news is saved right before and after sending it, so only the news
+// being sent at the moment of a crash can't be recovered (see Job.RecoverPublications).
+// The news already published to the chat by another run are skipped (see Job.claimPublication).
+// News failed to be published are saved to the dead letters and the rest of the news are published,
+// all news are left queued if the dead letters are not saved (see Job.deadLetter).
 func (job *Job) publish(
+	ctx context.Context,
 	tx *sentry.Span,
 	hub *sentry.Hub,
 	news []*archivist.News,
 ) ([]*archivist.News, error) {
 	updatedNews := make([]*archivist.News, 0, len(news))
+	var failed []error
 
 	for _, n := range news {
-		// Format news
-		var formattedText string
-		if job.options.shouldComposeText {
-			formattedText = formatNewsWithComposedMeta(*n)
-		} else {
-			formattedText = n.OriginalTitle + "\n" + n.OriginalDesc
+		// The rest of the news stay queued and are published by the recovery
+		if err := ctx.Err(); err != nil {
+			job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "publish"))
+			e := fmt.Errorf("[Job.publish]: %d news left unpublished: %w", len(news)-len(updatedNews)-len(failed), err)
+			utils.CaptureSentryException("jobPublishError", hub, e)
+			return updatedNews, errors.Join(append(failed, e)...)
+		}
+
+		chatID := job.publisher.ChatID(n.ChannelID)
+		claimed, pubID, err := job.claimPublication(ctx, hub, n.Hash, chatID)
+		if err != nil {
+			return updatedNews, err
+		}
+		if !claimed {
+			if pubID != "" && n.PublicationID == "" {
+				// Published by another run that failed to save the news
+				n.PublicationID = pubID
+				n.State = archivist.NewsStatePublished
+				_ = job.persistNews(ctx, hub, n)
+			}
+			continue
+		}
+
+		formattedText, changes := job.formatNews(ctx, n)
+
+		n.State = archivist.NewsStatePublishing
+		if err := job.persistNews(ctx, hub, n); err != nil {
+			return updatedNews, err
 		}
 
 		span := tx.StartChild("publish.Publish")
-		span.SetTag("news_hash", n.Hash)
-		id, err := job.publisher.Publish(formattedText)
+		span.SetTag(observability.TagNewsHash, n.Hash)
+		start := time.Now()
+		id, err := job.send(ctx, span, newsChannel(n), *n, formattedText, changes)
+		job.metrics.Timing(metrics.PublisherLatency, time.Since(start), job.metricsTag())
 		span.Finish()
 
 		if err != nil {
+			job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "publish"))
 			e := fmt.Errorf("[Job.publish][publisher.Publish]: %w", err)
 			utils.CaptureSentryException("jobPublishError", hub, e)
-			return nil, e
+			job.alerter.Alert(job.name, "publish", e)
+
+			// Message is not sent, so it can be published by the dead letters retry or the recovery
+			job.releasePublication(ctx, hub, n.Hash, chatID)
+			if job.deadLetter(ctx, hub, n, chatID, err) {
+				failed = append(failed, e)
+				continue
+			}
+			n.State = archivist.NewsStateQueued
+			_ = job.persistNews(ctx, hub, n)
+			return updatedNews, errors.Join(append(failed, e)...)
 		}
 
 		// Save publication data to the entity
 		n.PublicationID = id
 		n.PublishedAt = time.Now()
+		n.Publications = job.mirror(tx, hub, formattedText, job.newsMedia(*n), id)
+		n.State = archivist.NewsStatePublished
+		job.collectEmail(*n)
+		// Error is reported, the news is already published anyway
+		_ = job.completePublication(ctx, hub, n.Hash, chatID, id, n)
 
 		updatedNews = append(updatedNews, n)
 	}
 
+	job.metrics.Count(metrics.NewsPublished, int64(len(updatedNews)), job.metricsTag())
+
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
 		Category: "successful",
 		Message:  fmt.Sprintf("publishNews returned %d news", len(updatedNews)),
 		Level:    sentry.LevelInfo,
 	}, nil)
 
-	return updatedNews, nil
+	return updatedNews, errors.Join(failed...)
+}
+
+// send publishes the news to the channel as the formatted message, as the thread of messages
+// if the text is too long or as the plain text. Retries of the failed requests stop when the context is done.
+func (job *Job) send(
+	ctx context.Context,
+	span *sentry.Span,
+	channel string,
+	n archivist.News,
+	text string,
+	changes map[string]float64,
+) (string, error) {
+	p := job.publisher.WithContext(ctx)
+	media := job.newsMedia(n)
+	if job.options.shouldComposeText && p.Formatter != nil {
+		return p.PublishMessageWithMedia(channel, job.message(n, changes), media)
+	}
+	if maxLen := job.options.threadMaxLength; maxLen > 0 && len(text) > maxLen {
+		span.SetTag("thread", "true")
+		return p.PublishThread(channel, formatThread(text, maxLen))
+	}
+	return p.PublishWithMediaTo(channel, text, media)
+}
+
+// newsMedia returns the image of the news to publish with it (empty if the images are not attached)
+// and the link to the original news for the targets that truncate the message.
+func (job *Job) newsMedia(n archivist.News) publisher.Media {
+	media := publisher.Media{Link: n.URL}
+	if job.options.shouldAttachImages {
+		media.URL = n.ImageURL
+	}
+	return media
+}
+
+// formatNews formats the text of the news for publication and returns the day price changes of its tickers
+// (only for the composed news).
+func (job *Job) formatNews(ctx context.Context, n *archivist.News) (string, map[string]float64) {
+	if !job.options.shouldComposeText {
+		return n.OriginalTitle + "\n" + n.OriginalDesc, nil
+	}
+
+	changes := job.tickerChanges(ctx, *n)
+	text := formatNewsWithComposedMeta(*n) + formatChanges(changes)
+	if job.options.sentimentMin > 0 {
+		text = formatSentiment(*n, job.options.sentimentMin) + text
+	}
+	if job.options.breaking {
+		text = breakingPrefix + text
+	}
+	return text, changes
+}
+
+// message returns the formatted message of the composed news with the day price changes of its tickers.
+func (job *Job) message(n archivist.News, changes map[string]float64) publisher.Message {
+	msg := newsMessage(n, job.options.sentimentMin)
+	if job.options.breaking {
+		msg.Headline = breakingPrefix + msg.Headline
+	}
+	msg.Changes = changes
+	return msg
+}
+
+// mirror publishes the news to the additional targets and returns publication IDs from all targets (including Telegram).
+// Mirroring errors are reported, but don't stop the job, because the news is already published to the main channel.
+func (job *Job) mirror(tx *sentry.Span, hub *sentry.Hub, text string, media publisher.Media, telegramID string) datatypes.JSON {
+	ids := map[string]string{telegramTarget: telegramID}
+
+	if job.mirrors.Len() > 0 {
+		span := tx.StartChild("publish.Mirror")
+		mirrored, err := job.mirrors.PublishAllWithMedia(text, media)
+		span.Finish()
+		if err != nil {
+			job.reportMirrorError(hub, "mirror.PublishAll", err)
+		}
+		for target, id := range mirrored {
+			ids[target] = id
+		}
+	}
+
+	publications, _ := json.Marshal(ids)
+	return publications
+}
+
+// queueNews saves the queued state and the target channel of the news selected for publication.
+func (job *Job) queueNews(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	dbNews []*archivist.News,
+) error {
+	for _, n := range dbNews {
+		n.State = archivist.NewsStateQueued
+	}
+
+	return job.updateNews(ctx, tx, hub, dbNews)
+}
+
+// persistNews saves the publication state and data of the single news to the database.
+func (job *Job) persistNews(ctx context.Context, hub *sentry.Hub, n *archivist.News) error {
+	if !job.options.shouldSaveToDB {
+		return nil
+	}
+
+	err := job.archivist.Entities.News.Update(ctx, n)
+	if err != nil {
+		e := fmt.Errorf("[%s][persistNews.News.Update]: %w", job.name, err)
+		utils.CaptureSentryException("jobUpdateNewsError", hub, e)
+		job.alerter.Alert(job.name, "update", e)
+		return e
+	}
+
+	return nil
 }
 
 // updateNews updates news in the database.
@@ -526,17 +1287,14 @@ func (job *Job) updateNews(
 		return nil
 	}
 
-	for _, n := range dbNews {
-		// TODO: add update many method to archivist with transaction
-		span := tx.StartChild("updateNews.News.Update")
-		span.SetTag("news_hash", n.Hash)
-		err := job.archivist.Entities.News.Update(ctx, n)
-		span.Finish()
-		if err != nil {
-			e := fmt.Errorf("[%s][updateNews.News.Update]: %w", job.name, err)
-			utils.CaptureSentryException("jobUpdateNewsError", hub, e)
-			return e
-		}
+	span := tx.StartChild("updateNews.News.UpdateMany")
+	err := job.archivist.Entities.News.UpdateMany(ctx, dbNews)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][updateNews.News.UpdateMany]: %w", job.name, err)
+		utils.CaptureSentryException("jobUpdateNewsError", hub, e)
+		job.alerter.Alert(job.name, "update", e)
+		return e
 	}
 
 	hub.AddBreadcrumb(&sentry.Breadcrumb{
@@ -548,6 +1306,77 @@ func (job *Job) updateNews(
 	return nil
 }
 
+// newsChannel returns the channel to publish the news to: the name of the routed channel, so its forum topic
+// is applied, or the chat ID for the news saved before the channel names.
+func newsChannel(n *archivist.News) string {
+	if n.Channel != "" {
+		return n.Channel
+	}
+	return n.ChannelID
+}
+
+// importance returns the importance of the composed news, 0 if it is not rated.
+func importance(meta composer.ComposedMeta) int {
+	if meta.Importance == nil {
+		return 0
+	}
+	return *meta.Importance
+}
+
+// rulesNews converts the news to the representation available in the rules expressions.
+func (job *Job) rulesNews(n *archivist.News, meta composer.ComposedMeta) rules.News {
+	return rules.News{
+		Job:         job.journalist.Name,
+		Provider:    n.ProviderName,
+		Title:       n.OriginalTitle,
+		Description: n.OriginalDesc,
+		Text:        n.ComposedText,
+		URL:         n.URL,
+		Date:        n.OriginalDate,
+		Suspicious:  n.IsSuspicious,
+		Unverified:  meta.Unverified,
+		Tickers:     meta.Tickers,
+		Markets:     meta.Markets,
+		Hashtags:    meta.Hashtags,
+		Entities:    meta.Entities.Names(),
+	}
+}
+
+// sentryTags returns the Sentry tags of the job configuration: journalist, providers, channel and enabled flags.
+func (job *Job) sentryTags() observability.Tags {
+	o := job.options
+	return observability.Tags{
+		observability.TagJob:        job.name,
+		observability.TagJournalist: job.journalist.Name,
+		observability.TagProviders:  observability.List(job.journalist.ProviderNames()),
+		observability.TagChannel:    job.publisher.ChatID(o.channel),
+		observability.TagFlags: observability.Flags(map[string]bool{
+			"compose_text":         o.shouldComposeText,
+			"breaking":             o.breaking,
+			"classify":             o.shouldClassify,
+			"review_suspicious":    o.suspiciousMin > 0,
+			"omit_suspicious":      o.omitSuspicious,
+			"omit_empty_meta":      o.omitEmptyMetaKeys != nil,
+			"omit_unlisted_stocks": o.omitUnlistedStocks,
+			"read_images":          o.shouldReadImages,
+			"attach_images":        o.shouldAttachImages,
+			"save_to_db":           o.shouldSaveToDB,
+			"remove_clones":        o.shouldRemoveClones,
+			"similarity_dedup":     o.similarityWindow > 0,
+			"cluster_stories":      o.clusterMethod != "",
+			"publish_queue":        o.queuePublications,
+			"translations":         len(o.translations) > 0,
+			"subscriptions":        len(o.subscriptions) > 0,
+			"limit_publications":   o.maxPublish > 0,
+		}),
+	}
+}
+
+// metricsTag returns the tag that identifies the job in metrics.
+func (job *Job) metricsTag() metrics.Tag {
+	return metrics.T("job", job.journalist.Name)
+}
+
 func formatNewsWithComposedMeta(n archivist.News) string {
 	if n.MetaData == nil {
 		return n.ComposedText
@@ -569,6 +1398,144 @@ func formatNewsWithComposedMeta(n archivist.News) string {
 	return result
 }
 
+// newsMessage returns the composed news for the publisher.MessageFormatter: original title as the headline
+// (with the sentiment emoji if minConfidence > 0), composed text, tickers, hashtags and the source link.
+func newsMessage(n archivist.News, minConfidence float64) publisher.Message {
+	m := publisher.Message{
+		Headline:   n.OriginalTitle,
+		Text:       n.ComposedText,
+		SourceName: n.ProviderName,
+		SourceURL:  n.URL,
+	}
+	if minConfidence > 0 {
+		m.Headline = formatSentiment(n, minConfidence) + m.Headline
+	}
+
+	var meta composer.ComposedMeta
+	if n.MetaData != nil && json.Unmarshal(n.MetaData, &meta) == nil {
+		m.Tickers = meta.Tickers
+		m.Hashtags = meta.Hashtags
+		for _, s := range meta.Sources {
+			m.Sources = append(m.Sources, publisher.Source{Name: s.Name, URL: s.URL})
+		}
+	}
+
+	return m
+}
+
+// tickerChanges returns the day price changes of the news tickers. Tickers without quotes are skipped,
+// so the news is published anyway. Returns nil if quotes are not enabled.
+func (job *Job) tickerChanges(ctx context.Context, n archivist.News) map[string]float64 {
+	if job.quotes == nil || n.MetaData == nil {
+		return nil
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil || len(meta.Tickers) == 0 {
+		return nil
+	}
+
+	changes := make(map[string]float64, len(meta.Tickers))
+	for _, t := range meta.Tickers {
+		quote, err := job.quotes.FetchQuote(ctx, t)
+		if err != nil {
+			job.logger.WarnContext(ctx, "[Job.tickerChanges] Error fetching quote", "job", job.name, "ticker", t, "error", err)
+			continue
+		}
+		changes[t] = quote.Change
+	}
+
+	return changes
+}
+
+// formatChanges returns the line with the day price changes of the tickers (e.g. "$AAPL +1.4%")
+// to be appended to the news text or empty string if there are no changes.
+func formatChanges(changes map[string]float64) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	tickers := make([]string, 0, len(changes))
+	for t := range changes {
+		tickers = append(tickers, t)
+	}
+	sort.Strings(tickers)
+
+	parts := make([]string, len(tickers))
+	for i, t := range tickers {
+		parts[i] = fmt.Sprintf("$%s %s", t, publisher.FormatChange(changes[t]))
+	}
+
+	return "\n\n" + strings.Join(parts, " ")
+}
+
+// formatSentiment returns the sentiment emoji prefix for the news text or empty string
+// if the sentiment is neutral, unknown or its confidence is lower than minConfidence.
+func formatSentiment(n archivist.News, minConfidence float64) string {
+	if n.MetaData == nil {
+		return ""
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil || meta.Sentiment == nil {
+		return ""
+	}
+	if meta.Sentiment.Confidence < minConfidence {
+		return ""
+	}
+
+	switch meta.Sentiment.Label {
+	case composer.SentimentBullish:
+		return "🟢 "
+	case composer.SentimentBearish:
+		return "🔴 "
+	default:
+		return ""
+	}
+}
+
+// breakingPrefix is the prefix of the breaking news text and headline.
+const breakingPrefix = "🚨 "
+
+// maxImageURLLength is the max length of archivist.News.ImageURL.
+const maxImageURLLength = 1024
+
+// imageURL returns the image URL of the news to save. Longer URLs are skipped, because the image is optional.
+func imageURL(n *journalist.News) string {
+	if len(n.ImageURL) > maxImageURLLength {
+		return ""
+	}
+	return n.ImageURL
+}
+
+// telegramTarget is the name of the main publication target in News.Publications.
+const telegramTarget = "telegram"
+
+// threadNumberingSize is the space reserved in the thread part for its number ("\n\n🧵 1/2").
+const threadNumberingSize = 16
+
+// formatThread splits the long text into numbered parts of at most maxLength bytes (including numbering).
+func formatThread(text string, maxLength int) []string {
+	parts := utils.SplitBySentences(text, maxLength-threadNumberingSize)
+	if len(parts) < 2 {
+		return parts
+	}
+
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s\n\n🧵 %d/%d", parts[i], i+1, len(parts))
+	}
+
+	return parts
+}
+
+// stageContext returns the context with the deadline of the stage, if it is set.
+func (job *Job) stageContext(ctx context.Context, s stage) (context.Context, context.CancelFunc) {
+	if timeout := job.options.stageTimeouts[s]; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // JobFunc is a type for job function that will be executed by the scheduler.
 type JobFunc func()
 
@@ -582,6 +1549,24 @@ const (
 	MetaHashtags metaKey = "Hashtags"
 )
 
+// stage is a type for the run stages with their own deadlines.
+type stage string
+
+// Stages of the news job run.
+const (
+	StageFetch   stage = "fetch"   // fetching news from the providers
+	StageCompose stage = "compose" // filtering, reading images and composing news with LLM
+	StagePublish stage = "publish" // publishing news to the channels
+)
+
+// Default deadlines of the news job run and its stages.
+const (
+	defaultTimeout        = 25 * time.Second
+	defaultFetchTimeout   = 15 * time.Second
+	defaultComposeTimeout = 20 * time.Second
+	defaultPublishTimeout = 0 // publish until the run deadline
+)
+
 // omitKeyOptions holds keys that will omit news if empty. Note: requires jobOptions.shouldComposeText to be true.
 type omitKeyOptions struct {
 	emptyTickers  bool // if true, will omit articles with empty tickers meta from composer.ComposedMeta
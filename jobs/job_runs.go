@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"time"
+)
+
+// newJobRun creates the audit record of the job run. err is the error of the stage the run stopped at.
+func newJobRun(jobName string, run RunInfo, finishedAt time.Time, err error) *archivist.JobRun {
+	r := &archivist.JobRun{
+		JobName:    jobName,
+		StartedAt:  run.StartedAt,
+		FinishedAt: finishedAt,
+		Fetched:    run.Fetched,
+		Deduped:    run.Deduped,
+		Composed:   run.Composed,
+		Published:  run.Published,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// saveJobRun persists the audit record of the job run. Errors are only reported, the run is not affected.
+func (job *Job) saveJobRun(ctx context.Context, hub *sentry.Hub, run RunInfo, err error) {
+	if !job.options.shouldSaveToDB {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if e := job.archivist.Entities.JobRuns.Create(ctx, newJobRun(job.journalist.Name, run, time.Now(), err)); e != nil {
+		e = fmt.Errorf("[%s][saveJobRun.Create]: %w", job.name, e)
+		job.logger.WarnContext(ctx, e.Error())
+		utils.CaptureSentryException("jobSaveJobRunError", hub, e)
+	}
+}
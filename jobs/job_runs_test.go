@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewJobRun(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Second)
+	run := RunInfo{StartedAt: start, Fetched: 10, Deduped: 6, Composed: 4, Published: 3}
+
+	tests := []struct {
+		name string
+		err  error
+		want *archivist.JobRun
+	}{
+		{
+			name: "successful run",
+			want: &archivist.JobRun{
+				JobName: "MarketNews", StartedAt: start, FinishedAt: end,
+				Fetched: 10, Deduped: 6, Composed: 4, Published: 3,
+			},
+		},
+		{
+			name: "failed run",
+			err:  errors.New("publish failed"),
+			want: &archivist.JobRun{
+				JobName: "MarketNews", StartedAt: start, FinishedAt: end,
+				Fetched: 10, Deduped: 6, Composed: 4, Published: 3, Error: "publish failed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newJobRun("MarketNews", run, end, tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newJobRun() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
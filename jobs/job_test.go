@@ -3,13 +3,21 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/pkg/rules"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"log/slog"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_formatNewsWithComposedMeta(t *testing.T) {
@@ -70,10 +78,193 @@ func Test_formatNewsWithComposedMeta(t *testing.T) {
 	}
 }
 
+func Test_formatSentiment(t *testing.T) {
+	meta := func(s *composer.Sentiment) []byte {
+		d, _ := json.Marshal(composer.ComposedMeta{Sentiment: s})
+		return d
+	}
+	tests := []struct {
+		name string
+		meta []byte
+		want string
+	}{
+		{
+			name: "bullish",
+			meta: meta(&composer.Sentiment{Label: composer.SentimentBullish, Confidence: 0.9}),
+			want: "🟢 ",
+		},
+		{
+			name: "bearish",
+			meta: meta(&composer.Sentiment{Label: composer.SentimentBearish, Confidence: 0.6}),
+			want: "🔴 ",
+		},
+		{
+			name: "low confidence",
+			meta: meta(&composer.Sentiment{Label: composer.SentimentBullish, Confidence: 0.5}),
+		},
+		{
+			name: "neutral",
+			meta: meta(&composer.Sentiment{Label: composer.SentimentNeutral, Confidence: 1}),
+		},
+		{
+			name: "no sentiment",
+			meta: meta(nil),
+		},
+		{
+			name: "no meta",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSentiment(archivist.News{MetaData: tt.meta}, 0.6); got != tt.want {
+				t.Errorf("formatSentiment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newsMessage(t *testing.T) {
+	meta, _ := json.Marshal(composer.ComposedMeta{
+		Tickers:   []string{"AAPL"},
+		Hashtags:  []string{"earnings"},
+		Sentiment: &composer.Sentiment{Label: composer.SentimentBullish, Confidence: 0.9},
+	})
+	n := archivist.News{
+		OriginalTitle: "Apple beats estimates",
+		ComposedText:  "AAPL revenue rose 2%.",
+		ProviderName:  "cnbc",
+		URL:           "https://www.cnbc.com/apple",
+		MetaData:      meta,
+	}
+
+	want := publisher.Message{
+		Headline:   "Apple beats estimates",
+		Text:       "AAPL revenue rose 2%.",
+		Tickers:    []string{"AAPL"},
+		Hashtags:   []string{"earnings"},
+		SourceName: "cnbc",
+		SourceURL:  "https://www.cnbc.com/apple",
+	}
+	if got := newsMessage(n, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("newsMessage() = %+v, want %+v", got, want)
+	}
+
+	want.Headline = "🟢 Apple beats estimates"
+	if got := newsMessage(n, 0.6); !reflect.DeepEqual(got, want) {
+		t.Errorf("newsMessage() with sentiment = %+v, want %+v", got, want)
+	}
+}
+
+func TestJob_message(t *testing.T) {
+	n := archivist.News{OriginalTitle: "Fed cuts rates", ComposedText: "Fed cut rates by 50 bps."}
+	changes := map[string]float64{"SPY": 0.01}
+
+	tests := []struct {
+		name         string
+		job          *Job
+		wantHeadline string
+	}{
+		{
+			name:         "regular news",
+			job:          &Job{options: &jobOptions{}},
+			wantHeadline: "Fed cuts rates",
+		},
+		{
+			name:         "breaking news",
+			job:          (&Job{options: &jobOptions{}}).Breaking(),
+			wantHeadline: "🚨 Fed cuts rates",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.job.message(n, changes)
+			if got.Headline != tt.wantHeadline {
+				t.Errorf("message() headline = %q, want %q", got.Headline, tt.wantHeadline)
+			}
+			if !reflect.DeepEqual(got.Changes, changes) {
+				t.Errorf("message() changes = %v, want %v", got.Changes, changes)
+			}
+		})
+	}
+}
+
+// quotesStub returns the quotes from the map, unknown tickers fail.
+type quotesStub map[string]float64
+
+func (q quotesStub) FetchQuote(_ context.Context, ticker string) (*marketdata.Quote, error) {
+	change, ok := q[ticker]
+	if !ok {
+		return nil, errors.New("unknown ticker")
+	}
+	return &marketdata.Quote{Ticker: ticker, Change: change}, nil
+}
+
+func TestJob_tickerChanges(t *testing.T) {
+	meta, _ := json.Marshal(composer.ComposedMeta{Tickers: []string{"AAPL", "MSFT", "UNKNOWN"}})
+	n := archivist.News{ComposedText: "AAPL and MSFT rally", MetaData: meta}
+	job := &Job{name: "test", logger: slog.Default(), quotes: quotesStub{"AAPL": 0.014, "MSFT": -0.003}}
+
+	changes := job.tickerChanges(context.Background(), n)
+	want := map[string]float64{"AAPL": 0.014, "MSFT": -0.003}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("tickerChanges() = %v, want %v", changes, want)
+	}
+	if got, want := formatChanges(changes), "\n\n$AAPL +1.4% $MSFT -0.3%"; got != want {
+		t.Errorf("formatChanges() = %q, want %q", got, want)
+	}
+
+	job.quotes = nil
+	if changes := job.tickerChanges(context.Background(), n); changes != nil || formatChanges(changes) != "" {
+		t.Errorf("tickerChanges() without quotes = %v, want nil", changes)
+	}
+}
+
+func Test_formatThread(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      []string
+	}{
+		{
+			name:      "numbered parts",
+			text:      "Fed holds rates steady. Powell signals cuts later this year. Markets rally.",
+			maxLength: 60,
+			want: []string{
+				"Fed holds rates steady.\n\n🧵 1/3",
+				"Powell signals cuts later this year.\n\n🧵 2/3",
+				"Markets rally.\n\n🧵 3/3",
+			},
+		},
+		{
+			name:      "single part is not numbered",
+			text:      "Fed holds rates steady.",
+			maxLength: 60,
+			want:      []string{"Fed holds rates steady."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatThread(tt.text, tt.maxLength)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("formatThread() = %q, want %q", got, tt.want)
+			}
+			for _, part := range got {
+				if len(part) > tt.maxLength {
+					t.Errorf("formatThread() part %q is longer than %d", part, tt.maxLength)
+				}
+			}
+		})
+	}
+}
+
 func TestJob_prepublishFilter(t *testing.T) {
 	type fields struct {
-		stocks  *stocks.StockMap
-		options *jobOptions
+		stocks    *stocks.StockMap
+		rules     *rules.Set
+		router    *Router
+		publisher *publisher.TelegramPublisher
+		options   *jobOptions
 	}
 	type args struct {
 		news []*archivist.News
@@ -86,8 +277,22 @@ func TestJob_prepublishFilter(t *testing.T) {
 		Tickers: []string{"PLTR"},
 	})
 	emptyMeta, _ := json.Marshal(composer.ComposedMeta{})
+	rated := func(importance int) []byte {
+		meta, _ := json.Marshal(composer.ComposedMeta{Tickers: []string{"AAPL"}, Importance: &importance})
+		return meta
+	}
+	minorMeta, majorMeta, topMeta := rated(10), rated(60), rated(90)
 
 	okID := uuid.New()
+	priorityID := uuid.New()
+
+	ruleSet, err := rules.Compile([]rules.Rule{
+		{Name: "drop-pltr", When: `"PLTR" in news.tickers`, Drop: true},
+		{Name: "priority", When: `news.text contains "urgent"`, Priority: 10, Channel: "@urgent"},
+	})
+	if err != nil {
+		t.Fatalf("rules.Compile() error = %v", err)
+	}
 
 	tests := []struct {
 		name    string
@@ -299,12 +504,129 @@ func TestJob_prepublishFilter(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Apply rules",
+			fields: fields{
+				stocks:  nil,
+				rules:   ruleSet,
+				options: &jobOptions{},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:           okID,
+						ChannelID:    "@default",
+						ComposedText: "Some AAPL news.",
+						MetaData:     d1,
+					},
+					{
+						ID:           uuid.New(),
+						ChannelID:    "@default",
+						ComposedText: "Some PLTR news.",
+						MetaData:     d2,
+					},
+					{
+						ID:           priorityID,
+						Hash:         "urgent",
+						ChannelID:    "@default",
+						ComposedText: "Some urgent AAPL news.",
+						MetaData:     d1,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           priorityID,
+					Hash:         "urgent",
+					ChannelID:    "@urgent",
+					Channel:      "@urgent",
+					ComposedText: "Some urgent AAPL news.",
+					MetaData:     d1,
+					Priority:     10,
+				},
+				{
+					ID:           okID,
+					ChannelID:    "@default",
+					ComposedText: "Some AAPL news.",
+					MetaData:     d1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Route to named channels",
+			fields: fields{
+				router: NewRouter([]ChannelRoute{{Channel: "apple", Tickers: []string{"AAPL"}}}),
+				publisher: (&publisher.TelegramPublisher{ChannelID: "@default"}).
+					WithChannels(map[string]string{"apple": "@apple_news"}),
+				options: &jobOptions{},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:           okID,
+						ChannelID:    "@default",
+						ComposedText: "Some AAPL news.",
+						MetaData:     d1,
+					},
+					{
+						ID:           priorityID,
+						ChannelID:    "@default",
+						ComposedText: "Some PLTR news.",
+						MetaData:     d2,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           okID,
+					ChannelID:    "@apple_news",
+					Channel:      "apple",
+					ComposedText: "Some AAPL news.",
+					MetaData:     d1,
+				},
+				{
+					ID:           priorityID,
+					ChannelID:    "@default",
+					ComposedText: "Some PLTR news.",
+					MetaData:     d2,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Skip minor news and publish the biggest story first",
+			fields: fields{
+				stocks: nil,
+				options: &jobOptions{
+					minImportance: 30,
+				},
+			},
+			args: args{
+				news: []*archivist.News{
+					{Hash: "minor", MetaData: minorMeta},
+					{Hash: "major", MetaData: majorMeta},
+					{Hash: "unrated", MetaData: d1},
+					{Hash: "top", MetaData: topMeta},
+				},
+			},
+			want: []*archivist.News{
+				{Hash: "top", MetaData: topMeta},
+				{Hash: "major", MetaData: majorMeta},
+				{Hash: "unrated", MetaData: d1},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			job := &Job{
-				stocks:  tt.fields.stocks,
-				options: tt.fields.options,
+				journalist: journalist.NewJournalist("test", nil),
+				stocks:     tt.fields.stocks,
+				rules:      tt.fields.rules,
+				router:     tt.fields.router,
+				publisher:  tt.fields.publisher,
+				options:    tt.fields.options,
 			}
 			tx := sentry.StartTransaction(context.Background(), "test")
 			hub := sentry.CurrentHub().Clone()
@@ -320,3 +642,182 @@ func TestJob_prepublishFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestJob_applyFetchRules(t *testing.T) {
+	ruleSet, err := rules.Compile([]rules.Rule{
+		{Name: "suspicious", Stage: rules.StageFetch, Keywords: []string{"sponsored"}, Flag: true},
+		{Name: "reddit-memes", Stage: rules.StageFetch, Providers: []string{"reddit"}, When: `news.title matches "(?i)yolo"`, Drop: true},
+		{Name: "tesla", When: `"TSLA" in news.tickers`, Drop: true},
+	})
+	if err != nil {
+		t.Fatalf("rules.Compile() error = %v", err)
+	}
+
+	news := journalist.NewsList{
+		{ID: "ok", Title: "Fed holds rates", ProviderName: "rss"},
+		{ID: "flagged", Title: "Sponsored: best stocks to buy", ProviderName: "rss"},
+		{ID: "dropped", Title: "YOLO on calls", ProviderName: "reddit"},
+		{ID: "other-provider", Title: "YOLO is not a strategy", ProviderName: "rss"},
+	}
+
+	job := (&Job{
+		journalist: journalist.NewJournalist("test", nil),
+		logger:     slog.Default(),
+	}).WithRules(ruleSet)
+	tx := sentry.StartTransaction(context.Background(), "test")
+	hub := sentry.CurrentHub().Clone()
+
+	got := job.applyFetchRules(tx, hub, news)
+
+	var ids, flagged []string
+	for _, n := range got {
+		ids = append(ids, n.ID)
+		if n.IsSuspicious {
+			flagged = append(flagged, n.ID)
+		}
+	}
+	if want := []string{"ok", "flagged", "other-provider"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("applyFetchRules() = %v, want %v", ids, want)
+	}
+	if want := []string{"flagged"}; !reflect.DeepEqual(flagged, want) {
+		t.Errorf("applyFetchRules() flagged = %v, want %v", flagged, want)
+	}
+	if job.rules.Len() != 1 {
+		t.Errorf("publish rules len = %d, want 1", job.rules.Len())
+	}
+}
+
+type fakeMirror struct {
+	id  string
+	err error
+}
+
+func (f *fakeMirror) Publish(_ string) (string, error) {
+	return f.id, f.err
+}
+
+func (f *fakeMirror) PublishWithMedia(_ string, _ publisher.Media) (string, error) {
+	return f.id, f.err
+}
+
+func (f *fakeMirror) UpdatePublication(_, _ string) error {
+	return f.err
+}
+
+func (f *fakeMirror) DeletePublication(_ string) error {
+	return f.err
+}
+
+func TestJob_mirror(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors *publisher.MultiPublisher
+		want    string
+	}{
+		{
+			name:    "no mirrors",
+			mirrors: nil,
+			want:    `{"telegram":"1"}`,
+		},
+		{
+			name: "mirrored to all targets",
+			mirrors: publisher.NewMultiPublisher().
+				Add("discord", &fakeMirror{id: "d1"}),
+			want: `{"discord":"d1","telegram":"1"}`,
+		},
+		{
+			name: "failed mirror is skipped",
+			mirrors: publisher.NewMultiPublisher().
+				Add("discord", &fakeMirror{err: errors.New("webhook not found")}),
+			want: `{"telegram":"1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{
+				journalist: journalist.NewJournalist("test", nil),
+				logger:     slog.Default(),
+				metrics:    metrics.Noop{},
+				mirrors:    tt.mirrors,
+			}
+			tx := sentry.StartTransaction(context.Background(), "test")
+			hub := sentry.CurrentHub().Clone()
+
+			if got := job.mirror(tx, hub, "news", publisher.Media{}, "1"); string(got) != tt.want {
+				t.Errorf("mirror() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_stageContext(t *testing.T) {
+	job := &Job{options: &jobOptions{}}
+	job.WithStageTimeout(StageCompose, time.Minute)
+
+	ctx, cancel := job.stageContext(context.Background(), StageCompose)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("stageContext(compose) deadline = %v, %v, want within a minute", deadline, ok)
+	}
+
+	ctx, cancel = job.stageContext(context.Background(), StagePublish)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("stageContext(publish) has deadline, want none")
+	}
+}
+
+// newsProviderFunc is the journalist.NewsProvider for tests.
+type newsProviderFunc func(ctx context.Context, until time.Time) (journalist.NewsList, error)
+
+func (f newsProviderFunc) Fetch(ctx context.Context, until time.Time) (journalist.NewsList, error) {
+	return f(ctx, until)
+}
+
+func TestJob_getLatestNews(t *testing.T) {
+	ok := newsProviderFunc(func(context.Context, time.Time) (journalist.NewsList, error) {
+		return journalist.NewsList{{Title: "Fed holds rates"}}, nil
+	})
+	failing := newsProviderFunc(func(context.Context, time.Time) (journalist.NewsList, error) {
+		return nil, errors.New("boom")
+	})
+
+	tests := []struct {
+		name      string
+		providers []journalist.NewsProvider
+		wantNews  int
+		wantErr   bool
+	}{
+		{
+			name:      "partial results are processed",
+			providers: []journalist.NewsProvider{ok, failing},
+			wantNews:  1,
+		},
+		{
+			name:      "all providers failed",
+			providers: []journalist.NewsProvider{failing, failing},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{
+				name:       "test",
+				journalist: journalist.NewJournalist("test", tt.providers),
+				logger:     slog.Default(),
+				metrics:    metrics.Noop{},
+				options:    &jobOptions{},
+			}
+			tx := sentry.StartTransaction(context.Background(), "test")
+			hub := sentry.CurrentHub().Clone()
+
+			got, err := job.getLatestNews(context.Background(), tx, hub)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getLatestNews() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.wantNews {
+				t.Errorf("getLatestNews() got %d news, want %d", len(got), tt.wantNews)
+			}
+		})
+	}
+}
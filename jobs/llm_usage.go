@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// errBudgetExceeded is reported when the compose stage is paused by the monthly LLM budget.
+var errBudgetExceeded = errors.New("monthly LLM budget is exceeded, news are published with the original titles")
+
+// LLMUsageJob collects the token usage of the LLM requests in memory and periodically saves
+// it as daily aggregates. It also guards the monthly budget: the spend estimate is the saved spend
+// of the month plus the usage that is not saved yet.
+type LLMUsageJob struct {
+	archivist *archivist.Archivist // archivist that will save the usage to the database
+	budget    float64              // monthly budget in USD, 0 for unlimited
+	logger    *slog.Logger         // special logger for the job
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*archivist.LLMUsage // not saved usage by day, provider and model
+	spent   float64                        // saved spend of the current month
+	month   time.Time                      // month of the saved spend
+}
+
+func NewLLMUsageJob(arch *archivist.Archivist) *LLMUsageJob {
+	return &LLMUsageJob{
+		archivist: arch,
+		logger:    logging.For(logging.ModuleJobs),
+		now:       time.Now,
+		buckets:   make(map[string]*archivist.LLMUsage),
+	}
+}
+
+// WithMonthlyBudget sets the monthly budget in USD, 0 for unlimited.
+func (j *LLMUsageJob) WithMonthlyBudget(budget float64) *LLMUsageJob {
+	j.budget = budget
+	return j
+}
+
+// Observe is the composer.UsageObserver that registers the token usage of the request.
+func (j *LLMUsageJob) Observe(u composer.Usage) {
+	day := j.now().UTC().Truncate(24 * time.Hour)
+	key := day.Format(time.DateOnly) + "|" + u.Provider + "|" + u.Model
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	b, ok := j.buckets[key]
+	if !ok {
+		b = &archivist.LLMUsage{Day: day, Provider: u.Provider, Model: u.Model}
+		j.buckets[key] = b
+	}
+	b.Requests++
+	b.PromptTokens += int64(u.PromptTokens)
+	b.CompletionTokens += int64(u.CompletionTokens)
+	b.Cost += u.Cost()
+}
+
+// BudgetExceeded returns true if the monthly spend estimate reached the budget.
+// It is safe to call on nil LLMUsageJob.
+func (j *LLMUsageJob) BudgetExceeded() bool {
+	if j == nil || j.budget <= 0 {
+		return false
+	}
+	return j.MonthlySpend() >= j.budget
+}
+
+// MonthlySpend returns the spend estimate of the current month in USD.
+func (j *LLMUsageJob) MonthlySpend() float64 {
+	month := startOfMonth(j.now())
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var spend float64
+	if j.month.Equal(month) {
+		spend = j.spent
+	}
+	for _, b := range j.buckets {
+		if !b.Day.Before(month) {
+			spend += b.Cost
+		}
+	}
+	return spend
+}
+
+// Run saves the collected usage to the database and refreshes the saved spend of the month.
+func (j *LLMUsageJob) Run() JobFunc {
+	return func() {
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 10*time.Second)
+		defer cancel()
+
+		hub := sentry.CurrentHub().Clone()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		usage := j.takeBuckets()
+		if err := j.archivist.Entities.LLMUsage.Increment(ctx, usage); err != nil {
+			// Usage is counted again on the next run, so the budget is not bypassed by the database errors
+			j.restoreBuckets(usage)
+			e := fmt.Errorf("[job-llm-usage] Error saving LLM usage: %w", err)
+			j.logger.WarnContext(ctx, e.Error())
+			utils.CaptureSentryException("llmUsageJobSaveError", hub, e)
+			return
+		}
+
+		if err := j.LoadSpend(ctx); err != nil {
+			j.logger.WarnContext(ctx, err.Error())
+			utils.CaptureSentryException("llmUsageJobSumError", hub, err)
+		}
+	}
+}
+
+// LoadSpend loads the saved spend of the current month. It should be called at the startup before the news jobs
+// are scheduled, so the budget is guarded before the first Run saves the usage.
+func (j *LLMUsageJob) LoadSpend(ctx context.Context) error {
+	month := startOfMonth(j.now())
+	spent, err := j.archivist.Entities.LLMUsage.SumCost(ctx, month)
+	if err != nil {
+		return fmt.Errorf("[job-llm-usage] Error summing LLM spend: %w", err)
+	}
+
+	j.mu.Lock()
+	j.spent, j.month = spent, month
+	j.mu.Unlock()
+	return nil
+}
+
+// takeBuckets returns collected usage and resets it.
+func (j *LLMUsageJob) takeBuckets() []*archivist.LLMUsage {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	usage := make([]*archivist.LLMUsage, 0, len(j.buckets))
+	for _, b := range j.buckets {
+		usage = append(usage, b)
+	}
+	j.buckets = make(map[string]*archivist.LLMUsage)
+
+	return usage
+}
+
+// restoreBuckets adds back the usage that failed to save.
+func (j *LLMUsageJob) restoreBuckets(usage []*archivist.LLMUsage) {
+	for _, u := range usage {
+		key := u.Day.Format(time.DateOnly) + "|" + u.Provider + "|" + u.Model
+
+		j.mu.Lock()
+		if b, ok := j.buckets[key]; ok {
+			b.Requests += u.Requests
+			b.PromptTokens += u.PromptTokens
+			b.CompletionTokens += u.CompletionTokens
+			b.Cost += u.Cost
+		} else {
+			j.buckets[key] = u
+		}
+		j.mu.Unlock()
+	}
+}
+
+// startOfMonth returns the first day of the month (UTC).
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// originalComposed returns the news with their original titles as the composed texts,
+// it is the fallback of the compose stage when the LLM budget is exceeded.
+func originalComposed(news journalist.NewsList) []*composer.ComposedNews {
+	var composed []*composer.ComposedNews
+	for _, n := range news.RemoveFlagged() {
+		composed = append(composed, &composer.ComposedNews{ID: n.ID, Text: n.Title})
+	}
+	return composed
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLLMUsageJob_BudgetExceeded(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	// 1M prompt tokens of gpt-4o cost $2.5
+	usage := composer.Usage{Provider: composer.ProviderOpenAI, Model: "gpt-4o", PromptTokens: 1_000_000}
+
+	tests := []struct {
+		name      string
+		budget    float64
+		spent     float64   // saved spend
+		month     time.Time // month of the saved spend
+		requests  int       // number of observed requests
+		wantSpend float64
+		want      bool
+	}{
+		{
+			name:      "unlimited budget",
+			requests:  10,
+			wantSpend: 25,
+			want:      false,
+		},
+		{
+			name:      "under the budget",
+			budget:    10,
+			spent:     5,
+			month:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			requests:  1,
+			wantSpend: 7.5,
+			want:      false,
+		},
+		{
+			name:      "saved and not saved spend reach the budget",
+			budget:    10,
+			spent:     5,
+			month:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			requests:  2,
+			wantSpend: 10,
+			want:      true,
+		},
+		{
+			name:      "saved spend of the previous month",
+			budget:    10,
+			spent:     50,
+			month:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			requests:  1,
+			wantSpend: 2.5,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewLLMUsageJob(nil).WithMonthlyBudget(tt.budget)
+			j.now = func() time.Time { return now }
+			j.spent, j.month = tt.spent, tt.month
+			for i := 0; i < tt.requests; i++ {
+				j.Observe(usage)
+			}
+
+			if got := j.MonthlySpend(); math.Abs(got-tt.wantSpend) > 1e-9 {
+				t.Errorf("MonthlySpend() = %v, want %v", got, tt.wantSpend)
+			}
+			if got := j.BudgetExceeded(); got != tt.want {
+				t.Errorf("BudgetExceeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var nilJob *LLMUsageJob
+	if nilJob.BudgetExceeded() {
+		t.Error("BudgetExceeded() of nil job = true, want false")
+	}
+}
+
+func TestLLMUsageJob_LoadSpend(t *testing.T) {
+	a, err := archivist.NewArchivist("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("NewArchivist() error = %v", err)
+	}
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	now := time.Now().UTC()
+	ctx := context.Background()
+	err = a.Entities.LLMUsage.Increment(ctx, []*archivist.LLMUsage{
+		{Day: now.Truncate(24 * time.Hour), Provider: composer.ProviderOpenAI, Model: "gpt-4o", Requests: 1, Cost: 12},
+		{Day: startOfMonth(now).AddDate(0, -1, 0), Provider: composer.ProviderOpenAI, Model: "gpt-4o", Requests: 1, Cost: 30},
+	})
+	if err != nil {
+		t.Fatalf("Increment() error = %v", err)
+	}
+
+	j := NewLLMUsageJob(a).WithMonthlyBudget(10)
+	if j.BudgetExceeded() {
+		t.Fatal("BudgetExceeded() = true before the spend is loaded")
+	}
+	if err := j.LoadSpend(ctx); err != nil {
+		t.Fatalf("LoadSpend() error = %v", err)
+	}
+	if got := j.MonthlySpend(); math.Abs(got-12) > 1e-9 {
+		t.Errorf("MonthlySpend() = %v, want the spend of the current month 12", got)
+	}
+	if !j.BudgetExceeded() {
+		t.Error("BudgetExceeded() = false after the spend is loaded")
+	}
+}
+
+func TestLLMUsageJob_Observe(t *testing.T) {
+	j := NewLLMUsageJob(nil)
+	j.now = func() time.Time { return time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC) }
+
+	j.Observe(composer.Usage{Provider: composer.ProviderOpenAI, Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 10})
+	j.Observe(composer.Usage{Provider: composer.ProviderOpenAI, Model: "gpt-4o", PromptTokens: 50, CompletionTokens: 5})
+	j.Observe(composer.Usage{Provider: composer.ProviderAnthropic, Model: "claude-3-5-haiku-latest", PromptTokens: 10})
+
+	usage := j.takeBuckets()
+	if len(usage) != 2 {
+		t.Fatalf("takeBuckets() returned %d buckets, want 2", len(usage))
+	}
+	for _, u := range usage {
+		if u.Model == "gpt-4o" && (u.Requests != 2 || u.PromptTokens != 150 || u.CompletionTokens != 15) {
+			t.Errorf("gpt-4o usage = %+v, want 2 requests, 150 prompt and 15 completion tokens", u)
+		}
+		if !u.Day.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("usage day = %v, want 2024-03-15", u.Day)
+		}
+	}
+
+	j.restoreBuckets(usage)
+	if got := len(j.takeBuckets()); got != 2 {
+		t.Errorf("restored %d buckets, want 2", got)
+	}
+}
+
+func TestOriginalComposed(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Fed holds rates"},
+		{ID: "2", Title: "Filtered news", IsFiltered: true},
+	}
+
+	got := originalComposed(news)
+	if len(got) != 1 || got[0].ID != "1" || got[0].Text != "Fed holds rates" {
+		t.Errorf("originalComposed() = %v, want news 1 with the original title", got)
+	}
+}
@@ -0,0 +1,236 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// marketMoodPoll is the name of the market mood polls in the database.
+const marketMoodPoll = "market_mood"
+
+// marketMoodQuestion and marketMoodOptions are the daily market mood poll.
+var (
+	marketMoodQuestion = "What is your market mood today?"
+	marketMoodOptions  = []string{"🐂 Bullish", "🐻 Bearish", "😐 Neutral"}
+)
+
+// MarketMoodJob publishes the daily bullish/bearish poll to the channel and the follow-up post with its results.
+// Polls are saved to the database, so the results can be read back by the other instance or after the restart.
+type MarketMoodJob struct {
+	publisher *publisher.TelegramPublisher // publisher that will publish the polls and the results to the channel
+	archivist *archivist.Archivist         // archivist that will save the polls and their results
+	channel   string                       // channel name or chat id of the polls, empty for the default channel
+	logger    *slog.Logger                 // special logger for the job
+	alerter   *Alerter                     // sends alerts to the admin chat on failures (optional)
+}
+
+func NewMarketMoodJob(
+	publisher *publisher.TelegramPublisher,
+	archivist *archivist.Archivist,
+	channel string,
+) *MarketMoodJob {
+	return &MarketMoodJob{
+		publisher: publisher,
+		archivist: archivist,
+		channel:   channel,
+		logger:    logging.For(logging.ModuleJobs),
+	}
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *MarketMoodJob) WithAlerter(a *Alerter) *MarketMoodJob {
+	j.alerter = a
+	return j
+}
+
+// Run publishes the market mood poll of the day. It should be run before the market open.
+func (j *MarketMoodJob) Run() JobFunc {
+	return func() {
+		err := j.publishPoll()
+		j.alerter.Alert("MarketMoodJob", "poll", err)
+	}
+}
+
+// RunResults stops the open market mood polls and publishes their results. It should be run after the market close.
+func (j *MarketMoodJob) RunResults() JobFunc {
+	return func() {
+		err := j.publishResults()
+		j.alerter.Alert("MarketMoodJob", "results", err)
+	}
+}
+
+func (j *MarketMoodJob) publishPoll() error {
+	ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), time.Minute)
+	defer cancel()
+
+	tx := sentry.StartTransaction(ctx, "RunMarketMoodJob")
+	tx.Op = "job-market-mood"
+
+	// Sentry performance monitoring
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+	defer hub.Recover(nil)
+
+	span := tx.StartChild("TelegramPublisher.PublishPoll")
+	pubID, pollID, err := j.publisher.PublishPoll(j.channel, marketMoodQuestion, marketMoodOptions)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-market-mood] Error publishing poll: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobMarketMoodPublishError", hub, e)
+		return e
+	}
+
+	options, _ := json.Marshal(marketMoodOptions)
+	span = tx.StartChild("Polls.Create")
+	err = j.archivist.Entities.Polls.Create(ctx, &archivist.Poll{
+		Name:          marketMoodPoll,
+		ChatID:        j.publisher.ChatID(j.channel),
+		PublicationID: pubID,
+		PollID:        pollID,
+		Question:      marketMoodQuestion,
+		Options:       options,
+	})
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-market-mood] Error saving poll: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobMarketMoodSaveError", hub, e)
+		return e
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "Market mood poll published successfully",
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return nil
+}
+
+func (j *MarketMoodJob) publishResults() error {
+	ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), time.Minute)
+	defer cancel()
+
+	tx := sentry.StartTransaction(ctx, "RunMarketMoodResultsJob")
+	tx.Op = "job-market-mood-results"
+
+	// Sentry performance monitoring
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+	defer hub.Recover(nil)
+
+	span := tx.StartChild("Polls.FindOpen")
+	polls, err := j.archivist.Entities.Polls.FindOpen(ctx, marketMoodPoll, time.Now().UTC())
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-market-mood] Error finding open polls: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobMarketMoodFindError", hub, e)
+		return e
+	}
+
+	// Failed polls don't block the others, they are retried on the next run
+	var errs []error
+	for _, p := range polls {
+		span = tx.StartChild("TelegramPublisher.StopPoll")
+		result, err := j.publisher.StopPoll(p.ChatID, p.PublicationID)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-market-mood] Error stopping poll %s: %w", p.PublicationID, err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("jobMarketMoodStopError", hub, e)
+			errs = append(errs, e)
+			continue
+		}
+
+		// Results are not available if the publishing is disabled
+		var votes []int
+		total := 0
+		if result != nil {
+			votes, total = pollVotes(result), result.TotalVoters
+		}
+
+		span = tx.StartChild("Polls.Close")
+		err = j.archivist.Entities.Polls.Close(ctx, p.ID, votes, total)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-market-mood] Error closing poll %s: %w", p.PublicationID, err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("jobMarketMoodCloseError", hub, e)
+			errs = append(errs, e)
+			continue
+		}
+
+		if total == 0 {
+			j.logger.InfoContext(ctx, "[job-market-mood] No votes in the poll", "publication_id", p.PublicationID)
+			continue
+		}
+
+		span = tx.StartChild("TelegramPublisher.PublishTo")
+		_, err = j.publisher.PublishTo(j.channel, formatMarketMood(p.OptionList(), votes, total))
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-market-mood] Error publishing poll results: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("jobMarketMoodResultsError", hub, e)
+			errs = append(errs, e)
+			continue
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Market mood results published with %d votes", total),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+
+	return errors.Join(errs...)
+}
+
+// pollVotes returns the votes of the poll in the order of its options.
+func pollVotes(p *publisher.Poll) []int {
+	votes := make([]int, len(p.Options))
+	for i, o := range p.Options {
+		votes[i] = o.Voters
+	}
+	return votes
+}
+
+// formatMarketMood formats the results of the market mood poll: the share of the votes of each option.
+func formatMarketMood(options []string, votes []int, total int) string {
+	var m strings.Builder
+	m.WriteString("🗳 #mood\nMarket mood of the day:\n")
+	for i, o := range options {
+		count := 0
+		if i < len(votes) {
+			count = votes[i]
+		}
+		m.WriteString(fmt.Sprintf("%s: %.0f%%\n", o, float64(count)/float64(total)*100))
+	}
+	m.WriteString(fmt.Sprintf("Votes: %d", total))
+
+	return m.String()
+}
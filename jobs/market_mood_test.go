@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/publisher"
+	"reflect"
+	"testing"
+)
+
+func Test_pollVotes(t *testing.T) {
+	p := &publisher.Poll{Options: []publisher.PollOption{{Text: "🐂 Bullish", Voters: 7}, {Text: "🐻 Bearish", Voters: 3}}}
+	if got := pollVotes(p); !reflect.DeepEqual(got, []int{7, 3}) {
+		t.Errorf("pollVotes() = %v, want [7 3]", got)
+	}
+}
+
+func Test_formatMarketMood(t *testing.T) {
+	tests := []struct {
+		name  string
+		votes []int
+		total int
+		want  string
+	}{
+		{
+			name:  "all options voted",
+			votes: []int{6, 3, 1},
+			total: 10,
+			want:  "🗳 #mood\nMarket mood of the day:\n🐂 Bullish: 60%\n🐻 Bearish: 30%\n😐 Neutral: 10%\nVotes: 10",
+		},
+		{
+			name:  "missing votes",
+			votes: []int{1},
+			total: 1,
+			want:  "🗳 #mood\nMarket mood of the day:\n🐂 Bullish: 100%\n🐻 Bearish: 0%\n😐 Neutral: 0%\nVotes: 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMarketMood(marketMoodOptions, tt.votes, tt.total); got != tt.want {
+				t.Errorf("formatMarketMood() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
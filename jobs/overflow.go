@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"slices"
+	"time"
+)
+
+// OverflowOrder is the order of publishing the news if the job publishes no more than the given number per run.
+type OverflowOrder string
+
+const (
+	OverflowOldest     OverflowOrder = "oldest"     // the oldest news first (default)
+	OverflowImportance OverflowOrder = "importance" // the news with the higher rules priority and importance first, then the oldest
+)
+
+// pendingMaxAge is the age of the pending news after which they are not published anymore: the stale news
+// are worse than the missed ones. They stay pending until the retention job deletes them.
+const pendingMaxAge = 24 * time.Hour
+
+// limitPublications returns the news to publish in this run: the pending news of the previous runs and the new ones
+// in the overflow order, no more than the per-run limit. The rest are saved as pending.
+// The news are returned as is if the limit is not set.
+func (job *Job) limitPublications(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	news []*archivist.News,
+) ([]*archivist.News, error) {
+	if job.options.maxPublish <= 0 || !job.options.shouldSaveToDB {
+		return news, nil
+	}
+
+	span := tx.StartChild("limitPublications.News.FindPending")
+	pending, err := job.archivist.Entities.News.FindPending(ctx, job.journalist.Name, time.Now().Add(-pendingMaxAge))
+	span.Finish()
+	if err != nil {
+		// The pending news are published by the next runs, so the new ones are not blocked by the error
+		e := fmt.Errorf("[%s][limitPublications.News.FindPending]: %w", job.name, err)
+		job.logger.WarnContext(ctx, e.Error())
+		utils.CaptureSentryException("jobFindPendingNewsError", hub, e)
+	}
+
+	publish, overflow := splitOverflow(append(pending, news...), job.options.maxPublish, job.options.overflowOrder)
+	if len(overflow) == 0 {
+		return publish, nil
+	}
+
+	for _, n := range overflow {
+		n.State = archivist.NewsStatePending
+	}
+	if err := job.updateNews(ctx, tx, hub, overflow); err != nil {
+		return nil, err
+	}
+
+	job.logger.InfoContext(ctx, fmt.Sprintf("[%s][limitPublications]: %d news left pending", job.name, len(overflow)))
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("limitPublications returned %d news, %d left pending", len(publish), len(overflow)),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return publish, nil
+}
+
+// splitOverflow sorts the news in the overflow order and splits them to the first maxPerRun news to publish
+// and the rest.
+func splitOverflow(news []*archivist.News, maxPerRun int, order OverflowOrder) (publish, overflow []*archivist.News) {
+	importances := make(map[*archivist.News]int, len(news))
+	if order == OverflowImportance {
+		for _, n := range news {
+			var meta composer.ComposedMeta
+			// News without the meta data are not rated
+			_ = json.Unmarshal(n.MetaData, &meta)
+			importances[n] = importance(meta)
+		}
+	}
+
+	slices.SortStableFunc(news, func(a, b *archivist.News) int {
+		if order == OverflowImportance && a.Priority != b.Priority {
+			return b.Priority - a.Priority
+		}
+		if order == OverflowImportance && importances[a] != importances[b] {
+			return importances[b] - importances[a]
+		}
+		return a.OriginalDate.Compare(b.OriginalDate)
+	})
+
+	if len(news) <= maxPerRun {
+		return news, nil
+	}
+	return news[:maxPerRun], news[maxPerRun:]
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"encoding/json"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_splitOverflow(t *testing.T) {
+	now := time.Now()
+	hashes := func(news []*archivist.News) []string {
+		result := make([]string, len(news))
+		for i, n := range news {
+			result[i] = n.Hash
+		}
+		return result
+	}
+	importance := 80
+	important, _ := json.Marshal(composer.ComposedMeta{Importance: &importance})
+	news := func() []*archivist.News {
+		return []*archivist.News{
+			{Hash: "new", OriginalDate: now, Priority: 5, MetaData: important},
+			{Hash: "old", OriginalDate: now.Add(-2 * time.Hour)},
+			{Hash: "mid", OriginalDate: now.Add(-time.Hour), Priority: 5},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		maxPerRun    int
+		order        OverflowOrder
+		wantPublish  []string
+		wantOverflow []string
+	}{
+		{
+			name:         "oldest first",
+			maxPerRun:    2,
+			order:        OverflowOldest,
+			wantPublish:  []string{"old", "mid"},
+			wantOverflow: []string{"new"},
+		},
+		{
+			name:         "default order is oldest first",
+			maxPerRun:    1,
+			order:        "",
+			wantPublish:  []string{"old"},
+			wantOverflow: []string{"mid", "new"},
+		},
+		{
+			name:         "importance first",
+			maxPerRun:    2,
+			order:        OverflowImportance,
+			wantPublish:  []string{"new", "mid"},
+			wantOverflow: []string{"old"},
+		},
+		{
+			name:         "under the limit",
+			maxPerRun:    5,
+			order:        OverflowOldest,
+			wantPublish:  []string{"old", "mid", "new"},
+			wantOverflow: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publish, overflow := splitOverflow(news(), tt.maxPerRun, tt.order)
+			if got := hashes(publish); !reflect.DeepEqual(got, tt.wantPublish) {
+				t.Errorf("splitOverflow() publish = %v, want %v", got, tt.wantPublish)
+			}
+			if got := hashes(overflow); !reflect.DeepEqual(got, tt.wantOverflow) {
+				t.Errorf("splitOverflow() overflow = %v, want %v", got, tt.wantOverflow)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/avast/retry-go"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"time"
+)
+
+// minPodcastHeadlines is the minimum number of news and events to make the episode.
+const minPodcastHeadlines = 5
+
+// PodcastJob creates the daily audio digest: the spoken script of the day news and events
+// is converted to speech and published to the channel as a single audio file.
+type PodcastJob struct {
+	composer     *composer.Composer           // composer that will write the script and convert it to speech
+	publisher    *publisher.TelegramPublisher // publisher that will publish the audio to the channel
+	archivist    *archivist.Archivist         // archivist that will read news and save episodes to the database
+	logger       *slog.Logger                 // special logger for the job
+	metrics      metrics.Emitter              // metrics emitter for job counters and latencies
+	alerter      *Alerter                     // sends alerts to the admin chat on failures (optional)
+	saveEpisodes bool                         // if true, episodes are saved to the database for the podcast feed
+}
+
+func NewPodcastJob(
+	composer *composer.Composer,
+	publisher *publisher.TelegramPublisher,
+	archivist *archivist.Archivist,
+) *PodcastJob {
+	return &PodcastJob{
+		composer:  composer,
+		publisher: publisher,
+		archivist: archivist,
+		logger:    logging.For(logging.ModuleJobs),
+		metrics:   metrics.Noop{},
+	}
+}
+
+// WithMetrics sets the metrics emitter for the job counters and latencies.
+func (j *PodcastJob) WithMetrics(m metrics.Emitter) *PodcastJob {
+	j.metrics = m
+	return j
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *PodcastJob) WithAlerter(a *Alerter) *PodcastJob {
+	j.alerter = a
+	return j
+}
+
+// SaveEpisodes saves published episodes to the database, so they can be served by the podcast feed.
+func (j *PodcastJob) SaveEpisodes() *PodcastJob {
+	j.saveEpisodes = true
+	return j
+}
+
+// Run runs the Podcast job for the news and events since the start of the current day (UTC).
+func (j *PodcastJob) Run() JobFunc {
+	return func() {
+		err := retry.Do(func() error {
+			ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 3*time.Minute)
+			defer cancel()
+
+			tx := sentry.StartTransaction(ctx, "RunPodcastJob")
+			tx.Op = "job-podcast"
+
+			// Sentry performance monitoring
+			hub := sentry.GetHubFromContext(ctx)
+			if hub == nil {
+				hub = sentry.CurrentHub().Clone()
+				ctx = sentry.SetHubOnContext(ctx, hub)
+			}
+
+			defer tx.Finish()
+			defer hub.Flush(2 * time.Second)
+			defer hub.Recover(nil)
+
+			from := time.Now().UTC().Truncate(24 * time.Hour)
+
+			span := tx.StartChild("News.FindAllUntilDate")
+			news, err := j.archivist.Entities.News.FindAllUntilDate(ctx, from)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("error fetching news from the database: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("jobPodcastNewsFindAllError", hub, e)
+				return e
+			}
+
+			span = tx.StartChild("Events.FindAllUntilDate")
+			events, err := j.archivist.Entities.Events.FindAllUntilDate(ctx, from)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("error fetching events from the database: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("jobPodcastEventsFindAllError", hub, e)
+				return e
+			}
+
+			if sum := len(events) + len(news); sum < minPodcastHeadlines {
+				j.logger.InfoContext(ctx, "Not enough news or events for the podcast", "total", sum)
+				return nil
+			}
+
+			var headlines []*composer.Headline
+			for _, e := range events {
+				headlines = append(headlines, e.ToHeadline())
+			}
+			for _, n := range news {
+				headlines = append(headlines, n.ToHeadline())
+			}
+
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "successful",
+				Message:  fmt.Sprintf("Found %d headlines for the podcast", len(headlines)),
+				Level:    sentry.LevelInfo,
+			}, nil)
+
+			span = tx.StartChild("ComposeDigestScript")
+			start := time.Now()
+			script, err := j.composer.ComposeDigestScript(ctx, headlines)
+			j.metrics.Timing(metrics.LLMLatency, time.Since(start), metrics.T("job", "Podcast"), metrics.T("stage", "script"))
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("error composing podcast script: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("jobPodcastComposeScriptError", hub, e)
+				return e
+			}
+			if script == "" {
+				j.logger.InfoContext(ctx, "Empty podcast script")
+				return nil
+			}
+
+			span = tx.StartChild("TextToSpeech")
+			start = time.Now()
+			audio, err := j.composer.TextToSpeech(ctx, script)
+			j.metrics.Timing(metrics.LLMLatency, time.Since(start), metrics.T("job", "Podcast"), metrics.T("stage", "speech"))
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("error converting podcast script to speech: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("jobPodcastTextToSpeechError", hub, e)
+				return e
+			}
+
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "successful",
+				Message:  fmt.Sprintf("TextToSpeech returned %d bytes of audio", len(audio)),
+				Level:    sentry.LevelInfo,
+			}, nil)
+
+			title, caption := formatEpisode(from)
+
+			span = tx.StartChild("PublishAudio")
+			pubID, err := j.publisher.PublishAudio(title, caption, audio)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("error publishing podcast: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("jobPodcastPublishError", hub, e)
+				// Note: Unrecoverable error, because Telegram API often hangs up, but somehow publishes the message
+				return retry.Unrecoverable(e) //nolint:wrapcheck
+			}
+			j.metrics.Count(metrics.NewsPublished, 1, metrics.T("job", "Podcast"))
+
+			if !j.saveEpisodes {
+				return nil
+			}
+
+			span = tx.StartChild("Podcasts.Create")
+			err = j.archivist.Entities.Podcasts.Create(ctx, &archivist.PodcastEpisode{
+				Title:         title,
+				Script:        script,
+				Audio:         audio,
+				PublicationID: pubID,
+			})
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("error saving podcast episode: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("jobPodcastSaveError", hub, e)
+				return retry.Unrecoverable(e) //nolint:wrapcheck
+			}
+
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "successful",
+				Message:  "Podcast episode published successfully",
+				Level:    sentry.LevelInfo,
+			}, nil)
+
+			return nil
+		},
+			retry.Attempts(3),
+			retry.Delay(5*time.Minute),
+		)
+		j.alerter.Alert("PodcastJob", "run", err)
+	}
+}
+
+// formatEpisode returns the title and the channel caption of the episode for the given day.
+func formatEpisode(day time.Time) (title, caption string) {
+	date := day.Format("Jan 2, 2006")
+	return fmt.Sprintf("Market digest %s", date), fmt.Sprintf("🎧 #podcast\nDaily market digest for %s", date)
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_formatEpisode(t *testing.T) {
+	tests := []struct {
+		name        string
+		day         time.Time
+		wantTitle   string
+		wantCaption string
+	}{
+		{
+			name:        "episode of the day",
+			day:         time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+			wantTitle:   "Market digest Mar 8, 2024",
+			wantCaption: "🎧 #podcast\nDaily market digest for Mar 8, 2024",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, caption := formatEpisode(tt.day)
+			if title != tt.wantTitle {
+				t.Errorf("formatEpisode() title = %q, want %q", title, tt.wantTitle)
+			}
+			if caption != tt.wantCaption {
+				t.Errorf("formatEpisode() caption = %q, want %q", caption, tt.wantCaption)
+			}
+		})
+	}
+}
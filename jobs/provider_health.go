@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ProviderHealthJob collects fetch results of the providers in memory and periodically
+// saves them as hourly health counters, so the feeds degradation can be tracked over time.
+type ProviderHealthJob struct {
+	archivist *archivist.Archivist // archivist that will save health counters to the database
+	logger    *slog.Logger         // special logger for the job
+
+	mu      sync.Mutex
+	buckets map[string]*archivist.ProviderHealth // not saved counters by provider and hour
+}
+
+func NewProviderHealthJob(arch *archivist.Archivist) *ProviderHealthJob {
+	return &ProviderHealthJob{
+		archivist: arch,
+		logger:    logging.For(logging.ModuleJobs),
+		buckets:   make(map[string]*archivist.ProviderHealth),
+	}
+}
+
+// Observe is the journalist.FetchObserver that registers the fetch result.
+func (j *ProviderHealthJob) Observe(r journalist.FetchResult) {
+	hour := r.At.UTC().Truncate(time.Hour)
+	key := r.Provider + "|" + hour.Format(time.RFC3339)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	b, ok := j.buckets[key]
+	if !ok {
+		b = &archivist.ProviderHealth{ProviderName: r.Provider, Hour: hour}
+		j.buckets[key] = b
+	}
+	b.AddFetch(r.Duration, r.StatusCode, r.Err != nil)
+}
+
+// Run saves collected counters to the database.
+func (j *ProviderHealthJob) Run() JobFunc {
+	return func() {
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 10*time.Second)
+		defer cancel()
+
+		hub := sentry.CurrentHub().Clone()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		health := j.takeBuckets()
+		if len(health) == 0 {
+			return
+		}
+
+		err := j.archivist.Entities.ProviderHealth.Increment(ctx, health)
+		if err != nil {
+			e := fmt.Errorf("[job-provider-health] Error saving provider health: %w", err)
+			j.logger.WarnContext(ctx, e.Error())
+			utils.CaptureSentryException("providerHealthJobSaveError", hub, e)
+		}
+	}
+}
+
+// takeBuckets returns collected counters and resets them.
+func (j *ProviderHealthJob) takeBuckets() []*archivist.ProviderHealth {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	health := make([]*archivist.ProviderHealth, 0, len(j.buckets))
+	for _, b := range j.buckets {
+		health = append(health, b)
+	}
+	j.buckets = make(map[string]*archivist.ProviderHealth)
+
+	return health
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"testing"
+	"time"
+)
+
+func TestProviderHealthJob_Observe(t *testing.T) {
+	hour := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	j := NewProviderHealthJob(nil)
+
+	j.Observe(journalist.FetchResult{Provider: "reuters", At: hour.Add(time.Minute), Duration: time.Second, StatusCode: 200})
+	j.Observe(journalist.FetchResult{Provider: "reuters", At: hour.Add(2 * time.Minute), Duration: 2 * time.Second, StatusCode: 503, Err: errors.New("503")})
+	j.Observe(journalist.FetchResult{Provider: "reuters", At: hour.Add(time.Hour), Duration: time.Second, StatusCode: 200})
+	j.Observe(journalist.FetchResult{Provider: "cnbc", At: hour, Duration: time.Second, StatusCode: 200})
+
+	health := j.takeBuckets()
+	if len(health) != 3 {
+		t.Fatalf("takeBuckets() returned %d buckets, want 3", len(health))
+	}
+	for _, h := range health {
+		if h.ProviderName == "reuters" && h.Hour.Equal(hour) {
+			if h.Fetches != 2 || h.Failures != 1 || h.Status5xx != 1 || h.LatencyMaxMs != 2000 {
+				t.Errorf("reuters bucket = %+v", h)
+			}
+		}
+	}
+
+	if len(j.takeBuckets()) != 0 {
+		t.Error("takeBuckets() should reset the buckets")
+	}
+}
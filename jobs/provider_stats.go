@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"time"
+)
+
+// providerStats collects quality counters of the news providers during the single job run.
+type providerStats map[string]*archivist.ProviderStat
+
+func (ps providerStats) get(provider string) *archivist.ProviderStat {
+	s, ok := ps[provider]
+	if !ok {
+		s = &archivist.ProviderStat{ProviderName: provider}
+		ps[provider] = s
+	}
+	return s
+}
+
+// countFetched counts fetched and suspicious news.
+func (ps providerStats) countFetched(news journalist.NewsList) {
+	for _, n := range news {
+		s := ps.get(n.ProviderName)
+		s.Fetched++
+		if n.IsSuspicious {
+			s.Suspicious++
+		}
+	}
+}
+
+// countDuplicates counts news that are present in the fetched list, but not in the unique one.
+func (ps providerStats) countDuplicates(fetched, unique journalist.NewsList) {
+	uniqueIDs := make(map[string]struct{}, len(unique))
+	for _, n := range unique {
+		uniqueIDs[n.ID] = struct{}{}
+	}
+	for _, n := range fetched {
+		if _, ok := uniqueIDs[n.ID]; !ok {
+			ps.get(n.ProviderName).Duplicates++
+		}
+	}
+}
+
+// countDropped counts saved news that didn't pass the composer and pre-publish filters.
+func (ps providerStats) countDropped(saved, passed []*archivist.News) {
+	passedHashes := make(map[string]struct{}, len(passed))
+	for _, n := range passed {
+		passedHashes[n.Hash] = struct{}{}
+	}
+	for _, n := range saved {
+		if _, ok := passedHashes[n.Hash]; !ok {
+			ps.get(n.ProviderName).ComposeDropped++
+		}
+	}
+}
+
+// countPublished counts published news.
+func (ps providerStats) countPublished(published []*archivist.News) {
+	for _, n := range published {
+		ps.get(n.ProviderName).Published++
+	}
+}
+
+// saveProviderStats saves collected provider counters to the database.
+// It is called at the end of the run, so its context is detached from the job timeout.
+func (job *Job) saveProviderStats(ctx context.Context, hub *sentry.Hub, stats providerStats) {
+	if !job.options.shouldSaveToDB || len(stats) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	list := make([]*archivist.ProviderStat, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, s)
+	}
+
+	err := job.archivist.Entities.ProviderStats.Increment(ctx, list)
+	if err != nil {
+		e := fmt.Errorf("[%s][saveProviderStats.Increment]: %w", job.name, err)
+		job.logger.WarnContext(ctx, e.Error())
+		utils.CaptureSentryException("jobSaveProviderStatsError", hub, e)
+	}
+}
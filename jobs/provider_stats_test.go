@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/journalist"
+	"reflect"
+	"testing"
+)
+
+func TestProviderStats(t *testing.T) {
+	fetched := journalist.NewsList{
+		{ID: "1", ProviderName: "reuters"},
+		{ID: "2", ProviderName: "reuters", IsSuspicious: true},
+		{ID: "3", ProviderName: "reuters"},
+		{ID: "4", ProviderName: "cnbc"},
+	}
+	unique := journalist.NewsList{fetched[0], fetched[1], fetched[3]}
+	saved := []*archivist.News{
+		{Hash: "1", ProviderName: "reuters"},
+		{Hash: "2", ProviderName: "reuters"},
+		{Hash: "4", ProviderName: "cnbc"},
+	}
+	passed := []*archivist.News{saved[0], saved[2]}
+	published := []*archivist.News{saved[0]}
+
+	stats := providerStats{}
+	stats.countFetched(fetched)
+	stats.countDuplicates(fetched, unique)
+	stats.countDropped(saved, passed)
+	stats.countPublished(published)
+
+	want := providerStats{
+		"reuters": {ProviderName: "reuters", Fetched: 3, Duplicates: 1, Suspicious: 1, ComposeDropped: 1, Published: 1},
+		"cnbc":    {ProviderName: "cnbc", Fetched: 1},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("providerStats = %+v, want %+v", stats, want)
+	}
+}
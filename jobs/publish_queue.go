@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"time"
+)
+
+// defaultQueueBatch is the number of the queued news published by one PublishQueue run if the batch is not set.
+const defaultQueueBatch = 5
+
+// PublishQueue returns the job function that publishes the news queued by the runs of the job with
+// QueuePublications set. Each run publishes up to the batch of the queued news, the higher priority and the oldest
+// first, so the publication pace is set by the worker schedule and the publisher rate limiter instead of the
+// compose stage. Queued news older than a day are not published.
+//
+// Note: requires SaveToDB to be set.
+func (job *Job) PublishQueue(batch int) JobFunc {
+	if batch <= 0 {
+		batch = defaultQueueBatch
+	}
+
+	return func() {
+		if !job.options.shouldSaveToDB || job.control.Paused() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), job.options.timeout)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.PublishQueue", job.name))
+		tx.Op = "job"
+
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		span := tx.StartChild("PublishQueue.News.FindQueued")
+		news, err := job.archivist.Entities.News.FindQueued(ctx, job.journalist.Name, time.Now().Add(-pendingMaxAge), batch)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[%s][PublishQueue.News.FindQueued]: %w", job.name, err)
+			utils.CaptureSentryException("jobPublishQueueError", hub, e)
+			job.alerter.Alert(job.name, "queue", e)
+			return
+		}
+		// Queued news of the channels outside their publishing windows wait for them to open
+		news, err = job.holdClosed(ctx, tx, hub, news)
+		if err != nil || len(news) == 0 {
+			return
+		}
+
+		publishCtx, cancelPublish := job.stageContext(ctx, StagePublish)
+		defer cancelPublish()
+		published, _ := job.publish(publishCtx, tx, hub, news)
+
+		job.publishTranslations(publishCtx, tx, hub, published)
+		job.publishSubscriptions(publishCtx, tx, hub, published)
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("PublishQueue published %d of %d queued news", len(published), len(news)),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+}
@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RecoverPublications returns the job function that reconciles publications interrupted by a crash or restart.
+// News queued within the window by the directJobs (the jobs publishing without the queue, by their names)
+// are published, the news queued by the other jobs are left to their PublishQueue workers. News with
+// the publication in progress are marked as interrupted and reported to the admin, because they may be
+// already published (Telegram doesn't allow to check it), and it's better to miss the news than to publish it twice.
+//
+// It should run once at the startup before the regular jobs. Note: requires SaveToDB to be set.
+func (job *Job) RecoverPublications(window time.Duration, directJobs []string) JobFunc {
+	return func() {
+		if !job.options.shouldSaveToDB {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 60*time.Second)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.RecoverPublications", job.name))
+		tx.Op = "job"
+
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		span := tx.StartChild("RecoverPublications.FindAllByStates")
+		news, err := job.archivist.Entities.News.FindAllByStates(
+			ctx,
+			[]archivist.NewsState{archivist.NewsStateQueued, archivist.NewsStatePublishing},
+			time.Now().Add(-window),
+		)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[%s][RecoverPublications.FindAllByStates]: %w", job.name, err)
+			utils.CaptureSentryException("jobRecoverPublicationsError", hub, e)
+			job.alerter.Alert(job.name, "recovery", e)
+			return
+		}
+
+		queued, interrupted := splitByState(news, directJobs)
+
+		if len(interrupted) > 0 {
+			hashes := make([]string, len(interrupted))
+			for i, n := range interrupted {
+				n.State = archivist.NewsStateInterrupted
+				hashes[i] = n.Hash
+			}
+
+			if err := job.updateNews(ctx, tx, hub, interrupted); err != nil {
+				return
+			}
+
+			e := fmt.Errorf("[%s][RecoverPublications]: publication of %d news was interrupted, check the channel: %s",
+				job.name, len(interrupted), strings.Join(hashes, ", "))
+			job.logger.WarnContext(ctx, e.Error())
+			job.alerter.Alert(job.name, "recovery", e)
+		}
+
+		// Queued news of the channels outside their publishing windows wait for them to open
+		queued, err = job.holdClosed(ctx, tx, hub, queued)
+		if err != nil {
+			return
+		}
+		if len(queued) > 0 {
+			published, _ := job.publish(ctx, tx, hub, queued)
+			job.logger.InfoContext(ctx, fmt.Sprintf("[%s][RecoverPublications]: published %d of %d queued news", job.name, len(published), len(queued)))
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("RecoverPublications found %d queued and %d interrupted news", len(queued), len(interrupted)),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+}
+
+// splitByState splits the news to the queued ones (never sent) of the given jobs and interrupted ones
+// (publication in progress) of all jobs.
+func splitByState(news []*archivist.News, jobNames []string) (queued, interrupted []*archivist.News) {
+	for _, n := range news {
+		switch n.State {
+		case archivist.NewsStateQueued:
+			if slices.Contains(jobNames, n.JobName) {
+				queued = append(queued, n)
+			}
+		case archivist.NewsStatePublishing:
+			interrupted = append(interrupted, n)
+		}
+	}
+	return queued, interrupted
+}
+
+// Repost publishes the saved news again by its hash, e.g. if the message was deleted from the channel by mistake
+// or the interrupted publication was not delivered. Returns the new publication ID.
+// Note: requires SaveToDB to be set.
+func (job *Job) Repost(ctx context.Context, hash string) (string, error) {
+	tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.Repost", job.name))
+	tx.Op = "job"
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+
+	span := tx.StartChild("Repost.FindAllByHashes")
+	news, err := job.archivist.Entities.News.FindAllByHashes(ctx, []string{hash})
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][Repost.FindAllByHashes]: %w", job.name, err)
+		utils.CaptureSentryException("jobRepostError", hub, e)
+		return "", e
+	}
+	if len(news) == 0 {
+		return "", fmt.Errorf("[%s][Repost]: news %s not found", job.name, hash)
+	}
+
+	published, err := job.publish(ctx, tx, hub, news[:1])
+	if err != nil {
+		return "", err
+	}
+
+	return published[0].PublicationID, nil
+}
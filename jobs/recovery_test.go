@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"reflect"
+	"testing"
+)
+
+func Test_splitByState(t *testing.T) {
+	queued := &archivist.News{Hash: "1", State: archivist.NewsStateQueued, JobName: "news"}
+	publishing := &archivist.News{Hash: "2", State: archivist.NewsStatePublishing, JobName: "calendar"}
+	published := &archivist.News{Hash: "3", State: archivist.NewsStatePublished, JobName: "news"}
+	legacy := &archivist.News{Hash: "4"}
+	queuedByWorker := &archivist.News{Hash: "5", State: archivist.NewsStateQueued, JobName: "calendar"}
+
+	gotQueued, gotInterrupted := splitByState([]*archivist.News{queued, publishing, published, legacy, queuedByWorker}, []string{"news"})
+	if !reflect.DeepEqual(gotQueued, []*archivist.News{queued}) {
+		t.Errorf("splitByState() queued = %v, want %v", gotQueued, []*archivist.News{queued})
+	}
+	if !reflect.DeepEqual(gotInterrupted, []*archivist.News{publishing}) {
+		t.Errorf("splitByState() interrupted = %v, want %v", gotInterrupted, []*archivist.News{publishing})
+	}
+}
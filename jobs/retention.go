@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"log/slog"
+	"time"
+)
+
+// RetentionJob periodically deletes the old news, so the news table doesn't grow unbounded.
+// Published news have their own retention period, since they are the history of the channel.
+type RetentionJob struct {
+	archivist          *archivist.Archivist // archivist that will delete the news from the database
+	retention          time.Duration        // news that were not published are deleted after this period
+	publishedRetention time.Duration        // published news are deleted after this period, 0 to keep them forever
+	embeddings         bool                 // if true, the news embeddings are deleted after the retention period too
+	logger             *slog.Logger         // special logger for the job
+}
+
+func NewRetentionJob(archivist *archivist.Archivist, retention time.Duration) *RetentionJob {
+	return &RetentionJob{
+		archivist: archivist,
+		retention: retention,
+		logger:    logging.For(logging.ModuleJobs),
+	}
+}
+
+// WithPublishedRetention sets the retention period of the published news, 0 to keep them forever.
+func (j *RetentionJob) WithPublishedRetention(retention time.Duration) *RetentionJob {
+	j.publishedRetention = retention
+	return j
+}
+
+// WithEmbeddings enables pruning of the news embeddings (requires the embeddings table).
+func (j *RetentionJob) WithEmbeddings() *RetentionJob {
+	j.embeddings = true
+	return j
+}
+
+// Run deletes the news older than the retention periods.
+func (j *RetentionJob) Run() JobFunc {
+	return func() {
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 10*time.Minute)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, "RunRetentionJob")
+		tx.Op = "job-retention"
+
+		// Sentry performance monitoring
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		olderThan, publishedOlderThan := j.cutoffs(time.Now())
+
+		span := tx.StartChild("News.Prune")
+		news, err := j.archivist.Entities.News.Prune(ctx, olderThan, publishedOlderThan)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-retention] Error pruning news: %w", err)
+			j.logger.ErrorContext(ctx, e.Error())
+			utils.CaptureSentryException("retentionJobNewsError", hub, e)
+			return
+		}
+
+		var embeddings int64
+		if j.embeddings {
+			span = tx.StartChild("Embeddings.Prune")
+			embeddings, err = j.archivist.Entities.Embeddings.Prune(ctx, olderThan)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("[job-retention] Error pruning news embeddings: %w", err)
+				j.logger.ErrorContext(ctx, e.Error())
+				utils.CaptureSentryException("retentionJobEmbeddingsError", hub, e)
+				return
+			}
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Pruned %d news and %d embeddings older than %s", news, embeddings, olderThan.Format(time.DateOnly)),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	}
+}
+
+// cutoffs returns the creation dates before which the not published and published news are deleted.
+// The published cutoff is zero if the published news are kept forever.
+func (j *RetentionJob) cutoffs(now time.Time) (olderThan, publishedOlderThan time.Time) {
+	olderThan = now.Add(-j.retention)
+	if j.publishedRetention > 0 {
+		publishedOlderThan = now.Add(-j.publishedRetention)
+	}
+	return olderThan, publishedOlderThan
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetentionJob_cutoffs(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name               string
+		retention          time.Duration
+		publishedRetention time.Duration
+		wantOlderThan      time.Time
+		wantPublished      time.Time
+	}{
+		{
+			name:          "keep published news",
+			retention:     30 * day,
+			wantOlderThan: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:               "prune published news",
+			retention:          30 * day,
+			publishedRetention: 366 * day,
+			wantOlderThan:      time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			wantPublished:      time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewRetentionJob(nil, tt.retention).WithPublishedRetention(tt.publishedRetention)
+			olderThan, published := j.cutoffs(now)
+			if !olderThan.Equal(tt.wantOlderThan) {
+				t.Errorf("cutoffs() olderThan = %v, want %v", olderThan, tt.wantOlderThan)
+			}
+			if !published.Equal(tt.wantPublished) {
+				t.Errorf("cutoffs() publishedOlderThan = %v, want %v", published, tt.wantPublished)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"strings"
+)
+
+// ChannelRoute sends the news to the named channel if any of its tickers, markets, hashtags or entities matches.
+type ChannelRoute struct {
+	Channel  string   // name of the channel in the publisher (e.g. "crypto")
+	Tickers  []string // e.g. "COIN", "MSTR"
+	Markets  []string // e.g. "crypto"
+	Hashtags []string // e.g. "bitcoin", without the "#"
+	Entities []string // companies, people, central banks or countries, e.g. "Fed", "Jerome Powell"
+}
+
+// Router picks the channel for the composed news by its meta. Routes are checked in order,
+// the first matching route wins. Values are compared case-insensitively.
+type Router struct {
+	routes []ChannelRoute
+}
+
+// NewRouter creates a new Router with the given routes.
+func NewRouter(routes []ChannelRoute) *Router {
+	return &Router{routes: routes}
+}
+
+// Route returns the channel name for the news meta or empty string if no route matches.
+func (r *Router) Route(meta composer.ComposedMeta) string {
+	if r == nil {
+		return ""
+	}
+
+	for _, route := range r.routes {
+		if containsAny(route.Tickers, meta.Tickers) ||
+			containsAny(route.Markets, meta.Markets) ||
+			containsAny(route.Hashtags, meta.Hashtags) ||
+			containsAny(route.Entities, meta.Entities.Names()) {
+			return route.Channel
+		}
+	}
+
+	return ""
+}
+
+// containsAny checks if any of the values is in the list (case-insensitive, "#" prefix is ignored).
+func containsAny(list, values []string) bool {
+	for _, l := range list {
+		for _, v := range values {
+			if strings.EqualFold(strings.TrimPrefix(l, "#"), strings.TrimPrefix(v, "#")) {
+				return true
+			}
+		}
+	}
+	return false
+}
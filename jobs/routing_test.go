@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"testing"
+)
+
+func TestRouter_Route(t *testing.T) {
+	router := NewRouter([]ChannelRoute{
+		{Channel: "earnings", Hashtags: []string{"earnings"}},
+		{Channel: "crypto", Tickers: []string{"COIN", "MSTR"}, Markets: []string{"crypto"}, Hashtags: []string{"#bitcoin"}},
+		{Channel: "macro", Markets: []string{"bonds", "forex"}, Entities: []string{"Fed", "ECB"}},
+	})
+
+	tests := []struct {
+		name   string
+		router *Router
+		meta   composer.ComposedMeta
+		want   string
+	}{
+		{
+			name:   "by ticker",
+			router: router,
+			meta:   composer.ComposedMeta{Tickers: []string{"AAPL", "COIN"}},
+			want:   "crypto",
+		},
+		{
+			name:   "by market case-insensitive",
+			router: router,
+			meta:   composer.ComposedMeta{Markets: []string{"Bonds"}},
+			want:   "macro",
+		},
+		{
+			name:   "by hashtag with prefix",
+			router: router,
+			meta:   composer.ComposedMeta{Hashtags: []string{"bitcoin"}},
+			want:   "crypto",
+		},
+		{
+			name:   "by entity",
+			router: router,
+			meta:   composer.ComposedMeta{Entities: &composer.Entities{People: []string{"Jerome Powell"}, CentralBanks: []string{"fed"}}},
+			want:   "macro",
+		},
+		{
+			name:   "first route wins",
+			router: router,
+			meta:   composer.ComposedMeta{Tickers: []string{"COIN"}, Hashtags: []string{"earnings"}},
+			want:   "earnings",
+		},
+		{
+			name:   "no match",
+			router: router,
+			meta:   composer.ComposedMeta{Tickers: []string{"AAPL"}},
+			want:   "",
+		},
+		{
+			name:   "nil router",
+			router: nil,
+			meta:   composer.ComposedMeta{Tickers: []string{"COIN"}},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.router.Route(tt.meta); got != tt.want {
+				t.Errorf("Route() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
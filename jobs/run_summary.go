@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RunSummaryMode defines which runs of the job are summarized to the admin chat (see Job.SummarizeRuns).
+type RunSummaryMode string
+
+const (
+	RunSummaryAlways RunSummaryMode = "always" // every run, even without the news
+	RunSummaryActive RunSummaryMode = "active" // only the runs that fetched the new news or failed
+)
+
+// runSummary sends the compact summary of the job runs to the admin chat.
+type runSummary struct {
+	sender alertSender
+	chatID string
+	mode   RunSummaryMode
+}
+
+// SummarizeRuns sets the admin chat (name or chat ID) where the compact summary of the runs is sent
+// ("fetched 12, deduped 4, composed 3, published 3"), so the pipeline health can be watched without Sentry.
+// Paused runs are not summarized.
+func (job *Job) SummarizeRuns(sender alertSender, chatID string, mode RunSummaryMode) *Job {
+	job.summary = &runSummary{sender: sender, chatID: chatID, mode: mode}
+	return job
+}
+
+// sendRunSummary sends the summary of the run to the admin chat. err is the error of the stage the run stopped at.
+// Errors of sending are only logged, the run is not affected.
+func (job *Job) sendRunSummary(run RunInfo, err error) {
+	s := job.summary
+	if s == nil || run.Paused {
+		return
+	}
+	if s.mode == RunSummaryActive && run.Deduped == 0 && run.Published == 0 && err == nil {
+		return
+	}
+
+	if _, e := s.sender.PublishTo(s.chatID, formatRunSummary(job.journalist.Name, run, err)); e != nil {
+		job.logger.Warn("[run summary] Error sending run summary", "job", job.name, "error", e)
+	}
+}
+
+// formatRunSummary formats the run summary in Telegram Markdown.
+func formatRunSummary(job string, run RunInfo, err error) string {
+	icon, failure := "✅", "none"
+	if err != nil {
+		icon = "⚠️"
+		failure = err.Error()
+		if len(failure) > 300 {
+			failure = failure[:300] + "..."
+		}
+		// Backticks will break the code span
+		failure = "`" + strings.ReplaceAll(failure, "`", "'") + "`"
+	}
+
+	return fmt.Sprintf("%s *Run summary*\nJob: %s (%s)\nFetched %d, deduped %d, composed %d, published %d\nErrors: %s",
+		icon, job, run.Duration.Round(100*time.Millisecond), run.Fetched, run.Deduped, run.Composed, run.Published, failure)
+}
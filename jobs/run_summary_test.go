@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestJob_sendRunSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		mode RunSummaryMode
+		run  RunInfo
+		err  error
+		want string
+	}{
+		{
+			name: "summary of the run",
+			mode: RunSummaryAlways,
+			run:  RunInfo{Duration: 3210 * time.Millisecond, Fetched: 12, Deduped: 4, Composed: 3, Published: 3},
+			want: "✅ *Run summary*\nJob: market (3.2s)\nFetched 12, deduped 4, composed 3, published 3\nErrors: none",
+		},
+		{
+			name: "failed run",
+			mode: RunSummaryActive,
+			run:  RunInfo{Duration: time.Second, Fetched: 5},
+			err:  errors.New("[market][saveNews]: `db` is down"),
+			want: "⚠️ *Run summary*\nJob: market (1s)\nFetched 5, deduped 0, composed 0, published 0\nErrors: `[market][saveNews]: 'db' is down`",
+		},
+		{
+			name: "run without new news in active mode",
+			mode: RunSummaryActive,
+			run:  RunInfo{Duration: time.Second, Fetched: 5},
+		},
+		{
+			name: "paused run",
+			mode: RunSummaryAlways,
+			run:  RunInfo{Paused: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeAlertSender{}
+			job := (&Job{
+				journalist: journalist.NewJournalist("market", nil),
+				logger:     slog.Default(),
+			}).SummarizeRuns(sender, "admin", tt.mode)
+
+			job.sendRunSummary(tt.run, tt.err)
+
+			if tt.want == "" {
+				if len(sender.messages) != 0 {
+					t.Errorf("sendRunSummary() sent %q, want no summary", sender.messages)
+				}
+				return
+			}
+			if len(sender.messages) != 1 || sender.messages[0] != tt.want {
+				t.Errorf("sendRunSummary() sent %q, want %q", sender.messages, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"math"
+	"time"
+)
+
+// RemoveSimilar sets the options that will remove news semantically similar to the news fetched within
+// the window (e.g. the same story from another provider) or to the other news in the same batch.
+// minSimilarity is the cosine similarity of the news embeddings (0..1), e.g. 0.9.
+// Note: requires RemoveClones to be set and the embeddings table to be migrated (Archivist.MigrateEmbeddings).
+func (job *Job) RemoveSimilar(window time.Duration, minSimilarity float64) *Job {
+	job.options.similarityWindow = window
+	job.options.minSimilarity = minSimilarity
+	return job
+}
+
+// removeSimilar removes news similar to the recent or other news in the batch and saves embeddings of the rest.
+// Errors are reported, but don't stop the job: news are returned unchanged, exact duplicates are removed anyway.
+func (job *Job) removeSimilar(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) journalist.NewsList {
+	if job.options.similarityWindow == 0 || !job.options.shouldRemoveClones || len(news) == 0 {
+		return news
+	}
+
+	texts := make([]string, len(news))
+	for i, n := range news {
+		texts[i] = n.Title + "\n" + n.Description
+	}
+
+	span := tx.StartChild("removeSimilar.Embed")
+	start := time.Now()
+	embeddings, err := job.composer.Embed(ctx, texts)
+	job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", "embed"))
+	span.Finish()
+	if err != nil {
+		job.similarityError(hub, fmt.Errorf("[%s][removeSimilar.Embed]: %w", job.name, err))
+		return news
+	}
+
+	maxDistance := 1 - job.options.minSimilarity
+	since := time.Now().Add(-job.options.similarityWindow)
+
+	span = tx.StartChild("removeSimilar.Embeddings.FindSimilar")
+	result := make(journalist.NewsList, 0, len(news))
+	kept := make([]*archivist.NewsEmbedding, 0, len(news))
+	for i, n := range news {
+		v := archivist.Vector(embeddings[i])
+		if hasSimilar(v, kept, maxDistance) {
+			continue
+		}
+
+		similar, err := job.archivist.Entities.Embeddings.FindSimilar(ctx, v, since, maxDistance)
+		if err != nil {
+			span.Finish()
+			job.similarityError(hub, fmt.Errorf("[%s][removeSimilar.Embeddings.FindSimilar]: %w", job.name, err))
+			return news
+		}
+		if similar != nil {
+			job.logger.InfoContext(ctx, fmt.Sprintf("[%s] news %s is similar to %s (distance %.3f)", job.name, n.ID, similar.Hash, similar.Distance))
+			continue
+		}
+
+		result = append(result, n)
+		kept = append(kept, &archivist.NewsEmbedding{Hash: n.ID, Embedding: v})
+	}
+	span.Finish()
+
+	span = tx.StartChild("removeSimilar.Embeddings.Create")
+	err = job.archivist.Entities.Embeddings.Create(ctx, kept)
+	span.Finish()
+	if err != nil {
+		job.similarityError(hub, fmt.Errorf("[%s][removeSimilar.Embeddings.Create]: %w", job.name, err))
+	}
+
+	job.metrics.Count(metrics.NewsDuplicates, int64(len(news)-len(result)), job.metricsTag(), metrics.T("type", "similar"))
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("removeSimilar returned %d news", len(result)),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return result
+}
+
+// similarityError reports the error of the similarity check without failing the job.
+func (job *Job) similarityError(hub *sentry.Hub, e error) {
+	job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "similarity"))
+	job.logger.Warn(e.Error())
+	utils.CaptureSentryException("jobRemoveSimilarError", hub, e)
+	job.alerter.Alert(job.name, "similarity", e)
+}
+
+// hasSimilar checks if any of the embeddings is within maxDistance from the vector.
+func hasSimilar(v archivist.Vector, embeddings []*archivist.NewsEmbedding, maxDistance float64) bool {
+	for _, e := range embeddings {
+		if cosineDistance(v, e.Embedding) <= maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// cosineDistance returns the cosine distance (1 - cosine similarity) between the vectors,
+// the same as pgvector's <=> operator. Vectors of different length or zero vectors are the most distant.
+func cosineDistance(a, b archivist.Vector) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 2
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 2
+	}
+
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"math"
+	"testing"
+)
+
+func Test_cosineDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a    archivist.Vector
+		b    archivist.Vector
+		want float64
+	}{
+		{
+			name: "same direction",
+			a:    archivist.Vector{1, 2, 3},
+			b:    archivist.Vector{2, 4, 6},
+			want: 0,
+		},
+		{
+			name: "orthogonal",
+			a:    archivist.Vector{1, 0},
+			b:    archivist.Vector{0, 1},
+			want: 1,
+		},
+		{
+			name: "opposite",
+			a:    archivist.Vector{1, 0},
+			b:    archivist.Vector{-1, 0},
+			want: 2,
+		},
+		{
+			name: "different length",
+			a:    archivist.Vector{1, 0},
+			b:    archivist.Vector{1, 0, 0},
+			want: 2,
+		},
+		{
+			name: "zero vector",
+			a:    archivist.Vector{0, 0},
+			b:    archivist.Vector{1, 0},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineDistance(tt.a, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("cosineDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hasSimilar(t *testing.T) {
+	kept := []*archivist.NewsEmbedding{
+		{Hash: "1", Embedding: archivist.Vector{1, 0, 0}},
+		{Hash: "2", Embedding: archivist.Vector{0, 1, 0}},
+	}
+
+	tests := []struct {
+		name string
+		v    archivist.Vector
+		want bool
+	}{
+		{
+			name: "near duplicate",
+			v:    archivist.Vector{0.1, 1, 0},
+			want: true,
+		},
+		{
+			name: "different story",
+			v:    archivist.Vector{0.5, 0.5, 1},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasSimilar(tt.v, kept, 0.1); got != tt.want {
+				t.Errorf("hasSimilar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
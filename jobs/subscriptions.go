@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/pkg/observability"
+	"strings"
+)
+
+// subscriptionPrefix is the prefix of the ticker channel targets in News.Publications, followed by the channel.
+const subscriptionPrefix = "subscription:"
+
+// SubscribeTickers sets the channels subscribed to the news of the tickers, e.g. {"TSLA": "@tsla_news"}.
+// The published news are also published to the channels of their tickers, once per channel.
+// Channels are the names (see publisher.TelegramPublisher.Channels) or chat IDs. Note: requires ComposeText to be set.
+func (job *Job) SubscribeTickers(subscriptions map[string]string) *Job {
+	job.options.subscriptions = make(map[string]string, len(subscriptions))
+	for ticker, channel := range subscriptions {
+		job.options.subscriptions[normalizeTicker(ticker)] = channel
+	}
+	return job
+}
+
+// publishSubscriptions publishes the published news to the channels subscribed to their tickers.
+// Each news is published to the channel once, even if the channel is subscribed to several of its tickers,
+// and not published again to the channel it is already published to. Errors are reported, but don't stop
+// the job, because the news are already published to the main channel.
+func (job *Job) publishSubscriptions(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news []*archivist.News) {
+	if len(job.options.subscriptions) == 0 || !job.options.shouldComposeText || len(news) == 0 {
+		return
+	}
+
+	for _, n := range news {
+		channels := job.subscribedChannels(n)
+		if len(channels) == 0 {
+			continue
+		}
+
+		ids := publicationIDs(n)
+		text, changes := job.formatNews(ctx, n)
+		for _, channel := range channels {
+			chatID := job.publisher.ChatID(channel)
+			claimed, _, err := job.claimPublication(ctx, hub, n.Hash, chatID)
+			if err != nil || !claimed {
+				continue
+			}
+
+			span := tx.StartChild("publishSubscriptions.Publish")
+			span.SetTag(observability.TagChannel, channel)
+			id, err := job.send(ctx, span, channel, *n, text, changes)
+			span.Finish()
+			if err != nil {
+				job.releasePublication(ctx, hub, n.Hash, chatID)
+				job.reportSubscriptionError(hub, "publishSubscriptions.Publish", err)
+				continue
+			}
+			_ = job.completePublication(ctx, hub, n.Hash, chatID, id, nil)
+			job.metrics.Count(metrics.NewsPublished, 1, job.metricsTag(), metrics.T("channel", channel))
+
+			ids[subscriptionPrefix+channel] = id
+		}
+
+		n.Publications, _ = json.Marshal(ids)
+		_ = job.persistNews(ctx, hub, n) // error is reported, the news are already published anyway
+	}
+}
+
+// subscribedChannels returns the channels subscribed to the tickers of the news in the order of the tickers,
+// without the duplicates and the channel the news is published to.
+func (job *Job) subscribedChannels(n *archivist.News) []string {
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{job.publisher.ChatID(n.ChannelID): true}
+	var channels []string
+	for _, ticker := range meta.Tickers {
+		channel, ok := job.options.subscriptions[normalizeTicker(ticker)]
+		if !ok {
+			continue
+		}
+		if chatID := job.publisher.ChatID(channel); !seen[chatID] {
+			seen[chatID] = true
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// updateSubscriptions updates the messages of the corrected news in the ticker channels.
+func (job *Job) updateSubscriptions(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, n *archivist.News) {
+	var text string
+	var changes map[string]float64
+	for target, pubID := range publicationIDs(n) {
+		channel, ok := strings.CutPrefix(target, subscriptionPrefix)
+		if !ok {
+			continue
+		}
+		if text == "" {
+			text, changes = job.formatNews(ctx, n)
+		}
+
+		span := tx.StartChild("updateSubscriptions.Update")
+		err := job.update(ctx, channel, pubID, *n, text, changes)
+		span.Finish()
+		if err != nil {
+			job.reportSubscriptionError(hub, "updateSubscriptions.Update", err)
+		}
+	}
+}
+
+// deleteSubscriptions deletes the messages of the news from the ticker channels.
+func (job *Job) deleteSubscriptions(tx *sentry.Span, hub *sentry.Hub, n *archivist.News) {
+	for target, pubID := range publicationIDs(n) {
+		channel, ok := strings.CutPrefix(target, subscriptionPrefix)
+		if !ok {
+			continue
+		}
+
+		span := tx.StartChild("deleteSubscriptions.Delete")
+		err := job.publisher.DeletePublicationIn(channel, pubID)
+		span.Finish()
+		if err != nil {
+			job.reportSubscriptionError(hub, "deleteSubscriptions.Delete", err)
+		}
+	}
+}
+
+// reportSubscriptionError reports the failed publication to the ticker channel. It doesn't stop the job,
+// because the news is already published to the main channel.
+func (job *Job) reportSubscriptionError(hub *sentry.Hub, op string, err error) {
+	job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "subscriptions"))
+	e := fmt.Errorf("[%s][%s]: %w", job.name, op, err)
+	job.logger.Warn(e.Error())
+	utils.CaptureSentryException("jobSubscriptionError", hub, e)
+	job.alerter.Alert(job.name, "subscriptions", e)
+}
+
+// normalizeTicker returns the upper-case ticker without the "$" prefix.
+func normalizeTicker(ticker string) string {
+	return strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(ticker), "$"))
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"reflect"
+	"testing"
+)
+
+func TestJob_subscribedChannels(t *testing.T) {
+	job := (&Job{
+		publisher: &publisher.TelegramPublisher{ChannelID: "@main", Channels: map[string]string{"tesla": "@tsla_news"}},
+		options:   &jobOptions{},
+	}).SubscribeTickers(map[string]string{"TSLA": "tesla", "$nvda": "@nvda_news", "AMD": "@nvda_news", "SPY": "@main"})
+
+	tests := []struct {
+		name string
+		news *archivist.News
+		want []string
+	}{
+		{
+			name: "channels in the order of the tickers",
+			news: &archivist.News{MetaData: []byte(`{"tickers":["NVDA","$tsla","AAPL"]}`)},
+			want: []string{"@nvda_news", "tesla"},
+		},
+		{
+			name: "channel subscribed to several tickers",
+			news: &archivist.News{MetaData: []byte(`{"tickers":["NVDA","AMD"]}`)},
+			want: []string{"@nvda_news"},
+		},
+		{
+			name: "channel of the news is skipped",
+			news: &archivist.News{ChannelID: "tesla", MetaData: []byte(`{"tickers":["TSLA","SPY"]}`)},
+			want: []string{"@main"},
+		},
+		{
+			name: "default channel is skipped",
+			news: &archivist.News{MetaData: []byte(`{"tickers":["SPY"]}`)},
+		},
+		{
+			name: "no tickers",
+			news: &archivist.News{MetaData: []byte(`{"tickers":[]}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := job.subscribedChannels(tt.news); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subscribedChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
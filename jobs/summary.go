@@ -8,6 +8,8 @@ import (
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/composer"
 	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/metrics"
 	"github.com/samgozman/fin-thread/publisher"
 	"log/slog"
 	"strings"
@@ -19,6 +21,8 @@ type SummaryJob struct {
 	publisher *publisher.TelegramPublisher // publisher that will publish news to the channel
 	archivist *archivist.Archivist         // archivist that will save news to the database
 	logger    *slog.Logger                 // special logger for the job
+	metrics   metrics.Emitter              // metrics emitter for job counters and latencies
+	alerter   *Alerter                     // sends alerts to the admin chat on failures (optional)
 }
 
 func NewSummaryJob(
@@ -30,15 +34,28 @@ func NewSummaryJob(
 		composer:  composer,
 		publisher: publisher,
 		archivist: archivist,
-		logger:    slog.Default(),
+		logger:    logging.For(logging.ModuleJobs),
+		metrics:   metrics.Noop{},
 	}
 }
 
+// WithMetrics sets the metrics emitter for the job counters and latencies.
+func (j *SummaryJob) WithMetrics(m metrics.Emitter) *SummaryJob {
+	j.metrics = m
+	return j
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *SummaryJob) WithAlerter(a *Alerter) *SummaryJob {
+	j.alerter = a
+	return j
+}
+
 // Run runs the Summary job. From if the time from which events should be processed.
 func (j *SummaryJob) Run(from time.Time) JobFunc {
 	return func() {
-		_ = retry.Do(func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+		err := retry.Do(func() error {
+			ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 25*time.Second)
 			defer cancel()
 
 			tx := sentry.StartTransaction(ctx, "RunSummaryJob")
@@ -61,7 +78,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("error fetching news from the database: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "database",
 					Message:  "Error fetching news from the database",
@@ -82,7 +99,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("error fetching events from the database: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "database",
 					Message:  "Error fetching events from the database",
@@ -99,7 +116,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 			}, nil)
 
 			if sum := len(events) + len(news); sum < 5 {
-				j.logger.Info("No news or events to process (or total < 5)")
+				j.logger.InfoContext(ctx, "No news or events to process (or total < 5)")
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "successful",
 					Message:  fmt.Sprintf("Sum of news & events = %d, which is below summary threshold (5). ", sum),
@@ -117,11 +134,13 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 			}
 
 			span = sentry.StartSpan(ctx, "Summarise", sentry.WithTransactionName("SummaryJob.Run"))
+			start := time.Now()
 			summarised, err := j.composer.Summarise(ctx, headlines, 20, 2048)
+			j.metrics.Timing(metrics.LLMLatency, time.Since(start), metrics.T("job", "Summary"), metrics.T("stage", "summarise"))
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("error summarising news: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "composer",
 					Message:  "Error composing summary",
@@ -131,7 +150,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 				return e
 			}
 			if len(summarised) == 0 {
-				j.logger.Info("No summarised news")
+				j.logger.InfoContext(ctx, "No summarised news")
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "debug",
 					Message:  "No summarised news",
@@ -148,7 +167,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 
 			message := formatSummary(summarised, from)
 			if message == "" {
-				j.logger.Info("No summary message")
+				j.logger.InfoContext(ctx, "No summary message")
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "debug",
 					Message:  "No summary message",
@@ -163,7 +182,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 			span.Finish()
 			if err != nil {
 				e := fmt.Errorf("error publishing summary: %w", err)
-				j.logger.Error(e.Error())
+				j.logger.ErrorContext(ctx, e.Error())
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "publisher",
 					Message:  "Error publishing summary",
@@ -186,6 +205,7 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 			retry.Attempts(5),
 			retry.Delay(10*time.Minute),
 		)
+		j.alerter.Alert("SummaryJob", "run", err)
 	}
 }
 
@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/pkg/observability"
+	"strings"
+	"time"
+)
+
+// translationPrefix is the prefix of the translation targets in News.Publications, followed by the chat ID.
+const translationPrefix = "translation:"
+
+// Translation is the channel where the published news are also published translated into its language.
+type Translation struct {
+	Channel  string // name of the channel (see publisher.TelegramPublisher.Channels) or chat ID
+	Language string // language of the channel in English, e.g. "German"
+}
+
+// TranslateTo sets the channels where the published news are also published translated into their languages
+// by the Composer.Translate. Note: requires ComposeText to be set.
+func (job *Job) TranslateTo(translations ...Translation) *Job {
+	job.options.translations = translations
+	return job
+}
+
+// publishTranslations translates the published news into the languages of the translation channels
+// and publishes them there. Errors are reported, but don't stop the job, because the news are already
+// published to the main channel.
+func (job *Job) publishTranslations(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news []*archivist.News) {
+	if len(job.options.translations) == 0 || !job.options.shouldComposeText || len(news) == 0 {
+		return
+	}
+
+	for _, t := range job.options.translations {
+		translated, err := job.translate(ctx, tx, t.Language, news)
+		if err != nil {
+			job.reportTranslationError(hub, "publishTranslations.Translate", err)
+			continue
+		}
+
+		chatID := job.publisher.ChatID(t.Channel)
+		for _, n := range news {
+			tn, ok := translatedNews(*n, translated)
+			if !ok {
+				continue
+			}
+			claimed, _, err := job.claimPublication(ctx, hub, n.Hash, chatID)
+			if err != nil || !claimed {
+				continue
+			}
+			text, changes := job.formatNews(ctx, &tn)
+
+			span := tx.StartChild("publishTranslations.Publish")
+			span.SetTag(observability.TagLanguage, t.Language)
+			id, err := job.send(ctx, span, chatID, tn, text, changes)
+			span.Finish()
+			if err != nil {
+				job.releasePublication(ctx, hub, n.Hash, chatID)
+				job.reportTranslationError(hub, "publishTranslations.Publish", err)
+				continue
+			}
+			_ = job.completePublication(ctx, hub, n.Hash, chatID, id, nil)
+
+			ids := publicationIDs(n)
+			ids[translationPrefix+chatID] = id
+			n.Publications, _ = json.Marshal(ids)
+		}
+	}
+
+	for _, n := range news {
+		_ = job.persistNews(ctx, hub, n) // error is reported, the translations are already published anyway
+	}
+}
+
+// updateTranslations translates the corrected news again and updates its messages in the translation channels.
+func (job *Job) updateTranslations(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, n *archivist.News) {
+	ids := publicationIDs(n)
+	for _, t := range job.options.translations {
+		chatID := job.publisher.ChatID(t.Channel)
+		pubID, ok := ids[translationPrefix+chatID]
+		if !ok {
+			continue
+		}
+
+		translated, err := job.translate(ctx, tx, t.Language, []*archivist.News{n})
+		if err != nil {
+			job.reportTranslationError(hub, "updateTranslations.Translate", err)
+			continue
+		}
+		tn, ok := translatedNews(*n, translated)
+		if !ok {
+			continue
+		}
+		text, changes := job.formatNews(ctx, &tn)
+
+		span := tx.StartChild("updateTranslations.Update")
+		err = job.update(ctx, chatID, pubID, tn, text, changes)
+		span.Finish()
+		if err != nil {
+			job.reportTranslationError(hub, "updateTranslations.Update", err)
+		}
+	}
+}
+
+// deleteTranslations deletes the messages of the news from the translation channels.
+func (job *Job) deleteTranslations(tx *sentry.Span, hub *sentry.Hub, n *archivist.News) {
+	for target, pubID := range publicationIDs(n) {
+		chatID, ok := strings.CutPrefix(target, translationPrefix)
+		if !ok {
+			continue
+		}
+
+		span := tx.StartChild("deleteTranslations.Delete")
+		err := job.publisher.DeletePublicationIn(chatID, pubID)
+		span.Finish()
+		if err != nil {
+			job.reportTranslationError(hub, "deleteTranslations.Delete", err)
+		}
+	}
+}
+
+// translate translates the titles and composed texts of the news into the language, the translations are by the news hash.
+func (job *Job) translate(
+	ctx context.Context,
+	tx *sentry.Span,
+	language string,
+	news []*archivist.News,
+) (map[string]*composer.TranslatedNews, error) {
+	texts := make([]*composer.TranslatedNews, 0, len(news))
+	for _, n := range news {
+		if n.ComposedText != "" {
+			texts = append(texts, &composer.TranslatedNews{ID: n.Hash, Title: n.OriginalTitle, Text: n.ComposedText})
+		}
+	}
+
+	span := tx.StartChild("translate")
+	span.SetTag(observability.TagLanguage, language)
+	start := time.Now()
+	translated, err := job.composer.Translate(ctx, texts, language)
+	job.metrics.Timing(metrics.LLMLatency, time.Since(start), job.metricsTag(), metrics.T("stage", "translate"))
+	span.Finish()
+
+	return translated, err
+}
+
+// translatedNews returns the copy of the news with the translated title and composed text.
+// Returns false if the news is not translated.
+func translatedNews(n archivist.News, translated map[string]*composer.TranslatedNews) (archivist.News, bool) {
+	t, ok := translated[n.Hash]
+	if !ok {
+		return n, false
+	}
+
+	n.ComposedText = t.Text
+	if t.Title != "" {
+		n.OriginalTitle = t.Title
+	}
+	return n, true
+}
+
+// reportTranslationError reports the failed translation or its publication. It doesn't stop the job,
+// because the news is already published to the main channel.
+func (job *Job) reportTranslationError(hub *sentry.Hub, op string, err error) {
+	job.metrics.Count(metrics.JobErrors, 1, job.metricsTag(), metrics.T("stage", "translate"))
+	e := fmt.Errorf("[%s][%s]: %w", job.name, op, err)
+	job.logger.Warn(e.Error())
+	utils.CaptureSentryException("jobTranslationError", hub, e)
+	job.alerter.Alert(job.name, "translate", e)
+}
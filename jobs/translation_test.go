@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"testing"
+)
+
+func TestTranslatedNews(t *testing.T) {
+	n := archivist.News{Hash: "1", OriginalTitle: "Fed holds rates", ComposedText: "Fed holds rates steady"}
+	tests := []struct {
+		name       string
+		translated map[string]*composer.TranslatedNews
+		want       archivist.News
+		wantOK     bool
+	}{
+		{
+			name:       "translated title and text",
+			translated: map[string]*composer.TranslatedNews{"1": {ID: "1", Title: "Fed hält Zinsen", Text: "Die Fed lässt die Zinsen unverändert"}},
+			want:       archivist.News{Hash: "1", OriginalTitle: "Fed hält Zinsen", ComposedText: "Die Fed lässt die Zinsen unverändert"},
+			wantOK:     true,
+		},
+		{
+			name:       "title is kept if not translated",
+			translated: map[string]*composer.TranslatedNews{"1": {ID: "1", Text: "Die Fed lässt die Zinsen unverändert"}},
+			want:       archivist.News{Hash: "1", OriginalTitle: "Fed holds rates", ComposedText: "Die Fed lässt die Zinsen unverändert"},
+			wantOK:     true,
+		},
+		{
+			name:       "not translated",
+			translated: map[string]*composer.TranslatedNews{"2": {ID: "2", Text: "Andere Nachricht"}},
+			want:       n,
+			wantOK:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := translatedNews(n, tt.translated)
+			if ok != tt.wantOK || got.OriginalTitle != tt.want.OriginalTitle || got.ComposedText != tt.want.ComposedText {
+				t.Errorf("translatedNews() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if n.ComposedText != "Fed holds rates steady" {
+		t.Errorf("translatedNews() changed the original news: %+v", n)
+	}
+}
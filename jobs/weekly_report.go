@@ -0,0 +1,221 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/observability"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	weeklyReportTickers  = 10 // number of the most mentioned tickers in the report
+	weeklyReportMinCount = 2  // tickers with fewer mentions are not included
+	weeklyReportTopPosts = 5  // number of the most viewed posts in the report
+)
+
+// topPostMarkdown are the characters replaced in the titles of the top posts, so they don't break the Markdown links.
+var topPostMarkdown = strings.NewReplacer("[", "", "]", "", "_", " ", "*", "", "`", "")
+
+// WeeklyReportJob publishes the weekly "news vs price" recap: the most mentioned tickers of the week
+// with the number of news about them and their weekly price change, followed by the most viewed posts
+// of the week if their engagement stats are collected (see EngagementJob).
+type WeeklyReportJob struct {
+	marketData *marketdata.MarketData       // market data scavenger that will fetch price performance
+	publisher  *publisher.TelegramPublisher // publisher that will publish the report to the channel
+	archivist  *archivist.Archivist         // archivist that will read the week news from the database
+	logger     *slog.Logger                 // special logger for the job
+	alerter    *Alerter                     // sends alerts to the admin chat on failures (optional)
+}
+
+func NewWeeklyReportJob(
+	marketData *marketdata.MarketData,
+	publisher *publisher.TelegramPublisher,
+	archivist *archivist.Archivist,
+) *WeeklyReportJob {
+	return &WeeklyReportJob{
+		marketData: marketData,
+		publisher:  publisher,
+		archivist:  archivist,
+		logger:     logging.For(logging.ModuleJobs),
+	}
+}
+
+// WithAlerter sets the Alerter that will notify the admin chat about failed runs.
+func (j *WeeklyReportJob) WithAlerter(a *Alerter) *WeeklyReportJob {
+	j.alerter = a
+	return j
+}
+
+// tickerReport is the single row of the weekly report.
+type tickerReport struct {
+	Ticker   string
+	Mentions int
+	Change   *float64 // weekly price change, nil if market data is not available
+}
+
+// Run creates the report for the last 7 days. It should be run on weekends.
+func (j *WeeklyReportJob) Run() JobFunc {
+	return func() {
+		err := j.run()
+		j.alerter.Alert("WeeklyReportJob", "run", err)
+	}
+}
+
+func (j *WeeklyReportJob) run() error {
+	ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), 2*time.Minute)
+	defer cancel()
+
+	tx := sentry.StartTransaction(ctx, "RunWeeklyReportJob")
+	tx.Op = "job-weekly-report"
+
+	// Sentry performance monitoring
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+	defer hub.Recover(nil)
+
+	to := time.Now().UTC()
+	from := to.Truncate(24*time.Hour).AddDate(0, 0, -7)
+
+	span := tx.StartChild("News.TopTickers")
+	counts, err := j.archivist.Entities.News.TopTickers(ctx, from, weeklyReportTickers)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-weekly-report] Error counting tickers in the database: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobWeeklyReportTopTickersError", hub, e)
+		return e
+	}
+
+	// Top posts are optional, the report is published without them on failure
+	span = tx.StartChild("News.FindTopEngaged")
+	top, err := j.archivist.Entities.News.FindTopEngaged(ctx, from, weeklyReportTopPosts)
+	span.Finish()
+	if err != nil {
+		j.logger.WarnContext(ctx, "[job-weekly-report] Error finding top posts", "error", err)
+	}
+
+	reports := tickerReports(counts)
+	if len(reports) == 0 && len(top) == 0 {
+		j.logger.InfoContext(ctx, "[job-weekly-report] No tickers mentioned this week")
+		return nil
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("Found %d most mentioned tickers", len(reports)),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	// Missing prices are not fatal, the ticker is reported without the change
+	for _, r := range reports {
+		span = tx.StartChild("MarketData.FetchPerformance")
+		span.SetTag(observability.TagTicker, r.Ticker)
+		p, err := j.marketData.FetchPerformance(ctx, r.Ticker, from, to)
+		span.Finish()
+		if err != nil {
+			j.logger.WarnContext(ctx, "[job-weekly-report] Error fetching price performance", "ticker", r.Ticker, "error", err)
+			continue
+		}
+		r.Change = &p.Change
+	}
+
+	span = tx.StartChild("TelegramPublisher.Publish")
+	var parts []string
+	if len(reports) > 0 {
+		parts = append(parts, formatWeeklyReport(reports))
+	}
+	if len(top) > 0 {
+		parts = append(parts, formatTopPosts(top))
+	}
+	_, err = j.publisher.Publish(strings.Join(parts, "\n\n"))
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[job-weekly-report] Error publishing report: %w", err)
+		j.logger.ErrorContext(ctx, e.Error())
+		utils.CaptureSentryException("jobWeeklyReportPublishError", hub, e)
+		return e
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  "Weekly report published successfully",
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return nil
+}
+
+// tickerReports returns the report rows of the tickers with enough mentions.
+func tickerReports(counts []*archivist.TickerCount) []*tickerReport {
+	reports := make([]*tickerReport, 0, len(counts))
+	for _, c := range counts {
+		if c.Mentions >= weeklyReportMinCount {
+			reports = append(reports, &tickerReport{Ticker: c.Ticker, Mentions: c.Mentions})
+		}
+	}
+	return reports
+}
+
+// formatWeeklyReport formats the report as a monospace table.
+func formatWeeklyReport(reports []*tickerReport) string {
+	var m strings.Builder
+	m.WriteString("📊 #weekly\nMost mentioned tickers of the week: news vs price\n\n")
+	m.WriteString("```\n")
+	m.WriteString(fmt.Sprintf("%-6s %5s %8s\n", "Ticker", "News", "Week"))
+	for _, r := range reports {
+		change := "n/a"
+		if r.Change != nil {
+			change = fmt.Sprintf("%+.1f%%", *r.Change*100)
+		}
+		m.WriteString(fmt.Sprintf("%-6s %5d %8s\n", r.Ticker, r.Mentions, change))
+	}
+	m.WriteString("```")
+
+	return m.String()
+}
+
+// formatTopPosts formats the most viewed posts as the numbered list of the links with their views and reactions.
+func formatTopPosts(news []*archivist.News) string {
+	var m strings.Builder
+	m.WriteString("🏆 Top posts of the week\n")
+	for i, n := range news {
+		title := strings.TrimSpace(topPostMarkdown.Replace(n.OriginalTitle))
+		if r := []rune(title); len(r) > 80 {
+			title = strings.TrimSpace(string(r[:79])) + "…"
+		}
+		link := fmt.Sprintf("https://t.me/%s/%s", strings.TrimPrefix(n.ChannelID, "@"), n.PublicationID)
+		m.WriteString(fmt.Sprintf("\n%d. [%s](%s) 👁 %s", i+1, title, link, formatCount(n.Views)))
+		if n.Reactions > 0 {
+			m.WriteString(fmt.Sprintf(" · ❤️ %s", formatCount(n.Reactions)))
+		}
+	}
+
+	return m.String()
+}
+
+// formatCount shortens the counter the way Telegram does, e.g. 12400 is "12.4K".
+func formatCount(v int) string {
+	switch {
+	case v >= 1e6:
+		return strings.Replace(fmt.Sprintf("%.1fM", float64(v)/1e6), ".0M", "M", 1)
+	case v >= 1e3:
+		return strings.Replace(fmt.Sprintf("%.1fK", float64(v)/1e3), ".0K", "K", 1)
+	default:
+		return strconv.Itoa(v)
+	}
+}
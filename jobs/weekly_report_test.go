@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"reflect"
+	"testing"
+)
+
+func Test_tickerReports(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []*archivist.TickerCount
+		want   []*tickerReport
+	}{
+		{
+			name: "rare tickers skipped",
+			counts: []*archivist.TickerCount{
+				{Ticker: "MSFT", Mentions: 3},
+				{Ticker: "AAPL", Mentions: 2},
+				{Ticker: "NVDA", Mentions: 1},
+			},
+			want: []*tickerReport{
+				{Ticker: "MSFT", Mentions: 3},
+				{Ticker: "AAPL", Mentions: 2},
+			},
+		},
+		{
+			name:   "no tickers",
+			counts: nil,
+			want:   []*tickerReport{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tickerReports(tt.counts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tickerReports() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatWeeklyReport(t *testing.T) {
+	change := 0.034
+	reports := []*tickerReport{
+		{Ticker: "MSFT", Mentions: 12, Change: &change},
+		{Ticker: "AAPL", Mentions: 3},
+	}
+	want := "📊 #weekly\nMost mentioned tickers of the week: news vs price\n\n" +
+		"```\n" +
+		"Ticker  News     Week\n" +
+		"MSFT      12    +3.4%\n" +
+		"AAPL       3      n/a\n" +
+		"```"
+
+	if got := formatWeeklyReport(reports); got != want {
+		t.Errorf("formatWeeklyReport() = %q, want %q", got, want)
+	}
+}
+
+func Test_formatTopPosts(t *testing.T) {
+	news := []*archivist.News{
+		{OriginalTitle: "Fed holds [rates] steady", ChannelID: "@finthread", PublicationID: "42", Views: 12400, Reactions: 125},
+		{OriginalTitle: "Apple_beats estimates", ChannelID: "@finthread", PublicationID: "40", Views: 980},
+	}
+	want := "🏆 Top posts of the week\n" +
+		"\n1. [Fed holds rates steady](https://t.me/finthread/42) 👁 12.4K · ❤️ 125" +
+		"\n2. [Apple beats estimates](https://t.me/finthread/40) 👁 980"
+
+	if got := formatTopPosts(news); got != want {
+		t.Errorf("formatTopPosts() = %q, want %q", got, want)
+	}
+}
+
+func Test_formatCount(t *testing.T) {
+	tests := []struct {
+		v    int
+		want string
+	}{
+		{980, "980"},
+		{1000, "1K"},
+		{12400, "12.4K"},
+		{3000000, "3M"},
+	}
+	for _, tt := range tests {
+		if got := formatCount(tt.v); got != tt.want {
+			t.Errorf("formatCount(%d) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
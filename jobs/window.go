@@ -0,0 +1,284 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRoundupHeader is the message published before the news held outside the publishing window.
+const defaultRoundupHeader = "🌙 Overnight roundup"
+
+// DayRange is the daily time range of the publishing window, e.g. 07:00-23:00. The range wraps
+// around midnight if it ends before it starts, e.g. the quiet hours 23:00-07:00.
+type DayRange struct {
+	From time.Duration // start of the range since midnight
+	To   time.Duration // end of the range since midnight (exclusive)
+}
+
+// ParseDayRange parses the time range in the HH:MM-HH:MM format.
+func ParseDayRange(s string) (DayRange, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return DayRange{}, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", s)
+	}
+	var r DayRange
+	var err error
+	if r.From, err = parseClock(from); err != nil {
+		return DayRange{}, fmt.Errorf("invalid time range %q: %w", s, err)
+	}
+	if r.To, err = parseClock(to); err != nil {
+		return DayRange{}, fmt.Errorf("invalid time range %q: %w", s, err)
+	}
+	if r.From == r.To {
+		return DayRange{}, fmt.Errorf("invalid time range %q, it must not be empty", s)
+	}
+	return r, nil
+}
+
+// parseClock parses the time of the day in the HH:MM format, 24:00 is the end of the day.
+func parseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether the time of the day of t (in its location) is within the range.
+func (r DayRange) contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if r.From < r.To {
+		return clock >= r.From && clock < r.To
+	}
+	return clock >= r.From || clock < r.To
+}
+
+// PublishWindow is the time the news are published to the channel: within the Open range and outside
+// the quiet hours in the channel timezone. News composed outside the window are held and published
+// when it opens after the roundup header (see Job.WithPublishWindows).
+type PublishWindow struct {
+	Open     *DayRange      // news are published within this range only, all day if nil
+	Quiet    []DayRange     // news are not published within these ranges
+	Location *time.Location // timezone of the ranges, UTC if nil
+	Header   string         // message published before the held news, defaultRoundupHeader if empty
+
+	mu       sync.Mutex
+	headerAt time.Time // last time the header was published, shared by the jobs publishing to the channel
+}
+
+// NewPublishWindow creates the window by the range (e.g. "07:00-23:00", empty for all day), the comma-separated
+// quiet hours (e.g. "12:00-13:00,02:00-05:00"), the timezone name (e.g. "Europe/Berlin", UTC if empty)
+// and the roundup header. Returns nil if neither the range nor the quiet hours are set.
+func NewPublishWindow(open, quiet, timezone, header string) (*PublishWindow, error) {
+	if strings.TrimSpace(open) == "" && strings.TrimSpace(quiet) == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	w := &PublishWindow{Location: time.UTC, Header: header}
+	if strings.TrimSpace(open) != "" {
+		r, err := ParseDayRange(open)
+		if err != nil {
+			return nil, err
+		}
+		w.Open = &r
+	}
+	for _, s := range strings.Split(quiet, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		r, err := ParseDayRange(s)
+		if err != nil {
+			return nil, err
+		}
+		w.Quiet = append(w.Quiet, r)
+	}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		w.Location = loc
+	}
+
+	return w, nil
+}
+
+// IsOpen reports whether the news can be published at the time. The nil window is always open.
+func (w *PublishWindow) IsOpen(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	if w.Location != nil {
+		t = t.In(w.Location)
+	}
+	if w.Open != nil && !w.Open.contains(t) {
+		return false
+	}
+	for _, q := range w.Quiet {
+		if q.contains(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// header returns the roundup header if it was not published since the oldest held news was saved,
+// so the jobs releasing the news of the same night to the channel publish it once. Empty if published.
+func (w *PublishWindow) header(oldest, now time.Time) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.headerAt.After(oldest) {
+		return ""
+	}
+	w.headerAt = now
+	if w.Header == "" {
+		return defaultRoundupHeader
+	}
+	return w.Header
+}
+
+// WithPublishWindows sets the publishing windows of the channels by their names (empty for the default channel).
+// News to publish outside the window of their channel are held and published by PublishHeld when it opens.
+// The windows are shared by the jobs publishing to the same channels.
+//
+// Note: requires SaveToDB to be set.
+func (job *Job) WithPublishWindows(windows map[string]*PublishWindow) *Job {
+	job.windows = windows
+	return job
+}
+
+// holdClosed saves the news to publish to the channels with the closed window as held and returns the rest.
+func (job *Job) holdClosed(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	news []*archivist.News,
+) ([]*archivist.News, error) {
+	if len(job.windows) == 0 || !job.options.shouldSaveToDB {
+		return news, nil
+	}
+
+	publish, held := splitByWindow(news, job.windows, time.Now())
+	if len(held) == 0 {
+		return publish, nil
+	}
+
+	for _, n := range held {
+		n.State = archivist.NewsStateHeld
+	}
+	if err := job.updateNews(ctx, tx, hub, held); err != nil {
+		return nil, err
+	}
+
+	job.logger.InfoContext(ctx, fmt.Sprintf("[%s][holdClosed]: %d news held until the publishing window opens", job.name, len(held)))
+	return publish, nil
+}
+
+// splitByWindow splits the news to the ones which channel window is open at the time and the held ones.
+func splitByWindow(news []*archivist.News, windows map[string]*PublishWindow, now time.Time) (publish, held []*archivist.News) {
+	for _, n := range news {
+		if windows[n.Channel].IsOpen(now) {
+			publish = append(publish, n)
+		} else {
+			held = append(held, n)
+		}
+	}
+	return publish, held
+}
+
+// PublishHeld returns the job function that publishes the news held by the job to the channels which windows
+// are open, the oldest first after the roundup header. The per-run limit of the job (see LimitPublications)
+// applies to each channel. Held news older than a day are not published.
+//
+// Note: requires SaveToDB and WithPublishWindows to be set.
+func (job *Job) PublishHeld() JobFunc {
+	return func() {
+		if !job.options.shouldSaveToDB || len(job.windows) == 0 || job.control.Paused() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(logging.WithNewRunID(context.Background()), job.options.timeout)
+		defer cancel()
+
+		tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s.PublishHeld", job.name))
+		tx.Op = "job"
+
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub().Clone()
+			ctx = sentry.SetHubOnContext(ctx, hub)
+		}
+
+		defer tx.Finish()
+		defer hub.Flush(2 * time.Second)
+		defer hub.Recover(nil)
+
+		channels := make([]string, 0, len(job.windows))
+		for channel := range job.windows {
+			channels = append(channels, channel)
+		}
+		slices.Sort(channels)
+
+		now := time.Now()
+		for _, channel := range channels {
+			w := job.windows[channel]
+			if !w.IsOpen(now) {
+				continue
+			}
+
+			span := tx.StartChild("PublishHeld.News.FindHeld")
+			news, err := job.archivist.Entities.News.FindHeld(ctx, job.journalist.Name, channel, now.Add(-pendingMaxAge), job.options.maxPublish)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("[%s][PublishHeld.News.FindHeld]: %w", job.name, err)
+				utils.CaptureSentryException("jobPublishHeldError", hub, e)
+				job.alerter.Alert(job.name, "held", e)
+				return
+			}
+			if len(news) == 0 {
+				continue
+			}
+
+			// News are queued first, so the recovery publishes them if the run is interrupted
+			if err := job.queueNews(ctx, tx, hub, news); err != nil {
+				return
+			}
+
+			if header := w.header(news[0].CreatedAt, now); header != "" {
+				span = tx.StartChild("PublishHeld.PublishTo")
+				_, err := job.publisher.PublishTo(channel, header)
+				span.Finish()
+				if err != nil {
+					// The news are published without the header
+					job.logger.WarnContext(ctx, fmt.Sprintf("[%s][PublishHeld]: error publishing roundup header", job.name),
+						"channel", channel, "error", err)
+				}
+			}
+
+			publishCtx, cancelPublish := job.stageContext(ctx, StagePublish)
+			published, _ := job.publish(publishCtx, tx, hub, news)
+			job.publishTranslations(publishCtx, tx, hub, published)
+			job.publishSubscriptions(publishCtx, tx, hub, published)
+			cancelPublish()
+
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "successful",
+				Message:  fmt.Sprintf("PublishHeld published %d of %d held news to the channel %q", len(published), len(news), channel),
+				Level:    sentry.LevelInfo,
+			}, nil)
+		}
+	}
+}
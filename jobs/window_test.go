@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDayRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    DayRange
+		wantErr bool
+	}{
+		{
+			name: "day range",
+			s:    "07:00-23:30",
+			want: DayRange{From: 7 * time.Hour, To: 23*time.Hour + 30*time.Minute},
+		},
+		{
+			name: "until the end of the day",
+			s:    " 09:00 - 24:00 ",
+			want: DayRange{From: 9 * time.Hour, To: 24 * time.Hour},
+		},
+		{
+			name:    "missing end",
+			s:       "07:00",
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			s:       "7am-11pm",
+			wantErr: true,
+		},
+		{
+			name:    "empty range",
+			s:       "07:00-07:00",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDayRange(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDayRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDayRange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishWindow_IsOpen(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		open     string
+		quiet    string
+		timezone string
+		at       time.Time
+		want     bool
+	}{
+		{
+			name: "within the window",
+			open: "07:00-23:00",
+			at:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "before the window",
+			open: "07:00-23:00",
+			at:   time.Date(2024, 3, 1, 6, 59, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name:     "window in the channel timezone",
+			open:     "07:00-23:00",
+			timezone: "Europe/Berlin",
+			at:       time.Date(2024, 3, 1, 6, 30, 0, 0, berlin).UTC(),
+			want:     false,
+		},
+		{
+			name:  "quiet hours over midnight",
+			quiet: "23:00-07:00",
+			at:    time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC),
+			want:  false,
+		},
+		{
+			name:  "quiet hours within the window",
+			open:  "07:00-23:00",
+			quiet: "12:00-13:00",
+			at:    time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewPublishWindow(tt.open, tt.quiet, tt.timezone, "")
+			if err != nil {
+				t.Fatalf("NewPublishWindow() error = %v", err)
+			}
+			if got := w.IsOpen(tt.at); got != tt.want {
+				t.Errorf("IsOpen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPublishWindow(t *testing.T) {
+	w, err := NewPublishWindow("", "", "Europe/Berlin", "")
+	if err != nil || w != nil {
+		t.Errorf("NewPublishWindow() = %v, %v, want nil window without ranges", w, err)
+	}
+	if !w.IsOpen(time.Now()) {
+		t.Error("IsOpen() of the nil window = false, want true")
+	}
+	if _, err := NewPublishWindow("07:00-23:00", "", "Mars/Olympus", ""); err == nil {
+		t.Error("NewPublishWindow() error = nil, want invalid timezone error")
+	}
+	if _, err := NewPublishWindow("", "12:00-13:00,2am", "", ""); err == nil {
+		t.Error("NewPublishWindow() error = nil, want invalid quiet hours error")
+	}
+}
+
+func TestPublishWindow_header(t *testing.T) {
+	w := &PublishWindow{}
+	night := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	morning := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+
+	if got := w.header(night, morning); got != defaultRoundupHeader {
+		t.Errorf("header() = %q, want %q", got, defaultRoundupHeader)
+	}
+	// The news of the same night released by another job
+	if got := w.header(night.Add(time.Hour), morning.Add(time.Minute)); got != "" {
+		t.Errorf("header() = %q, want empty for the same night", got)
+	}
+
+	w.Header = "Good morning"
+	if got := w.header(morning.Add(20*time.Hour), morning.Add(24*time.Hour)); got != "Good morning" {
+		t.Errorf("header() = %q, want the header for the next night", got)
+	}
+}
+
+func Test_splitByWindow(t *testing.T) {
+	night := &PublishWindow{Open: &DayRange{From: 7 * time.Hour, To: 23 * time.Hour}}
+	windows := map[string]*PublishWindow{"": night}
+	main := &archivist.News{Hash: "main"}
+	crypto := &archivist.News{Hash: "crypto", Channel: "crypto"}
+
+	publish, held := splitByWindow([]*archivist.News{main, crypto}, windows, time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC))
+	if !reflect.DeepEqual(publish, []*archivist.News{crypto}) || !reflect.DeepEqual(held, []*archivist.News{main}) {
+		t.Errorf("splitByWindow() = %v, %v, want the news of the channel without the window published", publish, held)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/robfig/cron/v3"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/jobs"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+// jobsFile is the structure of the jobs config file (see JOBS_CONFIG env).
+type jobsFile struct {
+	Jobs    []jobDefinition    `yaml:"jobs" validate:"required,min=1,dive"`
+	Threads []threadDefinition `yaml:"threads"` // independent pipelines of the other channels, see threadDefinition
+}
+
+// jobDefinition defines the news job: its journalists, schedule and filters.
+// Exactly one of Cron or Every must be set.
+type jobDefinition struct {
+	Name             string        `yaml:"name" validate:"required,max=64"`
+	Cron             string        `yaml:"cron" validate:"required_without=Every,excluded_with=Every"` // e.g. "*/5 * * * 1-5" (UTC)
+	Every            time.Duration `yaml:"every" validate:"required_without=Cron,omitempty,gte=10s"`   // e.g. "60s", "4m"
+	Jitter           time.Duration `yaml:"jitter" validate:"gte=0"`                                    // max random delay of each run, e.g. "20s"
+	FetchUntil       time.Duration `yaml:"fetch_until" validate:"gte=0"`                               // news published this long before the start are skipped
+	Limit            int           `yaml:"limit" validate:"gte=0"`                                     // max news to fetch from each provider, 0 for no limit
+	Journalists      []rssProvider `yaml:"journalists" validate:"required_without_all=EconomicCalendar EarningsCalendar,dive"`
+	EconomicCalendar bool          `yaml:"economic_calendar"` // publish high impact economic releases as news
+	// Tickers to publish the earnings reports of: the estimates the morning of the report and the results (Finnhub)
+	EarningsCalendar []string `yaml:"earnings_calendar" validate:"dive,required,max=16"`
+	ComposeText      bool     `yaml:"compose_text"`
+	ClassifyNews     bool     `yaml:"classify_news"` // drop unimportant news with the separate LLM stage before composing
+	Breaking         bool     `yaml:"breaking"`      // fast path: skip the LLM filters, lite compose, 🚨 prefix and priority sends
+	// Unflag the news flagged by the suspicious keywords if their LLM spam score is lower (0..1, 0 to disable)
+	SuspiciousThreshold float64  `yaml:"suspicious_threshold" validate:"gte=0,lte=1"`
+	OmitSuspicious      bool     `yaml:"omit_suspicious"`
+	OmitEmptyMeta       []string `yaml:"omit_empty_meta" validate:"dive,oneof=Tickers Markets Hashtags"`
+	OmitIfAllKeysEmpty  bool     `yaml:"omit_if_all_keys_empty"`
+	OmitUnlistedStocks  bool     `yaml:"omit_unlisted_stocks"`
+	RemoveClones        bool     `yaml:"remove_clones"`
+	SaveToDB            bool     `yaml:"save_to_db"`
+	Channel             string   `yaml:"channel" validate:"max=64"` // name of the channel from TELEGRAM_CHANNELS or chat ID
+	// Style of the composed text: concise, analytical or casual (the style of the channel or COMPOSE_STYLE if empty)
+	Style   string        `yaml:"style" validate:"omitempty,oneof=concise analytical casual"`
+	Timeout time.Duration `yaml:"timeout" validate:"gte=0"` // deadline of the run, 25s by default
+	// Max sentences and characters of the composed text (the limits of the channel or COMPOSE_MAX_SENTENCES and COMPOSE_MAX_CHARS if 0)
+	MaxSentences int `yaml:"max_sentences" validate:"gte=0"`
+	MaxChars     int `yaml:"max_chars" validate:"gte=0,lte=4096"`
+	// Only the news published within the window are composed, e.g. "12h" (COMPOSE_FRESHNESS_WINDOW if 0)
+	FreshnessWindow time.Duration `yaml:"freshness_window" validate:"gte=0"`
+	// Deadline of each provider fetch (5s by default), the news of the providers that made it in time are processed
+	ProviderTimeout time.Duration `yaml:"provider_timeout" validate:"gte=0"`
+	// Deadlines of the run stages (fetch, compose, publish), e.g. {compose: 40s}
+	StageTimeouts map[string]time.Duration `yaml:"stage_timeouts" validate:"dive,keys,oneof=fetch compose publish,endkeys,gte=0"`
+	// Channels where the published news are also published translated into their languages
+	Translations []translationDefinition `yaml:"translations" validate:"dive"`
+	// Max news published per run (0 for no limit), the rest are left pending for the next runs
+	MaxPublishPerRun int `yaml:"max_publish_per_run" validate:"gte=0"`
+	// Order of publishing the pending and new news: oldest (default) or importance (rules priority and importance, then oldest)
+	OverflowOrder string `yaml:"overflow_order" validate:"omitempty,oneof=oldest importance"`
+	// Interval of the publisher worker draining the queued news, the runs only queue them if set
+	QueueEvery time.Duration `yaml:"queue_every" validate:"omitempty,gte=1s"`
+	// News published by one run of the publisher worker, 5 if 0
+	QueueBatch int `yaml:"queue_batch" validate:"gte=0"`
+	// Min importance (0..100) of the composed news rated by the LLM, less important news are not published
+	MinImportance int `yaml:"min_importance" validate:"gte=0,lte=100"`
+	// Download the articles of the news and pass their main text to the compose prompt instead of the description
+	FetchContent bool `yaml:"fetch_content"`
+	// Follow the tracking redirects of the links to the canonical URLs and strip the UTM parameters
+	ResolveLinks bool `yaml:"resolve_links"`
+	// Domains of the paywalled sites, e.g. wsj.com, their links are replaced with the archive links (requires resolve_links)
+	ArchiveDomains []string `yaml:"archive_domains" validate:"dive,hostname"`
+	// Domains of the news links to keep, e.g. reuters.com, all domains if empty (subdomains match their domains)
+	AllowDomains []string `yaml:"allow_domains" validate:"dive,hostname"`
+	// Domains of the news links to drop, e.g. low-quality aggregators, take precedence over allow_domains
+	BlockDomains []string `yaml:"block_domains" validate:"dive,hostname"`
+	// Group the related news of the run by title or embeddings similarity and publish them as one merged story
+	ClusterStories string `yaml:"cluster_stories" validate:"omitempty,oneof=title embeddings"`
+	// Min similarity (0..1) of the news of one story, 0.5 for title and 0.85 for embeddings if 0
+	ClusterSimilarity float64 `yaml:"cluster_similarity" validate:"gte=0,lte=1"`
+	// Send the summary of the runs to the admin chat: always (every run) or active (runs with the new news or errors)
+	RunSummary string `yaml:"run_summary" validate:"omitempty,oneof=always active"`
+	// Chat of the run summaries: name from TELEGRAM_CHANNELS or chat ID, ADMIN_CHAT_ID if empty
+	SummaryChat string `yaml:"summary_chat" validate:"max=64"`
+}
+
+// translationDefinition is the channel of the job that publishes the news translated into the language.
+type translationDefinition struct {
+	Channel  string `yaml:"channel" validate:"required,max=64"`  // name of the channel from TELEGRAM_CHANNELS or chat ID
+	Language string `yaml:"language" validate:"required,max=32"` // language in English, e.g. "German"
+}
+
+// loadJobsFile reads and validates the jobs config file. Job names of the threads are prefixed with the thread name.
+func loadJobsFile(path string) (*jobsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jobs config: %w", err)
+	}
+
+	var f jobsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error unmarshalling jobs config: %w", err)
+	}
+
+	if err := validateJobs(f.Jobs); err != nil {
+		return nil, fmt.Errorf("error validating jobs config: %w", err)
+	}
+
+	for i := range f.Threads {
+		f.Threads[i].prefixJobs()
+	}
+	if err := validateThreads(f.Threads, f.Jobs); err != nil {
+		return nil, fmt.Errorf("error validating jobs config: %w", err)
+	}
+
+	return &f, nil
+}
+
+// validateJobs validates the job definitions and the dependencies between their options.
+func validateJobs(defs []jobDefinition) error {
+	if err := validator.New().Struct(jobsFile{Jobs: defs}); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	names := make(map[string]bool, len(defs))
+	for _, d := range defs {
+		if names[d.Name] {
+			return fmt.Errorf("job %s: duplicate name", d.Name)
+		}
+		names[d.Name] = true
+
+		for _, j := range d.Journalists {
+			if err := j.validateType(); err != nil {
+				return fmt.Errorf("job %s: %w", d.Name, err)
+			}
+		}
+		if d.Cron != "" {
+			if _, err := cron.ParseStandard(d.Cron); err != nil {
+				return fmt.Errorf("job %s: invalid cron: %w", d.Name, err)
+			}
+		}
+		if d.Every > 0 && d.Jitter >= d.Every {
+			return fmt.Errorf("job %s: jitter must be shorter than every", d.Name)
+		}
+		// Only the news checked for duplicates are published, so the job without it never publishes
+		if !d.RemoveClones {
+			return fmt.Errorf("job %s: remove_clones is required", d.Name)
+		}
+		if !d.SaveToDB {
+			return fmt.Errorf("job %s: remove_clones requires save_to_db", d.Name)
+		}
+		if (len(d.OmitEmptyMeta) > 0 || d.OmitIfAllKeysEmpty) && !d.ComposeText {
+			return fmt.Errorf("job %s: omit_empty_meta and omit_if_all_keys_empty require compose_text", d.Name)
+		}
+		if d.Breaking && (d.ClassifyNews || d.SuspiciousThreshold > 0 || d.FetchContent || d.ResolveLinks) {
+			return fmt.Errorf("job %s: breaking jobs skip classify_news, suspicious_threshold, fetch_content and resolve_links stages", d.Name)
+		}
+		if len(d.ArchiveDomains) > 0 && !d.ResolveLinks {
+			return fmt.Errorf("job %s: archive_domains requires resolve_links", d.Name)
+		}
+		if len(d.Translations) > 0 && !d.ComposeText {
+			return fmt.Errorf("job %s: translations require compose_text", d.Name)
+		}
+		if d.ClusterStories != "" && (!d.ComposeText || d.Breaking) {
+			return fmt.Errorf("job %s: cluster_stories requires compose_text and is skipped by breaking jobs", d.Name)
+		}
+		if (d.MaxSentences > 0 || d.MaxChars > 0) && !d.ComposeText {
+			return fmt.Errorf("job %s: max_sentences and max_chars require compose_text", d.Name)
+		}
+		if d.MinImportance > 0 && !d.ComposeText {
+			return fmt.Errorf("job %s: min_importance requires compose_text", d.Name)
+		}
+		if d.SummaryChat != "" && d.RunSummary == "" {
+			return fmt.Errorf("job %s: summary_chat requires run_summary", d.Name)
+		}
+		if d.MaxPublishPerRun > 0 && !d.SaveToDB {
+			return fmt.Errorf("job %s: max_publish_per_run requires save_to_db", d.Name)
+		}
+		if d.QueueEvery > 0 && !d.SaveToDB {
+			return fmt.Errorf("job %s: queue_every requires save_to_db", d.Name)
+		}
+		if d.QueueBatch > 0 && d.QueueEvery == 0 {
+			return fmt.Errorf("job %s: queue_batch requires queue_every", d.Name)
+		}
+	}
+
+	return nil
+}
+
+// defaultJobs returns the market and broad news jobs with the journalists from the MARKET_JOURNALISTS
+// and BROAD_JOURNALISTS envs. Used if the jobs config file is not set.
+func defaultJobs(env *Env) ([]jobDefinition, error) {
+	marketJournalists, err := unmarshalRssProviders(env.MarketJournalists)
+	if err != nil {
+		return nil, fmt.Errorf("marketJournalists: %w", err)
+	}
+
+	broadJournalists, err := unmarshalRssProviders(env.BroadJournalists)
+	if err != nil {
+		return nil, fmt.Errorf("broadJournalists: %w", err)
+	}
+
+	return []jobDefinition{
+		{
+			Name:                "MarketNews",
+			Every:               60 * time.Second,
+			Timeout:             time.Duration(env.JobTimeout) * time.Second,
+			FetchUntil:          60 * time.Second,
+			Limit:               2,
+			Journalists:         marketJournalists,
+			EconomicCalendar:    env.CalendarNewsEnabled,
+			ComposeText:         true,
+			ClassifyNews:        env.ClassifyNews,
+			SuspiciousThreshold: env.SuspiciousThreshold,
+			OmitSuspicious:      true,
+			OmitIfAllKeysEmpty:  true,
+			OmitUnlistedStocks:  true,
+			RemoveClones:        true,
+			SaveToDB:            true,
+		},
+		{
+			Name:                "BroadNews",
+			Every:               4 * time.Minute,
+			Timeout:             time.Duration(env.JobTimeout) * time.Second,
+			FetchUntil:          4 * time.Minute,
+			Limit:               1,
+			Journalists:         broadJournalists,
+			ComposeText:         true,
+			ClassifyNews:        env.ClassifyNews,
+			SuspiciousThreshold: env.SuspiciousThreshold,
+			OmitSuspicious:      true,
+			OmitEmptyMeta:       []string{string(jobs.MetaTickers)},
+			OmitUnlistedStocks:  true,
+			RemoveClones:        true,
+			SaveToDB:            true,
+		},
+	}, nil
+}
+
+// providers creates the news providers of the job. Finnhub token is required for the earnings calendar.
+func (d *jobDefinition) providers(finnhubToken string) ([]journalist.NewsProvider, error) {
+	result, err := newsProviders(d.Journalists)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", d.Name, err)
+	}
+	if d.EconomicCalendar {
+		result = append(result, journalist.NewCalendarProvider("EconomicCalendar", &ecal.EconomicCalendar{}))
+	}
+	if len(d.EarningsCalendar) > 0 {
+		if finnhubToken == "" {
+			return nil, fmt.Errorf("job %s: earnings_calendar requires FINNHUB_TOKEN", d.Name)
+		}
+		result = append(result, journalist.NewEarningsProvider("EarningsCalendar", marketdata.NewFinnhub(finnhubToken), d.EarningsCalendar))
+	}
+	return result, nil
+}
+
+// apply sets the job options from the definition.
+func (d *jobDefinition) apply(job *jobs.Job) *jobs.Job {
+	if d.FetchUntil > 0 {
+		job.FetchUntil(time.Now().Add(-d.FetchUntil))
+	}
+	if d.OmitSuspicious {
+		job.OmitSuspicious()
+	}
+	for _, key := range d.OmitEmptyMeta {
+		switch key {
+		case string(jobs.MetaTickers):
+			job.OmitEmptyMeta(jobs.MetaTickers)
+		case string(jobs.MetaMarkets):
+			job.OmitEmptyMeta(jobs.MetaMarkets)
+		case string(jobs.MetaHashtags):
+			job.OmitEmptyMeta(jobs.MetaHashtags)
+		}
+	}
+	if d.OmitIfAllKeysEmpty {
+		job.OmitIfAllKeysEmpty()
+	}
+	if d.OmitUnlistedStocks {
+		job.OmitUnlistedStocks()
+	}
+	if d.RemoveClones {
+		job.RemoveClones()
+	}
+	if d.ComposeText {
+		job.ComposeText()
+	}
+	if d.ClassifyNews {
+		job.ClassifyNews()
+	}
+	if d.Breaking {
+		job.Breaking()
+	}
+	if d.SuspiciousThreshold > 0 {
+		job.ReviewSuspicious(d.SuspiciousThreshold)
+	}
+	if d.SaveToDB {
+		job.SaveToDB()
+	}
+	if d.Channel != "" {
+		job.PublishToChannel(d.Channel)
+	}
+	if d.Style != "" {
+		job.WithStyle(composer.Style(d.Style))
+	}
+	if d.FreshnessWindow > 0 {
+		job.WithFreshnessWindow(d.FreshnessWindow)
+	}
+	if d.MaxSentences > 0 || d.MaxChars > 0 {
+		job.LimitLength(d.MaxSentences, d.MaxChars)
+	}
+	if len(d.Translations) > 0 {
+		translations := make([]jobs.Translation, len(d.Translations))
+		for i, t := range d.Translations {
+			translations[i] = jobs.Translation{Channel: t.Channel, Language: t.Language}
+		}
+		job.TranslateTo(translations...)
+	}
+	if d.MinImportance > 0 {
+		job.MinImportance(d.MinImportance)
+	}
+	if d.FetchContent {
+		job.FetchContent(journalist.NewContentFetcher())
+	}
+	if d.ResolveLinks {
+		job.ResolveLinks(journalist.NewLinkResolver().WithPaywalled(d.ArchiveDomains...))
+	}
+	if d.ClusterStories != "" {
+		job.ClusterStories(jobs.ClusterMethod(d.ClusterStories), d.ClusterSimilarity)
+	}
+	if d.MaxPublishPerRun > 0 {
+		job.LimitPublications(d.MaxPublishPerRun, jobs.OverflowOrder(d.OverflowOrder))
+	}
+	if d.QueueEvery > 0 {
+		job.QueuePublications()
+	}
+	if d.Timeout > 0 {
+		job.WithTimeout(d.Timeout)
+	}
+	for s, timeout := range d.StageTimeouts {
+		switch s {
+		case "fetch":
+			job.WithStageTimeout(jobs.StageFetch, timeout)
+		case "compose":
+			job.WithStageTimeout(jobs.StageCompose, timeout)
+		case "publish":
+			job.WithStageTimeout(jobs.StagePublish, timeout)
+		}
+	}
+	return job
+}
+
+// findJob returns the job definition by its name (prefixed for the thread jobs) with the configuration
+// of its pipeline. Empty name selects the first job matching the filter, the default pipeline first.
+func (c *Config) findJob(name string, match func(d *jobDefinition) bool) (*Config, *jobDefinition, error) {
+	for _, pc := range c.pipelines() {
+		for i := range pc.jobs {
+			d := &pc.jobs[i]
+			if (name != "" && d.Name == name) || (name == "" && match(d)) {
+				return pc, d, nil
+			}
+		}
+	}
+	if name == "" {
+		return nil, nil, errors.New("no matching job found")
+	}
+	return nil, nil, fmt.Errorf("job %s not found", name)
+}
+
+// findProvider returns the news provider of the job by its name with the configuration of the job pipeline,
+// all jobs are searched if the job name is empty. Webhook providers can't be fetched, their news are pushed.
+func (c *Config) findProvider(jobName, providerName string) (*Config, *jobDefinition, rssProvider, error) {
+	for _, pc := range c.pipelines() {
+		for i := range pc.jobs {
+			d := &pc.jobs[i]
+			if jobName != "" && d.Name != jobName {
+				continue
+			}
+			for _, p := range d.Journalists {
+				if p.Name != providerName {
+					continue
+				}
+				if p.providerType() == journalist.ProviderWebhook {
+					return nil, nil, rssProvider{}, fmt.Errorf("provider %s is the webhook, its news can't be fetched", p.Name)
+				}
+				return pc, d, p, nil
+			}
+		}
+	}
+	if jobName != "" {
+		return nil, nil, rssProvider{}, fmt.Errorf("provider %s not found in job %s", providerName, jobName)
+	}
+	return nil, nil, rssProvider{}, fmt.Errorf("provider %s not found", providerName)
+}
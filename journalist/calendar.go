@@ -0,0 +1,103 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// calendarLookback is added to the fetch period, because actual values are often published
+	// with a delay after the event time. Already published events are removed by the jobs as duplicates.
+	calendarLookback = 30 * time.Minute
+	calendarPageURL  = "https://www.mql5.com/en/economic-calendar"
+)
+
+// calendarFetcher is the interface for the economic calendar client.
+type calendarFetcher interface {
+	Fetch(ctx context.Context, from, to time.Time) (ecal.EconomicCalendarEvents, error)
+}
+
+// CalendarProvider is the NewsProvider implementation that emits the released economic calendar events
+// (CPI, NFP, FOMC decisions, etc.) with their actual, forecast and previous values.
+type CalendarProvider struct {
+	Name     string // Name is used for logging purposes
+	Calendar calendarFetcher
+	Impacts  []ecal.EconomicCalendarImpact // Only events with these impacts are emitted
+}
+
+// NewCalendarProvider creates a new CalendarProvider instance for the high impact events.
+func NewCalendarProvider(name string, calendar calendarFetcher) *CalendarProvider {
+	return &CalendarProvider{
+		Name:     name,
+		Calendar: calendar,
+		Impacts:  []ecal.EconomicCalendarImpact{ecal.EconomicCalendarImpactHigh},
+	}
+}
+
+// Fetch fetches the events released since the given date (with calendarLookback) that already have
+// the actual value and converts them to the news.
+func (c *CalendarProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	to := time.Now().UTC()
+	from := until.UTC().Add(-calendarLookback)
+	if until.IsZero() || to.Sub(from) > 24*time.Hour {
+		from = to.Add(-24 * time.Hour)
+	}
+
+	events, err := c.Calendar.Fetch(ctx, from, to)
+	if err != nil {
+		return nil, newError(errlvl.ERROR, err).WithProvider(c.Name)
+	}
+
+	var news NewsList
+	for _, e := range events {
+		if e.Actual == "" || !slices.Contains(c.Impacts, e.Impact) {
+			continue
+		}
+
+		newsItem, err := newNews(calendarTitle(e), calendarDescription(e), calendarLink(e), e.DateTime.Format(time.RFC3339), c.Name)
+		if err != nil {
+			return nil, newError(errlvl.INFO, err).WithProvider(c.Name)
+		}
+		news = append(news, newsItem)
+	}
+
+	// Latest events first, as in the RSS feeds
+	slices.Reverse(news)
+
+	return news, nil
+}
+
+// calendarTitle returns the news title for the event, e.g. "United States: CPI y/y".
+func calendarTitle(e *ecal.EconomicCalendarEvent) string {
+	return fmt.Sprintf("%s: %s", e.Country, e.Title)
+}
+
+// calendarDescription returns the news description with the event values,
+// e.g. "Actual: 3.1%, forecast: 3.0%, previous: 3.2% (USD)".
+func calendarDescription(e *ecal.EconomicCalendarEvent) string {
+	values := []string{"Actual: " + e.Actual}
+	if e.Forecast != "" {
+		values = append(values, "forecast: "+e.Forecast)
+	}
+	if e.Previous != "" {
+		values = append(values, "previous: "+e.Previous)
+	}
+
+	return fmt.Sprintf("%s (%s)", strings.Join(values, ", "), e.Currency)
+}
+
+// calendarLink returns the unique link to the event release. The same event page is used every month,
+// so the release time is added as the fragment to not treat the next release as a duplicate.
+func calendarLink(e *ecal.EconomicCalendarEvent) string {
+	link := e.URL
+	if link == "" {
+		link = calendarPageURL
+	}
+
+	return fmt.Sprintf("%s#%d", link, e.DateTime.Unix())
+}
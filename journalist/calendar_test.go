@@ -0,0 +1,138 @@
+package journalist
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeCalendar struct {
+	events ecal.EconomicCalendarEvents
+	err    error
+}
+
+func (f *fakeCalendar) Fetch(_ context.Context, _, _ time.Time) (ecal.EconomicCalendarEvents, error) {
+	return f.events, f.err
+}
+
+func TestCalendarProvider_Fetch(t *testing.T) {
+	released := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	events := ecal.EconomicCalendarEvents{
+		{
+			DateTime: released.Add(-time.Minute),
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Currency: ecal.EconomicCalendarUSD,
+			Impact:   ecal.EconomicCalendarImpactHigh,
+			Title:    "Nonfarm Payrolls",
+			Actual:   "275k",
+			Forecast: "200k",
+			Previous: "229k",
+			URL:      "https://www.mql5.com/en/economic-calendar/united-states/nonfarm-payrolls",
+		},
+		{
+			DateTime: released,
+			Country:  ecal.EconomicCalendarEuropeanUnion,
+			Currency: ecal.EconomicCalendarEUR,
+			Impact:   ecal.EconomicCalendarImpactHigh,
+			Title:    "ECB Interest Rate Decision",
+			Actual:   "4.50%",
+		},
+		{
+			DateTime: released,
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Currency: ecal.EconomicCalendarUSD,
+			Impact:   ecal.EconomicCalendarImpactHigh,
+			Title:    "CPI y/y",
+			Forecast: "3.1%",
+		},
+		{
+			DateTime: released,
+			Country:  ecal.EconomicCalendarJapan,
+			Currency: ecal.EconomicCalendarJPY,
+			Impact:   ecal.EconomicCalendarImpactMedium,
+			Title:    "Household Spending y/y",
+			Actual:   "-2.5%",
+		},
+	}
+
+	type want struct {
+		Title       string
+		Description string
+		Link        string
+	}
+	tests := []struct {
+		name     string
+		calendar *fakeCalendar
+		want     []want
+		wantErr  bool
+	}{
+		{
+			name:     "released high impact events",
+			calendar: &fakeCalendar{events: events},
+			want: []want{
+				{
+					Title:       "European Union: ECB Interest Rate Decision",
+					Description: "Actual: 4.50% (EUR)",
+					Link:        calendarLink(events[1]),
+				},
+				{
+					Title:       "United States: Nonfarm Payrolls",
+					Description: "Actual: 275k, forecast: 200k, previous: 229k (USD)",
+					Link:        calendarLink(events[0]),
+				},
+			},
+		},
+		{
+			name:     "calendar error",
+			calendar: &fakeCalendar{err: errors.New("timeout")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewCalendarProvider("calendar", tt.calendar)
+			got, err := p.Fetch(context.Background(), time.Now().Add(-time.Hour))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalendarProvider.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var gotNews []want
+			for _, n := range got {
+				gotNews = append(gotNews, want{Title: n.Title, Description: n.Description, Link: n.Link})
+			}
+			if !reflect.DeepEqual(gotNews, tt.want) {
+				t.Errorf("CalendarProvider.Fetch() = %+v, want %+v", gotNews, tt.want)
+			}
+		})
+	}
+}
+
+func Test_calendarLink(t *testing.T) {
+	date := time.Date(2024, 3, 8, 13, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event *ecal.EconomicCalendarEvent
+		want  string
+	}{
+		{
+			name:  "event page",
+			event: &ecal.EconomicCalendarEvent{DateTime: date, URL: "https://www.mql5.com/en/economic-calendar/united-states/nonfarm-payrolls"},
+			want:  "https://www.mql5.com/en/economic-calendar/united-states/nonfarm-payrolls#1709904600",
+		},
+		{
+			name:  "calendar page",
+			event: &ecal.EconomicCalendarEvent{DateTime: date},
+			want:  "https://www.mql5.com/en/economic-calendar#1709904600",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendarLink(tt.event); got != tt.want {
+				t.Errorf("calendarLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	contentMaxLength       = 20_000  // Max length of the extracted article text in runes, longer texts are truncated
+	contentMaxBytes        = 5 << 20 // Max size of the downloaded article page
+	contentConcurrency     = 4       // Number of the articles downloaded at the same time
+	contentMinParagraphLen = 25      // Shorter paragraphs (captions, bylines, buttons) are not scored
+)
+
+// ContentFetcher downloads the articles of the news and extracts their main text readability-style:
+// the block with the most paragraph text, without the scripts, navigation, comments and promo blocks.
+type ContentFetcher struct {
+	UserAgent string // User agent of the requests
+	client    *http.Client
+}
+
+// NewContentFetcher creates a new ContentFetcher with the default user agent and 15s timeout per article.
+func NewContentFetcher() *ContentFetcher {
+	return &ContentFetcher{
+		UserAgent: rssUserAgent,
+		client:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// FetchContent sets the Content of the news in place. News without the link or with the failed download
+// keep the empty content, so the news can be composed by the description. Returns the errors of the failed news.
+func (f *ContentFetcher) FetchContent(ctx context.Context, news NewsList) error {
+	var eg errgroup.Group
+	eg.SetLimit(contentConcurrency)
+
+	var mu sync.Mutex
+	var failed []error
+	for _, n := range news {
+		if n.Link == "" || n.Content != "" {
+			continue
+		}
+
+		eg.Go(func() error {
+			content, err := f.fetch(ctx, n.Link)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, fmt.Errorf("%s: %w", n.Link, err))
+				return nil
+			}
+			n.Content = content
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	if len(failed) > 0 {
+		return newError(errlvl.WARN, append([]error{errFetchContent}, failed...)...)
+	}
+	return nil
+}
+
+// fetch downloads the article page and extracts its main text.
+func (f *ContentFetcher) fetch(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !strings.Contains(mediaType, "html") {
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	return ExtractContent(io.LimitReader(resp.Body, contentMaxBytes))
+}
+
+var (
+	// contentSkipTags are the elements that never contain the article text.
+	contentSkipTags = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Svg: true,
+		atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+		atom.Iframe: true, atom.Button: true, atom.Select: true, atom.Figcaption: true,
+	}
+	// contentSkipRe matches the class or id of the blocks around the article (comments, share buttons, promo, etc.).
+	contentSkipRe = regexp.MustCompile(`(?i)comment|share|social|related|promo|sidebar|newsletter|subscribe|advert|banner|cookie|popup|modal|breadcrumb|footer|menu`)
+	// contentBlockTags are the text blocks of the article collected from the best container.
+	contentBlockTags = map[atom.Atom]bool{
+		atom.P: true, atom.H2: true, atom.H3: true, atom.Li: true, atom.Blockquote: true, atom.Pre: true,
+	}
+	spacesRe = regexp.MustCompile(`\s+`)
+)
+
+// ExtractContent extracts the main text of the article from the HTML page: paragraphs of the container
+// with the most paragraph text, separated by the blank lines. Returns an error if the page has no article text.
+func ExtractContent(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	// Each paragraph adds its length to the score of the parent and the half of it to the grandparent,
+	// so the container of the article text wins over the single long paragraph of the sidebar
+	scores := make(map[*html.Node]int)
+	walkContent(doc, func(n *html.Node) {
+		if n.DataAtom != atom.P {
+			return
+		}
+		length := utf8.RuneCountInString(nodeText(n))
+		if length < contentMinParagraphLen || n.Parent == nil {
+			return
+		}
+		scores[n.Parent] += length
+		if n.Parent.Parent != nil {
+			scores[n.Parent.Parent] += length / 2
+		}
+	})
+
+	var best *html.Node
+	for n, score := range scores {
+		if best == nil || score > scores[best] {
+			best = n
+		}
+	}
+	if best == nil {
+		return "", errContentEmpty
+	}
+
+	var blocks []string
+	walkContent(best, func(n *html.Node) {
+		if !contentBlockTags[n.DataAtom] || hasBlockParent(n, best) {
+			return
+		}
+		if text := nodeText(n); text != "" {
+			blocks = append(blocks, text)
+		}
+	})
+
+	return truncateRunes(strings.Join(blocks, "\n\n"), contentMaxLength), nil
+}
+
+// walkContent calls fn for the element nodes in the document order, skipping the non-article blocks.
+func walkContent(n *html.Node, fn func(n *html.Node)) {
+	if n.Type == html.ElementNode {
+		if contentSkipTags[n.DataAtom] || contentSkipRe.MatchString(attr(n, "class")+" "+attr(n, "id")) {
+			return
+		}
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkContent(c, fn)
+	}
+}
+
+// hasBlockParent returns true if the node is inside the other text block (e.g. the paragraph in the list item),
+// so its text is already collected with the parent.
+func hasBlockParent(n, root *html.Node) bool {
+	for p := n.Parent; p != nil && p != root; p = p.Parent {
+		if contentBlockTags[p.DataAtom] {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeText returns the text of the node with the collapsed whitespaces, skipping the non-article blocks.
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && contentSkipTags[n.DataAtom] {
+			return
+		}
+		if n.DataAtom == atom.Br {
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+
+	return strings.TrimSpace(spacesRe.ReplaceAllString(sb.String(), " "))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// truncateRunes cuts the string to the max number of runes.
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
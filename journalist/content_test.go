@@ -0,0 +1,107 @@
+package journalist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const articlePage = `<html><head><title>Apple</title><script>var ads = "Buy now, this is a long script text";</script></head>
+<body>
+<header><p>Markets Tech Crypto Opinion Video Newsletters Subscribe today</p></header>
+<nav><ul><li>Home</li><li>Markets</li></ul></nav>
+<article>
+  <h1>Apple beats estimates</h1>
+  <p>Apple reported quarterly revenue of $119.6 billion, up 2 percent year over year.</p>
+  <p>iPhone sales rose to $69.7 billion, beating the analyst estimates of $68.3 billion.</p>
+  <div class="share-buttons"><p>Share this article on the social networks right now</p></div>
+  <h2>Guidance</h2>
+  <p>The company expects the revenue growth to continue in the March quarter.<br>CEO Tim Cook said the demand is strong.</p>
+  <ul><li>Services revenue hit a record of $23.1 billion.</li></ul>
+</article>
+<aside><p>Related: Microsoft shares fall after the earnings report was released today</p></aside>
+<footer><p>Copyright 2024 Example News Inc. All rights reserved worldwide.</p></footer>
+</body></html>`
+
+func TestExtractContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "article",
+			page: articlePage,
+			want: "Apple reported quarterly revenue of $119.6 billion, up 2 percent year over year.\n\n" +
+				"iPhone sales rose to $69.7 billion, beating the analyst estimates of $68.3 billion.\n\n" +
+				"Guidance\n\n" +
+				"The company expects the revenue growth to continue in the March quarter. CEO Tim Cook said the demand is strong.\n\n" +
+				"Services revenue hit a record of $23.1 billion.",
+		},
+		{
+			name:    "no paragraphs",
+			page:    `<html><body><div>Short</div><p>Too short</p></body></html>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractContent(strings.NewReader(tt.page))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractContent() got =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentFetcher_FetchContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(articlePage))
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	news := NewsList{
+		{ID: "1", Link: srv.URL + "/article"},
+		{ID: "2", Link: srv.URL + "/pdf"},
+		{ID: "3", Link: srv.URL + "/missing"},
+		{ID: "4"},
+		{ID: "5", Link: srv.URL + "/missing", Content: "already fetched"},
+	}
+
+	err := NewContentFetcher().FetchContent(context.Background(), news)
+	if err == nil {
+		t.Fatal("FetchContent() error = nil, want the errors of the failed news")
+	}
+	for _, link := range []string{"/pdf", "/missing"} {
+		if !strings.Contains(err.Error(), srv.URL+link) {
+			t.Errorf("FetchContent() error = %v, want to contain %s", err, link)
+		}
+	}
+
+	if !strings.HasPrefix(news[0].Content, "Apple reported quarterly revenue") {
+		t.Errorf("FetchContent() content = %q, want the article text", news[0].Content)
+	}
+	for _, n := range news[1:4] {
+		if n.Content != "" {
+			t.Errorf("FetchContent() news %s content = %q, want empty", n.ID, n.Content)
+		}
+	}
+	if news[4].Content != "already fetched" {
+		t.Errorf("FetchContent() news 5 content = %q, want unchanged", news[4].Content)
+	}
+}
@@ -0,0 +1,151 @@
+package journalist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// feedTypes are the link types of the feeds announced by the page.
+var feedTypes = []string{"application/rss+xml", "application/atom+xml", "application/feed+json", "application/json"}
+
+// feedPaths are the common feed locations checked if the page doesn't announce its feeds.
+var feedPaths = []string{"/feed", "/rss", "/feed.xml", "/rss.xml", "/atom.xml", "/index.xml"}
+
+// Discover finds the feed of the site by its page URL (e.g. the homepage), so the new source can be added
+// by pasting the link: the feeds announced by <link rel="alternate"> are checked first, then the common paths.
+// The page itself can be the feed. Returns the config of the rss provider with the first feed that has news.
+func (j *Journalist) Discover(ctx context.Context, pageURL string) (ProviderConfig, error) {
+	if !strings.Contains(pageURL, "://") {
+		pageURL = "https://" + pageURL
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil || base.Host == "" {
+		return ProviderConfig{}, newError(errlvl.INFO, errDiscoverURL, err)
+	}
+
+	page, err := fetchPage(ctx, base.String())
+	if err != nil {
+		return ProviderConfig{}, newError(errlvl.WARN, errDiscoverPage, err)
+	}
+	if feed, err := gofeed.NewParser().Parse(bytes.NewReader(page)); err == nil && len(feed.Items) > 0 {
+		return feedConfig(base.String(), feed), nil
+	}
+
+	candidates, err := feedLinks(bytes.NewReader(page), base)
+	if err != nil {
+		return ProviderConfig{}, newError(errlvl.WARN, errDiscoverPage, err)
+	}
+	for _, p := range feedPaths {
+		candidates = append(candidates, base.ResolveReference(&url.URL{Path: p}).String())
+	}
+
+	var errs []error
+	for _, link := range candidates {
+		feed, err := fetchFeed(ctx, link)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", link, err))
+			continue
+		}
+		return feedConfig(link, feed), nil
+	}
+
+	return ProviderConfig{}, newError(errlvl.INFO, append([]error{errFeedNotFound}, errs...)...)
+}
+
+// feedLinks returns the unique absolute URLs of the feeds announced by the HTML page in the document order.
+// Relative links are resolved against the <base> of the page or its URL.
+func feedLinks(r io.Reader, base *url.URL) ([]string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var links []string
+	seen := make(map[string]bool)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Base:
+				if u, err := base.Parse(attr(n, "href")); err == nil && attr(n, "href") != "" {
+					base = u
+				}
+			case atom.Link, atom.A:
+				rel := strings.Fields(strings.ToLower(attr(n, "rel")))
+				typ := strings.ToLower(strings.TrimSpace(attr(n, "type")))
+				href := strings.TrimSpace(attr(n, "href"))
+				if href != "" && slices.Contains(rel, "alternate") && slices.Contains(feedTypes, typ) {
+					if u, err := base.Parse(href); err == nil && !seen[u.String()] {
+						seen[u.String()] = true
+						links = append(links, u.String())
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return links, nil
+}
+
+// fetchFeed downloads and parses the feed, the feed without news is not valid.
+func fetchFeed(ctx context.Context, link string) (*gofeed.Feed, error) {
+	body, err := fetchPage(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if len(feed.Items) == 0 {
+		return nil, errors.New("feed has no news")
+	}
+	return feed, nil
+}
+
+// fetchPage downloads the page with the default user agent of the RSS requests.
+func fetchPage(ctx context.Context, link string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", rssUserAgent)
+
+	resp, err := rssClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, contentMaxBytes))
+}
+
+// feedConfig returns the config of the rss provider of the feed named after its title or the host.
+func feedConfig(link string, feed *gofeed.Feed) ProviderConfig {
+	name := strings.TrimSpace(feed.Title)
+	if name == "" {
+		if u, err := url.Parse(link); err == nil {
+			name = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+	}
+	return ProviderConfig{Type: ProviderRSS, Name: name, URL: link}
+}
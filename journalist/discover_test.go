@@ -0,0 +1,79 @@
+package journalist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const discoverFeed = `<?xml version="1.0"?><rss version="2.0"><channel><title>Example Markets</title>
+<item><title>Apple beats estimates</title><link>https://example.com/apple</link></item></channel></rss>`
+
+func TestJournalist_Discover(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    map[string]string
+		wantURL  string
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name: "announced feed",
+			pages: map[string]string{
+				"/":            `<html><head><link rel="alternate" type="application/rss+xml" href="/markets/rss"></head></html>`,
+				"/markets/rss": discoverFeed,
+			},
+			wantURL:  "/markets/rss",
+			wantName: "Example Markets",
+		},
+		{
+			name: "empty announced feed is skipped",
+			pages: map[string]string{
+				"/":          `<html><head><link rel="alternate" type="application/atom+xml" href="empty.xml"></head></html>`,
+				"/empty.xml": `<?xml version="1.0"?><rss version="2.0"><channel><title>Empty</title></channel></rss>`,
+				"/feed":      discoverFeed,
+			},
+			wantURL:  "/feed",
+			wantName: "Example Markets",
+		},
+		{
+			name:     "page is the feed",
+			pages:    map[string]string{"/": discoverFeed},
+			wantURL:  "",
+			wantName: "Example Markets",
+		},
+		{
+			name:    "no feed",
+			pages:   map[string]string{"/": `<html><head><link rel="stylesheet" href="/style.css"></head></html>`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page, ok := tt.pages[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = w.Write([]byte(page))
+			}))
+			defer srv.Close()
+
+			got, err := NewJournalist("test", nil).Discover(context.Background(), srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Discover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := srv.URL + tt.wantURL; got.URL != want {
+				t.Errorf("Discover() URL = %v, want %v", got.URL, want)
+			}
+			if got.Name != tt.wantName || got.Type != ProviderRSS {
+				t.Errorf("Discover() = %+v, want rss provider %v", got, tt.wantName)
+			}
+		})
+	}
+}
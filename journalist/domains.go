@@ -0,0 +1,63 @@
+package journalist
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DomainFilter keeps the news by the domain of their links. News of the blocked domains are dropped and,
+// if the allowed domains are set, only the news of the allowed domains are kept. Subdomains match their domains.
+type DomainFilter struct {
+	Allowed []string // Domains to keep the news of, all domains if empty
+	Blocked []string // Domains to drop the news of, takes precedence over Allowed
+}
+
+// Empty returns true if the filter keeps all news.
+func (f DomainFilter) Empty() bool {
+	return len(f.Allowed) == 0 && len(f.Blocked) == 0
+}
+
+// Allows returns true if the news with the link pass the filter. News without the valid link pass only
+// if the allowed domains are not set.
+func (f DomainFilter) Allows(link string) bool {
+	if f.Empty() {
+		return true
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || u.Hostname() == "" {
+		return len(f.Allowed) == 0
+	}
+	host := u.Hostname()
+	if matchesDomain(host, f.Blocked) {
+		return false
+	}
+	return len(f.Allowed) == 0 || matchesDomain(host, f.Allowed)
+}
+
+// Filter returns the news that pass the filter.
+func (f DomainFilter) Filter(news NewsList) NewsList {
+	if f.Empty() {
+		return news
+	}
+
+	filtered := make(NewsList, 0, len(news))
+	for _, n := range news {
+		if f.Allows(n.Link) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+// matchesDomain returns true if the host is one of the domains or their subdomains.
+func matchesDomain(host string, domains []string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(d, "www."))
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
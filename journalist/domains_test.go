@@ -0,0 +1,93 @@
+package journalist
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDomainFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		blocked []string
+		link    string
+		want    bool
+	}{
+		{
+			name: "empty filter",
+			link: "https://www.benzinga.com/news/1",
+			want: true,
+		},
+		{
+			name:    "blocked domain",
+			blocked: []string{"benzinga.com"},
+			link:    "https://www.benzinga.com/news/1",
+			want:    false,
+		},
+		{
+			name:    "not blocked domain",
+			blocked: []string{"benzinga.com"},
+			link:    "https://www.reuters.com/markets/1",
+			want:    true,
+		},
+		{
+			name:    "allowed subdomain",
+			allowed: []string{"www.Reuters.com", "apnews.com"},
+			link:    "https://markets.reuters.com/1",
+			want:    true,
+		},
+		{
+			name:    "not allowed domain",
+			allowed: []string{"reuters.com"},
+			link:    "https://notreuters.com/1",
+			want:    false,
+		},
+		{
+			name:    "blocked subdomain of the allowed domain",
+			allowed: []string{"yahoo.com"},
+			blocked: []string{"sports.yahoo.com"},
+			link:    "https://sports.yahoo.com/1",
+			want:    false,
+		},
+		{
+			name:    "no link with the blocked domains",
+			blocked: []string{"benzinga.com"},
+			want:    true,
+		},
+		{
+			name:    "no link with the allowed domains",
+			allowed: []string{"reuters.com"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := DomainFilter{Allowed: tt.allowed, Blocked: tt.blocked}
+			if got := f.Allows(tt.link); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.link, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalist_FilterDomains(t *testing.T) {
+	provider := &funcProvider{fetch: func(context.Context) (NewsList, error) {
+		return NewsList{
+			{Title: "Aggregated", Link: "https://www.benzinga.com/news/1"},
+			{Title: "Fed holds rates", Link: "https://www.reuters.com/markets/1"},
+			{Title: "Oil rises", Link: "https://apnews.com/article/2"},
+		}, nil
+	}}
+
+	got, err := NewJournalist("test", []NewsProvider{provider}).
+		Limit(1).
+		FilterDomains(nil, []string{"benzinga.com"}).
+		GetLatestNews(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("GetLatestNews() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "Fed holds rates" {
+		t.Errorf("GetLatestNews() = %v, want only the news of the not blocked domain", got)
+	}
+}
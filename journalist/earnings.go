@@ -0,0 +1,215 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// earningsMorningHour is the hour (US Eastern time) the upcoming reports of the day are emitted at,
+	// before the reports scheduled before the market open.
+	earningsMorningHour = 6
+	earningsPageURL     = "https://www.nasdaq.com/market-activity/stocks/%s/earnings"
+)
+
+// earningsFetcher is the interface for the earnings calendar client (e.g. marketdata.Finnhub).
+type earningsFetcher interface {
+	FetchEarnings(ctx context.Context, from, to time.Time, ticker string) ([]*marketdata.EarningsReport, error)
+}
+
+// EarningsProvider is the NewsProvider implementation that emits the earnings reports of the watchlist
+// companies twice: the morning of the report day with the estimates and again when the actual EPS
+// and revenue are released.
+type EarningsProvider struct {
+	Name      string // Name is used for logging purposes
+	Calendar  earningsFetcher
+	Watchlist []string // Tickers of the companies to cover, all reports are emitted if empty
+	location  *time.Location
+	now       func() time.Time
+}
+
+// NewEarningsProvider creates a new EarningsProvider instance for the watchlist tickers.
+func NewEarningsProvider(name string, calendar earningsFetcher, watchlist []string) *EarningsProvider {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		// Time zone database is missing, the reports day is a bit off then
+		location = time.FixedZone("EST", -5*60*60)
+	}
+
+	tickers := make([]string, len(watchlist))
+	for i, t := range watchlist {
+		tickers[i] = strings.ToUpper(strings.TrimPrefix(t, "$"))
+	}
+
+	return &EarningsProvider{
+		Name:      name,
+		Calendar:  calendar,
+		Watchlist: tickers,
+		location:  location,
+		now:       time.Now,
+	}
+}
+
+// Fetch fetches the earnings calendar of yesterday and today (the results after the close are often
+// released after midnight UTC) and converts the reports to the news. The until date is ignored,
+// the same report news are removed by the jobs as duplicates.
+func (e *EarningsProvider) Fetch(ctx context.Context, _ time.Time) (NewsList, error) {
+	now := e.now().In(e.location)
+	today := now.Format(time.DateOnly)
+
+	// Calendar of all companies is fetched in one request if the watchlist has more than one ticker
+	var ticker string
+	if len(e.Watchlist) == 1 {
+		ticker = e.Watchlist[0]
+	}
+	reports, err := e.Calendar.FetchEarnings(ctx, now.AddDate(0, 0, -1), now, ticker)
+	if err != nil {
+		return nil, newError(errlvl.ERROR, err).WithProvider(e.Name)
+	}
+
+	var news NewsList
+	for _, r := range reports {
+		if len(e.Watchlist) > 0 && !slices.Contains(e.Watchlist, r.Ticker) {
+			continue
+		}
+
+		var title, description, link string
+		switch {
+		case r.IsReleased():
+			title, description = earningsResultsTitle(r), earningsResultsDescription(r)
+			link = earningsLink(r, "results")
+		case r.Date == today && now.Hour() >= earningsMorningHour:
+			title, description = earningsUpcomingTitle(r), earningsUpcomingDescription(r)
+			link = earningsLink(r, "upcoming")
+		default:
+			continue
+		}
+
+		newsItem, err := newNews(title, description, link, now.UTC().Format(time.RFC3339), e.Name)
+		if err != nil {
+			return nil, newError(errlvl.INFO, err).WithProvider(e.Name)
+		}
+		news = append(news, newsItem)
+	}
+
+	return news, nil
+}
+
+// earningsUpcomingTitle returns the news title for the report of the day, e.g. "$AAPL reports Q1 2024 earnings today after the close".
+func earningsUpcomingTitle(r *marketdata.EarningsReport) string {
+	title := fmt.Sprintf("$%s reports %s earnings today", r.Ticker, earningsQuarter(r))
+	switch r.Hour {
+	case marketdata.EarningsBeforeOpen:
+		title += " before the open"
+	case marketdata.EarningsAfterClose:
+		title += " after the close"
+	case marketdata.EarningsDuringHours:
+		title += " during market hours"
+	}
+	return title
+}
+
+// earningsUpcomingDescription returns the news description with the estimates,
+// e.g. "EPS estimate: 1.50, revenue estimate: $90.10B".
+func earningsUpcomingDescription(r *marketdata.EarningsReport) string {
+	var values []string
+	if r.EPSEstimate != nil {
+		values = append(values, fmt.Sprintf("EPS estimate: %.2f", *r.EPSEstimate))
+	}
+	if r.RevenueEstimate != nil {
+		values = append(values, "revenue estimate: "+formatRevenue(*r.RevenueEstimate))
+	}
+	if len(values) == 0 {
+		return "No estimates available."
+	}
+	return upperFirst(strings.Join(values, ", "))
+}
+
+// earningsResultsTitle returns the news title for the released report, e.g. "$AAPL Q1 2024 earnings: EPS beat".
+func earningsResultsTitle(r *marketdata.EarningsReport) string {
+	title := fmt.Sprintf("$%s %s earnings", r.Ticker, earningsQuarter(r))
+	var verdicts []string
+	if v := earningsVerdict(r.EPSActual, r.EPSEstimate); v != "" {
+		verdicts = append(verdicts, "EPS "+v)
+	}
+	if v := earningsVerdict(r.RevenueActual, r.RevenueEstimate); v != "" {
+		verdicts = append(verdicts, "revenue "+v)
+	}
+	if len(verdicts) > 0 {
+		title += ": " + strings.Join(verdicts, ", ")
+	}
+	return title
+}
+
+// earningsResultsDescription returns the news description with the actual values and estimates,
+// e.g. "EPS: 1.53 vs 1.50 estimate, revenue: $90.75B vs $90.10B estimate".
+func earningsResultsDescription(r *marketdata.EarningsReport) string {
+	var values []string
+	if r.EPSActual != nil {
+		v := fmt.Sprintf("EPS: %.2f", *r.EPSActual)
+		if r.EPSEstimate != nil {
+			v += fmt.Sprintf(" vs %.2f estimate", *r.EPSEstimate)
+		}
+		values = append(values, v)
+	}
+	if r.RevenueActual != nil {
+		v := "revenue: " + formatRevenue(*r.RevenueActual)
+		if r.RevenueEstimate != nil {
+			v += " vs " + formatRevenue(*r.RevenueEstimate) + " estimate"
+		}
+		values = append(values, v)
+	}
+	return upperFirst(strings.Join(values, ", "))
+}
+
+// earningsVerdict compares the actual value with the estimate: "beat", "miss", "in line" or empty if unknown.
+func earningsVerdict(actual, estimate *float64) string {
+	switch {
+	case actual == nil || estimate == nil:
+		return ""
+	case *actual > *estimate:
+		return "beat"
+	case *actual < *estimate:
+		return "miss"
+	default:
+		return "in line"
+	}
+}
+
+// earningsQuarter returns the fiscal quarter of the report, e.g. "Q1 2024".
+func earningsQuarter(r *marketdata.EarningsReport) string {
+	if r.Quarter == 0 || r.Year == 0 {
+		return "quarterly"
+	}
+	return fmt.Sprintf("Q%d %d", r.Quarter, r.Year)
+}
+
+// earningsLink returns the unique link to the report stage, so the results are not treated as
+// the duplicate of the upcoming report news.
+func earningsLink(r *marketdata.EarningsReport, stage string) string {
+	return fmt.Sprintf(earningsPageURL, strings.ToLower(r.Ticker)) + "#" + r.Date + "-" + stage
+}
+
+// formatRevenue formats the revenue in dollars with the scale suffix, e.g. "$90.10B".
+func formatRevenue(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("$%.2fB", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("$%.2fM", v/1e6)
+	default:
+		return fmt.Sprintf("$%.0f", v)
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,159 @@
+package journalist
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeEarnings struct {
+	reports []*marketdata.EarningsReport
+	err     error
+	ticker  string
+}
+
+func (f *fakeEarnings) FetchEarnings(_ context.Context, _, _ time.Time, ticker string) ([]*marketdata.EarningsReport, error) {
+	f.ticker = ticker
+	return f.reports, f.err
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestEarningsProvider_Fetch(t *testing.T) {
+	reports := []*marketdata.EarningsReport{
+		{
+			Ticker:          "AAPL",
+			Date:            "2024-04-25",
+			Hour:            marketdata.EarningsAfterClose,
+			Quarter:         2,
+			Year:            2024,
+			EPSEstimate:     ptr(1.5),
+			RevenueEstimate: ptr(90.1e9),
+		},
+		{
+			Ticker:          "MSFT",
+			Date:            "2024-04-24",
+			Hour:            marketdata.EarningsAfterClose,
+			Quarter:         3,
+			Year:            2024,
+			EPSEstimate:     ptr(2.82),
+			EPSActual:       ptr(2.94),
+			RevenueEstimate: ptr(60.8e9),
+			RevenueActual:   ptr(61.86e9),
+		},
+		{
+			Ticker:      "XYZ",
+			Date:        "2024-04-25",
+			EPSEstimate: ptr(0.1),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		watchlist []string
+		err       error
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "morning of the report",
+			now:       time.Date(2024, 4, 25, 11, 0, 0, 0, time.UTC), // 7:00 ET
+			watchlist: []string{"aapl", "$MSFT"},
+			want: []string{
+				"$AAPL reports Q2 2024 earnings today after the close",
+				"$MSFT Q3 2024 earnings: EPS beat, revenue beat",
+			},
+		},
+		{
+			name:      "before the morning",
+			now:       time.Date(2024, 4, 25, 8, 0, 0, 0, time.UTC), // 4:00 ET
+			watchlist: []string{"AAPL", "MSFT"},
+			want:      []string{"$MSFT Q3 2024 earnings: EPS beat, revenue beat"},
+		},
+		{
+			name: "empty watchlist",
+			now:  time.Date(2024, 4, 25, 11, 0, 0, 0, time.UTC),
+			want: []string{
+				"$AAPL reports Q2 2024 earnings today after the close",
+				"$MSFT Q3 2024 earnings: EPS beat, revenue beat",
+				"$XYZ reports quarterly earnings today",
+			},
+		},
+		{
+			name:    "calendar error",
+			now:     time.Date(2024, 4, 25, 11, 0, 0, 0, time.UTC),
+			err:     errors.New("boom"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEarningsProvider("Earnings", &fakeEarnings{reports: reports, err: tt.err}, tt.watchlist)
+			e.now = func() time.Time { return tt.now }
+
+			news, err := e.Fetch(context.Background(), time.Time{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var titles []string
+			for _, n := range news {
+				titles = append(titles, n.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.want) {
+				t.Errorf("Fetch() titles = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestEarningsProvider_Fetch_singleTicker(t *testing.T) {
+	calendar := &fakeEarnings{}
+	e := NewEarningsProvider("Earnings", calendar, []string{"nvda"})
+	if _, err := e.Fetch(context.Background(), time.Time{}); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if calendar.ticker != "NVDA" {
+		t.Errorf("Fetch() ticker = %q, want NVDA", calendar.ticker)
+	}
+}
+
+func Test_earningsResultsDescription(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *marketdata.EarningsReport
+		want   string
+	}{
+		{
+			name: "eps and revenue",
+			report: &marketdata.EarningsReport{
+				EPSEstimate: ptr(1.5), EPSActual: ptr(1.4), RevenueEstimate: ptr(90.1e9), RevenueActual: ptr(850e6),
+			},
+			want: "EPS: 1.40 vs 1.50 estimate, revenue: $850.00M vs $90.10B estimate",
+		},
+		{
+			name:   "eps without estimate",
+			report: &marketdata.EarningsReport{EPSActual: ptr(-0.25)},
+			want:   "EPS: -0.25",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := earningsResultsDescription(tt.report); got != tt.want {
+				t.Errorf("earningsResultsDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_earningsLink(t *testing.T) {
+	r := &marketdata.EarningsReport{Ticker: "AAPL", Date: "2024-04-25"}
+	if upcoming, results := earningsLink(r, "upcoming"), earningsLink(r, "results"); upcoming == results {
+		t.Errorf("earningsLink() = %q for both stages", upcoming)
+	}
+}
@@ -0,0 +1,250 @@
+package journalist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const edgarBaseURL = "https://www.sec.gov"
+
+// edgarForms holds the descriptions of the common form types for the news titles.
+var edgarForms = map[string]string{
+	"8-K":     "a current report",
+	"10-K":    "an annual report",
+	"10-Q":    "a quarterly report",
+	"13F-HR":  "a quarterly holdings report",
+	"S-1":     "an IPO registration statement",
+	"F-1":     "an IPO registration statement",
+	"SC 13D":  "an activist stake disclosure",
+	"SC 13G":  "a passive stake disclosure",
+	"4":       "an insider trade report",
+	"DEF 14A": "a proxy statement",
+}
+
+// edgarRoles are the roles of the company in the filing entries that are converted to news.
+// Filings with several parties (e.g. SC 13D, form 4) have the entry for each of them.
+var edgarRoles = []string{"Filer", "Subject", "Issuer"}
+
+// edgarTitleRe parses the title of the latest filings feed entry, e.g. "8-K - Apple Inc. (0000320193) (Filer)".
+var edgarTitleRe = regexp.MustCompile(`^(.+?) - (.+) \((\d{10})\) \((.+)\)$`)
+
+// EdgarProvider is the NewsProvider implementation that emits the new SEC EDGAR filings of the given form types
+// (8-K, 13F-HR, S-1, etc.) from the latest filings feed. If the watchlist is set, only filings of these companies
+// are emitted. The news title says who filed what, and the description lists the reported items (for 8-K),
+// so the composer can produce a meaningful headline.
+type EdgarProvider struct {
+	Name      string   // Name is used for logging purposes
+	Forms     []string // Form types, e.g. "8-K", "13F-HR", "S-1"
+	Watchlist []string // CIKs or tickers of the companies (optional)
+	UserAgent string   // SEC requires the user agent with the contact email
+	BaseURL   string
+	client    *http.Client
+
+	mu   sync.Mutex
+	ciks []string // resolved watchlist CIKs
+}
+
+// NewEdgarProvider creates a new EdgarProvider instance.
+func NewEdgarProvider(name string, forms, watchlist []string, userAgent string) *EdgarProvider {
+	return &EdgarProvider{
+		Name:      name,
+		Forms:     forms,
+		Watchlist: watchlist,
+		UserAgent: userAgent,
+		BaseURL:   edgarBaseURL,
+		client:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Fetch fetches the filings published after the given date.
+func (e *EdgarProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	ciks, err := e.watchlistCIKs(ctx)
+	if err != nil {
+		return nil, newError(errlvl.ERROR, err).WithProvider(e.Name)
+	}
+	if len(ciks) == 0 {
+		ciks = []string{""} // all companies
+	}
+
+	var news NewsList
+	for _, form := range e.Forms {
+		for _, cik := range ciks {
+			items, err := e.fetchFeed(ctx, form, cik)
+			if err != nil {
+				return nil, newError(errlvl.ERROR, err).WithProvider(e.Name)
+			}
+
+			for _, item := range items {
+				n, err := e.itemToNews(item, form)
+				if err != nil {
+					return nil, newError(errlvl.INFO, err).WithProvider(e.Name)
+				}
+				if n == nil || n.Date.Before(until) {
+					continue
+				}
+				news = append(news, n)
+			}
+		}
+	}
+
+	return news, nil
+}
+
+// fetchFeed fetches the latest filings of the form type (and company if cik is set).
+func (e *EdgarProvider) fetchFeed(ctx context.Context, form, cik string) ([]*gofeed.Item, error) {
+	q := url.Values{}
+	q.Set("action", "getcurrent")
+	q.Set("type", form)
+	q.Set("owner", "include")
+	q.Set("count", "100")
+	q.Set("output", "atom")
+	if cik != "" {
+		q.Set("CIK", cik)
+	}
+
+	fp := gofeed.NewParser()
+	fp.UserAgent = e.UserAgent
+	fp.Client = e.client
+	feed, err := fp.ParseURLWithContext(e.BaseURL+"/cgi-bin/browse-edgar?"+q.Encode(), ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s filings: %w", form, err)
+	}
+
+	return feed.Items, nil
+}
+
+// itemToNews converts the feed entry to the news. Returns nil if the entry is not a filing of the form type
+// (e.g. amendment) or is the duplicate entry of the same filing for the other party (e.g. "Reporting" person).
+func (e *EdgarProvider) itemToNews(item *gofeed.Item, form string) (*News, error) {
+	m := edgarTitleRe.FindStringSubmatch(item.Title)
+	if m == nil || m[1] != form || !slices.Contains(edgarRoles, m[4]) {
+		return nil, nil
+	}
+	company := m[2]
+
+	date := item.Updated
+	if date == "" {
+		date = item.Published
+	}
+	filed, err := utils.ParseDate(date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse date '%s': %w", date, err)
+	}
+
+	// Description has the filing date, so the next reports of the company are not treated as duplicates
+	return newNews(edgarTitle(company, form), edgarDescription(form, filed, item.Description), item.Link, date, e.Name)
+}
+
+// edgarTitle returns the news title, e.g. "Apple Inc. filed 8-K (a current report)".
+func edgarTitle(company, form string) string {
+	if desc, ok := edgarForms[form]; ok {
+		return fmt.Sprintf("%s filed %s (%s)", company, form, desc)
+	}
+	return fmt.Sprintf("%s filed %s", company, form)
+}
+
+// edgarDescription returns the news description with the reported items parsed from the entry summary, e.g.
+// "Filed: 2024-02-01 AccNo: 0000320193-24-000006 Size: 52 KB<br>Item 2.02: Results of Operations".
+func edgarDescription(form string, date time.Time, summary string) string {
+	var items []string
+	for _, line := range strings.Split(summary, "<br>") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Item ") {
+			items = append(items, line)
+		}
+	}
+
+	desc := fmt.Sprintf("SEC form %s filed on %s.", form, date.Format(time.DateOnly))
+	if len(items) == 0 {
+		return desc
+	}
+	return desc + " " + strings.Join(items, ". ")
+}
+
+// watchlistCIKs returns the watchlist CIKs, tickers are resolved via the SEC company tickers file.
+// Resolved CIKs are cached, so the file is fetched only once.
+func (e *EdgarProvider) watchlistCIKs(ctx context.Context) ([]string, error) {
+	if len(e.Watchlist) == 0 {
+		return nil, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ciks != nil {
+		return e.ciks, nil
+	}
+
+	var tickers map[string]string // ticker -> CIK
+	ciks := make([]string, 0, len(e.Watchlist))
+	for _, w := range e.Watchlist {
+		if cik, err := strconv.Atoi(w); err == nil {
+			ciks = append(ciks, fmt.Sprintf("%010d", cik))
+			continue
+		}
+
+		if tickers == nil {
+			var err error
+			tickers, err = e.fetchTickers(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cik, ok := tickers[strings.ToUpper(w)]
+		if !ok {
+			return nil, fmt.Errorf("unknown ticker %s", w)
+		}
+		ciks = append(ciks, cik)
+	}
+
+	slices.Sort(ciks)
+	e.ciks = slices.Compact(ciks)
+
+	return e.ciks, nil
+}
+
+// fetchTickers fetches the map of the tickers to the CIKs.
+func (e *EdgarProvider) fetchTickers(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.BaseURL+"/files/company_tickers.json", http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", e.UserAgent)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch company tickers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var companies map[string]struct {
+		CIK    int    `json:"cik_str"`
+		Ticker string `json:"ticker"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&companies); err != nil {
+		return nil, fmt.Errorf("failed to decode company tickers: %w", err)
+	}
+
+	tickers := make(map[string]string, len(companies))
+	for _, c := range companies {
+		tickers[c.Ticker] = fmt.Sprintf("%010d", c.CIK)
+	}
+
+	return tickers, nil
+}
@@ -0,0 +1,96 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEdgarProvider_Fetch(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := func(title, summary string, updated time.Time) string {
+		return fmt.Sprintf(`<entry><title>%s</title><link rel="alternate" type="text/html" href="https://www.sec.gov/Archives/edgar/data/%d-index.htm"/>
+			<summary type="html">%s</summary><updated>%s</updated></entry>`, title, updated.Unix(), summary, updated.Format(time.RFC3339))
+	}
+	feed := `<?xml version="1.0" encoding="ISO-8859-1" ?><feed xmlns="http://www.w3.org/2005/Atom"><title>Latest Filings</title>` +
+		entry("8-K - Apple Inc. (0000320193) (Filer)",
+			" &lt;b&gt;Filed:&lt;/b&gt; 2024-02-01 &lt;b&gt;AccNo:&lt;/b&gt; 0000320193-24-000006 &lt;b&gt;Size:&lt;/b&gt; 52 KB&lt;br&gt;Item 2.02: Results of Operations and Financial Condition&lt;br&gt;Item 9.01: Financial Statements and Exhibits",
+			now) +
+		entry("8-K/A - Tesla, Inc. (0001318605) (Filer)", "Amendment", now) +
+		entry("8-K - Old Corp (0000000001) (Filer)", "Old", now.Add(-2*time.Hour)) +
+		`</feed>`
+
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "test admin@example.com" {
+			t.Errorf("unexpected User-Agent %q", r.Header.Get("User-Agent"))
+		}
+		switch r.URL.Path {
+		case "/files/company_tickers.json":
+			_, _ = w.Write([]byte(`{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."}}`))
+		case "/cgi-bin/browse-edgar":
+			queries = append(queries, r.URL.Query().Get("type")+":"+r.URL.Query().Get("CIK"))
+			_, _ = w.Write([]byte(feed))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("latest filings", func(t *testing.T) {
+		queries = nil
+		e := NewEdgarProvider("edgar", []string{"8-K"}, nil, "test admin@example.com")
+		e.BaseURL = srv.URL
+
+		news, err := e.Fetch(context.Background(), now.Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if len(queries) != 1 || queries[0] != "8-K:" {
+			t.Errorf("Fetch() queries = %v", queries)
+		}
+		if len(news) != 1 {
+			t.Fatalf("Fetch() returned %d news, want 1", len(news))
+		}
+
+		n := news[0]
+		if n.Title != "Apple Inc. filed 8-K (a current report)" {
+			t.Errorf("Fetch() title = %q", n.Title)
+		}
+		wantDesc := fmt.Sprintf("SEC form 8-K filed on %s. Item 2.02: Results of Operations and Financial Condition. "+
+			"Item 9.01: Financial Statements and Exhibits", now.Format(time.DateOnly))
+		if n.Description != wantDesc {
+			t.Errorf("Fetch() description = %q, want %q", n.Description, wantDesc)
+		}
+		if !strings.HasPrefix(n.Link, "https://www.sec.gov/Archives/") || !n.Date.Equal(now) {
+			t.Errorf("Fetch() link = %q, date = %v", n.Link, n.Date)
+		}
+	})
+
+	t.Run("watchlist", func(t *testing.T) {
+		queries = nil
+		e := NewEdgarProvider("edgar", []string{"8-K", "S-1"}, []string{"AAPL", "1318605"}, "test admin@example.com")
+		e.BaseURL = srv.URL
+
+		if _, err := e.Fetch(context.Background(), now.Add(-time.Hour)); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		want := []string{"8-K:0000320193", "8-K:0001318605", "S-1:0000320193", "S-1:0001318605"}
+		if fmt.Sprint(queries) != fmt.Sprint(want) {
+			t.Errorf("Fetch() queries = %v, want %v", queries, want)
+		}
+	})
+
+	t.Run("unknown ticker", func(t *testing.T) {
+		e := NewEdgarProvider("edgar", []string{"8-K"}, []string{"NOPE"}, "test admin@example.com")
+		e.BaseURL = srv.URL
+
+		if _, err := e.Fetch(context.Background(), now.Add(-time.Hour)); err == nil {
+			t.Error("Fetch() expected error")
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"strings"
 )
 
 var (
@@ -11,6 +12,16 @@ var (
 	errMarshalSimpleNews  = errors.New("failed to marshal simpleNews")
 	errPanicGetLatestNews = errors.New("panic in Journalist.GetLatestNews")
 	errPanicUnknown       = errors.New("unknown panic")
+	errPluginExec         = errors.New("failed to execute plugin")
+	errPluginResponse     = errors.New("failed to decode plugin response")
+	errPluginFailed       = errors.New("plugin returned an error")
+	errWebhookItem        = errors.New("invalid webhook item")
+	errFetchContent       = errors.New("failed to fetch content of the news")
+	errContentEmpty       = errors.New("no article text found")
+	errDiscoverURL        = errors.New("invalid page URL")
+	errDiscoverPage       = errors.New("failed to fetch page")
+	errFeedNotFound       = errors.New("no valid feed found")
+	errResolveLinks       = errors.New("failed to resolve links of the news")
 )
 
 // Error is the error type for the Journalist.
@@ -43,6 +54,59 @@ func (e *Error) getWrappedError() error {
 	return errlvl.Wrap(err, e.level)
 }
 
+// ProviderError is the failed fetch of the single provider.
+type ProviderError struct {
+	Provider string // name of the provider
+	Err      error  // error of the provider, its message contains the provider name
+}
+
+// FetchError is the error of Journalist.GetLatestNews with the failed providers.
+// News of the other providers are returned along with it.
+type FetchError struct {
+	Failed []ProviderError
+	Total  int // number of the providers of the journalist
+}
+
+func (e *FetchError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Err.Error()
+	}
+	return fmt.Sprintf("%d of %d providers failed: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed providers, so errors.Is and errors.As check all of them.
+func (e *FetchError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// Providers returns the names of the failed providers.
+func (e *FetchError) Providers() []string {
+	names := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		names[i] = f.Provider
+	}
+	return names
+}
+
+// AllFailed returns true if none of the providers fetched the news.
+func (e *FetchError) AllFailed() bool {
+	return len(e.Failed) >= e.Total
+}
+
+// withProvider adds the provider name to the error if it doesn't have one (e.g. timeouts and panics).
+func withProvider(err error, providerName string) error {
+	var e *Error
+	if errors.As(err, &e) && e.providerName != "" {
+		return err
+	}
+	return newError(errlvl.ERROR, err).WithProvider(providerName)
+}
+
 // newError creates a new Error instance.
 func newError(lvl errlvl.Lvl, errs ...error) *Error {
 	return &Error{
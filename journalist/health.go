@@ -0,0 +1,66 @@
+package journalist
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"net/http"
+	"time"
+)
+
+// FetchResult is the outcome of the single provider fetch, used for the providers health monitoring.
+type FetchResult struct {
+	Provider   string        // Name of the provider
+	At         time.Time     // Time when the fetch started
+	Duration   time.Duration // Fetch latency
+	StatusCode int           // HTTP status code of the response, 0 if the request failed before getting one
+	Err        error         // Fetch error, nil if successful
+}
+
+// FetchObserver receives the results of all provider fetches. It must be safe for concurrent use.
+type FetchObserver func(r FetchResult)
+
+// ObserveFetches sets the observer that will receive the result of each provider fetch.
+func (j *Journalist) ObserveFetches(o FetchObserver) *Journalist {
+	j.observer = o
+	return j
+}
+
+// providerName returns the name of the provider for the health monitoring.
+func providerName(p NewsProvider) string {
+	switch v := p.(type) {
+	case *RssProvider:
+		return v.Name
+	case *PluginProvider:
+		return v.Name
+	case *CalendarProvider:
+		return v.Name
+	case *RedditProvider:
+		return v.Name
+	case *EdgarProvider:
+		return v.Name
+	case *XProvider:
+		return v.Name
+	case *EarningsProvider:
+		return v.Name
+	case *WebhookProvider:
+		return v.Name
+	default:
+		return fmt.Sprintf("%T", p)
+	}
+}
+
+// statusCode returns the HTTP status code for the fetch error.
+// Successful fetch is considered as 200, errors without HTTP response as 0.
+func statusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var httpErr gofeed.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+
+	return 0
+}
@@ -0,0 +1,39 @@
+package journalist
+
+import (
+	"errors"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"testing"
+)
+
+func Test_statusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "successful fetch",
+			err:  nil,
+			want: 200,
+		},
+		{
+			name: "wrapped http error",
+			err:  newError(errlvl.ERROR, gofeed.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}).WithProvider("test"),
+			want: 503,
+		},
+		{
+			name: "network error",
+			err:  newError(errlvl.ERROR, errors.New("dial tcp: i/o timeout")).WithProvider("test"),
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusCode(tt.err); got != tt.want {
+				t.Errorf("statusCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package journalist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	htmlMinInterval = 5 * time.Minute // Default min interval between the page requests, the pages are crawled politely
+	robotsTTL       = 24 * time.Hour  // robots.txt of the site is requested again after this interval
+)
+
+// htmlDateLayouts are the common date formats of the news pages, tried after HTMLSelectors.DateLayout.
+var htmlDateLayouts = []string{
+	"2006-01-02",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"02 Jan 2006",
+	"02.01.2006",
+	"01/02/2006",
+}
+
+// linkSelector matches the links of the news titles without the link selector.
+var linkSelector = cascadia.MustCompile("a[href]")
+
+// errRobotsDisallowed is returned if robots.txt of the site disallows the page for the user agent.
+var errRobotsDisallowed = errors.New("page is disallowed by robots.txt")
+
+// HTMLSelectors are the CSS selectors of the news on the page without RSS (e.g. exchange notices),
+// e.g. "ul.notices > li" for Item and "a" for Title. Other selectors are matched within the item.
+type HTMLSelectors struct {
+	Item        string // selector of the news items, required
+	Title       string // selector of the title within the item, required
+	Link        string // selector of the link within the item, the title link if empty
+	Date        string // selector of the date within the item: datetime attribute or the text (optional)
+	Description string // selector of the description within the item (optional)
+	DateLayout  string // Go layout of the date text, e.g. "02 Jan 2006", the common formats are tried if empty
+}
+
+// HTMLProvider is the NewsProvider of the pages without RSS (e.g. exchange notices, regulator press releases)
+// scraped by the CSS selectors. The crawling is polite: robots.txt of the site is respected, the page is requested
+// at most once per MinInterval (or Crawl-delay) with the conditional GET.
+//
+// Items without date are dated by the fetch they first appeared on the page in, the items found by the first
+// fetch are considered old. Items removed from the page are forgotten.
+type HTMLProvider struct {
+	Name        string        // Name is used for logging purposes
+	URL         string        // URL of the page
+	Selectors   HTMLSelectors // CSS selectors of the news
+	UserAgent   string        // User agent of the requests, also matched with the robots.txt groups
+	MinInterval time.Duration // Fetches more often than this are skipped, 5 minutes by default
+	client      *http.Client
+
+	item, title, link, date, description cascadia.Selector
+
+	mu           sync.Mutex
+	lastFetch    time.Time
+	etag         string
+	lastModified string
+	robots       *robotsRules
+	robotsAt     time.Time
+	seen         map[string]time.Time // first seen time of the items on the page by their link and title, nil before the first fetch
+}
+
+// NewHTMLProvider creates a new HTMLProvider instance. Returns the error if the selectors are invalid.
+func NewHTMLProvider(name, url string, selectors HTMLSelectors) (*HTMLProvider, error) {
+	h := &HTMLProvider{
+		Name:        name,
+		URL:         url,
+		Selectors:   selectors,
+		UserAgent:   rssUserAgent,
+		MinInterval: htmlMinInterval,
+		client:      &http.Client{Timeout: 15 * time.Second},
+	}
+
+	var err error
+	if h.item, err = cascadia.Compile(selectors.Item); err != nil {
+		return nil, fmt.Errorf("invalid item selector: %w", err)
+	}
+	if h.title, err = cascadia.Compile(selectors.Title); err != nil {
+		return nil, fmt.Errorf("invalid title selector: %w", err)
+	}
+	for _, s := range []struct {
+		name string
+		sel  string
+		dst  *cascadia.Selector
+	}{
+		{"link", selectors.Link, &h.link},
+		{"date", selectors.Date, &h.date},
+		{"description", selectors.Description, &h.description},
+	} {
+		if s.sel == "" {
+			continue
+		}
+		if *s.dst, err = cascadia.Compile(s.sel); err != nil {
+			return nil, fmt.Errorf("invalid %s selector: %w", s.name, err)
+		}
+	}
+
+	return h, nil
+}
+
+// WithUserAgent sets the user agent of the requests.
+func (h *HTMLProvider) WithUserAgent(userAgent string) *HTMLProvider {
+	h.UserAgent = userAgent
+	return h
+}
+
+// WithMinInterval sets the minimum interval between the page requests. Fetches made earlier return no news.
+func (h *HTMLProvider) WithMinInterval(d time.Duration) *HTMLProvider {
+	h.MinInterval = d
+	return h
+}
+
+// Fetch scrapes the news published after the until date from the page.
+// Returns no news if the page is not modified or the crawl interval has not passed yet.
+func (h *HTMLProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	robots, err := h.robotsRules(ctx)
+	if err != nil {
+		return nil, newError(errlvl.ERROR, err).WithProvider(h.Name)
+	}
+
+	now := time.Now()
+	interval := max(h.MinInterval, robots.crawlDelay)
+	if !h.lastFetch.IsZero() && now.Sub(h.lastFetch) < interval {
+		return nil, nil
+	}
+	h.lastFetch = now
+
+	if u, err := url.Parse(h.URL); err == nil && !robots.allowed(u.RequestURI()) {
+		return nil, newError(errlvl.WARN, errRobotsDisallowed).WithProvider(h.Name)
+	}
+
+	doc, err := h.fetchPage(ctx)
+	if err != nil {
+		return nil, newError(errlvl.ERROR, err).WithProvider(h.Name)
+	}
+	if doc == nil {
+		return nil, nil
+	}
+
+	firstFetch := h.seen == nil
+	seen := make(map[string]time.Time)
+	var news NewsList
+	for _, item := range h.item.MatchAll(doc) {
+		title, link, date, description := h.parseItem(item)
+		if title == "" || link == "" {
+			continue
+		}
+
+		key := link + "\n" + title
+		firstSeen, ok := h.seen[key]
+		if !ok && !firstFetch {
+			firstSeen = now
+		}
+		seen[key] = firstSeen
+
+		if date.IsZero() {
+			// The items of the first fetch were published before the provider started
+			if firstSeen.IsZero() {
+				continue
+			}
+			date = firstSeen
+		}
+		if date.Before(until) {
+			continue
+		}
+
+		n, err := newNews(title, description, link, date.Format(time.RFC3339), h.Name)
+		if err != nil {
+			return nil, newError(errlvl.INFO, err).WithProvider(h.Name)
+		}
+		news = append(news, n)
+	}
+	h.seen = seen
+
+	return news, nil
+}
+
+// parseItem returns the title, absolute link, date (zero if unknown) and description of the news item.
+func (h *HTMLProvider) parseItem(item *html.Node) (title, link string, date time.Time, description string) {
+	titleNode := h.title.MatchFirst(item)
+	if titleNode == nil {
+		return "", "", time.Time{}, ""
+	}
+	title = nodeText(titleNode)
+
+	var linkNode *html.Node
+	if h.link != nil {
+		linkNode = h.link.MatchFirst(item)
+	} else {
+		linkNode = closestLink(titleNode, item)
+	}
+	if linkNode != nil {
+		if href := strings.TrimSpace(attr(linkNode, "href")); href != "" && !strings.HasPrefix(href, "#") {
+			link = resolveURL(h.URL, href)
+		}
+	}
+
+	if h.date != nil {
+		if dateNode := h.date.MatchFirst(item); dateNode != nil {
+			date = h.parseDate(dateNode)
+		}
+	}
+
+	if h.description != nil {
+		if descNode := h.description.MatchFirst(item); descNode != nil {
+			description = nodeText(descNode)
+		}
+	}
+
+	return title, link, date, description
+}
+
+// parseDate parses the datetime (or content) attribute or the text of the date node. Returns zero time if unknown.
+func (h *HTMLProvider) parseDate(n *html.Node) time.Time {
+	text := attr(n, "datetime")
+	if text == "" {
+		text = attr(n, "content")
+	}
+	if text == "" {
+		text = nodeText(n)
+	}
+
+	layouts := htmlDateLayouts
+	if h.Selectors.DateLayout != "" {
+		layouts = append([]string{h.Selectors.DateLayout}, layouts...)
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.UTC()
+		}
+	}
+	if t, err := jsonDate(text); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// closestLink returns the link of the title: the title itself, its first link or the closest link parent within the item.
+func closestLink(n, item *html.Node) *html.Node {
+	if n.DataAtom == atom.A {
+		return n
+	}
+	if a := linkSelector.MatchFirst(n); a != nil {
+		return a
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.DataAtom == atom.A {
+			return p
+		}
+		if p == item {
+			break
+		}
+	}
+	return nil
+}
+
+// fetchPage requests the page with the cache validators of the previous response.
+// Returns nil document if the page is not modified.
+func (h *HTMLProvider) fetchPage(ctx context.Context) (*html.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", h.userAgent())
+	if h.etag != "" {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+	if h.lastModified != "" {
+		req.Header.Set("If-Modified-Since", h.lastModified)
+	}
+
+	resp, err := h.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", h.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// The same error type as for the RSS feeds, so the status is tracked by the providers health
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, contentMaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", h.Name, err)
+	}
+	h.etag = resp.Header.Get("ETag")
+	h.lastModified = resp.Header.Get("Last-Modified")
+
+	return doc, nil
+}
+
+// robotsRules returns the robots.txt rules of the site for the user agent, they are requested once per robotsTTL.
+// Missing robots.txt (4xx) allows everything, other failures are returned, so the page is not crawled blindly.
+func (h *HTMLProvider) robotsRules(ctx context.Context) (*robotsRules, error) {
+	if h.robots != nil && time.Since(h.robotsAt) < robotsTTL {
+		return h.robots, nil
+	}
+
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create robots.txt request: %w", err)
+	}
+	req.Header.Set("User-Agent", h.userAgent())
+
+	resp, err := h.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		h.robots = parseRobots(resp.Body, h.userAgent())
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		h.robots = &robotsRules{}
+	default:
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+	h.robotsAt = time.Now()
+
+	return h.robots, nil
+}
+
+func (h *HTMLProvider) userAgent() string {
+	if h.UserAgent == "" {
+		return rssUserAgent
+	}
+	return h.UserAgent
+}
+
+func (h *HTMLProvider) httpClient() *http.Client {
+	if h.client == nil {
+		return rssClient
+	}
+	return h.client
+}
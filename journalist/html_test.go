@@ -0,0 +1,102 @@
+package journalist
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTMLProvider_Fetch(t *testing.T) {
+	var mu sync.Mutex
+	page := `<ul class="notices">
+<li><a href="/notices/1">Trading halt in ACME</a><time datetime="2024-01-02T10:00:00Z">Jan 2</time></li>
+<li><h3>Undated notice</h3><a href="https://cdn.example.com/2.pdf">PDF</a></li>
+<li><span class="title">Without link</span></li>
+</ul>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		default:
+			_, _ = w.Write([]byte(page))
+		}
+	}))
+	defer srv.Close()
+
+	h, err := NewHTMLProvider("notices", srv.URL+"/notices", HTMLSelectors{
+		Item:  "ul.notices > li",
+		Title: "a, h3, span.title",
+		Link:  "a[href]",
+		Date:  "time",
+	})
+	if err != nil {
+		t.Fatalf("NewHTMLProvider() error = %v", err)
+	}
+	h.WithMinInterval(0)
+
+	ctx := context.Background()
+	until := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	news, err := h.Fetch(ctx, until)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	// Undated items of the first fetch are considered old
+	if len(news) != 1 || news[0].Title != "Trading halt in ACME" || news[0].Link != srv.URL+"/notices/1" ||
+		!news[0].Date.Equal(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Fetch() = %+v, want the dated notice", news)
+	}
+
+	mu.Lock()
+	page = strings.Replace(page, "</ul>", `<li><h3>New notice</h3><a href="/notices/3">Read</a></li></ul>`, 1)
+	mu.Unlock()
+
+	start := time.Now()
+	news, err = h.Fetch(ctx, start.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(news) != 1 || news[0].Title != "New notice" || news[0].Link != srv.URL+"/notices/3" || news[0].Date.Before(start.Add(-time.Second)) {
+		t.Fatalf("Fetch() = %+v, want the new notice dated by the fetch", news)
+	}
+
+	private, err := NewHTMLProvider("private", srv.URL+"/private/notices", HTMLSelectors{Item: "li", Title: "a"})
+	if err != nil {
+		t.Fatalf("NewHTMLProvider() error = %v", err)
+	}
+	if _, err := private.Fetch(ctx, until); !errors.Is(err, errRobotsDisallowed) {
+		t.Errorf("Fetch() error = %v, want %v", err, errRobotsDisallowed)
+	}
+}
+
+func TestHTMLProvider_MinInterval(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		_, _ = w.Write([]byte(`<ul><li><a href="/1">Notice</a></li></ul>`))
+	}))
+	defer srv.Close()
+
+	h, err := NewHTMLProvider("notices", srv.URL, HTMLSelectors{Item: "li", Title: "a"})
+	if err != nil {
+		t.Fatalf("NewHTMLProvider() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := h.Fetch(context.Background(), time.Time{}); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("page requests = %d, want 1 within the min interval", requests)
+	}
+}
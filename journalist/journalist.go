@@ -4,17 +4,31 @@ import (
 	"context"
 	"errors"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/pkg/metrics"
 	"golang.org/x/sync/errgroup"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
+// defaultProviderTimeout is the deadline of the single provider fetch.
+const defaultProviderTimeout = 5 * time.Second
+
+// logger is the logger of the provider fetches.
+var logger = logging.For(logging.ModuleJournalist)
+
 // Journalist is the main struct that fetches the news from all providers and merges them into unified list.
 type Journalist struct {
 	Name      string // Name of the journalist (for logging purposes)
 	providers []NewsProvider
-	flagKeys  []string // Keys that will "flag" the news as something that should be double-checked by human
-	limitNews int      // Limit the number of news to fetch from each provider
+	limitNews int // Limit the number of news to fetch from each provider
+	// Deadline of the single provider fetch, defaultProviderTimeout if not set
+	providerTimeout time.Duration
+	observer        FetchObserver
+	metrics         metrics.Emitter
+	domains         DomainFilter // Filter of the news by the domain of their links
 }
 
 // NewJournalist creates a new Journalist instance.
@@ -22,22 +36,47 @@ func NewJournalist(name string, providers []NewsProvider) *Journalist {
 	return &Journalist{
 		Name:      name,
 		providers: providers,
+		metrics:   metrics.Noop{},
 	}
 }
 
-// FlagByKeys sets the keys that will "flag" news that contain them by setting News.IsSuspicious to true.
-func (j *Journalist) FlagByKeys(flagKeys []string) *Journalist {
-	j.flagKeys = flagKeys
+// WithMetrics sets the metrics emitter for the fetched news, errors and latency of each provider.
+func (j *Journalist) WithMetrics(m metrics.Emitter) *Journalist {
+	j.metrics = m
+	return j
+}
+
+// WithProviderTimeout sets the deadline of the single provider fetch (5s by default).
+func (j *Journalist) WithProviderTimeout(timeout time.Duration) *Journalist {
+	j.providerTimeout = timeout
 	return j
 }
 
+// FilterDomains keeps only the news of the allowed domains (all if empty) and drops the news of the blocked ones.
+// The limit of news per provider is applied after the filter.
+func (j *Journalist) FilterDomains(allowed, blocked []string) *Journalist {
+	j.domains = DomainFilter{Allowed: allowed, Blocked: blocked}
+	return j
+}
+
+// ProviderNames returns the names of the providers in the order they were added.
+func (j *Journalist) ProviderNames() []string {
+	names := make([]string, len(j.providers))
+	for i, p := range j.providers {
+		names[i] = providerName(p)
+	}
+	return names
+}
+
 // Limit sets the limit of news to fetch from each provider.
 func (j *Journalist) Limit(limit int) *Journalist {
 	j.limitNews = limit
 	return j
 }
 
-// GetLatestNews fetches the latest news (until date) from all providers and merges them into unified list.
+// GetLatestNews fetches the latest news (until date) from all providers concurrently and merges them into unified list.
+// Each provider has its own timeout (see WithProviderTimeout), so the hanging provider doesn't stall the others.
+// If some providers fail, the news of the rest are returned along with the *FetchError describing the failed ones.
 func (j *Journalist) GetLatestNews(ctx context.Context, until time.Time) (NewsList, error) {
 	// Manage goroutines and errors
 	var eg errgroup.Group
@@ -45,14 +84,24 @@ func (j *Journalist) GetLatestNews(ctx context.Context, until time.Time) (NewsLi
 	// Use a mutex to safely access shared data (results and errors)
 	var mu sync.Mutex
 	var results NewsList
-	var e []error
+	var failed []ProviderError
+
+	m := j.metrics
+	if m == nil {
+		m = metrics.Noop{}
+	}
+	timeout := j.providerTimeout
+	if timeout <= 0 {
+		timeout = defaultProviderTimeout
+	}
 
 	for i := 0; i < len(j.providers); i++ {
 		// Capture loop variable
 		id := i
+		name := providerName(j.providers[id])
 
 		eg.Go(func() error {
-			c, cancel := context.WithTimeout(ctx, 5*time.Second)
+			c, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
 			defer func() {
 				if r := recover(); r != nil {
@@ -63,24 +112,47 @@ func (j *Journalist) GetLatestNews(ctx context.Context, until time.Time) (NewsLi
 
 					mu.Lock()
 					defer mu.Unlock()
-					e = append(e, errors.Join(errPanicGetLatestNews, err))
+					failed = append(failed, ProviderError{
+						Provider: name,
+						Err:      withProvider(errors.Join(errPanicGetLatestNews, err), name),
+					})
 				}
 			}()
 
+			start := time.Now()
 			result, err := j.providers[id].Fetch(c, until)
+			m.Timing(metrics.ProviderLatency, time.Since(start), metrics.T("provider", name))
+			if j.observer != nil {
+				j.observer(FetchResult{
+					Provider:   name,
+					At:         start,
+					Duration:   time.Since(start),
+					StatusCode: statusCode(err),
+					Err:        err,
+				})
+			}
 			if err != nil {
+				logger.DebugContext(ctx, "[journalist] Provider fetch failed",
+					"journalist", j.Name, "provider", name, "duration", time.Since(start), "error", err)
+				m.Count(metrics.ProviderErrors, 1, metrics.T("provider", name))
 				// Use a mutex to safely append errors
 				mu.Lock()
 				defer mu.Unlock()
-				e = append(e, err)
+				failed = append(failed, ProviderError{Provider: name, Err: withProvider(err, name)})
 				return nil // Return nil to continue processing other goroutines
 			}
 
+			result = j.domains.Filter(result)
+
 			// Limit the number of news to fetch from each provider if limitNews > 0
 			if j.limitNews > 0 && len(result) > j.limitNews {
 				result = result[:j.limitNews]
 			}
 
+			m.Count(metrics.ProviderFetched, int64(len(result)), metrics.T("provider", name))
+			logger.DebugContext(ctx, "[journalist] Provider fetched",
+				"journalist", j.Name, "provider", name, "duration", time.Since(start), "news", len(result))
+
 			// Use a mutex to safely append results
 			mu.Lock()
 			defer mu.Unlock()
@@ -96,9 +168,11 @@ func (j *Journalist) GetLatestNews(ctx context.Context, until time.Time) (NewsLi
 
 	results = results.mapIDs()
 
-	if len(j.flagKeys) > 0 {
-		results.flagByKeywords(j.flagKeys)
+	if len(failed) > 0 {
+		// Stable order of the failed providers in the logs
+		slices.SortFunc(failed, func(a, b ProviderError) int { return strings.Compare(a.Provider, b.Provider) })
+		return results, &FetchError{Failed: failed, Total: len(j.providers)}
 	}
 
-	return results, errors.Join(e...)
+	return results, nil
 }
@@ -2,6 +2,8 @@ package journalist
 
 import (
 	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"testing"
 	"time"
 )
@@ -89,3 +91,86 @@ func TestJournalist_GetLatestNews(t *testing.T) {
 		})
 	}
 }
+
+// funcProvider is the NewsProvider for tests.
+type funcProvider struct {
+	fetch func(ctx context.Context) (NewsList, error)
+}
+
+func (p *funcProvider) Fetch(ctx context.Context, _ time.Time) (NewsList, error) {
+	return p.fetch(ctx)
+}
+
+func TestJournalist_GetLatestNews_partial(t *testing.T) {
+	okNews := &funcProvider{fetch: func(context.Context) (NewsList, error) {
+		return NewsList{{Title: "Fed holds rates", ProviderName: "ok"}}, nil
+	}}
+	hanging := &funcProvider{fetch: func(ctx context.Context) (NewsList, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	failing := &funcProvider{fetch: func(context.Context) (NewsList, error) {
+		return nil, newError(errlvl.ERROR, errors.New("boom")).WithProvider("failing")
+	}}
+	panicking := &funcProvider{fetch: func(context.Context) (NewsList, error) {
+		panic(errors.New("nil map"))
+	}}
+
+	tests := []struct {
+		name       string
+		providers  []NewsProvider
+		wantNews   int
+		wantFailed int
+		allFailed  bool
+	}{
+		{
+			name:      "all providers succeed",
+			providers: []NewsProvider{okNews},
+			wantNews:  1,
+		},
+		{
+			name:       "partial results",
+			providers:  []NewsProvider{okNews, hanging, failing, panicking},
+			wantNews:   1,
+			wantFailed: 3,
+		},
+		{
+			name:       "all providers fail",
+			providers:  []NewsProvider{hanging, failing},
+			wantFailed: 2,
+			allFailed:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJournalist("test", tt.providers).WithProviderTimeout(50 * time.Millisecond)
+
+			start := time.Now()
+			got, err := j.GetLatestNews(context.Background(), time.Time{})
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("GetLatestNews() took %v, the hanging provider is not timed out", d)
+			}
+			if len(got) != tt.wantNews {
+				t.Errorf("GetLatestNews() got %d news, want %d", len(got), tt.wantNews)
+			}
+
+			var fetchErr *FetchError
+			if tt.wantFailed == 0 {
+				if err != nil {
+					t.Errorf("GetLatestNews() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.As(err, &fetchErr) {
+				t.Fatalf("GetLatestNews() error = %v, want *FetchError", err)
+			}
+			if len(fetchErr.Failed) != tt.wantFailed || fetchErr.AllFailed() != tt.allFailed {
+				t.Errorf("GetLatestNews() failed = %v, all failed = %v", fetchErr.Providers(), fetchErr.AllFailed())
+			}
+			if !errors.Is(err, errlvl.ErrError) {
+				// Provider errors keep their levels for Sentry
+				t.Errorf("GetLatestNews() error %v has no level", err)
+			}
+		})
+	}
+}
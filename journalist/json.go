@@ -0,0 +1,328 @@
+package journalist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonMaxPages is the max number of pages of the single fetch if JSONPagination.MaxPages is not set.
+const jsonMaxPages = 1
+
+// JSONFields are the paths of the news fields in the item of the JSON API response (see jsonPath),
+// e.g. "headline" or "attributes.published_at". Title and Date are required.
+type JSONFields struct {
+	Title       string // path of the title
+	Description string // path of the description or summary (optional)
+	Link        string // path of the article URL, relative URLs are resolved against the API URL (optional)
+	Date        string // path of the date: RFC3339, RFC1123 or the Unix timestamp in seconds or milliseconds
+	Image       string // path of the image URL (optional)
+}
+
+// JSONPagination defines how the next pages of the JSON API are requested. Pages are requested until
+// MaxPages, the page without the news newer than the until date or the page without the next one.
+// Only one of PageParam, CursorPath or NextPath is used (in this order).
+type JSONPagination struct {
+	PageParam   string // query parameter of the page number, e.g. "page"
+	StartPage   int    // number of the first page, 1 if 0
+	CursorPath  string // path of the cursor of the next page in the response, e.g. "meta.next_cursor"
+	CursorParam string // query parameter of the cursor, "cursor" if empty
+	NextPath    string // path of the URL of the next page in the response, e.g. "links.next"
+	MaxPages    int    // max pages of the single fetch, 1 if 0
+}
+
+// JSONProvider is the NewsProvider of the arbitrary JSON APIs (e.g. the fintech news APIs) configured
+// with the paths of the news array and fields in the response instead of the provider code.
+type JSONProvider struct {
+	Name       string            // Name is used for logging purposes
+	URL        string            // URL of the API endpoint with the query parameters
+	Items      string            // path of the news array in the response, e.g. "data.articles", the response itself if empty
+	Fields     JSONFields        // paths of the news fields in the item
+	Headers    map[string]string // headers of the requests, e.g. the API key (optional)
+	Pagination JSONPagination    // pagination of the API (optional)
+	UserAgent  string            // user agent of the requests
+	client     *http.Client
+}
+
+// NewJSONProvider creates a new JSONProvider instance.
+func NewJSONProvider(name, url, items string, fields JSONFields) *JSONProvider {
+	return &JSONProvider{
+		Name:      name,
+		URL:       url,
+		Items:     items,
+		Fields:    fields,
+		UserAgent: rssUserAgent,
+		client:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// WithHeaders sets the headers of the requests, e.g. {"X-Api-Key": "..."}.
+func (j *JSONProvider) WithHeaders(headers map[string]string) *JSONProvider {
+	j.Headers = headers
+	return j
+}
+
+// WithPagination sets the pagination of the API.
+func (j *JSONProvider) WithPagination(p JSONPagination) *JSONProvider {
+	j.Pagination = p
+	return j
+}
+
+// WithUserAgent sets the user agent of the requests.
+func (j *JSONProvider) WithUserAgent(userAgent string) *JSONProvider {
+	j.UserAgent = userAgent
+	return j
+}
+
+// Fetch fetches the news published after the until date from the pages of the API.
+func (j *JSONProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	maxPages := j.Pagination.MaxPages
+	if maxPages <= 0 {
+		maxPages = jsonMaxPages
+	}
+	page := j.Pagination.StartPage
+	if page == 0 {
+		page = 1
+	}
+
+	var news NewsList
+	pageURL := withQueryParam(j.URL, j.Pagination.PageParam, strconv.Itoa(page))
+	for i := 0; i < maxPages && pageURL != ""; i++ {
+		body, err := j.fetchPage(ctx, pageURL)
+		if err != nil {
+			return nil, newError(errlvl.ERROR, err).WithProvider(j.Name)
+		}
+
+		items, ok := jsonPath(body, j.Items).([]interface{})
+		if !ok {
+			return nil, newError(errlvl.ERROR, fmt.Errorf("no array at path %q", j.Items)).WithProvider(j.Name)
+		}
+
+		fresh := 0
+		for _, item := range items {
+			n, err := j.newsItem(item)
+			if err != nil {
+				return nil, newError(errlvl.INFO, err).WithProvider(j.Name)
+			}
+			if n.Date.Before(until) {
+				continue
+			}
+			news = append(news, n)
+			fresh++
+		}
+		if fresh == 0 {
+			break
+		}
+
+		pageURL = j.nextPageURL(body, pageURL, page+i+1)
+	}
+
+	return news, nil
+}
+
+// nextPageURL returns the URL of the next page by the pagination or empty string if there is no next page.
+func (j *JSONProvider) nextPageURL(body interface{}, pageURL string, nextPage int) string {
+	p := j.Pagination
+	switch {
+	case p.PageParam != "":
+		return withQueryParam(pageURL, p.PageParam, strconv.Itoa(nextPage))
+	case p.CursorPath != "":
+		cursor := jsonString(jsonPath(body, p.CursorPath))
+		if cursor == "" {
+			return ""
+		}
+		param := p.CursorParam
+		if param == "" {
+			param = "cursor"
+		}
+		return withQueryParam(pageURL, param, cursor)
+	case p.NextPath != "":
+		next := jsonString(jsonPath(body, p.NextPath))
+		if next == "" {
+			return ""
+		}
+		return resolveURL(pageURL, next)
+	default:
+		return ""
+	}
+}
+
+// withQueryParam returns the URL with the query parameter set, the URL as is if the parameter is empty.
+func withQueryParam(rawURL, param, value string) string {
+	if param == "" {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set(param, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// fetchPage requests the page and decodes its JSON, numbers are decoded as json.Number.
+func (j *JSONProvider) fetchPage(ctx context.Context, pageURL string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if j.UserAgent != "" {
+		req.Header.Set("User-Agent", j.UserAgent)
+	}
+	for k, v := range j.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := j.client
+	if client == nil {
+		client = rssClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", j.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// The same error type as for the RSS feeds, so the status is tracked by the providers health
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var body interface{}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", j.Name, err)
+	}
+	return body, nil
+}
+
+// newsItem creates the news from the item of the response by the field paths.
+func (j *JSONProvider) newsItem(item interface{}) (*News, error) {
+	title := jsonString(jsonPath(item, j.Fields.Title))
+	if title == "" {
+		return nil, fmt.Errorf("no title at path %q", j.Fields.Title)
+	}
+
+	date, err := jsonDate(jsonPath(item, j.Fields.Date))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date at path %q: %w", j.Fields.Date, err)
+	}
+
+	link := jsonString(jsonPath(item, j.Fields.Link))
+	if link != "" {
+		link = resolveURL(j.URL, link)
+	}
+
+	n, err := newNews(title, jsonString(jsonPath(item, j.Fields.Description)), link, date.Format(time.RFC3339), j.Name)
+	if err != nil {
+		return nil, err
+	}
+	if j.Fields.Image != "" {
+		n.ImageURL = jsonString(jsonPath(item, j.Fields.Image))
+	}
+	return n, nil
+}
+
+// jsonPath returns the value at the path of the decoded JSON: the subset of JSONPath with the keys separated
+// by dots and the array indexes, e.g. "$.data.items[0].title". "[*]" at the end selects the whole array.
+// The value itself is returned for the empty path and nil if there is no such value.
+func jsonPath(v interface{}, path string) interface{} {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.TrimSuffix(path, "[*]")
+	if path == "" {
+		return v
+	}
+
+	for _, key := range strings.Split(strings.ReplaceAll(path, "[", ".["), ".") {
+		if key == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil
+			}
+			i, err := strconv.Atoi(key[1 : len(key)-1])
+			if err != nil || i < 0 || i >= len(arr) {
+				return nil
+			}
+			v = arr[i]
+			continue
+		}
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+// jsonString returns the string or number value as string, empty string for the other values.
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// errNoDate is returned if the item has no date, the news can't be checked against the until date then.
+var errNoDate = errors.New("no date")
+
+// jsonDate parses the date string or the Unix timestamp in seconds or milliseconds.
+func jsonDate(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case json.Number:
+		ts, err := v.Int64()
+		if err != nil {
+			f, err := v.Float64()
+			if err != nil {
+				return time.Time{}, err //nolint:wrapcheck
+			}
+			ts = int64(f)
+		}
+		if ts > 9999999999 {
+			return time.UnixMilli(ts).UTC(), nil
+		}
+		return time.Unix(ts, 0).UTC(), nil
+	case string:
+		for _, layout := range []string{time.RFC3339, time.RFC1123Z, time.RFC1123, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unknown date format %q", v)
+	default:
+		return time.Time{}, errNoDate
+	}
+}
+
+// resolveURL resolves the reference (e.g. "/news/1") against the base URL, the reference is returned as is on errors.
+func resolveURL(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
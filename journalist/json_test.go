@@ -0,0 +1,154 @@
+package journalist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_jsonPath(t *testing.T) {
+	var doc interface{}
+	d := json.NewDecoder(strings.NewReader(`{"data":{"items":[{"title":"Fed holds","tags":["fed","rates"]}],"total":1}}`))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{path: "$.data.items[0].title", want: "Fed holds"},
+		{path: "data.items[0].tags[1]", want: "rates"},
+		{path: "data.total", want: json.Number("1")},
+		{path: "$.data.items[*]", want: doc.(map[string]interface{})["data"].(map[string]interface{})["items"]},
+		{path: "data.items[5].title", want: nil},
+		{path: "data.missing.title", want: nil},
+		{path: "data.total.value", want: nil},
+		{path: "", want: doc},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := jsonPath(doc, tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jsonPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_jsonDate(t *testing.T) {
+	want := time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{name: "RFC3339", value: "2024-03-20T14:00:00-04:00"},
+		{name: "unix seconds", value: json.Number("1710957600")},
+		{name: "unix milliseconds", value: json.Number("1710957600000")},
+		{name: "datetime without zone", value: "2024-03-20 18:00:00"},
+		{name: "unknown format", value: "yesterday", wantErr: true},
+		{name: "no date", value: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("jsonDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(want) {
+				t.Errorf("jsonDate() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJSONProvider_Fetch(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	pages := map[string]string{
+		"": fmt.Sprintf(`{"data":[
+			{"headline":"Fed holds rates","summary":"Powell speaks","url":"/news/1","published":%d,"image":{"src":"https://img/1.png"}},
+			{"headline":"Oil rises","url":"https://example.com/news/2","published":%d}
+		],"meta":{"next":"c2"}}`, now.Unix(), now.Add(-time.Minute).UnixMilli()),
+		"c2": fmt.Sprintf(`{"data":[
+			{"headline":"Gold falls","url":"/news/3","published":"%s"},
+			{"headline":"Old news","url":"/news/4","published":"%s"}
+		],"meta":{"next":"c3"}}`, now.Add(-2*time.Minute).Format(time.RFC3339), now.Add(-48*time.Hour).Format(time.RFC3339)),
+		"c3": fmt.Sprintf(`{"data":[{"headline":"Older news","url":"/news/5","published":"%s"}],"meta":{"next":"c4"}}`,
+			now.Add(-72*time.Hour).Format(time.RFC3339)),
+	}
+
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		cursor := r.URL.Query().Get("after")
+		requested = append(requested, cursor)
+		_, _ = w.Write([]byte(pages[cursor]))
+	}))
+	defer srv.Close()
+
+	fields := JSONFields{Title: "headline", Description: "summary", Link: "url", Date: "published", Image: "image.src"}
+	p := NewJSONProvider("api", srv.URL+"/v1/news?lang=en", "$.data[*]", fields).
+		WithHeaders(map[string]string{"X-Api-Key": "secret"}).
+		WithPagination(JSONPagination{CursorPath: "meta.next", CursorParam: "after", MaxPages: 5})
+
+	got, err := p.Fetch(context.Background(), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	var titles []string
+	for _, n := range got {
+		titles = append(titles, n.Title)
+	}
+	if want := []string{"Fed holds rates", "Oil rises", "Gold falls"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Fetch() titles = %v, want %v", titles, want)
+	}
+	// The page without the fresh news is the last one
+	if want := []string{"", "c2", "c3"}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("Fetch() requested pages %q, want %q", requested, want)
+	}
+	first := got[0]
+	if first.Link != srv.URL+"/news/1" || first.Description != "Powell speaks" || first.ImageURL != "https://img/1.png" ||
+		!first.Date.Equal(now) || first.ProviderName != "api" {
+		t.Errorf("Fetch() first news = %+v", first)
+	}
+	if !got[1].Date.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Fetch() date of the milliseconds timestamp = %v, want %v", got[1].Date, now.Add(-time.Minute))
+	}
+
+	if _, err := NewJSONProvider("api", srv.URL, "data", fields).Fetch(context.Background(), time.Time{}); err == nil {
+		t.Error("Fetch() without the API key error = nil, want the HTTP error")
+	}
+}
+
+func TestJSONProvider_nextPageURL(t *testing.T) {
+	body := map[string]interface{}{"links": map[string]interface{}{"next": "/v1/news?page=2"}}
+	tests := []struct {
+		name       string
+		pagination JSONPagination
+		want       string
+	}{
+		{name: "page number", pagination: JSONPagination{PageParam: "p"}, want: "https://api.example.com/v1/news?lang=en&p=3"},
+		{name: "next url", pagination: JSONPagination{NextPath: "links.next"}, want: "https://api.example.com/v1/news?page=2"},
+		{name: "no cursor", pagination: JSONPagination{CursorPath: "meta.cursor"}, want: ""},
+		{name: "no pagination", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewJSONProvider("api", "https://api.example.com/v1/news?lang=en", "", JSONFields{}).WithPagination(tt.pagination)
+			if got := p.nextPageURL(body, "https://api.example.com/v1/news?lang=en", 3); got != tt.want {
+				t.Errorf("nextPageURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	linkMaxBytes     = 512 << 10 // Max size of the page head read to find the canonical link
+	linkConcurrency  = 4         // Number of the links resolved at the same time
+	linkMaxRedirects = 10
+)
+
+// DefaultArchiveURL is the prefix of the archive links of the paywalled articles, the article URL is appended.
+const DefaultArchiveURL = "https://archive.ph/newest/"
+
+// trackingParams are the query parameters of the links that only track the clicks.
+var trackingParams = []string{"fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "ocid", "cmpid", "guccounter", "_hsenc", "_hsmi"}
+
+// LinkResolver resolves the links of the news to the canonical article URLs: follows the redirects of the
+// tracking links (feedproxy, news.google.com, etc.) and the canonical link of the page, and strips the UTM
+// and other tracking parameters. Links to the paywalled domains are replaced with the archive links.
+type LinkResolver struct {
+	UserAgent  string   // User agent of the requests
+	Paywalled  []string // Domains of the paywalled sites, their subdomains are paywalled too (optional)
+	ArchiveURL string   // Prefix of the archive links, DefaultArchiveURL by default
+	client     *http.Client
+}
+
+// NewLinkResolver creates a new LinkResolver with the default user agent and 10s timeout per link.
+func NewLinkResolver() *LinkResolver {
+	return &LinkResolver{
+		UserAgent:  rssUserAgent,
+		ArchiveURL: DefaultArchiveURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+				if len(via) >= linkMaxRedirects {
+					return fmt.Errorf("stopped after %d redirects", linkMaxRedirects)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// WithPaywalled sets the domains of the paywalled sites, e.g. "wsj.com", their links are replaced with the archive links.
+func (r *LinkResolver) WithPaywalled(domains ...string) *LinkResolver {
+	r.Paywalled = domains
+	return r
+}
+
+// ResolveLinks replaces the Link of the news with the resolved one in place. News with the failed request
+// keep the link without the tracking parameters. Returns the errors of the failed news.
+func (r *LinkResolver) ResolveLinks(ctx context.Context, news NewsList) error {
+	var eg errgroup.Group
+	eg.SetLimit(linkConcurrency)
+
+	var mu sync.Mutex
+	var failed []error
+	for _, n := range news {
+		if n.Link == "" {
+			continue
+		}
+
+		eg.Go(func() error {
+			link, err := r.Resolve(ctx, n.Link)
+			n.Link = link
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, err)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	if len(failed) > 0 {
+		return newError(errlvl.WARN, append([]error{errResolveLinks}, failed...)...)
+	}
+	return nil
+}
+
+// Resolve returns the canonical URL of the link without the tracking parameters, or the archive link
+// if the article is paywalled. If the request fails, the link without the tracking parameters is returned
+// along with the error.
+func (r *LinkResolver) Resolve(ctx context.Context, link string) (string, error) {
+	resolved, err := r.follow(ctx, link)
+	if err != nil {
+		resolved = link
+		err = fmt.Errorf("%s: %w", link, err)
+	}
+
+	u, parseErr := url.Parse(resolved)
+	if parseErr != nil {
+		return link, fmt.Errorf("%s: %w", link, parseErr)
+	}
+	stripTracking(u)
+
+	if r.isPaywalled(u.Hostname()) {
+		return r.ArchiveURL + u.String(), err
+	}
+	return u.String(), err
+}
+
+// follow requests the link and returns the URL after the redirects or the canonical link of the page.
+func (r *LinkResolver) follow(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", r.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Paywalled and bot-protected pages answer with 4xx, the redirects are followed anyway
+	final := resp.Request.URL
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return final.String(), nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !strings.Contains(mediaType, "html") {
+		return final.String(), nil
+	}
+
+	if canonical := canonicalLink(io.LimitReader(resp.Body, linkMaxBytes), final); canonical != "" {
+		return canonical, nil
+	}
+	return final.String(), nil
+}
+
+// canonicalLink returns the absolute <link rel="canonical"> URL of the page or empty string if there is none.
+func canonicalLink(r io.Reader, base *url.URL) string {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return ""
+	}
+
+	var canonical string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Link &&
+			slices.Contains(strings.Fields(strings.ToLower(attr(n, "rel"))), "canonical") {
+			u, err := base.Parse(strings.TrimSpace(attr(n, "href")))
+			if err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+				canonical = u.String()
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil && canonical == ""; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return canonical
+}
+
+// stripTracking removes the UTM and other tracking parameters and the empty query of the URL.
+func stripTracking(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	q := u.Query()
+	for key := range q {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") || slices.Contains(trackingParams, strings.ToLower(key)) {
+			q.Del(key)
+		}
+	}
+	u.RawQuery = q.Encode()
+}
+
+// isPaywalled returns true if the host is one of the paywalled domains or their subdomains.
+func (r *LinkResolver) isPaywalled(host string) bool {
+	return matchesDomain(host, r.Paywalled)
+}
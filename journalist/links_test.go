@@ -0,0 +1,116 @@
+package journalist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLinkResolver_Resolve(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, srv.URL+"/article?id=1&utm_source=rss&utm_medium=feed", http.StatusFound)
+		case "/amp":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="/article?id=2&fbclid=abc"></head><body></body></html>`))
+		case "/paywall":
+			http.Redirect(w, r, srv.URL+"/blocked?utm_campaign=x", http.StatusMovedPermanently)
+		case "/blocked":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><p>Article</p></body></html>`))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		link      string
+		paywalled []string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "redirect without tracking parameters",
+			link: srv.URL + "/redirect",
+			want: srv.URL + "/article?id=1",
+		},
+		{
+			name: "canonical link of the page",
+			link: srv.URL + "/amp?utm_source=rss",
+			want: srv.URL + "/article?id=2",
+		},
+		{
+			name: "page without canonical link",
+			link: srv.URL + "/article?gclid=1",
+			want: srv.URL + "/article",
+		},
+		{
+			name:      "paywalled article",
+			link:      srv.URL + "/paywall",
+			paywalled: []string{"127.0.0.1"},
+			want:      DefaultArchiveURL + srv.URL + "/blocked",
+		},
+		{
+			name:    "failed request",
+			link:    "http://127.0.0.1:1/article?utm_source=rss&id=3",
+			want:    "http://127.0.0.1:1/article?id=3",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewLinkResolver().WithPaywalled(tt.paywalled...)
+			got, err := r.Resolve(context.Background(), tt.link)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkResolver_ResolveLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/article" {
+			http.Redirect(w, r, "/article", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	news := NewsList{
+		{ID: "1", Link: srv.URL + "/feedproxy/1"},
+		{ID: "2", Link: ""},
+		{ID: "3", Link: "http://127.0.0.1:1/down"},
+	}
+	err := NewLinkResolver().ResolveLinks(context.Background(), news)
+	if err == nil || !strings.Contains(err.Error(), "127.0.0.1:1/down") {
+		t.Errorf("ResolveLinks() error = %v, want the error of the failed link", err)
+	}
+	if news[0].Link != srv.URL+"/article" || news[1].Link != "" || news[2].Link != "http://127.0.0.1:1/down" {
+		t.Errorf("ResolveLinks() links = %q, %q, %q", news[0].Link, news[1].Link, news[2].Link)
+	}
+}
+
+func Test_isPaywalled(t *testing.T) {
+	r := NewLinkResolver().WithPaywalled("www.wsj.com", "FT.com")
+	tests := map[string]bool{
+		"wsj.com":        true,
+		"www.wsj.com":    true,
+		"markets.ft.com": true,
+		"notft.com":      false,
+		"reuters.com":    false,
+	}
+	for host, want := range tests {
+		if got := r.isPaywalled(host); got != want {
+			t.Errorf("isPaywalled(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
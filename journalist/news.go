@@ -9,8 +9,6 @@ import (
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"html"
-	"regexp"
-	"strings"
 	"time"
 )
 
@@ -23,6 +21,11 @@ type News struct {
 	ProviderName string    // ProviderName is the Name of the provider that fetched the news
 	IsSuspicious bool      // IsSuspicious is true if the news contains keywords that should be checked by human before publishing
 	IsFiltered   bool      // IsFiltered is true if the news was filtered out by others service (e.g. Composer.Filter)
+	ImageURL     string    // ImageURL is the URL of the main image of the news (optional)
+	ImageFigures string    // ImageFigures are the key figures extracted from the image (e.g. by Composer.ExtractImageFigures)
+	Score        int       // Score is the popularity of the news in the source, e.g. Reddit upvotes (0 if unknown)
+	Comments     int       // Comments is the number of comments in the source (0 if unknown)
+	Content      string    // Content is the main text of the article (optional, see ContentFetcher)
 	// TODO: Add creator field if possible
 }
 
@@ -66,37 +69,20 @@ func newNews(title, description, link, date, provider string) (*News, error) {
 	}, nil
 }
 
-func (n *News) contains(keywords []string) bool {
-	symbolsMatcherRe := regexp.MustCompile("^[^a-zA-Z0-9]*$")
-
-	for _, k := range keywords {
-		ke := strings.ToLower(regexp.QuoteMeta(k))
-
-		var pattern string
-		// Check that the keyword contains only symbols (for lagging by symbols feature)
-		if symbolsMatcherRe.MatchString(k) {
-			pattern = ke // Don't add word boundaries if the keyword contains only symbols
-		} else {
-			pattern = fmt.Sprintf("\\b%s\\b", ke)
-		}
-
-		s := strings.ToLower(fmt.Sprintf("%s %s", n.Title, n.Description))
-		match, _ := regexp.MatchString(pattern, s)
-		if match {
-			return true
-		}
-	}
-	return false
-}
-
 type NewsList []*News
 
-// ToContentJSON returns the JSON of the news content only: id, title, description.
+// contentPromptLength is the max length of the article text in ToContentJSON (runes), so the prompt stays small.
+const contentPromptLength = 3000
+
+// ToContentJSON returns the JSON of the news content only: id, title, description, image figures
+// and the truncated article text (if any).
 func (n NewsList) ToContentJSON() (string, error) {
 	type simpleNews struct {
 		ID          string `json:"id"`
 		Title       string `json:"title"`
 		Description string `json:"description"`
+		Figures     string `json:"figures,omitempty"`
+		Content     string `json:"content,omitempty"`
 	}
 
 	contentNews := make([]*simpleNews, 0, len(n))
@@ -105,6 +91,8 @@ func (n NewsList) ToContentJSON() (string, error) {
 			ID:          news.ID,
 			Title:       news.Title,
 			Description: news.Description,
+			Figures:     news.ImageFigures,
+			Content:     truncateRunes(news.Content, contentPromptLength),
 		})
 	}
 
@@ -127,27 +115,6 @@ func (n NewsList) RemoveFlagged() NewsList {
 	return news
 }
 
-// filterByKeywords returns only a list of news that contains at least one of the keywords.
-func (n NewsList) filterByKeywords(keywords []string) NewsList {
-	var filteredNews NewsList
-	for _, n := range n {
-		if n.contains(keywords) {
-			filteredNews = append(filteredNews, n)
-		}
-	}
-
-	return filteredNews
-}
-
-// flagByKeywords sets IsSuspicious to true if the news contains at least one of the keywords.
-func (n NewsList) flagByKeywords(keywords []string) {
-	for _, news := range n {
-		if news.contains(keywords) {
-			news.IsSuspicious = true
-		}
-	}
-}
-
 // mapIDs removes duplicates news by creating a map of ID hashes.
 // Since same news can be fetched from multiple feeds, we need to filter them out.
 func (n NewsList) mapIDs() NewsList {
@@ -2,6 +2,7 @@ package journalist
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -84,86 +85,6 @@ func TestNewNews(t *testing.T) {
 	}
 }
 
-func TestNewsList_FilterByKeywords(t *testing.T) {
-	type args struct {
-		keywords []string
-	}
-	tests := []struct {
-		name string
-		n    NewsList
-		args args
-		want NewsList
-	}{
-		{
-			name: "filter by one keyword",
-			n: NewsList{
-				{
-					ID:          "id1",
-					Title:       "Some news about Uganda",
-					Description: "Read more about Uganda",
-				},
-				{
-					ID:          "id2",
-					Title:       "Some news about United States",
-					Description: "Read more about United States",
-				},
-			},
-			args: args{
-				keywords: []string{"United States"},
-			},
-			want: NewsList{
-				{
-					ID:          "id2",
-					Title:       "Some news about United States",
-					Description: "Read more about United States",
-				},
-			},
-		},
-		{
-			name: "filter by multiple keywords",
-			n: NewsList{
-				{
-					ID:          "id1",
-					Title:       "Some news about Uganda",
-					Description: "Read more about Uganda",
-				},
-				{
-					ID:          "id2",
-					Title:       "Some news about United States",
-					Description: "Read more about United States",
-				},
-				{
-					ID:          "id3",
-					Title:       "Some news about United Kingdom",
-					Description: "Read more about United Kingdom",
-				},
-			},
-			args: args{
-				keywords: []string{"United States", "United Kingdom"},
-			},
-			want: NewsList{
-				{
-					ID:          "id2",
-					Title:       "Some news about United States",
-					Description: "Read more about United States",
-				},
-				{
-					ID:          "id3",
-					Title:       "Some news about United Kingdom",
-					Description: "Read more about United Kingdom",
-				},
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.n.filterByKeywords(tt.args.keywords); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("filterByKeywords() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestNewsList_MapIDs(t *testing.T) {
 	tests := []struct {
 		name string
@@ -212,136 +133,6 @@ func TestNewsList_MapIDs(t *testing.T) {
 	}
 }
 
-func TestNewsList_FlagByKeywords(t *testing.T) {
-	type args struct {
-		keywords []string
-	}
-	tests := []struct {
-		name           string
-		n              NewsList
-		args           args
-		wantFlaggedLen int
-	}{
-		{
-			name: "flag by one keyword",
-			n: NewsList{
-				{
-					ID:          "id1",
-					Title:       "Some news about United States",
-					Description: "Read more about United States",
-				},
-				{
-					ID:          "id2",
-					Title:       "Some news about kek",
-					Description: "Read more about kek",
-				},
-				{
-					ID:          "id3",
-					Title:       "Some news about keking",
-					Description: "Read more about keking",
-				},
-			},
-			args: args{
-				keywords: []string{"kek"},
-			},
-			wantFlaggedLen: 1,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.n.flagByKeywords(tt.args.keywords)
-			flaggedLen := 0
-			for _, n := range tt.n {
-				if n.IsSuspicious {
-					flaggedLen++
-				}
-			}
-			if flaggedLen != tt.wantFlaggedLen {
-				t.Errorf("flagByKeywords() = %v, want %v", flaggedLen, tt.wantFlaggedLen)
-			}
-		})
-	}
-}
-
-func TestNews_contains(t *testing.T) {
-	type args struct {
-		keywords []string
-	}
-	tests := []struct {
-		name   string
-		fields News
-		args   args
-		want   bool
-	}{
-		{
-			name: "contains one keyword",
-			fields: News{
-				Title:       "Some news about United States",
-				Description: "Read more about United States",
-			},
-			args: args{
-				keywords: []string{"united States"},
-			},
-			want: true,
-		},
-		{
-			name: "contains none",
-			fields: News{
-				Title:       "Some news about United States",
-				Description: "Read more about United States",
-			},
-			args: args{
-				keywords: []string{"kek", "?"},
-			},
-			want: false,
-		},
-		{
-			name: "contains none full words",
-			fields: News{
-				Title:       "Some news about United States",
-				Description: "Read more about United States",
-			},
-			args: args{
-				keywords: []string{"ted"},
-			},
-			want: false,
-		},
-		{
-			name: "contains pronoun",
-			fields: News{
-				Title:       "'I'm not a cat': Lawyer struggles with Zoom kitten filter during court case",
-				Description: "A lawyer in Texas has gone viral after accidentally appearing in court as a cat.",
-			},
-			args: args{
-				keywords: []string{"i'm"},
-			},
-			want: true,
-		},
-		{
-			name: "contains symbol",
-			fields: News{
-				Title:       "Some news about United States or not?",
-				Description: "Read more about United States",
-			},
-			args: args{
-				keywords: []string{"?"},
-			},
-			want: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			n := &News{
-				Title:       tt.fields.Title,
-				Description: tt.fields.Description,
-			}
-			if got := n.contains(tt.args.keywords); got != tt.want {
-				t.Errorf("contains() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestNewsList_ToContentJSON(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -367,6 +158,19 @@ func TestNewsList_ToContentJSON(t *testing.T) {
 			want:    `[{"id":"id1","title":"Some news about United States","description":"Read more about United States"},{"id":"id2","title":"Some news about kek","description":"Read more about kek"}]`,
 			wantErr: false,
 		},
+		{
+			name: "truncated content",
+			n: NewsList{
+				{
+					ID:          "id1",
+					Title:       "Apple beats estimates",
+					Description: "Read more",
+					Content:     strings.Repeat("a", contentPromptLength+10),
+				},
+			},
+			want:    `[{"id":"id1","title":"Apple beats estimates","description":"Read more","content":"` + strings.Repeat("a", contentPromptLength) + `"}]`,
+			wantErr: false,
+		},
 		{
 			name:    "empty news list",
 			n:       NewsList{},
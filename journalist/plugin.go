@@ -0,0 +1,108 @@
+package journalist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PluginProvider is the NewsProvider implementation that runs an external process to fetch the news.
+// It allows to drop in custom providers at deploy time without recompiling the main binary.
+//
+// The protocol is a single JSON object per run over stdio. The plugin receives the request on stdin:
+//
+//	{"name": "provider name", "until": "2024-01-02T15:04:05Z"}
+//
+// and must write the response to stdout:
+//
+//	{"news": [{"title": "", "description": "", "link": "", "date": "", "image": ""}], "error": ""}
+//
+// Date can be in any format supported by utils.ParseDate. Stderr of the plugin is attached to the error.
+type PluginProvider struct {
+	Name    string   // Name is used for logging purposes
+	Command string   // Command is the path to the plugin executable
+	Args    []string // Args are passed to the plugin executable
+}
+
+// NewPluginProvider creates a new PluginProvider instance.
+func NewPluginProvider(name, command string, args ...string) *PluginProvider {
+	return &PluginProvider{
+		Name:    name,
+		Command: command,
+		Args:    args,
+	}
+}
+
+type pluginRequest struct {
+	Name  string    `json:"name"`
+	Until time.Time `json:"until"`
+}
+
+type pluginResponse struct {
+	News []struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Link        string `json:"link"`
+		Date        string `json:"date"`
+		Image       string `json:"image"`
+	} `json:"news"`
+	Error string `json:"error"`
+}
+
+// Fetch runs the plugin and returns the news until the given date.
+// The plugin process is killed if the context is done.
+func (p *PluginProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	req, err := json.Marshal(pluginRequest{Name: p.Name, Until: until.UTC()})
+	if err != nil {
+		return nil, newError(errlvl.ERROR, errPluginExec, err).WithProvider(p.Name)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			err = fmt.Errorf("%w: %s", err, s)
+		}
+		return nil, newError(errlvl.ERROR, errPluginExec, err).WithProvider(p.Name)
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, newError(errlvl.ERROR, errPluginResponse, err).WithProvider(p.Name)
+	}
+	if resp.Error != "" {
+		return nil, newError(errlvl.ERROR, errPluginFailed, errors.New(resp.Error)).WithProvider(p.Name)
+	}
+
+	news := make(NewsList, 0, len(resp.News))
+	for _, item := range resp.News {
+		// Skip news with empty required fields. Note: description can be empty.
+		if item.Title == "" || item.Link == "" || item.Date == "" {
+			continue
+		}
+
+		newsItem, err := newNews(item.Title, item.Description, item.Link, item.Date, p.Name)
+		if err != nil {
+			return nil, newError(errlvl.INFO, err).WithProvider(p.Name)
+		}
+		newsItem.ImageURL = item.Image
+
+		// Plugins are not required to sort the news, so filter them one by one
+		if newsItem.Date.Before(until) {
+			continue
+		}
+		news = append(news, newsItem)
+	}
+
+	return news, nil
+}
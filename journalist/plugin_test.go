@@ -0,0 +1,76 @@
+package journalist
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPluginProvider_Fetch(t *testing.T) {
+	until := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	response := `{"news": [
+		{"title": "Fresh news", "description": "<b>Body</b>", "link": "https://example.com/1", "date": "2024-01-03T10:00:00Z"},
+		{"title": "Old news", "description": "", "link": "https://example.com/2", "date": "2024-01-01T10:00:00Z"},
+		{"title": "", "description": "No title", "link": "https://example.com/3", "date": "2024-01-03T10:00:00Z"}
+	]}`
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantTitle []string
+		wantErr   bool
+	}{
+		{
+			name:      "valid response",
+			args:      []string{"-c", "cat > /dev/null; echo '" + response + "'"},
+			wantTitle: []string{"Fresh news"},
+		},
+		{
+			name:      "request is passed to stdin",
+			args:      []string{"-c", `grep -q '"until":"2024-01-02T00:00:00Z"' && echo '{"news": []}'`},
+			wantTitle: []string{},
+		},
+		{
+			name:    "plugin error",
+			args:    []string{"-c", `echo '{"error": "rate limited"}'`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid response",
+			args:    []string{"-c", "echo not json"},
+			wantErr: true,
+		},
+		{
+			name:    "non-zero exit code",
+			args:    []string{"-c", "echo failure >&2; exit 1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPluginProvider("test", "sh", tt.args...)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			got, err := p.Fetch(ctx, until)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PluginProvider.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.wantTitle) {
+				t.Fatalf("PluginProvider.Fetch() got %d news, want %d", len(got), len(tt.wantTitle))
+			}
+			for i, n := range got {
+				if n.Title != tt.wantTitle[i] {
+					t.Errorf("PluginProvider.Fetch() title = %q, want %q", n.Title, tt.wantTitle[i])
+				}
+				if n.ProviderName != "test" {
+					t.Errorf("PluginProvider.Fetch() provider = %q, want %q", n.ProviderName, "test")
+				}
+			}
+		})
+	}
+}
@@ -4,34 +4,75 @@ import (
 	"context"
 	"errors"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mmcdole/gofeed"
 )
 
+// rssUserAgent is the default user agent of the RSS requests, so the feed owners know who polls them.
+const rssUserAgent = "fin-thread/1.0 (+https://github.com/samgozman/fin-thread)"
+
+// rssClient is the HTTP client of the RssProvider created without the constructor.
+var rssClient = &http.Client{Timeout: 15 * time.Second}
+
 // NewsProvider is the interface for the data fetcher (via RSS, API, etc.).
 type NewsProvider interface {
 	Fetch(ctx context.Context, until time.Time) (NewsList, error)
 }
 
-// RssProvider is the RSS provider implementation.
+// RssProvider is the RSS provider implementation. The feed is fetched with the conditional GET
+// (ETag / Last-Modified of the previous response), so unchanged feeds are not downloaded again.
 type RssProvider struct {
-	Name string // Name is used for logging purposes
-	URL  string
+	Name        string        // Name is used for logging purposes
+	URL         string        // URL of the feed
+	UserAgent   string        // User agent of the requests (optional)
+	MinInterval time.Duration // Fetches more often than this are skipped, 0 - no limit
+	client      *http.Client
+
+	mu           sync.Mutex
+	lastFetch    time.Time
+	etag         string
+	lastModified string
 }
 
 // NewRssProvider creates a new RssProvider instance.
 func NewRssProvider(name, url string) *RssProvider {
 	return &RssProvider{
-		Name: name,
-		URL:  url,
+		Name:      name,
+		URL:       url,
+		UserAgent: rssUserAgent,
+		client:    &http.Client{Timeout: 15 * time.Second},
 	}
 }
 
+// WithUserAgent sets the user agent of the requests. Some feeds ban the default user agents.
+func (r *RssProvider) WithUserAgent(userAgent string) *RssProvider {
+	r.UserAgent = userAgent
+	return r
+}
+
+// WithMinInterval sets the minimum interval between the feed requests. Fetches made earlier return no news.
+func (r *RssProvider) WithMinInterval(d time.Duration) *RssProvider {
+	r.MinInterval = d
+	return r
+}
+
 // Fetch fetches the news from the RSS feed until the given date.
+// Returns no news if the feed is not modified since the previous fetch or the MinInterval has not passed yet.
 func (r *RssProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
-	fp := gofeed.NewParser()
-	feed, err := fp.ParseURLWithContext(r.URL, ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.MinInterval > 0 && !r.lastFetch.IsZero() && now.Sub(r.lastFetch) < r.MinInterval {
+		return nil, nil
+	}
+	r.lastFetch = now
+
+	feed, err := r.fetchFeed(ctx)
 	if err != nil {
 		if errors.Is(err, gofeed.ErrFeedTypeNotDetected) {
 			return nil, newError(errlvl.INFO, err).WithProvider(r.Name)
@@ -40,6 +81,10 @@ func (r *RssProvider) Fetch(ctx context.Context, until time.Time) (NewsList, err
 		return nil, newError(errlvl.ERROR, err).WithProvider(r.Name)
 	}
 
+	if feed == nil {
+		return nil, nil
+	}
+
 	var news NewsList
 	for _, item := range feed.Items {
 		// Skip news with empty required fields. Note: description can be empty.
@@ -51,6 +96,7 @@ func (r *RssProvider) Fetch(ctx context.Context, until time.Time) (NewsList, err
 		if err != nil {
 			return nil, newError(errlvl.INFO, err).WithProvider(r.Name)
 		}
+		newsItem.ImageURL = rssImageURL(item)
 		news = append(news, newsItem)
 	}
 
@@ -64,3 +110,73 @@ func (r *RssProvider) Fetch(ctx context.Context, until time.Time) (NewsList, err
 
 	return news, nil
 }
+
+// fetchFeed requests the feed with the cache validators of the previous response.
+// Returns nil feed if the feed is not modified.
+func (r *RssProvider) fetchFeed(ctx context.Context) (*gofeed.Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	userAgent := r.UserAgent
+	if userAgent == "" {
+		userAgent = rssUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.lastModified != "" {
+		req.Header.Set("If-Modified-Since", r.lastModified)
+	}
+
+	client := r.client
+	if client == nil {
+		client = rssClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	feed, err := gofeed.NewParser().Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Validators are saved only for the parsed feeds, so the broken response is requested again
+	r.etag = resp.Header.Get("ETag")
+	r.lastModified = resp.Header.Get("Last-Modified")
+
+	return feed, nil
+}
+
+// rssImageURL returns the URL of the main image of the RSS item: from the image tag,
+// image enclosure or media:content (in this order). Returns empty string if there is no image.
+func rssImageURL(item *gofeed.Item) string {
+	if item.Image != nil && item.Image.URL != "" {
+		return item.Image.URL
+	}
+
+	for _, e := range item.Enclosures {
+		if strings.HasPrefix(e.Type, "image/") && e.URL != "" {
+			return e.URL
+		}
+	}
+
+	for _, m := range item.Extensions["media"]["content"] {
+		medium, typ := m.Attrs["medium"], m.Attrs["type"]
+		if (medium == "image" || strings.HasPrefix(typ, "image/")) && m.Attrs["url"] != "" {
+			return m.Attrs["url"]
+		}
+	}
+
+	return ""
+}
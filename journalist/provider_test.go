@@ -2,6 +2,11 @@ package journalist
 
 import (
 	"context"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -75,3 +80,121 @@ func TestRssProvider_Fetch(t *testing.T) {
 		})
 	}
 }
+
+func TestRssProvider_Fetch_conditional(t *testing.T) {
+	published := time.Now().UTC().Add(-time.Minute).Format(time.RFC1123Z)
+	feed := fmt.Sprintf(`<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>`+
+		`<item><title>News</title><link>https://example.com/news</link><description>Text</description>`+
+		`<pubDate>%s</pubDate></item></channel></rss>`, published)
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("User-Agent") != "test-agent" {
+			t.Errorf("unexpected User-Agent %q", r.Header.Get("User-Agent"))
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer srv.Close()
+
+	until := time.Now().Add(-time.Hour)
+
+	t.Run("not modified", func(t *testing.T) {
+		requests = 0
+		r := NewRssProvider("test", srv.URL).WithUserAgent("test-agent")
+
+		news, err := r.Fetch(context.Background(), until)
+		if err != nil || len(news) != 1 {
+			t.Fatalf("Fetch() = %d news, error %v, want 1 news", len(news), err)
+		}
+		news, err = r.Fetch(context.Background(), until)
+		if err != nil || len(news) != 0 {
+			t.Fatalf("Fetch() of not modified feed = %d news, error %v, want no news", len(news), err)
+		}
+		if requests != 2 {
+			t.Errorf("Fetch() made %d requests, want 2", requests)
+		}
+	})
+
+	t.Run("min interval", func(t *testing.T) {
+		requests = 0
+		r := NewRssProvider("test", srv.URL).WithUserAgent("test-agent").WithMinInterval(time.Hour)
+
+		if _, err := r.Fetch(context.Background(), until); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		news, err := r.Fetch(context.Background(), until)
+		if err != nil || len(news) != 0 {
+			t.Fatalf("Fetch() within interval = %d news, error %v, want no news", len(news), err)
+		}
+		if requests != 1 {
+			t.Errorf("Fetch() made %d requests, want 1", requests)
+		}
+	})
+}
+
+func TestRssProvider_Fetch_jsonFeed(t *testing.T) {
+	published := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	feed := fmt.Sprintf(`{"version":"https://jsonfeed.org/version/1.1","title":"Test","items":[`+
+		`{"id":"1","title":"News","url":"https://example.com/news","summary":"Text","date_published":"%s"}]}`, published)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/feed+json")
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer srv.Close()
+
+	news, err := NewRssProvider("test", srv.URL).Fetch(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil || len(news) != 1 {
+		t.Fatalf("Fetch() = %d news, error %v, want 1 news", len(news), err)
+	}
+	if news[0].Title != "News" || news[0].Link != "https://example.com/news" || news[0].Description != "Text" {
+		t.Errorf("Fetch() news = %+v", news[0])
+	}
+}
+
+func Test_rssImageURL(t *testing.T) {
+	tests := []struct {
+		name string
+		item *gofeed.Item
+		want string
+	}{
+		{
+			name: "image tag",
+			item: &gofeed.Item{Image: &gofeed.Image{URL: "https://example.com/image.png"}},
+			want: "https://example.com/image.png",
+		},
+		{
+			name: "image enclosure",
+			item: &gofeed.Item{Enclosures: []*gofeed.Enclosure{
+				{URL: "https://example.com/audio.mp3", Type: "audio/mpeg"},
+				{URL: "https://example.com/chart.jpg", Type: "image/jpeg"},
+			}},
+			want: "https://example.com/chart.jpg",
+		},
+		{
+			name: "media content",
+			item: &gofeed.Item{Extensions: ext.Extensions{"media": {"content": []ext.Extension{
+				{Attrs: map[string]string{"url": "https://example.com/table.png", "medium": "image"}},
+			}}}},
+			want: "https://example.com/table.png",
+		},
+		{
+			name: "no image",
+			item: &gofeed.Item{},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rssImageURL(tt.item); got != tt.want {
+				t.Errorf("rssImageURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
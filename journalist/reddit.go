@@ -0,0 +1,130 @@
+package journalist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	redditBaseURL   = "https://www.reddit.com"
+	redditUserAgent = "fin-thread/1.0 (+https://github.com/samgozman/fin-thread)" // Reddit throttles default user agents
+)
+
+// RedditProvider is the NewsProvider implementation that fetches the hot posts from the subreddits
+// (e.g. r/stocks, r/wallstreetbets) via the Reddit JSON API. Only trending posts with the score
+// not lower than MinScore are emitted.
+type RedditProvider struct {
+	Name       string   // Name is used for logging purposes
+	Subreddits []string // Subreddits without the "r/" prefix, e.g. "stocks"
+	Sort       string   // Listing to fetch: hot, top, rising or new
+	MinScore   int      // Posts with the lower score (upvotes - downvotes) are skipped
+	Limit      int      // Number of posts to fetch from each subreddit (max 100)
+	BaseURL    string
+	client     *http.Client
+}
+
+// NewRedditProvider creates a new RedditProvider instance for the hot posts with the score of at least 100.
+func NewRedditProvider(name string, subreddits []string) *RedditProvider {
+	return &RedditProvider{
+		Name:       name,
+		Subreddits: subreddits,
+		Sort:       "hot",
+		MinScore:   100,
+		Limit:      25,
+		BaseURL:    redditBaseURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithMinScore sets the min score of the posts.
+func (r *RedditProvider) WithMinScore(score int) *RedditProvider {
+	r.MinScore = score
+	return r
+}
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Title       string  `json:"title"`
+	SelfText    string  `json:"selftext"`
+	Permalink   string  `json:"permalink"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	CreatedUTC  float64 `json:"created_utc"`
+	Stickied    bool    `json:"stickied"`
+	Over18      bool    `json:"over_18"`
+}
+
+// Fetch fetches the posts from all subreddits created after the given date.
+func (r *RedditProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	var news NewsList
+	for _, sub := range r.Subreddits {
+		posts, err := r.fetchSubreddit(ctx, sub)
+		if err != nil {
+			return nil, newError(errlvl.ERROR, err).WithProvider(r.Name)
+		}
+
+		for _, p := range posts {
+			created := time.Unix(int64(p.CreatedUTC), 0).UTC()
+			// Pinned posts are the rules and weekly threads, not the news
+			if p.Stickied || p.Over18 || p.Score < r.MinScore || created.Before(until) {
+				continue
+			}
+
+			newsItem, err := newNews(p.Title, p.SelfText, r.BaseURL+p.Permalink, created.Format(time.RFC3339), r.Name)
+			if err != nil {
+				return nil, newError(errlvl.INFO, err).WithProvider(r.Name)
+			}
+			newsItem.Score = p.Score
+			newsItem.Comments = p.NumComments
+			news = append(news, newsItem)
+		}
+	}
+
+	return news, nil
+}
+
+// fetchSubreddit fetches the listing of the subreddit.
+func (r *RedditProvider) fetchSubreddit(ctx context.Context, sub string) ([]redditPost, error) {
+	u := fmt.Sprintf("%s/r/%s/%s.json?limit=%d&raw_json=1", r.BaseURL, url.PathEscape(sub), r.Sort, r.Limit)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", redditUserAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch r/%s: %w", sub, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// The same error type as for the RSS feeds, so the status is tracked by the providers health
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var listing redditListing
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to decode r/%s: %w", sub, err)
+	}
+
+	posts := make([]redditPost, 0, len(listing.Data.Children))
+	for _, c := range listing.Data.Children {
+		posts = append(posts, c.Data)
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,88 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRedditProvider_Fetch(t *testing.T) {
+	now := time.Now().Unix()
+	listing := fmt.Sprintf(`{"data":{"children":[
+		{"data":{"title":"Daily Discussion Thread","selftext":"Rules","permalink":"/r/stocks/comments/1/daily/","score":500,"num_comments":900,"created_utc":%d,"stickied":true}},
+		{"data":{"title":"NVDA beats earnings &amp; raises guidance","selftext":"Revenue up 200%%","permalink":"/r/stocks/comments/2/nvda/","score":1500,"num_comments":300,"created_utc":%d}},
+		{"data":{"title":"What should I buy?","selftext":"","permalink":"/r/stocks/comments/3/buy/","score":12,"num_comments":40,"created_utc":%d}},
+		{"data":{"title":"Old news","selftext":"","permalink":"/r/stocks/comments/4/old/","score":3000,"num_comments":10,"created_utc":%d}}
+	]}}`, now, now, now, now-7200)
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("User-Agent") != redditUserAgent {
+			t.Errorf("unexpected User-Agent %q", r.Header.Get("User-Agent"))
+		}
+		if r.URL.Path == "/r/private/hot.json" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(listing))
+	}))
+	defer srv.Close()
+
+	t.Run("trending posts", func(t *testing.T) {
+		paths = nil
+		r := NewRedditProvider("reddit", []string{"stocks"})
+		r.BaseURL = srv.URL
+
+		news, err := r.Fetch(context.Background(), time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if len(paths) != 1 || paths[0] != "/r/stocks/hot.json" {
+			t.Errorf("Fetch() requested %v", paths)
+		}
+		if len(news) != 1 {
+			t.Fatalf("Fetch() returned %d news, want 1", len(news))
+		}
+
+		n := news[0]
+		if n.Title != "NVDA beats earnings & raises guidance" || n.Description != "Revenue up 200%" {
+			t.Errorf("Fetch() title = %q, description = %q", n.Title, n.Description)
+		}
+		if n.Link != srv.URL+"/r/stocks/comments/2/nvda/" || n.ProviderName != "reddit" {
+			t.Errorf("Fetch() link = %q, provider = %q", n.Link, n.ProviderName)
+		}
+		if n.Score != 1500 || n.Comments != 300 {
+			t.Errorf("Fetch() score = %d, comments = %d", n.Score, n.Comments)
+		}
+	})
+
+	t.Run("min score", func(t *testing.T) {
+		r := NewRedditProvider("reddit", []string{"stocks"}).WithMinScore(10)
+		r.BaseURL = srv.URL
+
+		news, err := r.Fetch(context.Background(), time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if len(news) != 2 {
+			t.Errorf("Fetch() returned %d news, want 2", len(news))
+		}
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		r := NewRedditProvider("reddit", []string{"private"})
+		r.BaseURL = srv.URL
+
+		_, err := r.Fetch(context.Background(), time.Now().Add(-time.Hour))
+		if err == nil {
+			t.Fatal("Fetch() expected error")
+		}
+		if code := statusCode(err); code != http.StatusForbidden {
+			t.Errorf("statusCode() = %d, want %d", code, http.StatusForbidden)
+		}
+	})
+}
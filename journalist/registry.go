@@ -0,0 +1,200 @@
+package journalist
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Built-in provider types, see RegisterProvider.
+const (
+	ProviderRSS     = "rss"
+	ProviderPlugin  = "plugin"
+	ProviderEdgar   = "edgar"
+	ProviderX       = "x"
+	ProviderReddit  = "reddit"
+	ProviderWebhook = "webhook"
+	ProviderJSON    = "json"
+	ProviderHTML    = "html"
+)
+
+// ProviderConfig is the declarative configuration of the news provider (e.g. from the jobs config file).
+// Built-in providers use the typed fields, third-party ones can read their settings from Options.
+type ProviderConfig struct {
+	Type        string                 // registered provider type, e.g. "rss"
+	Name        string                 // name of the provider, used for logging and stats
+	URL         string                 // URL of the feed or API (rss and most of the third-party providers)
+	UserAgent   string                 // user agent of the requests (optional)
+	MinInterval time.Duration          // fetches more often than this are skipped (rss)
+	Command     string                 // executable of the plugin (plugin)
+	Args        []string               // arguments of the plugin (plugin)
+	Forms       []string               // SEC form types, e.g. "8-K" (edgar)
+	Watchlist   []string               // CIKs or tickers to fetch the filings of (edgar, optional)
+	Accounts    []string               // X accounts, e.g. "DeItaone" (x)
+	BearerToken string                 // X API v2 bearer token, the Mirror is used if empty (x)
+	Mirror      string                 // nitter-style mirror with the RSS feeds of the accounts (x)
+	Subreddits  []string               // subreddits to fetch the hot posts from (reddit)
+	MinScore    int                    // min score of the posts, 0 for the default (reddit)
+	Token       string                 // secret of the pushed news requests (webhook)
+	Items       string                 // path of the news array in the response, the response itself if empty (json)
+	Fields      JSONFields             // paths of the news fields in the item (json)
+	Headers     map[string]string      // headers of the requests, e.g. the API key (json, optional)
+	Pagination  JSONPagination         // pagination of the API (json, optional)
+	Selectors   HTMLSelectors          // CSS selectors of the news on the page (html)
+	Options     map[string]interface{} // settings of the third-party providers
+}
+
+// ProviderFactory creates the news provider from its configuration.
+type ProviderFactory func(cfg ProviderConfig) (NewsProvider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]ProviderFactory)
+)
+
+// RegisterProvider makes the provider type available by its name in the config, so the third-party providers
+// can be added without changing the Journalist construction code (e.g. in the init function of their package).
+// Panics if the factory is nil or the type is already registered.
+func RegisterProvider(providerType string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if factory == nil {
+		panic("journalist: RegisterProvider factory is nil")
+	}
+	if _, dup := providers[providerType]; dup {
+		panic("journalist: RegisterProvider called twice for provider " + providerType)
+	}
+	providers[providerType] = factory
+}
+
+// IsProviderRegistered returns true if the provider type is registered.
+func IsProviderRegistered(providerType string) bool {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	_, ok := providers[providerType]
+	return ok
+}
+
+// RegisteredProviders returns the sorted names of the registered provider types.
+func RegisteredProviders() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider creates the news provider of the registered type from its configuration.
+func NewProvider(cfg ProviderConfig) (NewsProvider, error) {
+	providersMu.RLock()
+	factory, ok := providers[cfg.Type]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("provider %s: unknown type %q", cfg.Name, cfg.Type)
+	}
+
+	p, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", cfg.Name, err)
+	}
+	return p, nil
+}
+
+func init() {
+	RegisterProvider(ProviderRSS, func(cfg ProviderConfig) (NewsProvider, error) {
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		rss := NewRssProvider(cfg.Name, cfg.URL).WithMinInterval(cfg.MinInterval)
+		if cfg.UserAgent != "" {
+			rss.WithUserAgent(cfg.UserAgent)
+		}
+		return rss, nil
+	})
+	RegisterProvider(ProviderPlugin, func(cfg ProviderConfig) (NewsProvider, error) {
+		if cfg.Command == "" {
+			return nil, errors.New("command is required")
+		}
+		return NewPluginProvider(cfg.Name, cfg.Command, cfg.Args...), nil
+	})
+	RegisterProvider(ProviderEdgar, func(cfg ProviderConfig) (NewsProvider, error) {
+		if len(cfg.Forms) == 0 {
+			return nil, errors.New("forms are required")
+		}
+		return NewEdgarProvider(cfg.Name, cfg.Forms, cfg.Watchlist, cfg.UserAgent), nil
+	})
+	RegisterProvider(ProviderX, func(cfg ProviderConfig) (NewsProvider, error) {
+		if len(cfg.Accounts) == 0 {
+			return nil, errors.New("accounts are required")
+		}
+		x := NewXProvider(cfg.Name, cfg.Accounts)
+		if cfg.BearerToken != "" {
+			x.WithBearerToken(cfg.BearerToken)
+		} else {
+			x.WithMirror(cfg.Mirror)
+		}
+		return x, nil
+	})
+	RegisterProvider(ProviderReddit, func(cfg ProviderConfig) (NewsProvider, error) {
+		if len(cfg.Subreddits) == 0 {
+			return nil, errors.New("subreddits are required")
+		}
+		reddit := NewRedditProvider(cfg.Name, cfg.Subreddits)
+		if cfg.MinScore > 0 {
+			reddit.WithMinScore(cfg.MinScore)
+		}
+		return reddit, nil
+	})
+	RegisterProvider(ProviderJSON, func(cfg ProviderConfig) (NewsProvider, error) {
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		if cfg.Fields.Title == "" || cfg.Fields.Date == "" {
+			return nil, errors.New("title and date fields are required")
+		}
+		j := NewJSONProvider(cfg.Name, cfg.URL, cfg.Items, cfg.Fields).
+			WithHeaders(cfg.Headers).
+			WithPagination(cfg.Pagination)
+		if cfg.UserAgent != "" {
+			j.WithUserAgent(cfg.UserAgent)
+		}
+		return j, nil
+	})
+	RegisterProvider(ProviderHTML, func(cfg ProviderConfig) (NewsProvider, error) {
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		if cfg.Selectors.Item == "" || cfg.Selectors.Title == "" {
+			return nil, errors.New("item and title selectors are required")
+		}
+		h, err := NewHTMLProvider(cfg.Name, cfg.URL, cfg.Selectors)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.UserAgent != "" {
+			h.WithUserAgent(cfg.UserAgent)
+		}
+		if cfg.MinInterval > 0 {
+			h.WithMinInterval(cfg.MinInterval)
+		}
+		return h, nil
+	})
+	RegisterProvider(ProviderWebhook, func(cfg ProviderConfig) (NewsProvider, error) {
+		if cfg.Token == "" {
+			return nil, errors.New("token is required")
+		}
+		webhook := NewWebhookProvider(cfg.Name, cfg.Token)
+		if err := registerWebhook(webhook); err != nil {
+			return nil, err
+		}
+		return webhook, nil
+	})
+}
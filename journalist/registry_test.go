@@ -0,0 +1,117 @@
+package journalist
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	url string
+}
+
+func (f *fakeProvider) Fetch(_ context.Context, _ time.Time) (NewsList, error) {
+	return nil, nil
+}
+
+func TestNewProvider(t *testing.T) {
+	RegisterProvider("test-fake", func(cfg ProviderConfig) (NewsProvider, error) {
+		return &fakeProvider{url: cfg.URL + "?key=" + cfg.Options["key"].(string)}, nil
+	})
+
+	tests := []struct {
+		name    string
+		cfg     ProviderConfig
+		want    NewsProvider
+		wantErr bool
+	}{
+		{
+			name: "rss",
+			cfg:  ProviderConfig{Type: ProviderRSS, Name: "reuters", URL: "https://example.com/rss", MinInterval: time.Minute},
+			want: NewRssProvider("reuters", "https://example.com/rss").WithMinInterval(time.Minute),
+		},
+		{
+			name:    "rss without url",
+			cfg:     ProviderConfig{Type: ProviderRSS, Name: "reuters"},
+			wantErr: true,
+		},
+		{
+			name: "reddit",
+			cfg:  ProviderConfig{Type: ProviderReddit, Name: "reddit", Subreddits: []string{"stocks"}, MinScore: 500},
+			want: NewRedditProvider("reddit", []string{"stocks"}).WithMinScore(500),
+		},
+		{
+			name: "json",
+			cfg: ProviderConfig{
+				Type:    ProviderJSON,
+				Name:    "api",
+				URL:     "https://api.example.com/news",
+				Items:   "data",
+				Fields:  JSONFields{Title: "headline", Date: "published"},
+				Headers: map[string]string{"X-Api-Key": "secret"},
+			},
+			want: NewJSONProvider("api", "https://api.example.com/news", "data", JSONFields{Title: "headline", Date: "published"}).
+				WithHeaders(map[string]string{"X-Api-Key": "secret"}),
+		},
+		{
+			name:    "json without date field",
+			cfg:     ProviderConfig{Type: ProviderJSON, Name: "api", URL: "https://api.example.com/news", Fields: JSONFields{Title: "headline"}},
+			wantErr: true,
+		},
+		{
+			name:    "html without title selector",
+			cfg:     ProviderConfig{Type: ProviderHTML, Name: "notices", URL: "https://example.com/notices", Selectors: HTMLSelectors{Item: "li"}},
+			wantErr: true,
+		},
+		{
+			name: "html with invalid selector",
+			cfg: ProviderConfig{
+				Type:      ProviderHTML,
+				Name:      "notices",
+				URL:       "https://example.com/notices",
+				Selectors: HTMLSelectors{Item: "li", Title: "a", Date: "[unclosed"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "webhook without token",
+			cfg:     ProviderConfig{Type: ProviderWebhook, Name: "zapier"},
+			wantErr: true,
+		},
+		{
+			name: "third-party provider",
+			cfg:  ProviderConfig{Type: "test-fake", Name: "fake", URL: "https://example.com", Options: map[string]interface{}{"key": "secret"}},
+			want: &fakeProvider{url: "https://example.com?key=secret"},
+		},
+		{
+			name:    "unknown type",
+			cfg:     ProviderConfig{Type: "unknown", Name: "unknown"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewProvider(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewProvider() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if !IsProviderRegistered("test-fake") || IsProviderRegistered("unknown") {
+		t.Errorf("IsProviderRegistered() doesn't match the registered providers %v", RegisteredProviders())
+	}
+}
+
+func TestRegisterProvider_duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterProvider() didn't panic on the duplicate type")
+		}
+	}()
+	RegisterProvider(ProviderRSS, func(cfg ProviderConfig) (NewsProvider, error) { return nil, nil })
+}
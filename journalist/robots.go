@@ -0,0 +1,138 @@
+package journalist
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsMaxBytes is the max size of the parsed robots.txt, the rest is ignored.
+const robotsMaxBytes = 512 << 10
+
+// robotsRules are the rules of robots.txt for one user agent. The longest matching path wins,
+// Allow wins over Disallow of the same length.
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// parseRobots parses robots.txt and returns the rules of the group of the user agent (matched by its product
+// token, e.g. "fin-thread" of "fin-thread/1.0 (+https://...)") or the rules of the "*" group.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var specific, wildcard *robotsRules
+	var group []*robotsRules // rules of the user agents of the current group
+	agentLines := false
+	scanner := bufio.NewScanner(io.LimitReader(r, robotsMaxBytes))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// Consecutive user agents share the rules that follow them
+			if !agentLines {
+				group = nil
+				agentLines = true
+			}
+			switch agent := strings.ToLower(value); {
+			case agent == "*":
+				if wildcard == nil {
+					wildcard = &robotsRules{}
+				}
+				group = append(group, wildcard)
+			case agent == token:
+				if specific == nil {
+					specific = &robotsRules{}
+				}
+				group = append(group, specific)
+			}
+			continue
+		}
+		agentLines = false
+
+		for _, rules := range group {
+			switch key {
+			case "allow":
+				if value != "" {
+					rules.allow = append(rules.allow, value)
+				}
+			case "disallow":
+				// Empty Disallow allows everything
+				if value != "" {
+					rules.disallow = append(rules.disallow, value)
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	switch {
+	case specific != nil:
+		return specific
+	case wildcard != nil:
+		return wildcard
+	default:
+		return &robotsRules{}
+	}
+}
+
+// allowed returns true if the path (with the query) can be crawled.
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+
+	allowLen, disallowLen := -1, -1
+	for _, p := range r.allow {
+		if robotsMatch(p, path) && len(p) > allowLen {
+			allowLen = len(p)
+		}
+	}
+	for _, p := range r.disallow {
+		if robotsMatch(p, path) && len(p) > disallowLen {
+			disallowLen = len(p)
+		}
+	}
+	return disallowLen < 0 || allowLen >= disallowLen
+}
+
+// robotsMatch matches the path with the robots.txt pattern: the path prefix with "*" wildcards and the optional
+// "$" at the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -0,0 +1,51 @@
+package journalist
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseRobots(t *testing.T) {
+	robots := `# Comment
+User-agent: *
+Disallow: /private
+Crawl-delay: 10
+
+User-agent: fin-thread
+User-agent: other-bot
+Disallow: /notices/archive
+Allow: /notices/archive/latest$
+Disallow: /*.pdf
+Crawl-delay: 0.5
+`
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{name: "wildcard group disallowed", userAgent: "curl/8.0", path: "/private/page", want: false},
+		{name: "wildcard group allowed", userAgent: "curl/8.0", path: "/notices/archive", want: true},
+		{name: "own group ignores wildcard", userAgent: rssUserAgent, path: "/private/page", want: true},
+		{name: "own group disallowed", userAgent: rssUserAgent, path: "/notices/archive?page=2", want: false},
+		{name: "longer allow wins", userAgent: rssUserAgent, path: "/notices/archive/latest", want: true},
+		{name: "anchored allow", userAgent: rssUserAgent, path: "/notices/archive/latest/1", want: false},
+		{name: "wildcard pattern", userAgent: rssUserAgent, path: "/files/report.pdf", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(robots), tt.userAgent)
+			if got := rules.allowed(tt.path); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if got := parseRobots(strings.NewReader(robots), rssUserAgent).crawlDelay; got != 500*time.Millisecond {
+		t.Errorf("crawlDelay = %v, want 500ms", got)
+	}
+	if got := parseRobots(strings.NewReader(""), rssUserAgent); !got.allowed("/anything") {
+		t.Errorf("allowed() = false, want empty robots.txt to allow everything")
+	}
+}
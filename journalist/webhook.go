@@ -0,0 +1,122 @@
+package journalist
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"sync"
+	"time"
+)
+
+// webhookQueueSize is the max number of the pushed news waiting for the fetch, the oldest ones are dropped.
+const webhookQueueSize = 500
+
+// WebhookProvider is the NewsProvider implementation for the push-based sources (e.g. Zapier, custom scrapers).
+// The news are pushed to the ingest endpoint of the HTTP server and queued in memory until the next fetch,
+// so they go through the same pipeline as the pulled news. Note: the queue is lost on restart, and only
+// the leader replica accepts the pushes (see server.Server.WithLeader).
+type WebhookProvider struct {
+	Name  string // Name is used for logging purposes and in the ingest endpoint URL
+	token string // secret of the ingest requests
+	mu    sync.Mutex
+	queue NewsList
+}
+
+// WebhookItem is the news item pushed to the webhook, the same as the news item of the plugin response.
+// Date can be in any format supported by utils.ParseDate, the time of the push is used if empty.
+type WebhookItem struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Link        string `json:"link"`
+	Date        string `json:"date"`
+	Image       string `json:"image"`
+}
+
+// NewWebhookProvider creates a new WebhookProvider instance accepting the pushes with the given token.
+func NewWebhookProvider(name, token string) *WebhookProvider {
+	return &WebhookProvider{
+		Name:  name,
+		token: token,
+	}
+}
+
+// Authorize returns true if the token of the push request is valid.
+func (p *WebhookProvider) Authorize(token string) bool {
+	return p.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(p.token)) == 1
+}
+
+// Push validates the pushed items and queues them for the next fetch. Nothing is queued if any item is invalid.
+// Returns the number of the queued news.
+func (p *WebhookProvider) Push(items []WebhookItem) (int, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	news := make(NewsList, 0, len(items))
+	for i, item := range items {
+		if item.Title == "" || item.Link == "" {
+			return 0, newError(errlvl.INFO, errWebhookItem, fmt.Errorf("item %d: title and link are required", i)).WithProvider(p.Name)
+		}
+		if item.Date == "" {
+			item.Date = now
+		}
+
+		newsItem, err := newNews(item.Title, item.Description, item.Link, item.Date, p.Name)
+		if err != nil {
+			return 0, newError(errlvl.INFO, errWebhookItem, fmt.Errorf("item %d: %w", i, err)).WithProvider(p.Name)
+		}
+		newsItem.ImageURL = item.Image
+		news = append(news, newsItem)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, news...)
+	if len(p.queue) > webhookQueueSize {
+		p.queue = p.queue[len(p.queue)-webhookQueueSize:]
+	}
+
+	return len(news), nil
+}
+
+// Fetch returns the news pushed since the previous fetch until the given date and empties the queue.
+func (p *WebhookProvider) Fetch(_ context.Context, until time.Time) (NewsList, error) {
+	p.mu.Lock()
+	queue := p.queue
+	p.queue = nil
+	p.mu.Unlock()
+
+	news := make(NewsList, 0, len(queue))
+	for _, n := range queue {
+		if n.Date.Before(until) {
+			continue
+		}
+		news = append(news, n)
+	}
+
+	return news, nil
+}
+
+var (
+	webhooksMu sync.RWMutex
+	webhooks   = make(map[string]*WebhookProvider)
+)
+
+// registerWebhook makes the webhook provider available to the ingest endpoint by its name.
+func registerWebhook(p *WebhookProvider) error {
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+
+	if _, dup := webhooks[p.Name]; dup {
+		return fmt.Errorf("webhook %s is already registered", p.Name)
+	}
+	webhooks[p.Name] = p
+	return nil
+}
+
+// Webhook returns the webhook provider created from the config by its name.
+func Webhook(name string) (*WebhookProvider, bool) {
+	webhooksMu.RLock()
+	defer webhooksMu.RUnlock()
+
+	p, ok := webhooks[name]
+	return p, ok
+}
@@ -0,0 +1,84 @@
+package journalist
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWebhookProvider_Authorize(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		got   string
+		want  bool
+	}{
+		{name: "valid", token: "secret", got: "secret", want: true},
+		{name: "invalid", token: "secret", got: "guess", want: false},
+		{name: "empty", token: "secret", got: "", want: false},
+		{name: "not configured", token: "", got: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewWebhookProvider("zapier", tt.token).Authorize(tt.got); got != tt.want {
+				t.Errorf("Authorize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookProvider_PushFetch(t *testing.T) {
+	p := NewWebhookProvider("zapier", "secret")
+
+	_, err := p.Push([]WebhookItem{
+		{Title: "Valid", Link: "https://example.com/1"},
+		{Title: "No link"},
+	})
+	if err == nil {
+		t.Fatal("Push() error = nil, want error for the item without the link")
+	}
+
+	now := time.Now().UTC()
+	accepted, err := p.Push([]WebhookItem{
+		{Title: "Fresh <b>news</b>", Description: "Text", Link: "https://example.com/1", Image: "https://example.com/1.png"},
+		{Title: "Old news", Link: "https://example.com/2", Date: now.Add(-time.Hour).Format(time.RFC3339)},
+	})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if accepted != 2 {
+		t.Errorf("Push() = %d, want 2", accepted)
+	}
+
+	news, err := p.Fetch(context.Background(), now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(news) != 1 {
+		t.Fatalf("Fetch() returned %d news, want 1", len(news))
+	}
+	if news[0].Title != "Fresh news" || news[0].ProviderName != "zapier" || news[0].ImageURL != "https://example.com/1.png" {
+		t.Errorf("Fetch() news = %+v", news[0])
+	}
+
+	news, _ = p.Fetch(context.Background(), now.Add(-time.Minute))
+	if len(news) != 0 {
+		t.Errorf("Fetch() after the fetch returned %d news, want 0", len(news))
+	}
+}
+
+func TestWebhookProvider_Push_queueSize(t *testing.T) {
+	p := NewWebhookProvider("zapier", "secret")
+	items := make([]WebhookItem, webhookQueueSize+10)
+	for i := range items {
+		items[i] = WebhookItem{Title: "News", Description: string(rune('a' + i%26)), Link: "https://example.com"}
+	}
+	if _, err := p.Push(items); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	news, _ := p.Fetch(context.Background(), time.Time{})
+	if len(news) != webhookQueueSize {
+		t.Errorf("Fetch() returned %d news, want %d", len(news), webhookQueueSize)
+	}
+}
@@ -0,0 +1,317 @@
+package journalist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	xAPIBaseURL = "https://api.x.com"
+	xBaseURL    = "https://x.com" // base URL of the links to the posts
+)
+
+var errXNoSource = errors.New("bearer token or mirror URL is required")
+
+// XProvider is the NewsProvider implementation that fetches the latest posts of the X (Twitter) accounts
+// (e.g. @DeItaone, @FirstSquawk) via the X API v2 or the nitter-style mirror with the RSS feed of each account,
+// so the breaking headlines are published faster than they appear in the RSS feeds of the news sites.
+// Retweets are resolved to the original posts, so the same post retweeted by several accounts is emitted once.
+type XProvider struct {
+	Name        string   // Name is used for logging purposes
+	Accounts    []string // Account names with or without the "@" prefix, e.g. "DeItaone"
+	BearerToken string   // X API v2 bearer token, if empty the posts are fetched from the MirrorURL
+	MirrorURL   string   // Base URL of the nitter-style mirror with the feeds at /<account>/rss
+	Limit       int      // Number of posts to fetch from each account via the API (5..100)
+	BaseURL     string   // X API base URL
+	client      *http.Client
+
+	mu      sync.Mutex
+	userIDs map[string]string // X API user IDs by the account name
+}
+
+// xPost is the post of the account. ID is the ID of the original post for the retweets.
+type xPost struct {
+	ID      string
+	Author  string
+	Text    string
+	Created time.Time
+}
+
+// NewXProvider creates a new XProvider instance. Either WithBearerToken or WithMirror must be set.
+func NewXProvider(name string, accounts []string) *XProvider {
+	return &XProvider{
+		Name:     name,
+		Accounts: accounts,
+		Limit:    20,
+		BaseURL:  xAPIBaseURL,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		userIDs:  make(map[string]string),
+	}
+}
+
+// WithBearerToken sets the X API v2 bearer token, the posts are fetched via the API then.
+func (x *XProvider) WithBearerToken(token string) *XProvider {
+	x.BearerToken = token
+	return x
+}
+
+// WithMirror sets the base URL of the nitter-style mirror (e.g. https://nitter.example.com),
+// it is used if the bearer token is not set.
+func (x *XProvider) WithMirror(mirrorURL string) *XProvider {
+	x.MirrorURL = strings.TrimSuffix(mirrorURL, "/")
+	return x
+}
+
+// Fetch fetches the posts of all accounts created after the given date.
+func (x *XProvider) Fetch(ctx context.Context, until time.Time) (NewsList, error) {
+	if x.BearerToken == "" && x.MirrorURL == "" {
+		return nil, newError(errlvl.ERROR, errXNoSource).WithProvider(x.Name)
+	}
+
+	seen := make(map[string]bool)
+	var news NewsList
+	for _, account := range x.Accounts {
+		account = strings.TrimPrefix(account, "@")
+
+		var posts []xPost
+		var err error
+		if x.BearerToken != "" {
+			posts, err = x.fetchAPI(ctx, account)
+		} else {
+			posts, err = x.fetchMirror(ctx, account)
+		}
+		if err != nil {
+			return nil, newError(errlvl.ERROR, err).WithProvider(x.Name)
+		}
+
+		for _, p := range posts {
+			if seen[p.ID] || p.Created.Before(until) {
+				continue
+			}
+			seen[p.ID] = true
+
+			title, description := splitPost(p.Text)
+			if title == "" {
+				continue
+			}
+			link := fmt.Sprintf("%s/%s/status/%s", xBaseURL, p.Author, p.ID)
+			newsItem, err := newNews(title, description, link, p.Created.Format(time.RFC3339), x.Name)
+			if err != nil {
+				return nil, newError(errlvl.INFO, err).WithProvider(x.Name)
+			}
+			news = append(news, newsItem)
+		}
+	}
+
+	return news, nil
+}
+
+type xUserResponse struct {
+	Data struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+type xTweet struct {
+	ID               string    `json:"id"`
+	Text             string    `json:"text"`
+	AuthorID         string    `json:"author_id"`
+	CreatedAt        time.Time `json:"created_at"`
+	ReferencedTweets []struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"referenced_tweets"`
+}
+
+type xTweetsResponse struct {
+	Data     []xTweet `json:"data"`
+	Includes struct {
+		Tweets []xTweet `json:"tweets"`
+		Users  []struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"users"`
+	} `json:"includes"`
+}
+
+// fetchAPI fetches the latest posts of the account (without replies) via the X API v2.
+func (x *XProvider) fetchAPI(ctx context.Context, account string) ([]xPost, error) {
+	userID, err := x.userID(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("max_results", fmt.Sprint(min(max(x.Limit, 5), 100)))
+	params.Set("exclude", "replies")
+	params.Set("tweet.fields", "created_at,author_id,referenced_tweets")
+	params.Set("expansions", "referenced_tweets.id,referenced_tweets.id.author_id")
+	params.Set("user.fields", "username")
+
+	var resp xTweetsResponse
+	if err := x.getJSON(ctx, fmt.Sprintf("%s/2/users/%s/tweets?%s", x.BaseURL, userID, params.Encode()), &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch posts of @%s: %w", account, err)
+	}
+
+	return resolveRetweets(account, resp), nil
+}
+
+// resolveRetweets converts the API response to the posts, the retweets are replaced with the original posts.
+func resolveRetweets(account string, resp xTweetsResponse) []xPost {
+	included := make(map[string]xTweet, len(resp.Includes.Tweets))
+	for _, t := range resp.Includes.Tweets {
+		included[t.ID] = t
+	}
+	usernames := make(map[string]string, len(resp.Includes.Users))
+	for _, u := range resp.Includes.Users {
+		usernames[u.ID] = u.Username
+	}
+
+	posts := make([]xPost, 0, len(resp.Data))
+	for _, t := range resp.Data {
+		post := xPost{ID: t.ID, Author: account, Text: t.Text, Created: t.CreatedAt}
+		for _, ref := range t.ReferencedTweets {
+			if ref.Type != "retweeted" {
+				continue
+			}
+			post.ID = ref.ID
+			post.Text = retweetPrefixRe.ReplaceAllString(t.Text, "")
+			if orig, ok := included[ref.ID]; ok {
+				post.Text = orig.Text // text of the retweet is truncated
+				if username, ok := usernames[orig.AuthorID]; ok {
+					post.Author = username
+				}
+			}
+		}
+		posts = append(posts, post)
+	}
+
+	return posts
+}
+
+// userID returns the X API user ID of the account, the IDs are cached.
+func (x *XProvider) userID(ctx context.Context, account string) (string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if id, ok := x.userIDs[account]; ok {
+		return id, nil
+	}
+
+	var resp xUserResponse
+	if err := x.getJSON(ctx, fmt.Sprintf("%s/2/users/by/username/%s", x.BaseURL, url.PathEscape(account)), &resp); err != nil {
+		return "", fmt.Errorf("failed to find user @%s: %w", account, err)
+	}
+	if resp.Data.ID == "" {
+		return "", fmt.Errorf("user @%s not found", account)
+	}
+	x.userIDs[account] = resp.Data.ID
+
+	return resp.Data.ID, nil
+}
+
+// getJSON requests the X API with the bearer token and decodes the JSON response into v.
+func (x *XProvider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+x.BearerToken)
+
+	resp, err := x.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// The same error type as for the RSS feeds, so the status is tracked by the providers health
+		return gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchMirror fetches the posts of the account from the RSS feed of the mirror. Links of the retweets
+// point to the original posts.
+func (x *XProvider) fetchMirror(ctx context.Context, account string) ([]xPost, error) {
+	u := fmt.Sprintf("%s/%s/rss", x.MirrorURL, url.PathEscape(account))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", rssUserAgent)
+
+	resp, err := x.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch posts of @%s: %w", account, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	feed, err := gofeed.NewParser().Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse posts of @%s: %w", account, err)
+	}
+
+	posts := make([]xPost, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		author, id, ok := parseStatusLink(item.Link)
+		if !ok || item.PublishedParsed == nil {
+			continue
+		}
+		posts = append(posts, xPost{
+			ID:      id,
+			Author:  author,
+			Text:    mirrorRetweetPrefixRe.ReplaceAllString(item.Title, ""),
+			Created: *item.PublishedParsed,
+		})
+	}
+
+	return posts, nil
+}
+
+var (
+	// retweetPrefixRe matches the prefix of the retweet text in the API ("RT @FirstSquawk: ").
+	retweetPrefixRe = regexp.MustCompile(`^RT @\w+: `)
+	// mirrorRetweetPrefixRe matches the prefix of the retweet title in the mirror feed ("RT by @DeItaone: ").
+	mirrorRetweetPrefixRe = regexp.MustCompile(`^RT by @\w+: `)
+	// shortLinkRe matches the t.co links appended to the posts with media.
+	shortLinkRe = regexp.MustCompile(`\s*https://t\.co/\w+`)
+)
+
+// parseStatusLink returns the author and the post ID from the post link (e.g. https://nitter.net/DeItaone/status/1#m).
+func parseStatusLink(link string) (author, id string, ok bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 3 || parts[1] != "status" || parts[0] == "" || parts[2] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[2], true
+}
+
+// splitPost splits the post text into the title (first line) and the description (the rest).
+// The t.co links are removed.
+func splitPost(text string) (title, description string) {
+	text = strings.TrimSpace(shortLinkRe.ReplaceAllString(text, ""))
+	title, description, _ = strings.Cut(text, "\n")
+	return strings.TrimSpace(title), strings.TrimSpace(description)
+}
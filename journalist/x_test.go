@@ -0,0 +1,138 @@
+package journalist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestXProvider_Fetch_api(t *testing.T) {
+	now := time.Now().UTC()
+	created := now.Format(time.RFC3339)
+	tweets := map[string]string{
+		// DeItaone posts the headline
+		"/2/users/1/tweets": fmt.Sprintf(`{"data":[
+			{"id":"100","text":"*FED HOLDS RATES STEADY AT 5.25%%-5.5%% https://t.co/abc","author_id":"1","created_at":%q},
+			{"id":"99","text":"Old headline","author_id":"1","created_at":%q}
+		]}`, created, now.Add(-2*time.Hour).Format(time.RFC3339)),
+		// FirstSquawk retweets it with the truncated text
+		"/2/users/2/tweets": fmt.Sprintf(`{"data":[
+			{"id":"200","text":"RT @DeItaone: *FED HOLDS RATES STEADY AT 5.25%%…","author_id":"2","created_at":%q,
+			 "referenced_tweets":[{"type":"retweeted","id":"100"}]},
+			{"id":"201","text":"US 10Y YIELD RISES TO 4.5%%\nHighest since November","author_id":"2","created_at":%q}
+		],"includes":{
+			"tweets":[{"id":"100","text":"*FED HOLDS RATES STEADY AT 5.25%%-5.5%%","author_id":"1","created_at":%q}],
+			"users":[{"id":"1","username":"DeItaone"}]
+		}}`, created, created, created),
+	}
+
+	var userRequests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/2/users/by/username/DeItaone":
+			userRequests++
+			_, _ = w.Write([]byte(`{"data":{"id":"1"}}`))
+		case "/2/users/by/username/FirstSquawk":
+			userRequests++
+			_, _ = w.Write([]byte(`{"data":{"id":"2"}}`))
+		case "/2/users/1/tweets", "/2/users/2/tweets":
+			if r.URL.Query().Get("exclude") != "replies" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(tweets[r.URL.Path]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	x := NewXProvider("x", []string{"@DeItaone", "FirstSquawk"}).WithBearerToken("token")
+	x.BaseURL = srv.URL
+
+	news, err := x.Fetch(context.Background(), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(news) != 2 {
+		t.Fatalf("Fetch() returned %d news, want 2", len(news))
+	}
+	if news[0].Title != "*FED HOLDS RATES STEADY AT 5.25%-5.5%" || news[0].Link != "https://x.com/DeItaone/status/100" {
+		t.Errorf("Fetch() news[0] = %q, %q", news[0].Title, news[0].Link)
+	}
+	if news[1].Title != "US 10Y YIELD RISES TO 4.5%" || news[1].Description != "Highest since November" {
+		t.Errorf("Fetch() news[1] = %q, %q", news[1].Title, news[1].Description)
+	}
+	if news[1].Link != "https://x.com/FirstSquawk/status/201" || news[1].ProviderName != "x" {
+		t.Errorf("Fetch() news[1] link = %q, provider = %q", news[1].Link, news[1].ProviderName)
+	}
+
+	// User IDs are cached
+	if _, err := x.Fetch(context.Background(), now.Add(-time.Hour)); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if userRequests != 2 {
+		t.Errorf("Fetch() requested users %d times, want 2", userRequests)
+	}
+}
+
+func TestXProvider_Fetch_mirror(t *testing.T) {
+	pubDate := time.Now().UTC().Format(time.RFC1123Z)
+	feeds := map[string]string{
+		"/DeItaone/rss": fmt.Sprintf(`<?xml version="1.0"?><rss version="2.0"><channel><title>DeItaone</title>
+			<item><title>*APPLE TO BUY BACK $110B OF SHARES</title><link>https://nitter.example.com/DeItaone/status/300#m</link><pubDate>%s</pubDate></item>
+		</channel></rss>`, pubDate),
+		"/FirstSquawk/rss": fmt.Sprintf(`<?xml version="1.0"?><rss version="2.0"><channel><title>FirstSquawk</title>
+			<item><title>RT by @FirstSquawk: *APPLE TO BUY BACK $110B OF SHARES</title><link>https://nitter.example.com/DeItaone/status/300#m</link><pubDate>%s</pubDate></item>
+			<item><title>OIL FALLS 2%% ON OPEC+ OUTPUT HIKE</title><link>https://nitter.example.com/FirstSquawk/status/301#m</link><pubDate>%s</pubDate></item>
+		</channel></rss>`, pubDate, pubDate),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed, ok := feeds[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer srv.Close()
+
+	t.Run("retweets are deduplicated", func(t *testing.T) {
+		x := NewXProvider("x", []string{"DeItaone", "FirstSquawk"}).WithMirror(srv.URL + "/")
+
+		news, err := x.Fetch(context.Background(), time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if len(news) != 2 {
+			t.Fatalf("Fetch() returned %d news, want 2", len(news))
+		}
+		if news[0].Title != "*APPLE TO BUY BACK $110B OF SHARES" || news[0].Link != "https://x.com/DeItaone/status/300" {
+			t.Errorf("Fetch() news[0] = %q, %q", news[0].Title, news[0].Link)
+		}
+		if news[1].Link != "https://x.com/FirstSquawk/status/301" {
+			t.Errorf("Fetch() news[1] link = %q", news[1].Link)
+		}
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		x := NewXProvider("x", []string{"unknown"}).WithMirror(srv.URL)
+		if _, err := x.Fetch(context.Background(), time.Now().Add(-time.Hour)); err == nil {
+			t.Errorf("Fetch() error = nil, want error")
+		}
+	})
+
+	t.Run("no source", func(t *testing.T) {
+		x := NewXProvider("x", []string{"DeItaone"})
+		if _, err := x.Fetch(context.Background(), time.Now().Add(-time.Hour)); err == nil {
+			t.Errorf("Fetch() error = nil, want error")
+		}
+	})
+}
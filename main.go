@@ -1,59 +1,310 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/getsentry/sentry-go"
-	"github.com/go-playground/validator/v10"
-	"log/slog"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
+// logger is the logger of the app lifecycle and commands.
+var logger = logging.For(logging.ModuleMain)
+
 func main() {
-	l := slog.Default()
+	if err := setupLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging configuration: %s\n", err)
+		os.Exit(2)
+	}
+
+	name, args := commandArgs(os.Args[1:])
+
+	if name == "h" || name == "help" {
+		fmt.Print(usage)
+		return
+	}
+	if run, ok := standaloneCommands[name]; ok {
+		if err := run(args); err != nil && !errors.Is(err, flag.ErrHelp) {
+			logger.Error("[main] Command failed", "command", name, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+	// Flags are checked before the environment, so `finfeed <command> -h` works without the configuration
+	action, err := cmd(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		os.Exit(2)
+	}
 
+	envs := &envParser{}
 	env := Env{
-		TelegramChannelID: os.Getenv("TELEGRAM_CHANNEL_ID"),
-		TelegramBotToken:  os.Getenv("TELEGRAM_BOT_TOKEN"),
-		OpenAiToken:       os.Getenv("OPENAI_TOKEN"),
-		TogetherAIToken:   os.Getenv("TOGETHER_AI_TOKEN"),
-		GoogleGeminiToken: os.Getenv("GOOGLE_GEMINI_TOKEN"),
-		PostgresDSN:       os.Getenv("POSTGRES_DSN"),
-		SentryDSN:         os.Getenv("SENTRY_DSN"),
-		StockSymbols:      os.Getenv("STOCK_SYMBOLS"),
-		MarketJournalists: os.Getenv("MARKET_JOURNALISTS"),
-		BroadJournalists:  os.Getenv("BROAD_JOURNALISTS"),
-		ServerName:        os.Getenv("SERVER_NAME"),
-		ShouldPublish:     os.Getenv("SHOULD_PUBLISH") == "true",
-	}
-	validate := validator.New()
-	if err := validate.Struct(env); err != nil {
-		l.Error("[main] Error validating environment variables:", err)
+		TelegramChannelID:  os.Getenv("TELEGRAM_CHANNEL_ID"),
+		TelegramChannels:   os.Getenv("TELEGRAM_CHANNELS"),
+		TickerChannels:     os.Getenv("TICKER_CHANNELS"),
+		TelegramBotToken:   os.Getenv("TELEGRAM_BOT_TOKEN"),
+		DiscordWebhookURL:  os.Getenv("DISCORD_WEBHOOK_URL"),
+		MastodonURL:        os.Getenv("MASTODON_URL"),
+		MastodonToken:      os.Getenv("MASTODON_TOKEN"),
+		MastodonMaxLength:  envs.Int("MASTODON_MAX_LENGTH", 500),
+		BlueskyURL:         os.Getenv("BLUESKY_URL"),
+		BlueskyHandle:      os.Getenv("BLUESKY_HANDLE"),
+		BlueskyAppPassword: os.Getenv("BLUESKY_APP_PASSWORD"),
+		OpenAiToken:        os.Getenv("OPENAI_TOKEN"),
+		TogetherAIToken:    os.Getenv("TOGETHER_AI_TOKEN"),
+		GoogleGeminiToken:  os.Getenv("GOOGLE_GEMINI_TOKEN"),
+		OpenAiBaseURL:      os.Getenv("OPENAI_BASE_URL"),
+		OpenAiModel:        os.Getenv("OPENAI_MODEL"),
+		ComposerProvider:   os.Getenv("COMPOSER_PROVIDER"),
+		AnthropicToken:     os.Getenv("ANTHROPIC_TOKEN"),
+		AnthropicModel:     os.Getenv("ANTHROPIC_MODEL"),
+		ComposerFallback:   os.Getenv("COMPOSER_FALLBACK"),
+		MetaModel:          os.Getenv("META_MODEL"),
+		MetaMarkets:        os.Getenv("META_MARKETS"),
+		ComposeVerify:      os.Getenv("COMPOSE_VERIFY"),
+		VerifyModel:        os.Getenv("VERIFY_MODEL"),
+		PromptsDir:         os.Getenv("PROMPTS_DIR"),
+		ComposeStyle:       os.Getenv("COMPOSE_STYLE"),
+		PromptFiles:        os.Getenv("PROMPT_FILES"),
+		TickerValidation:   os.Getenv("TICKER_VALIDATION") == "true",
+		SecuritiesFile:     os.Getenv("SECURITIES_FILE"),
+		SecuritiesURL:      os.Getenv("SECURITIES_URL"),
+		TickerAliases:      os.Getenv("TICKER_ALIASES"),
+		PostgresDSN:        os.Getenv("POSTGRES_DSN"),
+		SentryDSN:          os.Getenv("SENTRY_DSN"),
+		// There are not many transactions, so by default we can afford to send all of them
+		SentryTracesSampleRate:   envs.Float("SENTRY_TRACES_SAMPLE_RATE", 1.0),
+		SentryProfilesSampleRate: envs.Float("SENTRY_PROFILES_SAMPLE_RATE", 1.0),
+		SentryMaxValueLength:     envs.Int("SENTRY_MAX_VALUE_LENGTH", 500),
+		StockSymbols:             os.Getenv("STOCK_SYMBOLS"),
+		MarketJournalists:        os.Getenv("MARKET_JOURNALISTS"),
+		BroadJournalists:         os.Getenv("BROAD_JOURNALISTS"),
+		JobsConfig:               os.Getenv("JOBS_CONFIG"),
+		ServerName:               os.Getenv("SERVER_NAME"),
+		ShouldPublish:            os.Getenv("SHOULD_PUBLISH") == "true",
+		HeartbeatURL:             os.Getenv("HEARTBEAT_URL"),
+		StatsdAddr:               os.Getenv("STATSD_ADDR"),
+		LeaderElection:           os.Getenv("LEADER_ELECTION_LEASE"),
+		PodName:                  os.Getenv("POD_NAME"),
+		ExportS3Endpoint:         os.Getenv("EXPORT_S3_ENDPOINT"),
+		ExportS3Region:           os.Getenv("EXPORT_S3_REGION"),
+		ExportS3Bucket:           os.Getenv("EXPORT_S3_BUCKET"),
+		ExportS3AccessKey:        os.Getenv("EXPORT_S3_ACCESS_KEY"),
+		ExportS3SecretKey:        os.Getenv("EXPORT_S3_SECRET_KEY"),
+		Rules:                    os.Getenv("RULES"),
+		RulesFile:                os.Getenv("RULES_FILE"),
+		AdminChatID:              os.Getenv("ADMIN_CHAT_ID"),
+		AdminAlertThreshold:      envs.Int("ADMIN_ALERT_THRESHOLD", 1),
+		AdminCommandsEnabled:     os.Getenv("ADMIN_COMMANDS_ENABLED") == "true",
+		HTTPAddr:                 os.Getenv("HTTP_ADDR"),
+		PrometheusEnabled:        os.Getenv("PROMETHEUS_ENABLED") == "true",
+		ReadyMaxJobAge:           envs.Int("READY_MAX_JOB_AGE", 0),
+		ExtractImageFigures:      os.Getenv("EXTRACT_IMAGE_FIGURES") == "true",
+		AttachImages:             os.Getenv("ATTACH_IMAGES") == "true",
+		ClassifyNews:             os.Getenv("CLASSIFY_NEWS") == "true",
+		SuspiciousThreshold:      envs.Float("SUSPICIOUS_THRESHOLD", 0),
+		SentimentMinConfidence:   envs.Float("SENTIMENT_MIN_CONFIDENCE", 0),
+		MessageFormat:            os.Getenv("MESSAGE_FORMAT"),
+		LinkPreview:              os.Getenv("LINK_PREVIEW") == "true",
+		PodcastEnabled:           os.Getenv("PODCAST_ENABLED") == "true",
+		SimilarityDedupEnabled:   os.Getenv("SIMILARITY_DEDUP_ENABLED") == "true",
+		SimilarityDedupMin:       envs.Float("SIMILARITY_DEDUP_MIN", 0.9),
+		SimilarityDedupWindow:    envs.Int("SIMILARITY_DEDUP_WINDOW", 24),
+		CalendarNewsEnabled:      os.Getenv("CALENDAR_NEWS_ENABLED") == "true",
+		PodcastBaseURL:           os.Getenv("PODCAST_BASE_URL"),
+		FeedBaseURL:              os.Getenv("FEED_BASE_URL"),
+		FeedItems:                envs.Int("FEED_ITEMS", 50),
+		FeedDir:                  os.Getenv("FEED_DIR"),
+		FeedS3Enabled:            os.Getenv("FEED_S3_ENABLED") == "true",
+		DigestCron:               os.Getenv("DIGEST_CRON"),
+		DigestHours:              envs.Int("DIGEST_HOURS", 24),
+		MarketMoodCron:           os.Getenv("MARKET_MOOD_CRON"),
+		MarketMoodResultsCron:    os.Getenv("MARKET_MOOD_RESULTS_CRON"),
+		MarketMoodChannel:        os.Getenv("MARKET_MOOD_CHANNEL"),
+		EmailDigestCron:          os.Getenv("EMAIL_DIGEST_CRON"),
+		EmailProvider:            os.Getenv("EMAIL_PROVIDER"),
+		EmailFrom:                os.Getenv("EMAIL_FROM"),
+		EmailTo:                  os.Getenv("EMAIL_TO"),
+		EmailSubject:             os.Getenv("EMAIL_SUBJECT"),
+		EmailUnsubscribeURL:      os.Getenv("EMAIL_UNSUBSCRIBE_URL"),
+		EmailTemplate:            os.Getenv("EMAIL_TEMPLATE"),
+		SMTPAddr:                 os.Getenv("SMTP_ADDR"),
+		SMTPUsername:             os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:             os.Getenv("SMTP_PASSWORD"),
+		SendGridAPIKey:           os.Getenv("SENDGRID_API_KEY"),
+		QuotesProvider:           os.Getenv("QUOTES_PROVIDER"),
+		FinnhubToken:             os.Getenv("FINNHUB_TOKEN"),
+		QuotesCacheTTL:           envs.Int("QUOTES_CACHE_TTL", 60),
+		ThreadMaxLength:          envs.Int("THREAD_MAX_LENGTH", 1000),
+		PublishRetryAttempts:     envs.Int("PUBLISH_RETRY_ATTEMPTS", 3),
+		PublishRetryMaxDelay:     envs.Int("PUBLISH_RETRY_MAX_DELAY", 30),
+		DeadLetterMaxAttempts:    envs.Int("DEAD_LETTER_MAX_ATTEMPTS", 5),
+		PublishRatePerChat:       envs.Int("PUBLISH_RATE_PER_CHAT", 20),
+		JobTimeout:               envs.Int("JOB_TIMEOUT", 25),
+		PublishRateGlobal:        envs.Int("PUBLISH_RATE_GLOBAL", 0),
+		NewsRetentionDays:        envs.Int("NEWS_RETENTION_DAYS", 0),
+		PublishedRetentionDays:   envs.Int("PUBLISHED_RETENTION_DAYS", 0),
+		EngagementStatsDays:      envs.Int("ENGAGEMENT_STATS_DAYS", 0),
+		PublishWindow:            os.Getenv("PUBLISH_WINDOW"),
+		QuietHours:               os.Getenv("QUIET_HOURS"),
+		PublishTimezone:          os.Getenv("PUBLISH_TIMEZONE"),
+		RoundupHeader:            os.Getenv("ROUNDUP_HEADER"),
+		ComposeFreshnessWindow:   envs.Int("COMPOSE_FRESHNESS_WINDOW", 360),
+		ComposeMaxSentences:      envs.Int("COMPOSE_MAX_SENTENCES", 0),
+		ComposeMaxChars:          envs.Int("COMPOSE_MAX_CHARS", 0),
+		ComposeCacheTTL:          envs.Int("COMPOSE_CACHE_TTL", 60),
+		ComposeCacheSize:         envs.Int("COMPOSE_CACHE_SIZE", 1000),
+		LLMMonthlyBudget:         envs.Float("LLM_MONTHLY_BUDGET", 0),
+	}
+	if err := errors.Join(envs.Err(), (&Config{env: &env}).Validate()); err != nil {
+		logger.Error("[main] Invalid configuration, fix the environment variables:\n" + err.Error())
+		os.Exit(1)
+	}
+
+	cnf, err := NewConfig(&env)
+	if err != nil {
+		logger.Error("[main] Error creating Config", "error", err)
 		return
 	}
 
-	err := sentry.Init(sentry.ClientOptions{
+	// Remove tokens, DSNs and full news bodies from the events before sending them to Sentry
+	scrubber := utils.NewSentryScrubber(append([]string{
+		env.TelegramBotToken,
+		env.OpenAiToken,
+		env.TogetherAIToken,
+		env.GoogleGeminiToken,
+		env.AnthropicToken,
+		env.PostgresDSN,
+		env.SentryDSN,
+		env.ExportS3SecretKey,
+		env.MastodonToken,
+		env.BlueskyAppPassword,
+		env.FinnhubToken,
+		env.DiscordWebhookURL,
+		env.SMTPPassword,
+		env.SendGridAPIKey,
+	}, cnf.threadBotTokens()...), env.SentryMaxValueLength)
+
+	err = sentry.Init(sentry.ClientOptions{
 		Dsn:                env.SentryDSN,
 		EnableTracing:      true,
-		TracesSampleRate:   1.0, // There are not many transactions, so we can afford to send all of them
-		ProfilesSampleRate: 1.0, // Same here
+		TracesSampleRate:   env.SentryTracesSampleRate,
+		ProfilesSampleRate: env.SentryProfilesSampleRate,
 		ServerName:         env.ServerName,
+		BeforeSend:         scrubber.BeforeSend,
+		BeforeBreadcrumb:   scrubber.BeforeBreadcrumb,
 	})
 	if err != nil {
-		l.Error("[main] Error initializing Sentry:", err)
+		logger.Error("[main] Error initializing Sentry", "error", err)
 		os.Exit(1)
 	}
 	defer sentry.Flush(2 * time.Second)
 	defer sentry.Recover()
 
-	cnf, err := NewConfig(&env)
+	app := &App{
+		cnf,
+	}
+
+	if err := action(app); err != nil {
+		logger.Error("[main] Command failed", "command", name, "error", err)
+		sentry.Flush(2 * time.Second)
+		os.Exit(1)
+	}
+}
+
+// setupLogging configures the module loggers by LOG_LEVEL (info by default), LOG_FORMAT (text or json)
+// and LOG_LEVELS (module levels, e.g. "journalist=debug,composer=warn"). It runs before the commands,
+// so the standalone ones log the same way.
+func setupLogging() error {
+	c := logging.Config{Format: os.Getenv("LOG_FORMAT")}
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		l, err := logging.ParseLevel(level)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		c.Level = l
+	}
+	modules, err := logging.ParseModules(os.Getenv("LOG_LEVELS"))
 	if err != nil {
-		l.Error("[main] Error creating Config:", err)
-		return
+		return err //nolint:wrapcheck
 	}
+	c.Modules = modules
+	return logging.Setup(c) //nolint:wrapcheck
+}
 
-	app := &App{
-		cnf,
+// checkHealth requests the /healthz endpoint of the app listening on the given address (e.g. ":8080").
+func checkHealth(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_ADDR: %w", err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://" + net.JoinHostPort(host, port) + "/healthz")
+	if err != nil {
+		return fmt.Errorf("failed to request /healthz: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected /healthz status: %s", resp.Status)
 	}
+	return nil
+}
+
+// envParser parses the numeric environment variables and collects the parsing errors,
+// so all of them are reported at once with the validation problems.
+type envParser struct {
+	errs []error
+}
+
+// Float parses float environment variable, returns def if it is unset.
+func (p *envParser) Float(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s must be a number, got %q", key, v))
+		return def
+	}
+	return f
+}
+
+// Int parses int environment variable, returns def if it is unset.
+func (p *envParser) Int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s must be an integer, got %q", key, v))
+		return def
+	}
+	return i
+}
 
-	app.start()
+// Err returns all parsing errors joined.
+func (p *envParser) Err() error {
+	return errors.Join(p.errs...)
 }
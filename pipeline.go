@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/jobs"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"github.com/samgozman/fin-thread/pkg/scheduler"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
+	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"time"
+)
+
+// pipeline is the news pipeline of one channel: the default one configured by the env or the thread from JOBS_CONFIG.
+type pipeline struct {
+	name      string                       // name of the thread, empty for the default pipeline
+	cnf       *Config                      // configuration of the pipeline
+	publisher *publisher.TelegramPublisher // publisher of the pipeline channel
+	composer  *composer.Composer           // composer with the LLM provider and style of the pipeline
+	archivist *archivist.Archivist         // archivist scoped to the channels of the pipeline
+	router    *jobs.Router                 // routes news to the named channels (default pipeline only)
+	mirrors   *publisher.MultiPublisher    // additional targets of the published news (default pipeline only)
+	email     *publisher.EmailPublisher    // email digest of the published news (default pipeline only)
+	// Publishing windows of the pipeline channels by their names, shared by the jobs publishing to them
+	windows map[string]*jobs.PublishWindow
+
+	newsJobs []*jobs.Job
+	// The first job that saves news, it recovers and retries the publications of the pipeline
+	publicationsJob *jobs.Job
+}
+
+// pipelineServices are the services shared by the news pipelines of all channels.
+type pipelineServices struct {
+	metrics    metrics.Emitter
+	usage      *jobs.LLMUsageJob
+	health     *jobs.ProviderHealthJob
+	control    *jobs.Control
+	alerter    *jobs.Alerter
+	stockMap   *stocks.StockMap
+	quotes     marketdata.QuoteProvider
+	templates  *composer.PromptTemplates
+	securities *composer.Securities
+}
+
+// newThread creates the publisher, composer and news jobs of the thread. Its news and dead letters are scoped
+// by the thread channel. Errors are returned, so the broken thread is skipped without stopping the others.
+func (a *App) newThread(t *threadDefinition, root *archivist.Archivist, s *pipelineServices) (*pipeline, error) {
+	thread := &App{cnf: t.config(a.cnf)}
+
+	telegramPublisher, err := thread.newTelegramPublisher(s.metrics)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Telegram publisher: %w", err)
+	}
+
+	composerEntity := thread.newComposer(s.metrics, s.usage)
+	if s.templates != nil {
+		composerEntity.WithPromptTemplates(s.templates)
+	}
+	if s.securities != nil {
+		composerEntity.WithSecurities(s.securities)
+	}
+
+	p := &pipeline{
+		name:      t.Name,
+		cnf:       thread.cnf,
+		publisher: telegramPublisher,
+		composer:  composerEntity,
+		archivist: root.Scoped(archivist.Scope{ChatIDs: []string{t.ChannelID}}),
+	}
+	if err := p.buildJobs(s); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// buildJobs creates the news jobs of the pipeline. News jobs are defined in the JOBS_CONFIG file or the default
+// market and broad news jobs are used. All news jobs of the pipeline share the same publisher and scope,
+// so the first job that saves news is used to recover, repost, correct and retract publications.
+func (p *pipeline) buildJobs(s *pipelineServices) error {
+	env := p.cnf.env
+
+	// Operator-defined rules with the suspicious keywords flagging the fetched news
+	ruleSet, err := p.cnf.ruleSet()
+	if err != nil {
+		return fmt.Errorf("error compiling rules: %w", err)
+	}
+
+	p.windows, err = p.cnf.publishWindows()
+	if err != nil {
+		return err
+	}
+
+	p.newsJobs = make([]*jobs.Job, len(p.cnf.jobs))
+	for i, def := range p.cnf.jobs {
+		providers, err := def.providers(env.FinnhubToken)
+		if err != nil {
+			return fmt.Errorf("error creating news providers: %w", err)
+		}
+		newsJournalist := journalist.NewJournalist(def.Name, providers).
+			Limit(def.Limit).
+			WithProviderTimeout(def.ProviderTimeout).
+			FilterDomains(def.AllowDomains, def.BlockDomains).
+			ObserveFetches(s.health.Observe).
+			WithMetrics(s.metrics)
+
+		newsJob := def.apply(jobs.NewJob(p.composer, p.publisher, p.archivist, newsJournalist, s.stockMap)).
+			ThreadLongText(env.ThreadMaxLength).
+			WithMetrics(s.metrics).
+			WithRouter(p.router).
+			MirrorTo(p.mirrors).
+			WithRules(ruleSet).
+			WithAlerter(s.alerter).
+			WithControl(s.control).
+			WithBudget(s.usage)
+		if env.ExtractImageFigures && def.ComposeText {
+			newsJob.ExtractImageFigures()
+		}
+		if env.AttachImages && def.SaveToDB {
+			newsJob.AttachImages()
+		}
+		if env.SentimentMinConfidence > 0 && def.ComposeText {
+			newsJob.ShowSentiment(env.SentimentMinConfidence)
+		}
+		if s.quotes != nil && def.ComposeText {
+			newsJob.WithQuotes(s.quotes)
+		}
+		if len(p.cnf.tickerChannels) > 0 && def.ComposeText {
+			newsJob.SubscribeTickers(p.cnf.tickerChannels)
+		}
+		if p.email != nil {
+			newsJob.EmailTo(p.email)
+		}
+		if def.RunSummary != "" {
+			chat := def.SummaryChat
+			if chat == "" {
+				chat = env.AdminChatID
+			}
+			if chat == "" {
+				return fmt.Errorf("job %s: run_summary requires summary_chat or ADMIN_CHAT_ID", def.Name)
+			}
+			newsJob.SummarizeRuns(p.publisher, p.publisher.ChatID(chat), jobs.RunSummaryMode(def.RunSummary))
+		}
+		if len(p.windows) > 0 && def.SaveToDB {
+			newsJob.WithPublishWindows(p.windows)
+		}
+		if env.SimilarityDedupEnabled {
+			newsJob.RemoveSimilar(time.Duration(env.SimilarityDedupWindow)*time.Hour, env.SimilarityDedupMin)
+		}
+		p.newsJobs[i] = newsJob
+		if def.SaveToDB && p.publicationsJob == nil {
+			p.publicationsJob = newsJob
+		}
+	}
+
+	return nil
+}
+
+// schedule adds the news jobs of the pipeline and the recovery of its publications to the scheduler.
+// Names of the thread tasks are prefixed with the thread name like its jobs.
+func (p *pipeline) schedule(schedule func(def scheduler.Definition, task func())) {
+	prefix := ""
+	if p.name != "" {
+		prefix = p.name + "/"
+	}
+
+	if p.publicationsJob != nil {
+		// Publish news queued before the last shutdown by the jobs without the publish queue,
+		// the queue workers publish the rest
+		var directJobs []string
+		for _, def := range p.cnf.jobs {
+			if def.SaveToDB && def.QueueEvery == 0 {
+				directJobs = append(directJobs, def.Name)
+			}
+		}
+		schedule(scheduler.Definition{
+			Name: prefix + "Publications recovery",
+			Once: true,
+		}, p.publicationsJob.RecoverPublications(time.Hour, directJobs))
+
+		// Publish news failed to be published again until the max attempts
+		schedule(scheduler.Definition{
+			Name:  prefix + "Dead letters retry",
+			Every: 10 * time.Minute,
+		}, p.publicationsJob.RetryDeadLetters(p.cnf.env.DeadLetterMaxAttempts))
+	}
+
+	for i, def := range p.cnf.jobs {
+		schedule(scheduler.Definition{
+			Name:   def.Name,
+			Cron:   def.Cron,
+			Every:  def.Every,
+			Jitter: def.Jitter,
+		}, p.newsJobs[i].Run())
+
+		// The composed news are queued by the runs and published by the worker at its own pace
+		if def.QueueEvery > 0 {
+			schedule(scheduler.Definition{
+				Name:  def.Name + " publish queue",
+				Every: def.QueueEvery,
+			}, p.newsJobs[i].PublishQueue(def.QueueBatch))
+		}
+
+		// News held outside the publishing windows are published when the windows open
+		if len(p.windows) > 0 && def.SaveToDB {
+			schedule(scheduler.Definition{
+				Name:  def.Name + " held news",
+				Every: 5 * time.Minute,
+			}, p.newsJobs[i].PublishHeld())
+		}
+	}
+}
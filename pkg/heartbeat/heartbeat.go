@@ -0,0 +1,52 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Heartbeat pings an external uptime monitor (healthchecks.io, Better Uptime, etc.) by sending
+// an HTTP GET request to the configured URL. If the pings stop, the monitor will raise an alert.
+type Heartbeat struct {
+	URL    string // URL of the check, e.g. "https://hc-ping.com/<uuid>"
+	client *http.Client
+}
+
+// New creates a new Heartbeat instance for the given URL.
+func New(url string) *Heartbeat {
+	return &Heartbeat{
+		URL:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled returns true if the Heartbeat has a URL to ping.
+func (h *Heartbeat) Enabled() bool {
+	return h != nil && h.URL != ""
+}
+
+// Ping sends a single heartbeat to the monitor. It does nothing if the Heartbeat is not enabled.
+func (h *Heartbeat) Ping(ctx context.Context) error {
+	if !h.Enabled() {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("error creating heartbeat request: %w", err)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat returned unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package heartbeat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeartbeat_Ping(t *testing.T) {
+	var hits int
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer okServer.Close()
+
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failServer.Close()
+
+	tests := []struct {
+		name    string
+		hb      *Heartbeat
+		wantErr bool
+	}{
+		{
+			name:    "successful ping",
+			hb:      New(okServer.URL),
+			wantErr: false,
+		},
+		{
+			name:    "unexpected status",
+			hb:      New(failServer.URL),
+			wantErr: true,
+		},
+		{
+			name:    "disabled heartbeat",
+			hb:      New(""),
+			wantErr: false,
+		},
+		{
+			name:    "nil heartbeat",
+			hb:      nil,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hb.Ping(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if hits != 1 {
+		t.Errorf("Ping() expected 1 hit on the monitor, got %d", hits)
+	}
+}
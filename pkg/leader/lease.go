@@ -0,0 +1,341 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	microTimeLayout   = "2006-01-02T15:04:05.000000Z07:00" // Kubernetes MicroTime format
+)
+
+var (
+	ErrNotLeader   = errors.New("this instance is not the leader")
+	errLeaseUpdate = errors.New("failed to update lease")
+)
+
+// LeaseElector implements leader election on top of the Kubernetes Lease API (coordination.k8s.io/v1).
+// Only one replica holds the lease at a time, others will take it over once the lease expires.
+//
+// It implements the gocron.Elector interface, so only the leader executes scheduled jobs.
+type LeaseElector struct {
+	client        *http.Client
+	baseURL       string        // Kubernetes API server URL
+	token         string        // service account token
+	namespace     string        // namespace of the lease
+	name          string        // name of the lease
+	identity      string        // identity of this replica (pod name)
+	leaseDuration time.Duration // how long the lease is valid without renewal
+	retryPeriod   time.Duration // how often to try to acquire or renew the lease
+	logger        *slog.Logger
+
+	mu             sync.RWMutex
+	isLeader       bool
+	renewedAt      time.Time // last time this replica renewed the lease
+	observedRecord string    // last observed holder and renew time of the lease
+	observedAt     time.Time // local time when observedRecord has changed
+}
+
+// NewLeaseElector creates a new LeaseElector using the in-cluster service account configuration.
+func NewLeaseElector(name, identity string, leaseDuration time.Duration) (*LeaseElector, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("leader election requires running inside Kubernetes cluster")
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	namespace, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account namespace: %w", err)
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	return newLeaseElector(
+		client,
+		"https://"+host+":"+port,
+		strings.TrimSpace(string(token)),
+		strings.TrimSpace(string(namespace)),
+		name,
+		identity,
+		leaseDuration,
+	), nil
+}
+
+func newLeaseElector(client *http.Client, baseURL, token, namespace, name, identity string, leaseDuration time.Duration) *LeaseElector {
+	return &LeaseElector{
+		client:        client,
+		baseURL:       baseURL,
+		token:         token,
+		namespace:     namespace,
+		name:          name,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		retryPeriod:   leaseDuration / 5,
+		logger:        logging.For(logging.ModuleLeader),
+	}
+}
+
+// IsLeader returns nil if this replica holds the lease and has renewed it recently.
+func (e *LeaseElector) IsLeader(_ context.Context) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.isLeader && time.Since(e.renewedAt) < e.leaseDuration {
+		return nil
+	}
+
+	return ErrNotLeader
+}
+
+// Run tries to acquire or renew the lease until the context is canceled.
+// On exit the lease is released (if held), so other replicas can take over immediately.
+func (e *LeaseElector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.retryPeriod)
+	defer ticker.Stop()
+
+	for {
+		e.tryAcquireOrRenew(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquireOrRenew makes a single attempt to acquire or renew the lease and updates the leader state.
+func (e *LeaseElector) tryAcquireOrRenew(ctx context.Context) {
+	leader, err := e.acquireOrRenew(ctx)
+	if err != nil {
+		e.logger.Warn("[leader] Error acquiring lease", "lease", e.name, "error", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if leader != e.isLeader {
+		e.logger.Info("[leader] Leadership changed", "lease", e.name, "identity", e.identity, "leader", leader)
+	}
+	e.isLeader = leader
+	if leader {
+		e.renewedAt = time.Now()
+	}
+}
+
+func (e *LeaseElector) acquireOrRenew(ctx context.Context) (bool, error) {
+	now := time.Now()
+
+	l, err := e.getLease(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if l == nil {
+		l = &lease{}
+		l.Metadata.Name = e.name
+		l.Metadata.Namespace = e.namespace
+		e.fillSpec(l, now, true)
+		return e.writeLease(ctx, http.MethodPost, l)
+	}
+
+	holder := ""
+	if l.Spec.HolderIdentity != nil {
+		holder = *l.Spec.HolderIdentity
+	}
+
+	if holder != e.identity && holder != "" && !e.isExpired(l, now) {
+		return false, nil
+	}
+
+	e.fillSpec(l, now, holder != e.identity)
+	return e.writeLease(ctx, http.MethodPut, l)
+}
+
+// isExpired checks if the lease held by another replica is expired. It uses the local time of the last
+// observed change instead of the renew time from the record, so it doesn't depend on clocks skew.
+func (e *LeaseElector) isExpired(l *lease, now time.Time) bool {
+	var holder, renewTime string
+	if l.Spec.HolderIdentity != nil {
+		holder = *l.Spec.HolderIdentity
+	}
+	if l.Spec.RenewTime != nil {
+		renewTime = *l.Spec.RenewTime
+	}
+	record := holder + "/" + renewTime
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if record != e.observedRecord {
+		e.observedRecord = record
+		e.observedAt = now
+	}
+
+	duration := e.leaseDuration
+	if l.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second
+	}
+
+	return now.After(e.observedAt.Add(duration))
+}
+
+func (e *LeaseElector) fillSpec(l *lease, now time.Time, acquire bool) {
+	ts := now.UTC().Format(microTimeLayout)
+	duration := int(e.leaseDuration.Seconds())
+	identity := e.identity
+
+	l.Spec.HolderIdentity = &identity
+	l.Spec.LeaseDurationSeconds = &duration
+	l.Spec.RenewTime = &ts
+	if acquire {
+		l.Spec.AcquireTime = &ts
+		l.Spec.LeaseTransitions++
+	}
+}
+
+// release gives up the lease, so other replicas don't need to wait for expiration.
+func (e *LeaseElector) release() {
+	e.mu.Lock()
+	wasLeader := e.isLeader
+	e.isLeader = false
+	e.mu.Unlock()
+
+	if !wasLeader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	l, err := e.getLease(ctx)
+	if err != nil || l == nil || l.Spec.HolderIdentity == nil || *l.Spec.HolderIdentity != e.identity {
+		return
+	}
+
+	empty := ""
+	l.Spec.HolderIdentity = &empty
+	if _, err := e.writeLease(ctx, http.MethodPut, l); err != nil {
+		e.logger.Warn("[leader] Error releasing lease", "lease", e.name, "error", err)
+	}
+}
+
+func (e *LeaseElector) leasesURL() string {
+	return fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", e.baseURL, e.namespace)
+}
+
+// getLease returns the current lease or nil if it doesn't exist yet.
+func (e *LeaseElector) getLease(ctx context.Context) (*lease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.leasesURL()+"/"+e.name, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lease request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+e.token)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil //nolint:nilnil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get lease: unexpected status %s", resp.Status)
+	}
+
+	var l lease
+	if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return nil, fmt.Errorf("failed to decode lease: %w", err)
+	}
+
+	return &l, nil
+}
+
+// writeLease creates (POST) or updates (PUT) the lease. Returns false without error
+// if another replica has updated the lease concurrently (conflict).
+func (e *LeaseElector) writeLease(ctx context.Context, method string, l *lease) (bool, error) {
+	l.APIVersion = "coordination.k8s.io/v1"
+	l.Kind = "Lease"
+
+	body, err := json.Marshal(l)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	url := e.leasesURL()
+	if method == http.MethodPut {
+		url += "/" + e.name
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create lease request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+e.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return false, errors.Join(errLeaseUpdate, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: unexpected status %s", errLeaseUpdate, resp.Status)
+	}
+}
+
+// lease is the minimal representation of the coordination.k8s.io/v1 Lease object.
+type lease struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		HolderIdentity       *string `json:"holderIdentity,omitempty"`
+		LeaseDurationSeconds *int    `json:"leaseDurationSeconds,omitempty"`
+		AcquireTime          *string `json:"acquireTime,omitempty"`
+		RenewTime            *string `json:"renewTime,omitempty"`
+		LeaseTransitions     int     `json:"leaseTransitions,omitempty"`
+	} `json:"spec"`
+}
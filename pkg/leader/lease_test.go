@@ -0,0 +1,100 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLeaseServer is a minimal in-memory implementation of the Kubernetes Lease API.
+type fakeLeaseServer struct {
+	mu    sync.Mutex
+	lease *lease
+}
+
+func (f *fakeLeaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(f.lease)
+	case http.MethodPost:
+		if f.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var l lease
+		_ = json.NewDecoder(r.Body).Decode(&l)
+		f.lease = &l
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPut:
+		var l lease
+		_ = json.NewDecoder(r.Body).Decode(&l)
+		f.lease = &l
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (f *fakeLeaseServer) holder() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lease == nil || f.lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *f.lease.Spec.HolderIdentity
+}
+
+func TestLeaseElector(t *testing.T) {
+	fake := &fakeLeaseServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	duration := 200 * time.Millisecond
+	first := newLeaseElector(server.Client(), server.URL, "token", "default", "fin-thread", "pod-1", duration)
+	second := newLeaseElector(server.Client(), server.URL, "token", "default", "fin-thread", "pod-2", duration)
+
+	// First replica creates the lease
+	first.tryAcquireOrRenew(ctx)
+	if err := first.IsLeader(ctx); err != nil {
+		t.Fatalf("first.IsLeader() error = %v, want nil", err)
+	}
+	if got := fake.holder(); got != "pod-1" {
+		t.Fatalf("lease holder = %v, want pod-1", got)
+	}
+
+	// Second replica can't take over the fresh lease
+	second.tryAcquireOrRenew(ctx)
+	if err := second.IsLeader(ctx); err == nil {
+		t.Fatalf("second.IsLeader() error = nil, want ErrNotLeader")
+	}
+
+	// After the lease expires without renewal, second replica takes over
+	time.Sleep(duration + 50*time.Millisecond)
+	second.tryAcquireOrRenew(ctx)
+	if err := second.IsLeader(ctx); err != nil {
+		t.Fatalf("second.IsLeader() error = %v, want nil", err)
+	}
+	if got := fake.holder(); got != "pod-2" {
+		t.Fatalf("lease holder = %v, want pod-2", got)
+	}
+
+	// Released lease is taken immediately
+	second.release()
+	if got := fake.holder(); got != "" {
+		t.Fatalf("lease holder after release = %v, want empty", got)
+	}
+	first.tryAcquireOrRenew(ctx)
+	if err := first.IsLeader(ctx); err != nil {
+		t.Fatalf("first.IsLeader() after release error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package logging provides the named loggers of the modules with their own levels, the text or JSON output
+// and the correlation IDs of the job runs, so the logs of one run can be grepped together by its run_id.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Names of the module loggers, see For.
+const (
+	ModuleMain       = "main"
+	ModuleJobs       = "jobs"
+	ModuleJournalist = "journalist"
+	ModuleComposer   = "composer"
+	ModulePublisher  = "publisher"
+	ModuleArchivist  = "archivist"
+	ModuleServer     = "server"
+	ModuleAdmin      = "admin"
+	ModuleLeader     = "leader"
+)
+
+// Output formats of the logs.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Attribute names added to the records.
+const (
+	KeyModule = "module" // name of the module logger
+	KeyRunID  = "run_id" // correlation ID of the job run, see WithRunID
+)
+
+// Config is the configuration of the loggers.
+type Config struct {
+	Level   slog.Level            // min level of the modules without their own level
+	Modules map[string]slog.Level // min levels of the modules by their names, e.g. {"journalist": slog.LevelDebug}
+	Format  string                // FormatText (default) or FormatJSON
+	Output  io.Writer             // destination of the logs, os.Stderr if nil
+}
+
+// state is the configuration the module loggers use at the moment of logging, so the loggers created
+// before Setup (e.g. by the package constructors) follow it too.
+type state struct {
+	handler slog.Handler
+	level   slog.Level
+	modules map[string]slog.Level
+}
+
+// current is the state of the loggers, the default slog handler until Setup.
+var current atomic.Pointer[state]
+
+func init() {
+	current.Store(&state{handler: slog.Default().Handler(), level: slog.LevelInfo})
+}
+
+// Setup configures the loggers of all modules and the default slog logger. Returns the error if the format is unknown.
+func Setup(c Config) error {
+	out := c.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	// Levels are checked by the module loggers, the handler passes everything
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	switch c.Format {
+	case "", FormatText:
+		handler = slog.NewTextHandler(out, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", c.Format)
+	}
+
+	current.Store(&state{handler: handler, level: c.Level, modules: c.Modules})
+	slog.SetDefault(slog.New(&moduleHandler{}))
+	return nil
+}
+
+// For returns the logger of the module. Its records have the module attribute and are filtered by the module level.
+func For(module string) *slog.Logger {
+	return slog.New(&moduleHandler{module: module})
+}
+
+// ParseLevel parses the level name: debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return l, nil
+}
+
+// ParseModules parses the comma-separated levels of the modules, e.g. "journalist=debug,composer=warn".
+func ParseModules(s string) (map[string]slog.Level, error) {
+	modules := make(map[string]slog.Level)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		module, level, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(module) == "" {
+			return nil, fmt.Errorf("invalid module level %q, expected module=level", item)
+		}
+		l, err := ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", module, err)
+		}
+		modules[strings.TrimSpace(module)] = l
+	}
+	return modules, nil
+}
+
+type runIDKey struct{}
+
+// WithRunID returns the context with the correlation ID of the job run. Records logged with the context
+// (e.g. logger.InfoContext) get the run_id attribute.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// WithNewRunID returns the context with the new correlation ID of the job run, see WithRunID.
+func WithNewRunID(ctx context.Context) context.Context {
+	return WithRunID(ctx, NewRunID())
+}
+
+// RunID returns the correlation ID of the job run of the context, empty if none.
+func RunID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// NewRunID returns the random correlation ID of the job run, e.g. "3f9c2a7d1b04e6a5".
+func NewRunID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// moduleHandler filters the records by the level of the module and passes them to the handler of the current state
+// with the module and run_id attributes.
+type moduleHandler struct {
+	module string
+	// WithAttrs and WithGroup calls applied to the current handler in order
+	ops []func(slog.Handler) slog.Handler
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, l slog.Level) bool {
+	s := current.Load()
+	if level, ok := s.modules[h.module]; ok {
+		return l >= level
+	}
+	return l >= s.level
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	handler := current.Load().handler
+	if h.module != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String(KeyModule, h.module)})
+	}
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	if id := RunID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRunID, id))
+	}
+	return handler.Handle(ctx, r) //nolint:wrapcheck
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *moduleHandler) with(op func(slog.Handler) slog.Handler) *moduleHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &moduleHandler{module: h.module, ops: append(ops, op)}
+}
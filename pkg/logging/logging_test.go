@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	var buf bytes.Buffer
+	err := Setup(Config{
+		Level:   slog.LevelInfo,
+		Modules: map[string]slog.Level{ModuleJournalist: slog.LevelDebug, ModuleComposer: slog.LevelWarn},
+		Format:  FormatJSON,
+		Output:  &buf,
+	})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	// The logger created before the records is configured by Setup as well
+	journalist := For(ModuleJournalist).With("journalist", "MarketNews")
+	ctx := WithRunID(context.Background(), "abc123")
+	journalist.DebugContext(ctx, "fetched", "news", 3)
+	For(ModuleComposer).Info("skipped by the module level")
+	For(ModuleJobs).Debug("skipped by the default level")
+	For(ModuleJobs).WithGroup("run").Info("published", "news", 1)
+	slog.Info("default logger")
+
+	var got []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("json.Unmarshal(%q) error = %v", line, err)
+		}
+		delete(record, "time")
+		got = append(got, record)
+	}
+
+	want := []map[string]any{
+		{"level": "DEBUG", "msg": "fetched", "module": "journalist", "journalist": "MarketNews", "news": 3.0, "run_id": "abc123"},
+		{"level": "INFO", "msg": "published", "module": "jobs", "run": map[string]any{"news": 1.0}},
+		{"level": "INFO", "msg": "default logger"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+}
+
+func TestSetup_unknownFormat(t *testing.T) {
+	if err := Setup(Config{Format: "xml"}); err == nil {
+		t.Error("Setup() error = nil, want error")
+	}
+}
+
+func TestParseModules(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    map[string]slog.Level
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			s:    "",
+			want: map[string]slog.Level{},
+		},
+		{
+			name: "levels",
+			s:    "journalist=debug, composer=WARN,",
+			want: map[string]slog.Level{"journalist": slog.LevelDebug, "composer": slog.LevelWarn},
+		},
+		{
+			name:    "missing level",
+			s:       "journalist",
+			wantErr: true,
+		},
+		{
+			name:    "invalid level",
+			s:       "journalist=verbose",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseModules(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseModules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseModules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	id := NewRunID()
+	if len(id) != 16 || id == NewRunID() {
+		t.Errorf("NewRunID() = %q, want unique 16 hex chars", id)
+	}
+	if got := RunID(WithNewRunID(context.Background())); len(got) != 16 {
+		t.Errorf("RunID() = %q, want 16 hex chars", got)
+	}
+}
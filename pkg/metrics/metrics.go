@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"time"
+)
+
+// Emitter is the abstraction over the metrics backends used by the app (StatsD/DogStatsD, etc.).
+// Implementations must be safe for concurrent use.
+type Emitter interface {
+	// Count increments the counter by the given value.
+	Count(name string, value int64, tags ...Tag)
+	// Gauge sets the gauge to the given value.
+	Gauge(name string, value float64, tags ...Tag)
+	// Timing records the duration of some operation.
+	Timing(name string, d time.Duration, tags ...Tag)
+}
+
+// Tag is a key-value pair attached to the metric (e.g. job name or provider name).
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// T is a shorthand for creating a new Tag.
+func T(key, value string) Tag {
+	return Tag{Key: key, Value: value}
+}
+
+// Noop is the Emitter that discards all metrics. It is used when no metrics backend is configured.
+type Noop struct{}
+
+func (Noop) Count(string, int64, ...Tag)          {}
+func (Noop) Gauge(string, float64, ...Tag)        {}
+func (Noop) Timing(string, time.Duration, ...Tag) {}
+
+// Multi is the Emitter that fans out all metrics to the list of emitters.
+type Multi []Emitter
+
+func (m Multi) Count(name string, value int64, tags ...Tag) {
+	for _, e := range m {
+		e.Count(name, value, tags...)
+	}
+}
+
+func (m Multi) Gauge(name string, value float64, tags ...Tag) {
+	for _, e := range m {
+		e.Gauge(name, value, tags...)
+	}
+}
+
+func (m Multi) Timing(name string, d time.Duration, tags ...Tag) {
+	for _, e := range m {
+		e.Timing(name, d, tags...)
+	}
+}
+
+// Metric names used across the app.
+const (
+	JobRuns          = "job.runs"          // Number of job runs
+	JobErrors        = "job.errors"        // Number of failed job stages
+	JobDuration      = "job.duration"      // Duration of the whole job run
+	NewsFetched      = "news.fetched"      // Number of news fetched by the journalist
+	NewsDuplicates   = "news.duplicates"   // Number of duplicated news removed
+	NewsComposed     = "news.composed"     // Number of news composed by the LLM
+	NewsUnverified   = "news.unverified"   // Number of composed texts failed the verification, tagged with the mode (flag, revert)
+	NewsPublished    = "news.published"    // Number of news published to the channel
+	LLMLatency       = "llm.latency"       // Latency of the LLM request
+	PublisherLatency = "publisher.latency" // Latency of the publisher request
+	PublisherSends   = "publisher.sends"   // Number of requests sent by the publisher, tagged with the status (ok, error)
+	ProviderFetched  = "provider.fetched"  // Number of news fetched from the provider
+	ProviderErrors   = "provider.errors"   // Number of failed provider fetches
+	ProviderLatency  = "provider.latency"  // Latency of the provider fetch
+	LLMTokens        = "llm.tokens"        // Number of LLM tokens used, tagged with the type (prompt, completion)
+	LLMAttempts      = "llm.attempts"      // Number of LLM requests of the fallback chain, tagged with the model and result (ok, error, malformed)
+	LLMServed        = "llm.served"        // Number of LLM requests answered by the model of the fallback chain, tagged with fallback (true, false)
+	DBLatency        = "db.latency"        // Latency of the database write, tagged with the table and operation
+)
@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultBuckets are the histogram buckets in seconds, from the DB writes to the slow LLM requests.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Prometheus is the Emitter that keeps the metrics in memory and exposes them in the Prometheus text format
+// (see Handler). Counts are exported as counters with the "_total" suffix, gauges as gauges and timings
+// as histograms in seconds with the "_seconds" suffix. Dots in the names are replaced with underscores,
+// e.g. "llm.latency" is exported as "finthread_llm_latency_seconds".
+type Prometheus struct {
+	namespace string
+	tags      []Tag // global tags attached to every metric
+	buckets   []float64
+
+	mu       sync.Mutex
+	families map[string]*promFamily // by the exported name
+}
+
+// promFamily is the metric with all its label sets.
+type promFamily struct {
+	kind   string // counter, gauge or histogram
+	series map[string]*promSeries
+}
+
+// promSeries is the metric value for the label set.
+type promSeries struct {
+	labels  string // formatted labels, e.g. `job="MarketNews",stage="compose"`
+	value   float64
+	buckets []uint64 // histogram counts per bucket (not cumulative)
+	count   uint64
+}
+
+// NewPrometheus creates a new Prometheus emitter with the namespace (e.g. "finthread") and the global tags.
+func NewPrometheus(namespace string, tags ...Tag) *Prometheus {
+	return &Prometheus{
+		namespace: namespace,
+		tags:      tags,
+		buckets:   defaultBuckets,
+		families:  make(map[string]*promFamily),
+	}
+}
+
+func (p *Prometheus) Count(name string, value int64, tags ...Tag) {
+	p.observe(p.metricName(name)+"_total", "counter", tags, func(s *promSeries) {
+		s.value += float64(value)
+	})
+}
+
+func (p *Prometheus) Gauge(name string, value float64, tags ...Tag) {
+	p.observe(p.metricName(name), "gauge", tags, func(s *promSeries) {
+		s.value = value
+	})
+}
+
+func (p *Prometheus) Timing(name string, d time.Duration, tags ...Tag) {
+	seconds := d.Seconds()
+	p.observe(p.metricName(name)+"_seconds", "histogram", tags, func(s *promSeries) {
+		if s.buckets == nil {
+			s.buckets = make([]uint64, len(p.buckets))
+		}
+		if i := sort.SearchFloat64s(p.buckets, seconds); i < len(p.buckets) {
+			s.buckets[i]++
+		}
+		s.value += seconds
+		s.count++
+	})
+}
+
+// observe updates the series of the metric with the given labels.
+func (p *Prometheus) observe(name, kind string, tags []Tag, update func(s *promSeries)) {
+	labels := formatLabels(slices.Concat(p.tags, tags))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, ok := p.families[name]
+	if !ok {
+		f = &promFamily{kind: kind, series: make(map[string]*promSeries)}
+		p.families[name] = f
+	}
+	s, ok := f.series[labels]
+	if !ok {
+		s = &promSeries{labels: labels}
+		f.series[labels] = s
+	}
+	update(s)
+}
+
+// Handler returns the HTTP handler that writes all metrics in the Prometheus text format.
+func (p *Prometheus) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(p.format()))
+	})
+}
+
+// format writes all metrics sorted by the name and labels.
+func (p *Prometheus) format() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.families))
+	for name := range p.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := p.families[name]
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			s := f.series[k]
+			if f.kind != "histogram" {
+				fmt.Fprintf(&b, "%s%s %s\n", name, wrapLabels(s.labels), formatFloat(s.value))
+				continue
+			}
+
+			var cumulative uint64
+			for i, le := range p.buckets {
+				cumulative += s.buckets[i]
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(s.labels, `le="`+formatFloat(le)+`"`)), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(s.labels, `le="+Inf"`)), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, wrapLabels(s.labels), formatFloat(s.value))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, wrapLabels(s.labels), s.count)
+		}
+	}
+
+	return b.String()
+}
+
+// metricName returns the exported name of the metric with the namespace.
+func (p *Prometheus) metricName(name string) string {
+	if p.namespace != "" {
+		name = p.namespace + "_" + name
+	}
+	return sanitizeName(name)
+}
+
+// sanitizeName replaces the characters that are not allowed in the Prometheus names with underscores.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// formatLabels formats the tags as the Prometheus labels sorted by the key.
+func formatLabels(tags []Tag) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	labels := make([]string, len(tags))
+	for i, t := range tags {
+		labels[i] = sanitizeName(t.Key) + `="` + labelValueReplacer.Replace(t.Value) + `"`
+	}
+	sort.Strings(labels)
+
+	return strings.Join(labels, ",")
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func joinLabels(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
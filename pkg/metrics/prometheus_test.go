@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheus_format(t *testing.T) {
+	p := NewPrometheus("finthread", T("server", "eu-1"))
+	p.Count(NewsFetched, 3, T("job", "MarketNews"))
+	p.Count(NewsFetched, 2, T("job", "MarketNews"))
+	p.Count(NewsFetched, 1, T("job", "BroadNews"))
+	p.Gauge("queue.size", 7)
+	p.Timing(LLMLatency, 300*time.Millisecond, T("stage", "compose"))
+	p.Timing(LLMLatency, 2*time.Second, T("stage", "compose"))
+
+	got := p.format()
+
+	for _, want := range []string{
+		"# TYPE finthread_news_fetched_total counter\n",
+		`finthread_news_fetched_total{job="BroadNews",server="eu-1"} 1` + "\n",
+		`finthread_news_fetched_total{job="MarketNews",server="eu-1"} 5` + "\n",
+		"# TYPE finthread_queue_size gauge\n",
+		`finthread_queue_size{server="eu-1"} 7` + "\n",
+		"# TYPE finthread_llm_latency_seconds histogram\n",
+		`finthread_llm_latency_seconds_bucket{server="eu-1",stage="compose",le="0.25"} 0` + "\n",
+		`finthread_llm_latency_seconds_bucket{server="eu-1",stage="compose",le="0.5"} 1` + "\n",
+		`finthread_llm_latency_seconds_bucket{server="eu-1",stage="compose",le="2.5"} 2` + "\n",
+		`finthread_llm_latency_seconds_bucket{server="eu-1",stage="compose",le="+Inf"} 2` + "\n",
+		`finthread_llm_latency_seconds_sum{server="eu-1",stage="compose"} 2.3` + "\n",
+		`finthread_llm_latency_seconds_count{server="eu-1",stage="compose"} 2` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("format() doesn't contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrometheus_Handler(t *testing.T) {
+	p := NewPrometheus("")
+	p.Count(JobRuns, 1, T("job", `Market "News"`))
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	body, _ := io.ReadAll(rec.Body)
+	if want := `job_runs_total{job="Market \"News\""} 1`; !strings.Contains(string(body), want) {
+		t.Errorf("body doesn't contain %q, got:\n%s", want, body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+}
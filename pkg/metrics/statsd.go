@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsD is the Emitter that sends metrics to the StatsD server over UDP.
+// Tags are sent in DogStatsD format, so it can be used with the Datadog agent as well.
+type StatsD struct {
+	conn   net.Conn
+	prefix string // prefix for all metrics, e.g. "finthread."
+	tags   []Tag  // global tags attached to every metric
+	mu     sync.Mutex
+}
+
+// NewStatsD creates a new StatsD emitter for the given address (host:port).
+func NewStatsD(addr, prefix string, tags ...Tag) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", addr, err)
+	}
+
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	return &StatsD{
+		conn:   conn,
+		prefix: prefix,
+		tags:   tags,
+	}, nil
+}
+
+func (s *StatsD) Count(name string, value int64, tags ...Tag) {
+	s.send(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+func (s *StatsD) Gauge(name string, value float64, tags ...Tag) {
+	s.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+func (s *StatsD) Timing(name string, d time.Duration, tags ...Tag) {
+	s.send(name, strconv.FormatInt(d.Milliseconds(), 10), "ms", tags)
+}
+
+// Close closes the underlying UDP connection.
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+// send writes a single packet to the server. Errors are ignored, because metrics are best-effort.
+func (s *StatsD) send(name, value, metricType string, tags []Tag) {
+	packet := formatPacket(s.prefix+name, value, metricType, slices.Concat(s.tags, tags))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.conn.Write([]byte(packet))
+}
+
+// formatPacket formats the metric in DogStatsD format: `name:value|type|#key:value,key:value`.
+func formatPacket(name, value, metricType string, tags []Tag) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteString(":")
+	b.WriteString(value)
+	b.WriteString("|")
+	b.WriteString(metricType)
+
+	if len(tags) > 0 {
+		b.WriteString("|#")
+		for i, t := range tags {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(t.Key)
+			b.WriteString(":")
+			b.WriteString(t.Value)
+		}
+	}
+
+	return b.String()
+}
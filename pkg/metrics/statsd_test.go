@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_formatPacket(t *testing.T) {
+	type args struct {
+		name       string
+		value      string
+		metricType string
+		tags       []Tag
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "counter without tags",
+			args: args{name: "job.runs", value: "1", metricType: "c"},
+			want: "job.runs:1|c",
+		},
+		{
+			name: "timing with tags",
+			args: args{
+				name:       "llm.latency",
+				value:      "250",
+				metricType: "ms",
+				tags:       []Tag{T("job", "MarketNews"), T("stage", "compose")},
+			},
+			want: "llm.latency:250|ms|#job:MarketNews,stage:compose",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatPacket(tt.args.name, tt.args.value, tt.args.metricType, tt.args.tags); got != tt.want {
+				t.Errorf("formatPacket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsD_Count(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer pc.Close()
+
+	s, err := NewStatsD(pc.LocalAddr().String(), "finthread", T("env", "test"))
+	if err != nil {
+		t.Fatalf("NewStatsD() error = %v", err)
+	}
+	defer s.Close()
+
+	s.Count(NewsPublished, 3, T("job", "BroadNews"))
+
+	buf := make([]byte, 512)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+
+	want := "finthread.news.published:3|c|#env:test,job:BroadNews"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("Count() sent = %v, want %v", got, want)
+	}
+}
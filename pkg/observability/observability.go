@@ -0,0 +1,88 @@
+// Package observability attaches the context of the work to the Sentry transactions, errors and breadcrumbs,
+// so all modules use the same tag names and the events can be searched by them.
+package observability
+
+import (
+	"github.com/getsentry/sentry-go"
+	"sort"
+	"strings"
+)
+
+// Tag names shared by the modules.
+const (
+	TagJob             = "job"              // name of the job, e.g. "MarketNews"
+	TagJournalist      = "journalist"       // name of the journalist of the job
+	TagProviders       = "providers"        // comma-separated names of the news providers
+	TagFailedProviders = "failed_providers" // comma-separated names of the providers that failed to fetch
+	TagChannel         = "channel"          // chat ID or name of the channel the news are published to
+	TagFlags           = "flags"            // comma-separated enabled options of the job
+	TagNewsHash        = "news_hash"        // hash of the news
+	TagLanguage        = "language"         // language of the translation
+	TagTicker          = "ticker"           // ticker of the stock
+	TagRunID           = "run_id"           // correlation ID of the job run, the same as in the logs
+)
+
+// Tags are the Sentry tags by the name, empty values are not set.
+type Tags map[string]string
+
+// Tag sets the tags on the transaction and on the scope of the hub, so the errors captured by the hub
+// within the transaction have the same tags. Either of tx and hub can be nil.
+func Tag(tx *sentry.Span, hub *sentry.Hub, tags Tags) {
+	for name, value := range tags {
+		if value == "" {
+			continue
+		}
+		if tx != nil {
+			tx.SetTag(name, value)
+		}
+		if hub != nil {
+			hub.Scope().SetTag(name, value)
+		}
+	}
+}
+
+// SetContext sets the tags as the structured context of the events captured by the hub, e.g. the "job" context.
+func SetContext(hub *sentry.Hub, name string, tags Tags) {
+	if hub == nil {
+		return
+	}
+	hub.Scope().SetContext(name, tagsData(tags))
+}
+
+// Breadcrumb returns the breadcrumb with the tags as its data.
+func Breadcrumb(category, message string, level sentry.Level, tags Tags) *sentry.Breadcrumb {
+	return &sentry.Breadcrumb{
+		Category: category,
+		Message:  message,
+		Level:    level,
+		Data:     tagsData(tags),
+	}
+}
+
+// tagsData returns the tags with values as the event data.
+func tagsData(tags Tags) map[string]interface{} {
+	data := make(map[string]interface{}, len(tags))
+	for name, value := range tags {
+		if value != "" {
+			data[name] = value
+		}
+	}
+	return data
+}
+
+// Flags returns the sorted comma-separated names of the enabled flags, e.g. "compose_text,save_to_db".
+func Flags(flags map[string]bool) string {
+	enabled := make([]string, 0, len(flags))
+	for name, on := range flags {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return strings.Join(enabled, ",")
+}
+
+// List returns the tag value of the list, e.g. "rss,reddit".
+func List(values []string) string {
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,69 @@
+package observability
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"reflect"
+	"testing"
+)
+
+func TestTag(t *testing.T) {
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	tx := sentry.StartTransaction(context.Background(), "test")
+	tags := Tags{TagJob: "MarketNews", TagChannel: "@channel", TagFlags: ""}
+
+	Tag(tx, hub, tags)
+	SetContext(hub, "job", tags)
+
+	want := map[string]string{TagJob: "MarketNews", TagChannel: "@channel"}
+	if !reflect.DeepEqual(tx.Tags, want) {
+		t.Errorf("Tag() transaction tags = %v, want %v", tx.Tags, want)
+	}
+	event := hub.Scope().ApplyToEvent(&sentry.Event{}, nil)
+	if !reflect.DeepEqual(event.Tags, want) {
+		t.Errorf("Tag() scope tags = %v, want %v", event.Tags, want)
+	}
+	if got := event.Contexts["job"]; len(got) != 2 || got[TagJob] != "MarketNews" {
+		t.Errorf("SetContext() job context = %v, want job and channel", got)
+	}
+
+	// Nil transaction and hub are skipped
+	Tag(nil, nil, tags)
+	SetContext(nil, "job", tags)
+}
+
+func TestBreadcrumb(t *testing.T) {
+	b := Breadcrumb("job", "Job started", sentry.LevelInfo, Tags{TagJob: "MarketNews", TagProviders: ""})
+	if b.Category != "job" || b.Message != "Job started" || b.Level != sentry.LevelInfo {
+		t.Errorf("Breadcrumb() = %+v, want job category, message and info level", b)
+	}
+	if want := map[string]interface{}{TagJob: "MarketNews"}; !reflect.DeepEqual(b.Data, want) {
+		t.Errorf("Breadcrumb() data = %v, want %v", b.Data, want)
+	}
+}
+
+func TestFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags map[string]bool
+		want  string
+	}{
+		{
+			name:  "enabled flags are sorted",
+			flags: map[string]bool{"save_to_db": true, "compose_text": true, "breaking": false},
+			want:  "compose_text,save_to_db",
+		},
+		{
+			name:  "no enabled flags",
+			flags: map[string]bool{"breaking": false},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Flags(tt.flags); got != tt.want {
+				t.Errorf("Flags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+// Package rules evaluates operator-defined expressions (https://expr-lang.org) against the news
+// to filter, flag, prioritise and route them to channels at runtime, without recompiling the app.
+//
+// Rules of the fetch stage are evaluated right after fetching, before the news reach the LLM,
+// so they can only match the original title, description and provider. Rules of the publish stage
+// are evaluated before publishing and can also match the composed text, tickers, markets, hashtags and entities.
+//
+// Example rules:
+//
+//	{"name": "edgar-8k", "when": "news.provider == \"edgar\" && news.title contains \"8-K\"", "priority": 10}
+//	{"name": "promo", "stage": "fetch", "providers": ["prnewswire"], "keywords": ["sponsored"], "flag": true}
+//	{"name": "earnings", "when": "news.title matches \"(?i)q[1-4] earnings\" || \"NVDA\" in news.tickers", "channel": "@earnings"}
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Stages of the job the rules are evaluated at.
+const (
+	StageFetch   = "fetch"   // after fetching, before the news are composed by the LLM
+	StagePublish = "publish" // after composing, before publishing (default)
+)
+
+// Rule is a single rule from the configuration. The rule matches the news of the Providers (all if empty)
+// containing any of the Keywords (if set) and satisfying the When expression (if set).
+// Other fields are the actions applied to the matched news.
+type Rule struct {
+	Name      string   `json:"name" yaml:"name"`           // Name of the rule (for logging purposes)
+	Stage     string   `json:"stage" yaml:"stage"`         // StageFetch or StagePublish (default)
+	Providers []string `json:"providers" yaml:"providers"` // Names of the providers the rule applies to, all if empty
+	Keywords  []string `json:"keywords" yaml:"keywords"`   // Whole words or phrases in the title or description (case-insensitive)
+	When      string   `json:"when" yaml:"when"`           // Boolean expression, e.g. `news.provider == "edgar"`
+	Drop      bool     `json:"drop" yaml:"drop"`           // If true, matched news will not be published
+	Flag      bool     `json:"flag" yaml:"flag"`           // If true, matched news are flagged as suspicious (fetch stage only)
+	Priority  int      `json:"priority" yaml:"priority"`   // Added to the news priority, news with higher priority are published first (publish stage only)
+	Channel   string   `json:"channel" yaml:"channel"`     // Channel ID to publish matched news to instead of the default one (publish stage only)
+}
+
+// News is the news representation available in the expressions as `news`.
+type News struct {
+	Job         string    `expr:"job"`         // Name of the job (journalist) that fetched the news
+	Provider    string    `expr:"provider"`    // Name of the news provider
+	Title       string    `expr:"title"`       // Original title
+	Description string    `expr:"description"` // Original description
+	Text        string    `expr:"text"`        // Composed text
+	URL         string    `expr:"url"`         // URL of the original news
+	Date        time.Time `expr:"date"`        // Original date
+	Suspicious  bool      `expr:"suspicious"`  // True if the news contains suspicious keywords
+	Unverified  bool      `expr:"unverified"`  // True if the composed text failed the verification (publish stage only)
+	Tickers     []string  `expr:"tickers"`     // Tickers found by the composer
+	Markets     []string  `expr:"markets"`     // Markets found by the composer
+	Hashtags    []string  `expr:"hashtags"`    // Hashtags found by the composer
+	Entities    []string  `expr:"entities"`    // Companies, people, central banks and countries found by the composer
+}
+
+// env is the root of the expression environment.
+type env struct {
+	News News `expr:"news"`
+}
+
+// Decision is the combined result of all matched rules.
+type Decision struct {
+	Drop     bool     // True if any matched rule drops the news
+	Flag     bool     // True if any matched rule flags the news as suspicious
+	Priority int      // Sum of priorities of matched rules
+	Channel  string   // Channel of the first matched rule with channel set
+	Matched  []string // Names of matched rules
+}
+
+type compiledRule struct {
+	Rule
+	keywords *regexp.Regexp
+	program  *vm.Program
+}
+
+// Set is the compiled list of rules. Rules are evaluated in the configuration order.
+type Set struct {
+	rules []compiledRule
+}
+
+// Parse parses rules from the JSON array and compiles them.
+func Parse(str string) (*Set, error) {
+	var rules []Rule
+	if err := json.Unmarshal([]byte(str), &rules); err != nil {
+		return nil, fmt.Errorf("error unmarshalling rules: %w", err)
+	}
+
+	return Compile(rules)
+}
+
+// LoadFile reads the rules from the YAML (or JSON) file with the list of rules. Rules are not compiled,
+// so they can be combined with the other ones before Compile.
+func LoadFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rules file: %w", err)
+	}
+
+	var rules []Rule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error unmarshalling rules file: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Compile compiles the rules, so syntax and type errors are found at startup.
+func Compile(rules []Rule) (*Set, error) {
+	s := &Set{rules: make([]compiledRule, 0, len(rules))}
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		if r.Stage == "" {
+			r.Stage = StagePublish
+		}
+
+		c := compiledRule{Rule: r}
+		if len(r.Keywords) > 0 {
+			c.keywords = keywordsRegexp(r.Keywords)
+		}
+		if r.When != "" {
+			program, err := expr.Compile(r.When, expr.Env(env{}), expr.AsBool())
+			if err != nil {
+				return nil, fmt.Errorf("error compiling rule %s: %w", r.Name, err)
+			}
+			c.program = program
+		}
+		s.rules = append(s.rules, c)
+	}
+
+	return s, nil
+}
+
+// validate checks that the rule has the name and the condition, and its actions are supported by the stage.
+func (r Rule) validate() error {
+	if r.Name == "" || (r.When == "" && len(r.Keywords) == 0) {
+		return errors.New("rule name and expression or keywords are required")
+	}
+
+	switch r.Stage {
+	case StageFetch:
+		if r.Priority != 0 || r.Channel != "" {
+			return fmt.Errorf("rule %s: priority and channel are not supported by the %s stage", r.Name, StageFetch)
+		}
+	case StagePublish, "":
+		if r.Flag {
+			return fmt.Errorf("rule %s: flag is only supported by the %s stage", r.Name, StageFetch)
+		}
+	default:
+		return fmt.Errorf("rule %s: unknown stage %q, must be %s or %s", r.Name, r.Stage, StageFetch, StagePublish)
+	}
+
+	return nil
+}
+
+// keywordsRegexp returns the case-insensitive regexp matching any of the keywords as the whole word.
+// Keywords without letters and digits (e.g. "?") are matched anywhere.
+func keywordsRegexp(keywords []string) *regexp.Regexp {
+	symbolsOnlyRe := regexp.MustCompile("^[^a-zA-Z0-9]*$")
+
+	patterns := make([]string, len(keywords))
+	for i, k := range keywords {
+		if symbolsOnlyRe.MatchString(k) {
+			patterns[i] = regexp.QuoteMeta(k)
+		} else {
+			patterns[i] = `\b` + regexp.QuoteMeta(k) + `\b`
+		}
+	}
+
+	return regexp.MustCompile(`(?i)` + strings.Join(patterns, "|"))
+}
+
+// Len returns the number of rules in the Set. It is safe to call on nil Set.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// Stage returns the rules of the stage (StageFetch or StagePublish) in the same order.
+// Returns nil if there are no rules of the stage. It is safe to call on nil Set.
+func (s *Set) Stage(stage string) *Set {
+	if s == nil {
+		return nil
+	}
+
+	var staged []compiledRule
+	for _, r := range s.rules {
+		if r.Stage == stage {
+			staged = append(staged, r)
+		}
+	}
+	if len(staged) == 0 {
+		return nil
+	}
+
+	return &Set{rules: staged}
+}
+
+// Evaluate runs all rules against the news and combines the actions of the matched ones.
+// Rules that fail at runtime are skipped and their errors are returned along with the decision.
+func (s *Set) Evaluate(n News) (Decision, error) {
+	var d Decision
+	if s == nil {
+		return d, nil
+	}
+
+	var errs []error
+	for _, r := range s.rules {
+		matched, err := r.match(n)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error evaluating rule %s: %w", r.Name, err))
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		d.Matched = append(d.Matched, r.Name)
+		d.Drop = d.Drop || r.Drop
+		d.Flag = d.Flag || r.Flag
+		d.Priority += r.Priority
+		if d.Channel == "" {
+			d.Channel = r.Channel
+		}
+	}
+
+	return d, errors.Join(errs...)
+}
+
+// match returns true if the news is of the rule providers, contains the keywords and satisfies the expression.
+func (r compiledRule) match(n News) (bool, error) {
+	if len(r.Providers) > 0 && !slices.Contains(r.Providers, n.Provider) {
+		return false, nil
+	}
+	if r.keywords != nil && !r.keywords.MatchString(n.Title+" "+n.Description) {
+		return false, nil
+	}
+	if r.program == nil {
+		return true, nil
+	}
+
+	out, err := expr.Run(r.program, env{News: n})
+	if err != nil {
+		return false, err
+	}
+	matched, _ := out.(bool)
+	return matched, nil
+}
@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "valid rules",
+			str:     `[{"name": "edgar", "when": "news.provider == \"edgar\"", "priority": 1}]`,
+			wantLen: 1,
+		},
+		{
+			name:    "empty list",
+			str:     `[]`,
+			wantLen: 0,
+		},
+		{
+			name:    "invalid json",
+			str:     `{`,
+			wantErr: true,
+		},
+		{
+			name:    "syntax error",
+			str:     `[{"name": "broken", "when": "news.provider =="}]`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			str:     `[{"name": "unknown", "when": "news.author == \"me\""}]`,
+			wantErr: true,
+		},
+		{
+			name:    "non boolean expression",
+			str:     `[{"name": "string", "when": "news.title"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			str:     `[{"when": "true"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "keywords without expression",
+			str:     `[{"name": "promo", "stage": "fetch", "keywords": ["sponsored"], "flag": true}]`,
+			wantLen: 1,
+		},
+		{
+			name:    "missing expression and keywords",
+			str:     `[{"name": "empty", "drop": true}]`,
+			wantErr: true,
+		},
+		{
+			name:    "flag in publish stage",
+			str:     `[{"name": "flag", "when": "true", "flag": true}]`,
+			wantErr: true,
+		},
+		{
+			name:    "channel in fetch stage",
+			str:     `[{"name": "route", "stage": "fetch", "when": "true", "channel": "@crypto"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown stage",
+			str:     `[{"name": "compose", "stage": "compose", "when": "true"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Len() != tt.wantLen {
+				t.Errorf("Parse() len = %d, want %d", got.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestSet_Evaluate(t *testing.T) {
+	set, err := Compile([]Rule{
+		{Name: "edgar-8k", When: `news.provider == "edgar" && news.title contains "8-K"`, Priority: 10, Channel: "@filings"},
+		{Name: "tesla", When: `"TSLA" in news.tickers`, Priority: 5, Channel: "@tesla"},
+		{Name: "crypto", When: `news.title matches "(?i)bitcoin"`, Drop: true},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		set  *Set
+		news News
+		want Decision
+	}{
+		{
+			name: "multiple matched rules",
+			set:  set,
+			news: News{Provider: "edgar", Title: "Tesla files 8-K", Tickers: []string{"TSLA"}},
+			want: Decision{Priority: 15, Channel: "@filings", Matched: []string{"edgar-8k", "tesla"}},
+		},
+		{
+			name: "drop rule",
+			set:  set,
+			news: News{Provider: "rss", Title: "Bitcoin is up"},
+			want: Decision{Drop: true, Matched: []string{"crypto"}},
+		},
+		{
+			name: "no matched rules",
+			set:  set,
+			news: News{Provider: "rss", Title: "Markets are flat"},
+			want: Decision{},
+		},
+		{
+			name: "nil set",
+			set:  nil,
+			news: News{Title: "Anything"},
+			want: Decision{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.set.Evaluate(tt.news)
+			if err != nil {
+				t.Errorf("Evaluate() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Stage(t *testing.T) {
+	set, err := Compile([]Rule{
+		{Name: "suspicious", Stage: StageFetch, Keywords: []string{"united States", "?"}, Flag: true},
+		{Name: "promo", Stage: StageFetch, Providers: []string{"prnewswire"}, Keywords: []string{"i'm"}, Drop: true},
+		{Name: "reddit", Stage: StageFetch, Providers: []string{"reddit"}, When: `news.title matches "(?i)yolo|moon"`, Drop: true},
+		{Name: "tesla", When: `"TSLA" in news.tickers`, Priority: 5},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	fetch := set.Stage(StageFetch)
+	if fetch.Len() != 3 || set.Stage(StagePublish).Len() != 1 {
+		t.Fatalf("Stage() len = %d/%d, want 3/1", fetch.Len(), set.Stage(StagePublish).Len())
+	}
+
+	tests := []struct {
+		name string
+		news News
+		want Decision
+	}{
+		{
+			name: "keyword is matched case-insensitive",
+			news: News{Provider: "rss", Title: "Some news about United States"},
+			want: Decision{Flag: true, Matched: []string{"suspicious"}},
+		},
+		{
+			name: "keyword is matched as the whole word",
+			news: News{Provider: "rss", Title: "Some news about kek", Description: "Read more about the united statesman"},
+			want: Decision{},
+		},
+		{
+			name: "symbol keyword is matched anywhere",
+			news: News{Provider: "rss", Title: "Rates are up or not?"},
+			want: Decision{Flag: true, Matched: []string{"suspicious"}},
+		},
+		{
+			name: "keyword of the provider",
+			news: News{Provider: "prnewswire", Description: "I'm not a cat, says the lawyer"},
+			want: Decision{Drop: true, Matched: []string{"promo"}},
+		},
+		{
+			name: "keyword of the other provider",
+			news: News{Provider: "rss", Description: "I'm not a cat, says the lawyer"},
+			want: Decision{},
+		},
+		{
+			name: "expression of the provider",
+			news: News{Provider: "reddit", Title: "GME to the MOON"},
+			want: Decision{Drop: true, Matched: []string{"reddit"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fetch.Evaluate(tt.news)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	data := `- name: promo
+  stage: fetch
+  providers: [prnewswire]
+  keywords: [sponsored, advertorial]
+  flag: true
+- name: earnings
+  when: news.title matches "(?i)earnings"
+  channel: "@earnings"
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := []Rule{
+		{Name: "promo", Stage: StageFetch, Providers: []string{"prnewswire"}, Keywords: []string{"sponsored", "advertorial"}, Flag: true},
+		{Name: "earnings", When: `news.title matches "(?i)earnings"`, Channel: "@earnings"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFile() = %+v, want %+v", got, want)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile() of the missing file error = nil")
+	}
+}
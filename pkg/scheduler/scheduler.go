@@ -0,0 +1,252 @@
+// Package scheduler runs the jobs of the app on the cron or interval schedules with the random jitter
+// and keeps the metadata of their runs (next run, last duration) for the admin commands.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/robfig/cron/v3"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Definition is the schedule of the job. Exactly one of Cron, Every or Once must be set.
+type Definition struct {
+	Name  string        // unique name of the job, e.g. "MarketNews"
+	Cron  string        // standard cron expression, e.g. "*/5 * * * 1-5"
+	Every time.Duration // interval between the runs
+	Once  bool          // run once right after the start
+	// Max random delay of each run, spreads the requests of the jobs with the same schedule.
+	// Must be shorter than the interval of the Every jobs.
+	Jitter time.Duration
+	// Start the run even if the previous run is still running, the run is skipped by default
+	Overlap bool
+}
+
+// schedule returns the schedule in one line: the cron expression, "every 1m0s" or "once".
+func (d Definition) schedule() string {
+	switch {
+	case d.Cron != "":
+		return d.Cron
+	case d.Every > 0:
+		return fmt.Sprintf("every %s", d.Every)
+	default:
+		return "once"
+	}
+}
+
+// validate checks that the definition has exactly one schedule and the jitter fits it.
+func (d Definition) validate() error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+
+	set := 0
+	for _, ok := range []bool{d.Cron != "", d.Every != 0, d.Once} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of cron, every or once is required")
+	}
+
+	if d.Cron != "" {
+		if _, err := cron.ParseStandard(d.Cron); err != nil {
+			return fmt.Errorf("invalid cron: %w", err)
+		}
+	}
+	if d.Every < 0 {
+		return errors.New("every must be positive")
+	}
+	if d.Jitter < 0 || (d.Every > 0 && d.Jitter >= d.Every) {
+		return errors.New("jitter must be positive and shorter than every")
+	}
+
+	return nil
+}
+
+// JobInfo is the metadata of the scheduled job and its runs.
+type JobInfo struct {
+	Name         string
+	Schedule     string        // cron expression, "every 1m0s" or "once"
+	NextRun      time.Time     // zero if there are no more runs or the scheduler is not started
+	LastRun      time.Time     // start of the last run (after the jitter), zero if there were no runs yet
+	LastDuration time.Duration // duration of the last finished run
+	Running      bool          // the job is running or waiting for the jitter
+	Runs         int           // number of the finished runs
+	Skipped      int           // number of the runs skipped because the previous run was still running
+}
+
+// Scheduler runs the jobs on their schedules. Runs of the same job don't overlap unless Definition.Overlap is set:
+// the run is skipped if the previous one is still running.
+type Scheduler struct {
+	scheduler gocron.Scheduler
+	ctx       context.Context // canceled on Shutdown to stop waiting for the jitter
+	cancel    context.CancelFunc
+
+	mu   sync.RWMutex
+	jobs []*job
+}
+
+// job is the scheduled job with the metadata of its runs.
+type job struct {
+	def  Definition
+	task func()
+	cron gocron.Job
+
+	mu           sync.Mutex
+	running      int
+	lastRun      time.Time
+	lastDuration time.Duration
+	runs         int
+	skipped      int
+}
+
+// New creates a new Scheduler, the options are passed to the underlying gocron scheduler
+// (e.g. gocron.WithDistributedElector).
+func New(options ...gocron.SchedulerOption) (*Scheduler, error) {
+	s, err := gocron.NewScheduler(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduler: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		scheduler: s,
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Add schedules the task. Jobs can be added before and after Start.
+func (s *Scheduler) Add(def Definition, task func()) error {
+	if err := def.validate(); err != nil {
+		return fmt.Errorf("job %s: %w", def.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.jobs, func(j *job) bool { return j.def.Name == def.Name }) {
+		return fmt.Errorf("job %s: duplicate name", def.Name)
+	}
+
+	var definition gocron.JobDefinition
+	switch {
+	case def.Cron != "":
+		definition = gocron.CronJob(def.Cron, false)
+	case def.Every > 0:
+		definition = gocron.DurationJob(def.Every)
+	default:
+		definition = gocron.OneTimeJob(gocron.OneTimeJobStartImmediately())
+	}
+
+	j := &job{def: def, task: task}
+	cronJob, err := s.scheduler.NewJob(
+		definition,
+		gocron.NewTask(func() { j.run(s.ctx) }),
+		gocron.WithName(def.Name),
+	)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", def.Name, err)
+	}
+	j.cron = cronJob
+	s.jobs = append(s.jobs, j)
+
+	return nil
+}
+
+// Start starts running the jobs in the background.
+func (s *Scheduler) Start() {
+	s.scheduler.Start()
+}
+
+// Shutdown stops the scheduler and waits for the running jobs (up to the gocron stop timeout).
+// Runs waiting for the jitter are canceled.
+func (s *Scheduler) Shutdown() error {
+	s.cancel()
+	return s.scheduler.Shutdown() //nolint:wrapcheck
+}
+
+// Jobs returns the metadata of the scheduled jobs sorted by their names.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		infos = append(infos, j.info())
+	}
+	slices.SortFunc(infos, func(a, b JobInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return infos
+}
+
+// run executes the task after the random jitter, unless the previous run is still running.
+func (j *job) run(ctx context.Context) {
+	j.mu.Lock()
+	if j.running > 0 && !j.def.Overlap {
+		j.skipped++
+		j.mu.Unlock()
+		return
+	}
+	j.running++
+	j.mu.Unlock()
+
+	if j.def.Jitter > 0 {
+		select {
+		case <-ctx.Done():
+			j.mu.Lock()
+			j.running--
+			j.mu.Unlock()
+			return
+		case <-time.After(rand.N(j.def.Jitter)):
+		}
+	}
+
+	start := time.Now()
+	j.mu.Lock()
+	j.lastRun = start
+	j.mu.Unlock()
+
+	defer func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.running--
+		j.runs++
+		j.lastDuration = time.Since(start)
+	}()
+
+	j.task()
+}
+
+// info returns the metadata of the job.
+func (j *job) info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := JobInfo{
+		Name:         j.def.Name,
+		Schedule:     j.def.schedule(),
+		LastRun:      j.lastRun,
+		LastDuration: j.lastDuration,
+		Running:      j.running > 0,
+		Runs:         j.runs,
+		Skipped:      j.skipped,
+	}
+	if j.cron != nil {
+		if next, err := j.cron.NextRun(); err == nil {
+			info.NextRun = next
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     Definition
+		wantErr string
+	}{
+		{
+			name: "cron",
+			def:  Definition{Name: "MarketNews", Cron: "*/5 * * * 1-5", Jitter: time.Minute},
+		},
+		{
+			name: "every",
+			def:  Definition{Name: "MarketNews", Every: time.Minute, Jitter: 10 * time.Second},
+		},
+		{
+			name: "once",
+			def:  Definition{Name: "Recovery", Once: true},
+		},
+		{
+			name:    "without name",
+			def:     Definition{Every: time.Minute},
+			wantErr: "name is required",
+		},
+		{
+			name:    "without schedule",
+			def:     Definition{Name: "MarketNews"},
+			wantErr: "exactly one of cron, every or once",
+		},
+		{
+			name:    "cron and every",
+			def:     Definition{Name: "MarketNews", Cron: "* * * * *", Every: time.Minute},
+			wantErr: "exactly one of cron, every or once",
+		},
+		{
+			name:    "invalid cron",
+			def:     Definition{Name: "MarketNews", Cron: "every minute"},
+			wantErr: "invalid cron",
+		},
+		{
+			name:    "jitter longer than interval",
+			def:     Definition{Name: "MarketNews", Every: time.Minute, Jitter: time.Minute},
+			wantErr: "jitter must be positive and shorter than every",
+		},
+		{
+			name:    "duplicate name",
+			def:     Definition{Name: "Existing", Every: time.Minute},
+			wantErr: "duplicate name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New()
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := s.Add(Definition{Name: "Existing", Every: time.Hour}, func() {}); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			err = s.Add(tt.def, func() {})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Add() error = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Add() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduler_Jobs(t *testing.T) {
+	s, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = s.Shutdown() }()
+
+	ran := make(chan struct{})
+	if err := s.Add(Definition{Name: "Recovery", Once: true}, func() { close(ran) }); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add(Definition{Name: "Calendar", Cron: "0 4 * * 1-5"}, func() {}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	s.Start()
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("one time job didn't run")
+	}
+	// The metadata is updated after the task returns
+	time.Sleep(50 * time.Millisecond)
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 || jobs[0].Name != "Calendar" || jobs[1].Name != "Recovery" {
+		t.Fatalf("Jobs() = %+v, want Calendar and Recovery", jobs)
+	}
+	if jobs[0].Schedule != "0 4 * * 1-5" || jobs[0].NextRun.IsZero() || !jobs[0].LastRun.IsZero() {
+		t.Errorf("Jobs() Calendar = %+v, want the next run without runs", jobs[0])
+	}
+	if jobs[1].Schedule != "once" || jobs[1].Runs != 1 || jobs[1].LastRun.IsZero() || jobs[1].Running {
+		t.Errorf("Jobs() Recovery = %+v, want one finished run", jobs[1])
+	}
+}
+
+func Test_job_run(t *testing.T) {
+	tests := []struct {
+		name        string
+		def         Definition
+		wantRuns    int
+		wantSkipped int
+	}{
+		{
+			name:        "overlapping run is skipped",
+			def:         Definition{Name: "MarketNews", Every: time.Minute},
+			wantRuns:    1,
+			wantSkipped: 1,
+		},
+		{
+			name:     "overlapping run is allowed",
+			def:      Definition{Name: "MarketNews", Every: time.Minute, Overlap: true},
+			wantRuns: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+			j := &job{def: tt.def, task: func() {
+				started <- struct{}{}
+				<-release
+			}}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				j.run(context.Background())
+			}()
+			<-started
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				j.run(context.Background())
+			}()
+			if tt.def.Overlap {
+				<-started
+			} else {
+				// The second run returns at once without waiting for the first one
+				time.Sleep(50 * time.Millisecond)
+			}
+			if info := j.info(); !info.Running {
+				t.Errorf("info() Running = false, want true")
+			}
+
+			close(release)
+			wg.Wait()
+
+			info := j.info()
+			if info.Runs != tt.wantRuns || info.Skipped != tt.wantSkipped || info.Running {
+				t.Errorf("info() = %+v, want %d runs and %d skipped", info, tt.wantRuns, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func Test_job_runJitter(t *testing.T) {
+	ran := false
+	j := &job{def: Definition{Name: "MarketNews", Every: time.Hour, Jitter: 30 * time.Minute}, task: func() { ran = true }}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	j.run(ctx)
+
+	if info := j.info(); ran || info.Running || info.Runs != 0 {
+		t.Errorf("run() with canceled jitter = %+v, ran %v, want no runs", info, ran)
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_signingKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	got := hex.EncodeToString(signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got != want {
+		t.Errorf("signingKey() = %v, want %v", got, want)
+	}
+}
+
+func TestS3_PutObject(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := NewS3(server.URL, "us-east-1", "backups", "AKID", "secret")
+	err := s.PutObject(context.Background(), "exports/news.jsonl.gz", []byte("data"), "application/gzip")
+	if err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	if gotPath != "/backups/exports/news.jsonl.gz" {
+		t.Errorf("PutObject() path = %v, want /backups/exports/news.jsonl.gz", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("PutObject() authorization = %v", gotAuth)
+	}
+	if gotBody != "data" {
+		t.Errorf("PutObject() body = %v, want data", gotBody)
+	}
+}
@@ -0,0 +1,295 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultBlueskyURL is the PDS of the accounts hosted by Bluesky.
+	DefaultBlueskyURL = "https://bsky.social"
+	// blueskyMaxLength is the max length of the Bluesky post.
+	blueskyMaxLength = 300
+	// blueskyMaxImageSize is the max size of the image blob of the post.
+	blueskyMaxImageSize = 1_000_000
+	// blueskyPostCollection is the NSID of the post records in the repository of the account.
+	blueskyPostCollection = "app.bsky.feed.post"
+)
+
+// blueskyURLRe matches the URLs in the post text, they are linked with the facets.
+var blueskyURLRe = regexp.MustCompile(`https?://[^\s]+[^\s.,;:!?)]`)
+
+// BlueskyPublisher publishes messages as the posts of the Bluesky account using the AT protocol.
+// Markdown links are replaced with their text, long messages are truncated with the link to the full story.
+type BlueskyPublisher struct {
+	ServiceURL    string // URL of the PDS of the account, DefaultBlueskyURL for the accounts hosted by Bluesky
+	Identifier    string // Handle or email of the account (e.g. finthread.bsky.social)
+	Password      string // App password of the account
+	ShouldPublish bool   // If false, will print the message to the console (for development)
+	client        *http.Client
+	mu            sync.Mutex
+	session       *blueskySession
+}
+
+func NewBlueskyPublisher(serviceURL, identifier, password string, shouldPublish bool) *BlueskyPublisher {
+	if serviceURL == "" {
+		serviceURL = DefaultBlueskyURL
+	}
+	return &BlueskyPublisher{
+		ServiceURL:    strings.TrimSuffix(serviceURL, "/"),
+		Identifier:    identifier,
+		Password:      password,
+		ShouldPublish: shouldPublish,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Publish publishes the message as the new post. Returns the record key of the post.
+func (b *BlueskyPublisher) Publish(msg string) (pubID string, err error) {
+	return b.PublishWithMedia(msg, Media{})
+}
+
+// PublishWithMedia publishes the message with the image embedded into the post. The message is truncated
+// to the length limit with the media Link appended. If the image can't be uploaded, the post is published without it.
+func (b *BlueskyPublisher) PublishWithMedia(msg string, media Media) (pubID string, err error) {
+	text := truncateWithLink(plainText(msg), media.Link, blueskyMaxLength, utf8.RuneCountInString(media.Link))
+
+	if !b.ShouldPublish {
+		if !media.IsEmpty() {
+			fmt.Printf("[image] %s\n", media)
+		}
+		fmt.Println(text)
+		return "", nil
+	}
+
+	post := newBlueskyPost(text)
+	if !media.IsEmpty() {
+		// Image is optional, the news is published without it
+		if blob, err := b.uploadImage(media); err == nil {
+			post.Embed = &blueskyEmbed{
+				Type:   "app.bsky.embed.images",
+				Images: []blueskyImage{{Image: blob}},
+			}
+		}
+	}
+
+	var created struct {
+		URI string `json:"uri"`
+	}
+	err = b.xrpc("com.atproto.repo.createRecord", "application/json", func(did string) (any, error) {
+		return map[string]any{"repo": did, "collection": blueskyPostCollection, "record": post}, nil
+	}, &created)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to publish Bluesky post: %w", err), errlvl.ERROR)
+	}
+
+	// URI is at://<did>/app.bsky.feed.post/<rkey>
+	return created.URI[strings.LastIndex(created.URI, "/")+1:], nil
+}
+
+// UpdatePublication replaces the text of the post. Note: the embedded image is removed,
+// because the record is replaced as a whole.
+func (b *BlueskyPublisher) UpdatePublication(pubID, msg string) error {
+	if !b.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	post := newBlueskyPost(truncateWithLink(plainText(msg), "", blueskyMaxLength, 0))
+	err := b.xrpc("com.atproto.repo.putRecord", "application/json", func(did string) (any, error) {
+		return map[string]any{"repo": did, "collection": blueskyPostCollection, "rkey": pubID, "record": post}, nil
+	}, nil)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to update Bluesky post %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// DeletePublication deletes the post. Already deleted post is not an error.
+func (b *BlueskyPublisher) DeletePublication(pubID string) error {
+	if !b.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	err := b.xrpc("com.atproto.repo.deleteRecord", "application/json", func(did string) (any, error) {
+		return map[string]any{"repo": did, "collection": blueskyPostCollection, "rkey": pubID}, nil
+	}, nil)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to delete Bluesky post %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// uploadImage uploads the image blob and returns the blob reference for the embed.
+func (b *BlueskyPublisher) uploadImage(media Media) (json.RawMessage, error) {
+	file, err := mediaBytes(b.client, media)
+	if err != nil {
+		return nil, err
+	}
+	if len(file) > blueskyMaxImageSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", blueskyMaxImageSize)
+	}
+
+	var uploaded struct {
+		Blob json.RawMessage `json:"blob"`
+	}
+	err = b.xrpc("com.atproto.repo.uploadBlob", http.DetectContentType(file), func(string) (any, error) {
+		return file, nil
+	}, &uploaded)
+	if err != nil {
+		return nil, err
+	}
+	return uploaded.Blob, nil
+}
+
+// errBlueskyExpiredToken is returned by call if the access token of the session is expired.
+var errBlueskyExpiredToken = errors.New("expired token")
+
+// xrpc calls the procedure with the body built for the DID of the session and decodes the response into out (optional).
+// The session is created on the first call and re-created once if the access token is expired.
+// Body of the []byte type is sent as is, the other ones are encoded as JSON.
+func (b *BlueskyPublisher) xrpc(nsid, contentType string, body func(did string) (any, error), out any) error {
+	for attempt := 0; ; attempt++ {
+		s, err := b.authSession(attempt > 0)
+		if err != nil {
+			return err
+		}
+
+		in, err := body(s.DID)
+		if err != nil {
+			return err
+		}
+		err = b.call(nsid, contentType, s.AccessJwt, in, out)
+		if errors.Is(err, errBlueskyExpiredToken) && attempt == 0 {
+			continue
+		}
+		return err
+	}
+}
+
+// authSession returns the current session or creates the new one if there is none or renew is true.
+func (b *BlueskyPublisher) authSession(renew bool) (*blueskySession, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.session != nil && !renew {
+		return b.session, nil
+	}
+
+	var s blueskySession
+	in := map[string]string{"identifier": b.Identifier, "password": b.Password}
+	if err := b.call("com.atproto.server.createSession", "application/json", "", in, &s); err != nil {
+		return nil, fmt.Errorf("failed to create Bluesky session: %w", err)
+	}
+	b.session = &s
+	return b.session, nil
+}
+
+// call sends the XRPC procedure request, checks the response status and decodes the response into out (optional).
+func (b *BlueskyPublisher) call(nsid, contentType, token string, in, out any) error {
+	body, ok := in.([]byte)
+	if !ok {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.ServiceURL+"/xrpc/"+nsid, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var xrpcErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&xrpcErr)
+		if xrpcErr.Error == "ExpiredToken" {
+			return errBlueskyExpiredToken
+		}
+		return fmt.Errorf("unexpected status %s: %s %s", resp.Status, xrpcErr.Error, xrpcErr.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// newBlueskyPost returns the post record with the URLs of the text linked by the facets.
+func newBlueskyPost(text string) *blueskyPost {
+	post := &blueskyPost{
+		Type:      blueskyPostCollection,
+		Text:      text,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	// Facets are indexed by the UTF-8 bytes of the text
+	for _, loc := range blueskyURLRe.FindAllStringIndex(text, -1) {
+		post.Facets = append(post.Facets, blueskyFacet{
+			Index:    blueskyByteSlice{ByteStart: loc[0], ByteEnd: loc[1]},
+			Features: []blueskyFeature{{Type: "app.bsky.richtext.facet#link", URI: text[loc[0]:loc[1]]}},
+		})
+	}
+	return post
+}
+
+// blueskySession is the part of the createSession response used to authorize the requests.
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	DID       string `json:"did"`
+}
+
+// blueskyPost is the app.bsky.feed.post record.
+type blueskyPost struct {
+	Type      string         `json:"$type"`
+	Text      string         `json:"text"`
+	CreatedAt string         `json:"createdAt"`
+	Facets    []blueskyFacet `json:"facets,omitempty"`
+	Embed     *blueskyEmbed  `json:"embed,omitempty"`
+}
+
+type blueskyFacet struct {
+	Index    blueskyByteSlice `json:"index"`
+	Features []blueskyFeature `json:"features"`
+}
+
+type blueskyByteSlice struct {
+	ByteStart int `json:"byteStart"`
+	ByteEnd   int `json:"byteEnd"`
+}
+
+type blueskyFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri"`
+}
+
+type blueskyEmbed struct {
+	Type   string         `json:"$type"`
+	Images []blueskyImage `json:"images"`
+}
+
+type blueskyImage struct {
+	Alt   string          `json:"alt"`
+	Image json.RawMessage `json:"image"`
+}
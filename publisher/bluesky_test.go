@@ -0,0 +1,110 @@
+package publisher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBlueskyPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          string
+		media        Media
+		expireOnce   bool
+		wantSessions int
+		wantFacets   int
+	}{
+		{
+			name:         "short post",
+			msg:          "[AAPL](https://example.com/AAPL) holds rates steady.",
+			wantSessions: 1,
+		},
+		{
+			name:         "long post is truncated with linked URL",
+			msg:          strings.Repeat("Stocks rallied today. ", 40),
+			media:        Media{Link: "https://example.com/full-story"},
+			wantSessions: 1,
+			wantFacets:   1,
+		},
+		{
+			name:         "expired session is renewed",
+			msg:          "Fed holds rates steady.",
+			expireOnce:   true,
+			wantSessions: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sessions int
+			var expired bool
+			var got struct {
+				Repo       string      `json:"repo"`
+				Collection string      `json:"collection"`
+				Record     blueskyPost `json:"record"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/xrpc/com.atproto.server.createSession":
+					sessions++
+					_, _ = w.Write([]byte(`{"accessJwt":"jwt","did":"did:plc:abc"}`))
+				case "/xrpc/com.atproto.repo.createRecord":
+					if tt.expireOnce && !expired {
+						expired = true
+						w.WriteHeader(http.StatusBadRequest)
+						_, _ = w.Write([]byte(`{"error":"ExpiredToken","message":"Token has expired"}`))
+						return
+					}
+					if r.Header.Get("Authorization") != "Bearer jwt" {
+						t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+					}
+					_ = json.NewDecoder(r.Body).Decode(&got)
+					_, _ = w.Write([]byte(`{"uri":"at://did:plc:abc/app.bsky.feed.post/3kxyz","cid":"c"}`))
+				default:
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			b := NewBlueskyPublisher(srv.URL, "finthread.bsky.social", "app-password", true)
+			id, err := b.PublishWithMedia(tt.msg, tt.media)
+			if err != nil {
+				t.Fatalf("PublishWithMedia() error = %v", err)
+			}
+			if id != "3kxyz" {
+				t.Errorf("PublishWithMedia() = %q, want %q", id, "3kxyz")
+			}
+			if sessions != tt.wantSessions {
+				t.Errorf("created %d sessions, want %d", sessions, tt.wantSessions)
+			}
+			if got.Repo != "did:plc:abc" || got.Collection != blueskyPostCollection {
+				t.Errorf("record repo = %q, collection = %q", got.Repo, got.Collection)
+			}
+			if text := got.Record.Text; utf8.RuneCountInString(text) > blueskyMaxLength || strings.Contains(text, "](") {
+				t.Errorf("invalid post text %q", text)
+			}
+			if len(got.Record.Facets) != tt.wantFacets {
+				t.Fatalf("post has %d facets, want %d", len(got.Record.Facets), tt.wantFacets)
+			}
+			for _, f := range got.Record.Facets {
+				if linked := got.Record.Text[f.Index.ByteStart:f.Index.ByteEnd]; linked != f.Features[0].URI {
+					t.Errorf("facet links %q, want %q", linked, f.Features[0].URI)
+				}
+			}
+		})
+	}
+}
+
+func Test_newBlueskyPost(t *testing.T) {
+	post := newBlueskyPost("Ставки 📉 https://example.com/a.")
+	if len(post.Facets) != 1 {
+		t.Fatalf("post has %d facets, want 1", len(post.Facets))
+	}
+	f := post.Facets[0]
+	if got := post.Text[f.Index.ByteStart:f.Index.ByteEnd]; got != "https://example.com/a" || f.Features[0].URI != got {
+		t.Errorf("facet links %q to %q, want https://example.com/a", got, f.Features[0].URI)
+	}
+}
@@ -0,0 +1,217 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// discordMaxLength is the max length of the Discord message content.
+const discordMaxLength = 2000
+
+// DiscordPublisher publishes messages to the Discord channel using the webhook.
+type DiscordPublisher struct {
+	WebhookURL    string // Discord webhook URL (e.g. https://discord.com/api/webhooks/<id>/<token>)
+	ShouldPublish bool   // If false, will print the message to the console (for development)
+	client        *http.Client
+}
+
+func NewDiscordPublisher(webhookURL string, shouldPublish bool) *DiscordPublisher {
+	return &DiscordPublisher{
+		WebhookURL:    webhookURL,
+		ShouldPublish: shouldPublish,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish publishes the message to the webhook channel. Messages longer than the Discord limit
+// are split by sentences into several messages. Returns the ID of the first message.
+func (d *DiscordPublisher) Publish(msg string) (pubID string, err error) {
+	return d.PublishWithMedia(msg, Media{})
+}
+
+// PublishWithMedia publishes the message with the image embedded by its URL or uploaded as the file.
+// The image is attached to the first message if the text is split.
+func (d *DiscordPublisher) PublishWithMedia(msg string, media Media) (pubID string, err error) {
+	if !d.ShouldPublish {
+		if !media.IsEmpty() {
+			fmt.Printf("[image] %s\n", media)
+		}
+		fmt.Println(msg)
+		return "", nil
+	}
+
+	for i, part := range utils.SplitBySentences(msg, discordMaxLength) {
+		var attached Media
+		if i == 0 {
+			attached = media
+		}
+		id, err := d.send(part, attached)
+		if err != nil {
+			return pubID, err
+		}
+		if pubID == "" {
+			pubID = id
+		}
+	}
+
+	return pubID, nil
+}
+
+// UpdatePublication edits the message sent by the webhook. Long text is truncated to the first part,
+// because only the first message ID of the split publication is known.
+func (d *DiscordPublisher) UpdatePublication(pubID, msg string) error {
+	if !d.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	parts := utils.SplitBySentences(msg, discordMaxLength)
+	if len(parts) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(discordWebhookMessage{Content: parts[0]})
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to marshal Discord message: %w", err), errlvl.ERROR)
+	}
+
+	if err := d.do(http.MethodPatch, d.messageURL(pubID), body); err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to update Discord message %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// DeletePublication deletes the message sent by the webhook. Already deleted message is not an error.
+func (d *DiscordPublisher) DeletePublication(pubID string) error {
+	if !d.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	if err := d.do(http.MethodDelete, d.messageURL(pubID), nil); err != nil && !errors.Is(err, errDiscordNotFound) {
+		return errlvl.Wrap(fmt.Errorf("failed to delete Discord message %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// errDiscordNotFound is returned by do if the message doesn't exist.
+var errDiscordNotFound = errors.New("message not found")
+
+// messageURL returns the webhook URL of the message.
+func (d *DiscordPublisher) messageURL(pubID string) string {
+	return d.WebhookURL + "/messages/" + url.PathEscape(pubID)
+}
+
+// do sends the request to the webhook and checks the response status.
+func (d *DiscordPublisher) do(method, u string, body []byte) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return withoutURL(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errDiscordNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// send executes the webhook and waits for the created message to get its ID.
+func (d *DiscordPublisher) send(content string, media Media) (string, error) {
+	m := discordWebhookMessage{Content: content}
+	if media.URL != "" {
+		m.Embeds = []discordEmbed{{Image: &discordEmbedImage{URL: media.URL}}}
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to marshal Discord message: %w", err), errlvl.ERROR)
+	}
+
+	contentType := "application/json"
+	if media.URL == "" && len(media.Bytes) > 0 {
+		body, contentType, err = discordMultipart(body, media)
+		if err != nil {
+			return "", errlvl.Wrap(fmt.Errorf("failed to attach the file to Discord message: %w", err), errlvl.ERROR)
+		}
+	}
+
+	resp, err := d.client.Post(d.WebhookURL+"?wait=true", contentType, bytes.NewReader(body))
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send message to Discord: %w", withoutURL(err)), errlvl.ERROR)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send message to Discord: unexpected status %s", resp.Status), errlvl.ERROR)
+	}
+
+	var created discordWebhookMessage
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to decode Discord response: %w", err), errlvl.ERROR)
+	}
+
+	return created.ID, nil
+}
+
+// withoutURL returns the cause of the *url.Error without the URL, because the webhook URL contains its token.
+func withoutURL(err error) error {
+	var e *url.Error
+	if errors.As(err, &e) {
+		return fmt.Errorf("%s: %w", e.Op, e.Err)
+	}
+	return err
+}
+
+// discordMultipart returns the multipart body of the webhook message with the uploaded file and its content type.
+func discordMultipart(payload []byte, media Media) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
+		return nil, "", err
+	}
+	f, err := w.CreateFormFile("files[0]", media.fileName())
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := f.Write(media.Bytes); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// discordWebhookMessage is the part of the Discord message object used by the webhook.
+type discordWebhookMessage struct {
+	ID      string         `json:"id,omitempty"`
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+// discordEmbed is the part of the Discord embed object used to show the image.
+type discordEmbed struct {
+	Image *discordEmbedImage `json:"image,omitempty"`
+}
+
+type discordEmbedImage struct {
+	URL string `json:"url"`
+}
@@ -0,0 +1,238 @@
+package publisher
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiscordPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name          string
+		msg           string
+		shouldPublish bool
+		status        int
+		wantID        string
+		wantMessages  int
+		wantErr       bool
+	}{
+		{
+			name:          "single message",
+			msg:           "Fed holds rates steady.",
+			shouldPublish: true,
+			status:        http.StatusOK,
+			wantID:        "1",
+			wantMessages:  1,
+		},
+		{
+			name:          "long message is split",
+			msg:           strings.Repeat("Stocks rallied today. ", 150),
+			shouldPublish: true,
+			status:        http.StatusOK,
+			wantID:        "1",
+			wantMessages:  2,
+		},
+		{
+			name:          "webhook error",
+			msg:           "Fed holds rates steady.",
+			shouldPublish: true,
+			status:        http.StatusNotFound,
+			wantMessages:  1,
+			wantErr:       true,
+		},
+		{
+			name:          "publishing disabled",
+			msg:           "Fed holds rates steady.",
+			shouldPublish: false,
+			wantMessages:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				messages++
+				if r.URL.Query().Get("wait") != "true" {
+					t.Errorf("expected wait=true, got %s", r.URL.RawQuery)
+				}
+
+				var m discordWebhookMessage
+				if err := json.NewDecoder(r.Body).Decode(&m); err != nil || len(m.Content) > discordMaxLength {
+					t.Errorf("invalid message: %v (len %d)", err, len(m.Content))
+				}
+
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(discordWebhookMessage{ID: strconv.Itoa(messages), Content: m.Content})
+			}))
+			defer srv.Close()
+
+			d := NewDiscordPublisher(srv.URL, tt.shouldPublish)
+			got, err := d.Publish(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.wantID {
+				t.Errorf("Publish() = %q, want %q", got, tt.wantID)
+			}
+			if messages != tt.wantMessages {
+				t.Errorf("Publish() sent %d messages, want %d", messages, tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestDiscordPublisher_UpdateDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		status     int
+		wantMethod string
+		wantErr    bool
+	}{
+		{
+			name:       "update",
+			method:     "update",
+			status:     http.StatusOK,
+			wantMethod: http.MethodPatch,
+		},
+		{
+			name:       "update of deleted message",
+			method:     "update",
+			status:     http.StatusNotFound,
+			wantMethod: http.MethodPatch,
+			wantErr:    true,
+		},
+		{
+			name:       "delete",
+			method:     "delete",
+			status:     http.StatusNoContent,
+			wantMethod: http.MethodDelete,
+		},
+		{
+			name:       "delete of deleted message",
+			method:     "delete",
+			status:     http.StatusNotFound,
+			wantMethod: http.MethodDelete,
+		},
+		{
+			name:       "delete error",
+			method:     "delete",
+			status:     http.StatusForbidden,
+			wantMethod: http.MethodDelete,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotPath, gotContent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotPath = r.Method, r.URL.Path
+				if r.Method == http.MethodPatch {
+					var m discordWebhookMessage
+					_ = json.NewDecoder(r.Body).Decode(&m)
+					gotContent = m.Content
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			d := NewDiscordPublisher(srv.URL+"/webhook", true)
+			var err error
+			if tt.method == "update" {
+				err = d.UpdatePublication("42", "Fed cuts rates.")
+			} else {
+				err = d.DeletePublication("42")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("%s error = %v, wantErr %v", tt.method, err, tt.wantErr)
+			}
+			if gotMethod != tt.wantMethod || gotPath != "/webhook/messages/42" {
+				t.Errorf("request = %s %s, want %s /webhook/messages/42", gotMethod, gotPath, tt.wantMethod)
+			}
+			if tt.method == "update" && gotContent != "Fed cuts rates." {
+				t.Errorf("updated content = %q, want %q", gotContent, "Fed cuts rates.")
+			}
+		})
+	}
+}
+
+func TestDiscordPublisher_PublishWithMedia(t *testing.T) {
+	tests := []struct {
+		name      string
+		media     Media
+		wantImage string // embedded image URL
+		wantFile  string // uploaded file content
+	}{
+		{
+			name:      "image by URL is embedded",
+			media:     Media{URL: "https://example.com/chart.png"},
+			wantImage: "https://example.com/chart.png",
+		},
+		{
+			name:     "image file is uploaded",
+			media:    Media{Bytes: []byte("png"), Name: "chart.png"},
+			wantFile: "png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m discordWebhookMessage
+			var file string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+					_ = json.Unmarshal([]byte(r.FormValue("payload_json")), &m)
+					if f, _, err := r.FormFile("files[0]"); err == nil {
+						b, _ := io.ReadAll(f)
+						file = string(b)
+					}
+				} else {
+					_ = json.NewDecoder(r.Body).Decode(&m)
+				}
+				_ = json.NewEncoder(w).Encode(discordWebhookMessage{ID: "1"})
+			}))
+			defer srv.Close()
+
+			got, err := NewDiscordPublisher(srv.URL, true).PublishWithMedia("Fed holds rates steady.", tt.media)
+			if err != nil || got != "1" {
+				t.Fatalf("PublishWithMedia() = %q, %v, want 1", got, err)
+			}
+			if m.Content != "Fed holds rates steady." {
+				t.Errorf("PublishWithMedia() content = %q", m.Content)
+			}
+			var image string
+			if len(m.Embeds) > 0 && m.Embeds[0].Image != nil {
+				image = m.Embeds[0].Image.URL
+			}
+			if image != tt.wantImage || file != tt.wantFile {
+				t.Errorf("PublishWithMedia() image = %q, file = %q, want %q, %q", image, file, tt.wantImage, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestDiscordPublisher_errorWithoutURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	webhook := srv.URL + "/api/webhooks/123/secret-token"
+	srv.Close()
+
+	d := NewDiscordPublisher(webhook, true)
+	_, err := d.Publish("Fed holds rates steady.")
+	if err == nil {
+		t.Fatal("Publish() error = nil, want the connection error")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("Publish() error = %q, want it without the webhook URL", err)
+	}
+
+	err = d.DeletePublication("1")
+	if err == nil {
+		t.Fatal("DeletePublication() error = nil, want the connection error")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("DeletePublication() error = %q, want it without the webhook URL", err)
+	}
+}
@@ -0,0 +1,421 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"html/template"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEmailSubject = "fin-thread digest"
+	emailOtherMarket    = "Other" // section of the items without the market
+	sendGridURL         = "https://api.sendgrid.com/v3/mail/send"
+)
+
+// EmailItem is the published news collected into the email digest.
+type EmailItem struct {
+	ID        string    // Unique ID of the news, e.g. its hash, to update or remove the item before the digest is sent
+	Title     string    // Title of the news
+	Text      string    // Composed text of the news, Markdown links are replaced with their text
+	Link      string    // Link to the original news (optional)
+	Markets   []string  // Markets of the news, the item is listed in the section of the first one
+	Published time.Time // Publication date
+}
+
+// EmailMessage is the HTML email sent by the EmailSender.
+type EmailMessage struct {
+	From           string
+	To             []string // Recipients of the mailing list, they don't see each other
+	Subject        string
+	HTML           string
+	UnsubscribeURL string // Sent as the List-Unsubscribe header (optional)
+}
+
+// EmailSender sends the email to the recipients (see SMTPSender and SendGridSender).
+type EmailSender interface {
+	Send(ctx context.Context, m *EmailMessage) error
+}
+
+// EmailPublisher collects the published news and sends them as the HTML digest email to the mailing list
+// (see Flush), so the readers without Telegram get the news once per window instead of every message.
+// Items are kept in memory until the digest is sent.
+type EmailPublisher struct {
+	From           string   // Sender address, e.g. "fin-thread <news@example.com>"
+	To             []string // Recipients of the digest
+	Subject        string   // Subject of the digest, the date is appended
+	UnsubscribeURL string   // Link of the unsubscribe footer and the List-Unsubscribe header (optional)
+	ShouldPublish  bool     // If false, will print the digest to the console (for development)
+	sender         EmailSender
+	template       *template.Template
+	mu             sync.Mutex
+	items          []EmailItem
+}
+
+func NewEmailPublisher(sender EmailSender, from string, to []string, shouldPublish bool) *EmailPublisher {
+	return &EmailPublisher{
+		From:          from,
+		To:            to,
+		Subject:       defaultEmailSubject,
+		ShouldPublish: shouldPublish,
+		sender:        sender,
+		template:      template.Must(template.New("email").Funcs(emailFuncs).Parse(defaultEmailTemplate)),
+	}
+}
+
+// WithSubject sets the subject of the digest.
+func (e *EmailPublisher) WithSubject(subject string) *EmailPublisher {
+	e.Subject = subject
+	return e
+}
+
+// WithUnsubscribeURL sets the link of the unsubscribe footer, e.g. the unsubscribe page of the mailing list.
+func (e *EmailPublisher) WithUnsubscribeURL(url string) *EmailPublisher {
+	e.UnsubscribeURL = url
+	return e
+}
+
+// WithTemplate replaces the default layout of the digest with the html/template. The template is executed
+// with the fields Subject, Date, Count, Sections (Market and Items of EmailItem) and UnsubscribeURL.
+func (e *EmailPublisher) WithTemplate(text string) (*EmailPublisher, error) {
+	tmpl, err := template.New("email").Funcs(emailFuncs).Parse(text)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to parse email template: %w", err), errlvl.ERROR)
+	}
+	e.template = tmpl
+	return e, nil
+}
+
+// Add collects the item into the next digest. The item with the same ID replaces the collected one.
+func (e *EmailPublisher) Add(item EmailItem) {
+	item.Text = plainText(item.Text)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range e.items {
+		if item.ID != "" && e.items[i].ID == item.ID {
+			e.items[i] = item
+			return
+		}
+	}
+	e.items = append(e.items, item)
+}
+
+// Update replaces the text of the collected item, e.g. if the news was corrected before the digest is sent.
+func (e *EmailPublisher) Update(id, text string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range e.items {
+		if e.items[i].ID == id {
+			e.items[i].Text = plainText(text)
+		}
+	}
+}
+
+// Remove removes the collected item, e.g. if the news was retracted before the digest is sent.
+func (e *EmailPublisher) Remove(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range e.items {
+		if e.items[i].ID == id {
+			e.items = append(e.items[:i], e.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// Len returns the number of the collected items.
+func (e *EmailPublisher) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.items)
+}
+
+// Flush sends the digest of the collected items and returns their number. Nothing is sent if there are no items.
+// If the digest can't be sent, the items are kept for the next Flush.
+func (e *EmailPublisher) Flush(ctx context.Context) (int, error) {
+	e.mu.Lock()
+	items := e.items
+	e.items = nil
+	e.mu.Unlock()
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	err := e.send(ctx, items)
+	if err != nil {
+		e.mu.Lock()
+		e.items = append(items, e.items...)
+		e.mu.Unlock()
+		return 0, err
+	}
+
+	return len(items), nil
+}
+
+// send renders the digest of the items and sends it to the recipients.
+func (e *EmailPublisher) send(ctx context.Context, items []EmailItem) error {
+	now := time.Now().UTC()
+	subject := fmt.Sprintf("%s: %s", e.Subject, now.Format("Jan 2, 2006"))
+	html, err := e.render(subject, now, items)
+	if err != nil {
+		return err
+	}
+
+	if !e.ShouldPublish {
+		fmt.Printf("[email] %s (%d news)\n%s\n", subject, len(items), html)
+		return nil
+	}
+
+	err = e.sender.Send(ctx, &EmailMessage{
+		From:           e.From,
+		To:             e.To,
+		Subject:        subject,
+		HTML:           html,
+		UnsubscribeURL: e.UnsubscribeURL,
+	})
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to send email digest: %w", err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// emailSection is the market section of the digest.
+type emailSection struct {
+	Market string
+	Items  []EmailItem
+}
+
+// render executes the template of the digest with the items grouped into the market sections.
+func (e *EmailPublisher) render(subject string, date time.Time, items []EmailItem) (string, error) {
+	var buf bytes.Buffer
+	err := e.template.Execute(&buf, struct {
+		Subject        string
+		Date           time.Time
+		Count          int
+		Sections       []emailSection
+		UnsubscribeURL string
+	}{
+		Subject:        subject,
+		Date:           date,
+		Count:          len(items),
+		Sections:       emailSections(items),
+		UnsubscribeURL: e.UnsubscribeURL,
+	})
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to render email digest: %w", err), errlvl.ERROR)
+	}
+	return buf.String(), nil
+}
+
+// emailSections groups the items by their first market. Sections are sorted by the number of items,
+// the items without the market are the last section.
+func emailSections(items []EmailItem) []emailSection {
+	var sections []emailSection
+	index := make(map[string]int)
+	for _, item := range items {
+		market := emailOtherMarket
+		if len(item.Markets) > 0 && strings.TrimSpace(item.Markets[0]) != "" {
+			market = strings.TrimSpace(item.Markets[0])
+		}
+		i, ok := index[market]
+		if !ok {
+			i = len(sections)
+			index[market] = i
+			sections = append(sections, emailSection{Market: market})
+		}
+		sections[i].Items = append(sections[i].Items, item)
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		if (sections[i].Market == emailOtherMarket) != (sections[j].Market == emailOtherMarket) {
+			return sections[j].Market == emailOtherMarket
+		}
+		return len(sections[i].Items) > len(sections[j].Items)
+	})
+	return sections
+}
+
+var emailFuncs = template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format("15:04 MST") },
+}
+
+const defaultEmailTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="font-family: Arial, sans-serif; max-width: 640px; margin: 0 auto; color: #222;">
+<h1 style="font-size: 22px;">{{.Subject}}</h1>
+<p style="color: #666;">{{.Count}} news published</p>
+{{range .Sections}}
+<h2 style="font-size: 18px; border-bottom: 1px solid #ddd;">{{.Market}}</h2>
+{{range .Items}}
+<div style="margin-bottom: 16px;">
+<p style="margin: 0;"><strong>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</strong> <span style="color: #999;">{{time .Published}}</span></p>
+<p style="margin: 4px 0 0;">{{.Text}}</p>
+</div>
+{{end}}
+{{end}}
+<hr>
+<p style="font-size: 12px; color: #999;">You receive this email because you are subscribed to the fin-thread digest.{{if .UnsubscribeURL}} <a href="{{.UnsubscribeURL}}">Unsubscribe</a>{{end}}</p>
+</body>
+</html>
+`
+
+// SMTPSender sends the emails via the SMTP server. STARTTLS is used if the server supports it.
+type SMTPSender struct {
+	Addr     string // Address of the SMTP server, e.g. "smtp.example.com:587"
+	Username string // Username of the PLAIN auth, no auth if empty
+	Password string
+}
+
+func NewSMTPSender(addr, username, password string) *SMTPSender {
+	return &SMTPSender{Addr: addr, Username: username, Password: password}
+}
+
+// Send sends the email to all recipients at once, they are not listed in the To header.
+func (s *SMTPSender) Send(_ context.Context, m *EmailMessage) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %s: %w", s.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	body, err := mimeMessage(m)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.Addr, auth, emailAddress(m.From), m.To, body); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	return nil
+}
+
+// mimeMessage builds the quoted-printable HTML message for the SMTP server.
+func mimeMessage(m *EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", m.From},
+		{"To", "undisclosed-recipients:;"},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/html; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	if m.UnsubscribeURL != "" {
+		headers = append(headers, [2]string{"List-Unsubscribe", "<" + m.UnsubscribeURL + ">"})
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+	}
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(m.HTML)); err != nil {
+		return nil, fmt.Errorf("failed to encode email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// emailAddress returns the address of the "Name <address>" string.
+func emailAddress(s string) string {
+	if i, j := strings.LastIndex(s, "<"), strings.LastIndex(s, ">"); i >= 0 && j > i {
+		return s[i+1 : j]
+	}
+	return s
+}
+
+// SendGridSender sends the emails via the SendGrid v3 Mail Send API.
+type SendGridSender struct {
+	APIKey string
+	URL    string // Mail Send API endpoint, SendGrid by default
+	client *http.Client
+}
+
+func NewSendGridSender(apiKey string) *SendGridSender {
+	return &SendGridSender{
+		APIKey: apiKey,
+		URL:    sendGridURL,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridRequest struct {
+	Personalizations []struct {
+		To []sendGridAddress `json:"to"`
+	} `json:"personalizations"`
+	From    sendGridAddress   `json:"from"`
+	Subject string            `json:"subject"`
+	Content []sendGridContent `json:"content"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Send sends the email with the personalization per recipient, so they don't see each other.
+func (s *SendGridSender) Send(ctx context.Context, m *EmailMessage) error {
+	req := sendGridRequest{
+		From:    sendGridAddress{Email: emailAddress(m.From)},
+		Subject: m.Subject,
+		Content: []sendGridContent{{Type: "text/html", Value: m.HTML}},
+	}
+	if name := strings.TrimSpace(strings.Split(m.From, "<")[0]); name != "" && name != req.From.Email {
+		req.From.Name = name
+	}
+	for _, to := range m.To {
+		req.Personalizations = append(req.Personalizations, struct {
+			To []sendGridAddress `json:"to"`
+		}{To: []sendGridAddress{{Email: to}}})
+	}
+	if m.UnsubscribeURL != "" {
+		req.Headers = map[string]string{"List-Unsubscribe": "<" + m.UnsubscribeURL + ">"}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal SendGrid request: %w", err)
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create SendGrid request: %w", err)
+	}
+	r.Header.Set("Authorization", "Bearer "+s.APIKey)
+	r.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(r)
+	if err != nil {
+		return fmt.Errorf("failed to send SendGrid request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected SendGrid status %s: %s", res.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeEmailSender struct {
+	sent []*EmailMessage
+	err  error
+}
+
+func (s *fakeEmailSender) Send(_ context.Context, m *EmailMessage) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func TestEmailPublisher_Flush(t *testing.T) {
+	published := time.Date(2024, 3, 8, 21, 30, 0, 0, time.UTC)
+	sender := &fakeEmailSender{}
+	e := NewEmailPublisher(sender, "fin-thread <news@example.com>", []string{"a@example.com"}, true).
+		WithUnsubscribeURL("https://example.com/unsubscribe")
+
+	if n, err := e.Flush(context.Background()); n != 0 || err != nil || len(sender.sent) != 0 {
+		t.Fatalf("Flush() of empty digest = %d, %v, want nothing sent", n, err)
+	}
+
+	e.Add(EmailItem{ID: "1", Title: "Fed holds rates", Text: "Stocks of [SPY](https://example.com/SPY) rose", Markets: []string{"US stocks"}, Published: published})
+	e.Add(EmailItem{ID: "2", Title: "Gold <rallies>", Text: "Gold rose 2%", Link: "https://example.com/gold", Markets: []string{"commodities"}})
+	e.Add(EmailItem{ID: "3", Title: "Retracted", Text: "Wrong story"})
+	e.Add(EmailItem{ID: "4", Title: "Apple beats", Text: "Revenue rose", Markets: []string{"US stocks"}})
+	e.Update("4", "Revenue rose 5%")
+	e.Remove("3")
+	if e.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", e.Len())
+	}
+
+	sender.err = errors.New("connection refused")
+	if _, err := e.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() error = nil, want error")
+	}
+	if e.Len() != 3 {
+		t.Fatalf("Len() after failed Flush() = %d, want the items kept", e.Len())
+	}
+
+	sender.err = nil
+	n, err := e.Flush(context.Background())
+	if err != nil || n != 3 {
+		t.Fatalf("Flush() = %d, %v, want 3 news sent", n, err)
+	}
+	if e.Len() != 0 {
+		t.Errorf("Len() after Flush() = %d, want 0", e.Len())
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sender.sent))
+	}
+
+	m := sender.sent[0]
+	if !strings.HasPrefix(m.Subject, "fin-thread digest: ") || m.UnsubscribeURL != "https://example.com/unsubscribe" {
+		t.Errorf("message = %+v, want the subject with the date and the unsubscribe URL", m)
+	}
+	for _, want := range []string{
+		"<h2 style=\"font-size: 18px; border-bottom: 1px solid #ddd;\">US stocks</h2>",
+		"Stocks of SPY rose",
+		"Revenue rose 5%",
+		"21:30 UTC",
+		`<a href="https://example.com/gold">Gold &lt;rallies&gt;</a>`,
+		`<a href="https://example.com/unsubscribe">Unsubscribe</a>`,
+	} {
+		if !strings.Contains(m.HTML, want) {
+			t.Errorf("HTML = %s, want to contain %s", m.HTML, want)
+		}
+	}
+	if strings.Contains(m.HTML, "Wrong story") {
+		t.Errorf("HTML = %s, want the removed item skipped", m.HTML)
+	}
+	if strings.Index(m.HTML, "US stocks") > strings.Index(m.HTML, "commodities") {
+		t.Errorf("HTML = %s, want the bigger section first", m.HTML)
+	}
+}
+
+func TestEmailPublisher_WithTemplate(t *testing.T) {
+	sender := &fakeEmailSender{}
+	e, err := NewEmailPublisher(sender, "news@example.com", []string{"a@example.com"}, true).
+		WithTemplate(`{{.Count}}:{{range .Sections}}[{{.Market}}{{range .Items}} {{.Title}}{{end}}]{{end}}`)
+	if err != nil {
+		t.Fatalf("WithTemplate() error = %v", err)
+	}
+	e.Add(EmailItem{ID: "1", Title: "Fed holds rates", Markets: []string{"bonds"}})
+	e.Add(EmailItem{ID: "2", Title: "Earnings season"})
+	if _, err := e.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got, want := sender.sent[0].HTML, "2:[bonds Fed holds rates][Other Earnings season]"; got != want {
+		t.Errorf("HTML = %s, want %s", got, want)
+	}
+
+	if _, err := e.WithTemplate("{{.Count"); err == nil {
+		t.Error("WithTemplate() of invalid template error = nil, want error")
+	}
+}
+
+func Test_emailSections(t *testing.T) {
+	items := []EmailItem{
+		{ID: "1", Markets: []string{"crypto"}},
+		{ID: "2"},
+		{ID: "3", Markets: []string{"US stocks", "crypto"}},
+		{ID: "4", Markets: []string{"US stocks"}},
+		{ID: "5", Markets: []string{" "}},
+	}
+	var got []string
+	for _, s := range emailSections(items) {
+		ids := make([]string, len(s.Items))
+		for i, item := range s.Items {
+			ids[i] = item.ID
+		}
+		got = append(got, s.Market+":"+strings.Join(ids, ","))
+	}
+	want := []string{"US stocks:3,4", "crypto:1", "Other:2,5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("emailSections() = %v, want %v", got, want)
+	}
+}
+
+func Test_mimeMessage(t *testing.T) {
+	body, err := mimeMessage(&EmailMessage{
+		From:           "fin-thread <news@example.com>",
+		To:             []string{"a@example.com", "b@example.com"},
+		Subject:        "Digest — Mar 8",
+		HTML:           `<p style="color: red;">Fed</p>`,
+		UnsubscribeURL: "https://example.com/unsubscribe",
+	})
+	if err != nil {
+		t.Fatalf("mimeMessage() error = %v", err)
+	}
+	for _, want := range []string{
+		"From: fin-thread <news@example.com>\r\n",
+		"To: undisclosed-recipients:;\r\n",
+		"Subject: =?utf-8?q?Digest_=E2=80=94_Mar_8?=\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+		"List-Unsubscribe: <https://example.com/unsubscribe>\r\n",
+		"\r\n\r\n<p style=3D\"color: red;\">Fed</p>",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("mimeMessage() = %q, want to contain %q", body, want)
+		}
+	}
+	if strings.Contains(string(body), "a@example.com") {
+		t.Errorf("mimeMessage() = %q, want the recipients hidden", body)
+	}
+}
+
+func TestSendGridSender_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "unauthorized", status: http.StatusUnauthorized, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got sendGridRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer key" {
+					t.Errorf("Authorization = %s, want Bearer key", r.Header.Get("Authorization"))
+				}
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			s := NewSendGridSender("key")
+			s.URL = srv.URL
+			err := s.Send(context.Background(), &EmailMessage{
+				From:           "fin-thread <news@example.com>",
+				To:             []string{"a@example.com", "b@example.com"},
+				Subject:        "Digest",
+				HTML:           "<p>Fed</p>",
+				UnsubscribeURL: "https://example.com/unsubscribe",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got.From != (sendGridAddress{Email: "news@example.com", Name: "fin-thread"}) {
+				t.Errorf("from = %+v, want the name and address", got.From)
+			}
+			if len(got.Personalizations) != 2 || got.Personalizations[1].To[0].Email != "b@example.com" {
+				t.Errorf("personalizations = %+v, want one per recipient", got.Personalizations)
+			}
+			if got.Headers["List-Unsubscribe"] != "<https://example.com/unsubscribe>" {
+				t.Errorf("headers = %v, want List-Unsubscribe", got.Headers)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFeedTitle       = "fin-thread"
+	defaultFeedDescription = "Financial news composed and published by fin-thread."
+)
+
+// FeedItem is the published news in the output feed.
+type FeedItem struct {
+	ID         string    // Unique ID of the news, e.g. its hash
+	Title      string    // Title of the news
+	Text       string    // Composed text of the news
+	Link       string    // Link to the original news
+	Published  time.Time // Publication date
+	Categories []string  // Tickers and hashtags of the news, e.g. "AAPL", "inflation"
+}
+
+// Feed builds the RSS 2.0 and Atom feeds of the published news, so the news can be followed without Telegram.
+type Feed struct {
+	Title       string // Title of the feed
+	Description string // Description of the feed (RSS) or its subtitle (Atom)
+	BaseURL     string // Public URL of the feed files, e.g. "https://example.com", feeds are linked as BaseURL/feed.xml and BaseURL/feed.atom
+}
+
+// Names of the feed files relative to the Feed.BaseURL.
+const (
+	RSSFeedFile  = "feed.xml"
+	AtomFeedFile = "feed.atom"
+)
+
+// NewFeed creates a new Feed with the default title and description.
+func NewFeed(baseURL string) *Feed {
+	return &Feed{
+		Title:       defaultFeedTitle,
+		Description: defaultFeedDescription,
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// rssFeed is the RSS 2.0 feed with the self link of the Atom namespace.
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Atom    string   `xml:"xmlns:atom,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		Language      string    `xml:"language"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Self          atomLink  `xml:"atom:link"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// atomFeed is the Atom (RFC 4287) feed.
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomAuthor  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Link       *atomLink      `xml:"link,omitempty"`
+	Summary    string         `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// RSS renders the RSS 2.0 feed of the items, the items are expected in the newest first order.
+func (f *Feed) RSS(items []FeedItem) ([]byte, error) {
+	feed := &rssFeed{Version: "2.0", Atom: "http://www.w3.org/2005/Atom"}
+	feed.Channel.Title = f.Title
+	feed.Channel.Link = f.BaseURL + "/"
+	feed.Channel.Description = f.Description
+	feed.Channel.Language = "en"
+	feed.Channel.Self = atomLink{Href: f.BaseURL + "/" + RSSFeedFile, Rel: "self", Type: "application/rss+xml"}
+	if len(items) > 0 {
+		feed.Channel.LastBuildDate = items[0].Published.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range items {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Text,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Categories:  item.Categories,
+		})
+	}
+
+	return encodeFeed(feed)
+}
+
+// Atom renders the Atom feed of the items, the items are expected in the newest first order.
+func (f *Feed) Atom(items []FeedItem) ([]byte, error) {
+	feed := &atomFeed{
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.BaseURL + "/" + AtomFeedFile,
+		Links: []atomLink{
+			{Href: f.BaseURL + "/" + AtomFeedFile, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.BaseURL + "/", Rel: "alternate"},
+		},
+		Author: atomAuthor{Name: f.Title},
+	}
+	// The feed must have the updated date even if it is empty
+	updated := time.Unix(0, 0)
+	if len(items) > 0 {
+		updated = items[0].Published
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	for _, item := range items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        fmt.Sprintf("urn:fin-thread:news:%s", item.ID),
+			Updated:   item.Published.UTC().Format(time.RFC3339),
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Summary:   item.Text,
+		}
+		if item.Link != "" {
+			entry.Link = &atomLink{Href: item.Link, Rel: "alternate"}
+		}
+		for _, c := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return encodeFeed(feed)
+}
+
+// encodeFeed encodes the feed into the indented XML document.
+func encodeFeed(feed interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+package publisher
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeed(t *testing.T) {
+	items := []FeedItem{
+		{
+			ID:         "abc",
+			Title:      "Fed holds rates <steady>",
+			Text:       "The Fed kept rates at 5.5% & signalled cuts.",
+			Link:       "https://example.com/fed",
+			Published:  time.Date(2024, 3, 8, 21, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+			Categories: []string{"SPY", "rates"},
+		},
+		{
+			ID:        "def",
+			Title:     "Apple beats estimates",
+			Published: time.Date(2024, 3, 8, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		render func(*Feed, []FeedItem) ([]byte, error)
+		items  []FeedItem
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "rss",
+			render: (*Feed).RSS,
+			items:  items,
+			want: []string{
+				`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`,
+				`<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"></atom:link>`,
+				`<lastBuildDate>Sat, 09 Mar 2024 02:30:00 +0000</lastBuildDate>`,
+				`<title>Fed holds rates &lt;steady&gt;</title>`,
+				`<description>The Fed kept rates at 5.5% &amp; signalled cuts.</description>`,
+				`<guid isPermaLink="false">abc</guid>`,
+				`<category>rates</category>`,
+				`<pubDate>Fri, 08 Mar 2024 20:00:00 +0000</pubDate>`,
+			},
+		},
+		{
+			name:   "empty rss",
+			render: (*Feed).RSS,
+			want:   []string{`<link>https://example.com/</link>`},
+			absent: []string{"<item>", "<lastBuildDate>"},
+		},
+		{
+			name:   "atom",
+			render: (*Feed).Atom,
+			items:  items,
+			want: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom">`,
+				`<id>https://example.com/feed.atom</id>`,
+				`<updated>2024-03-09T02:30:00Z</updated>`,
+				`<id>urn:fin-thread:news:abc</id>`,
+				`<link href="https://example.com/fed" rel="alternate"></link>`,
+				`<category term="SPY"></category>`,
+			},
+		},
+		{
+			name:   "empty atom",
+			render: (*Feed).Atom,
+			want:   []string{`<updated>1970-01-01T00:00:00Z</updated>`},
+			absent: []string{"<entry>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.render(NewFeed("https://example.com/"), tt.items)
+			if err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+			if err := xml.Unmarshal(got, new(struct{})); err != nil {
+				t.Fatalf("render() is not a valid XML: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("render() = %s, want to contain %s", got, want)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(string(got), absent) {
+					t.Errorf("render() = %s, want not to contain %s", got, absent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package publisher
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ModeMarkdownV2 is the Telegram MarkdownV2 parse mode (missing in tgbotapi v4).
+const ModeMarkdownV2 = "MarkdownV2"
+
+// defaultTickerURL is the link for the tickers, %s is replaced with the ticker.
+const defaultTickerURL = "https://short-fork.extr.app/en/%s?utm_source=finthread"
+
+// Message is the composed news to be formatted by the MessageFormatter.
+type Message struct {
+	Headline   string   // Headline of the news, rendered in bold (optional)
+	Text       string   // Text of the news
+	Tickers    []string // Tickers are linked in the text, the ones not found in the text are listed after it
+	Hashtags   []string // Hashtags without the "#"
+	SourceName string   // Name of the source for the link text (optional)
+	SourceURL  string   // Link to the original news (optional)
+	// Sources are the other sources of the story merged from several news, linked after the source (optional)
+	Sources []Source
+	// Changes are the day price changes of the tickers (e.g. 0.014 for +1.4%), shown after the ticker links (optional)
+	Changes map[string]float64
+}
+
+// Source is the other source of the merged story.
+type Source struct {
+	Name string // Name of the source for the link text
+	URL  string // Link to the news of the source
+}
+
+// MessageFormatter renders the composed news into the Telegram message in MarkdownV2 or HTML:
+// bold headline, text with inline $TICKER links, hashtags and the source link.
+type MessageFormatter struct {
+	Mode        string // tgbotapi.ModeHTML or ModeMarkdownV2
+	TickerURL   string // Link for the tickers, %s is replaced with the ticker
+	LinkPreview bool   // If true, Telegram shows the preview of the first link in the message
+}
+
+// NewMessageFormatter creates a new MessageFormatter for the parse mode (tgbotapi.ModeHTML or ModeMarkdownV2).
+// Link previews are disabled by default.
+func NewMessageFormatter(mode string) *MessageFormatter {
+	return &MessageFormatter{
+		Mode:      mode,
+		TickerURL: defaultTickerURL,
+	}
+}
+
+// WithLinkPreview enables or disables the link previews.
+func (f *MessageFormatter) WithLinkPreview(enabled bool) *MessageFormatter {
+	f.LinkPreview = enabled
+	return f
+}
+
+// Format renders the message.
+func (f *MessageFormatter) Format(m Message) string {
+	d := f.data(m)
+	parts := []string{d.Headline, d.Text, d.Tickers, d.Hashtags, d.Source}
+
+	var sb strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(part)
+	}
+
+	return sb.String()
+}
+
+// data renders the parts of the message for the parse mode, empty parts are not set.
+func (f *MessageFormatter) data(m Message) TemplateData {
+	d := TemplateData{Message: m}
+
+	if m.Headline != "" {
+		d.Headline = f.bold(m.Headline)
+	}
+
+	var unmatched []string
+	d.Text, unmatched = f.linkTickers(m.Text, m.Tickers, m.Changes)
+
+	if len(unmatched) > 0 {
+		links := make([]string, len(unmatched))
+		for i, t := range unmatched {
+			links[i] = f.tickerLink(t, m.Changes)
+		}
+		d.Tickers = strings.Join(links, " ")
+	}
+
+	if len(m.Hashtags) > 0 {
+		tags := make([]string, len(m.Hashtags))
+		for i, h := range m.Hashtags {
+			tags[i] = f.escape("#" + strings.TrimPrefix(h, "#"))
+		}
+		d.Hashtags = strings.Join(tags, " ")
+	}
+
+	links := make([]string, 0, len(m.Sources)+1)
+	if m.SourceURL != "" {
+		links = append(links, f.sourceLink(m.SourceName, m.SourceURL))
+	}
+	for _, s := range m.Sources {
+		links = append(links, f.sourceLink(s.Name, s.URL))
+	}
+	d.Source = strings.Join(links, ", ")
+
+	return d
+}
+
+// sourceLink returns the link to the source, "Source" is the link text if the name is empty.
+func (f *MessageFormatter) sourceLink(name, url string) string {
+	if name == "" {
+		name = "Source"
+	}
+	return f.link(name, url)
+}
+
+// linkTickers escapes the text and replaces the first occurrence of each ticker with the link.
+// Returns the tickers not found in the text.
+func (f *MessageFormatter) linkTickers(text string, tickers []string, changes map[string]float64) (string, []string) {
+	if len(tickers) == 0 {
+		return f.escape(text), nil
+	}
+
+	quoted := make([]string, len(tickers))
+	for i, t := range tickers {
+		quoted[i] = regexp.QuoteMeta(strings.TrimPrefix(t, "$"))
+	}
+	// Ticker can be written with "$" in the text, it is a part of the link then
+	re := regexp.MustCompile(`\$?\b(` + strings.Join(quoted, "|") + `)\b`)
+
+	var sb strings.Builder
+	linked := make(map[string]bool, len(tickers))
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		ticker := text[loc[2]:loc[3]]
+		if linked[ticker] {
+			continue
+		}
+		linked[ticker] = true
+
+		sb.WriteString(f.escape(text[last:loc[0]]))
+		sb.WriteString(f.tickerLink(ticker, changes))
+		last = loc[1]
+	}
+	sb.WriteString(f.escape(text[last:]))
+
+	var unmatched []string
+	for _, t := range tickers {
+		if t = strings.TrimPrefix(t, "$"); !linked[t] {
+			unmatched = append(unmatched, t)
+		}
+	}
+
+	return sb.String(), unmatched
+}
+
+// tickerLink returns the $TICKER link followed by the price change if known, e.g. "$AAPL +1.4%".
+func (f *MessageFormatter) tickerLink(ticker string, changes map[string]float64) string {
+	link := f.link("$"+ticker, fmt.Sprintf(f.TickerURL, ticker))
+	if change, ok := changes[ticker]; ok {
+		link += " " + f.escape(FormatChange(change))
+	}
+	return link
+}
+
+// FormatChange formats the relative price change as the percentage with the sign, e.g. "+1.4%".
+func FormatChange(change float64) string {
+	return fmt.Sprintf("%+.1f%%", change*100)
+}
+
+func (f *MessageFormatter) bold(s string) string {
+	if f.Mode == tgbotapi.ModeHTML {
+		return "<b>" + f.escape(s) + "</b>"
+	}
+	return "*" + f.escape(s) + "*"
+}
+
+func (f *MessageFormatter) link(text, url string) string {
+	if f.Mode == tgbotapi.ModeHTML {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), f.escape(text))
+	}
+	return fmt.Sprintf("[%s](%s)", f.escape(text), markdownV2URLReplacer.Replace(url))
+}
+
+// escape escapes the special characters of the parse mode.
+func (f *MessageFormatter) escape(s string) string {
+	if f.Mode == tgbotapi.ModeHTML {
+		return html.EscapeString(s)
+	}
+	return markdownV2Replacer.Replace(s)
+}
+
+var (
+	// markdownV2Replacer escapes all the characters reserved in MarkdownV2 text.
+	markdownV2Replacer = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+		">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	// markdownV2URLReplacer escapes the characters reserved in MarkdownV2 inline link URL.
+	markdownV2URLReplacer = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
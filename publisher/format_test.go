@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"testing"
+)
+
+func TestMessageFormatter_Format(t *testing.T) {
+	msg := Message{
+		Headline:   "Apple beats Q1 estimates (again)!",
+		Text:       "AAPL revenue rose 2.1% to $119.6B, MSFT and $AAPL peers lag.",
+		Tickers:    []string{"AAPL", "MSFT", "GOOG"},
+		Hashtags:   []string{"earnings", "#AI"},
+		SourceName: "cnbc",
+		SourceURL:  "https://www.cnbc.com/apple_(q1)",
+	}
+
+	tests := []struct {
+		name string
+		mode string
+		msg  Message
+		want string
+	}{
+		{
+			name: "markdown v2",
+			mode: ModeMarkdownV2,
+			msg:  msg,
+			want: "*Apple beats Q1 estimates \\(again\\)\\!*\n\n" +
+				"[$AAPL](https://short-fork.extr.app/en/AAPL?utm_source=finthread) revenue rose 2\\.1% to $119\\.6B, " +
+				"[$MSFT](https://short-fork.extr.app/en/MSFT?utm_source=finthread) and $AAPL peers lag\\.\n\n" +
+				"[$GOOG](https://short-fork.extr.app/en/GOOG?utm_source=finthread)\n\n" +
+				"\\#earnings \\#AI\n\n" +
+				"[cnbc](https://www.cnbc.com/apple_(q1\\))",
+		},
+		{
+			name: "html",
+			mode: tgbotapi.ModeHTML,
+			msg:  msg,
+			want: "<b>Apple beats Q1 estimates (again)!</b>\n\n" +
+				`<a href="https://short-fork.extr.app/en/AAPL?utm_source=finthread">$AAPL</a> revenue rose 2.1% to $119.6B, ` +
+				`<a href="https://short-fork.extr.app/en/MSFT?utm_source=finthread">$MSFT</a> and $AAPL peers lag.` + "\n\n" +
+				`<a href="https://short-fork.extr.app/en/GOOG?utm_source=finthread">$GOOG</a>` + "\n\n" +
+				"#earnings #AI\n\n" +
+				`<a href="https://www.cnbc.com/apple_(q1)">cnbc</a>`,
+		},
+		{
+			name: "html escaping and ticker with dollar",
+			mode: tgbotapi.ModeHTML,
+			msg: Message{
+				Text:    "$TSLA <up> & rising",
+				Tickers: []string{"TSLA"},
+			},
+			want: `<a href="https://short-fork.extr.app/en/TSLA?utm_source=finthread">$TSLA</a> &lt;up&gt; &amp; rising`,
+		},
+		{
+			name: "price changes",
+			mode: ModeMarkdownV2,
+			msg: Message{
+				Text:    "AAPL and MSFT rally.",
+				Tickers: []string{"AAPL", "MSFT", "GOOG"},
+				Changes: map[string]float64{"AAPL": 0.014, "GOOG": -0.0032},
+			},
+			want: "[$AAPL](https://short-fork.extr.app/en/AAPL?utm_source=finthread) \\+1\\.4% and " +
+				"[$MSFT](https://short-fork.extr.app/en/MSFT?utm_source=finthread) rally\\.\n\n" +
+				"[$GOOG](https://short-fork.extr.app/en/GOOG?utm_source=finthread) \\-0\\.3%",
+		},
+		{
+			name: "merged story sources",
+			mode: ModeMarkdownV2,
+			msg: Message{
+				Text:       "Fed holds rates.",
+				SourceName: "reuters",
+				SourceURL:  "https://reuters.com/fed",
+				Sources:    []Source{{Name: "cnbc", URL: "https://cnbc.com/fed"}, {URL: "https://apnews.com/fed"}},
+			},
+			want: "Fed holds rates\\.\n\n" +
+				"[reuters](https://reuters.com/fed), [cnbc](https://cnbc.com/fed), [Source](https://apnews.com/fed)",
+		},
+		{
+			name: "text only",
+			mode: ModeMarkdownV2,
+			msg:  Message{Text: "Fed holds rates."},
+			want: "Fed holds rates\\.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMessageFormatter(tt.mode).Format(tt.msg); got != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
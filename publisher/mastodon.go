@@ -0,0 +1,186 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// mastodonMaxLength is the default max length of the Mastodon status.
+	mastodonMaxLength = 500
+	// mastodonURLLength is the number of characters Mastodon counts for every URL regardless of its length.
+	mastodonURLLength = 23
+)
+
+// MastodonPublisher publishes messages as the statuses of the Mastodon account.
+// Markdown links are replaced with their text, long messages are truncated with the link to the full story.
+type MastodonPublisher struct {
+	InstanceURL   string // URL of the Mastodon instance (e.g. https://mastodon.social)
+	Token         string // Access token of the application with write:statuses and write:media scopes
+	MaxLength     int    // Max length of the status, 500 by default (some instances allow more)
+	ShouldPublish bool   // If false, will print the message to the console (for development)
+	client        *http.Client
+}
+
+func NewMastodonPublisher(instanceURL, token string, shouldPublish bool) *MastodonPublisher {
+	return &MastodonPublisher{
+		InstanceURL:   strings.TrimSuffix(instanceURL, "/"),
+		Token:         token,
+		MaxLength:     mastodonMaxLength,
+		ShouldPublish: shouldPublish,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithMaxLength sets the max length of the status of the instance.
+func (m *MastodonPublisher) WithMaxLength(maxLength int) *MastodonPublisher {
+	m.MaxLength = maxLength
+	return m
+}
+
+// Publish publishes the message as the new status. Returns the ID of the status.
+func (m *MastodonPublisher) Publish(msg string) (pubID string, err error) {
+	return m.PublishWithMedia(msg, Media{})
+}
+
+// PublishWithMedia publishes the message with the image uploaded to the instance. The message is truncated
+// to the length limit with the media Link appended. If the image can't be uploaded, the status is published without it.
+func (m *MastodonPublisher) PublishWithMedia(msg string, media Media) (pubID string, err error) {
+	status := mastodonStatus{Status: truncateWithLink(plainText(msg), media.Link, m.MaxLength, mastodonURLLength)}
+
+	if !m.ShouldPublish {
+		if !media.IsEmpty() {
+			fmt.Printf("[image] %s\n", media)
+		}
+		fmt.Println(status.Status)
+		return "", nil
+	}
+
+	if !media.IsEmpty() {
+		// Image is optional, the news is published without it
+		if id, err := m.uploadMedia(media); err == nil {
+			status.MediaIDs = []string{id}
+		}
+	}
+
+	body, err := json.Marshal(status)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to marshal Mastodon status: %w", err), errlvl.ERROR)
+	}
+
+	var created mastodonStatus
+	if err := m.do(http.MethodPost, "/api/v1/statuses", "application/json", body, &created); err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to publish Mastodon status: %w", err), errlvl.ERROR)
+	}
+
+	return created.ID, nil
+}
+
+// UpdatePublication edits the text of the status, the attached image is kept.
+func (m *MastodonPublisher) UpdatePublication(pubID, msg string) error {
+	if !m.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(mastodonStatus{Status: truncateWithLink(plainText(msg), "", m.MaxLength, 0)})
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to marshal Mastodon status: %w", err), errlvl.ERROR)
+	}
+
+	if err := m.do(http.MethodPut, "/api/v1/statuses/"+url.PathEscape(pubID), "application/json", body, nil); err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to update Mastodon status %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// DeletePublication deletes the status. Already deleted status is not an error.
+func (m *MastodonPublisher) DeletePublication(pubID string) error {
+	if !m.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	err := m.do(http.MethodDelete, "/api/v1/statuses/"+url.PathEscape(pubID), "", nil, nil)
+	if err != nil && !errors.Is(err, errMastodonNotFound) {
+		return errlvl.Wrap(fmt.Errorf("failed to delete Mastodon status %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// errMastodonNotFound is returned by do if the status doesn't exist.
+var errMastodonNotFound = errors.New("status not found")
+
+// uploadMedia uploads the image to the instance and returns the ID of the media attachment.
+func (m *MastodonPublisher) uploadMedia(media Media) (string, error) {
+	file, err := mediaBytes(m.client, media)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	f, err := w.CreateFormFile("file", media.fileName())
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(file); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	var attachment struct {
+		ID string `json:"id"`
+	}
+	if err := m.do(http.MethodPost, "/api/v2/media", w.FormDataContentType(), buf.Bytes(), &attachment); err != nil {
+		return "", err
+	}
+	return attachment.ID, nil
+}
+
+// do sends the authorized request to the instance API, checks the response status and decodes the response into out (optional).
+func (m *MastodonPublisher) do(method, path, contentType string, body []byte, out any) error {
+	req, err := http.NewRequest(method, m.InstanceURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errMastodonNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, msg)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// mastodonStatus is the part of the Mastodon status object used to publish it.
+type mastodonStatus struct {
+	ID       string   `json:"id,omitempty"`
+	Status   string   `json:"status"`
+	MediaIDs []string `json:"media_ids,omitempty"`
+}
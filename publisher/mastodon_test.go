@@ -0,0 +1,165 @@
+package publisher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMastodonPublisher_PublishWithMedia(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         string
+		media       Media
+		mediaStatus int
+		status      int
+		wantID      string
+		wantMedia   bool
+		wantLink    bool
+		wantErr     bool
+	}{
+		{
+			name:   "short status",
+			msg:    "[AAPL](https://example.com/AAPL) holds rates steady.",
+			status: http.StatusOK,
+			wantID: "1",
+		},
+		{
+			name:     "long status is truncated with link",
+			msg:      strings.Repeat("Stocks rallied today. ", 40),
+			media:    Media{Link: "https://example.com/full-story"},
+			status:   http.StatusOK,
+			wantID:   "1",
+			wantLink: true,
+		},
+		{
+			name:        "status with image",
+			msg:         "Fed holds rates steady.",
+			media:       Media{Bytes: []byte("png"), Name: "chart.png"},
+			mediaStatus: http.StatusOK,
+			status:      http.StatusOK,
+			wantID:      "1",
+			wantMedia:   true,
+		},
+		{
+			name:        "failed upload is skipped",
+			msg:         "Fed holds rates steady.",
+			media:       Media{Bytes: []byte("png")},
+			mediaStatus: http.StatusUnprocessableEntity,
+			status:      http.StatusOK,
+			wantID:      "1",
+		},
+		{
+			name:    "status error",
+			msg:     "Fed holds rates steady.",
+			status:  http.StatusUnauthorized,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got mastodonStatus
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+				}
+				switch r.URL.Path {
+				case "/api/v2/media":
+					w.WriteHeader(tt.mediaStatus)
+					_, _ = w.Write([]byte(`{"id":"m1"}`))
+				case "/api/v1/statuses":
+					_ = json.NewDecoder(r.Body).Decode(&got)
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(`{"id":"1"}`))
+				default:
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			m := NewMastodonPublisher(srv.URL+"/", "token", true)
+			id, err := m.PublishWithMedia(tt.msg, tt.media)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PublishWithMedia() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.wantID {
+				t.Errorf("PublishWithMedia() = %q, want %q", id, tt.wantID)
+			}
+			if strings.Contains(got.Status, "](") {
+				t.Errorf("status contains Markdown links: %q", got.Status)
+			}
+			if utf8.RuneCountInString(got.Status) > mastodonMaxLength {
+				t.Errorf("status length = %d, want at most %d", utf8.RuneCountInString(got.Status), mastodonMaxLength)
+			}
+			if gotLink := strings.HasSuffix(got.Status, "…\n\n"+tt.media.Link); gotLink != tt.wantLink {
+				t.Errorf("status ends with link = %v, want %v", gotLink, tt.wantLink)
+			}
+			if gotMedia := len(got.MediaIDs) == 1 && got.MediaIDs[0] == "m1"; gotMedia != tt.wantMedia {
+				t.Errorf("status media = %v, want %v", got.MediaIDs, tt.wantMedia)
+			}
+		})
+	}
+}
+
+func TestMastodonPublisher_UpdateDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		status     int
+		wantMethod string
+		wantErr    bool
+	}{
+		{
+			name:       "update",
+			method:     "update",
+			status:     http.StatusOK,
+			wantMethod: http.MethodPut,
+		},
+		{
+			name:       "update of deleted status",
+			method:     "update",
+			status:     http.StatusNotFound,
+			wantMethod: http.MethodPut,
+			wantErr:    true,
+		},
+		{
+			name:       "delete",
+			method:     "delete",
+			status:     http.StatusOK,
+			wantMethod: http.MethodDelete,
+		},
+		{
+			name:       "delete of deleted status",
+			method:     "delete",
+			status:     http.StatusNotFound,
+			wantMethod: http.MethodDelete,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotPath string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotPath = r.Method, r.URL.Path
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			m := NewMastodonPublisher(srv.URL, "token", true)
+			var err error
+			if tt.method == "update" {
+				err = m.UpdatePublication("42", "Fed cuts rates.")
+			} else {
+				err = m.DeletePublication("42")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotMethod != tt.wantMethod || gotPath != "/api/v1/statuses/42" {
+				t.Errorf("request = %s %s, want %s /api/v1/statuses/42", gotMethod, gotPath, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package publisher
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"strconv"
+	"unicode/utf8"
+)
+
+// telegramCaptionMaxLength is the max length of the Telegram media caption.
+const telegramCaptionMaxLength = 1024
+
+// Media is the image attached to the message, either by its URL or as the file.
+type Media struct {
+	URL   string // URL of the image fetched by the target, e.g. the enclosure of the RSS item
+	Bytes []byte // image file, e.g. the rendered price chart. Used if URL is empty
+	Name  string // file name of the Bytes, e.g. "chart.png" ("image.png" by default)
+	// Link is the URL of the full story, appended by the targets with the short length limit
+	// when they truncate the message (optional, not an image)
+	Link string
+}
+
+// fileName returns the file name of the Bytes.
+func (m Media) fileName() string {
+	if m.Name == "" {
+		return "image.png"
+	}
+	return m.Name
+}
+
+// IsEmpty returns true if the media has neither the URL nor the file (Link is not the image).
+func (m Media) IsEmpty() bool {
+	return m.URL == "" && len(m.Bytes) == 0
+}
+
+// String describes the media for the console output.
+func (m Media) String() string {
+	if m.URL != "" {
+		return m.URL
+	}
+	return fmt.Sprintf("%s (%d bytes)", m.fileName(), len(m.Bytes))
+}
+
+func (t *TelegramPublisher) PublishWithMedia(msg string, media Media) (pubID string, err error) {
+	return t.PublishWithMediaTo(t.ChannelID, msg, media)
+}
+
+// PublishWithMediaTo publishes the photo with the message as its caption to the given channel (name or chat id).
+// Messages longer than the caption limit or without the media are published as the text.
+func (t *TelegramPublisher) PublishWithMediaTo(channel, msg string, media Media) (pubID string, err error) {
+	if media.IsEmpty() || utf8.RuneCountInString(msg) > telegramCaptionMaxLength {
+		return t.PublishTo(channel, msg)
+	}
+
+	if !t.ShouldPublish {
+		fmt.Printf("[photo] %s\n%s\n", media, msg)
+		return "", nil
+	}
+
+	pubID, err = t.sendPhoto(channel, msg, tgbotapi.ModeMarkdown, media)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send photo to Telegram: %w", err), errlvl.ERROR)
+	}
+	return pubID, nil
+}
+
+// PublishMessageWithMedia formats the composed news with the Formatter and publishes the photo with it
+// as the caption to the given channel (name or chat id). Messages longer than the caption limit
+// or without the media are published as the text. Note: requires Formatter to be set.
+func (t *TelegramPublisher) PublishMessageWithMedia(channel string, m Message, media Media) (pubID string, err error) {
+	text, err := t.format(channel, m)
+	if err != nil {
+		return "", err
+	}
+
+	if media.IsEmpty() || utf8.RuneCountInString(text) > telegramCaptionMaxLength {
+		return t.PublishMessage(channel, m)
+	}
+
+	if !t.ShouldPublish {
+		fmt.Printf("[photo] %s\n%s\n", media, text)
+		return "", nil
+	}
+
+	pubID, err = t.sendPhoto(channel, text, t.Formatter.Mode, media)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send formatted photo to Telegram: %w", err), errlvl.ERROR)
+	}
+	return pubID, nil
+}
+
+// sendPhoto sends the photo by its URL or as the file with the caption to the channel (name or chat id),
+// into its forum topic if set, and returns the message ID.
+func (t *TelegramPublisher) sendPhoto(channel, caption, parseMode string, media Media) (string, error) {
+	chatID := t.ChatID(channel)
+	if topicID := t.TopicID(channel); topicID != 0 {
+		m, err := t.sendTopicPhoto(chatID, topicID, caption, parseMode, media)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(m.MessageID), nil
+	}
+
+	file := tgbotapi.BaseFile{BaseChat: tgbotapi.BaseChat{ChannelUsername: chatID}}
+	if media.URL != "" {
+		// Telegram downloads the photo by the URL itself
+		file.FileID = media.URL
+		file.UseExisting = true
+	} else {
+		file.File = tgbotapi.FileBytes{Name: media.fileName(), Bytes: media.Bytes}
+	}
+
+	m, err := t.send(chatID, tgbotapi.PhotoConfig{BaseFile: file, Caption: caption, ParseMode: parseMode})
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(m.MessageID), nil
+}
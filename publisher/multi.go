@@ -0,0 +1,142 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MultiPublisher fans the message out to several named publishers (e.g. to mirror the feed to Discord).
+type MultiPublisher struct {
+	targets []namedPublisher
+}
+
+type namedPublisher struct {
+	name      string
+	publisher Publisher
+}
+
+func NewMultiPublisher() *MultiPublisher {
+	return &MultiPublisher{}
+}
+
+// Add adds the publisher with the given target name (e.g. "discord").
+func (m *MultiPublisher) Add(name string, p Publisher) *MultiPublisher {
+	m.targets = append(m.targets, namedPublisher{name: name, publisher: p})
+	return m
+}
+
+// Len returns the number of targets.
+func (m *MultiPublisher) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.targets)
+}
+
+// Publish publishes the message to all targets and returns the publication ID of the first one.
+func (m *MultiPublisher) Publish(msg string) (pubID string, err error) {
+	ids, err := m.PublishAll(msg)
+	if m.Len() > 0 {
+		pubID = ids[m.targets[0].name]
+	}
+	return pubID, err
+}
+
+// PublishWithMedia publishes the message with the image to all targets and returns the publication ID of the first one.
+func (m *MultiPublisher) PublishWithMedia(msg string, media Media) (pubID string, err error) {
+	ids, err := m.PublishAllWithMedia(msg, media)
+	if m.Len() > 0 {
+		pubID = ids[m.targets[0].name]
+	}
+	return pubID, err
+}
+
+// PublishAll publishes the message to all targets and returns publication IDs by the target name.
+// Failed target doesn't stop the others, all errors are returned joined.
+func (m *MultiPublisher) PublishAll(msg string) (map[string]string, error) {
+	return m.publishAll(func(p Publisher) (string, error) {
+		return p.Publish(msg)
+	})
+}
+
+// PublishAllWithMedia publishes the message with the image to all targets and returns publication IDs
+// by the target name. Failed target doesn't stop the others, all errors are returned joined.
+func (m *MultiPublisher) PublishAllWithMedia(msg string, media Media) (map[string]string, error) {
+	return m.publishAll(func(p Publisher) (string, error) {
+		return p.PublishWithMedia(msg, media)
+	})
+}
+
+// publishAll calls publish for every target and collects the publication IDs by the target name.
+func (m *MultiPublisher) publishAll(publish func(p Publisher) (string, error)) (map[string]string, error) {
+	ids := make(map[string]string, m.Len())
+	if m == nil {
+		return ids, nil
+	}
+
+	var errs []error
+	for _, t := range m.targets {
+		id, err := publish(t.publisher)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.name, err))
+			continue
+		}
+		ids[t.name] = id
+	}
+
+	return ids, errors.Join(errs...)
+}
+
+// UpdatePublication updates the message in the first target, the same one Publish returns the ID of.
+// Use UpdateAll to update the message in all targets.
+func (m *MultiPublisher) UpdatePublication(pubID, msg string) error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m.UpdateAll(map[string]string{m.targets[0].name: pubID}, msg)
+}
+
+// DeletePublication deletes the message from the first target, the same one Publish returns the ID of.
+// Use DeleteAll to delete the message from all targets.
+func (m *MultiPublisher) DeletePublication(pubID string) error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m.DeleteAll(map[string]string{m.targets[0].name: pubID})
+}
+
+// UpdateAll updates the message in the targets by their publication IDs (see PublishAll).
+// Targets without the ID are skipped, all errors are returned joined.
+func (m *MultiPublisher) UpdateAll(ids map[string]string, msg string) error {
+	return m.each(ids, func(p Publisher, id string) error {
+		return p.UpdatePublication(id, msg)
+	})
+}
+
+// DeleteAll deletes the message from the targets by their publication IDs (see PublishAll).
+// Targets without the ID are skipped, all errors are returned joined.
+func (m *MultiPublisher) DeleteAll(ids map[string]string) error {
+	return m.each(ids, func(p Publisher, id string) error {
+		return p.DeletePublication(id)
+	})
+}
+
+// each calls fn for every target with the publication ID.
+func (m *MultiPublisher) each(ids map[string]string, fn func(p Publisher, id string) error) error {
+	if m == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, t := range m.targets {
+		id, ok := ids[t.name]
+		if !ok || id == "" {
+			continue
+		}
+		if err := fn(t.publisher, id); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,153 @@
+package publisher
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakePublisher struct {
+	id       string
+	err      error
+	messages []string
+	media    []Media
+	updated  map[string]string // text by publication ID
+	deleted  []string
+}
+
+func (f *fakePublisher) Publish(msg string) (string, error) {
+	f.messages = append(f.messages, msg)
+	return f.id, f.err
+}
+
+func (f *fakePublisher) PublishWithMedia(msg string, media Media) (string, error) {
+	f.media = append(f.media, media)
+	return f.Publish(msg)
+}
+
+func (f *fakePublisher) UpdatePublication(pubID, msg string) error {
+	if f.updated == nil {
+		f.updated = map[string]string{}
+	}
+	f.updated[pubID] = msg
+	return f.err
+}
+
+func (f *fakePublisher) DeletePublication(pubID string) error {
+	f.deleted = append(f.deleted, pubID)
+	return f.err
+}
+
+func TestMultiPublisher_PublishAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets map[string]*fakePublisher
+		order   []string
+		wantIDs map[string]string
+		wantErr bool
+	}{
+		{
+			name: "all targets",
+			targets: map[string]*fakePublisher{
+				"telegram": {id: "10"},
+				"discord":  {id: "20"},
+			},
+			order:   []string{"telegram", "discord"},
+			wantIDs: map[string]string{"telegram": "10", "discord": "20"},
+		},
+		{
+			name: "failed target doesn't stop others",
+			targets: map[string]*fakePublisher{
+				"discord":  {err: errors.New("webhook not found")},
+				"telegram": {id: "10"},
+			},
+			order:   []string{"discord", "telegram"},
+			wantIDs: map[string]string{"telegram": "10"},
+			wantErr: true,
+		},
+		{
+			name:    "no targets",
+			targets: map[string]*fakePublisher{},
+			wantIDs: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiPublisher()
+			for _, name := range tt.order {
+				m.Add(name, tt.targets[name])
+			}
+
+			got, err := m.PublishAll("news")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PublishAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("PublishAll() = %v, want %v", got, tt.wantIDs)
+			}
+			for name, p := range tt.targets {
+				if len(p.messages) != 1 {
+					t.Errorf("target %s got %d messages, want 1", name, len(p.messages))
+				}
+			}
+		})
+	}
+}
+
+func TestMultiPublisher_PublishWithMedia(t *testing.T) {
+	telegram := &fakePublisher{id: "10"}
+	discord := &fakePublisher{id: "20"}
+	m := NewMultiPublisher().Add("telegram", telegram).Add("discord", discord)
+	media := Media{URL: "https://example.com/chart.png"}
+
+	got, err := m.PublishWithMedia("news", media)
+	if err != nil || got != "10" {
+		t.Errorf("PublishWithMedia() = %q, %v, want the first target ID", got, err)
+	}
+	for name, p := range map[string]*fakePublisher{"telegram": telegram, "discord": discord} {
+		if !reflect.DeepEqual(p.media, []Media{media}) || !reflect.DeepEqual(p.messages, []string{"news"}) {
+			t.Errorf("target %s got media %v and messages %v", name, p.media, p.messages)
+		}
+	}
+}
+
+func TestMultiPublisher_UpdateAll_DeleteAll(t *testing.T) {
+	telegram := &fakePublisher{}
+	discord := &fakePublisher{err: errors.New("webhook not found")}
+	slack := &fakePublisher{}
+	m := NewMultiPublisher().Add("telegram", telegram).Add("discord", discord).Add("slack", slack)
+	ids := map[string]string{"telegram": "10", "discord": "20"}
+
+	if err := m.UpdateAll(ids, "corrected"); err == nil {
+		t.Errorf("UpdateAll() error = nil, want discord error")
+	}
+	if !reflect.DeepEqual(telegram.updated, map[string]string{"10": "corrected"}) {
+		t.Errorf("UpdateAll() telegram updated = %v", telegram.updated)
+	}
+	if !reflect.DeepEqual(discord.updated, map[string]string{"20": "corrected"}) {
+		t.Errorf("UpdateAll() discord updated = %v", discord.updated)
+	}
+	if slack.updated != nil {
+		t.Errorf("UpdateAll() updated target without publication: %v", slack.updated)
+	}
+
+	if err := m.DeleteAll(map[string]string{"telegram": "10"}); err != nil {
+		t.Errorf("DeleteAll() error = %v", err)
+	}
+	if !reflect.DeepEqual(telegram.deleted, []string{"10"}) || discord.deleted != nil || slack.deleted != nil {
+		t.Errorf("DeleteAll() deleted = %v, %v, %v, want only telegram", telegram.deleted, discord.deleted, slack.deleted)
+	}
+}
+
+func TestMultiPublisher_Nil(t *testing.T) {
+	var m *MultiPublisher
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", m.Len())
+	}
+	if ids, err := m.PublishAll("news"); err != nil || len(ids) != 0 {
+		t.Errorf("PublishAll() = %v, %v, want empty result", ids, err)
+	}
+	if err := m.UpdateAll(map[string]string{"telegram": "10"}, "news"); err != nil {
+		t.Errorf("UpdateAll() error = %v, want nil", err)
+	}
+}
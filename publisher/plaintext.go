@@ -0,0 +1,71 @@
+package publisher
+
+import (
+	"fmt"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// markdownLinkRe matches the inline Markdown link, e.g. "[AAPL](https://example.com)".
+var markdownLinkRe = regexp.MustCompile(`\[([^\]]+)\]\([^)\s]+\)`)
+
+// plainText replaces the Markdown links of the message with their text for the targets without Markdown support.
+func plainText(msg string) string {
+	return markdownLinkRe.ReplaceAllString(msg, "$1")
+}
+
+// truncateWithLink cuts the text to the limit of characters by sentences (or words for the long sentence)
+// and appends the ellipsis with the link to the full story. linkLength is the number of characters
+// the target counts for the link (e.g. Mastodon counts every URL as 23 characters).
+// The text is returned as is if it fits the limit.
+func truncateWithLink(text, link string, limit, linkLength int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	suffix := "…"
+	if link != "" {
+		suffix += "\n\n" + link
+		limit -= linkLength + 2
+	}
+
+	// Limit of SplitBySentences is in bytes, which are never fewer than the characters
+	parts := utils.SplitBySentences(text, max(limit-1, 1))
+	if len(parts) == 0 {
+		return strings.TrimPrefix(suffix, "…\n\n")
+	}
+	return strings.TrimRight(parts[0], " ,;:-") + suffix
+}
+
+// maxMediaSize is the max size of the image downloaded to upload it to the target.
+const maxMediaSize = 10 << 20
+
+// mediaBytes returns the file of the media, downloading it by the URL if needed.
+func mediaBytes(client *http.Client, media Media) ([]byte, error) {
+	if media.URL == "" {
+		return media.Bytes, nil
+	}
+
+	resp, err := client.Get(media.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", media.URL, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxMediaSize {
+		return nil, fmt.Errorf("failed to download %s: image is larger than %d bytes", media.URL, maxMediaSize)
+	}
+	return b, nil
+}
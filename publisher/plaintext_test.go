@@ -0,0 +1,89 @@
+package publisher
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func Test_plainText(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "no links",
+			msg:  "Fed holds rates steady.",
+			want: "Fed holds rates steady.",
+		},
+		{
+			name: "ticker links",
+			msg:  "[AAPL](https://short-fork.extr.app/en/AAPL?utm_source=finthread) and [MSFT](https://example.com/MSFT) rallied.",
+			want: "AAPL and MSFT rallied.",
+		},
+		{
+			name: "brackets without link",
+			msg:  "Revenue [unaudited] rose.",
+			want: "Revenue [unaudited] rose.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plainText(tt.msg); got != tt.want {
+				t.Errorf("plainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_truncateWithLink(t *testing.T) {
+	long := strings.Repeat("Stocks rallied today. ", 30)
+	tests := []struct {
+		name       string
+		text       string
+		link       string
+		limit      int
+		linkLength int
+		want       string
+	}{
+		{
+			name:  "fits the limit",
+			text:  "Fed holds rates steady.",
+			link:  "https://example.com/fed",
+			limit: 500,
+			want:  "Fed holds rates steady.",
+		},
+		{
+			name:       "truncated by sentences with link",
+			text:       long,
+			link:       "https://example.com/stocks",
+			limit:      100,
+			linkLength: 23,
+			want:       strings.TrimSpace(strings.Repeat("Stocks rallied today. ", 3)) + "…\n\nhttps://example.com/stocks",
+		},
+		{
+			name:  "truncated without link",
+			text:  long,
+			limit: 50,
+			want:  strings.TrimSpace(strings.Repeat("Stocks rallied today. ", 2)) + "…",
+		},
+		{
+			name:  "long sentence is cut by words",
+			text:  strings.Repeat("word ", 30),
+			limit: 22,
+			want:  "word word word word…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateWithLink(tt.text, tt.link, tt.limit, tt.linkLength)
+			if got != tt.want {
+				t.Errorf("truncateWithLink() = %q, want %q", got, tt.want)
+			}
+			if tt.link == "" && utf8.RuneCountInString(got) > tt.limit {
+				t.Errorf("truncateWithLink() length = %d, want at most %d", utf8.RuneCountInString(got), tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Poll is the Telegram poll with the votes of its options.
+type Poll struct {
+	ID          string       `json:"id"`                // Telegram poll id
+	Question    string       `json:"question"`          // Question of the poll
+	Options     []PollOption `json:"options"`           // Options in the published order
+	TotalVoters int          `json:"total_voter_count"` // Number of the users voted in the poll
+	IsClosed    bool         `json:"is_closed"`         // True if the poll is stopped
+}
+
+// PollOption is the answer option of the poll.
+type PollOption struct {
+	Text   string `json:"text"`
+	Voters int    `json:"voter_count"`
+}
+
+// pollMessage is the sent message with the poll, tgbotapi v4 doesn't support the polls.
+type pollMessage struct {
+	MessageID int  `json:"message_id"`
+	Poll      Poll `json:"poll"`
+}
+
+// PublishPoll publishes the anonymous poll with the question and the answer options to the given channel
+// (name or chat id), into its forum topic if set. Returns the ID of the message and the ID of the poll.
+// Telegram allows 2-10 options, the channels allow the anonymous polls only.
+func (t *TelegramPublisher) PublishPoll(channel, question string, options []string) (pubID, pollID string, err error) {
+	if !t.ShouldPublish {
+		fmt.Printf("[poll] %s\n- %s\n", question, strings.Join(options, "\n- "))
+		return "", "", nil
+	}
+
+	jsonOptions, err := json.Marshal(options)
+	if err != nil {
+		return "", "", errlvl.Wrap(fmt.Errorf("failed to marshal poll options: %w", err), errlvl.ERROR)
+	}
+
+	chatID := t.ChatID(channel)
+	params := url.Values{}
+	params.Set("chat_id", chatID)
+	params.Set("question", question)
+	params.Set("options", string(jsonOptions))
+	params.Set("is_anonymous", "true")
+	if topicID := t.TopicID(channel); topicID != 0 {
+		params.Set("message_thread_id", strconv.Itoa(topicID))
+	}
+
+	var m pollMessage
+	if err := t.call(chatID, "sendPoll", params, &m); err != nil {
+		return "", "", errlvl.Wrap(fmt.Errorf("failed to send poll to Telegram: %w", err), errlvl.ERROR)
+	}
+	return strconv.Itoa(m.MessageID), m.Poll.ID, nil
+}
+
+// StopPoll stops the poll published with PublishPoll in the given channel (name or chat id)
+// and returns its final results. Returns nil if the publishing is disabled.
+func (t *TelegramPublisher) StopPoll(channel, pubID string) (*Poll, error) {
+	if !t.ShouldPublish || pubID == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	chatID := t.ChatID(channel)
+	params := url.Values{}
+	params.Set("chat_id", chatID)
+	params.Set("message_id", pubID)
+
+	var p Poll
+	if err := t.call(chatID, "stopPoll", params, &p); err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to stop poll %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return &p, nil
+}
+
+// call calls the Bot API method with retries and decodes its result. Each attempt waits for the rate limiter.
+func (t *TelegramPublisher) call(chatID, method string, params url.Values, result any) error {
+	return t.retrier.Do(t.context(), func() error {
+		if err := t.wait(chatID); err != nil {
+			return err
+		}
+		resp, err := t.BotAPI.MakeRequest(method, params)
+		t.countSend(err)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(resp.Result, result)
+	})
+}
@@ -0,0 +1,82 @@
+package publisher
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pollTestPublisher returns the publisher which Bot API answers with the result and records the request.
+func pollTestPublisher(result string, method *string, params *url.Values) *TelegramPublisher {
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) *http.Response {
+		*method = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		_ = r.ParseForm()
+		*params = r.PostForm
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true,"result":` + result + `}`)),
+		}
+	})}
+	p := &TelegramPublisher{
+		BotAPI:        &tgbotapi.BotAPI{Token: "token", Client: client},
+		ShouldPublish: true,
+	}
+	return p.WithChannels(map[string]string{"crypto": "-1001234567890"}).WithTopics(map[string]int{"crypto": 5})
+}
+
+func TestTelegramPublisher_PublishPoll(t *testing.T) {
+	var method string
+	var params url.Values
+	p := pollTestPublisher(`{"message_id":42,"chat":{"id":-1001234567890},"poll":{"id":"5001","question":"Mood?"}}`, &method, &params)
+
+	pubID, pollID, err := p.PublishPoll("crypto", "Mood?", []string{"Bullish", "Bearish"})
+	if err != nil {
+		t.Fatalf("PublishPoll() error = %v", err)
+	}
+	if pubID != "42" || pollID != "5001" {
+		t.Errorf("PublishPoll() = %s, %s, want 42, 5001", pubID, pollID)
+	}
+	if method != "sendPoll" {
+		t.Errorf("PublishPoll() method = %s, want sendPoll", method)
+	}
+	want := url.Values{
+		"chat_id":           {"-1001234567890"},
+		"message_thread_id": {"5"},
+		"question":          {"Mood?"},
+		"options":           {`["Bullish","Bearish"]`},
+		"is_anonymous":      {"true"},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("PublishPoll() params = %v, want %v", params, want)
+	}
+}
+
+func TestTelegramPublisher_StopPoll(t *testing.T) {
+	var method string
+	var params url.Values
+	p := pollTestPublisher(`{"id":"5001","question":"Mood?","options":[{"text":"Bullish","voter_count":7},`+
+		`{"text":"Bearish","voter_count":3}],"total_voter_count":10,"is_closed":true}`, &method, &params)
+
+	got, err := p.StopPoll("@macro", "42")
+	if err != nil {
+		t.Fatalf("StopPoll() error = %v", err)
+	}
+	want := &Poll{
+		ID:          "5001",
+		Question:    "Mood?",
+		Options:     []PollOption{{Text: "Bullish", Voters: 7}, {Text: "Bearish", Voters: 3}},
+		TotalVoters: 10,
+		IsClosed:    true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StopPoll() = %+v, want %+v", got, want)
+	}
+	if method != "stopPoll" || params.Get("chat_id") != "@macro" || params.Get("message_id") != "42" {
+		t.Errorf("StopPoll() request = %s %v, want stopPoll of the message 42 in @macro", method, params)
+	}
+}
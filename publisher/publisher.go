@@ -1,16 +1,43 @@
 package publisher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/pkg/metrics"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Publisher publishes messages to the target (Telegram channel, Discord webhook, etc.).
+type Publisher interface {
+	// Publish publishes the message and returns its publication ID in the target.
+	Publish(msg string) (pubID string, err error)
+	// PublishWithMedia publishes the message with the attached image and returns its publication ID in the target.
+	PublishWithMedia(msg string, media Media) (pubID string, err error)
+	// UpdatePublication replaces the text of the published message (e.g. to correct the news).
+	UpdatePublication(pubID, msg string) error
+	// DeletePublication deletes the published message (e.g. when the source retracted the news).
+	DeletePublication(pubID string) error
+}
+
 type TelegramPublisher struct {
-	ChannelID     string // Telegram channel id (e.g. @my_channel)
+	ChannelID     string            // Telegram channel id (e.g. @my_channel)
+	Channels      map[string]string // Named channels for routing (e.g. "crypto" -> "@my_crypto_channel")
+	Topics        map[string]int    // Forum topics of the named channels (e.g. "crypto" -> 5), see WithTopics
 	BotAPI        *tgbotapi.BotAPI
-	ShouldPublish bool // If false, will print the message to the console (for development)
+	ShouldPublish bool                        // If false, will print the message to the console (for development)
+	Formatter     *MessageFormatter           // Formats the messages for PublishMessage (optional)
+	Templates     map[string]*MessageTemplate // Message templates of the named channels, see WithTemplates
+	retrier       *Retrier                    // Retries failed requests, if nil requests are sent only once
+	limiter       *RateLimiter                // Limits the rate of the sent messages, if nil messages are sent immediately
+	metrics       metrics.Emitter             // Counts the sent requests by status (optional)
+	priority      bool                        // If true, the messages skip the rate limiter queue (see Priority)
+	ctx           context.Context             // Stops waiting for the retries when done (see WithContext), background if nil
 }
 
 func NewTelegramPublisher(channelID string, token string, shouldPublish bool) (*TelegramPublisher, error) {
@@ -25,19 +52,354 @@ func NewTelegramPublisher(channelID string, token string, shouldPublish bool) (*
 	}, nil
 }
 
+// WithChannels sets the named channels, so the news can be routed to them by name.
+func (t *TelegramPublisher) WithChannels(channels map[string]string) *TelegramPublisher {
+	t.Channels = channels
+	return t
+}
+
+// WithRetry enables retries of the failed requests (rate limits, server and network errors)
+// with exponential backoff. Telegram's retry_after is honored if it doesn't exceed maxDelay.
+func (t *TelegramPublisher) WithRetry(attempts int, baseDelay, maxDelay time.Duration) *TelegramPublisher {
+	t.retrier = NewRetrier(attempts, baseDelay, maxDelay).WithClassifier(telegramRetryable)
+	return t
+}
+
+// WithRateLimit limits the number of messages per minute sent to the same chat and to all chats.
+// Sends over the limit wait for their turn, 0 disables the limit.
+func (t *TelegramPublisher) WithRateLimit(perChat, global int) *TelegramPublisher {
+	if perChat > 0 || global > 0 {
+		t.limiter = NewRateLimiter(perChat, global)
+	}
+	return t
+}
+
+// Priority returns the copy of the publisher which messages skip the queue of the rate limiter (e.g. for the breaking news).
+// The copy shares the bot, retries and rate limits with the original publisher.
+func (t *TelegramPublisher) Priority() *TelegramPublisher {
+	p := *t
+	p.priority = true
+	return &p
+}
+
+// WithContext returns the copy of the publisher which retries of the failed requests stop when the context is done
+// (e.g. the publish stage of the job timed out). The copy shares the bot, retries and rate limits with the original publisher.
+func (t *TelegramPublisher) WithContext(ctx context.Context) *TelegramPublisher {
+	p := *t
+	p.ctx = ctx
+	return &p
+}
+
+// context returns the context of the requests, see WithContext.
+func (t *TelegramPublisher) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// WithMetrics sets the metrics emitter for the successful and failed requests.
+func (t *TelegramPublisher) WithMetrics(m metrics.Emitter) *TelegramPublisher {
+	t.metrics = m
+	return t
+}
+
+// WithFormatter sets the MessageFormatter for the composed news (see PublishMessage).
+func (t *TelegramPublisher) WithFormatter(f *MessageFormatter) *TelegramPublisher {
+	t.Formatter = f
+	return t
+}
+
+// WithTemplates sets the message templates of the named channels, so the composed news published
+// with PublishMessage to the channel are rendered with its template instead of the default layout.
+func (t *TelegramPublisher) WithTemplates(templates map[string]*MessageTemplate) *TelegramPublisher {
+	t.Templates = templates
+	return t
+}
+
+// format renders the composed news with the template of the channel or with the default layout of the Formatter.
+func (t *TelegramPublisher) format(channel string, m Message) (string, error) {
+	if t.Formatter == nil {
+		return "", errlvl.Wrap(errors.New("message formatter is not set"), errlvl.ERROR)
+	}
+
+	tmpl, ok := t.Templates[channel]
+	if !ok {
+		return t.Formatter.Format(m), nil
+	}
+
+	text, err := t.Formatter.FormatTemplate(m, tmpl)
+	if err != nil {
+		return "", errlvl.Wrap(err, errlvl.ERROR)
+	}
+	return text, nil
+}
+
+// ChatID resolves the channel name to its chat id. Empty channel is the default one,
+// unknown names are treated as chat ids (e.g. "@my_channel").
+func (t *TelegramPublisher) ChatID(channel string) string {
+	if t == nil {
+		return channel
+	}
+	if channel == "" {
+		return t.ChannelID
+	}
+	if chatID, ok := t.Channels[channel]; ok {
+		return chatID
+	}
+	return channel
+}
+
+// Ping checks that the Telegram Bot API is reachable and the token is valid.
+func (t *TelegramPublisher) Ping() error {
+	if _, err := t.BotAPI.GetMe(); err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to reach Telegram: %w", err), errlvl.ERROR)
+	}
+	return nil
+}
+
 func (t *TelegramPublisher) Publish(msg string) (pubID string, err error) {
+	return t.PublishTo(t.ChannelID, msg)
+}
+
+// PublishTo publishes the message to the given channel (name or chat id) instead of the default one.
+func (t *TelegramPublisher) PublishTo(channel, msg string) (pubID string, err error) {
 	if !t.ShouldPublish {
 		fmt.Println(msg)
 		return "", nil
 	}
 
-	tgMsg := tgbotapi.NewMessageToChannel(t.ChannelID, msg)
-	tgMsg.ParseMode = tgbotapi.ModeMarkdown
-	tgMsg.DisableWebPagePreview = true
-
-	m, err := t.BotAPI.Send(tgMsg)
+	m, err := t.sendText(channel, msg, tgbotapi.ModeMarkdown, false, 0)
 	if err != nil {
 		return "", errlvl.Wrap(fmt.Errorf("failed to send message to Telegram: %w", err), errlvl.ERROR)
 	}
 	return strconv.Itoa(m.MessageID), nil
 }
+
+// PublishMessage formats the composed news with the Formatter and publishes it to the given channel
+// (name or chat id). Named channels with the template are rendered with it, see WithTemplates.
+// Note: requires Formatter to be set.
+func (t *TelegramPublisher) PublishMessage(channel string, m Message) (pubID string, err error) {
+	text, err := t.format(channel, m)
+	if err != nil {
+		return "", err
+	}
+
+	if !t.ShouldPublish {
+		fmt.Println(text)
+		return "", nil
+	}
+
+	msg, err := t.sendText(channel, text, t.Formatter.Mode, t.Formatter.LinkPreview, 0)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send formatted message to Telegram: %w", err), errlvl.ERROR)
+	}
+	return strconv.Itoa(msg.MessageID), nil
+}
+
+// PublishThread publishes the parts as a linked chain of messages, each one replying to the previous.
+// Returns the ID of the first message of the thread.
+func (t *TelegramPublisher) PublishThread(channel string, parts []string) (pubID string, err error) {
+	if !t.ShouldPublish {
+		fmt.Println(strings.Join(parts, "\n---\n"))
+		return "", nil
+	}
+
+	replyTo := 0
+	for _, part := range parts {
+		m, err := t.sendText(channel, part, tgbotapi.ModeMarkdown, false, replyTo)
+		if err != nil {
+			return pubID, errlvl.Wrap(fmt.Errorf("failed to send thread message to Telegram: %w", err), errlvl.ERROR)
+		}
+		if pubID == "" {
+			pubID = strconv.Itoa(m.MessageID)
+		}
+		replyTo = m.MessageID
+	}
+
+	return pubID, nil
+}
+
+// PublishAudio publishes the MP3 audio file with the given title and caption to the default channel.
+func (t *TelegramPublisher) PublishAudio(title, caption string, audio []byte) (pubID string, err error) {
+	if !t.ShouldPublish {
+		fmt.Printf("[audio] %s (%d bytes)\n%s\n", title, len(audio), caption)
+		return "", nil
+	}
+
+	tgAudio := tgbotapi.AudioConfig{
+		BaseFile: tgbotapi.BaseFile{
+			BaseChat: tgbotapi.BaseChat{ChannelUsername: t.ChannelID},
+			File:     tgbotapi.FileBytes{Name: title + ".mp3", Bytes: audio},
+		},
+		Title:     title,
+		Performer: t.ChannelID,
+		Caption:   caption,
+		ParseMode: tgbotapi.ModeMarkdown,
+	}
+
+	m, err := t.send(t.ChannelID, tgAudio)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send audio to Telegram: %w", err), errlvl.ERROR)
+	}
+	return strconv.Itoa(m.MessageID), nil
+}
+
+// Pin pins the published message in the given channel (name or chat id) without notification.
+// The bot has to be the channel admin with the permission to pin messages.
+func (t *TelegramPublisher) Pin(channel, pubID string) error {
+	if !t.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	// PinChatMessageConfig of tgbotapi v4 doesn't support the channel usernames (e.g. @my_channel)
+	params := url.Values{}
+	params.Set("chat_id", t.ChatID(channel))
+	params.Set("message_id", pubID)
+	params.Set("disable_notification", "true")
+
+	err := t.retrier.Do(t.context(), func() error {
+		_, err := t.BotAPI.MakeRequest("pinChatMessage", params)
+		return err
+	})
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to pin message %s: %w", pubID, err), errlvl.WARN)
+	}
+	return nil
+}
+
+func (t *TelegramPublisher) UpdatePublication(pubID, msg string) error {
+	return t.UpdatePublicationIn(t.ChannelID, pubID, msg)
+}
+
+// UpdatePublicationIn replaces the text of the message published with PublishTo in the given channel (name or chat id).
+// For the threads only the first message is updated.
+func (t *TelegramPublisher) UpdatePublicationIn(channel, pubID, msg string) error {
+	if !t.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	if err := t.edit(t.ChatID(channel), pubID, msg, tgbotapi.ModeMarkdown, false); err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to update message %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// UpdateMessage formats the composed news with the Formatter and replaces the text of the message
+// published with PublishMessage in the given channel (name or chat id). Note: requires Formatter to be set.
+func (t *TelegramPublisher) UpdateMessage(channel, pubID string, m Message) error {
+	text, err := t.format(channel, m)
+	if err != nil {
+		return err
+	}
+
+	if !t.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	if err := t.edit(t.ChatID(channel), pubID, text, t.Formatter.Mode, t.Formatter.LinkPreview); err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to update formatted message %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+func (t *TelegramPublisher) DeletePublication(pubID string) error {
+	return t.DeletePublicationIn(t.ChannelID, pubID)
+}
+
+// DeletePublicationIn deletes the published message from the given channel (name or chat id).
+// Already deleted message is not an error. Note: Telegram allows the bot to delete messages in the channel
+// only if it is the channel admin with the permission to delete messages.
+func (t *TelegramPublisher) DeletePublicationIn(channel, pubID string) error {
+	if !t.ShouldPublish || pubID == "" {
+		return nil
+	}
+
+	chatID := t.ChatID(channel)
+	params := url.Values{}
+	params.Set("chat_id", chatID)
+	params.Set("message_id", pubID)
+
+	err := t.request(chatID, "deleteMessage", params)
+	if err != nil && !isTelegramError(err, "message to delete not found") {
+		return errlvl.Wrap(fmt.Errorf("failed to delete message %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// edit replaces the text of the message. Unchanged text is not an error.
+func (t *TelegramPublisher) edit(chatID, pubID, text, parseMode string, linkPreview bool) error {
+	// EditMessageTextConfig of tgbotapi v4 doesn't support the channel usernames (e.g. @my_channel)
+	params := url.Values{}
+	params.Set("chat_id", chatID)
+	params.Set("message_id", pubID)
+	params.Set("text", text)
+	params.Set("parse_mode", parseMode)
+	params.Set("disable_web_page_preview", strconv.FormatBool(!linkPreview))
+
+	err := t.request(chatID, "editMessageText", params)
+	if isTelegramError(err, "there is no text in the message to edit") {
+		// Message was published with the media, its text is the caption
+		params.Del("text")
+		params.Del("disable_web_page_preview")
+		params.Set("caption", text)
+		err = t.request(chatID, "editMessageCaption", params)
+	}
+	if err != nil && !isTelegramError(err, "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// request calls the Bot API method with retries. Each attempt waits for the rate limiter.
+func (t *TelegramPublisher) request(chatID, method string, params url.Values) error {
+	return t.retrier.Do(t.context(), func() error {
+		if err := t.wait(chatID); err != nil {
+			return err
+		}
+		_, err := t.BotAPI.MakeRequest(method, params)
+		t.countSend(err)
+		return err
+	})
+}
+
+// isTelegramError returns true if the error is the Telegram API error containing the message.
+func isTelegramError(err error, message string) bool {
+	var tgErr tgbotapi.Error
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Message, message)
+}
+
+// send sends the message to the chat with retries. Each attempt waits for the rate limiter.
+func (t *TelegramPublisher) send(chatID string, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var m tgbotapi.Message
+	err := t.retrier.Do(t.context(), func() error {
+		if err := t.wait(chatID); err != nil {
+			return err
+		}
+		var err error
+		m, err = t.BotAPI.Send(c)
+		t.countSend(err)
+		return err
+	})
+	return m, err
+}
+
+// wait blocks until the message can be sent to the chat by the rate limits or the context of the publisher is done.
+func (t *TelegramPublisher) wait(chatID string) error {
+	if t.priority {
+		return t.limiter.WaitPriority(t.context(), chatID)
+	}
+	return t.limiter.Wait(t.context(), chatID)
+}
+
+// countSend counts the request by its status, each retry attempt is counted separately.
+func (t *TelegramPublisher) countSend(err error) {
+	if t.metrics == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	t.metrics.Count(metrics.PublisherSends, 1, metrics.T("target", "telegram"), metrics.T("status", status))
+}
@@ -0,0 +1,143 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of the sent messages per chat and globally with token buckets, so the concurrent
+// jobs queue their sends instead of hitting the Telegram flood limits. Nil RateLimiter doesn't limit anything.
+type RateLimiter struct {
+	PerChat int // Max messages per minute to the same chat, 0 - unlimited
+	Global  int // Max messages per minute to all chats, 0 - unlimited
+
+	mu     sync.Mutex
+	global *tokenBucket
+	chats  map[string]*tokenBucket
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimiter creates a new RateLimiter with the given number of messages per minute per chat and globally.
+// Burst is the same as the rate, so up to a minute's worth of messages can be sent at once after the idle period.
+func NewRateLimiter(perChat, global int) *RateLimiter {
+	r := &RateLimiter{
+		PerChat: perChat,
+		Global:  global,
+		chats:   make(map[string]*tokenBucket),
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+	if global > 0 {
+		r.global = newTokenBucket(global, r.now())
+	}
+	return r
+}
+
+// Wait blocks until the message can be sent to the chat or the context is done. If the context is done,
+// the reserved token is returned, so the next callers don't queue behind the message that is not sent.
+func (r *RateLimiter) Wait(ctx context.Context, chatID string) error {
+	return r.wait(ctx, chatID, false)
+}
+
+// WaitPriority blocks until the priority message (e.g. breaking news) can be sent to the chat or the context is done.
+// Priority messages don't queue behind the waiting ones, they wait for one token at most.
+func (r *RateLimiter) WaitPriority(ctx context.Context, chatID string) error {
+	return r.wait(ctx, chatID, true)
+}
+
+func (r *RateLimiter) wait(ctx context.Context, chatID string, priority bool) error {
+	if r == nil {
+		return nil
+	}
+	d := r.reserve(chatID, priority)
+	if d <= 0 {
+		return nil
+	}
+	if err := r.sleep(ctx, d); err != nil {
+		r.release(chatID)
+		return fmt.Errorf("rate limit wait canceled: %w", err)
+	}
+	return nil
+}
+
+// reserve takes the tokens from the chat and global buckets and returns the delay before the message can be sent.
+// The tokens are taken in advance, so the next callers queue behind the waiting ones.
+func (r *RateLimiter) reserve(chatID string, priority bool) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var wait time.Duration
+	if r.global != nil {
+		wait = r.global.reserve(now, priority)
+	}
+
+	if r.PerChat > 0 {
+		b, ok := r.chats[chatID]
+		if !ok {
+			b = newTokenBucket(r.PerChat, now)
+			r.chats[chatID] = b
+		}
+		wait = max(wait, b.reserve(now, priority))
+	}
+
+	return wait
+}
+
+// release returns the token reserved for the message that is not sent to the chat and global buckets.
+func (r *RateLimiter) release(chatID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.global != nil {
+		r.global.release()
+	}
+	if b, ok := r.chats[chatID]; ok {
+		b.release()
+	}
+}
+
+// tokenBucket is refilled with perMinute tokens per minute up to perMinute tokens.
+// Tokens can go negative, which means the messages are queued.
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	perSec   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens:   float64(perMinute),
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+// reserve takes one token and returns the delay until it is available. Priority reservations
+// skip the queue, so their delay is not longer than the refill time of one token.
+func (b *tokenBucket) reserve(now time.Time, priority bool) time.Duration {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed*b.perSec)
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	wait := -b.tokens
+	if priority {
+		wait = min(wait, 1)
+	}
+	return time.Duration(wait / b.perSec * float64(time.Second))
+}
+
+// release returns one reserved token.
+func (b *tokenBucket) release() {
+	b.tokens = min(b.capacity, b.tokens+1)
+}
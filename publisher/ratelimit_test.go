@@ -0,0 +1,146 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	tests := []struct {
+		name      string
+		perChat   int
+		global    int
+		chats     []string // chats of the consecutive sends at the same moment
+		wantSleep []time.Duration
+	}{
+		{
+			name:      "within the burst",
+			perChat:   3,
+			chats:     []string{"a", "a", "a"},
+			wantSleep: nil,
+		},
+		{
+			name:      "per chat limit queues the sends",
+			perChat:   2,
+			chats:     []string{"a", "a", "a", "a"},
+			wantSleep: []time.Duration{30 * time.Second, time.Minute},
+		},
+		{
+			name:      "chats are limited separately",
+			perChat:   1,
+			chats:     []string{"a", "b", "a"},
+			wantSleep: []time.Duration{time.Minute},
+		},
+		{
+			name:      "global limit",
+			perChat:   10,
+			global:    2,
+			chats:     []string{"a", "b", "c"},
+			wantSleep: []time.Duration{30 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			var slept []time.Duration
+			r := NewRateLimiter(tt.perChat, tt.global)
+			r.now = func() time.Time { return now }
+			r.sleep = func(_ context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			for _, chat := range tt.chats {
+				_ = r.Wait(context.Background(), chat)
+			}
+
+			if len(slept) != len(tt.wantSleep) {
+				t.Fatalf("slept %v, want %v", slept, tt.wantSleep)
+			}
+			for i := range slept {
+				if slept[i] != tt.wantSleep[i] {
+					t.Errorf("sleep[%d] = %s, want %s", i, slept[i], tt.wantSleep[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	r := NewRateLimiter(1, 0)
+	r.now = func() time.Time { return now }
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	_ = r.Wait(context.Background(), "a")
+	now = now.Add(time.Minute)
+	_ = r.Wait(context.Background(), "a")
+
+	if len(slept) != 0 {
+		t.Errorf("slept %v after the bucket refill, want no sleep", slept)
+	}
+}
+
+func TestRateLimiter_WaitPriority(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	r := NewRateLimiter(60, 0) // one token per second
+	r.now = func() time.Time { return now }
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	// Bucket is emptied by 60 messages and 4 more are queued
+	for i := 0; i < 64; i++ {
+		_ = r.Wait(context.Background(), "a")
+	}
+	_ = r.WaitPriority(context.Background(), "a")
+	_ = r.Wait(context.Background(), "a")
+
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, time.Second, 6 * time.Second}
+	if !reflect.DeepEqual(slept, want) {
+		t.Errorf("slept %v, want %v", slept, want)
+	}
+}
+
+func TestRateLimiter_Wait_canceled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRateLimiter(1, 1)
+	r.now = func() time.Time { return now }
+
+	if err := r.Wait(context.Background(), "a"); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	// The next message waits for a minute until it is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	err := r.Wait(ctx, "a")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait() returned after %v, want right after the cancel", elapsed)
+	}
+
+	// The canceled message doesn't hold its token in the chat and global buckets
+	if d := r.reserve("a", false); d != time.Minute {
+		t.Errorf("reserve() after the canceled wait = %v, want %v", d, time.Minute)
+	}
+}
+
+func TestRateLimiter_Nil(t *testing.T) {
+	var r *RateLimiter
+	if err := r.Wait(context.Background(), "a"); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+}
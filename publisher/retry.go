@@ -0,0 +1,141 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+)
+
+// logger is the logger of the publishers.
+var logger = logging.For(logging.ModulePublisher)
+
+// Retrier retries the failed publications with exponential backoff and jitter.
+// Nil Retrier calls the function only once.
+type Retrier struct {
+	Attempts  int           // Max number of attempts including the first one
+	BaseDelay time.Duration // Delay before the first retry, doubled for each next one
+	MaxDelay  time.Duration // Max delay between attempts, longer retry-after delays are not awaited
+	// Retryable classifies the error: whether it should be retried and after which delay
+	// (0 to use the backoff). All errors are retried if nil.
+	Retryable func(err error) (retry bool, after time.Duration)
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetrier creates a new Retrier that retries all errors.
+func NewRetrier(attempts int, baseDelay, maxDelay time.Duration) *Retrier {
+	return &Retrier{
+		Attempts:  attempts,
+		BaseDelay: baseDelay,
+		MaxDelay:  maxDelay,
+		sleep:     sleepContext,
+	}
+}
+
+// sleepContext waits for the duration or until the context is done, returns the context error in the latter case.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WithClassifier sets the function that decides which errors should be retried.
+func (r *Retrier) WithClassifier(retryable func(err error) (retry bool, after time.Duration)) *Retrier {
+	r.Retryable = retryable
+	return r
+}
+
+// Do calls fn until it succeeds, returns a permanent error, the attempts are exhausted or the context is done
+// while waiting for the next attempt. Returns the last error.
+func (r *Retrier) Do(ctx context.Context, fn func() error) error {
+	if r == nil {
+		return fn()
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= r.Attempts {
+			return err
+		}
+
+		retry, after := true, time.Duration(0)
+		if r.Retryable != nil {
+			retry, after = r.Retryable(err)
+		}
+		if !retry {
+			return err
+		}
+		if after > r.MaxDelay {
+			return fmt.Errorf("retry after %s exceeds max delay: %w", after, err)
+		}
+		if after == 0 {
+			after = r.backoff(attempt)
+		}
+
+		logger.Debug("[publisher] Retrying failed publication", "attempt", attempt, "after", after, "error", err)
+		if ctxErr := r.sleep(ctx, after); ctxErr != nil {
+			return fmt.Errorf("retry canceled (%w): %w", ctxErr, err)
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt: exponential delay capped by MaxDelay
+// with the "equal jitter" (random value in the upper half of the delay).
+func (r *Retrier) backoff(attempt int) time.Duration {
+	d := r.BaseDelay << (attempt - 1)
+	if d > r.MaxDelay || d <= 0 {
+		d = r.MaxDelay
+	}
+	if d < 2 {
+		return d
+	}
+
+	half := d / 2
+	return half + rand.N(half) //nolint:gosec
+}
+
+// telegramRetryable retries Telegram rate limits (using retry_after), server errors and the network errors
+// of the requests that didn't reach Telegram. Other Telegram API errors (bad request, forbidden, etc.) are permanent.
+// Other errors (e.g. the client timeout or the broken response) are not retried, because the message may be
+// already sent, and it's better to miss the news than to publish it twice.
+func telegramRetryable(err error) (retry bool, after time.Duration) {
+	var tgErr tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return notSent(err), 0
+	}
+
+	if tgErr.RetryAfter > 0 {
+		return true, time.Duration(tgErr.RetryAfter) * time.Second
+	}
+
+	for _, s := range []string{"Too Many Requests", "Internal Server Error", "Bad Gateway", "Service Unavailable", "Gateway Timeout"} {
+		if strings.Contains(tgErr.Message, s) {
+			return true, 0
+		}
+	}
+
+	return false, 0
+}
+
+// notSent returns true if the request failed before it was sent: the host wasn't resolved or the connection
+// wasn't established.
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,189 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetrier_Do(t *testing.T) {
+	transient := &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	permanent := tgbotapi.Error{Message: "Bad Request: chat not found"}
+	rateLimited := tgbotapi.Error{Message: "Too Many Requests: retry after 3", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}
+
+	tests := []struct {
+		name      string
+		errs      []error // errors returned by the consecutive calls, nil means success
+		wantCalls int
+		wantSleep []time.Duration
+		wantErr   error
+	}{
+		{
+			name:      "success",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "success after transient errors",
+			errs:      []error{transient, transient, nil},
+			wantCalls: 3,
+			wantSleep: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "permanent error",
+			errs:      []error{permanent},
+			wantCalls: 1,
+			wantErr:   permanent,
+		},
+		{
+			name:      "retry after is honored",
+			errs:      []error{rateLimited, nil},
+			wantCalls: 2,
+			wantSleep: []time.Duration{3 * time.Second},
+		},
+		{
+			name:      "attempts exhausted",
+			errs:      []error{transient, transient, transient, transient},
+			wantCalls: 4,
+			wantSleep: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+			wantErr:   transient,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var slept []time.Duration
+			r := NewRetrier(4, time.Second, 10*time.Second).WithClassifier(telegramRetryable)
+			r.sleep = func(_ context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			calls := 0
+			err := r.Do(context.Background(), func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if len(slept) != len(tt.wantSleep) {
+				t.Fatalf("Do() slept %v, want %v", slept, tt.wantSleep)
+			}
+			for i, d := range slept {
+				// Backoff has jitter in the upper half of the delay, retry after is exact
+				if d > tt.wantSleep[i] || d < tt.wantSleep[i]/2 {
+					t.Errorf("Do() slept %v, want in (%v, %v]", d, tt.wantSleep[i]/2, tt.wantSleep[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetrier_Do_retryAfterTooLong(t *testing.T) {
+	r := NewRetrier(3, time.Second, 10*time.Second).WithClassifier(telegramRetryable)
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		t.Errorf("unexpected sleep %v", d)
+		return nil
+	}
+
+	err := r.Do(context.Background(), func() error {
+		return tgbotapi.Error{ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 60}}
+	})
+	if err == nil {
+		t.Error("Do() expected error")
+	}
+}
+
+func TestRetrier_Do_nil(t *testing.T) {
+	var r *Retrier
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		return errors.New("failed")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do() error = %v, calls = %d", err, calls)
+	}
+}
+
+func TestRetrier_Do_canceled(t *testing.T) {
+	r := NewRetrier(3, time.Hour, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := errors.New("failed")
+
+	calls := 0
+	err := r.Do(ctx, func() error {
+		calls++
+		cancel()
+		return failed
+	})
+	if !errors.Is(err, failed) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() error = %v, want the last error and the context error", err)
+	}
+	if calls != 1 {
+		t.Errorf("Do() calls = %d, want 1", calls)
+	}
+}
+
+func Test_telegramRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantRetry bool
+		wantAfter time.Duration
+	}{
+		{
+			name:      "connection error",
+			err:       &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.OpError{Op: "dial", Err: errors.New("i/o timeout")}},
+			wantRetry: true,
+		},
+		{
+			name:      "DNS error",
+			err:       &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.DNSError{Err: "no such host", Name: "api.telegram.org"}},
+			wantRetry: true,
+		},
+		{
+			name: "client timeout",
+			err:  &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: context.DeadlineExceeded},
+		},
+		{
+			name: "connection reset",
+			err:  &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}},
+		},
+		{
+			name: "broken response",
+			err:  errors.New("unexpected end of JSON input"),
+		},
+		{
+			name:      "rate limit with retry after",
+			err:       fmt.Errorf("wrapped: %w", tgbotapi.Error{Message: "Too Many Requests: retry after 7", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}}),
+			wantRetry: true,
+			wantAfter: 7 * time.Second,
+		},
+		{
+			name:      "server error",
+			err:       tgbotapi.Error{Message: "Bad Gateway"},
+			wantRetry: true,
+		},
+		{
+			name: "bad request",
+			err:  tgbotapi.Error{Message: "Bad Request: can't parse entities"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, after := telegramRetryable(tt.err)
+			if retry != tt.wantRetry || after != tt.wantAfter {
+				t.Errorf("telegramRetryable() = %v, %v, want %v, %v", retry, after, tt.wantRetry, tt.wantAfter)
+			}
+		})
+	}
+}
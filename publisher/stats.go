@@ -0,0 +1,135 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"golang.org/x/net/html"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsMaxBytes is the max size of the post widget page read by TelegramStats.
+const statsMaxBytes = 1 << 20
+
+var (
+	viewsSelector    = cascadia.MustCompile(".tgme_widget_message_views")
+	reactionSelector = cascadia.MustCompile(".tgme_reaction")
+	// countPattern matches the shortened counter at the end of the text, e.g. "987", "1.2K" or "3M"
+	countPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)([KM]?)\s*$`)
+)
+
+var (
+	// ErrStatsUnavailable is returned for the messages of the private channels (numeric chat ids), their widgets are not public.
+	ErrStatsUnavailable = errors.New("stats are available for the public channels only")
+	errStatsNotFound    = errors.New("post widget has no views, the message is deleted or hidden")
+)
+
+// MessageStats are the engagement stats of the published message.
+type MessageStats struct {
+	Views     int // Number of the views
+	Forwards  int // Number of the forwards, 0 if the source doesn't show them
+	Reactions int // Number of the reactions of all emojis
+}
+
+// TelegramStats reads the engagement stats of the messages published in the public channels from their
+// post widgets (t.me/<channel>/<id>?embed=1), the Bot API doesn't expose the stats to the bots.
+// The widget shows the views and reactions, the forwards are not shown and stay 0.
+type TelegramStats struct {
+	BaseURL string // URL of the widgets, https://t.me by default
+	client  *http.Client
+}
+
+// NewTelegramStats creates a new TelegramStats instance.
+func NewTelegramStats() *TelegramStats {
+	return &TelegramStats{
+		BaseURL: "https://t.me",
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// MessageStats returns the engagement stats of the message published in the channel (e.g. @my_channel).
+// Returns ErrStatsUnavailable for the private channels.
+func (s *TelegramStats) MessageStats(ctx context.Context, chatID, pubID string) (*MessageStats, error) {
+	username, ok := strings.CutPrefix(chatID, "@")
+	if !ok || username == "" {
+		return nil, errlvl.Wrap(ErrStatsUnavailable, errlvl.DEBUG)
+	}
+
+	u := fmt.Sprintf("%s/%s/%s?embed=1&mode=tme", strings.TrimSuffix(s.BaseURL, "/"), username, pubID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to create stats request: %w", err), errlvl.ERROR)
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to fetch stats of %s/%s: %w", chatID, pubID, err), errlvl.WARN)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to fetch stats of %s/%s: %s", chatID, pubID, resp.Status), errlvl.WARN)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, statsMaxBytes))
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("failed to parse stats of %s/%s: %w", chatID, pubID, err), errlvl.WARN)
+	}
+	return parseWidgetStats(doc)
+}
+
+// parseWidgetStats returns the views and the sum of the reactions of the post widget.
+func parseWidgetStats(doc *html.Node) (*MessageStats, error) {
+	views := viewsSelector.MatchFirst(doc)
+	if views == nil {
+		return nil, errlvl.Wrap(errStatsNotFound, errlvl.INFO)
+	}
+
+	stats := &MessageStats{Views: parseCount(textContent(views))}
+	for _, r := range reactionSelector.MatchAll(doc) {
+		stats.Reactions += parseCount(textContent(r))
+	}
+	return stats, nil
+}
+
+// parseCount parses the shortened counter of the widget, e.g. "1.2K" is 1200. Returns 0 if there is no counter.
+func parseCount(s string) int {
+	m := countPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0
+	}
+	switch m[2] {
+	case "K":
+		v *= 1e3
+	case "M":
+		v *= 1e6
+	}
+	return int(v + 0.5)
+}
+
+// textContent returns the concatenated text of the node and its children.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTelegramStats_MessageStats(t *testing.T) {
+	const widget = `<html><body><div class="tgme_widget_message">
+<div class="tgme_widget_message_text">Fed holds rates steady.</div>
+<div class="tgme_widget_message_reactions">
+<span class="tgme_reaction"><i class="emoji"><b>👍</b></i>125</span>
+<span class="tgme_reaction"><i class="emoji"><b>🔥</b></i>1.1K</span>
+</div>
+<span class="tgme_widget_message_views">12.4K</span>
+</div></body></html>`
+
+	tests := []struct {
+		name     string
+		chatID   string
+		status   int
+		body     string
+		want     *MessageStats
+		wantPath string
+		wantErr  error
+	}{
+		{
+			name:     "public channel",
+			chatID:   "@finthread",
+			status:   http.StatusOK,
+			body:     widget,
+			want:     &MessageStats{Views: 12400, Reactions: 1225},
+			wantPath: "/finthread/42",
+		},
+		{
+			name:    "private channel",
+			chatID:  "-1001234567890",
+			wantErr: ErrStatsUnavailable,
+		},
+		{
+			name:    "deleted message",
+			chatID:  "@finthread",
+			status:  http.StatusOK,
+			body:    `<html><body><div class="tgme_widget_message_error">Post not found</div></body></html>`,
+			wantErr: errStatsNotFound,
+		},
+		{
+			name:    "server error",
+			chatID:  "@finthread",
+			status:  http.StatusBadGateway,
+			wantErr: errors.New("any"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				if r.URL.Query().Get("embed") != "1" {
+					t.Errorf("request query = %s, want embed=1", r.URL.RawQuery)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			s := NewTelegramStats()
+			s.BaseURL = srv.URL
+			got, err := s.MessageStats(context.Background(), tt.chatID, "42")
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("MessageStats() error = nil, want %v", tt.wantErr)
+				}
+				if tt.wantErr.Error() != "any" && !errors.Is(err, tt.wantErr) {
+					t.Errorf("MessageStats() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MessageStats() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MessageStats() = %+v, want %+v", got, tt.want)
+			}
+			if path != tt.wantPath {
+				t.Errorf("request path = %s, want %s", path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func Test_parseCount(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"987", 987},
+		{" 1.2K ", 1200},
+		{"3M", 3000000},
+		{"👍15", 15},
+		{"views", 0},
+	}
+	for _, tt := range tests {
+		if got := parseCount(tt.s); got != tt.want {
+			t.Errorf("parseCount(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
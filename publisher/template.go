@@ -0,0 +1,97 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data of the MessageTemplate. The fields are rendered for the parse mode of the formatter
+// the same way as in the default layout of MessageFormatter.Format, empty if the message has no such part.
+type TemplateData struct {
+	Headline string  // Bold headline
+	Text     string  // Text with the ticker links
+	Tickers  string  // Links of the tickers not found in the text, space-separated
+	Hashtags string  // Hashtags with "#", space-separated
+	Source   string  // Link to the original news and the other sources of the merged story, comma-separated
+	Message  Message // Raw message for the conditions, e.g. {{if .Message.Tickers}}. Note: not escaped
+}
+
+// MessageTemplate renders the composed news with the Go text/template instead of the default layout
+// (e.g. to add the emoji or the footer disclaimer of the channel). Static text of the template is not escaped,
+// use the escape, bold and link functions for the text with the characters reserved in the parse mode:
+//
+//	{{.Headline}}
+//
+//	{{.Text}}
+//
+//	{{.Source}} {{.Hashtags}}
+//
+//	{{escape "Not financial advice."}}
+//
+// Empty parts don't leave the blank lines: more than one blank line in a row is collapsed into one.
+type MessageTemplate struct {
+	tmpl *template.Template
+}
+
+// blankLinesRe matches the blank lines left by the empty parts of the template.
+var blankLinesRe = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+\n`)
+
+// sampleMessage is used to check the template on parsing, so the unknown fields are reported at startup.
+var sampleMessage = Message{
+	Headline:   "Headline",
+	Text:       "Text about AAPL",
+	Tickers:    []string{"AAPL", "MSFT"},
+	Hashtags:   []string{"earnings"},
+	SourceName: "source",
+	SourceURL:  "https://example.com",
+	Changes:    map[string]float64{"AAPL": 0.01},
+}
+
+// ParseMessageTemplate parses the message template, name is used in the errors (e.g. the channel name).
+// The template is executed with the sample message, so the errors of unknown fields are returned here
+// instead of the publication.
+func ParseMessageTemplate(name, text string) (*MessageTemplate, error) {
+	f := NewMessageFormatter(ModeMarkdownV2)
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs(f)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message template %s: %w", name, err)
+	}
+
+	t := &MessageTemplate{tmpl: tmpl}
+	if _, err := f.FormatTemplate(sampleMessage, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FormatTemplate renders the message with the template. Returns an error if the rendered message is empty.
+func (f *MessageFormatter) FormatTemplate(m Message, t *MessageTemplate) (string, error) {
+	// Functions are bound to the formatter on each execution, the clone keeps the parsed template intact
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("failed to clone message template %s: %w", t.tmpl.Name(), err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Funcs(templateFuncs(f)).Execute(&sb, f.data(m)); err != nil {
+		return "", fmt.Errorf("failed to execute message template %s: %w", t.tmpl.Name(), err)
+	}
+
+	text := strings.TrimSpace(blankLinesRe.ReplaceAllString(sb.String(), "\n\n"))
+	if text == "" {
+		return "", errors.New("message template " + t.tmpl.Name() + " rendered empty message")
+	}
+	return text, nil
+}
+
+// templateFuncs returns the functions of the message template for the static text.
+func templateFuncs(f *MessageFormatter) template.FuncMap {
+	return template.FuncMap{
+		"escape": f.escape,
+		"bold":   f.bold,
+		"link":   f.link,
+	}
+}
@@ -0,0 +1,105 @@
+package publisher
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"testing"
+)
+
+func TestMessageFormatter_FormatTemplate(t *testing.T) {
+	msg := Message{
+		Headline:   "Apple beats estimates!",
+		Text:       "AAPL revenue rose 2.1%.",
+		Tickers:    []string{"AAPL", "GOOG"},
+		Hashtags:   []string{"earnings"},
+		SourceName: "cnbc",
+		SourceURL:  "https://www.cnbc.com/apple",
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		template string
+		msg      Message
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "markdown v2 with disclaimer",
+			mode:     ModeMarkdownV2,
+			template: "📰 {{.Headline}}\n\n{{.Text}}\n\n{{.Source}} {{.Hashtags}}\n\n{{escape \"Not financial advice.\"}}",
+			msg:      msg,
+			want: "📰 *Apple beats estimates\\!*\n\n" +
+				"[$AAPL](https://short-fork.extr.app/en/AAPL?utm_source=finthread) revenue rose 2\\.1%\\.\n\n" +
+				"[cnbc](https://www.cnbc.com/apple) \\#earnings\n\n" +
+				"Not financial advice\\.",
+		},
+		{
+			name:     "html with functions",
+			mode:     tgbotapi.ModeHTML,
+			template: "{{.Text}}\n\n{{.Tickers}}\n\n{{bold \"Disclaimer:\"}} {{link \"terms\" \"https://example.com/?a=1&b=2\"}}",
+			msg:      msg,
+			want: `<a href="https://short-fork.extr.app/en/AAPL?utm_source=finthread">$AAPL</a> revenue rose 2.1%.` + "\n\n" +
+				`<a href="https://short-fork.extr.app/en/GOOG?utm_source=finthread">$GOOG</a>` + "\n\n" +
+				`<b>Disclaimer:</b> <a href="https://example.com/?a=1&amp;b=2">terms</a>`,
+		},
+		{
+			name:     "empty parts don't leave blank lines",
+			mode:     tgbotapi.ModeHTML,
+			template: "{{.Headline}}\n\n{{.Text}}\n\n{{.Tickers}}\n\n{{.Hashtags}}\n\n{{.Source}}\n",
+			msg:      Message{Text: "Markets are closed."},
+			want:     "Markets are closed.",
+		},
+		{
+			name:     "conditions on the raw message",
+			mode:     tgbotapi.ModeHTML,
+			template: "{{.Text}}{{if .Message.Tickers}} (stocks){{end}}",
+			msg:      Message{Text: "Fed holds rates."},
+			want:     "Fed holds rates.",
+		},
+		{
+			name:     "empty message",
+			mode:     tgbotapi.ModeHTML,
+			template: "{{.Headline}}",
+			msg:      Message{Text: "Fed holds rates."},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseMessageTemplate(tt.name, tt.template)
+			if err != nil {
+				t.Fatalf("ParseMessageTemplate() error = %v", err)
+			}
+
+			got, err := NewMessageFormatter(tt.mode).FormatTemplate(tt.msg, tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatTemplate() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "valid", template: "{{.Headline}}\n\n{{.Text}}"},
+		{name: "syntax error", template: "{{.Text", wantErr: true},
+		{name: "unknown field", template: "{{.Hash}}", wantErr: true},
+		{name: "unknown function", template: "{{upper .Text}}", wantErr: true},
+		{name: "blank", template: " ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMessageTemplate(tt.name, tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMessageTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package publisher
+
+import (
+	"encoding/json"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"net/url"
+	"strconv"
+)
+
+// WithTopics sets the forum topics (message_thread_id) of the named channels, so the messages routed
+// to the channel are published into the topic of the forum supergroup (e.g. "crypto" -> 5).
+// Several channels can share the same chat with different topics.
+func (t *TelegramPublisher) WithTopics(topics map[string]int) *TelegramPublisher {
+	t.Topics = topics
+	return t
+}
+
+// TopicID returns the forum topic of the channel name or 0 if the channel has no topic.
+func (t *TelegramPublisher) TopicID(channel string) int {
+	if t == nil {
+		return 0
+	}
+	return t.Topics[channel]
+}
+
+// sendText sends the text message to the channel (name or chat id), into its forum topic if set.
+// replyTo is the ID of the message to reply to, 0 for none.
+func (t *TelegramPublisher) sendText(channel, text, parseMode string, linkPreview bool, replyTo int) (tgbotapi.Message, error) {
+	chatID := t.ChatID(channel)
+	topicID := t.TopicID(channel)
+	if topicID == 0 {
+		tgMsg := tgbotapi.NewMessageToChannel(chatID, text)
+		tgMsg.ParseMode = parseMode
+		tgMsg.DisableWebPagePreview = !linkPreview
+		tgMsg.ReplyToMessageID = replyTo
+		return t.send(chatID, tgMsg)
+	}
+
+	// tgbotapi v4 doesn't support message_thread_id of the forum topics
+	params := url.Values{}
+	params.Set("chat_id", chatID)
+	params.Set("message_thread_id", strconv.Itoa(topicID))
+	params.Set("text", text)
+	params.Set("parse_mode", parseMode)
+	params.Set("disable_web_page_preview", strconv.FormatBool(!linkPreview))
+	if replyTo != 0 {
+		params.Set("reply_to_message_id", strconv.Itoa(replyTo))
+	}
+
+	return t.sendRaw(chatID, func() (tgbotapi.APIResponse, error) {
+		return t.BotAPI.MakeRequest("sendMessage", params)
+	})
+}
+
+// sendTopicPhoto sends the photo by its URL or as the file with the caption into the forum topic.
+func (t *TelegramPublisher) sendTopicPhoto(chatID string, topicID int, caption, parseMode string, media Media) (tgbotapi.Message, error) {
+	params := map[string]string{
+		"chat_id":           chatID,
+		"message_thread_id": strconv.Itoa(topicID),
+		"caption":           caption,
+		"parse_mode":        parseMode,
+	}
+
+	return t.sendRaw(chatID, func() (tgbotapi.APIResponse, error) {
+		if media.URL != "" {
+			// Telegram downloads the photo by the URL itself
+			values := url.Values{}
+			for k, v := range params {
+				values.Set(k, v)
+			}
+			values.Set("photo", media.URL)
+			return t.BotAPI.MakeRequest("sendPhoto", values)
+		}
+		return t.BotAPI.UploadFile("sendPhoto", params, "photo", tgbotapi.FileBytes{Name: media.fileName(), Bytes: media.Bytes})
+	})
+}
+
+// sendRaw calls the Bot API method that sends the message with retries and decodes the sent message.
+// Each attempt waits for the rate limiter.
+func (t *TelegramPublisher) sendRaw(chatID string, call func() (tgbotapi.APIResponse, error)) (tgbotapi.Message, error) {
+	var m tgbotapi.Message
+	err := t.retrier.Do(t.context(), func() error {
+		if err := t.wait(chatID); err != nil {
+			return err
+		}
+		resp, err := call()
+		t.countSend(err)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(resp.Result, &m)
+	})
+	return m, err
+}
@@ -0,0 +1,87 @@
+package publisher
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc is the http.RoundTripper for tests.
+type roundTripFunc func(r *http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r), nil
+}
+
+func TestTelegramPublisher_sendText(t *testing.T) {
+	tests := []struct {
+		name       string
+		channel    string
+		replyTo    int
+		wantMethod string
+		wantParams url.Values
+	}{
+		{
+			name:       "forum topic",
+			channel:    "crypto",
+			replyTo:    41,
+			wantMethod: "sendMessage",
+			wantParams: url.Values{
+				"chat_id":             {"-1001234567890"},
+				"message_thread_id":   {"5"},
+				"reply_to_message_id": {"41"},
+			},
+		},
+		{
+			name:       "channel without topic",
+			channel:    "macro",
+			wantMethod: "sendMessage",
+			wantParams: url.Values{
+				"chat_id":           {"@macro"},
+				"message_thread_id": nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method string
+			var params url.Values
+			client := &http.Client{Transport: roundTripFunc(func(r *http.Request) *http.Response {
+				method = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+				_ = r.ParseForm()
+				params = r.PostForm
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"ok":true,"result":{"message_id":42,"chat":{"id":-1001234567890}}}`)),
+				}
+			})}
+
+			p := &TelegramPublisher{
+				BotAPI:        &tgbotapi.BotAPI{Token: "token", Client: client},
+				ShouldPublish: true,
+			}
+			p.WithChannels(map[string]string{"crypto": "-1001234567890", "macro": "@macro"}).
+				WithTopics(map[string]int{"crypto": 5})
+
+			m, err := p.sendText(tt.channel, "Bitcoin hits a new high", tgbotapi.ModeMarkdown, false, tt.replyTo)
+			if err != nil {
+				t.Fatalf("sendText() error = %v", err)
+			}
+			if m.MessageID != 42 {
+				t.Errorf("sendText() message ID = %d, want 42", m.MessageID)
+			}
+			if method != tt.wantMethod {
+				t.Errorf("sendText() method = %s, want %s", method, tt.wantMethod)
+			}
+			for k, want := range tt.wantParams {
+				if got := params[k]; strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("sendText() param %s = %v, want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 const (
 	economicCalendarURL = "https://www.mql5.com/en/economic-calendar/content"
+	eventPageURL        = "https://www.mql5.com/en/economic-calendar/"
 )
 
 // EconomicCalendar is the struct for economics calendar fetcher.
@@ -142,6 +143,9 @@ func parseEvent(event mql5Calendar) (*EconomicCalendarEvent, error) {
 		Forecast:  strings.ReplaceAll(strings.ToLower(event.ForecastValue), "\u00a0", ""),
 		Previous:  strings.ReplaceAll(strings.ToLower(event.PreviousValue), "\u00a0", ""),
 	}
+	if event.URL != "" {
+		e.URL = eventPageURL + strings.TrimPrefix(event.URL, "/")
+	}
 
 	return e, nil
 }
@@ -374,6 +378,7 @@ type EconomicCalendarEvent struct {
 	Actual    string                   // Actual value of the event (if available)
 	Forecast  string                   // Forecasted value of the event (if available)
 	Previous  string                   // Previous value of the event (if available)
+	URL       string                   // Link to the event page (if available)
 }
 
 // MQL5 calendar event object.
@@ -0,0 +1,62 @@
+package marketdata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Report times of the EarningsReport.
+const (
+	EarningsBeforeOpen  = "bmo" // before the market open
+	EarningsAfterClose  = "amc" // after the market close
+	EarningsDuringHours = "dmh" // during the market hours
+)
+
+// EarningsReport is the scheduled quarterly earnings report of the company. Actual values are nil
+// until the results are released.
+type EarningsReport struct {
+	Ticker          string   `json:"symbol"`
+	Date            string   `json:"date"` // date of the report, e.g. "2024-04-25"
+	Hour            string   `json:"hour"` // EarningsBeforeOpen, EarningsAfterClose, EarningsDuringHours or empty if unknown
+	Quarter         int      `json:"quarter"`
+	Year            int      `json:"year"`
+	EPSEstimate     *float64 `json:"epsEstimate"`
+	EPSActual       *float64 `json:"epsActual"`
+	RevenueEstimate *float64 `json:"revenueEstimate"`
+	RevenueActual   *float64 `json:"revenueActual"`
+}
+
+// IsReleased returns true if the actual EPS or revenue of the report is known.
+func (r *EarningsReport) IsReleased() bool {
+	return r.EPSActual != nil || r.RevenueActual != nil
+}
+
+// finnhubEarnings is the Finnhub earnings calendar response.
+type finnhubEarnings struct {
+	EarningsCalendar []*EarningsReport `json:"earningsCalendar"`
+}
+
+// FetchEarnings returns the earnings reports scheduled between the dates (inclusive) of the ticker
+// or of all companies if the ticker is empty.
+func (f *Finnhub) FetchEarnings(ctx context.Context, from, to time.Time, ticker string) ([]*EarningsReport, error) {
+	q := url.Values{}
+	q.Set("from", from.Format(time.DateOnly))
+	q.Set("to", to.Format(time.DateOnly))
+	if ticker != "" {
+		q.Set("symbol", ticker)
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var resp finnhubEarnings
+	if err := getJSON(ctx, client, f.BaseURL+"/api/v1/calendar/earnings?"+q.Encode(), f.header(), "earnings calendar", &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.EarningsCalendar, nil
+}
@@ -0,0 +1,163 @@
+// Package marketdata fetches historical prices and current quotes of the stocks to compare the news
+// with the price action.
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultBaseURL = "https://query1.finance.yahoo.com"
+
+var errNoPrices = errors.New("no prices for the period")
+
+// MarketData fetches daily prices from the Yahoo Finance chart API.
+// The zero value is ready to use.
+type MarketData struct {
+	BaseURL string       // API URL, Yahoo Finance by default
+	Client  *http.Client // HTTP client, http.DefaultClient by default
+}
+
+// Performance is the price change of the ticker over the period.
+type Performance struct {
+	Ticker string    `json:"ticker"`
+	From   time.Time `json:"from"`   // date of the first trading day in the period
+	To     time.Time `json:"to"`     // date of the last trading day in the period
+	Open   float64   `json:"open"`   // open price of the first trading day
+	Close  float64   `json:"close"`  // close price of the last trading day
+	Change float64   `json:"change"` // relative price change, e.g. 0.05 for +5%
+}
+
+// FetchPerformance returns the price performance of the ticker between from and to (daily candles).
+func (m *MarketData) FetchPerformance(ctx context.Context, ticker string, from, to time.Time) (*Performance, error) {
+	q := url.Values{}
+	q.Set("period1", strconv.FormatInt(from.Unix(), 10))
+	q.Set("period2", strconv.FormatInt(to.Unix(), 10))
+	q.Set("interval", "1d")
+	u := fmt.Sprintf("%s/v8/finance/chart/%s?%s", m.baseURL(), url.PathEscape(ticker), q.Encode())
+
+	var chart yahooChartResponse
+	if err := getJSON(ctx, m.client(), u, nil, ticker+" prices", &chart); err != nil {
+		return nil, err
+	}
+
+	p, err := chart.performance(ticker)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error parsing %s prices: %w", ticker, err), errlvl.WARN)
+	}
+
+	return p, nil
+}
+
+// getJSON requests the URL with the additional headers (nil if none) and decodes the JSON response into v.
+// What is the subject of the request for the error messages, e.g. "AAPL prices". Credentials must be sent
+// in the headers, because the URL is included in the client errors.
+func getJSON(ctx context.Context, client *http.Client, u string, header http.Header, what string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("error creating request to fetch %s: %w", what, err), errlvl.ERROR)
+	}
+	for k, values := range header {
+		for _, value := range values {
+			req.Header.Add(k, value)
+		}
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("error fetching %s: %w", what, err), errlvl.WARN)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errlvl.Wrap(fmt.Errorf("error fetching %s: unexpected status %s", what, resp.Status), errlvl.WARN)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errlvl.Wrap(fmt.Errorf("error parsing %s: %w", what, err), errlvl.ERROR)
+	}
+
+	return nil
+}
+
+func (m *MarketData) baseURL() string {
+	if m == nil || m.BaseURL == "" {
+		return defaultBaseURL
+	}
+	return m.BaseURL
+}
+
+func (m *MarketData) client() *http.Client {
+	if m == nil || m.Client == nil {
+		return http.DefaultClient
+	}
+	return m.Client
+}
+
+// yahooChartResponse is the part of the Yahoo Finance chart API response.
+type yahooChartResponse struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				RegularMarketPrice float64 `json:"regularMarketPrice"`
+				RegularMarketTime  int64   `json:"regularMarketTime"`
+				ChartPreviousClose float64 `json:"chartPreviousClose"`
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Open  []*float64 `json:"open"`
+					Close []*float64 `json:"close"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// performance calculates the change from the first open to the last close, skipping empty candles.
+func (r *yahooChartResponse) performance(ticker string) (*Performance, error) {
+	if r.Chart.Error != nil {
+		return nil, fmt.Errorf("%s: %s", r.Chart.Error.Code, r.Chart.Error.Description)
+	}
+	if len(r.Chart.Result) == 0 || len(r.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, errNoPrices
+	}
+
+	result := r.Chart.Result[0]
+	quote := result.Indicators.Quote[0]
+	first, last := -1, -1
+	for i := range result.Timestamp {
+		if i < len(quote.Open) && quote.Open[i] != nil && first == -1 {
+			first = i
+		}
+		if i < len(quote.Close) && quote.Close[i] != nil {
+			last = i
+		}
+	}
+	if first == -1 || last == -1 || *quote.Open[first] == 0 {
+		return nil, errNoPrices
+	}
+
+	open, closePrice := *quote.Open[first], *quote.Close[last]
+	return &Performance{
+		Ticker: ticker,
+		From:   time.Unix(result.Timestamp[first], 0).UTC(),
+		To:     time.Unix(result.Timestamp[last], 0).UTC(),
+		Open:   open,
+		Close:  closePrice,
+		Change: (closePrice - open) / open,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package marketdata
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMarketData_FetchPerformance(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantOpen   float64
+		wantClose  float64
+		wantChange float64
+		wantErr    bool
+	}{
+		{
+			name:   "weekly performance",
+			status: http.StatusOK,
+			body: `{"chart":{"result":[{"timestamp":[1709510400,1709596800,1709683200],
+				"indicators":{"quote":[{"open":[null,100,102],"close":[99,101,null]}]}}],"error":null}}`,
+			wantOpen:   100,
+			wantClose:  101,
+			wantChange: 0.01,
+		},
+		{
+			name:    "unknown ticker",
+			status:  http.StatusOK,
+			body:    `{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "no prices",
+			status:  http.StatusOK,
+			body:    `{"chart":{"result":[{"timestamp":[1709510400],"indicators":{"quote":[{"open":[null],"close":[null]}]}}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unexpected status",
+			status:  http.StatusTooManyRequests,
+			body:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v8/finance/chart/AAPL" || r.URL.Query().Get("interval") != "1d" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			m := &MarketData{BaseURL: srv.URL}
+			to := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+			got, err := m.FetchPerformance(context.Background(), "AAPL", to.AddDate(0, 0, -7), to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPerformance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Open != tt.wantOpen || got.Close != tt.wantClose || math.Abs(got.Change-tt.wantChange) > 1e-9 {
+				t.Errorf("FetchPerformance() = %+v, want open %v close %v change %v", got, tt.wantOpen, tt.wantClose, tt.wantChange)
+			}
+		})
+	}
+}
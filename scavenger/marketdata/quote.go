@@ -0,0 +1,173 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const defaultFinnhubURL = "https://finnhub.io"
+
+var errNoQuote = errors.New("no quote for the ticker")
+
+// Quote is the current price of the ticker with the change from the previous close.
+type Quote struct {
+	Ticker string    `json:"ticker"`
+	Price  float64   `json:"price"`
+	Change float64   `json:"change"` // relative change from the previous close, e.g. 0.014 for +1.4%
+	Time   time.Time `json:"time"`   // time of the last trade
+}
+
+// QuoteProvider fetches the current quotes of the tickers.
+type QuoteProvider interface {
+	FetchQuote(ctx context.Context, ticker string) (*Quote, error)
+}
+
+// FetchQuote returns the current quote of the ticker from the Yahoo Finance chart API.
+func (m *MarketData) FetchQuote(ctx context.Context, ticker string) (*Quote, error) {
+	q := url.Values{}
+	q.Set("range", "1d")
+	q.Set("interval", "1d")
+	u := fmt.Sprintf("%s/v8/finance/chart/%s?%s", m.baseURL(), url.PathEscape(ticker), q.Encode())
+
+	var chart yahooChartResponse
+	if err := getJSON(ctx, m.client(), u, nil, ticker+" quote", &chart); err != nil {
+		return nil, err
+	}
+
+	quote, err := chart.quote(ticker)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error parsing %s quote: %w", ticker, err), errlvl.WARN)
+	}
+
+	return quote, nil
+}
+
+// quote returns the last price and its change from the previous close.
+func (r *yahooChartResponse) quote(ticker string) (*Quote, error) {
+	if r.Chart.Error != nil {
+		return nil, fmt.Errorf("%s: %s", r.Chart.Error.Code, r.Chart.Error.Description)
+	}
+	if len(r.Chart.Result) == 0 {
+		return nil, errNoQuote
+	}
+
+	meta := r.Chart.Result[0].Meta
+	if meta.RegularMarketPrice == 0 || meta.ChartPreviousClose == 0 {
+		return nil, errNoQuote
+	}
+
+	return &Quote{
+		Ticker: ticker,
+		Price:  meta.RegularMarketPrice,
+		Change: (meta.RegularMarketPrice - meta.ChartPreviousClose) / meta.ChartPreviousClose,
+		Time:   time.Unix(meta.RegularMarketTime, 0).UTC(),
+	}, nil
+}
+
+// Finnhub fetches the current quotes from the Finnhub API. Requires the API token.
+type Finnhub struct {
+	Token   string
+	BaseURL string       // API URL, Finnhub by default
+	Client  *http.Client // HTTP client, http.DefaultClient by default
+}
+
+// NewFinnhub creates a new Finnhub quotes provider.
+func NewFinnhub(token string) *Finnhub {
+	return &Finnhub{
+		Token:   token,
+		BaseURL: defaultFinnhubURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// header returns the headers of the Finnhub API requests with the token, so it isn't leaked by the URL in errors.
+func (f *Finnhub) header() http.Header {
+	return http.Header{"X-Finnhub-Token": []string{f.Token}}
+}
+
+// finnhubQuote is the Finnhub quote response. Unknown tickers have all values zero.
+type finnhubQuote struct {
+	Current       float64 `json:"c"`
+	PercentChange float64 `json:"dp"`
+	PreviousClose float64 `json:"pc"`
+	Timestamp     int64   `json:"t"`
+}
+
+// FetchQuote returns the current quote of the ticker.
+func (f *Finnhub) FetchQuote(ctx context.Context, ticker string) (*Quote, error) {
+	q := url.Values{}
+	q.Set("symbol", ticker)
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var resp finnhubQuote
+	if err := getJSON(ctx, client, f.BaseURL+"/api/v1/quote?"+q.Encode(), f.header(), ticker+" quote", &resp); err != nil {
+		return nil, err
+	}
+	if resp.Current == 0 || resp.PreviousClose == 0 {
+		return nil, errlvl.Wrap(fmt.Errorf("error parsing %s quote: %w", ticker, errNoQuote), errlvl.WARN)
+	}
+
+	return &Quote{
+		Ticker: ticker,
+		Price:  resp.Current,
+		Change: resp.PercentChange / 100,
+		Time:   time.Unix(resp.Timestamp, 0).UTC(),
+	}, nil
+}
+
+// QuoteCache caches the quotes of the QuoteProvider for the TTL, so the same tickers mentioned
+// in several news don't hit the API rate limits. Failed fetches are not cached.
+type QuoteCache struct {
+	provider QuoteProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	quotes map[string]cachedQuote
+}
+
+type cachedQuote struct {
+	quote     *Quote
+	fetchedAt time.Time
+}
+
+// NewQuoteCache creates a new QuoteCache for the provider.
+func NewQuoteCache(provider QuoteProvider, ttl time.Duration) *QuoteCache {
+	return &QuoteCache{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		quotes:   make(map[string]cachedQuote),
+	}
+}
+
+// FetchQuote returns the cached quote of the ticker or fetches it from the provider.
+func (c *QuoteCache) FetchQuote(ctx context.Context, ticker string) (*Quote, error) {
+	c.mu.Lock()
+	cached, ok := c.quotes[ticker]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < c.ttl {
+		return cached.quote, nil
+	}
+
+	quote, err := c.provider.FetchQuote(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.quotes[ticker] = cachedQuote{quote: quote, fetchedAt: c.now()}
+	c.mu.Unlock()
+
+	return quote, nil
+}
@@ -0,0 +1,177 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMarketData_FetchQuote(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantPrice  float64
+		wantChange float64
+		wantErr    bool
+	}{
+		{
+			name:       "day change",
+			body:       `{"chart":{"result":[{"meta":{"regularMarketPrice":101.4,"regularMarketTime":1709596800,"chartPreviousClose":100}}],"error":null}}`,
+			wantPrice:  101.4,
+			wantChange: 0.014,
+		},
+		{
+			name:    "unknown ticker",
+			body:    `{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "no previous close",
+			body:    `{"chart":{"result":[{"meta":{"regularMarketPrice":101.4}}]}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v8/finance/chart/AAPL" || r.URL.Query().Get("range") != "1d" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			m := &MarketData{BaseURL: srv.URL}
+			got, err := m.FetchQuote(context.Background(), "AAPL")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchQuote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Price != tt.wantPrice || math.Abs(got.Change-tt.wantChange) > 1e-9 {
+				t.Errorf("FetchQuote() = %+v, want price %v change %v", got, tt.wantPrice, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestFinnhub_FetchQuote(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantChange float64
+		wantErr    bool
+	}{
+		{
+			name:       "day change",
+			body:       `{"c":101.4,"d":1.4,"dp":1.4,"pc":100,"t":1709596800}`,
+			wantChange: 0.014,
+		},
+		{
+			name:    "unknown ticker",
+			body:    `{"c":0,"d":null,"dp":null,"pc":0,"t":0}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/quote" || r.URL.Query().Get("symbol") != "AAPL" || r.URL.Query().Has("token") ||
+					r.Header.Get("X-Finnhub-Token") != "token" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			f := NewFinnhub("token")
+			f.BaseURL = srv.URL
+			got, err := f.FetchQuote(context.Background(), "AAPL")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchQuote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got.Change-tt.wantChange) > 1e-9 {
+				t.Errorf("FetchQuote() change = %v, want %v", got.Change, tt.wantChange)
+			}
+		})
+	}
+}
+
+// quoteProviderFunc is the QuoteProvider for tests.
+type quoteProviderFunc func(ctx context.Context, ticker string) (*Quote, error)
+
+func (f quoteProviderFunc) FetchQuote(ctx context.Context, ticker string) (*Quote, error) {
+	return f(ctx, ticker)
+}
+
+func TestQuoteCache_FetchQuote(t *testing.T) {
+	calls := 0
+	fail := false
+	provider := quoteProviderFunc(func(_ context.Context, ticker string) (*Quote, error) {
+		calls++
+		if fail {
+			return nil, errors.New("rate limit")
+		}
+		return &Quote{Ticker: ticker, Price: float64(calls)}, nil
+	})
+
+	now := time.Now()
+	c := NewQuoteCache(provider, time.Minute)
+	c.now = func() time.Time { return now }
+
+	fetch := func() (*Quote, error) { return c.FetchQuote(context.Background(), "AAPL") }
+
+	if q, err := fetch(); err != nil || q.Price != 1 {
+		t.Fatalf("FetchQuote() = %v, %v, want the fetched quote", q, err)
+	}
+	if q, _ := fetch(); q.Price != 1 || calls != 1 {
+		t.Errorf("FetchQuote() within TTL = %v after %d calls, want the cached quote", q, calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	fail = true
+	if _, err := fetch(); err == nil {
+		t.Error("FetchQuote() after TTL error = nil, want the provider error")
+	}
+
+	fail = false
+	if q, _ := fetch(); q.Price != 3 {
+		t.Errorf("FetchQuote() after failure = %v, want the refetched quote", q)
+	}
+}
+
+func TestFinnhub_FetchEarnings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/v1/calendar/earnings" || q.Get("from") != "2024-04-24" || q.Get("to") != "2024-04-25" ||
+			q.Get("symbol") != "AAPL" || q.Has("token") || r.Header.Get("X-Finnhub-Token") != "token" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"earningsCalendar":[{"date":"2024-04-25","epsActual":null,"epsEstimate":1.5,"hour":"amc",` +
+			`"quarter":2,"revenueActual":null,"revenueEstimate":90100000000,"symbol":"AAPL","year":2024}]}`))
+	}))
+	defer srv.Close()
+
+	f := NewFinnhub("token")
+	f.BaseURL = srv.URL
+	from := time.Date(2024, 4, 24, 0, 0, 0, 0, time.UTC)
+	got, err := f.FetchEarnings(context.Background(), from, from.AddDate(0, 0, 1), "AAPL")
+	if err != nil {
+		t.Fatalf("FetchEarnings() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("FetchEarnings() got %d reports, want 1", len(got))
+	}
+	r := got[0]
+	if r.Ticker != "AAPL" || r.Hour != EarningsAfterClose || r.Quarter != 2 || r.EPSEstimate == nil || *r.EPSEstimate != 1.5 {
+		t.Errorf("FetchEarnings() = %+v", r)
+	}
+	if r.IsReleased() {
+		t.Errorf("IsReleased() = true, want false")
+	}
+}
@@ -2,6 +2,7 @@ package scavenger
 
 import (
 	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/marketdata"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
 )
 
@@ -13,4 +14,5 @@ import (
 type Scavenger struct {
 	EconomicCalendar *ecal.EconomicCalendar
 	Screener         *stocks.Screener
+	MarketData       *marketdata.MarketData
 }
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"net/http"
+)
+
+// publishedNewsStore finds the latest published news for the output feeds.
+type publishedNewsStore interface {
+	FindLatestPublished(ctx context.Context, limit int) ([]*archivist.News, error)
+}
+
+var errFindPublishedNews = errors.New("failed to find published news")
+
+// WithFeed enables the RSS (/feed.xml) and Atom (/feed.atom) feeds of the latest limit published news.
+func (s *Server) WithFeed(feed *publisher.Feed, limit int) *Server {
+	s.feed = feed
+	s.feedLimit = limit
+	return s
+}
+
+// handleRSSFeed renders the RSS feed of the latest published news.
+func (s *Server) handleRSSFeed(w http.ResponseWriter, r *http.Request) {
+	s.serveFeed(w, r, "application/rss+xml; charset=utf-8", s.feed.RSS)
+}
+
+// handleAtomFeed renders the Atom feed of the latest published news.
+func (s *Server) handleAtomFeed(w http.ResponseWriter, r *http.Request) {
+	s.serveFeed(w, r, "application/atom+xml; charset=utf-8", s.feed.Atom)
+}
+
+// serveFeed renders the feed of the latest published news with the render function.
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request, contentType string, render func([]publisher.FeedItem) ([]byte, error)) {
+	if s.feed == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	news, err := s.published.FindLatestPublished(r.Context(), s.feedLimit)
+	if err != nil {
+		s.logger.Error("[server] Error finding published news", "error", err)
+		writeError(w, http.StatusInternalServerError, errFindPublishedNews)
+		return
+	}
+
+	items := make([]publisher.FeedItem, len(news))
+	for i, n := range news {
+		items[i] = n.ToFeedItem()
+	}
+	body, err := render(items)
+	if err != nil {
+		s.logger.Error("[server] Error rendering feed", "error", err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(body)
+}
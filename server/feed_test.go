@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"gorm.io/datatypes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakePublishedNews struct {
+	news []*archivist.News
+	err  error
+}
+
+func (f *fakePublishedNews) FindLatestPublished(_ context.Context, limit int) ([]*archivist.News, error) {
+	return f.news[:min(limit, len(f.news))], f.err
+}
+
+func TestServer_handleFeed(t *testing.T) {
+	news := []*archivist.News{
+		{
+			Hash:          "abc",
+			URL:           "https://example.com/apple",
+			OriginalTitle: "Apple beats estimates",
+			ComposedText:  "Apple's Q1 revenue rose 5% to $120B.",
+			MetaData:      datatypes.JSON(`{"tickers": ["AAPL"], "hashtags": ["earnings"]}`),
+			PublishedAt:   time.Date(2024, 3, 8, 21, 30, 0, 0, time.UTC),
+		},
+		{
+			Hash:          "def",
+			URL:           "https://example.com/fed",
+			OriginalTitle: "Fed holds rates",
+			PublishedAt:   time.Date(2024, 3, 8, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		feed       *publisher.Feed
+		path       string
+		store      *fakePublishedNews
+		wantStatus int
+		wantType   string
+		wantBody   []string
+	}{
+		{
+			name:       "rss",
+			feed:       publisher.NewFeed("https://example.com/"),
+			path:       "/feed.xml",
+			store:      &fakePublishedNews{news: news},
+			wantStatus: http.StatusOK,
+			wantType:   "application/rss+xml; charset=utf-8",
+			wantBody: []string{
+				`<title>Apple beats estimates</title>`,
+				`<description>Apple&#39;s Q1 revenue rose 5% to $120B.</description>`,
+				`<category>AAPL</category>`,
+				`<guid isPermaLink="false">abc</guid>`,
+			},
+		},
+		{
+			name:       "atom",
+			feed:       publisher.NewFeed("https://example.com"),
+			path:       "/feed.atom",
+			store:      &fakePublishedNews{news: news},
+			wantStatus: http.StatusOK,
+			wantType:   "application/atom+xml; charset=utf-8",
+			wantBody: []string{
+				`<id>urn:fin-thread:news:def</id>`,
+				`<link href="https://example.com/feed.atom" rel="self" type="application/atom+xml"></link>`,
+			},
+		},
+		{
+			name:       "storage error",
+			feed:       publisher.NewFeed("https://example.com"),
+			path:       "/feed.xml",
+			store:      &fakePublishedNews{err: errors.New("db is down")},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "feed disabled",
+			path:       "/feed.atom",
+			store:      &fakePublishedNews{news: news},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := (&Server{published: tt.store, logger: slog.Default()}).WithFeed(tt.feed, 10)
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantType != "" && rec.Header().Get("Content-Type") != tt.wantType {
+				t.Errorf("Content-Type = %s, want %s", rec.Header().Get("Content-Type"), tt.wantType)
+			}
+			body := rec.Body.String()
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body = %s, want to contain %s", body, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/jobs"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// HealthCheck checks the dependency of the app (database, Telegram API, etc.) for the readiness probe.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// jobRunsStore keeps the last runs of the news jobs (see jobs.Control).
+type jobRunsStore interface {
+	LastRuns() map[string]jobs.RunInfo
+	LastSuccesses() map[string]time.Time
+}
+
+// health holds the readiness checks of the app.
+type health struct {
+	checks    []HealthCheck
+	runs      jobRunsStore
+	maxRunAge time.Duration // jobs without a successful run for longer are not ready, 0 to only report the runs
+	startedAt time.Time
+}
+
+// checkResult is the result of the readiness check.
+type checkResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// jobResult is the last run and the last successful run of the job.
+type jobResult struct {
+	Job         string     `json:"job"`
+	OK          bool       `json:"ok"`
+	LastRun     time.Time  `json:"last_run"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// WithHealth enables the readiness checks of the dependencies and the last successful runs of the jobs
+// for the /readyz endpoint. If maxRunAge > 0, the app is not ready if any job has no successful run for longer.
+func (s *Server) WithHealth(checks []HealthCheck, runs jobRunsStore, maxRunAge time.Duration) *Server {
+	s.health.checks = checks
+	s.health.runs = runs
+	s.health.maxRunAge = maxRunAge
+	return s
+}
+
+// handleHealthz is the liveness probe: the server is up and serving requests.
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": statusOK,
+		"uptime": time.Since(s.health.startedAt).Truncate(time.Second).String(),
+	})
+}
+
+// handleReadyz is the readiness probe: reports the dependency checks and the last successful runs of the jobs.
+// Responds with 503 if any of them failed.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ready := true
+	checks := make([]checkResult, 0, len(s.health.checks))
+	for _, c := range s.health.checks {
+		res := checkResult{Name: c.Name, OK: true}
+		if err := c.Check(ctx); err != nil {
+			res.OK, res.Error = false, err.Error()
+			ready = false
+		}
+		checks = append(checks, res)
+	}
+
+	jobResults := s.health.jobResults(time.Now())
+	for _, j := range jobResults {
+		ready = ready && j.OK
+	}
+
+	status, code := statusOK, http.StatusOK
+	if !ready {
+		status, code = statusDown, http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+		"jobs":   jobResults,
+	})
+}
+
+// jobResults returns the last runs of the jobs sorted by the job name. Jobs that have never succeeded
+// are checked against the server start time.
+func (h *health) jobResults(now time.Time) []jobResult {
+	results := []jobResult{}
+	if h.runs == nil {
+		return results
+	}
+
+	successes := h.runs.LastSuccesses()
+	for name, run := range h.runs.LastRuns() {
+		res := jobResult{Job: name, LastRun: run.StartedAt}
+		since := h.startedAt
+		if t, ok := successes[name]; ok {
+			res.LastSuccess = &t
+			since = t
+		}
+		res.OK = h.maxRunAge <= 0 || now.Sub(since) <= h.maxRunAge
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Job < results[j].Job })
+
+	return results
+}
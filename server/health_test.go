@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/samgozman/fin-thread/jobs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeJobRuns struct {
+	runs      map[string]jobs.RunInfo
+	successes map[string]time.Time
+}
+
+func (f *fakeJobRuns) LastRuns() map[string]jobs.RunInfo {
+	return f.runs
+}
+
+func (f *fakeJobRuns) LastSuccesses() map[string]time.Time {
+	return f.successes
+}
+
+func TestServer_handleHealthz(t *testing.T) {
+	s := &Server{health: health{startedAt: time.Now()}, logger: slog.Default()}
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestServer_handleReadyz(t *testing.T) {
+	now := time.Now()
+	ok := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		runs       *fakeJobRuns
+		maxRunAge  time.Duration
+		startedAt  time.Time
+		wantStatus int
+		wantJobs   []bool // readiness of the jobs sorted by name
+	}{
+		{
+			name:       "all checks pass",
+			checks:     []HealthCheck{{Name: "database", Check: ok}, {Name: "telegram", Check: ok}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "database is down",
+			checks: []HealthCheck{
+				{Name: "database", Check: func(context.Context) error { return errors.New("connection refused") }},
+				{Name: "telegram", Check: ok},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "jobs are only reported without max run age",
+			runs: &fakeJobRuns{
+				runs:      map[string]jobs.RunInfo{"MarketNews": {StartedAt: now}},
+				successes: map[string]time.Time{},
+			},
+			startedAt:  now.Add(-time.Hour),
+			wantStatus: http.StatusOK,
+			wantJobs:   []bool{true},
+		},
+		{
+			name: "stale job",
+			runs: &fakeJobRuns{
+				runs: map[string]jobs.RunInfo{"BroadNews": {StartedAt: now}, "MarketNews": {StartedAt: now, Failed: true}},
+				successes: map[string]time.Time{
+					"BroadNews":  now.Add(-time.Minute),
+					"MarketNews": now.Add(-time.Hour),
+				},
+			},
+			maxRunAge:  10 * time.Minute,
+			startedAt:  now.Add(-2 * time.Hour),
+			wantStatus: http.StatusServiceUnavailable,
+			wantJobs:   []bool{true, false},
+		},
+		{
+			name: "job has not succeeded yet after the start",
+			runs: &fakeJobRuns{
+				runs:      map[string]jobs.RunInfo{"MarketNews": {StartedAt: now, Failed: true}},
+				successes: map[string]time.Time{},
+			},
+			maxRunAge:  10 * time.Minute,
+			startedAt:  now.Add(-time.Minute),
+			wantStatus: http.StatusOK,
+			wantJobs:   []bool{true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{health: health{startedAt: tt.startedAt}, logger: slog.Default()}
+			if tt.runs != nil {
+				s.WithHealth(tt.checks, tt.runs, tt.maxRunAge)
+			} else {
+				s.WithHealth(tt.checks, nil, tt.maxRunAge)
+			}
+
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var body struct {
+				Checks []checkResult `json:"checks"`
+				Jobs   []jobResult   `json:"jobs"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+			if len(body.Checks) != len(tt.checks) {
+				t.Errorf("checks len = %d, want %d", len(body.Checks), len(tt.checks))
+			}
+			if len(body.Jobs) != len(tt.wantJobs) {
+				t.Fatalf("jobs len = %d, want %d", len(body.Jobs), len(tt.wantJobs))
+			}
+			for i, want := range tt.wantJobs {
+				if body.Jobs[i].OK != want {
+					t.Errorf("job %s ok = %v, want %v", body.Jobs[i].Job, body.Jobs[i].OK, want)
+				}
+			}
+		})
+	}
+}
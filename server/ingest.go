@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/journalist"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ingestMaxBody  = 1 << 20 // max size of the ingest request body
+	ingestMaxItems = 100     // max number of the news items in one ingest request
+)
+
+// webhookLookup returns the push-based news provider by its name.
+type webhookLookup func(name string) (*journalist.WebhookProvider, bool)
+
+// leaderElector reports whether the replica is the leader running the jobs (see leader.LeaseElector).
+type leaderElector interface {
+	IsLeader(ctx context.Context) error
+}
+
+var (
+	errIngestUnauthorized = errors.New("invalid token")
+	errIngestBody         = errors.New("body must be a news item or an array of the news items")
+	errIngestTooMany      = fmt.Errorf("no more than %d news items per request", ingestMaxItems)
+	errIngestNotLeader    = errors.New("replica doesn't run the jobs, retry the request")
+)
+
+// WithLeader makes the ingest endpoint accept the pushed news only on the leader replica, the others respond
+// with 503, because the news are queued in memory of the replica and processed by its jobs.
+func (s *Server) WithLeader(e leaderElector) *Server {
+	s.leader = e
+	return s
+}
+
+// handleIngest accepts the news items pushed by the external systems to the webhook provider of the jobs config
+// and queues them for the next run of its job. The token is passed in the query (?token=) or as the Bearer token.
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.webhooks(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !provider.Authorize(ingestToken(r)) {
+		writeError(w, http.StatusUnauthorized, errIngestUnauthorized)
+		return
+	}
+	if s.leader != nil && s.leader.IsLeader(r.Context()) != nil {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, errIngestNotLeader)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ingestMaxBody))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("body is larger than %d bytes", ingestMaxBody))
+		return
+	}
+
+	items, err := decodeIngestItems(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(items) > ingestMaxItems {
+		writeError(w, http.StatusBadRequest, errIngestTooMany)
+		return
+	}
+
+	accepted, err := provider.Push(items)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.logger.Info("[server] News pushed to the webhook", "provider", provider.Name, "accepted", accepted)
+	writeJSON(w, http.StatusAccepted, map[string]int{"accepted": accepted})
+}
+
+// ingestToken returns the token of the ingest request from the query or the Authorization header.
+func ingestToken(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// decodeIngestItems decodes the single news item or the array of them.
+func decodeIngestItems(body []byte) ([]journalist.WebhookItem, error) {
+	body = bytes.TrimSpace(body)
+
+	var items []journalist.WebhookItem
+	if bytes.HasPrefix(body, []byte("[")) {
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, errIngestBody
+		}
+		return items, nil
+	}
+
+	var item journalist.WebhookItem
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, errIngestBody
+	}
+	return append(items, item), nil
+}
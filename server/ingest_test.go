@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLeader is the leaderElector of the leader replica if the error is nil.
+type fakeLeader struct{ err error }
+
+func (f fakeLeader) IsLeader(context.Context) error { return f.err }
+
+func TestServer_handleIngest(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		auth       string
+		body       string
+		leader     leaderElector
+		wantStatus int
+		wantNews   int
+	}{
+		{
+			name:       "single item",
+			path:       "/ingest/zapier?token=secret",
+			body:       `{"title": "Fed cuts rates", "link": "https://example.com/1"}`,
+			wantStatus: http.StatusAccepted,
+			wantNews:   1,
+		},
+		{
+			name:       "array with the bearer token",
+			path:       "/ingest/zapier",
+			auth:       "Bearer secret",
+			body:       `[{"title": "First", "link": "https://example.com/1"}, {"title": "Second", "link": "https://example.com/2"}]`,
+			wantStatus: http.StatusAccepted,
+			wantNews:   2,
+		},
+		{
+			name:       "leader replica",
+			path:       "/ingest/zapier?token=secret",
+			body:       `{"title": "Fed cuts rates", "link": "https://example.com/1"}`,
+			leader:     fakeLeader{},
+			wantStatus: http.StatusAccepted,
+			wantNews:   1,
+		},
+		{
+			name:       "follower replica",
+			path:       "/ingest/zapier?token=secret",
+			body:       `{"title": "Fed cuts rates", "link": "https://example.com/1"}`,
+			leader:     fakeLeader{err: errors.New("not the leader")},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "unknown provider",
+			path:       "/ingest/unknown?token=secret",
+			body:       `{"title": "Fed cuts rates", "link": "https://example.com/1"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid token",
+			path:       "/ingest/zapier?token=guess",
+			body:       `{"title": "Fed cuts rates", "link": "https://example.com/1"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid body",
+			path:       "/ingest/zapier?token=secret",
+			body:       `title=Fed`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid item",
+			path:       "/ingest/zapier?token=secret",
+			body:       `{"title": "Fed cuts rates"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := journalist.NewWebhookProvider("zapier", "secret")
+			s := &Server{
+				webhooks: func(name string) (*journalist.WebhookProvider, bool) {
+					return provider, name == provider.Name
+				},
+				leader: tt.leader,
+				logger: slog.Default(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			news, _ := provider.Fetch(context.Background(), time.Time{})
+			if len(news) != tt.wantNews {
+				t.Errorf("queued news = %d, want %d", len(news), tt.wantNews)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	podcastTitle        = "fin-thread daily market digest"
+	podcastDescription  = "Daily audio digest of the most important financial news and economic events."
+	podcastFeedEpisodes = 30
+)
+
+// podcastStore is the storage of the daily audio digest episodes.
+type podcastStore interface {
+	FindLatest(ctx context.Context, limit int) ([]*archivist.PodcastEpisode, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*archivist.PodcastEpisode, error)
+}
+
+var errFindPodcastEpisodes = errors.New("failed to find podcast episodes")
+
+// WithPodcast enables the podcast RSS feed of the daily audio digests.
+// The baseURL is the public URL of the server used for the episode links (e.g. "https://example.com").
+func (s *Server) WithPodcast(baseURL string) *Server {
+	s.podcastURL = strings.TrimSuffix(baseURL, "/")
+	return s
+}
+
+// handlePodcastFeed renders the RSS feed with the latest episodes.
+func (s *Server) handlePodcastFeed(w http.ResponseWriter, r *http.Request) {
+	if s.podcastURL == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	episodes, err := s.podcasts.FindLatest(r.Context(), podcastFeedEpisodes)
+	if err != nil {
+		s.logger.Error("[server] Error finding podcast episodes", "error", err)
+		writeError(w, http.StatusInternalServerError, errFindPodcastEpisodes)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	_ = enc.Encode(buildPodcastFeed(s.podcastURL, episodes))
+}
+
+// handlePodcastAudio serves the MP3 audio of the episode by "<id>.mp3" file name.
+func (s *Server) handlePodcastAudio(w http.ResponseWriter, r *http.Request) {
+	if s.podcastURL == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	file := r.PathValue("file")
+	id, err := uuid.Parse(strings.TrimSuffix(file, ".mp3"))
+	if err != nil || !strings.HasSuffix(file, ".mp3") {
+		http.NotFound(w, r)
+		return
+	}
+
+	episode, err := s.podcasts.FindByID(r.Context(), id)
+	if err != nil {
+		s.logger.Error("[server] Error finding podcast episode", "error", err)
+		writeError(w, http.StatusInternalServerError, errFindPodcastEpisodes)
+		return
+	}
+	if episode == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Header().Set("Content-Length", strconv.Itoa(len(episode.Audio)))
+	_, _ = w.Write(episode.Audio)
+}
+
+// podcastFeed is the RSS 2.0 podcast feed with iTunes tags.
+type podcastFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	ITunes  string   `xml:"xmlns:itunes,attr"`
+	Channel struct {
+		Title       string            `xml:"title"`
+		Link        string            `xml:"link"`
+		Description string            `xml:"description"`
+		Language    string            `xml:"language"`
+		Author      string            `xml:"itunes:author"`
+		Explicit    string            `xml:"itunes:explicit"`
+		Items       []podcastFeedItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type podcastFeedItem struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Enclosure   struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+// buildPodcastFeed creates the feed from the episodes, audio is linked to the server by baseURL.
+func buildPodcastFeed(baseURL string, episodes []*archivist.PodcastEpisode) *podcastFeed {
+	feed := &podcastFeed{Version: "2.0", ITunes: "http://www.itunes.com/dtds/podcast-1.0.dtd"}
+	feed.Channel.Title = podcastTitle
+	feed.Channel.Link = baseURL + "/podcast.xml"
+	feed.Channel.Description = podcastDescription
+	feed.Channel.Language = "en"
+	feed.Channel.Author = "fin-thread"
+	feed.Channel.Explicit = "false"
+
+	for _, e := range episodes {
+		item := podcastFeedItem{
+			Title:       e.Title,
+			Description: e.Script,
+			GUID:        e.ID.String(),
+			PubDate:     e.PublishedAt.UTC().Format(time.RFC1123Z),
+		}
+		item.Enclosure.URL = baseURL + "/podcast/" + e.ID.String() + ".mp3"
+		item.Enclosure.Length = e.AudioSize
+		item.Enclosure.Type = "audio/mpeg"
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return feed
+}
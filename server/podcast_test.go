@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakePodcasts struct {
+	episodes []*archivist.PodcastEpisode
+	err      error
+}
+
+func (f *fakePodcasts) FindLatest(_ context.Context, _ int) ([]*archivist.PodcastEpisode, error) {
+	return f.episodes, f.err
+}
+
+func (f *fakePodcasts) FindByID(_ context.Context, id uuid.UUID) (*archivist.PodcastEpisode, error) {
+	for _, e := range f.episodes {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, f.err
+}
+
+func TestServer_handlePodcast(t *testing.T) {
+	episode := &archivist.PodcastEpisode{
+		ID:          uuid.MustParse("5f0c6f5e-8d6a-4c1e-9a39-6f1b2a3c4d5e"),
+		Title:       "Market digest Mar 8, 2024",
+		Script:      "Good evening & welcome.",
+		Audio:       []byte("mp3"),
+		AudioSize:   3,
+		PublishedAt: time.Date(2024, 3, 8, 21, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name       string
+		baseURL    string
+		path       string
+		store      *fakePodcasts
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "feed",
+			baseURL:    "https://example.com/",
+			path:       "/podcast.xml",
+			store:      &fakePodcasts{episodes: []*archivist.PodcastEpisode{episode}},
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">`,
+				`<title>Market digest Mar 8, 2024</title>`,
+				`<description>Good evening &amp; welcome.</description>`,
+				`<pubDate>Fri, 08 Mar 2024 21:30:00 +0000</pubDate>`,
+				`<enclosure url="https://example.com/podcast/5f0c6f5e-8d6a-4c1e-9a39-6f1b2a3c4d5e.mp3" length="3" type="audio/mpeg">`,
+			},
+		},
+		{
+			name:       "feed storage error",
+			baseURL:    "https://example.com",
+			path:       "/podcast.xml",
+			store:      &fakePodcasts{err: errors.New("db is down")},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "feed disabled",
+			path:       "/podcast.xml",
+			store:      &fakePodcasts{},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "audio",
+			baseURL:    "https://example.com",
+			path:       "/podcast/5f0c6f5e-8d6a-4c1e-9a39-6f1b2a3c4d5e.mp3",
+			store:      &fakePodcasts{episodes: []*archivist.PodcastEpisode{episode}},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"mp3"},
+		},
+		{
+			name:       "audio not found",
+			baseURL:    "https://example.com",
+			path:       "/podcast/" + uuid.NewString() + ".mp3",
+			store:      &fakePodcasts{episodes: []*archivist.PodcastEpisode{episode}},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid file name",
+			baseURL:    "https://example.com",
+			path:       "/podcast/5f0c6f5e-8d6a-4c1e-9a39-6f1b2a3c4d5e",
+			store:      &fakePodcasts{episodes: []*archivist.PodcastEpisode{episode}},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := (&Server{podcasts: tt.store, logger: slog.Default()}).WithPodcast(tt.baseURL)
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			body := rec.Body.String()
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body = %s, want to contain %s", body, want)
+				}
+			}
+		})
+	}
+}
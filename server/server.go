@@ -0,0 +1,160 @@
+// Package server provides the HTTP API of the app: stats and status endpoints for the operators,
+// the health probes for the container orchestrators and the ingest endpoint for the pushed news.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/logging"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// providerStatsStore is the storage of the providers quality stats.
+type providerStatsStore interface {
+	FindQualitySince(ctx context.Context, since time.Time) ([]*archivist.ProviderQuality, error)
+}
+
+var (
+	errFindProviderStats  = errors.New("failed to find provider stats")
+	errFindProviderHealth = errors.New("failed to find provider health")
+)
+
+// Server is the HTTP server that exposes the app API.
+type Server struct {
+	providerStats  providerStatsStore
+	providerHealth providerHealthStore
+	podcasts       podcastStore
+	published      publishedNewsStore
+	webhooks       webhookLookup   // push-based news providers of the ingest endpoint
+	leader         leaderElector   // the ingest endpoint accepts the news only on the leader replica if set
+	podcastURL     string          // public URL of the server for the podcast feed, the feed is disabled if empty
+	feed           *publisher.Feed // builder of the news feeds, the feeds are disabled if nil
+	feedLimit      int             // number of the latest news in the feeds
+	health         health
+	metricsHandler http.Handler // Prometheus metrics handler, /metrics is disabled if nil
+	httpServer     *http.Server
+	logger         *slog.Logger
+}
+
+// NewServer creates a new Server listening on the given address (e.g. ":8080").
+func NewServer(addr string, arch *archivist.Archivist) *Server {
+	s := &Server{
+		providerStats:  arch.Entities.ProviderStats,
+		providerHealth: arch.Entities.ProviderHealth,
+		podcasts:       arch.Entities.Podcasts,
+		published:      arch.Entities.News,
+		webhooks:       journalist.Webhook,
+		health:         health{startedAt: time.Now()},
+		logger:         logging.For(logging.ModuleServer),
+	}
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return s
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/stats/providers", s.handleProviderStats)
+	mux.HandleFunc("GET /api/status/providers", s.handleProviderStatus)
+	mux.HandleFunc("GET /status", s.handleStatusPage)
+	mux.HandleFunc("GET /podcast.xml", s.handlePodcastFeed)
+	mux.HandleFunc("GET /podcast/{file}", s.handlePodcastAudio)
+	mux.HandleFunc("GET /"+publisher.RSSFeedFile, s.handleRSSFeed)
+	mux.HandleFunc("GET /"+publisher.AtomFeedFile, s.handleAtomFeed)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("POST /ingest/{provider}", s.handleIngest)
+	return mux
+}
+
+// WithMetrics exposes the metrics handler (e.g. metrics.Prometheus.Handler) on /metrics.
+func (s *Server) WithMetrics(h http.Handler) *Server {
+	s.metricsHandler = h
+	return s
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metricsHandler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.metricsHandler.ServeHTTP(w, r)
+}
+
+// Start starts the server in the background.
+func (s *Server) Start() {
+	go func() {
+		s.logger.Info("[server] Starting HTTP server", "addr", s.httpServer.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("[server] HTTP server stopped", "error", err)
+		}
+	}()
+}
+
+// Shutdown gracefully stops the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx) //nolint:wrapcheck
+}
+
+// handleProviderStats returns the quality stats of the providers for the last `days` days (7 by default).
+func (s *Server) handleProviderStats(w http.ResponseWriter, r *http.Request) {
+	days, err := queryInt(r, "days", 7)
+	if err != nil || days < 1 || days > 365 {
+		writeError(w, http.StatusBadRequest, errors.New("days must be an integer from 1 to 365"))
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days+1)
+	stats, err := s.providerStats.FindQualitySince(r.Context(), since)
+	if err != nil {
+		s.logger.Error("[server] Error finding provider stats", "error", err)
+		writeError(w, http.StatusInternalServerError, errFindProviderStats)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"since":     since.Truncate(24 * time.Hour),
+		"providers": stats,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func formatPercent(f float64) string {
+	return strconv.FormatFloat(f*100, 'f', 1, 64) + "%"
+}
+
+// queryInt parses the integer query parameter, returns def if it is not set.
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return i, nil
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeProviderStats struct {
+	stats []*archivist.ProviderQuality
+	err   error
+	since time.Time
+}
+
+func (f *fakeProviderStats) FindQualitySince(_ context.Context, since time.Time) ([]*archivist.ProviderQuality, error) {
+	f.since = since
+	return f.stats, f.err
+}
+
+func TestServer_handleProviderStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		store      *fakeProviderStats
+		wantStatus int
+		wantLen    int
+		wantDays   int
+	}{
+		{
+			name:       "default period",
+			store:      &fakeProviderStats{stats: []*archivist.ProviderQuality{{ProviderName: "reuters", Fetched: 10}}},
+			wantStatus: http.StatusOK,
+			wantLen:    1,
+			wantDays:   7,
+		},
+		{
+			name:       "custom period",
+			query:      "?days=30",
+			store:      &fakeProviderStats{},
+			wantStatus: http.StatusOK,
+			wantLen:    0,
+			wantDays:   30,
+		},
+		{
+			name:       "invalid period",
+			query:      "?days=abc",
+			store:      &fakeProviderStats{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "storage error",
+			store:      &fakeProviderStats{err: errors.New("db is down")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{providerStats: tt.store, logger: slog.Default()}
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats/providers"+tt.query, http.NoBody))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Providers []*archivist.ProviderQuality `json:"providers"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+			if len(body.Providers) != tt.wantLen {
+				t.Errorf("providers len = %d, want %d", len(body.Providers), tt.wantLen)
+			}
+			days := int(time.Since(tt.store.since).Hours()/24) + 1
+			if days != tt.wantDays {
+				t.Errorf("period = %d days, want %d", days, tt.wantDays)
+			}
+		})
+	}
+}
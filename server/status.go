@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/archivist"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// providerHealthStore is the storage of the providers hourly health counters.
+type providerHealthStore interface {
+	FindSince(ctx context.Context, since time.Time) ([]*archivist.ProviderHealth, error)
+}
+
+// Provider statuses, from the worst to the best.
+const (
+	statusDown     = "down"     // most of the fetches in the last hour failed
+	statusDegraded = "degraded" // some fetches in the last hour failed or latency has grown
+	statusStale    = "stale"    // no fetches in the last hour
+	statusOK       = "ok"
+)
+
+var statusOrder = map[string]int{statusDown: 0, statusDegraded: 1, statusStale: 2, statusOK: 3}
+
+// providerStatus is the latency and reliability summary of the provider.
+type providerStatus struct {
+	Provider        string           `json:"provider"`
+	Status          string           `json:"status"`
+	Uptime1h        float64          `json:"uptime_1h"`
+	Uptime24h       float64          `json:"uptime_24h"`
+	AvgLatencyMs1h  int64            `json:"avg_latency_ms_1h"`
+	AvgLatencyMs24h int64            `json:"avg_latency_ms_24h"`
+	MaxLatencyMs24h int64            `json:"max_latency_ms_24h"`
+	Statuses24h     map[string]int64 `json:"statuses_24h"` // HTTP status classes distribution
+	LastFetchHour   time.Time        `json:"last_fetch_hour"`
+}
+
+// buildProviderStatuses summarises hourly health rows of the last 24 hours, the worst providers go first.
+func buildProviderStatuses(rows []*archivist.ProviderHealth, now time.Time) []*providerStatus {
+	type totals struct{ fetches, failures, latency int64 }
+	lastHour := now.UTC().Truncate(time.Hour).Add(-time.Hour)
+
+	byProvider := make(map[string]*providerStatus)
+	day := make(map[string]*totals)
+	hour := make(map[string]*totals)
+	for _, r := range rows {
+		s, ok := byProvider[r.ProviderName]
+		if !ok {
+			s = &providerStatus{Provider: r.ProviderName, Statuses24h: make(map[string]int64)}
+			byProvider[r.ProviderName] = s
+			day[r.ProviderName] = &totals{}
+			hour[r.ProviderName] = &totals{}
+		}
+
+		d := day[r.ProviderName]
+		d.fetches += r.Fetches
+		d.failures += r.Failures
+		d.latency += r.LatencySumMs
+		if !r.Hour.Before(lastHour) {
+			h := hour[r.ProviderName]
+			h.fetches += r.Fetches
+			h.failures += r.Failures
+			h.latency += r.LatencySumMs
+		}
+
+		s.MaxLatencyMs24h = max(s.MaxLatencyMs24h, r.LatencyMaxMs)
+		s.Statuses24h["2xx"] += r.Status2xx
+		s.Statuses24h["3xx"] += r.Status3xx
+		s.Statuses24h["4xx"] += r.Status4xx
+		s.Statuses24h["5xx"] += r.Status5xx
+		s.Statuses24h["network"] += r.StatusNetwork
+		if r.Fetches > 0 && r.Hour.After(s.LastFetchHour) {
+			s.LastFetchHour = r.Hour
+		}
+	}
+
+	result := make([]*providerStatus, 0, len(byProvider))
+	for name, s := range byProvider {
+		d, h := day[name], hour[name]
+		s.Uptime24h = uptime(d.fetches, d.failures)
+		s.Uptime1h = uptime(h.fetches, h.failures)
+		if d.fetches > 0 {
+			s.AvgLatencyMs24h = d.latency / d.fetches
+		}
+		if h.fetches > 0 {
+			s.AvgLatencyMs1h = h.latency / h.fetches
+		}
+
+		switch {
+		case h.fetches == 0:
+			s.Status = statusStale
+		case s.Uptime1h < 0.5:
+			s.Status = statusDown
+		case s.Uptime1h < 0.95,
+			s.AvgLatencyMs1h > 1000 && s.AvgLatencyMs1h > 2*s.AvgLatencyMs24h:
+			s.Status = statusDegraded
+		default:
+			s.Status = statusOK
+		}
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if statusOrder[result[i].Status] != statusOrder[result[j].Status] {
+			return statusOrder[result[i].Status] < statusOrder[result[j].Status]
+		}
+		return result[i].Provider < result[j].Provider
+	})
+
+	return result
+}
+
+func uptime(fetches, failures int64) float64 {
+	if fetches == 0 {
+		return 0
+	}
+	return float64(fetches-failures) / float64(fetches)
+}
+
+// findProviderStatuses returns statuses of the providers for the last 24 hours.
+func (s *Server) findProviderStatuses(ctx context.Context) ([]*providerStatus, error) {
+	now := time.Now()
+	rows, err := s.providerHealth.FindSince(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return buildProviderStatuses(rows, now), nil
+}
+
+// handleProviderStatus returns statuses of the providers in JSON.
+func (s *Server) handleProviderStatus(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.findProviderStatuses(r.Context())
+	if err != nil {
+		s.logger.Error("[server] Error finding provider health", "error", err)
+		writeError(w, http.StatusInternalServerError, errFindProviderHealth)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"providers": statuses})
+}
+
+// handleStatusPage renders the status page with the providers health.
+func (s *Server) handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.findProviderStatuses(r.Context())
+	if err != nil {
+		s.logger.Error("[server] Error finding provider health", "error", err)
+		http.Error(w, errFindProviderHealth.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPage.Execute(w, statuses); err != nil {
+		s.logger.Error("[server] Error rendering status page", "error", err)
+	}
+}
+
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"percent": formatPercent,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>fin-thread status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 12px; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.down { color: #c00; font-weight: bold; }
+.degraded { color: #d80; font-weight: bold; }
+.stale { color: #888; }
+.ok { color: #080; }
+</style>
+</head>
+<body>
+<h1>Providers status (last 24h)</h1>
+<table>
+<tr><th>Provider</th><th>Status</th><th>Uptime 1h</th><th>Uptime 24h</th><th>Avg latency 1h</th><th>Avg latency 24h</th><th>Max latency 24h</th><th>2xx / 3xx / 4xx / 5xx / network</th></tr>
+{{range .}}<tr>
+<td>{{.Provider}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{percent .Uptime1h}}</td>
+<td>{{percent .Uptime24h}}</td>
+<td>{{.AvgLatencyMs1h}} ms</td>
+<td>{{.AvgLatencyMs24h}} ms</td>
+<td>{{.MaxLatencyMs24h}} ms</td>
+<td>{{index .Statuses24h "2xx"}} / {{index .Statuses24h "3xx"}} / {{index .Statuses24h "4xx"}} / {{index .Statuses24h "5xx"}} / {{index .Statuses24h "network"}}</td>
+</tr>{{else}}<tr><td colspan="8">No data yet</td></tr>{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/archivist"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeProviderHealth struct {
+	rows []*archivist.ProviderHealth
+}
+
+func (f *fakeProviderHealth) FindSince(_ context.Context, _ time.Time) ([]*archivist.ProviderHealth, error) {
+	return f.rows, nil
+}
+
+func Test_buildProviderStatuses(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 30, 0, 0, time.UTC)
+	hour := now.Truncate(time.Hour)
+
+	rows := []*archivist.ProviderHealth{
+		// healthy
+		{ProviderName: "cnbc", Hour: hour.Add(-3 * time.Hour), Fetches: 60, LatencySumMs: 6000, Status2xx: 60},
+		{ProviderName: "cnbc", Hour: hour, Fetches: 30, LatencySumMs: 3000, Status2xx: 30},
+		// failing in the last hour
+		{ProviderName: "reuters", Hour: hour.Add(-3 * time.Hour), Fetches: 60, LatencySumMs: 6000, Status2xx: 60},
+		{ProviderName: "reuters", Hour: hour, Fetches: 30, Failures: 20, LatencySumMs: 3000, Status2xx: 10, Status5xx: 20},
+		// slow in the last hour
+		{ProviderName: "wsj", Hour: hour.Add(-5 * time.Hour), Fetches: 100, LatencySumMs: 50000, Status2xx: 100},
+		{ProviderName: "wsj", Hour: hour.Add(-time.Hour), Fetches: 10, LatencySumMs: 40000, LatencyMaxMs: 4500, Status2xx: 10},
+		// not fetched recently
+		{ProviderName: "bloomberg", Hour: hour.Add(-10 * time.Hour), Fetches: 10, LatencySumMs: 1000, Status2xx: 10},
+	}
+
+	got := buildProviderStatuses(rows, now)
+
+	want := []struct {
+		provider string
+		status   string
+	}{
+		{"reuters", statusDown},
+		{"wsj", statusDegraded},
+		{"bloomberg", statusStale},
+		{"cnbc", statusOK},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("buildProviderStatuses() returned %d statuses, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Provider != w.provider || got[i].Status != w.status {
+			t.Errorf("status #%d = %s/%s, want %s/%s", i, got[i].Provider, got[i].Status, w.provider, w.status)
+		}
+	}
+
+	reuters := got[0]
+	if reuters.Statuses24h["5xx"] != 20 || reuters.Statuses24h["2xx"] != 70 {
+		t.Errorf("reuters statuses = %v", reuters.Statuses24h)
+	}
+	if reuters.AvgLatencyMs24h != 100 {
+		t.Errorf("reuters avg latency = %d, want 100", reuters.AvgLatencyMs24h)
+	}
+}
+
+func TestServer_handleStatusPage(t *testing.T) {
+	s := &Server{
+		providerHealth: &fakeProviderHealth{rows: []*archivist.ProviderHealth{
+			{ProviderName: "<script>", Hour: time.Now().UTC().Truncate(time.Hour), Fetches: 1, Status2xx: 1},
+		}},
+		logger: slog.Default(),
+	}
+
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "&lt;script&gt;") || strings.Contains(body, "<script>") {
+		t.Error("provider name is not escaped")
+	}
+	if !strings.Contains(body, "100.0%") {
+		t.Error("uptime is not rendered")
+	}
+}
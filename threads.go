@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/samgozman/fin-thread/composer"
+	"os"
+)
+
+// threadDefinition is the independent news pipeline of its own channel (see threads in JOBS_CONFIG):
+// the bot, composer settings and news jobs of the channel. News and dead letters of the thread are scoped
+// by its chat ID, so the thread doesn't recover or retry the publications of the other ones.
+type threadDefinition struct {
+	Name      string `yaml:"name" validate:"required,max=32,excludesall=/"`
+	ChannelID string `yaml:"channel_id" validate:"required,max=64"` // chat ID or username of the thread channel
+	// Name of the env with the bot token of the channel, TELEGRAM_BOT_TOKEN if empty
+	BotTokenEnv string `yaml:"bot_token_env" validate:"max=64"`
+	// LLM provider (openai or anthropic) and its model, COMPOSER_PROVIDER and its model if empty
+	ComposerProvider string `yaml:"composer_provider" validate:"omitempty,oneof=openai anthropic"`
+	Model            string `yaml:"model" validate:"max=64"`
+	// Style and length limits of the composed text (COMPOSE_STYLE, COMPOSE_MAX_SENTENCES and COMPOSE_MAX_CHARS if empty)
+	Style        string          `yaml:"style" validate:"omitempty,oneof=concise analytical casual"`
+	MaxSentences int             `yaml:"max_sentences" validate:"gte=0"`
+	MaxChars     int             `yaml:"max_chars" validate:"gte=0,lte=4096"`
+	Jobs         []jobDefinition `yaml:"jobs" validate:"required,min=1,dive"`
+	// Publishing window, quiet hours, timezone and roundup header of the channel (PUBLISH_WINDOW, QUIET_HOURS,
+	// PUBLISH_TIMEZONE and ROUNDUP_HEADER if empty)
+	PublishWindow string `yaml:"publish_window"`
+	QuietHours    string `yaml:"quiet_hours"`
+	Timezone      string `yaml:"timezone"`
+	RoundupHeader string `yaml:"roundup_header"`
+}
+
+// config returns the configuration of the thread pipeline: the app configuration with the channel, bot, composer
+// and jobs of the thread. Named channels, ticker subscriptions and the channel rules belong to the default pipeline.
+func (t *threadDefinition) config(c *Config) *Config {
+	env := *c.env
+	env.TelegramChannelID = t.ChannelID
+	env.TelegramChannels = ""
+	env.TickerChannels = ""
+	if t.BotTokenEnv != "" {
+		env.TelegramBotToken = os.Getenv(t.BotTokenEnv)
+	}
+	if t.ComposerProvider != "" {
+		env.ComposerProvider = t.ComposerProvider
+	}
+	if t.Model != "" {
+		if env.ComposerProvider == composer.ProviderAnthropic {
+			env.AnthropicModel = t.Model
+		} else {
+			env.OpenAiModel = t.Model
+		}
+	}
+	if t.Style != "" {
+		env.ComposeStyle = t.Style
+	}
+	if t.MaxSentences > 0 {
+		env.ComposeMaxSentences = t.MaxSentences
+	}
+	if t.MaxChars > 0 {
+		env.ComposeMaxChars = t.MaxChars
+	}
+	if t.PublishWindow != "" {
+		env.PublishWindow = t.PublishWindow
+	}
+	if t.QuietHours != "" {
+		env.QuietHours = t.QuietHours
+	}
+	if t.Timezone != "" {
+		env.PublishTimezone = t.Timezone
+	}
+	if t.RoundupHeader != "" {
+		env.RoundupHeader = t.RoundupHeader
+	}
+
+	thread := *c
+	thread.env = &env
+	thread.channels = nil
+	thread.tickerChannels = nil
+	thread.jobs = t.Jobs
+	thread.threads = nil
+	thread.rules = nil
+	for _, r := range c.rules {
+		if r.Channel == "" {
+			thread.rules = append(thread.rules, r)
+		}
+	}
+
+	return &thread
+}
+
+// threadBotTokens returns the bot tokens of the threads from their bot_token_env envs, so they are scrubbed
+// from the Sentry events like TELEGRAM_BOT_TOKEN.
+func (c *Config) threadBotTokens() []string {
+	var tokens []string
+	for _, t := range c.threads {
+		if t.BotTokenEnv != "" {
+			tokens = append(tokens, os.Getenv(t.BotTokenEnv))
+		}
+	}
+	return tokens
+}
+
+// prefixJobs prefixes the names of the thread jobs with the thread name ("thread/job"), so the jobs of all
+// pipelines have the unique names in the scheduler, admin commands and the database.
+func (t *threadDefinition) prefixJobs() {
+	for i := range t.Jobs {
+		t.Jobs[i].Name = t.Name + "/" + t.Jobs[i].Name
+	}
+}
+
+// validateThreads validates the thread definitions with their jobs. Thread jobs publish only to the thread channel,
+// so their news stay in the scope of the thread. Job names must be prefixed (see prefixJobs).
+func validateThreads(threads []threadDefinition, defaultJobs []jobDefinition) error {
+	jobNames := make(map[string]bool, len(defaultJobs))
+	for _, d := range defaultJobs {
+		jobNames[d.Name] = true
+	}
+
+	names := make(map[string]bool, len(threads))
+	chatIDs := make(map[string]bool, len(threads))
+	for _, t := range threads {
+		if err := validator.New().Struct(t); err != nil {
+			return fmt.Errorf("thread %s: %w", t.Name, err)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("thread %s: duplicate name", t.Name)
+		}
+		names[t.Name] = true
+		if chatIDs[t.ChannelID] {
+			return fmt.Errorf("thread %s: channel_id is used by another thread", t.Name)
+		}
+		chatIDs[t.ChannelID] = true
+
+		for _, d := range t.Jobs {
+			if jobNames[d.Name] {
+				return fmt.Errorf("thread %s: job %s: duplicate name", t.Name, d.Name)
+			}
+			jobNames[d.Name] = true
+			if d.Channel != "" || len(d.Translations) > 0 {
+				return fmt.Errorf("thread %s: job %s: thread jobs publish only to the thread channel, "+
+					"channel and translations are not supported", t.Name, d.Name)
+			}
+		}
+		if err := validateJobs(t.Jobs); err != nil {
+			return fmt.Errorf("thread %s: %w", t.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// threadChatIDs returns the chat IDs of the thread channels.
+func (c *Config) threadChatIDs() []string {
+	chatIDs := make([]string, len(c.threads))
+	for i, t := range c.threads {
+		chatIDs[i] = t.ChannelID
+	}
+	return chatIDs
+}
+
+// pipelines returns the configurations of all news pipelines: the default one first, then the threads.
+func (c *Config) pipelines() []*Config {
+	result := []*Config{c}
+	for i := range c.threads {
+		result = append(result, c.threads[i].config(c))
+	}
+	return result
+}